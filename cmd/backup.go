@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/backup"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var backupOpt struct {
+	output     string
+	recipients []string
+	plain      bool
+}
+
+// BackupCmd writes a backup of the database, encrypted to the configured recipients
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backup the database, encrypted to the configured age or pgp recipients",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		text := setting.GetStr(conf.BackupRecipients)
+		for _, r := range backupOpt.recipients {
+			// a recipient is either an age public key or a file of armored pgp public keys
+			if data, err := os.ReadFile(r); err == nil {
+				r = string(data)
+			}
+			text += "\n" + r
+		}
+		recipients, err := backup.ParseRecipients(text)
+		if err != nil {
+			return err
+		}
+		if recipients.Empty() && !backupOpt.plain {
+			return fmt.Errorf("no backup recipients configured, add them with --recipient or pass --plain to write an unencrypted backup")
+		}
+		output := backupOpt.output
+		if output == "" {
+			output = fmt.Sprintf("openlist-backup-%s.tar.gz%s", time.Now().Format("20060102-150405"), recipients.Ext())
+		}
+		f, err := os.OpenFile(output, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		var w io.WriteCloser = f
+		if !recipients.Empty() {
+			if w, err = recipients.Encrypt(f); err != nil {
+				return err
+			}
+		}
+		if err = backup.Write(w); err != nil {
+			return err
+		}
+		if w != f {
+			if err = w.Close(); err != nil {
+				return err
+			}
+		}
+		utils.Log.Infof("backup written to %s", output)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(BackupCmd)
+	BackupCmd.Flags().StringVarP(&backupOpt.output, "output", "o", "", "file to write the backup to, defaults to openlist-backup-<time>.tar.gz in the current dir")
+	BackupCmd.Flags().StringArrayVarP(&backupOpt.recipients, "recipient", "r", nil, "age public key or file of armored pgp public keys to encrypt to, in addition to the backup_recipients setting")
+	BackupCmd.Flags().BoolVar(&backupOpt.plain, "plain", false, "write the backup unencrypted when no recipients are configured")
+}
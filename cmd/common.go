@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strconv"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/data"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
@@ -18,11 +19,15 @@ func Init() {
 	bootstrap.InitDB()
 	data.InitData()
 	bootstrap.InitStreamLimit()
+	bootstrap.InitNotify()
+	bootstrap.InitAudit()
 	bootstrap.InitIndex()
 	bootstrap.InitUpgradePatch()
 }
 
 func Release() {
+	bootstrap.StopCertificateJobs()
+	audit.Close()
 	db.Close()
 }
 
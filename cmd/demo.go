@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/spf13/cobra"
+)
+
+// DemoCmd manages the certificate demo data used by evaluation instances and UI development
+var DemoCmd = &cobra.Command{
+	Use:   "demo",
+	Short: "Seed or wipe certificate demo data",
+}
+
+var demoSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Populate demo certificates in every status and pending requests",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDemo(true)
+	},
+}
+
+var demoWipeCmd = &cobra.Command{
+	Use:   "wipe",
+	Short: "Delete the seeded demo data, real certificates are kept",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDemo(false)
+	},
+}
+
+func runDemo(seed bool) error {
+	Init()
+	defer Release()
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return fmt.Errorf("failed to get admin user: %+v", err)
+	}
+	seedOrWipe, done := op.WipeCertificateDemoData, "wiped"
+	if seed {
+		seedOrWipe, done = op.SeedCertificateDemoData, "seeded"
+	}
+	summary, err := seedOrWipe(admin)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Demo data %s: %d certificates, %d requests\n", done, summary.Certificates, summary.Requests)
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(DemoCmd)
+	DemoCmd.AddCommand(demoSeedCmd, demoWipeCmd)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// listenFdsStart is the first file descriptor passed by systemd,
+// see sd_listen_fds(3)
+const listenFdsStart = 3
+
+// systemdListeners returns the sockets passed in by systemd socket activation.
+// It returns nil if the process was not started by a systemd socket unit.
+func systemdListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds <= 0 {
+		return nil, nil
+	}
+	// avoid passing the sockets on to child processes
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+	listeners := make([]net.Listener, 0, nfds)
+	for fd := listenFdsStart; fd < listenFdsStart+nfds; fd++ {
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("LISTEN_FD_%d", fd))
+		listener, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("failed to use systemd socket fd %d: %w", fd, err)
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// listenUnix listens on the unix socket file and applies the configured permission.
+// A stale socket file left by a previous run is removed first.
+func listenUnix(file, perm string) (net.Listener, error) {
+	if utils.Exists(file) {
+		if fi, err := os.Lstat(file); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			_ = os.Remove(file)
+		}
+	}
+	listener, err := net.Listen("unix", file)
+	if err != nil {
+		return nil, err
+	}
+	if perm == "" {
+		return listener, nil
+	}
+	mode, err := strconv.ParseUint(perm, 8, 32)
+	if err != nil {
+		utils.Log.Errorf("failed to parse socket file permission: %+v", err)
+		return listener, nil
+	}
+	if err = os.Chmod(file, os.FileMode(mode)); err != nil {
+		utils.Log.Errorf("failed to chmod socket file: %+v", err)
+	}
+	return listener, nil
+}
+
+// adminTLSConfig builds the tls config of the admin listener.
+// It returns nil if no certificate is configured, and requires client
+// certificates signed by ClientCAFile when it is set (mTLS only).
+func adminTLSConfig() (*tls.Config, error) {
+	adminConf := conf.Conf.Admin
+	if adminConf.CertFile == "" || adminConf.KeyFile == "" {
+		if adminConf.ClientCAFile != "" {
+			return nil, fmt.Errorf("client_ca_file requires cert_file and key_file")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(adminConf.CertFile, adminConf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if adminConf.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(adminConf.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificate found in %s", adminConf.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// httpsTLSConfig builds the tls config of the https listener. The managed certificate
+// activated by an admin is looked up on every handshake so that renewals take effect
+// without a restart; cert_file and key_file are used while no certificate is activated.
+func httpsTLSConfig() (*tls.Config, error) {
+	var fileCert *tls.Certificate
+	if conf.Conf.Scheme.CertFile != "" && conf.Conf.Scheme.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(conf.Conf.Scheme.CertFile, conf.Conf.Scheme.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		fileCert = &cert
+	}
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert := op.ManagedTLSCertificate(); cert != nil {
+				return cert, nil
+			}
+			if fileCert != nil {
+				return fileCert, nil
+			}
+			return nil, fmt.Errorf("no tls certificate, activate a managed certificate or set cert_file and key_file")
+		},
+	}, nil
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/spf13/cobra"
+)
+
+// PrivateKeyCmd manages the private keys of certificates and certificate authorities stored in the database
+var PrivateKeyCmd = &cobra.Command{
+	Use:   "private-key",
+	Short: "Manage private keys stored in the database",
+}
+
+var privateKeyEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt private keys still stored in plaintext with the configured key encryption key",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		certs, cas, err := db.EncryptPrivateKeys()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Encrypted private keys of %d certificates and %d certificate authorities\n", certs, cas)
+		return nil
+	},
+}
+
+var privateKeyRewrapCmd = &cobra.Command{
+	Use:   "rewrap",
+	Short: "Seal encrypted private keys again with the configured key encryption key",
+	Long: `Seal encrypted private keys and secrets again with the configured key encryption key.
+To rotate the master key, move the old key to previous_master_key, set the new master_key and run this command,
+then remove previous_master_key. Values sealed by older versions are bound to their rows as well.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		Init()
+		defer Release()
+		n, err := db.RewrapPrivateKeys()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rewrapped %d private keys and secrets\n", n)
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(PrivateKeyCmd)
+	PrivateKeyCmd.AddCommand(privateKeyEncryptCmd)
+	PrivateKeyCmd.AddCommand(privateKeyRewrapCmd)
+}
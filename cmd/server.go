@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -44,6 +43,7 @@ the address is defined in config file`,
 		bootstrap.InitOfflineDownloadTools()
 		bootstrap.LoadStorages()
 		bootstrap.InitTaskManager()
+		bootstrap.InitCertificateJobs()
 		if !flags.Debug && !flags.Dev {
 			gin.SetMode(gin.ReleaseMode)
 		}
@@ -79,9 +79,13 @@ the address is defined in config file`,
 			httpsBase := fmt.Sprintf("%s:%d", conf.Conf.Scheme.Address, conf.Conf.Scheme.HttpsPort)
 			fmt.Printf("start HTTPS server @ %s\n", httpsBase)
 			utils.Log.Infof("start HTTPS server @ %s", httpsBase)
-			httpsSrv = &http.Server{Addr: httpsBase, Handler: r}
+			tlsConfig, err := httpsTLSConfig()
+			if err != nil {
+				utils.Log.Fatalf("failed to load https tls config: %+v", err)
+			}
+			httpsSrv = &http.Server{Addr: httpsBase, Handler: r, TLSConfig: tlsConfig}
 			go func() {
-				err := httpsSrv.ListenAndServeTLS(conf.Conf.Scheme.CertFile, conf.Conf.Scheme.KeyFile)
+				err := httpsSrv.ListenAndServeTLS("", "")
 				if err != nil && !errors.Is(err, http.ErrServerClosed) {
 					utils.Log.Fatalf("failed to start https: %s", err.Error())
 				}
@@ -92,26 +96,38 @@ the address is defined in config file`,
 			utils.Log.Infof("start unix server @ %s", conf.Conf.Scheme.UnixFile)
 			unixSrv = &http.Server{Handler: httpHandler}
 			go func() {
-				listener, err := net.Listen("unix", conf.Conf.Scheme.UnixFile)
+				listener, err := listenUnix(conf.Conf.Scheme.UnixFile, conf.Conf.Scheme.UnixFilePerm)
 				if err != nil {
 					utils.Log.Fatalf("failed to listen unix: %+v", err)
 				}
-				// set socket file permission
-				mode, err := strconv.ParseUint(conf.Conf.Scheme.UnixFilePerm, 8, 32)
-				if err != nil {
-					utils.Log.Errorf("failed to parse socket file permission: %+v", err)
-				} else {
-					err = os.Chmod(conf.Conf.Scheme.UnixFile, os.FileMode(mode))
-					if err != nil {
-						utils.Log.Errorf("failed to chmod socket file: %+v", err)
-					}
-				}
 				err = unixSrv.Serve(listener)
 				if err != nil && !errors.Is(err, http.ErrServerClosed) {
 					utils.Log.Fatalf("failed to start unix: %s", err.Error())
 				}
 			}()
 		}
+		var systemdSrv *http.Server
+		if conf.Conf.Scheme.SystemdSocket {
+			listeners, err := systemdListeners()
+			if err != nil {
+				utils.Log.Fatalf("failed to get systemd sockets: %+v", err)
+			}
+			if len(listeners) == 0 {
+				utils.Log.Warnf("systemd socket activation is enabled but no socket was passed in")
+			} else {
+				systemdSrv = &http.Server{Handler: httpHandler}
+			}
+			for _, listener := range listeners {
+				fmt.Printf("start systemd socket server @ %s\n", listener.Addr())
+				utils.Log.Infof("start systemd socket server @ %s", listener.Addr())
+				go func(l net.Listener) {
+					err := systemdSrv.Serve(l)
+					if err != nil && !errors.Is(err, http.ErrServerClosed) {
+						utils.Log.Fatalf("failed to start systemd socket server: %s", err.Error())
+					}
+				}(listener)
+			}
+		}
 		if conf.Conf.S3.Port != -1 && conf.Conf.S3.Enable {
 			s3r := gin.New()
 			s3r.Use(gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
@@ -134,6 +150,30 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		var adminSrv *http.Server
+		if conf.Conf.Admin.Enable {
+			adminR := gin.New()
+			adminR.Use(gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
+			server.InitAdmin(adminR)
+			tlsConfig, err := adminTLSConfig()
+			if err != nil {
+				utils.Log.Fatalf("failed to load admin tls config: %+v", err)
+			}
+			fmt.Printf("start admin server @ %s\n", conf.Conf.Admin.Listen)
+			utils.Log.Infof("start admin server @ %s", conf.Conf.Admin.Listen)
+			adminSrv = &http.Server{Addr: conf.Conf.Admin.Listen, Handler: adminR, TLSConfig: tlsConfig}
+			go func() {
+				var err error
+				if tlsConfig != nil {
+					err = adminSrv.ListenAndServeTLS("", "")
+				} else {
+					err = adminSrv.ListenAndServe()
+				}
+				if err != nil && !errors.Is(err, http.ErrServerClosed) {
+					utils.Log.Fatalf("failed to start admin server: %s", err.Error())
+				}
+			}()
+		}
 		var ftpDriver *server.FtpMainDriver
 		var ftpServer *ftpserver.FtpServer
 		if conf.Conf.FTP.Listen != "" && conf.Conf.FTP.Enable {
@@ -213,6 +253,24 @@ the address is defined in config file`,
 				}
 			}()
 		}
+		if adminSrv != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := adminSrv.Shutdown(ctx); err != nil {
+					utils.Log.Fatal("admin server shutdown err: ", err)
+				}
+			}()
+		}
+		if systemdSrv != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := systemdSrv.Shutdown(ctx); err != nil {
+					utils.Log.Fatal("systemd socket server shutdown err: ", err)
+				}
+			}()
+		}
 		if conf.Conf.FTP.Listen != "" && conf.Conf.FTP.Enable && ftpServer != nil && ftpDriver != nil {
 			wg.Add(1)
 			go func() {
@@ -0,0 +1,170 @@
+package audit
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+)
+
+// Event is a security relevant action streamed to the audit sinks
+type Event struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"` // such as certificate.revoked
+	Actor   string    `json:"actor"`
+	Target  string    `json:"target,omitempty"`
+	Outcome string    `json:"outcome"` // defaults to success
+	IP      string    `json:"ip,omitempty"`
+	Detail  string    `json:"detail,omitempty"`
+}
+
+// Sink delivers audit events to an external system, such as syslog or a SIEM collector
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, events []*Event) error
+}
+
+const (
+	batchSize     = 100
+	flushInterval = time.Second
+	maxRetries    = 3
+)
+
+// worker buffers the events of a sink so a slow or unreachable sink
+// never blocks the caller nor the other sinks
+type worker struct {
+	sink    Sink
+	queue   chan *Event
+	dropped atomic.Int64
+	done    chan struct{}
+}
+
+func newWorker(sink Sink, bufferSize int) *worker {
+	w := &worker{
+		sink:  sink,
+		queue: make(chan *Event, bufferSize),
+		done:  make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	batch := make([]*Event, 0, batchSize)
+	flush := func() {
+		if n := w.dropped.Swap(0); n > 0 {
+			log.Warnf("audit sink %s is falling behind, %d events dropped", w.sink.Name(), n)
+		}
+		if len(batch) == 0 {
+			return
+		}
+		w.write(batch)
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case e, ok := <-w.queue:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, e)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// write retries with backoff, the queue keeps filling meanwhile and
+// overflows are dropped instead of blocking
+func (w *worker) write(batch []*Event) {
+	backoff := time.Second
+	for i := 0; ; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		err := w.sink.Write(ctx, batch)
+		cancel()
+		if err == nil {
+			return
+		}
+		if i+1 >= maxRetries {
+			log.Errorf("failed to write %d events to audit sink %s: %+v", len(batch), w.sink.Name(), err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (w *worker) stop() {
+	close(w.queue)
+	<-w.done
+}
+
+var (
+	workersMu sync.RWMutex
+	workers   []*worker
+)
+
+// SetSinks replaces the enabled sinks, called on startup and whenever settings change.
+// Events still buffered for the old sinks are flushed before they are replaced.
+func SetSinks(bufferSize int, sinks ...Sink) {
+	if bufferSize <= 0 {
+		bufferSize = 1000
+	}
+	ws := make([]*worker, 0, len(sinks))
+	for _, sink := range sinks {
+		ws = append(ws, newWorker(sink, bufferSize))
+	}
+	workersMu.Lock()
+	old := workers
+	workers = ws
+	workersMu.Unlock()
+	for _, w := range old {
+		w.stop()
+	}
+}
+
+// Enabled reports whether any sink is configured
+func Enabled() bool {
+	workersMu.RLock()
+	defer workersMu.RUnlock()
+	return len(workers) > 0
+}
+
+// Emit queues the event for every sink without blocking,
+// events are dropped when the buffer of a sink is full
+func Emit(e *Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Outcome == "" {
+		e.Outcome = OutcomeSuccess
+	}
+	workersMu.RLock()
+	defer workersMu.RUnlock()
+	for _, w := range workers {
+		select {
+		case w.queue <- e:
+		default:
+			w.dropped.Add(1)
+		}
+	}
+}
+
+// Close flushes the buffered events and stops the sinks
+func Close() {
+	SetSinks(0)
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEmitToFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	SetSinks(10, &File{Path: path})
+	Emit(&Event{Type: "certificate.revoked", Actor: "admin", Target: "certificate:1"})
+	Emit(&Event{Type: "auth.login", Actor: "guest", Outcome: OutcomeFailure})
+	Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if events[0].Outcome != OutcomeSuccess || events[0].Time.IsZero() {
+		t.Errorf("defaults are not filled: %+v", events[0])
+	}
+	if events[1].Outcome != OutcomeFailure {
+		t.Errorf("outcome is overwritten: %+v", events[1])
+	}
+}
+
+func TestSyslogFormat(t *testing.T) {
+	s, err := NewSyslog("tcp://127.0.0.1:601", "openlist", FormatJSONL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := s.format(&Event{Type: "auth.login", Outcome: OutcomeFailure})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// octet counting framing and the warning severity of the audit facility
+	length, rest, _ := strings.Cut(string(msg), " ")
+	if length != strconv.Itoa(len(rest)) || !strings.HasPrefix(rest, "<108>1 ") {
+		t.Errorf("unexpected message: %s", msg)
+	}
+}
+
+func TestEncodeCEF(t *testing.T) {
+	e := &Event{Type: "certificate.revoked", Actor: "admin", Target: "certificate:1", Outcome: OutcomeSuccess, Detail: "key=compromised\nby|mail"}
+	got := string(encodeCEF(e))
+	want := `CEF:0|OpenList|OpenList|dev|certificate.revoked|certificate.revoked|6|rt=-62135596800000 suser=admin outcome=success cs1Label=target cs1=certificate:1 msg=key\=compromised\nby|mail`
+	if got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+)
+
+// File appends the events to a local file, one event per line.
+// The file is reopened for every batch so it can be rotated by external tools.
+type File struct {
+	Path   string
+	Format Format
+}
+
+func (f *File) Name() string {
+	return "file"
+}
+
+func (f *File) Write(ctx context.Context, events []*Event) error {
+	data, err := f.Format.encodeLines(events)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
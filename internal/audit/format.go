@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Format decides how a sink encodes the events
+type Format string
+
+const (
+	FormatJSONL Format = "jsonl"
+	// FormatCEF is the arcsight common event format, understood by most siem parsers
+	FormatCEF Format = "cef"
+)
+
+// ParseFormat returns the format of the name, defaulting to json lines
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case "", FormatJSONL:
+		return FormatJSONL, nil
+	case FormatCEF:
+		return FormatCEF, nil
+	default:
+		return "", fmt.Errorf("unsupported audit format: %s", name)
+	}
+}
+
+// Vendor, product and version reported in the cef header
+var (
+	CEFVendor  = "OpenList"
+	CEFProduct = "OpenList"
+	CEFVersion = "dev"
+)
+
+// cefSeverities raises the severity of events that deserve attention, others are 3
+var cefSeverities = map[string]int{
+	"certificate.revoked":    6,
+	"certificate.deleted":    5,
+	"auth.token_rejected":    5,
+	"auth.permission_denied": 5,
+}
+
+func cefSeverity(e *Event) int {
+	if e.Outcome == OutcomeFailure {
+		return 7
+	}
+	if severity, ok := cefSeverities[e.Type]; ok {
+		return severity
+	}
+	return 3
+}
+
+var (
+	cefHeaderEscaper    = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+	cefExtensionEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r\n", `\n`, "\r", `\r`, "\n", `\n`)
+)
+
+// encodeCEF encodes the event as a single cef line without the trailing newline
+func encodeCEF(e *Event) []byte {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "CEF:0|%s|%s|%s|%s|%s|%d|",
+		cefHeaderEscaper.Replace(CEFVendor),
+		cefHeaderEscaper.Replace(CEFProduct),
+		cefHeaderEscaper.Replace(CEFVersion),
+		cefHeaderEscaper.Replace(e.Type),
+		cefHeaderEscaper.Replace(e.Type),
+		cefSeverity(e))
+	ext := [][2]string{
+		{"rt", strconv.FormatInt(e.Time.UnixMilli(), 10)},
+		{"suser", e.Actor},
+		{"src", e.IP},
+		{"outcome", e.Outcome},
+		{"cs1Label", "target"},
+		{"cs1", e.Target},
+		{"msg", e.Detail},
+	}
+	first := true
+	for _, kv := range ext {
+		if kv[1] == "" || (kv[0] == "cs1Label" && e.Target == "") {
+			continue
+		}
+		if !first {
+			buf.WriteByte(' ')
+		}
+		first = false
+		buf.WriteString(kv[0])
+		buf.WriteByte('=')
+		buf.WriteString(cefExtensionEscaper.Replace(kv[1]))
+	}
+	return []byte(buf.String())
+}
+
+// Encode encodes the event as a single line without the trailing newline
+func (f Format) Encode(e *Event) ([]byte, error) {
+	if f == FormatCEF {
+		return encodeCEF(e), nil
+	}
+	return json.Marshal(e)
+}
+
+// ContentType is the mime type of a batch of events in the format
+func (f Format) ContentType() string {
+	if f == FormatCEF {
+		return "text/plain; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// encodeLines encodes the events one per line
+func (f Format) encodeLines(events []*Event) ([]byte, error) {
+	var buf []byte
+	for _, e := range events {
+		data, err := f.Encode(e)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, data...)
+		buf = append(buf, '\n')
+	}
+	return buf, nil
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+)
+
+// HTTP posts every batch of events one event per line,
+// accepted by collectors such as the splunk http event collector or logstash
+type HTTP struct {
+	URL    string
+	Format Format
+	// Authorization is sent as is in the authorization header, such as "Splunk <token>"
+	Authorization string
+}
+
+func (h *HTTP) Name() string {
+	return "http"
+}
+
+func (h *HTTP) Write(ctx context.Context, events []*Event) error {
+	data, err := h.Format.encodeLines(events)
+	if err != nil {
+		return err
+	}
+	req := base.RestyClient.R().SetContext(ctx).
+		SetHeader("Content-Type", h.Format.ContentType()).
+		SetBody(data)
+	if h.Authorization != "" {
+		req.SetHeader("Authorization", h.Authorization)
+	}
+	res, err := req.Post(h.URL)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return fmt.Errorf("audit collector responded with status %s: %s", res.Status(), res.String())
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// syslogFacility is the "log audit" facility of rfc 5424
+	syslogFacility       = 13
+	syslogSeverityNotice = 5
+	syslogSeverityWarn   = 4
+)
+
+// Syslog sends the events as rfc 5424 messages over udp, tcp or tls.
+// Messages over tcp and tls are framed with octet counting as in rfc 6587.
+type Syslog struct {
+	Network string // udp, tcp or tls
+	Addr    string
+	AppName string
+	Format  Format
+
+	mu       sync.Mutex
+	conn     net.Conn
+	hostname string
+}
+
+// NewSyslog creates the syslog sink from an address like udp://host:514, tcp://host:601 or tls://host:6514
+func NewSyslog(addr, appName string, format Format) (*Syslog, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid syslog address: %s", addr)
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+	return &Syslog{Network: u.Scheme, Addr: u.Host, AppName: appName, Format: format, hostname: hostname}, nil
+}
+
+func (s *Syslog) Name() string {
+	return "syslog"
+}
+
+func (s *Syslog) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if s.Network == "tls" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		return (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", s.Addr)
+	}
+	return dialer.DialContext(ctx, s.Network, s.Addr)
+}
+
+func (s *Syslog) format(e *Event) ([]byte, error) {
+	data, err := s.Format.Encode(e)
+	if err != nil {
+		return nil, err
+	}
+	severity := syslogSeverityNotice
+	if e.Outcome == OutcomeFailure {
+		severity = syslogSeverityWarn
+	}
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		syslogFacility*8+severity, e.Time.Format(time.RFC3339Nano), s.hostname, s.AppName, os.Getpid(), e.Type, data)
+	if s.Network == "udp" {
+		return []byte(msg), nil
+	}
+	return []byte(strconv.Itoa(len(msg)) + " " + msg), nil
+}
+
+func (s *Syslog) Write(ctx context.Context, events []*Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := s.dial(ctx)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = s.conn.SetWriteDeadline(deadline)
+	}
+	for _, e := range events {
+		msg, err := s.format(e)
+		if err != nil {
+			return err
+		}
+		if _, err = s.conn.Write(msg); err != nil {
+			// reconnect on the next write
+			_ = s.conn.Close()
+			s.conn = nil
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package backup
+
+import (
+	"io"
+
+	"filippo.io/age"
+)
+
+// AgeRecipient is an age x25519 public key
+type AgeRecipient = age.X25519Recipient
+
+// ParseAgeRecipient parses a bech32 encoded age public key like age1...
+func ParseAgeRecipient(s string) (*AgeRecipient, error) {
+	return age.ParseX25519Recipient(s)
+}
+
+// encryptAge encrypts to the recipients in the age v1 format, decrypted with age --decrypt
+func encryptAge(w io.Writer, recipients []*AgeRecipient) (io.WriteCloser, error) {
+	rs := make([]age.Recipient, len(recipients))
+	for i, r := range recipients {
+		rs[i] = r
+	}
+	return age.Encrypt(w, rs...)
+}
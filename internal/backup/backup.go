@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+)
+
+// Manifest describes the archive, stored as manifest.json
+type Manifest struct {
+	Version   string         `json:"version"`
+	CreatedAt time.Time      `json:"created_at"`
+	Tables    []string       `json:"tables"`
+	Parts     map[string]int `json:"parts"` // number of <table>/<part>.json files of each table
+}
+
+// batchSize is the number of rows of a table stored in one part, so that a table never has to be
+// held in memory as a whole
+const batchSize = 1000
+
+func writeFile(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = tw.Write(data)
+	return err
+}
+
+// Write writes a tar.gz archive of every table as <table>/<part>.json files, each part is a json
+// array of at most batchSize rows and the parts of a table are numbered from 1
+func Write(w io.Writer) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+	manifest := Manifest{Version: conf.Version, CreatedAt: time.Now(), Parts: map[string]int{}}
+	err := db.DumpTables(batchSize, func(table string, rows []map[string]any) error {
+		if _, ok := manifest.Parts[table]; !ok {
+			manifest.Tables = append(manifest.Tables, table)
+			manifest.Parts[table] = 0
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		data, err := json.Marshal(rows)
+		if err != nil {
+			return err
+		}
+		manifest.Parts[table]++
+		return writeFile(tw, fmt.Sprintf("%s/%05d.json", table, manifest.Parts[table]), data, manifest.CreatedAt)
+	})
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = writeFile(tw, "manifest.json", data, manifest.CreatedAt); err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
@@ -0,0 +1,132 @@
+package backup
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+func TestParseAgeRecipient(t *testing.T) {
+	const key = "age1qqqsyqcyq5rqwzqfpg9scrgwpugpzysnzs23v9ccrydpk8qarc0savhh7m"
+	r, err := ParseAgeRecipient(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.String() != key {
+		t.Fatalf("unexpected key: %s", r)
+	}
+	if _, err := ParseAgeRecipient("age1qqqsyqcyq5rqwzqfpg9scrgwpugpzysnzs23v9ccrydpk8qarc0savhh7n"); err == nil {
+		t.Error("expected checksum error")
+	}
+}
+
+func TestEncryptAge(t *testing.T) {
+	custodians := make([]*age.X25519Identity, 2)
+	text := "# custodians\n"
+	for i := range custodians {
+		identity, err := age.GenerateX25519Identity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		custodians[i] = identity
+		text += identity.Recipient().String() + "\n"
+	}
+	recipients, err := ParseRecipients(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipients.Ext() != ".age" {
+		t.Fatalf("unexpected ext: %s", recipients.Ext())
+	}
+	// payloads around the 64KiB chunk size of the age format
+	tests := []struct {
+		name string
+		size int
+	}{
+		{name: "empty", size: 0},
+		{name: "single chunk", size: 1000},
+		{name: "exactly one chunk", size: 64 * 1024},
+		{name: "multiple chunks", size: 3*64*1024 + 17},
+	}
+	for _, tt := range tests {
+		plain := make([]byte, tt.size)
+		for i := range plain {
+			plain[i] = byte(i)
+		}
+		var out bytes.Buffer
+		w, err := recipients.Encrypt(&out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write(plain); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// every custodian can decrypt the archive on their own
+		for i, identity := range custodians {
+			r, err := age.Decrypt(bytes.NewReader(out.Bytes()), identity)
+			if err != nil {
+				t.Fatalf("%s: custodian %d: %v", tt.name, i, err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("%s: custodian %d: %v", tt.name, i, err)
+			}
+			if !bytes.Equal(got, plain) {
+				t.Errorf("%s: custodian %d: decrypted backup doesn't match", tt.name, i)
+			}
+		}
+	}
+}
+
+func TestEncryptPGP(t *testing.T) {
+	entity, err := openpgp.NewEntity("custodian", "", "custodian@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key bytes.Buffer
+	aw, err := armor.Encode(&key, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = entity.Serialize(aw); err != nil {
+		t.Fatal(err)
+	}
+	_ = aw.Close()
+
+	recipients, err := ParseRecipients("# custodians\n" + key.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recipients.Ext() != ".gpg" {
+		t.Fatalf("unexpected ext: %s", recipients.Ext())
+	}
+	var out bytes.Buffer
+	w, err := recipients.Encrypt(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := strings.Repeat("backup", 1000)
+	_, _ = io.WriteString(w, plain)
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	md, err := openpgp.ReadMessage(&out, openpgp.EntityList{entity}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(md.UnverifiedBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != plain {
+		t.Error("decrypted backup doesn't match")
+	}
+}
@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const pgpKeyBlockHeader = "-----BEGIN PGP PUBLIC KEY BLOCK-----"
+
+// Recipients are the public keys of the custodians allowed to decrypt the backups.
+// An archive is encrypted either with age or with openpgp, the two can't be mixed.
+type Recipients struct {
+	Age []*AgeRecipient
+	PGP []string // armored public key blocks
+}
+
+// ParseRecipients parses age recipients (age1...) one per line and armored pgp public key blocks
+func ParseRecipients(text string) (*Recipients, error) {
+	r := &Recipients{}
+	rest := text
+	if i := strings.Index(text, pgpKeyBlockHeader); i >= 0 {
+		rest = text[:i]
+		for _, block := range strings.Split(text[i:], pgpKeyBlockHeader)[1:] {
+			r.PGP = append(r.PGP, pgpKeyBlockHeader+block)
+		}
+	}
+	for _, line := range strings.Split(rest, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		recipient, err := ParseAgeRecipient(line)
+		if err != nil {
+			return nil, err
+		}
+		r.Age = append(r.Age, recipient)
+	}
+	if len(r.Age) > 0 && len(r.PGP) > 0 {
+		return nil, fmt.Errorf("age and pgp recipients can't be mixed")
+	}
+	return r, nil
+}
+
+func (r *Recipients) Empty() bool {
+	return len(r.Age) == 0 && len(r.PGP) == 0
+}
+
+// Ext is the file extension of the encrypted archive
+func (r *Recipients) Ext() string {
+	if len(r.PGP) > 0 {
+		return ".gpg"
+	}
+	if len(r.Age) > 0 {
+		return ".age"
+	}
+	return ""
+}
+
+// Encrypt returns a writer encrypting to the recipients, the archive is complete only after Close
+func (r *Recipients) Encrypt(w io.Writer) (io.WriteCloser, error) {
+	if len(r.PGP) > 0 {
+		return encryptPGP(w, r.PGP)
+	}
+	if len(r.Age) > 0 {
+		return encryptAge(w, r.Age)
+	}
+	return nil, fmt.Errorf("no backup recipients")
+}
@@ -0,0 +1,21 @@
+package backup
+
+import (
+	"io"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// encryptPGP encrypts to the public keys as a binary openpgp message, decrypted with gpg --decrypt
+func encryptPGP(w io.Writer, blocks []string) (io.WriteCloser, error) {
+	var entities openpgp.EntityList
+	for _, block := range blocks {
+		list, err := openpgp.ReadArmoredKeyRing(strings.NewReader(block))
+		if err != nil {
+			return nil, err
+		}
+		entities = append(entities, list...)
+	}
+	return openpgp.Encrypt(w, entities, nil, &openpgp.FileHints{IsBinary: true}, nil)
+}
@@ -0,0 +1,48 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// auditFormat reads the format of a sink, falling back to json lines when invalid
+func auditFormat(key string) audit.Format {
+	format, err := audit.ParseFormat(setting.GetStr(key))
+	if err != nil {
+		utils.Log.Errorf("failed to load %s: %+v", key, err)
+		return audit.FormatJSONL
+	}
+	return format
+}
+
+func loadAuditSinks() {
+	var sinks []audit.Sink
+	if addr := setting.GetStr(conf.AuditSyslogAddr); addr != "" {
+		syslog, err := audit.NewSyslog(addr, "openlist", auditFormat(conf.AuditSyslogFormat))
+		if err != nil {
+			utils.Log.Errorf("failed to load audit syslog sink: %+v", err)
+		} else {
+			sinks = append(sinks, syslog)
+		}
+	}
+	if path := setting.GetStr(conf.AuditFilePath); path != "" {
+		sinks = append(sinks, &audit.File{Path: path, Format: auditFormat(conf.AuditFileFormat)})
+	}
+	if url := setting.GetStr(conf.AuditHttpUrl); url != "" {
+		sinks = append(sinks, &audit.HTTP{
+			URL:           url,
+			Format:        auditFormat(conf.AuditHttpFormat),
+			Authorization: setting.GetStr(conf.AuditHttpAuthorization),
+		})
+	}
+	audit.SetSinks(setting.GetInt(conf.AuditBufferSize, 1000), sinks...)
+}
+
+func InitAudit() {
+	audit.CEFVersion = conf.Version
+	loadAuditSinks()
+	op.RegisterSettingChangingCallback(loadAuditSinks)
+}
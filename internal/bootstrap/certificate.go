@@ -0,0 +1,137 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	certificateCron       *cron.Cron
+	certificateExpiryCron *cron.Cron
+	tlsCertificateCron    *cron.Cron
+	webhookCron           *cron.Cron
+)
+
+// tlsCertificateReloadInterval is how often every instance checks whether the managed
+// https certificate has been renewed or replaced, possibly by another instance
+const tlsCertificateReloadInterval = 5 * time.Minute
+
+// certificateJobsLease is held by the instance running the certificate jobs when several
+// instances share one database. It outlives the hourly interval so the leader keeps it.
+const (
+	certificateJobsLease    = "certificate_jobs"
+	certificateJobsLeaseTTL = 90 * time.Minute
+	certificateExpiryLease  = "certificate_expiry"
+	certificateWebhookLease = "certificate_webhooks"
+)
+
+// InitCertificateJobs starts the scheduled jobs of the certificate module
+func InitCertificateJobs() {
+	certificateCron = cron.NewCron(time.Hour)
+	certificateCron.Do(func() {
+		if !op.AcquireSchedulerLease(certificateJobsLease, certificateJobsLeaseTTL) {
+			log.Debugf("certificate jobs are run by another instance")
+			return
+		}
+		// expire stale requests first so their approvers are not reminded again
+		if err := op.ExpireCertificateRequests(context.Background()); err != nil {
+			log.Errorf("failed to expire certificate requests: %+v", err)
+		}
+		if err := op.RemindPendingCertificateRequests(context.Background()); err != nil {
+			log.Errorf("failed to remind pending certificate requests: %+v", err)
+		}
+		if err := op.SendCertificateDigests(context.Background()); err != nil {
+			log.Errorf("failed to send certificate digests: %+v", err)
+		}
+		if err := op.IssueScheduledCertificates(context.Background()); err != nil {
+			log.Errorf("failed to issue scheduled certificates: %+v", err)
+		}
+		if err := op.RenewExpiringCertificates(context.Background()); err != nil {
+			log.Errorf("failed to renew expiring certificates: %+v", err)
+		}
+		if err := op.RunCARotations(context.Background()); err != nil {
+			log.Errorf("failed to run ca rotations: %+v", err)
+		}
+		if _, err := op.PublishRevocationData(context.Background()); err != nil {
+			log.Errorf("failed to publish revocation data: %+v", err)
+		}
+		if _, err := op.ReconcileCertificates(); err != nil {
+			log.Errorf("failed to reconcile certificates: %+v", err)
+		}
+		if err := op.ExpireCertificateRequestDrafts(); err != nil {
+			log.Errorf("failed to expire certificate request drafts: %+v", err)
+		}
+	})
+	initCertificateExpiryJob()
+	initTLSCertificateJob()
+	initCertificateWebhookJob()
+}
+
+// initCertificateWebhookJob retries failed webhook deliveries every minute, only one instance retries
+func initCertificateWebhookJob() {
+	webhookCron = cron.NewCron(time.Minute)
+	webhookCron.Do(func() {
+		if !op.AcquireSchedulerLease(certificateWebhookLease, 5*time.Minute) {
+			return
+		}
+		if err := op.RetryCertificateWebhookDeliveries(context.Background()); err != nil {
+			log.Errorf("failed to retry certificate webhook deliveries: %+v", err)
+		}
+	})
+}
+
+// initTLSCertificateJob loads the managed https certificate and reloads it periodically.
+// It runs on every instance since each one serves its own listener.
+func initTLSCertificateJob() {
+	if err := op.ReloadTLSCertificate(); err != nil {
+		log.Errorf("failed to load tls certificate: %+v", err)
+	}
+	tlsCertificateCron = cron.NewCron(tlsCertificateReloadInterval)
+	tlsCertificateCron.Do(func() {
+		if err := op.ReloadTLSCertificate(); err != nil {
+			log.Errorf("failed to reload tls certificate: %+v", err)
+		}
+	})
+}
+
+// initCertificateExpiryJob marks expiring and expired certificates at the interval
+// configured in the config file, independent of the hourly jobs
+func initCertificateExpiryJob() {
+	interval := time.Duration(conf.Conf.Certificate.ExpiryCheckMinutes) * time.Minute
+	if interval <= 0 {
+		log.Infof("certificate expiry job is disabled")
+		return
+	}
+	certificateExpiryCron = cron.NewCron(interval)
+	certificateExpiryCron.Do(func() {
+		if !op.AcquireSchedulerLease(certificateExpiryLease, interval*3/2) {
+			return
+		}
+		if err := op.UpdateCertificateExpiryStatuses(context.Background()); err != nil {
+			log.Errorf("failed to update certificate expiry statuses: %+v", err)
+		}
+	})
+}
+
+func StopCertificateJobs() {
+	if certificateCron != nil {
+		certificateCron.Stop()
+		op.ReleaseSchedulerLease(certificateJobsLease)
+	}
+	if certificateExpiryCron != nil {
+		certificateExpiryCron.Stop()
+		op.ReleaseSchedulerLease(certificateExpiryLease)
+	}
+	if tlsCertificateCron != nil {
+		tlsCertificateCron.Stop()
+	}
+	if webhookCron != nil {
+		webhookCron.Stop()
+		op.ReleaseSchedulerLease(certificateWebhookLease)
+	}
+}
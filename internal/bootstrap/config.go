@@ -116,6 +116,9 @@ func InitConfig() {
 	convertAbsPath(&conf.Conf.Scheme.CertFile)
 	convertAbsPath(&conf.Conf.Scheme.KeyFile)
 	convertAbsPath(&conf.Conf.Scheme.UnixFile)
+	convertAbsPath(&conf.Conf.Admin.CertFile)
+	convertAbsPath(&conf.Conf.Admin.KeyFile)
+	convertAbsPath(&conf.Conf.Admin.ClientCAFile)
 	convertAbsPath(&conf.Conf.Log.Name)
 	convertAbsPath(&conf.Conf.TempDir)
 	convertAbsPath(&conf.Conf.BleveDir)
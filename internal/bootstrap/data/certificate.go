@@ -0,0 +1,31 @@
+package data
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// initCertificateTypes 确保内置的证书类型存在于注册表中
+func initCertificateTypes() {
+	builtin := []model.CertificateTypeDef{
+		{Name: model.CertificateTypeUser, Description: "User certificate", ValidityPresets: model.DefaultValidityPresets},
+		{Name: model.CertificateTypeNode, Description: "Node certificate", ValidityPresets: model.DefaultValidityPresets},
+		// 公开信任证书的有效期由 ACME CA 决定
+		{Name: model.CertificateTypePublic, Description: "Publicly trusted certificate issued through ACME", ValidityDays: 90},
+	}
+	for i := range builtin {
+		_, err := db.GetCertificateTypeByName(builtin[i].Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			utils.Log.Fatalf("[init certificate type] failed get certificate type: %v", err)
+		}
+		if err := db.CreateCertificateType(&builtin[i]); err != nil {
+			utils.Log.Fatalf("[init certificate type] failed create certificate type: %v", err)
+		}
+	}
+}
@@ -6,6 +6,7 @@ func InitData() {
 	initUser()
 	initSettings()
 	initTasks()
+	initCertificateTypes()
 	if flags.Dev {
 		initDevData()
 		initDevDo()
@@ -230,6 +230,105 @@ func InitialSettings() []model.SettingItem {
 		{Key: conf.StreamMaxClientUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerDownloadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
 		{Key: conf.StreamMaxServerUploadSpeed, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE},
+		{Key: conf.UserMaxConcurrency, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `max concurrent expensive requests per user, -1 means unlimited`},
+		{Key: conf.UserRateLimit, Value: "-1", Type: conf.TypeNumber, Group: model.TRAFFIC, Flag: model.PRIVATE, Help: `max expensive requests per user per minute, -1 means unlimited`},
+
+		// notify settings
+		{Key: conf.NotifyWebhookUrl, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `json payload of every notification is posted to this url`},
+		{Key: conf.NotifySmtpHost, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `notifications are emailed to users with an email address when set`},
+		{Key: conf.NotifySmtpPort, Value: "465", Type: conf.TypeNumber, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySmtpUsername, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySmtpPassword, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySmtpFrom, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySmtpSSL, Value: "true", Type: conf.TypeBool, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `use implicit tls, otherwise starttls is used when the server supports it`},
+		{Key: conf.NotifySlackToken, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `bot token of the slack app, notifications are posted to the slack channel when set`},
+		{Key: conf.NotifySlackChannel, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySlackSecret, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `signing secret of the slack app, required by the approve/reject buttons`},
+		{Key: conf.NotifyTelegramBot, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `token of the telegram bot, notifications are posted to the telegram chat when set`},
+		{Key: conf.NotifyTelegramChat, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `id of the telegram chat or @channel name`},
+		{Key: conf.NotifyFcmAccount, Value: "", Type: conf.TypeText, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `json of the firebase service account, pushes to android and web devices when set`},
+		{Key: conf.NotifyApnsKey, Value: "", Type: conf.TypeText, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `content of the .p8 apns auth key, pushes to ios devices when set`},
+		{Key: conf.NotifyApnsKeyID, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifyApnsTeamID, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifyApnsTopic, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `bundle id of the app`},
+		{Key: conf.NotifyApnsSandbox, Value: "false", Type: conf.TypeBool, Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySmsProvider, Value: "none", Type: conf.TypeSelect, Options: "none,twilio,aliyun", Group: model.NOTIFY, Flag: model.PRIVATE},
+		{Key: conf.NotifySmsAccount, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `twilio account sid or aliyun access key id`},
+		{Key: conf.NotifySmsSecret, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `twilio auth token or aliyun access key secret`},
+		{Key: conf.NotifySmsFrom, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `twilio sender number or aliyun sign name`},
+		{Key: conf.NotifySmsTemplate, Value: "", Type: conf.TypeString, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `aliyun template code, the template should contain a ${content} variable`},
+		{Key: conf.NotifyRouting, Value: "info=webhook,email,slack,telegram,push\nwarning=webhook,email,slack,telegram,push\ncritical=webhook,email,slack,telegram,push,sms", Type: conf.TypeText, Group: model.NOTIFY, Flag: model.PRIVATE, Help: `channels of each severity, one severity per line, severities not listed go to every channel`},
+
+		// certificate settings
+		{Key: conf.CertApprovalRemindHours, Value: "24", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `remind approvers of requests pending longer than this, 0 to disable`},
+		{Key: conf.CertApprovalEscalateDays, Value: "3", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `also notify the escalation users after a request is pending for this many days, 0 to disable`},
+		{Key: conf.CertApprovalEscalationUsers, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `one username per line`},
+		{Key: conf.CertMentionGrantsAccess, Value: "true", Type: conf.TypeBool, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `users @mentioned in request comments can read that request`},
+		{Key: conf.CertApprovalLinkHours, Value: "72", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `validity of the one-click approval links in notification emails, 0 to disable, requires site_url`},
+		{Key: conf.CertDigestFrequency, Value: "weekly", Type: conf.TypeSelect, Options: "off,daily,weekly,monthly", Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `default frequency of the expiring certificates digest email, users can change their own`},
+		{Key: conf.CertCalendarAlarmDays, Value: "30,7", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `comma separated days before expiration to add alarms in the calendar feed`},
+		{Key: conf.CertDraftExpireDays, Value: "30", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `drafts of certificate requests not updated for this many days are deleted, 0 to keep them`},
+		{Key: conf.CertRequestExpireDays, Value: "30", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `pending certificate requests not approved or rejected within this many days are expired and the requester is notified to submit again, 0 to keep them pending`},
+		{Key: conf.CertRevokedDownloadPolicy, Value: "block", Type: conf.TypeSelect, Options: "block,grace,allow", Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `whether revoked certificates can still be downloaded: block immediately, allow within the grace period, or always allow, downloads are marked as revoked`},
+		{Key: conf.CertRevokedDownloadGrace, Value: "72", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `hours after revocation during which the certificate can still be downloaded when the policy is grace`},
+		{Key: conf.CertCABundleVersion, Value: "0", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.READONLY, Help: `version of the public ca bundle, increased whenever a ca certificate is added or retired`},
+		{Key: conf.CertCARotationBatchSize, Value: "50", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `default number of certificates reissued by a ca rotation every hour`},
+		{Key: conf.CertAnomalyRequestSpike, Value: "5", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `alert when a user submits this many certificate requests within 24 hours, 0 to disable`},
+		{Key: conf.CertAnomalyRejections, Value: "3", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `alert when this many certificate requests of a user are rejected within 7 days, 0 to disable`},
+		{Key: conf.CertAnomalyBusinessHours, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `business hours like 9-18 in server time, certificates issued outside them or on weekends raise an alert, empty to disable`},
+		{Key: conf.CertAnomalyReviewers, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `usernames receiving anomaly alerts, one per line, all admins when empty`},
+		{Key: conf.CertApprovalChecklist, Value: "Identity verified\nDomain verified\nKey size OK", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `items approvers must tick when approving a certificate request, one per line, approving from slack or email links is not possible when set`},
+		{Key: conf.CertApprovalJustification, Value: "true", Type: conf.TypeBool, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `require approvers to record a justification when approving a certificate request`},
+		{Key: conf.CertRenewOverlapDays, Value: "0", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `certificates with auto renew enabled are renewed this many days before expiry while the old certificate stays valid, 0 to disable automatic renewal`},
+		{Key: conf.CertTenantDailyRequests, Value: "0", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `certificate requests a tenant may submit in 24 hours, 0 for no limit`},
+		{Key: conf.CertTenantMaxCertificates, Value: "1", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `valid certificates a tenant may hold at the same time, can be overridden per tenant, renewed certificates in their overlap period are not counted`},
+		{Key: conf.CertRevocationPublishPath, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `storage path that CRLs and pre-signed OCSP responses are published to, e.g. a mounted object storage used as CDN origin, empty to disable`},
+		{Key: conf.CertAttachmentPath, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `storage path that attachments of certificate requests are uploaded to, one directory per request, empty to disable attachments`},
+		{Key: conf.CertCRLValidityHours, Value: "24", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `hours until the next update of published CRLs and OCSP responses, they are republished every hour and on revocation`},
+		{Key: conf.CertOCSPDelegatedSigner, Value: "false", Type: conf.TypeBool, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `sign responses of /api/public/certificate/ocsp with a short-lived delegated OCSP signing certificate issued by the CA instead of the CA key itself`},
+		{Key: conf.CertSandboxEnabled, Value: "false", Type: conf.TypeBool, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `allow tenants to submit sandbox requests, which are issued immediately by an untrusted test CA and don't count against quotas`},
+		{Key: conf.CertSandboxValidityHours, Value: "24", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `validity of sandbox certificates in hours`},
+		{Key: conf.CertSandboxCACertificate, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `PEM encoded certificate of the sandbox CA, generated on first use when empty`},
+		{Key: conf.CertSandboxCAPrivateKey, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `PEM encoded private key of the sandbox CA`},
+		{Key: conf.CertCRLRefreshMinutes, Value: "60", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `minutes after which the CRL served by /api/public/certificate/crl is regenerated, it is also regenerated on revocation`},
+		{Key: conf.CertTSACertificate, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `PEM encoded certificate of the timestamp authority followed by its intermediates, it must only have the critical timeStamping extended key usage, empty to disable /api/public/timestamp`},
+		{Key: conf.CertTSAPrivateKey, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `PEM encoded private key of the timestamp authority certificate`},
+		{Key: conf.CertTSAPolicy, Value: "2.5.29.32.0", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `policy OID stamped into issued timestamps, requests asking for another policy are rejected`},
+		{Key: conf.CertSignPerHour, Value: "100", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `signatures a user may create with server-held certificate keys per hour, 0 for no limit`},
+		{Key: conf.CertJWTCertificate, Value: "0", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `id of the certificate whose server-held key signs JWTs, renewals of it take over automatically once their key is set, 0 to disable`},
+		{Key: conf.CertJWTIssuer, Value: "openlist", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `iss claim of signed JWTs`},
+		{Key: conf.CertJWTMaxTTL, Value: "3600", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `maximum lifetime of signed JWTs in seconds`},
+		{Key: conf.CertSSHCAPublicKey, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `public key of the SSH CA in authorized_keys format, used for @cert-authority known_hosts lines`},
+		{Key: conf.CertKeyAlgorithm, Value: "ecdsa-p256", Type: conf.TypeSelect, Options: "rsa-2048,rsa-3072,rsa-4096,ecdsa-p256,ecdsa-p384,ed25519", Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `key algorithm of key pairs generated for issued certificates`},
+		{Key: conf.CertLintBlockSeverity, Value: "", Type: conf.TypeSelect, Options: ",notice,warn,error,fatal", Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `issuance is refused when the certificate to be signed has RFC 5280 or Baseline Requirements lint findings of this severity or above, empty to only record the findings`},
+		{Key: conf.CertExpiringWindowDays, Value: "30", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `valid certificates are marked as expiring this many days before expiry`},
+		{Key: conf.CertExpiryNotifyDays, Value: "30,7,1", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `owners and admins are notified this many days before a certificate expires and again on expiry, comma separated, empty to disable`},
+		{Key: conf.CertACMEDirectoryURL, Value: "https://acme-v02.api.letsencrypt.org/directory", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `ACME directory of the CA issuing certificates of the public type`},
+		{Key: conf.CertACMEEmail, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `contact email of the ACME account`},
+		{Key: conf.CertACMEAccountKey, Value: "", Type: conf.TypeText, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `PEM encoded private key of the ACME account, generated on first use when empty and encrypted like certificate private keys when a key encryption key is configured`},
+		{Key: conf.CertACMEChallenge, Value: "http-01", Type: conf.TypeSelect, Options: "http-01,dns-01", Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `http-01 is answered at /.well-known/acme-challenge, which must be reachable on port 80 of every domain; dns-01 is delegated to the dns webhook`},
+		{Key: conf.CertACMEDNSWebhook, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `url receiving {"action":"present"|"cleanup","fqdn","value"} to create and remove the TXT records of dns-01 challenges, it should respond after the record is visible`},
+		{Key: conf.CertTicketSystem, Value: "", Type: conf.TypeSelect, Options: ",jira,servicenow", Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `ticket system receiving certificate requests, a ticket is created when a request is submitted without one and approvals and rejections are posted back to the linked ticket, empty to only keep manually linked tickets`},
+		{Key: conf.CertTicketBaseURL, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `base url of the ticket system, e.g. https://example.atlassian.net or https://example.service-now.com`},
+		{Key: conf.CertTicketUsername, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `username of the ticket system api, the token is sent as a bearer token when empty`},
+		{Key: conf.CertTicketToken, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `api token or password of the ticket system`},
+		{Key: conf.CertTicketProject, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `jira project key or servicenow table of created tickets, servicenow defaults to incident`},
+		{Key: conf.CertTLSCertificateID, Value: "0", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `id of the managed certificate served by the https listener instead of cert_file and key_file, set through activate_tls and follows renewals, 0 to use the configured files`},
+		{Key: conf.CertRevocationWebhookSecret, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `shared secret signing external revocation notices sent to /api/public/certificate/revocation_notice, empty to disable the endpoint`},
+		{Key: conf.CertEmailIntakeSecret, Value: "", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE, Help: `shared secret signing inbound emails forwarded by the mail provider to /api/public/certificate/email, senders are matched to users by email only when the provider reports a dmarc pass verdict, empty to disable the endpoint`},
+
+		// audit settings
+		{Key: conf.AuditSyslogAddr, Value: "", Type: conf.TypeString, Group: model.AUDIT, Flag: model.PRIVATE, Help: `audit events are sent to this syslog server when set, like udp://host:514, tcp://host:601 or tls://host:6514`},
+		{Key: conf.AuditFilePath, Value: "", Type: conf.TypeString, Group: model.AUDIT, Flag: model.PRIVATE, Help: `audit events are appended to this file as json lines when set`},
+		{Key: conf.AuditHttpUrl, Value: "", Type: conf.TypeString, Group: model.AUDIT, Flag: model.PRIVATE, Help: `audit events are posted to this url as json lines when set, such as a splunk or logstash http input`},
+		{Key: conf.AuditHttpAuthorization, Value: "", Type: conf.TypeString, Group: model.AUDIT, Flag: model.PRIVATE, Help: `value of the authorization header sent to the audit http url`},
+		{Key: conf.AuditSyslogFormat, Value: "jsonl", Type: conf.TypeSelect, Options: "jsonl,cef", Group: model.AUDIT, Flag: model.PRIVATE},
+		{Key: conf.AuditFileFormat, Value: "jsonl", Type: conf.TypeSelect, Options: "jsonl,cef", Group: model.AUDIT, Flag: model.PRIVATE},
+		{Key: conf.AuditHttpFormat, Value: "jsonl", Type: conf.TypeSelect, Options: "jsonl,cef", Group: model.AUDIT, Flag: model.PRIVATE},
+		{Key: conf.AuditBufferSize, Value: "1000", Type: conf.TypeNumber, Group: model.AUDIT, Flag: model.PRIVATE, Help: `events buffered for each sink, events are dropped when a sink falls behind further`},
+
+		// backup settings
+		{Key: conf.BackupRecipients, Value: "", Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE, Help: `backups are encrypted to these public keys when set, age recipients one per line or armored pgp public key blocks`},
 	}
 	additionalSettingItems := tool.Tools.Items()
 	// 固定顺序
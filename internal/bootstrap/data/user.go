@@ -34,7 +34,8 @@ func initUser() {
 				BasePath: "/",
 				Authn:    "[]",
 				// 0(can see hidden) - 8(webdav read) & 12(can read archives) - 14(can share)
-				Permission: 0x71FF,
+				// & 18(can read certificate content)
+				Permission: 0x471FF,
 			}
 			if err := op.CreateUser(admin); err != nil {
 				panic(err)
@@ -9,6 +9,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/pkg/envelope"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
@@ -85,5 +86,81 @@ func InitDB() {
 	if err != nil {
 		log.Fatalf("failed to connect database:%s", err.Error())
 	}
+	db.SetKeyWrapper(newKeyWrapper(conf.Conf.KeyEncryption), previousKeyWrappers(conf.Conf.KeyEncryption)...)
 	db.Init(dB)
+	if !flags.Dev {
+		db.InitReplicas(openReplicas(conf.Conf.Database, gormConfig))
+	}
+}
+
+// newKeyWrapper returns the key encryption key protecting private keys in the database,
+// private keys are stored in plaintext when neither a Vault key nor a master key is configured
+func newKeyWrapper(c conf.KeyEncryption) envelope.KeyWrapper {
+	var w envelope.KeyWrapper
+	var err error
+	switch {
+	case c.VaultAddr != "":
+		w, err = envelope.NewVaultWrapper(c.VaultAddr, c.VaultToken, c.VaultKey)
+	case c.MasterKey != "":
+		w, err = envelope.NewLocalWrapper(c.MasterKey)
+	default:
+		log.Warn("no key encryption key is configured, private keys are stored in plaintext")
+		return nil
+	}
+	if err != nil {
+		log.Fatalf("invalid key encryption config: %s", err.Error())
+	}
+	return w
+}
+
+// previousKeyWrappers returns the rotated master key, private keys sealed with it stay
+// readable until they are sealed again by the private-key rewrap command
+func previousKeyWrappers(c conf.KeyEncryption) []envelope.KeyWrapper {
+	if c.PreviousMasterKey == "" {
+		return nil
+	}
+	w, err := envelope.NewLocalWrapper(c.PreviousMasterKey)
+	if err != nil {
+		log.Fatalf("invalid previous master key: %s", err.Error())
+	}
+	return []envelope.KeyWrapper{w}
+}
+
+// openReplicas connects to the configured read replicas. A replica that can not be
+// reached is skipped so that reads fall back to the remaining replicas or the primary.
+func openReplicas(database conf.Database, gormConfig *gorm.Config) []*gorm.DB {
+	var replicas []*gorm.DB
+	for i, dsn := range database.ReplicaDSNs {
+		if strings.TrimSpace(dsn) == "" {
+			continue
+		}
+		var dialector gorm.Dialector
+		switch database.Type {
+		case "mysql":
+			dialector = mysql.Open(dsn)
+		case "postgres":
+			dialector = postgres.Open(dsn)
+		default:
+			log.Warnf("read replicas are not supported for database type %s", database.Type)
+			return nil
+		}
+		r, err := gorm.Open(dialector, gormConfig)
+		if err == nil {
+			err = pingReplica(r)
+		}
+		if err != nil {
+			log.Errorf("failed to connect read replica #%d: %s", i+1, err.Error())
+			continue
+		}
+		replicas = append(replicas, r)
+	}
+	return replicas
+}
+
+func pingReplica(r *gorm.DB) error {
+	sqlDB, err := r.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
 }
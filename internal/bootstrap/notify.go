@@ -0,0 +1,149 @@
+package bootstrap
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// userEmails resolves usernames to the email addresses set on the users
+func userEmails(usernames []string) map[string]string {
+	res := make(map[string]string, len(usernames))
+	for _, name := range usernames {
+		user, err := op.GetUserByName(name)
+		if err != nil || user.Disabled || user.Email == "" {
+			continue
+		}
+		res[name] = user.Email
+	}
+	return res
+}
+
+// userPhones resolves usernames to the phone numbers set on the users
+func userPhones(usernames []string) map[string]string {
+	res := make(map[string]string, len(usernames))
+	for _, name := range usernames {
+		user, err := op.GetUserByName(name)
+		if err != nil || user.Disabled || user.Phone == "" {
+			continue
+		}
+		res[name] = user.Phone
+	}
+	return res
+}
+
+func loadSMSChannel() notify.Channel {
+	var provider notify.SMSProvider
+	switch setting.GetStr(conf.NotifySmsProvider) {
+	case "twilio":
+		provider = &notify.Twilio{
+			AccountSID: setting.GetStr(conf.NotifySmsAccount),
+			AuthToken:  setting.GetStr(conf.NotifySmsSecret),
+			From:       setting.GetStr(conf.NotifySmsFrom),
+		}
+	case "aliyun":
+		provider = &notify.Aliyun{
+			AccessKeyID:     setting.GetStr(conf.NotifySmsAccount),
+			AccessKeySecret: setting.GetStr(conf.NotifySmsSecret),
+			SignName:        setting.GetStr(conf.NotifySmsFrom),
+			TemplateCode:    setting.GetStr(conf.NotifySmsTemplate),
+		}
+	default:
+		return nil
+	}
+	return &notify.SMS{Provider: provider, Phones: userPhones}
+}
+
+// loadNotifyRoutes parses lines like "critical=email,sms"
+func loadNotifyRoutes() map[notify.Severity][]string {
+	routes := make(map[notify.Severity][]string)
+	for _, line := range strings.Split(setting.GetStr(conf.NotifyRouting), "\n") {
+		severity, names, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		var list []string
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				list = append(list, name)
+			}
+		}
+		routes[notify.Severity(strings.TrimSpace(severity))] = list
+	}
+	return routes
+}
+
+func loadPushChannel() notify.Channel {
+	push := &notify.Push{
+		Devices:    op.GetPushDevices,
+		Unregister: op.UnregisterPushDevice,
+	}
+	if account := setting.GetStr(conf.NotifyFcmAccount); account != "" {
+		fcm, err := notify.NewFCM(account)
+		if err != nil {
+			utils.Log.Errorf("failed to load fcm push: %+v", err)
+		} else {
+			push.FCM = fcm
+		}
+	}
+	if key := setting.GetStr(conf.NotifyApnsKey); key != "" {
+		apns, err := notify.NewAPNs(key, setting.GetStr(conf.NotifyApnsKeyID), setting.GetStr(conf.NotifyApnsTeamID),
+			setting.GetStr(conf.NotifyApnsTopic), setting.GetBool(conf.NotifyApnsSandbox))
+		if err != nil {
+			utils.Log.Errorf("failed to load apns push: %+v", err)
+		} else {
+			push.APNs = apns
+		}
+	}
+	if push.FCM == nil && push.APNs == nil {
+		return nil
+	}
+	return push
+}
+
+func loadNotifyChannels() {
+	var channels []notify.Channel
+	if url := setting.GetStr(conf.NotifyWebhookUrl); url != "" {
+		channels = append(channels, &notify.Webhook{URL: url})
+	}
+	if host := setting.GetStr(conf.NotifySmtpHost); host != "" {
+		channels = append(channels, &notify.Email{
+			Host:      host,
+			Port:      setting.GetInt(conf.NotifySmtpPort, 465),
+			Username:  setting.GetStr(conf.NotifySmtpUsername),
+			Password:  setting.GetStr(conf.NotifySmtpPassword),
+			From:      setting.GetStr(conf.NotifySmtpFrom),
+			SSL:       setting.GetBool(conf.NotifySmtpSSL),
+			Addresses: userEmails,
+		})
+	}
+	if token := setting.GetStr(conf.NotifySlackToken); token != "" {
+		channels = append(channels, &notify.Slack{
+			Token:   token,
+			Channel: setting.GetStr(conf.NotifySlackChannel),
+		})
+	}
+	if token := setting.GetStr(conf.NotifyTelegramBot); token != "" {
+		channels = append(channels, &notify.Telegram{
+			Token:  token,
+			ChatID: setting.GetStr(conf.NotifyTelegramChat),
+		})
+	}
+	if push := loadPushChannel(); push != nil {
+		channels = append(channels, push)
+	}
+	if sms := loadSMSChannel(); sms != nil {
+		channels = append(channels, sms)
+	}
+	notify.SetChannels(channels...)
+	notify.SetRoutes(loadNotifyRoutes())
+}
+
+func InitNotify() {
+	loadNotifyChannels()
+	op.RegisterSettingChangingCallback(loadNotifyChannels)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_32_0"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_41_0"
 	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v3_all"
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap/patch/v4_1_0"
 )
 
 type VersionPatches struct {
@@ -33,6 +34,12 @@ var UpgradePatches = []VersionPatches{
 			v3_41_0.GrantAdminPermissions,
 		},
 	},
+	{
+		Version: "v4.1.0",
+		Patches: []func(){
+			v4_1_0.GrantCertificateContentPermission,
+		},
+	},
 	{
 		Version: "v3.0.0",
 		Patches: []func(){
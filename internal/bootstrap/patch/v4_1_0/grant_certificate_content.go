@@ -0,0 +1,30 @@
+package v4_1_0
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// GrantCertificateContentPermission gives existing admins Permission 18(can read certificate content),
+// which admins used to have implicitly. It is skipped once any admin holds the permission so that
+// revoking it from an admin is not undone on the next launch
+func GrantCertificateContentPermission() {
+	admins, err := db.GetUsersByRole(model.ADMIN)
+	if err != nil {
+		utils.Log.Errorf("Cannot grant certificate content permission to admins: %v", err)
+		return
+	}
+	for i := range admins {
+		if admins[i].CanReadCertificateContent() {
+			return
+		}
+	}
+	for i := range admins {
+		admins[i].Permission |= 1 << 18
+		if err := op.UpdateUser(&admins[i]); err != nil {
+			utils.Log.Errorf("Cannot grant certificate content permission to %s: %v", admins[i].Username, err)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"encoding/base64"
 	"path/filepath"
 
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
@@ -17,6 +18,9 @@ type Database struct {
 	TablePrefix string `json:"table_prefix" env:"TABLE_PREFIX"`
 	SSLMode     string `json:"ssl_mode" env:"SSL_MODE"`
 	DSN         string `json:"dsn" env:"DSN"`
+	// ReplicaDSNs are read replicas of a mysql or postgres database, heavy read queries
+	// are spread across them while writes always go to the primary
+	ReplicaDSNs []string `json:"replica_dsns" env:"REPLICA_DSNS"`
 }
 
 type Meilisearch struct {
@@ -26,15 +30,16 @@ type Meilisearch struct {
 }
 
 type Scheme struct {
-	Address      string `json:"address" env:"ADDR"`
-	HttpPort     int    `json:"http_port" env:"HTTP_PORT"`
-	HttpsPort    int    `json:"https_port" env:"HTTPS_PORT"`
-	ForceHttps   bool   `json:"force_https" env:"FORCE_HTTPS"`
-	CertFile     string `json:"cert_file" env:"CERT_FILE"`
-	KeyFile      string `json:"key_file" env:"KEY_FILE"`
-	UnixFile     string `json:"unix_file" env:"UNIX_FILE"`
-	UnixFilePerm string `json:"unix_file_perm" env:"UNIX_FILE_PERM"`
-	EnableH2c    bool   `json:"enable_h2c" env:"ENABLE_H2C"`
+	Address       string `json:"address" env:"ADDR"`
+	HttpPort      int    `json:"http_port" env:"HTTP_PORT"`
+	HttpsPort     int    `json:"https_port" env:"HTTPS_PORT"`
+	ForceHttps    bool   `json:"force_https" env:"FORCE_HTTPS"`
+	CertFile      string `json:"cert_file" env:"CERT_FILE"`
+	KeyFile       string `json:"key_file" env:"KEY_FILE"`
+	UnixFile      string `json:"unix_file" env:"UNIX_FILE"`
+	UnixFilePerm  string `json:"unix_file_perm" env:"UNIX_FILE_PERM"`
+	SystemdSocket bool   `json:"systemd_socket" env:"SYSTEMD_SOCKET"`
+	EnableH2c     bool   `json:"enable_h2c" env:"ENABLE_H2C"`
 }
 
 type LogConfig struct {
@@ -100,36 +105,73 @@ type FTP struct {
 	EnablePasvConnIPCheck   bool   `json:"enable_pasv_conn_ip_check" env:"ENABLE_PASV_CONN_IP_CHECK"`
 }
 
+type AdminServer struct {
+	Enable       bool   `json:"enable" env:"ENABLE"`
+	Listen       string `json:"listen" env:"LISTEN"`
+	CertFile     string `json:"cert_file" env:"CERT_FILE"`
+	KeyFile      string `json:"key_file" env:"KEY_FILE"`
+	ClientCAFile string `json:"client_ca_file" env:"CLIENT_CA_FILE"`
+}
+
+type CertificateConfig struct {
+	// ExpiryCheckMinutes is the interval of the job marking expiring and expired certificates
+	ExpiryCheckMinutes int `json:"expiry_check_minutes" env:"EXPIRY_CHECK_MINUTES"`
+	// SignWorkers limits concurrent signing operations, further requests wait in a queue
+	// of SignQueue entries and fail once the queue is full
+	SignWorkers int `json:"sign_workers" env:"SIGN_WORKERS"`
+	SignQueue   int `json:"sign_queue" env:"SIGN_QUEUE"`
+	// SignTimeoutSeconds bounds the time a request waits for and spends on signing
+	SignTimeoutSeconds int `json:"sign_timeout_seconds" env:"SIGN_TIMEOUT_SECONDS"`
+	// MaxBodyKB limits the request body of certificate JSON endpoints,
+	// MaxUploadMB the larger one of bulk imports and file uploads
+	MaxBodyKB   int `json:"max_body_kb" env:"MAX_BODY_KB"`
+	MaxUploadMB int `json:"max_upload_mb" env:"MAX_UPLOAD_MB"`
+}
+
+// KeyEncryption configures the key encryption key protecting private keys stored in the database.
+// The Vault transit key is used when VaultAddr is set, otherwise the base64 encoded 256-bit MasterKey.
+// Losing the key makes the stored private keys unreadable
+type KeyEncryption struct {
+	MasterKey         string `json:"master_key" env:"MASTER_KEY"`
+	PreviousMasterKey string `json:"previous_master_key" env:"PREVIOUS_MASTER_KEY"`
+	VaultAddr         string `json:"vault_addr" env:"VAULT_ADDR"`
+	VaultToken        string `json:"vault_token" env:"VAULT_TOKEN"`
+	VaultKey          string `json:"vault_key" env:"VAULT_KEY"`
+}
+
 type SFTP struct {
 	Enable bool   `json:"enable" env:"ENABLE"`
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
 type Config struct {
-	Force                 bool        `json:"force" env:"FORCE"`
-	SiteURL               string      `json:"site_url" env:"SITE_URL"`
-	Cdn                   string      `json:"cdn" env:"CDN"`
-	JwtSecret             string      `json:"jwt_secret" env:"JWT_SECRET"`
-	TokenExpiresIn        int         `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
-	Database              Database    `json:"database" envPrefix:"DB_"`
-	Meilisearch           Meilisearch `json:"meilisearch" envPrefix:"MEILISEARCH_"`
-	Scheme                Scheme      `json:"scheme"`
-	TempDir               string      `json:"temp_dir" env:"TEMP_DIR"`
-	BleveDir              string      `json:"bleve_dir" env:"BLEVE_DIR"`
-	DistDir               string      `json:"dist_dir"`
-	Log                   LogConfig   `json:"log" envPrefix:"LOG_"`
-	DelayedStart          int         `json:"delayed_start" env:"DELAYED_START"`
-	MaxBufferLimit        int         `json:"max_buffer_limitMB" env:"MAX_BUFFER_LIMIT_MB"`
-	MmapThreshold         int         `json:"mmap_thresholdMB" env:"MMAP_THRESHOLD_MB"`
-	MaxConnections        int         `json:"max_connections" env:"MAX_CONNECTIONS"`
-	MaxConcurrency        int         `json:"max_concurrency" env:"MAX_CONCURRENCY"`
-	TlsInsecureSkipVerify bool        `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
-	Tasks                 TasksConfig `json:"tasks" envPrefix:"TASKS_"`
-	Cors                  Cors        `json:"cors" envPrefix:"CORS_"`
-	S3                    S3          `json:"s3" envPrefix:"S3_"`
-	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
-	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
-	LastLaunchedVersion   string      `json:"last_launched_version"`
+	Force                 bool              `json:"force" env:"FORCE"`
+	SiteURL               string            `json:"site_url" env:"SITE_URL"`
+	Cdn                   string            `json:"cdn" env:"CDN"`
+	JwtSecret             string            `json:"jwt_secret" env:"JWT_SECRET"`
+	TokenExpiresIn        int               `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
+	Database              Database          `json:"database" envPrefix:"DB_"`
+	Meilisearch           Meilisearch       `json:"meilisearch" envPrefix:"MEILISEARCH_"`
+	Scheme                Scheme            `json:"scheme"`
+	TempDir               string            `json:"temp_dir" env:"TEMP_DIR"`
+	BleveDir              string            `json:"bleve_dir" env:"BLEVE_DIR"`
+	DistDir               string            `json:"dist_dir"`
+	Log                   LogConfig         `json:"log" envPrefix:"LOG_"`
+	DelayedStart          int               `json:"delayed_start" env:"DELAYED_START"`
+	MaxBufferLimit        int               `json:"max_buffer_limitMB" env:"MAX_BUFFER_LIMIT_MB"`
+	MmapThreshold         int               `json:"mmap_thresholdMB" env:"MMAP_THRESHOLD_MB"`
+	MaxConnections        int               `json:"max_connections" env:"MAX_CONNECTIONS"`
+	MaxConcurrency        int               `json:"max_concurrency" env:"MAX_CONCURRENCY"`
+	TlsInsecureSkipVerify bool              `json:"tls_insecure_skip_verify" env:"TLS_INSECURE_SKIP_VERIFY"`
+	Tasks                 TasksConfig       `json:"tasks" envPrefix:"TASKS_"`
+	Cors                  Cors              `json:"cors" envPrefix:"CORS_"`
+	S3                    S3                `json:"s3" envPrefix:"S3_"`
+	FTP                   FTP               `json:"ftp" envPrefix:"FTP_"`
+	SFTP                  SFTP              `json:"sftp" envPrefix:"SFTP_"`
+	Admin                 AdminServer       `json:"admin" envPrefix:"ADMIN_"`
+	Certificate           CertificateConfig `json:"certificate" envPrefix:"CERTIFICATE_"`
+	KeyEncryption         KeyEncryption     `json:"key_encryption" envPrefix:"KEY_ENCRYPTION_"`
+	LastLaunchedVersion   string            `json:"last_launched_version"`
 }
 
 func DefaultConfig(dataDir string) *Config {
@@ -242,6 +284,21 @@ func DefaultConfig(dataDir string) *Config {
 			Enable: false,
 			Listen: ":5222",
 		},
+		Admin: AdminServer{
+			Enable: false,
+			Listen: "127.0.0.1:5245",
+		},
+		Certificate: CertificateConfig{
+			ExpiryCheckMinutes: 60,
+			SignWorkers:        4,
+			SignQueue:          64,
+			SignTimeoutSeconds: 30,
+			MaxBodyKB:          256,
+			MaxUploadMB:        64,
+		},
+		KeyEncryption: KeyEncryption{
+			MasterKey: base64.StdEncoding.EncodeToString(random.Bytes(32)),
+		},
 		LastLaunchedVersion: "",
 	}
 }
@@ -144,6 +144,105 @@ const (
 	StreamMaxClientUploadSpeed            = "max_client_upload_speed"
 	StreamMaxServerDownloadSpeed          = "max_server_download_speed"
 	StreamMaxServerUploadSpeed            = "max_server_upload_speed"
+	UserMaxConcurrency                    = "user_max_concurrency"
+	UserRateLimit                         = "user_rate_limit"
+
+	// notify
+	NotifyWebhookUrl   = "notify_webhook_url"
+	NotifySmtpHost     = "notify_smtp_host"
+	NotifySmtpPort     = "notify_smtp_port"
+	NotifySmtpUsername = "notify_smtp_username"
+	NotifySmtpPassword = "notify_smtp_password"
+	NotifySmtpFrom     = "notify_smtp_from"
+	NotifySmtpSSL      = "notify_smtp_ssl"
+	NotifySlackToken   = "notify_slack_token"
+	NotifySlackChannel = "notify_slack_channel"
+	NotifySlackSecret  = "notify_slack_signing_secret"
+	NotifyTelegramBot  = "notify_telegram_bot_token"
+	NotifyTelegramChat = "notify_telegram_chat_id"
+	NotifyFcmAccount   = "notify_fcm_service_account"
+	NotifyApnsKey      = "notify_apns_key"
+	NotifyApnsKeyID    = "notify_apns_key_id"
+	NotifyApnsTeamID   = "notify_apns_team_id"
+	NotifyApnsTopic    = "notify_apns_topic"
+	NotifyApnsSandbox  = "notify_apns_sandbox"
+	NotifySmsProvider  = "notify_sms_provider"
+	NotifySmsAccount   = "notify_sms_account"
+	NotifySmsSecret    = "notify_sms_secret"
+	NotifySmsFrom      = "notify_sms_from"
+	NotifySmsTemplate  = "notify_sms_template"
+	NotifyRouting      = "notify_severity_routing"
+
+	// certificate
+	CertApprovalRemindHours     = "cert_approval_remind_hours"
+	CertApprovalEscalateDays    = "cert_approval_escalate_days"
+	CertApprovalEscalationUsers = "cert_approval_escalation_users"
+	CertMentionGrantsAccess     = "cert_mention_grants_access"
+	CertApprovalLinkHours       = "cert_approval_link_hours"
+	CertDigestFrequency         = "cert_digest_frequency"
+	CertCalendarAlarmDays       = "cert_calendar_alarm_days"
+	CertDraftExpireDays         = "cert_draft_expire_days"
+	CertRequestExpireDays       = "cert_request_expire_days"
+	CertRevokedDownloadPolicy   = "cert_revoked_download_policy"
+	CertRevokedDownloadGrace    = "cert_revoked_download_grace_hours"
+	CertCABundleVersion         = "cert_ca_bundle_version"
+	CertCARotationBatchSize     = "cert_ca_rotation_batch_size"
+	CertAnomalyRequestSpike     = "cert_anomaly_request_spike"
+	CertAnomalyRejections       = "cert_anomaly_rejections"
+	CertAnomalyBusinessHours    = "cert_anomaly_business_hours"
+	CertAnomalyReviewers        = "cert_anomaly_reviewers"
+	CertApprovalChecklist       = "cert_approval_checklist"
+	CertApprovalJustification   = "cert_approval_require_justification"
+	CertRevocationWebhookSecret = "cert_revocation_webhook_secret"
+	CertEmailIntakeSecret       = "cert_email_intake_secret"
+	CertRenewOverlapDays        = "cert_renew_overlap_days"
+	CertTenantDailyRequests     = "cert_tenant_daily_requests"
+	CertTenantMaxCertificates   = "cert_tenant_max_certificates"
+	CertRevocationPublishPath   = "cert_revocation_publish_path"
+	CertAttachmentPath          = "cert_attachment_path"
+	CertCRLValidityHours        = "cert_crl_validity_hours"
+	CertCRLRefreshMinutes       = "cert_crl_refresh_minutes"
+	CertOCSPDelegatedSigner     = "cert_ocsp_delegated_signer"
+	CertSandboxEnabled          = "cert_sandbox_enabled"
+	CertSandboxValidityHours    = "cert_sandbox_validity_hours"
+	CertSandboxCACertificate    = "cert_sandbox_ca_certificate"
+	CertSandboxCAPrivateKey     = "cert_sandbox_ca_private_key"
+	CertTSACertificate          = "cert_tsa_certificate"
+	CertTSAPrivateKey           = "cert_tsa_private_key"
+	CertTSAPolicy               = "cert_tsa_policy"
+	CertSignPerHour             = "cert_sign_per_hour"
+	CertJWTCertificate          = "cert_jwt_certificate"
+	CertJWTIssuer               = "cert_jwt_issuer"
+	CertJWTMaxTTL               = "cert_jwt_max_ttl"
+	CertSSHCAPublicKey          = "cert_ssh_ca_public_key"
+	CertKeyAlgorithm            = "cert_key_algorithm"
+	CertLintBlockSeverity       = "cert_lint_block_severity"
+	CertExpiringWindowDays      = "cert_expiring_window_days"
+	CertExpiryNotifyDays        = "cert_expiry_notify_days"
+	CertACMEDirectoryURL        = "cert_acme_directory_url"
+	CertACMEEmail               = "cert_acme_email"
+	CertACMEAccountKey          = "cert_acme_account_key"
+	CertACMEChallenge           = "cert_acme_challenge"
+	CertACMEDNSWebhook          = "cert_acme_dns_webhook"
+	CertTicketSystem            = "cert_ticket_system"
+	CertTicketBaseURL           = "cert_ticket_base_url"
+	CertTicketUsername          = "cert_ticket_username"
+	CertTicketToken             = "cert_ticket_token"
+	CertTicketProject           = "cert_ticket_project"
+	CertTLSCertificateID        = "cert_tls_certificate_id"
+
+	// audit
+	AuditSyslogAddr        = "audit_syslog_addr"
+	AuditFilePath          = "audit_file_path"
+	AuditHttpUrl           = "audit_http_url"
+	AuditHttpAuthorization = "audit_http_authorization"
+	AuditSyslogFormat      = "audit_syslog_format"
+	AuditFileFormat        = "audit_file_format"
+	AuditHttpFormat        = "audit_http_format"
+	AuditBufferSize        = "audit_buffer_size"
+
+	// backup
+	BackupRecipients = "backup_recipients"
 )
 
 const (
@@ -173,4 +272,6 @@ const (
 	UserAgentKey
 	PathKey
 	SharingIDKey
+	ImpersonatorKey
+	APIVersionKey
 )
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// DumpTables reads the raw columns of every row of the migrated tables through a cursor and passes
+// them to fn in batches of at most batchSize rows, fn is called once with no rows for an empty table
+// and must not keep rows after it returns. Columns hidden from the api such as password hashes are
+// included. Rows are read from a read replica when one is configured
+func DumpTables(batchSize int, fn func(table string, rows []map[string]any) error) error {
+	rdb := readDB()
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return errors.WithStack(err)
+		}
+		if err := dumpTable(rdb, stmt.Schema.Table, batchSize, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func dumpTable(rdb *gorm.DB, table string, batchSize int, fn func(table string, rows []map[string]any) error) error {
+	cursor, err := rdb.Table(table).Rows()
+	if err != nil {
+		return errors.Wrapf(err, "failed get rows of %s", table)
+	}
+	defer cursor.Close()
+	batch := make([]map[string]any, 0, batchSize)
+	sent := false
+	for cursor.Next() {
+		row := map[string]any{}
+		if err := rdb.ScanRows(cursor, &row); err != nil {
+			return errors.Wrapf(err, "failed scan row of %s", table)
+		}
+		batch = append(batch, row)
+		if len(batch) < batchSize {
+			continue
+		}
+		if err := fn(table, batch); err != nil {
+			return err
+		}
+		batch, sent = batch[:0], true
+	}
+	if err := cursor.Err(); err != nil {
+		return errors.Wrapf(err, "failed get rows of %s", table)
+	}
+	if len(batch) > 0 || !sent {
+		return fn(table, batch)
+	}
+	return nil
+}
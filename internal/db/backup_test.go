@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestDumpTablesBatches(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(models...); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := db.Create(&model.User{Username: fmt.Sprintf("dump-%d", i)}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	tableName := func(m any) string {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			t.Fatal(err)
+		}
+		return stmt.Schema.Table
+	}
+	batches := map[string][]int{}
+	err := DumpTables(2, func(table string, rows []map[string]any) error {
+		batches[table] = append(batches[table], len(rows))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		table string
+		want  []int
+	}{
+		{table: tableName(new(model.User)), want: []int{2, 2, 1}},
+		{table: tableName(new(model.Certificate)), want: []int{0}},
+	}
+	for _, tt := range tests {
+		if !slices.Equal(batches[tt.table], tt.want) {
+			t.Errorf("%s: got batches %v, want %v", tt.table, batches[tt.table], tt.want)
+		}
+	}
+	if len(batches) != len(models) {
+		t.Errorf("dumped %d tables, want %d", len(batches), len(models))
+	}
+}
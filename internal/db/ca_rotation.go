@@ -0,0 +1,102 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetCARotations() ([]model.CARotation, error) {
+	var rotations []model.CARotation
+	if err := db.Order(fmt.Sprintf("%s DESC", columnName("id"))).Find(&rotations).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ca rotations")
+	}
+	return rotations, nil
+}
+
+func GetRunningCARotations() ([]model.CARotation, error) {
+	var rotations []model.CARotation
+	if err := db.Where("status = ?", model.CARotationRunning).Order(columnName("id")).Find(&rotations).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get running ca rotations")
+	}
+	return rotations, nil
+}
+
+func GetCARotationByID(id uint) (*model.CARotation, error) {
+	var r model.CARotation
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ca rotation by id: %d", id)
+	}
+	return &r, nil
+}
+
+func CreateCARotation(r *model.CARotation) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateCARotation(r *model.CARotation) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+// GetActiveCertificatesByIssuer 获取由指定 CA 签发的有效证书
+func GetActiveCertificatesByIssuer(caID uint, limit int) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("issuer_id = ? AND status IN ?", caID,
+		[]model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring}).
+		Order(columnName("expiration_date")).Limit(limit).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates issued by ca %d", caID)
+	}
+	return certs, nil
+}
+
+// CountActiveCertificatesByIssuer 统计由指定 CA 签发的有效证书数量
+func CountActiveCertificatesByIssuer(caID uint) (int64, error) {
+	var count int64
+	if err := db.Model(&model.Certificate{}).Where("issuer_id = ? AND status IN ?", caID,
+		[]model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring}).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count certificates issued by ca %d", caID)
+	}
+	return count, nil
+}
+
+// ReplaceCertificate 在一个事务中创建替代证书并更新旧证书
+func ReplaceCertificate(old, replacement *model.Certificate) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(replacement).Error; err != nil {
+			return errors.Wrap(err, "failed create replacement certificate")
+		}
+		if err := tx.Save(old).Error; err != nil {
+			return errors.Wrap(err, "failed update replaced certificate")
+		}
+		return nil
+	}))
+}
+
+// RenewCertificate 在一个事务中创建续期证书并将旧证书关联到续期证书，旧证书保持有效
+func RenewCertificate(old, renewal *model.Certificate) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(renewal).Error; err != nil {
+			return errors.Wrap(err, "failed create renewal certificate")
+		}
+		old.SupersededByID = renewal.ID
+		if err := tx.Model(old).UpdateColumn("superseded_by_id", renewal.ID).Error; err != nil {
+			return errors.Wrap(err, "failed update renewed certificate")
+		}
+		return nil
+	}))
+}
+
+// GetPublishableCertificatesByIssuer 获取 CA 签发的、已解析出序列号且尚未过期的证书，用于生成 CRL 和 OCSP 响应
+func GetPublishableCertificatesByIssuer(caID uint) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("issuer_id = ? AND serial_number <> '' AND status IN ? AND expiration_date > ?", caID,
+		[]model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring, model.CertificateStatusRevoked, model.CertificateStatusHold}, time.Now()).
+		Order(columnName("id")).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates issued by ca %d", caID)
+	}
+	return certs, nil
+}
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetCAState 读取 CA 的完整状态，包含已删除的记录，导出时从只读副本读取
+func GetCAState() (*model.CAState, error) {
+	var state model.CAState
+	tx := readDB().Unscoped()
+	if err := tx.Find(&state.Certificates).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates")
+	}
+	if err := tx.Find(&state.Requests).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate requests")
+	}
+	if err := tx.Find(&state.Events).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate events")
+	}
+	if err := tx.Find(&state.Types).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate types")
+	}
+	if err := tx.Find(&state.Fields).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request fields")
+	}
+	if err := tx.Find(&state.Authorities).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate authorities")
+	}
+	for _, cert := range state.Certificates {
+		if cert.HasPrivateKey() {
+			if state.CertificateKeys == nil {
+				state.CertificateKeys = map[uint]string{}
+			}
+			state.CertificateKeys[cert.ID] = cert.PrivateKey
+		}
+	}
+	for _, ca := range state.Authorities {
+		if ca.HasPrivateKey() {
+			if state.AuthorityKeys == nil {
+				state.AuthorityKeys = map[string]string{}
+			}
+			state.AuthorityKeys[ca.Fingerprint] = ca.PrivateKey
+		}
+	}
+	return &state, nil
+}
+
+// CountAllCertificates 统计证书数量，包含已删除的证书
+func CountAllCertificates() (int64, error) {
+	var count int64
+	if err := db.Unscoped().Model(&model.Certificate{}).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count certificates")
+	}
+	return count, nil
+}
+
+// RestoreCAState 在一个事务中写入导出的 CA 状态，保留证书、申请和事件原有的 ID，
+// 类型和字段定义按名称覆盖初始化时生成的默认值，CA 按指纹合并到目标实例，
+// 证书的签发者和交叉证书引用改为目标实例中对应 CA 的 ID
+func RestoreCAState(state *model.CAState) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if len(state.Authorities) > 0 {
+			ids, err := restoreCertificateAuthorities(tx, state.Authorities)
+			if err != nil {
+				return err
+			}
+			for i := range state.Certificates {
+				state.Certificates[i].IssuerID = ids[state.Certificates[i].IssuerID]
+			}
+		}
+		if len(state.Certificates) > 0 {
+			if err := tx.CreateInBatches(state.Certificates, 100).Error; err != nil {
+				return errors.Wrap(err, "failed restore certificates")
+			}
+		}
+		if len(state.Requests) > 0 {
+			if err := tx.CreateInBatches(state.Requests, 100).Error; err != nil {
+				return errors.Wrap(err, "failed restore certificate requests")
+			}
+		}
+		if len(state.Events) > 0 {
+			if err := tx.CreateInBatches(state.Events, 100).Error; err != nil {
+				return errors.Wrap(err, "failed restore certificate events")
+			}
+		}
+		if len(state.Types) > 0 {
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "name"}},
+				UpdateAll: true,
+			}).Create(state.Types).Error
+			if err != nil {
+				return errors.Wrap(err, "failed restore certificate types")
+			}
+		}
+		if len(state.Fields) > 0 {
+			err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(state.Fields).Error
+			if err != nil {
+				return errors.Wrap(err, "failed restore certificate request fields")
+			}
+		}
+		return nil
+	}))
+}
+
+// restoreCertificateAuthorities 按指纹写入 CA，返回导出时的 ID 到目标实例中 ID 的映射
+func restoreCertificateAuthorities(tx *gorm.DB, authorities []model.CertificateAuthority) (map[uint]uint, error) {
+	oldIDs := make(map[string]uint, len(authorities))
+	fingerprints := make([]string, 0, len(authorities))
+	for i := range authorities {
+		oldIDs[authorities[i].Fingerprint] = authorities[i].ID
+		fingerprints = append(fingerprints, authorities[i].Fingerprint)
+		authorities[i].ID = 0
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fingerprint"}},
+		UpdateAll: true,
+	}).Create(authorities).Error
+	if err != nil {
+		return nil, errors.Wrap(err, "failed restore certificate authorities")
+	}
+	var restored []model.CertificateAuthority
+	if err := tx.Where("fingerprint IN ?", fingerprints).Find(&restored).Error; err != nil {
+		return nil, errors.Wrap(err, "failed get restored certificate authorities")
+	}
+	ids := make(map[uint]uint, len(restored))
+	for _, ca := range restored {
+		ids[oldIDs[ca.Fingerprint]] = ca.ID
+	}
+	for i := range authorities {
+		authorities[i].ID = ids[oldIDs[authorities[i].Fingerprint]]
+		if authorities[i].CrossSignOf == 0 {
+			continue
+		}
+		authorities[i].CrossSignOf = ids[authorities[i].CrossSignOf]
+		err := tx.Model(&model.CertificateAuthority{}).Where("id = ?", authorities[i].ID).
+			UpdateColumn("cross_sign_of", authorities[i].CrossSignOf).Error
+		if err != nil {
+			return nil, errors.Wrap(err, "failed restore cross signed certificate authorities")
+		}
+	}
+	return ids, nil
+}
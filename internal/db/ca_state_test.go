@@ -0,0 +1,63 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestRestoreCAStateRemapsAuthorities(t *testing.T) {
+	setupTestDB(t)
+	newCA := func(id uint, fingerprint string, crossSignOf uint) model.CertificateAuthority {
+		return model.CertificateAuthority{ID: id, Name: fingerprint, Kind: model.CertificateAuthorityRoot,
+			Status: model.CertificateAuthorityActive, Fingerprint: fingerprint, CrossSignOf: crossSignOf}
+	}
+	// 目标实例已有一个 CA，占用了导出文件中的 ID 1
+	existing := newCA(0, "existing", 0)
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatal(err)
+	}
+	state := &model.CAState{
+		Authorities: []model.CertificateAuthority{newCA(1, "root", 0), newCA(2, "cross", 1)},
+		Certificates: []model.Certificate{
+			{ID: 10, Name: "issued", Type: model.CertificateTypeUser, Status: model.CertificateStatusValid, IssuerID: 2, ExpirationDate: time.Now().AddDate(1, 0, 0)},
+			{ID: 11, Name: "imported", Type: model.CertificateTypeUser, Status: model.CertificateStatusValid, ExpirationDate: time.Now().AddDate(1, 0, 0)},
+		},
+	}
+	if err := RestoreCAState(state); err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]uint{}
+	var cas []model.CertificateAuthority
+	if err := db.Find(&cas).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, ca := range cas {
+		ids[ca.Fingerprint] = ca.ID
+	}
+	for _, ca := range cas {
+		if ca.Fingerprint == "cross" && ca.CrossSignOf != ids["root"] {
+			t.Errorf("cross sign of %d, want %d", ca.CrossSignOf, ids["root"])
+		}
+	}
+	tests := []struct {
+		id     uint
+		issuer uint
+	}{
+		{id: 10, issuer: ids["cross"]},
+		{id: 11, issuer: 0},
+	}
+	for _, tt := range tests {
+		cert, err := GetCertificateByID(tt.id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cert.IssuerID != tt.issuer {
+			t.Errorf("certificate %d: got issuer %d, want %d", tt.id, cert.IssuerID, tt.issuer)
+		}
+	}
+	if ids["root"] == existing.ID || ids["cross"] == existing.ID {
+		t.Errorf("restored authorities overwrote the existing one: %v", ids)
+	}
+}
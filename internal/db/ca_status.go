@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCAMaintenanceWindows(pageIndex, pageSize int) (windows []model.CAMaintenanceWindow, count int64, err error) {
+	windowDB := db.Model(&model.CAMaintenanceWindow{})
+	if err := windowDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get maintenance windows count")
+	}
+	if err := windowDB.Order(fmt.Sprintf("%s DESC", columnName("start_at"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&windows).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find maintenance windows")
+	}
+	return windows, count, nil
+}
+
+// GetUpcomingCAMaintenanceWindows 获取正在进行或尚未开始的维护窗口
+func GetUpcomingCAMaintenanceWindows(t time.Time) ([]model.CAMaintenanceWindow, error) {
+	var windows []model.CAMaintenanceWindow
+	if err := db.Where("end_at > ?", t).Order(columnName("start_at")).Find(&windows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get upcoming maintenance windows")
+	}
+	return windows, nil
+}
+
+func GetCAMaintenanceWindowByID(id uint) (*model.CAMaintenanceWindow, error) {
+	var w model.CAMaintenanceWindow
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get maintenance window by id: %d", id)
+	}
+	return &w, nil
+}
+
+func CreateCAMaintenanceWindow(w *model.CAMaintenanceWindow) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateCAMaintenanceWindow(w *model.CAMaintenanceWindow) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func DeleteCAMaintenanceWindow(id uint) error {
+	return errors.WithStack(db.Delete(&model.CAMaintenanceWindow{}, id).Error)
+}
@@ -2,22 +2,28 @@ package db
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 // --- Certificate Functions ---
 
-func GetCertificates(pageIndex, pageSize int) (certs []model.Certificate, count int64, err error) {
-	certDB := db.Model(&model.Certificate{})
+func GetCertificates(pageIndex, pageSize int, filter *model.CertificateFilter) (certs []model.Certificate, count int64, err error) {
+	rdb := readDB()
+	certDB := filterCertificates(rdb.Model(&model.Certificate{}), filter)
 	if err := certDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get certificates count")
 	}
-	if err := certDB.Order(fmt.Sprintf("%s DESC", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&certs).Error; err != nil {
+	if err := certDB.Order(certificateOrder(filter)).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&certs).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed find certificates")
 	}
+	if err := preloadCertificateDetails(rdb, certs); err != nil {
+		return nil, 0, err
+	}
 	return certs, count, nil
 }
 
@@ -29,24 +35,44 @@ func GetCertificateByID(id uint) (*model.Certificate, error) {
 	return &cert, nil
 }
 
-// GetCertificateByOwnerID 根据所有者ID获取证书，这是租户端查询的核心
+// activeOwnerCertificates 租户名下状态为 valid 或 expiring 且未过期的证书，沙箱证书不计入
+func activeOwnerCertificates(ownerID uint) *gorm.DB {
+	return db.Model(&model.Certificate{}).Where("owner_id = ? AND sandbox = ? AND (status = ? OR status = ?) AND expiration_date > ?",
+		ownerID, false, model.CertificateStatusValid, model.CertificateStatusExpiring, time.Now())
+}
+
+// GetCertificateByOwnerID 获取租户到期最晚的有效证书，续期重叠期内即为新证书。
+// 租户可以同时持有多个有效证书，未指定证书的租户操作使用该证书
 func GetCertificateByOwnerID(ownerID uint) (*model.Certificate, error) {
 	var cert model.Certificate
-	// 一个租户只应该有一个有效证书，所以使用 First
-	// 只查询状态为 valid 或 expiring 且未过期的证书
-	if err := db.Where("owner_id = ? AND (status = ? OR status = ?) AND expiration_date > ?",
-		ownerID, model.CertificateStatusValid, model.CertificateStatusExpiring, time.Now()).First(&cert).Error; err != nil {
+	if err := activeOwnerCertificates(ownerID).Order(columnName("expiration_date") + " DESC").First(&cert).Error; err != nil {
 		return nil, err // GORM 会在找不到记录时返回 ErrRecordNotFound
 	}
 	return &cert, nil
 }
 
+// GetCertificatesByOwnerID 获取租户的所有有效证书，按到期时间从晚到早排列
+func GetCertificatesByOwnerID(ownerID uint) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := activeOwnerCertificates(ownerID).Order(columnName("expiration_date") + " DESC").Find(&certs).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return certs, nil
+}
+
+// CountActiveCertificatesByOwnerID 统计租户占用配额的有效证书数量，已被续期证书取代的旧证书不计入
+func CountActiveCertificatesByOwnerID(ownerID uint) (int64, error) {
+	var count int64
+	err := activeOwnerCertificates(ownerID).Where("superseded_by_id = ?", 0).Count(&count).Error
+	return count, errors.WithStack(err)
+}
+
 func CreateCertificate(cert *model.Certificate) error {
-	return errors.WithStack(db.Create(cert).Error)
+	return Tx{tx: db}.CreateCertificate(cert)
 }
 
 func UpdateCertificate(cert *model.Certificate) error {
-	return errors.WithStack(db.Save(cert).Error)
+	return Tx{tx: db}.UpdateCertificate(cert)
 }
 
 func DeleteCertificate(id uint) error {
@@ -55,14 +81,23 @@ func DeleteCertificate(id uint) error {
 
 // --- CertificateRequest Functions ---
 
-func GetCertificateRequests(pageIndex, pageSize int) (reqs []model.CertificateRequest, count int64, err error) {
-	reqDB := db.Model(&model.CertificateRequest{})
+// GetCertificateRequests 分页获取申请，assignee 不为 nil 时只返回指派给该审批人的申请，为空字符串时只返回未认领的申请
+func GetCertificateRequests(pageIndex, pageSize int, assignee *string) (reqs []model.CertificateRequest, count int64, err error) {
+	// 草稿只对申请人可见
+	rdb := readDB()
+	reqDB := rdb.Model(&model.CertificateRequest{}).Where(fmt.Sprintf("%s <> ?", columnName("status")), model.CertificateStatusDraft)
+	if assignee != nil {
+		reqDB = reqDB.Where(fmt.Sprintf("%s = ?", columnName("assignee")), *assignee)
+	}
 	if err := reqDB.Count(&count).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed get certificate requests count")
 	}
 	if err := reqDB.Order(fmt.Sprintf("%s DESC", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&reqs).Error; err != nil {
 		return nil, 0, errors.Wrapf(err, "failed find certificate requests")
 	}
+	if err := preloadCertificateRequestDetails(rdb, reqs); err != nil {
+		return nil, 0, err
+	}
 	return reqs, count, nil
 }
 
@@ -86,16 +121,290 @@ func GetCertificateRequestsByUserID(userID uint) ([]model.CertificateRequest, er
 // GetPendingCertificateRequestByUserID 检查用户是否已有待处理的申请
 func GetPendingCertificateRequestByUserID(userID uint) (*model.CertificateRequest, error) {
 	var request model.CertificateRequest
-	if err := db.Where("user_id = ? AND status = ?", userID, model.CertificateStatusPending).First(&request).Error; err != nil {
+	// 等待计划签发的申请同样视为处理中
+	if err := db.Where("user_id = ? AND status IN ?", userID, []model.CertificateStatus{model.CertificateStatusPending, model.CertificateStatusScheduled}).First(&request).Error; err != nil {
 		return nil, err
 	}
 	return &request, nil
 }
 
 func CreateCertificateRequest(req *model.CertificateRequest) error {
-	return errors.WithStack(db.Create(req).Error)
+	return Tx{tx: db}.CreateCertificateRequest(req)
 }
 
 func UpdateCertificateRequest(req *model.CertificateRequest) error {
-	return errors.WithStack(db.Save(req).Error)
-}
\ No newline at end of file
+	return Tx{tx: db}.UpdateCertificateRequest(req)
+}
+
+func DeleteCertificateRequest(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateRequest{}, id).Error)
+}
+
+// GetPendingCertificateRequestsBefore 获取在指定时间之前提交且仍未审批的申请
+func GetPendingCertificateRequestsBefore(t time.Time) ([]model.CertificateRequest, error) {
+	var requests []model.CertificateRequest
+	if err := db.Where("status = ? AND created_at < ?", model.CertificateStatusPending, t).Order(columnName("id")).Find(&requests).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get pending certificate requests")
+	}
+	return requests, nil
+}
+
+// CountActiveCertificatesByType 统计某类型当前有效的证书数量
+func CountActiveCertificatesByType(t model.CertificateType) (int64, error) {
+	var count int64
+	if err := db.Model(&model.Certificate{}).Where("type = ? AND sandbox = ? AND (status = ? OR status = ?) AND expiration_date > ?",
+		t, false, model.CertificateStatusValid, model.CertificateStatusExpiring, time.Now()).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count certificates of type: %s", t)
+	}
+	return count, nil
+}
+
+// GetCertificatesExpiringBefore 获取在指定时间前到期的有效证书，不含沙箱证书，按到期时间排序
+func GetCertificatesExpiringBefore(t time.Time) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("(status = ? OR status = ?) AND sandbox = ? AND expiry_bucket <= ? AND expiration_date > ? AND expiration_date <= ?",
+		model.CertificateStatusValid, model.CertificateStatusExpiring, false, expiryBucketBefore(t), time.Now(), t).
+		Order("expiration_date").Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates expiring before %s", t)
+	}
+	return certs, nil
+}
+
+// GetActiveCertificates 获取未过期的有效证书，ownerID 为 0 时返回所有用户的证书
+func GetActiveCertificates(ownerID uint) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	query := db.Where("(status = ? OR status = ?) AND expiration_date > ?",
+		model.CertificateStatusValid, model.CertificateStatusExpiring, time.Now())
+	if ownerID != 0 {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	if err := query.Order("expiration_date").Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get active certificates")
+	}
+	return certs, nil
+}
+
+// GetCertificatesByIDs 根据ID获取证书，包括已删除的证书
+func GetCertificatesByIDs(ids []uint) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if len(ids) == 0 {
+		return certs, nil
+	}
+	if err := db.Unscoped().Where("id IN ?", ids).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates by ids")
+	}
+	return certs, nil
+}
+
+// GetCertificateIDsByOwnerID 获取用户所有证书的ID，包括已删除的证书
+func GetCertificateIDsByOwnerID(ownerID uint) ([]uint, error) {
+	ids := make([]uint, 0)
+	if err := db.Unscoped().Model(&model.Certificate{}).Where("owner_id = ?", ownerID).Pluck("id", &ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate ids of owner id: %d", ownerID)
+	}
+	return ids, nil
+}
+
+// GetCertificatesExpiredBetween 获取在时间段内到期且未被吊销的证书，ownerID 为 0 时不限用户
+func GetCertificatesExpiredBetween(ownerID uint, from, to time.Time) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	query := db.Where("status <> ? AND expiration_date > ? AND expiration_date <= ?",
+		model.CertificateStatusRevoked, from, to)
+	if ownerID != 0 {
+		query = query.Where("owner_id = ?", ownerID)
+	}
+	if err := query.Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get expired certificates")
+	}
+	return certs, nil
+}
+
+// DeleteCertificateRequestDraftsBefore 删除在指定时间之后没有更新过的草稿，跳过 excludeUserIDs 中用户的草稿
+func DeleteCertificateRequestDraftsBefore(t time.Time, excludeUserIDs []uint) (int64, error) {
+	query := db.Where(fmt.Sprintf("%s = ? AND %s < ?", columnName("status"), columnName("updated_at")), model.CertificateStatusDraft, t)
+	if len(excludeUserIDs) > 0 {
+		query = query.Where("user_id NOT IN ?", excludeUserIDs)
+	}
+	res := query.Delete(&model.CertificateRequest{})
+	return res.RowsAffected, errors.Wrapf(res.Error, "failed delete stale certificate request drafts")
+}
+
+// GetScheduledCertificateRequestsBefore 获取计划签发时间已到的申请
+func GetScheduledCertificateRequestsBefore(t time.Time) ([]model.CertificateRequest, error) {
+	var requests []model.CertificateRequest
+	if err := db.Where(fmt.Sprintf("%s = ? AND %s <= ?", columnName("status"), columnName("scheduled_at")), model.CertificateStatusScheduled, t).
+		Order(columnName("scheduled_at")).Find(&requests).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get scheduled certificate requests")
+	}
+	return requests, nil
+}
+
+// GetLatestRevokedCertificateByOwnerID 获取所有者最近吊销的证书
+func GetLatestRevokedCertificateByOwnerID(ownerID uint) (*model.Certificate, error) {
+	var cert model.Certificate
+	if err := db.Where("owner_id = ? AND status = ?", ownerID, model.CertificateStatusRevoked).
+		Order(fmt.Sprintf("%s DESC", columnName("updated_at"))).First(&cert).Error; err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// CountCertificateRequestsByUserSince 统计用户在指定时间之后提交的申请数量，不含草稿和沙箱申请
+func CountCertificateRequestsByUserSince(userID uint, t time.Time) (int64, error) {
+	var count int64
+	if err := db.Model(&model.CertificateRequest{}).Where("user_id = ? AND status <> ? AND sandbox = ? AND created_at >= ?", userID, model.CertificateStatusDraft, false, t).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count certificate requests")
+	}
+	return count, nil
+}
+
+// CountRejectedCertificateRequestsByUserSince 统计用户在指定时间之后被拒绝的申请数量
+func CountRejectedCertificateRequestsByUserSince(userID uint, t time.Time) (int64, error) {
+	var count int64
+	if err := db.Model(&model.CertificateRequest{}).Where("user_id = ? AND status = ? AND rejected_at >= ?", userID, model.CertificateStatusRejected, t).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count rejected certificate requests")
+	}
+	return count, nil
+}
+
+// GetCertificateBySerialOrFingerprint 按序列号或指纹查找证书，两者都提供时必须同时匹配，都为空时返回 gorm.ErrRecordNotFound
+func GetCertificateBySerialOrFingerprint(serial, fingerprint string) (*model.Certificate, error) {
+	if serial == "" && fingerprint == "" {
+		return nil, errors.WithStack(gorm.ErrRecordNotFound)
+	}
+	var cert model.Certificate
+	query := db.Model(&model.Certificate{})
+	if serial != "" {
+		query = query.Where("serial_number = ?", serial)
+	}
+	if fingerprint != "" {
+		query = query.Where("fingerprint = ?", fingerprint)
+	}
+	if err := query.First(&cert).Error; err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// GetCertificatesToRenew 获取在指定时间之前到期、开启了自动续期且尚未续期的有效证书
+func GetCertificatesToRenew(before time.Time) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("status IN ? AND auto_renew = ? AND superseded_by_id = 0 AND expiry_bucket <= ? AND expiration_date > ? AND expiration_date <= ?",
+		[]model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring}, true, expiryBucketBefore(before), time.Now(), before).
+		Order(columnName("expiration_date")).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates to renew")
+	}
+	return certs, nil
+}
+
+// GetCertificateRequestTimesByUserSince 获取用户在指定时间之后提交申请的时间，不含草稿和沙箱申请，按时间升序
+func GetCertificateRequestTimesByUserSince(userID uint, t time.Time) ([]time.Time, error) {
+	var times []time.Time
+	if err := db.Model(&model.CertificateRequest{}).Where("user_id = ? AND status <> ? AND sandbox = ? AND created_at >= ?", userID, model.CertificateStatusDraft, false, t).
+		Order(columnName("created_at")).Pluck("created_at", &times).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request times")
+	}
+	return times, nil
+}
+
+// GetCertificatesExpiringByStatus 获取到期时间早于 before 的有效和即将过期证书，用于更新到期状态
+func GetCertificatesExpiringByStatus(before time.Time) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("status IN ? AND expiration_date < ?",
+		[]model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring}, before).
+		Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates by expiration")
+	}
+	return certs, nil
+}
+
+// UpdateCertificateStatus 只在证书仍为 from 状态时更新为 to，避免覆盖同时发生的吊销等操作，
+// 返回是否更新了证书
+func UpdateCertificateStatus(id uint, from, to model.CertificateStatus) (bool, error) {
+	res := db.Model(&model.Certificate{}).Where("id = ? AND status = ?", id, from).
+		UpdateColumn("status", to)
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// SetCertificateAutoRenew 只更新证书的自动续期设置
+func SetCertificateAutoRenew(id uint, autoRenew bool) error {
+	return errors.WithStack(db.Model(&model.Certificate{ID: id}).UpdateColumn("auto_renew", autoRenew).Error)
+}
+
+// likeEscaper 转义 LIKE 中的通配符，转义字符使用 !，在各数据库中含义相同
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// filterCertificates 按过滤条件添加 WHERE 子句，调用方需先校验 filter
+func filterCertificates(tx *gorm.DB, filter *model.CertificateFilter) *gorm.DB {
+	if filter == nil {
+		return tx
+	}
+	if filter.Status != "" {
+		tx = tx.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		tx = tx.Where("type = ?", filter.Type)
+	}
+	if filter.Owner != "" {
+		tx = tx.Where("owner = ?", filter.Owner)
+	}
+	if filter.Name != "" {
+		tx = tx.Where("name LIKE ? ESCAPE '!'", "%"+likeEscaper.Replace(filter.Name)+"%")
+	}
+	if !filter.ExpiresAfter.IsZero() {
+		tx = tx.Where("expiration_date >= ?", filter.ExpiresAfter)
+	}
+	if !filter.ExpiresBefore.IsZero() {
+		tx = tx.Where("expiration_date < ?", filter.ExpiresBefore)
+	}
+	return tx
+}
+
+// certificateOrder 返回证书列表的排序子句，排序字段相同时按 id 排序保证分页稳定
+func certificateOrder(filter *model.CertificateFilter) string {
+	orderBy, direction := "id", "DESC"
+	if filter != nil {
+		if filter.OrderBy != "" {
+			orderBy, direction = filter.OrderBy, "ASC"
+		}
+		if filter.OrderDirection != "" {
+			direction = strings.ToUpper(filter.OrderDirection)
+		}
+	}
+	if orderBy == "id" {
+		return fmt.Sprintf("%s %s", columnName("id"), direction)
+	}
+	return fmt.Sprintf("%s %s, %s %s", columnName(orderBy), direction, columnName("id"), direction)
+}
+
+// GetCertificateByIssuerAndSerial 按签发 CA 和十六进制序列号查找证书，用于 OCSP 查询
+func GetCertificateByIssuerAndSerial(issuerID uint, serial string) (*model.Certificate, error) {
+	var cert model.Certificate
+	if err := db.Where("serial_number = ? AND issuer_id = ?", serial, issuerID).
+		Order(fmt.Sprintf("%s DESC", columnName("id"))).First(&cert).Error; err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// MarkCertificateRequestReminded 只更新仍待审批的申请的提醒时间，escalatedAt 为 nil 时不修改升级时间，
+// 不会覆盖同时进行的审批或拒绝
+func MarkCertificateRequestReminded(id uint, remindedAt time.Time, escalatedAt *time.Time) error {
+	columns := map[string]any{"reminded_at": remindedAt}
+	if escalatedAt != nil {
+		columns["escalated_at"] = *escalatedAt
+	}
+	return errors.WithStack(db.Model(&model.CertificateRequest{}).Where("id = ? AND status = ?", id, model.CertificateStatusPending).
+		UpdateColumns(columns).Error)
+}
+
+// UpdateCertificateRequestTicket 只更新申请关联的外部工单，不影响同时进行的审批
+func UpdateCertificateRequestTicket(id uint, ticketID, ticketURL string) error {
+	return errors.WithStack(db.Model(&model.CertificateRequest{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"ticket_id": ticketID, "ticket_url": ticketURL}).Error)
+}
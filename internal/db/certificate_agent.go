@@ -0,0 +1,71 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateAgents() ([]model.CertificateAgent, error) {
+	var agents []model.CertificateAgent
+	if err := db.Order(columnName("id")).Find(&agents).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate agents")
+	}
+	return agents, nil
+}
+
+func GetCertificateAgentByID(id uint) (*model.CertificateAgent, error) {
+	var a model.CertificateAgent
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate agent by id: %d", id)
+	}
+	return &a, nil
+}
+
+func GetCertificateAgentByTokenHash(hash string) (*model.CertificateAgent, error) {
+	var a model.CertificateAgent
+	if err := db.Where("token_hash = ?", hash).First(&a).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate agent by token")
+	}
+	return &a, nil
+}
+
+func CreateCertificateAgent(a *model.CertificateAgent) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+func UpdateCertificateAgent(a *model.CertificateAgent) error {
+	return errors.WithStack(db.Save(a).Error)
+}
+
+// TouchCertificateAgent 更新代理的最后在线时间和地址
+func TouchCertificateAgent(id uint, ip string, t time.Time) error {
+	return errors.WithStack(db.Model(&model.CertificateAgent{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"last_ip": ip, "last_seen_at": t}).Error)
+}
+
+func DeleteCertificateAgent(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateAgent{}, id).Error)
+}
+
+// GetCertificateEventsAfter 按 ID 顺序获取指定事件之后的证书事件
+func GetCertificateEventsAfter(certIDs []uint, afterID uint, events []string, limit int) ([]model.CertificateEvent, error) {
+	var res []model.CertificateEvent
+	if len(certIDs) == 0 {
+		return res, nil
+	}
+	if err := db.Where("id > ? AND certificate_id IN ? AND event IN ?", afterID, certIDs, events).
+		Order(columnName("id")).Limit(limit).Find(&res).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate events after %d", afterID)
+	}
+	return res, nil
+}
+
+// GetLastCertificateEventID 获取最新的证书事件 ID
+func GetLastCertificateEventID() (uint, error) {
+	var e model.CertificateEvent
+	err := db.Order(fmt.Sprintf("%s DESC", columnName("id"))).Limit(1).Find(&e).Error
+	return e.ID, errors.WithStack(err)
+}
@@ -0,0 +1,28 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+// IsCertificateApprovalNonceUsed 检查一键审批链接是否已被使用
+func IsCertificateApprovalNonceUsed(nonce string) (bool, error) {
+	var count int64
+	if err := db.Model(&model.CertificateApprovalNonce{}).Where("nonce = ?", nonce).Count(&count).Error; err != nil {
+		return false, errors.Wrapf(err, "failed check certificate approval nonce")
+	}
+	return count > 0, nil
+}
+
+// UseCertificateApprovalNonce 在审批或拒绝的事务中标记一键审批链接已使用，链接已被使用过时返回 false
+func (t Tx) UseCertificateApprovalNonce(nonce string, requestID uint) (bool, error) {
+	res := t.tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.CertificateApprovalNonce{
+		Nonce:     nonce,
+		RequestID: requestID,
+	})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestUseCertificateApprovalNonceSingleUse(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.CertificateApprovalNonce)); err != nil {
+		t.Fatal(err)
+	}
+	errAction := errors.New("approve failed")
+	// 依次执行，后面的步骤依赖前面步骤的结果
+	steps := []struct {
+		name     string
+		nonce    string
+		fail     bool
+		wantOK   bool
+		wantUsed bool
+	}{
+		{name: "failed action keeps the link", nonce: "a", fail: true, wantOK: true, wantUsed: false},
+		{name: "retry after failure", nonce: "a", wantOK: true, wantUsed: true},
+		{name: "second use", nonce: "a", wantOK: false, wantUsed: true},
+		{name: "other link", nonce: "b", wantOK: true, wantUsed: true},
+	}
+	for _, s := range steps {
+		var ok bool
+		err := Transaction(func(tx Tx) error {
+			var err error
+			if ok, err = tx.UseCertificateApprovalNonce(s.nonce, 1); err != nil {
+				return err
+			}
+			if s.fail {
+				return errAction
+			}
+			return nil
+		})
+		if s.fail != errors.Is(err, errAction) || (!s.fail && err != nil) {
+			t.Fatalf("%s: unexpected error %v", s.name, err)
+		}
+		if ok != s.wantOK {
+			t.Errorf("%s: got ok %v, want %v", s.name, ok, s.wantOK)
+		}
+		used, err := IsCertificateApprovalNonceUsed(s.nonce)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if used != s.wantUsed {
+			t.Errorf("%s: got used %v, want %v", s.name, used, s.wantUsed)
+		}
+	}
+}
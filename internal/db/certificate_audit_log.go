@@ -0,0 +1,30 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateCertificateAuditLog(l *model.CertificateAuditLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+// GetCertificateAuditLogs 分页获取证书审计日志，actor 和 action 为空时不过滤
+func GetCertificateAuditLogs(pageIndex, pageSize int, actor, action string) (logs []model.CertificateAuditLog, count int64, err error) {
+	logDB := readDB().Model(&model.CertificateAuditLog{})
+	if actor != "" {
+		logDB = logDB.Where("actor = ?", actor)
+	}
+	if action != "" {
+		logDB = logDB.Where("action = ?", action)
+	}
+	if err := logDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get certificate audit logs count")
+	}
+	if err := logDB.Order(fmt.Sprintf("%s DESC", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find certificate audit logs")
+	}
+	return logs, count, nil
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateAuthorities() ([]model.CertificateAuthority, error) {
+	var cas []model.CertificateAuthority
+	if err := db.Order(fmt.Sprintf("%s DESC", columnName("id"))).Find(&cas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate authorities")
+	}
+	return cas, nil
+}
+
+// GetActiveCertificateAuthorities 获取在用的 CA 证书，根证书在前
+func GetActiveCertificateAuthorities() ([]model.CertificateAuthority, error) {
+	var cas []model.CertificateAuthority
+	if err := db.Where("status = ?", model.CertificateAuthorityActive).
+		Order(fmt.Sprintf("%s DESC, %s", columnName("kind"), columnName("id"))).Find(&cas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get active certificate authorities")
+	}
+	return cas, nil
+}
+
+// GetServingCertificateAuthorities 获取需要继续提供吊销状态的 CA：在用的 CA，以及签发的证书在 now 时
+// 尚未全部过期的已停用 CA，根证书在前
+func GetServingCertificateAuthorities(now time.Time) ([]model.CertificateAuthority, error) {
+	var cas []model.CertificateAuthority
+	unexpired := db.Model(&model.Certificate{}).Select("issuer_id").Where("expiration_date > ?", now)
+	if err := db.Where("status = ? OR (status = ? AND id IN (?))", model.CertificateAuthorityActive, model.CertificateAuthorityRetired, unexpired).
+		Order(fmt.Sprintf("%s DESC, %s", columnName("kind"), columnName("id"))).Find(&cas).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get serving certificate authorities")
+	}
+	return cas, nil
+}
+
+func GetCertificateAuthorityByID(id uint) (*model.CertificateAuthority, error) {
+	var ca model.CertificateAuthority
+	if err := db.First(&ca, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate authority by id: %d", id)
+	}
+	return &ca, nil
+}
+
+func CreateCertificateAuthority(ca *model.CertificateAuthority) error {
+	return errors.WithStack(db.Create(ca).Error)
+}
+
+func UpdateCertificateAuthority(ca *model.CertificateAuthority) error {
+	return errors.WithStack(db.Save(ca).Error)
+}
+
+func GetCertificateAuthorityByFingerprint(fingerprint string) (*model.CertificateAuthority, error) {
+	var ca model.CertificateAuthority
+	if err := db.Where("fingerprint = ?", fingerprint).First(&ca).Error; err != nil {
+		return nil, err
+	}
+	return &ca, nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestGetServingCertificateAuthorities(t *testing.T) {
+	setupTestDB(t)
+	now := time.Now()
+	newCA := func(name, status string) *model.CertificateAuthority {
+		ca := &model.CertificateAuthority{Name: name, Kind: model.CertificateAuthorityIntermediate, Status: status, Fingerprint: name}
+		if err := CreateCertificateAuthority(ca); err != nil {
+			t.Fatal(err)
+		}
+		return ca
+	}
+	newCert := func(issuer *model.CertificateAuthority, status model.CertificateStatus, expiration time.Time) {
+		cert := &model.Certificate{Name: issuer.Name, Type: model.CertificateTypeUser, Status: status, IssuerID: issuer.ID, ExpirationDate: expiration}
+		if err := CreateCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	active := newCA("active", model.CertificateAuthorityActive)
+	retiredUnexpired := newCA("retired-unexpired", model.CertificateAuthorityRetired)
+	retiredRevoked := newCA("retired-revoked", model.CertificateAuthorityRetired)
+	retiredExpired := newCA("retired-expired", model.CertificateAuthorityRetired)
+	retiredEmpty := newCA("retired-empty", model.CertificateAuthorityRetired)
+	newCert(retiredUnexpired, model.CertificateStatusValid, now.AddDate(0, 1, 0))
+	newCert(retiredRevoked, model.CertificateStatusRevoked, now.AddDate(0, 1, 0))
+	newCert(retiredExpired, model.CertificateStatusValid, now.AddDate(0, -1, 0))
+
+	cas, err := GetServingCertificateAuthorities(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[uint]bool{}
+	for _, ca := range cas {
+		got[ca.ID] = true
+	}
+	tests := []struct {
+		ca   *model.CertificateAuthority
+		want bool
+	}{
+		{ca: active, want: true},
+		{ca: retiredUnexpired, want: true},
+		{ca: retiredRevoked, want: true},
+		{ca: retiredExpired, want: false},
+		{ca: retiredEmpty, want: false},
+	}
+	for _, tt := range tests {
+		if got[tt.ca.ID] != tt.want {
+			t.Errorf("%s: got serving %v, want %v", tt.ca.Name, got[tt.ca.ID], tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+func GetCertificateRequestComments(requestID uint) ([]model.CertificateRequestComment, error) {
+	var comments []model.CertificateRequestComment
+	if err := db.Where("request_id = ?", requestID).Order(columnName("id")).Find(&comments).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get comments of certificate request id: %d", requestID)
+	}
+	return comments, nil
+}
+
+func CreateCertificateRequestComment(comment *model.CertificateRequestComment) error {
+	return errors.WithStack(db.Create(comment).Error)
+}
+
+// CreateCertificateRequestMentions 记录被 @ 的用户，已记录过的用户会被忽略
+func CreateCertificateRequestMentions(mentions []model.CertificateRequestMention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.Clauses(clause.OnConflict{DoNothing: true}).Create(&mentions).Error)
+}
+
+// IsMentionedInCertificateRequest 检查用户是否在申请的评论中被 @ 过
+func IsMentionedInCertificateRequest(requestID, userID uint) (bool, error) {
+	var count int64
+	if err := db.Model(&model.CertificateRequestMention{}).Where("request_id = ? AND user_id = ?", requestID, userID).Count(&count).Error; err != nil {
+		return false, errors.Wrapf(err, "failed check mention of certificate request id: %d", requestID)
+	}
+	return count > 0, nil
+}
+
+func GetCertificateRequestNotes(requestID uint) ([]model.CertificateRequestNote, error) {
+	var notes []model.CertificateRequestNote
+	if err := db.Where("request_id = ?", requestID).Order(columnName("id")).Find(&notes).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get notes of certificate request id: %d", requestID)
+	}
+	return notes, nil
+}
+
+func CreateCertificateRequestNote(note *model.CertificateRequestNote) error {
+	return errors.WithStack(db.Create(note).Error)
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetApprovalDelegations(pageIndex, pageSize int) (delegations []model.ApprovalDelegation, count int64, err error) {
+	delegationDB := db.Model(&model.ApprovalDelegation{})
+	if err := delegationDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get approval delegations count")
+	}
+	if err := delegationDB.Order(fmt.Sprintf("%s DESC", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&delegations).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find approval delegations")
+	}
+	return delegations, count, nil
+}
+
+func GetApprovalDelegationByID(id uint) (*model.ApprovalDelegation, error) {
+	var d model.ApprovalDelegation
+	if err := db.First(&d, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get approval delegation by id: %d", id)
+	}
+	return &d, nil
+}
+
+// GetActiveApprovalDelegationsByDelegateID 获取代理人当前生效的委托
+func GetActiveApprovalDelegationsByDelegateID(delegateID uint, t time.Time) ([]model.ApprovalDelegation, error) {
+	var delegations []model.ApprovalDelegation
+	if err := db.Where("delegate_id = ? AND start_at <= ? AND end_at > ?", delegateID, t, t).Find(&delegations).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get active approval delegations of delegate id: %d", delegateID)
+	}
+	return delegations, nil
+}
+
+// GetActiveApprovalDelegations 获取所有当前生效的委托
+func GetActiveApprovalDelegations(t time.Time) ([]model.ApprovalDelegation, error) {
+	var delegations []model.ApprovalDelegation
+	if err := db.Where("start_at <= ? AND end_at > ?", t, t).Find(&delegations).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get active approval delegations")
+	}
+	return delegations, nil
+}
+
+func CreateApprovalDelegation(d *model.ApprovalDelegation) error {
+	return errors.WithStack(db.Create(d).Error)
+}
+
+func DeleteApprovalDelegation(id uint) error {
+	return errors.WithStack(db.Delete(&model.ApprovalDelegation{}, id).Error)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func (t Tx) CreateCertificateDemoRecord(targetType string, targetID uint) error {
+	return errors.WithStack(t.tx.Create(&model.CertificateDemoRecord{TargetType: targetType, TargetID: targetID}).Error)
+}
+
+// CountCertificateDemoRecords 统计已填充的演示记录数量
+func CountCertificateDemoRecords() (int64, error) {
+	var count int64
+	if err := db.Model(&model.CertificateDemoRecord{}).Count(&count).Error; err != nil {
+		return 0, errors.Wrap(err, "failed count certificate demo records")
+	}
+	return count, nil
+}
+
+// WipeCertificateDemoRecords 在一个事务中彻底删除演示数据创建的证书和申请，以及它们的事件、评论、关注等关联记录
+func WipeCertificateDemoRecords() (*model.CertificateDemoSummary, error) {
+	summary := &model.CertificateDemoSummary{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var certIDs, reqIDs []uint
+		if err := tx.Model(&model.CertificateDemoRecord{}).Where("target_type = ?", model.CertificateAuditTargetCertificate).
+			Pluck("target_id", &certIDs).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if err := tx.Model(&model.CertificateDemoRecord{}).Where("target_type = ?", model.CertificateAuditTargetRequest).
+			Pluck("target_id", &reqIDs).Error; err != nil {
+			return errors.WithStack(err)
+		}
+		if len(certIDs) > 0 {
+			for _, m := range []any{&model.CertificateEvent{}, &model.CertificateExpiryNotice{}, &model.CertificateDownload{}, &model.CertificateSignature{}} {
+				if err := tx.Where("certificate_id IN ?", certIDs).Delete(m).Error; err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			if err := tx.Where("target_type = ? AND target_id IN ?", model.CertificateWatchTargetCertificate, certIDs).
+				Delete(&model.CertificateWatch{}).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			if err := tx.Where("target_type = ? AND target_id IN ?", model.CertificateAuditTargetCertificate, certIDs).
+				Delete(&model.CertificateAuditLog{}).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			if err := tx.Unscoped().Delete(&model.Certificate{}, certIDs).Error; err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if len(reqIDs) > 0 {
+			for _, m := range []any{&model.CertificateRequestComment{}, &model.CertificateRequestMention{}, &model.CertificateRequestNote{}, &model.CertificateApprovalNonce{}} {
+				if err := tx.Where("request_id IN ?", reqIDs).Delete(m).Error; err != nil {
+					return errors.WithStack(err)
+				}
+			}
+			if err := tx.Where("target_type = ? AND target_id IN ?", model.CertificateWatchTargetRequest, reqIDs).
+				Delete(&model.CertificateWatch{}).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			if err := tx.Where("target_type = ? AND target_id IN ?", model.CertificateAuditTargetRequest, reqIDs).
+				Delete(&model.CertificateAuditLog{}).Error; err != nil {
+				return errors.WithStack(err)
+			}
+			if err := tx.Unscoped().Delete(&model.CertificateRequest{}, reqIDs).Error; err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		summary.Certificates, summary.Requests = len(certIDs), len(reqIDs)
+		return errors.WithStack(tx.Where("1 = 1").Delete(&model.CertificateDemoRecord{}).Error)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
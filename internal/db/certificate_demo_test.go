@@ -0,0 +1,68 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestWipeCertificateDemoRecordsKeepsRealData(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.CertificateDemoRecord), new(model.CertificateEvent), new(model.CertificateExpiryNotice),
+		new(model.CertificateDownload), new(model.CertificateSignature), new(model.CertificateWatch), new(model.CertificateAuditLog),
+		new(model.CertificateRequestComment), new(model.CertificateRequestMention), new(model.CertificateRequestNote),
+		new(model.CertificateApprovalNonce)); err != nil {
+		t.Fatal(err)
+	}
+	newCert := func(owner string) *model.Certificate {
+		cert := &model.Certificate{Name: owner, Type: model.CertificateTypeUser, Status: model.CertificateStatusValid,
+			Owner: owner, ExpirationDate: time.Now().AddDate(1, 0, 0)}
+		if err := CreateCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	kept, demo := newCert("alice"), newCert("demo-alice")
+	demoReq := &model.CertificateRequest{UserName: "demo-bob", Type: model.CertificateTypeUser, Status: model.CertificateStatusPending}
+	if err := CreateCertificateRequest(demoReq); err != nil {
+		t.Fatal(err)
+	}
+	err := Transaction(func(tx Tx) error {
+		if err := tx.CreateCertificateDemoRecord(model.CertificateAuditTargetCertificate, demo.ID); err != nil {
+			return err
+		}
+		return tx.CreateCertificateDemoRecord(model.CertificateAuditTargetRequest, demoReq.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cert := range []*model.Certificate{kept, demo} {
+		if err := CreateCertificateEvent(&model.CertificateEvent{CertificateID: cert.ID, Event: "certificate.created"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Create(&model.CertificateRequestComment{RequestID: demoReq.ID, Content: "demo"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := WipeCertificateDemoRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Certificates != 1 || summary.Requests != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	var certs, events, comments, requests, records int64
+	db.Unscoped().Model(&model.Certificate{}).Count(&certs)
+	db.Model(&model.CertificateEvent{}).Count(&events)
+	db.Model(&model.CertificateRequestComment{}).Count(&comments)
+	db.Unscoped().Model(&model.CertificateRequest{}).Count(&requests)
+	db.Model(&model.CertificateDemoRecord{}).Count(&records)
+	if certs != 1 || events != 1 || comments != 0 || requests != 0 || records != 0 {
+		t.Fatalf("got %d certificates, %d events, %d comments, %d requests and %d demo records left", certs, events, comments, requests, records)
+	}
+	if _, err := GetCertificateByID(kept.ID); err != nil {
+		t.Fatalf("real certificate should be kept: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetCertificateDigestPref 获取用户的摘要订阅设置，未设置时返回默认值
+func GetCertificateDigestPref(userID uint) (*model.CertificateDigestPref, error) {
+	pref := model.CertificateDigestPref{UserID: userID}
+	if err := db.Where(pref).Limit(1).Find(&pref).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate digest pref of user id: %d", userID)
+	}
+	return &pref, nil
+}
+
+// GetCertificateContactDigest 获取联系人的摘要发送记录，未发送过时返回空记录
+func GetCertificateContactDigest(email string) (*model.CertificateContactDigest, error) {
+	d := model.CertificateContactDigest{Email: email}
+	if err := db.Where(d).Limit(1).Find(&d).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate contact digest of %s", email)
+	}
+	return &d, nil
+}
+
+func SaveCertificateContactDigest(d *model.CertificateContactDigest) error {
+	return errors.WithStack(db.Save(d).Error)
+}
+
+func SaveCertificateDigestPref(pref *model.CertificateDigestPref) error {
+	return errors.WithStack(db.Save(pref).Error)
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// RecordCertificateDownload 在一个事务中保存下载记录并更新证书的下载统计
+func RecordCertificateDownload(d *model.CertificateDownload) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(d).Error; err != nil {
+			return errors.Wrap(err, "failed create certificate download")
+		}
+		// 使用 UpdateColumns 跳过钩子和更新时间，下载不算作证书的修改
+		err := tx.Model(&model.Certificate{ID: d.CertificateID}).UpdateColumns(map[string]any{
+			"download_count":     gorm.Expr("download_count + ?", 1),
+			"last_downloaded_at": d.CreatedAt,
+			"last_downloaded_by": d.Username,
+		}).Error
+		return errors.Wrap(err, "failed update certificate download statistics")
+	}))
+}
+
+func GetCertificateDownloads(certID uint, pageIndex, pageSize int) (downloads []model.CertificateDownload, count int64, err error) {
+	downloadDB := db.Model(&model.CertificateDownload{}).Where("certificate_id = ?", certID)
+	if err := downloadDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get certificate downloads count")
+	}
+	if err := downloadDB.Order(fmt.Sprintf("%s DESC", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&downloads).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find certificate downloads")
+	}
+	return downloads, count, nil
+}
+
+// GetUnusedCertificates 获取在 since 之前签发、此后没有被下载过的有效证书
+func GetUnusedCertificates(since time.Time) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("status IN ? AND issued_date < ? AND (last_downloaded_at IS NULL OR last_downloaded_at < ?)",
+		[]model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring}, since, since).
+		Order(columnName("issued_date")).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get unused certificates")
+	}
+	return certs, nil
+}
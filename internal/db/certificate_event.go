@@ -0,0 +1,37 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateCertificateEvent(e *model.CertificateEvent) error {
+	return Tx{tx: db}.CreateCertificateEvent(e)
+}
+
+func GetCertificateEvents(certID uint) ([]model.CertificateEvent, error) {
+	var events []model.CertificateEvent
+	if err := db.Where("certificate_id = ?", certID).Order(columnName("id")).Find(&events).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get events of certificate id: %d", certID)
+	}
+	return events, nil
+}
+
+// GetCertificateEventsSince 获取指定时间之后的证书事件，certIDs 为 nil 时不限证书
+func GetCertificateEventsSince(certIDs []uint, since time.Time, limit int) ([]model.CertificateEvent, error) {
+	var events []model.CertificateEvent
+	query := db.Where("created_at >= ?", since)
+	if certIDs != nil {
+		if len(certIDs) == 0 {
+			return events, nil
+		}
+		query = query.Where("certificate_id IN ?", certIDs)
+	}
+	if err := query.Order(fmt.Sprintf("%s DESC", columnName("id"))).Limit(limit).Find(&events).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate events")
+	}
+	return events, nil
+}
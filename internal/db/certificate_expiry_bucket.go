@@ -0,0 +1,55 @@
+package db
+
+import (
+	"math"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// UpdateCertificateExpiryBuckets 按当前时间重新计算证书的到期分桶，每个分桶一条 UPDATE，
+// 只更新分桶发生变化的证书，返回更新的证书数量
+func UpdateCertificateExpiryBuckets(now time.Time) (int64, error) {
+	var total int64
+	update := func(bucket int, query string, args ...any) error {
+		res := db.Model(&model.Certificate{}).Where(query, args...).Where("expiry_bucket <> ?", bucket).
+			UpdateColumn("expiry_bucket", bucket)
+		if res.Error != nil {
+			return errors.Wrapf(res.Error, "failed update certificate expiry bucket %d", bucket)
+		}
+		total += res.RowsAffected
+		return nil
+	}
+	if err := update(model.ExpiryBucketExpired, "expiration_date <= ?", now); err != nil {
+		return total, err
+	}
+	from := now
+	for _, days := range model.ExpiryBuckets {
+		to := now.AddDate(0, 0, days)
+		if err := update(days, "expiration_date > ? AND expiration_date <= ?", from, to); err != nil {
+			return total, err
+		}
+		from = to
+	}
+	if err := update(model.ExpiryBucketLater, "expiration_date > ?", from); err != nil {
+		return total, err
+	}
+	return total, nil
+}
+
+// expiryBucketBefore 返回在 t 之前到期的证书可能所在的最大分桶，用作到期查询的索引条件
+func expiryBucketBefore(t time.Time) int {
+	return model.ExpiryBucketCeil(int(math.Ceil(time.Until(t).Hours() / 24)))
+}
+
+// GetCertificateExpirySummary 按到期分桶统计有效证书的数量
+func GetCertificateExpirySummary() ([]model.CertificateExpirySummary, error) {
+	var summary []model.CertificateExpirySummary
+	if err := readDB().Model(&model.Certificate{}).Select("expiry_bucket AS bucket, COUNT(*) AS count").
+		Where("status IN ?", []model.CertificateStatus{model.CertificateStatusValid, model.CertificateStatusExpiring}).
+		Group("expiry_bucket").Order("expiry_bucket").Scan(&summary).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate expiry summary")
+	}
+	return summary, nil
+}
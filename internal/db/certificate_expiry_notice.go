@@ -0,0 +1,36 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+// ClaimCertificateExpiryNotice 记录证书某一阶段的到期提醒，已记录过时返回 false，
+// 多个实例同时扫描时只有一个能记录成功
+func ClaimCertificateExpiryNotice(certID uint, stage string) (bool, error) {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.CertificateExpiryNotice{CertificateID: certID, Stage: stage})
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected > 0, nil
+}
+
+// ReleaseCertificateExpiryNotice 删除提醒记录，发送失败时调用以便下次扫描重试
+func ReleaseCertificateExpiryNotice(certID uint, stage string) error {
+	return errors.WithStack(db.Where("certificate_id = ? AND stage = ?", certID, stage).
+		Delete(&model.CertificateExpiryNotice{}).Error)
+}
+
+// GetCertificatesExpiredSince 获取在 since 之后到期并已标记为过期的证书，不含沙箱证书
+func GetCertificatesExpiredSince(since time.Time) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("status = ? AND sandbox = ? AND expiration_date >= ? AND expiration_date <= ?",
+		model.CertificateStatusExpired, false, since, time.Now()).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get expired certificates")
+	}
+	return certs, nil
+}
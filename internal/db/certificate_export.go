@@ -0,0 +1,32 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// StreamCertificates 通过数据库游标逐行读取符合过滤条件的证书并交给 fn，不会一次加载全部结果。
+// 过滤和排序与证书列表相同，未指定排序时按 ID 从小到大，fn 返回错误时停止读取
+func StreamCertificates(filter *model.CertificateFilter, fn func(*model.Certificate) error) error {
+	rdb := readDB()
+	query := filterCertificates(rdb.Model(&model.Certificate{}), filter)
+	order := columnName("id")
+	if filter != nil && (filter.OrderBy != "" || filter.OrderDirection != "") {
+		order = certificateOrder(filter)
+	}
+	rows, err := query.Order(order).Rows()
+	if err != nil {
+		return errors.Wrap(err, "failed query certificates")
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cert model.Certificate
+		if err := rdb.ScanRows(rows, &cert); err != nil {
+			return errors.Wrap(err, "failed scan certificate")
+		}
+		if err := fn(&cert); err != nil {
+			return err
+		}
+	}
+	return errors.WithStack(rows.Err())
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestStreamCertificatesWithFilter(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.Certificate)); err != nil {
+		t.Fatal(err)
+	}
+	for _, cert := range []model.Certificate{
+		{Name: "b.example.com", Type: model.CertificateTypeNode, Status: model.CertificateStatusValid, Owner: "alice"},
+		{Name: "a.example.com", Type: model.CertificateTypeNode, Status: model.CertificateStatusValid, Owner: "alice"},
+		{Name: "c.example.com", Type: model.CertificateTypeNode, Status: model.CertificateStatusRevoked, Owner: "alice"},
+		{Name: "bob", Type: model.CertificateTypeUser, Status: model.CertificateStatusValid, Owner: "bob"},
+	} {
+		if err := CreateCertificate(&cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var names []string
+	filter := &model.CertificateFilter{Status: model.CertificateStatusValid, Name: "example", OrderBy: "name"}
+	err := StreamCertificates(filter, func(cert *model.Certificate) error {
+		names = append(names, cert.Name)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "a.example.com" || names[1] != "b.example.com" {
+		t.Errorf("got %v, want valid example.com certificates ordered by name", names)
+	}
+
+	count := 0
+	if err := StreamCertificates(nil, func(*model.Certificate) error { count++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Errorf("got %d certificates without filter, want 4", count)
+	}
+}
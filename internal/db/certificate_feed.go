@@ -0,0 +1,26 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateFeedTokenByUserID(userID uint) (*model.CertificateFeedToken, error) {
+	var t model.CertificateFeedToken
+	if err := db.Where("user_id = ?", userID).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get feed token of user id: %d", userID)
+	}
+	return &t, nil
+}
+
+func GetCertificateFeedToken(token string) (*model.CertificateFeedToken, error) {
+	var t model.CertificateFeedToken
+	if err := db.Where("token = ?", token).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get feed token")
+	}
+	return &t, nil
+}
+
+func SaveCertificateFeedToken(t *model.CertificateFeedToken) error {
+	return errors.WithStack(db.Save(t).Error)
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateRequestFields() ([]model.CertificateRequestField, error) {
+	var fields []model.CertificateRequestField
+	if err := db.Order(fmt.Sprintf("%s, %s", columnName("order"), columnName("id"))).Find(&fields).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request fields")
+	}
+	return fields, nil
+}
+
+func GetCertificateRequestFieldByID(id uint) (*model.CertificateRequestField, error) {
+	var f model.CertificateRequestField
+	if err := db.First(&f, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request field by id: %d", id)
+	}
+	return &f, nil
+}
+
+func CreateCertificateRequestField(f *model.CertificateRequestField) error {
+	return errors.WithStack(db.Create(f).Error)
+}
+
+func UpdateCertificateRequestField(f *model.CertificateRequestField) error {
+	return errors.WithStack(db.Save(f).Error)
+}
+
+func DeleteCertificateRequestField(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateRequestField{}, id).Error)
+}
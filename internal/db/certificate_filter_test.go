@@ -0,0 +1,61 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestGetCertificatesFilter(t *testing.T) {
+	setupTestDB(t)
+	now := time.Now()
+	for _, cert := range []model.Certificate{
+		{Name: "web_1", Type: model.CertificateTypeNode, Status: model.CertificateStatusValid, Owner: "alice", ExpirationDate: now.AddDate(0, 0, 10)},
+		{Name: "web%2", Type: model.CertificateTypeNode, Status: model.CertificateStatusRevoked, Owner: "bob", ExpirationDate: now.AddDate(0, 0, 20)},
+		{Name: "alice", Type: model.CertificateTypeUser, Status: model.CertificateStatusValid, Owner: "alice", ExpirationDate: now.AddDate(0, 0, 30)},
+	} {
+		if err := db.Create(&cert).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	names := func(filter *model.CertificateFilter) []string {
+		t.Helper()
+		certs, count, err := GetCertificates(1, 10, filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(count) != len(certs) {
+			t.Fatalf("count = %d, got %d certificates", count, len(certs))
+		}
+		var out []string
+		for _, cert := range certs {
+			out = append(out, cert.Name)
+		}
+		return out
+	}
+	for _, tc := range []struct {
+		name   string
+		filter model.CertificateFilter
+		want   []string
+	}{
+		{"status", model.CertificateFilter{Status: model.CertificateStatusValid, OrderBy: "name"}, []string{"alice", "web_1"}},
+		{"type and owner", model.CertificateFilter{Type: model.CertificateTypeNode, Owner: "alice"}, []string{"web_1"}},
+		{"wildcards are literal", model.CertificateFilter{Name: "%"}, []string{"web%2"}},
+		{"underscore is literal", model.CertificateFilter{Name: "b_"}, []string{"web_1"}},
+		{"expiration range", model.CertificateFilter{ExpiresAfter: now.AddDate(0, 0, 15), ExpiresBefore: now.AddDate(0, 0, 25)}, []string{"web%2"}},
+		{"order desc", model.CertificateFilter{OrderBy: "expiration_date", OrderDirection: "desc"}, []string{"alice", "web%2", "web_1"}},
+	} {
+		got := names(&tc.filter)
+		if len(got) != len(tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("%s: got %v, want %v", tc.name, got, tc.want)
+				break
+			}
+		}
+	}
+}
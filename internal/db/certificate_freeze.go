@@ -0,0 +1,52 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetCertificateFreezeWindows 获取冻结窗口，organization 不为 nil 时只返回该组织和全局的窗口
+func GetCertificateFreezeWindows(organization *string) ([]model.CertificateFreezeWindow, error) {
+	var windows []model.CertificateFreezeWindow
+	query := db.Order(fmt.Sprintf("%s DESC", columnName("start_at")))
+	if organization != nil {
+		query = query.Where("organization = ? OR organization = ?", *organization, "")
+	}
+	if err := query.Find(&windows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate freeze windows")
+	}
+	return windows, nil
+}
+
+// GetActiveCertificateFreezeWindows 获取在 t 时刻对该组织生效的冻结窗口
+func GetActiveCertificateFreezeWindows(organization string, t time.Time) ([]model.CertificateFreezeWindow, error) {
+	var windows []model.CertificateFreezeWindow
+	if err := db.Where("(organization = ? OR organization = ?) AND start_at <= ? AND end_at > ?", organization, "", t, t).
+		Find(&windows).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get active certificate freeze windows")
+	}
+	return windows, nil
+}
+
+func GetCertificateFreezeWindowByID(id uint) (*model.CertificateFreezeWindow, error) {
+	var w model.CertificateFreezeWindow
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate freeze window by id: %d", id)
+	}
+	return &w, nil
+}
+
+func CreateCertificateFreezeWindow(w *model.CertificateFreezeWindow) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateCertificateFreezeWindow(w *model.CertificateFreezeWindow) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+func DeleteCertificateFreezeWindow(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateFreezeWindow{}, id).Error)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func GetCertificateHoneytokens() ([]model.CertificateHoneytoken, error) {
+	var tokens []model.CertificateHoneytoken
+	if err := db.Find(&tokens).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate honeytokens")
+	}
+	return tokens, nil
+}
+
+func GetCertificateHoneytoken(certID uint) (*model.CertificateHoneytoken, error) {
+	var token model.CertificateHoneytoken
+	if err := db.Where("certificate_id = ?", certID).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func CreateCertificateHoneytoken(token *model.CertificateHoneytoken) error {
+	return errors.WithStack(db.Create(token).Error)
+}
+
+func DeleteCertificateHoneytoken(certID uint) error {
+	return errors.WithStack(db.Where("certificate_id = ?", certID).Delete(&model.CertificateHoneytoken{}).Error)
+}
+
+// TripCertificateHoneytoken 累加诱饵证书的触发次数
+func TripCertificateHoneytoken(certID uint, at time.Time) error {
+	return errors.WithStack(db.Model(&model.CertificateHoneytoken{}).Where("certificate_id = ?", certID).
+		UpdateColumns(map[string]any{
+			"trip_count":      gorm.Expr("trip_count + ?", 1),
+			"last_tripped_at": at,
+		}).Error)
+}
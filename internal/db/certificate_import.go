@@ -0,0 +1,55 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// ImportCertificateBatch 在一个事务中批量写入证书及其事件，指纹已存在的证书（包含已删除的）
+// 和批次内重复的证书被跳过，返回实际写入的证书数量
+func ImportCertificateBatch(certs []model.Certificate, event func(*model.Certificate) model.CertificateEvent) (int, error) {
+	if len(certs) == 0 {
+		return 0, nil
+	}
+	imported := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		fingerprints := make([]string, 0, len(certs))
+		for i := range certs {
+			fingerprints = append(fingerprints, certs[i].Fingerprint)
+		}
+		var existing []string
+		if err := tx.Unscoped().Model(&model.Certificate{}).Where("fingerprint IN ?", fingerprints).
+			Pluck("fingerprint", &existing).Error; err != nil {
+			return errors.Wrap(err, "failed get existing fingerprints")
+		}
+		seen := make(map[string]struct{}, len(certs))
+		for _, fp := range existing {
+			seen[fp] = struct{}{}
+		}
+		batch := make([]model.Certificate, 0, len(certs))
+		for i := range certs {
+			if _, ok := seen[certs[i].Fingerprint]; ok {
+				continue
+			}
+			seen[certs[i].Fingerprint] = struct{}{}
+			batch = append(batch, certs[i])
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(batch, 100).Error; err != nil {
+			return errors.Wrap(err, "failed create certificates")
+		}
+		events := make([]model.CertificateEvent, 0, len(batch))
+		for i := range batch {
+			events = append(events, event(&batch[i]))
+		}
+		if err := tx.CreateInBatches(events, 100).Error; err != nil {
+			return errors.Wrap(err, "failed create certificate events")
+		}
+		imported = len(batch)
+		return nil
+	})
+	return imported, errors.WithStack(err)
+}
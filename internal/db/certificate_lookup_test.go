@@ -0,0 +1,50 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/gorm"
+)
+
+func TestGetCertificateBySerialOrFingerprint(t *testing.T) {
+	setupTestDB(t)
+	// 导入的证书内容无法解析时序列号和指纹为空
+	for _, cert := range []*model.Certificate{
+		{Name: "unparsed", Type: model.CertificateTypeUser, Status: model.CertificateStatusValid, ExpirationDate: time.Now().AddDate(1, 0, 0)},
+		{Name: "issued", Type: model.CertificateTypeUser, Status: model.CertificateStatusValid, SerialNumber: "1a2b", Fingerprint: "ff00", ExpirationDate: time.Now().AddDate(1, 0, 0)},
+	} {
+		if err := CreateCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name        string
+		serial      string
+		fingerprint string
+		want        string
+	}{
+		{name: "both empty"},
+		{name: "serial", serial: "1a2b", want: "issued"},
+		{name: "fingerprint", fingerprint: "ff00", want: "issued"},
+		{name: "both match", serial: "1a2b", fingerprint: "ff00", want: "issued"},
+		{name: "fingerprint mismatch", serial: "1a2b", fingerprint: "ff01"},
+		{name: "unknown serial", serial: "1a2c"},
+	}
+	for _, tt := range tests {
+		cert, err := GetCertificateBySerialOrFingerprint(tt.serial, tt.fingerprint)
+		if tt.want == "" {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Errorf("%s: got %v, %v, want record not found", tt.name, cert, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if cert.Name != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, cert.Name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// 列表接口按页批量加载关联的用户和 CA，每种关联只查询一次，避免逐条查询
+
+// preloadCertificateDetails 为一页证书填充所有者信息和签发 CA 名称
+func preloadCertificateDetails(tx *gorm.DB, certs []model.Certificate) error {
+	if len(certs) == 0 {
+		return nil
+	}
+	ownerIDs := make([]uint, 0, len(certs))
+	issuerIDs := make([]uint, 0, len(certs))
+	for _, cert := range certs {
+		if cert.OwnerID != 0 {
+			ownerIDs = append(ownerIDs, cert.OwnerID)
+		}
+		if cert.IssuerID != 0 {
+			issuerIDs = append(issuerIDs, cert.IssuerID)
+		}
+	}
+	users, err := loadCertificateUsers(tx, ownerIDs)
+	if err != nil {
+		return err
+	}
+	issuers := map[uint]string{}
+	if len(issuerIDs) > 0 {
+		var cas []model.CertificateAuthority
+		if err := tx.Model(&model.CertificateAuthority{}).Select("id", "name").
+			Where("id IN ?", uniqueIDs(issuerIDs)).Find(&cas).Error; err != nil {
+			return errors.Wrapf(err, "failed preload certificate issuers")
+		}
+		for _, ca := range cas {
+			issuers[ca.ID] = ca.Name
+		}
+	}
+	for i := range certs {
+		certs[i].OwnerInfo = users[certs[i].OwnerID]
+		certs[i].IssuerName = issuers[certs[i].IssuerID]
+	}
+	return nil
+}
+
+// preloadCertificateRequestDetails 为一页证书申请填充申请人信息
+func preloadCertificateRequestDetails(tx *gorm.DB, reqs []model.CertificateRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+	userIDs := make([]uint, 0, len(reqs))
+	for _, req := range reqs {
+		if req.UserID != 0 {
+			userIDs = append(userIDs, req.UserID)
+		}
+	}
+	users, err := loadCertificateUsers(tx, userIDs)
+	if err != nil {
+		return err
+	}
+	for i := range reqs {
+		reqs[i].UserInfo = users[reqs[i].UserID]
+	}
+	return nil
+}
+
+func loadCertificateUsers(tx *gorm.DB, ids []uint) (map[uint]*model.CertificateUserInfo, error) {
+	users := map[uint]*model.CertificateUserInfo{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	var infos []model.CertificateUserInfo
+	if err := tx.Model(&model.User{}).Select("id", "username", "role", "disabled").
+		Where("id IN ?", uniqueIDs(ids)).Find(&infos).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed preload certificate users")
+	}
+	for i := range infos {
+		users[infos[i].ID] = &infos[i]
+	}
+	return users, nil
+}
+
+func uniqueIDs(ids []uint) []uint {
+	seen := make(map[uint]struct{}, len(ids))
+	out := ids[:0]
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupTestDB opens an in-memory database and counts the queries issued through it
+func setupTestDB(t *testing.T) *int {
+	t.Helper()
+	if conf.Conf == nil {
+		conf.Conf = conf.DefaultConfig(t.TempDir())
+	}
+	d, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	db = d
+	if err := registerPrivateKeyCallbacks(d); err != nil {
+		t.Fatal(err)
+	}
+	if err := AutoMigrate(new(model.User), new(model.Certificate), new(model.CertificateRequest), new(model.CertificateAuthority)); err != nil {
+		t.Fatal(err)
+	}
+	var queries int
+	if err := d.Callback().Query().Before("gorm:query").Register("test:count", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Callback().Row().Before("gorm:row").Register("test:count", func(*gorm.DB) { queries++ }); err != nil {
+		t.Fatal(err)
+	}
+	return &queries
+}
+
+func TestGetCertificatesPreloadsDetails(t *testing.T) {
+	queries := setupTestDB(t)
+	ca := &model.CertificateAuthority{Name: "root", Kind: "root", Status: "active", Fingerprint: "ca"}
+	if err := db.Create(ca).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		user := &model.User{Username: fmt.Sprintf("user%d", i)}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&model.Certificate{
+			Name: user.Username, Type: model.CertificateTypeUser, Status: model.CertificateStatusValid,
+			Owner: user.Username, OwnerID: user.ID, IssuerID: ca.ID, ExpirationDate: time.Now().AddDate(1, 0, 0),
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(&model.CertificateRequest{
+			UserName: user.Username, UserID: user.ID, Type: model.CertificateTypeUser, Status: model.CertificateStatusPending,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	*queries = 0
+	certs, _, err := GetCertificates(1, 50, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// count, page, owners and issuers
+	if *queries != 4 {
+		t.Errorf("GetCertificates issued %d queries, want 4", *queries)
+	}
+	for _, cert := range certs {
+		if cert.OwnerInfo == nil || cert.OwnerInfo.Username != cert.Owner {
+			t.Fatalf("owner of certificate %d not preloaded: %+v", cert.ID, cert.OwnerInfo)
+		}
+		if cert.IssuerName != ca.Name {
+			t.Fatalf("issuer of certificate %d not preloaded: %q", cert.ID, cert.IssuerName)
+		}
+	}
+
+	*queries = 0
+	reqs, _, err := GetCertificateRequests(1, 50, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// count, page and users
+	if *queries != 3 {
+		t.Errorf("GetCertificateRequests issued %d queries, want 3", *queries)
+	}
+	for _, req := range reqs {
+		if req.UserInfo == nil || req.UserInfo.Username != req.UserName {
+			t.Fatalf("user of request %d not preloaded: %+v", req.ID, req.UserInfo)
+		}
+	}
+}
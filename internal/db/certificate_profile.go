@@ -0,0 +1,49 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateProfiles() ([]model.CertificateProfile, error) {
+	var profiles []model.CertificateProfile
+	if err := db.Order(columnName("name")).Find(&profiles).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate profiles")
+	}
+	return profiles, nil
+}
+
+func GetCertificateProfileByID(id uint) (*model.CertificateProfile, error) {
+	var p model.CertificateProfile
+	if err := db.First(&p, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate profile by id: %d", id)
+	}
+	return &p, nil
+}
+
+func CreateCertificateProfile(p *model.CertificateProfile) error {
+	return errors.WithStack(db.Create(p).Error)
+}
+
+func UpdateCertificateProfile(p *model.CertificateProfile) error {
+	return errors.WithStack(db.Save(p).Error)
+}
+
+func DeleteCertificateProfile(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateProfile{}, id).Error)
+}
+
+// CountOpenCertificateRequestsByProfileID 统计引用签发配置且尚未签发的申请，包括草稿和计划签发的申请
+func CountOpenCertificateRequestsByProfileID(profileID uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.CertificateRequest{}).
+		Where(fmt.Sprintf("%s = ? AND %s IN ?", columnName("profile_id"), columnName("status")), profileID,
+			[]model.CertificateStatus{model.CertificateStatusDraft, model.CertificateStatusPending, model.CertificateStatusScheduled}).
+		Count(&count).Error
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed count certificate requests of profile id: %d", profileID)
+	}
+	return count, nil
+}
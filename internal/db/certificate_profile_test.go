@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestCountOpenCertificateRequestsByProfileID(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.CertificateProfile)); err != nil {
+		t.Fatal(err)
+	}
+	profile := &model.CertificateProfile{Name: "web", KeyType: "rsa", KeySize: 2048, AllowedSANs: []string{"*.example.com"}}
+	if err := CreateCertificateProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+	statuses := []model.CertificateStatus{
+		model.CertificateStatusDraft, model.CertificateStatusPending, model.CertificateStatusScheduled,
+		// 已签发和已拒绝的申请不再使用签发配置
+		model.CertificateStatusValid, model.CertificateStatusRejected,
+	}
+	for _, status := range statuses {
+		req := &model.CertificateRequest{UserName: "alice", Type: model.CertificateTypeNode, Status: status, ProfileID: profile.ID}
+		if err := CreateCertificateRequest(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CreateCertificateRequest(&model.CertificateRequest{UserName: "bob", Type: model.CertificateTypeNode, Status: model.CertificateStatusPending}); err != nil {
+		t.Fatal(err)
+	}
+	count, err := CountOpenCertificateRequestsByProfileID(profile.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("counted %d open requests, want 3", count)
+	}
+
+	got, err := GetCertificateProfileByID(profile.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.AllowedSANs) != 1 || got.KeyAlgorithm() != "rsa-2048" {
+		t.Errorf("unexpected profile %+v", got)
+	}
+}
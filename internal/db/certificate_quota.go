@@ -0,0 +1,31 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetCertificateTenantQuota 获取租户的配额设置，未设置时 MaxCertificates 为 0
+func GetCertificateTenantQuota(userID uint) (*model.CertificateTenantQuota, error) {
+	q := model.CertificateTenantQuota{UserID: userID}
+	if err := db.Where(q).Limit(1).Find(&q).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate quota of user id: %d", userID)
+	}
+	return &q, nil
+}
+
+func GetCertificateTenantQuotas() ([]model.CertificateTenantQuota, error) {
+	var quotas []model.CertificateTenantQuota
+	if err := db.Order("user_id").Find(&quotas).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return quotas, nil
+}
+
+func SaveCertificateTenantQuota(q *model.CertificateTenantQuota) error {
+	return errors.WithStack(db.Save(q).Error)
+}
+
+func DeleteCertificateTenantQuota(userID uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateTenantQuota{}, userID).Error)
+}
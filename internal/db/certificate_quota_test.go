@@ -0,0 +1,55 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestCountActiveCertificatesByOwnerID(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.CertificateTenantQuota)); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	certs := []*model.Certificate{
+		{Name: "a", Status: model.CertificateStatusValid, ExpirationDate: now.Add(30 * 24 * time.Hour)},
+		{Name: "b", Status: model.CertificateStatusExpiring, ExpirationDate: now.Add(60 * 24 * time.Hour)},
+		// 续期重叠期内被取代的旧证书、已吊销和沙箱证书不计入
+		{Name: "old", Status: model.CertificateStatusValid, ExpirationDate: now.Add(5 * 24 * time.Hour), SupersededByID: 1},
+		{Name: "revoked", Status: model.CertificateStatusRevoked, ExpirationDate: now.Add(30 * 24 * time.Hour)},
+		{Name: "sandbox", Status: model.CertificateStatusValid, ExpirationDate: now.Add(30 * 24 * time.Hour), Sandbox: true},
+	}
+	for _, cert := range certs {
+		cert.Type, cert.Owner, cert.OwnerID = model.CertificateTypeUser, "alice", 7
+		if err := CreateCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count, err := CountActiveCertificatesByOwnerID(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("counted %d active certificates, want 2", count)
+	}
+	list, err := GetCertificatesByOwnerID(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].Name != "b" {
+		t.Errorf("listed %d certificates starting with %q, want 3 starting with b", len(list), list[0].Name)
+	}
+
+	q, err := GetCertificateTenantQuota(7)
+	if err != nil || q.MaxCertificates != 0 {
+		t.Fatalf("unexpected quota %+v, %v", q, err)
+	}
+	if err := SaveCertificateTenantQuota(&model.CertificateTenantQuota{UserID: 7, MaxCertificates: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if q, _ = GetCertificateTenantQuota(7); q.MaxCertificates != 3 {
+		t.Errorf("max certificates %d, want 3", q.MaxCertificates)
+	}
+}
@@ -0,0 +1,36 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetApprovedCertificateRequestsWithoutCertificate 获取已批准但没有任何证书（含已删除）的申请
+func GetApprovedCertificateRequestsWithoutCertificate() ([]model.CertificateRequest, error) {
+	var requests []model.CertificateRequest
+	issued := db.Unscoped().Model(&model.Certificate{}).Select("request_id")
+	if err := db.Where("status = ? AND id NOT IN (?)", model.CertificateStatusValid, issued).Find(&requests).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get approved requests without certificate")
+	}
+	return requests, nil
+}
+
+// GetCertificatesWithoutRequest 获取关联的申请已不存在的证书
+func GetCertificatesWithoutRequest() ([]model.Certificate, error) {
+	var certs []model.Certificate
+	requests := db.Model(&model.CertificateRequest{}).Select("id")
+	if err := db.Where("request_id <> 0 AND request_id NOT IN (?)", requests).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates without request")
+	}
+	return certs, nil
+}
+
+// GetCertificatesWithUnapprovedRequest 获取关联申请未处于已批准状态的证书
+func GetCertificatesWithUnapprovedRequest() ([]model.Certificate, error) {
+	var certs []model.Certificate
+	requests := db.Model(&model.CertificateRequest{}).Select("id").Where("status <> ?", model.CertificateStatusValid)
+	if err := db.Where("request_id IN (?)", requests).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates with unapproved request")
+	}
+	return certs, nil
+}
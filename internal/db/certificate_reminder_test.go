@@ -0,0 +1,43 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestMarkCertificateRequestRemindedKeepsDecision(t *testing.T) {
+	setupTestDB(t)
+	req := &model.CertificateRequest{UserName: "alice", Type: model.CertificateTypeUser, Status: model.CertificateStatusPending}
+	if err := CreateCertificateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := MarkCertificateRequestReminded(req.ID, now, &now); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetCertificateRequestByID(req.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RemindedAt == nil || got.EscalatedAt == nil {
+		t.Fatalf("got reminded %v escalated %v, want both set", got.RemindedAt, got.EscalatedAt)
+	}
+	// 提醒发送期间申请被批准，之后的提醒不应把申请改回待审批
+	got.Status = model.CertificateStatusValid
+	if err := UpdateCertificateRequest(got); err != nil {
+		t.Fatal(err)
+	}
+	later := now.Add(time.Hour)
+	if err := MarkCertificateRequestReminded(req.ID, later, nil); err != nil {
+		t.Fatal(err)
+	}
+	got, err = GetCertificateRequestByID(req.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.CertificateStatusValid || got.RemindedAt.After(now.Add(time.Minute)) {
+		t.Errorf("got status %s reminded %v, want valid and unchanged reminder", got.Status, got.RemindedAt)
+	}
+}
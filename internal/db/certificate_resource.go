@@ -0,0 +1,27 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// GetCertificateRequestByExternalID 获取租户声明式资源最近一次的申请
+func GetCertificateRequestByExternalID(userID uint, externalID string) (*model.CertificateRequest, error) {
+	var req model.CertificateRequest
+	if err := db.Where("user_id = ? AND external_id = ?", userID, externalID).
+		Order(fmt.Sprintf("%s DESC", columnName("id"))).First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetCurrentCertificateByRequestID 获取申请签发的证书中未被取代的最新一张，续期和重新签发的证书沿用来源申请
+func GetCurrentCertificateByRequestID(requestID uint) (*model.Certificate, error) {
+	var cert model.Certificate
+	if err := db.Where("request_id = ? AND superseded_by_id = 0", requestID).
+		Order(fmt.Sprintf("%s DESC", columnName("id"))).First(&cert).Error; err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
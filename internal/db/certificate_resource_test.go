@@ -0,0 +1,50 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestCertificateResourceLookup(t *testing.T) {
+	setupTestDB(t)
+	reqs := []*model.CertificateRequest{
+		{UserName: "alice", UserID: 7, ExternalID: "web", Status: model.CertificateStatusRejected},
+		{UserName: "alice", UserID: 7, ExternalID: "web", Status: model.CertificateStatusValid},
+		{UserName: "bob", UserID: 8, ExternalID: "web", Status: model.CertificateStatusPending},
+	}
+	for _, req := range reqs {
+		req.Type = model.CertificateTypeNode
+		if err := CreateCertificateRequest(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := GetCertificateRequestByExternalID(7, "web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != reqs[1].ID {
+		t.Errorf("got request %d, want the latest request %d of the tenant", got.ID, reqs[1].ID)
+	}
+
+	// 续期后旧证书被取代，当前证书为续期证书
+	old := &model.Certificate{Name: "old", Type: model.CertificateTypeNode, Status: model.CertificateStatusValid, RequestID: reqs[1].ID}
+	if err := CreateCertificate(old); err != nil {
+		t.Fatal(err)
+	}
+	renewed := &model.Certificate{Name: "renewed", Type: model.CertificateTypeNode, Status: model.CertificateStatusValid, RequestID: reqs[1].ID, SupersedesID: old.ID}
+	if err := CreateCertificate(renewed); err != nil {
+		t.Fatal(err)
+	}
+	old.SupersededByID = renewed.ID
+	if err := UpdateCertificate(old); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := GetCurrentCertificateByRequestID(reqs[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert.ID != renewed.ID {
+		t.Errorf("got certificate %s, want renewed", cert.Name)
+	}
+}
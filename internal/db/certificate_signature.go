@@ -0,0 +1,38 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateCertificateSignature(s *model.CertificateSignature) error {
+	return errors.WithStack(db.Create(s).Error)
+}
+
+// GetCertificateSignatures 获取证书的签名记录，certID 为 0 时按用户查询
+func GetCertificateSignatures(certID, userID uint, limit int) ([]model.CertificateSignature, error) {
+	var signatures []model.CertificateSignature
+	query := db.Order(fmt.Sprintf("%s DESC", columnName("id"))).Limit(limit)
+	if certID != 0 {
+		query = query.Where("certificate_id = ?", certID)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.Find(&signatures).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate signatures")
+	}
+	return signatures, nil
+}
+
+// CountCertificateSignaturesByUserSince 统计用户在指定时间之后创建的签名数量
+func CountCertificateSignaturesByUserSince(userID uint, t time.Time) (int64, error) {
+	var count int64
+	if err := db.Model(&model.CertificateSignature{}).Where("user_id = ? AND created_at >= ?", userID, t).Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count certificate signatures")
+	}
+	return count, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetCertificateRequestTemplates 获取用户的模板以及共享模板
+func GetCertificateRequestTemplates(userID uint) ([]model.CertificateRequestTemplate, error) {
+	var templates []model.CertificateRequestTemplate
+	err := db.Where(fmt.Sprintf("%s IN ?", columnName("user_id")), []uint{0, userID}).
+		Order(columnName("user_id")).Order(columnName("name")).Find(&templates).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request templates")
+	}
+	return templates, nil
+}
+
+func GetCertificateRequestTemplateByID(id uint) (*model.CertificateRequestTemplate, error) {
+	var t model.CertificateRequestTemplate
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request template by id: %d", id)
+	}
+	return &t, nil
+}
+
+func CreateCertificateRequestTemplate(t *model.CertificateRequestTemplate) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateCertificateRequestTemplate(t *model.CertificateRequestTemplate) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+func DeleteCertificateRequestTemplate(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateRequestTemplate{}, id).Error)
+}
@@ -0,0 +1,34 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestUpdateCertificateRequestKeepsTicket(t *testing.T) {
+	setupTestDB(t)
+	req := &model.CertificateRequest{UserName: "alice", Type: model.CertificateTypeUser, Status: model.CertificateStatusPending}
+	if err := CreateCertificateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	// 审批前读取的申请不包含异步关联的工单，保存时不应清除工单
+	stale, err := GetCertificateRequestByID(req.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UpdateCertificateRequestTicket(req.ID, "OPS-1", "https://jira.example.com/browse/OPS-1"); err != nil {
+		t.Fatal(err)
+	}
+	stale.Status = model.CertificateStatusRejected
+	if err := UpdateCertificateRequest(stale); err != nil {
+		t.Fatal(err)
+	}
+	got, err := GetCertificateRequestByID(req.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != model.CertificateStatusRejected || got.TicketID != "OPS-1" || got.TicketURL == "" {
+		t.Errorf("got status %s ticket %q %q, want rejected with ticket OPS-1", got.Status, got.TicketID, got.TicketURL)
+	}
+}
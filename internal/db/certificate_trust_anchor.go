@@ -0,0 +1,42 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetCertificateTrustAnchors 获取信任库中的 CA 证书，organization 不为 nil 时只返回该组织的
+func GetCertificateTrustAnchors(organization *string) ([]model.CertificateTrustAnchor, error) {
+	var anchors []model.CertificateTrustAnchor
+	query := db.Order(columnName("id"))
+	if organization != nil {
+		query = query.Where("organization = ?", *organization)
+	}
+	if err := query.Find(&anchors).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate trust anchors")
+	}
+	return anchors, nil
+}
+
+func GetCertificateTrustAnchorByID(id uint) (*model.CertificateTrustAnchor, error) {
+	var a model.CertificateTrustAnchor
+	if err := db.First(&a, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate trust anchor by id: %d", id)
+	}
+	return &a, nil
+}
+
+// CountCertificateTrustAnchorsByFingerprint 统计组织信任库中指纹相同的 CA 证书，用于去重
+func CountCertificateTrustAnchorsByFingerprint(organization, fingerprint string) (int64, error) {
+	var count int64
+	err := db.Model(&model.CertificateTrustAnchor{}).Where("organization = ? AND fingerprint = ?", organization, fingerprint).Count(&count).Error
+	return count, errors.WithStack(err)
+}
+
+func CreateCertificateTrustAnchors(anchors []model.CertificateTrustAnchor) error {
+	return errors.WithStack(db.Create(&anchors).Error)
+}
+
+func DeleteCertificateTrustAnchor(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateTrustAnchor{}, id).Error)
+}
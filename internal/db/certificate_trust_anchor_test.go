@@ -0,0 +1,44 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestCertificateTrustAnchorsByOrganization(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.CertificateTrustAnchor)); err != nil {
+		t.Fatal(err)
+	}
+	anchors := []model.CertificateTrustAnchor{
+		{Organization: "acme", Name: "a", Fingerprint: "aa"},
+		{Organization: "acme", Name: "b", Fingerprint: "bb"},
+		{Organization: "globex", Name: "a", Fingerprint: "aa"},
+	}
+	if err := CreateCertificateTrustAnchors(anchors); err != nil {
+		t.Fatal(err)
+	}
+	org := "acme"
+	list, err := GetCertificateTrustAnchors(&org)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 {
+		t.Errorf("got %d anchors of acme, want 2", len(list))
+	}
+	all, err := GetCertificateTrustAnchors(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Errorf("got %d anchors, want 3", len(all))
+	}
+	// 同一张 CA 可以被多个组织信任，去重只在组织内进行
+	if count, _ := CountCertificateTrustAnchorsByFingerprint("globex", "bb"); count != 0 {
+		t.Errorf("globex has %d anchors with fingerprint bb, want 0", count)
+	}
+	if count, _ := CountCertificateTrustAnchorsByFingerprint("acme", "aa"); count != 1 {
+		t.Errorf("acme has %d anchors with fingerprint aa, want 1", count)
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateTypes() ([]model.CertificateTypeDef, error) {
+	var types []model.CertificateTypeDef
+	if err := db.Order(columnName("id")).Find(&types).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate types")
+	}
+	return types, nil
+}
+
+func GetCertificateTypeByID(id uint) (*model.CertificateTypeDef, error) {
+	var t model.CertificateTypeDef
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate type by id: %d", id)
+	}
+	return &t, nil
+}
+
+func GetCertificateTypeByName(name model.CertificateType) (*model.CertificateTypeDef, error) {
+	var t model.CertificateTypeDef
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("name")), name).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate type by name: %s", name)
+	}
+	return &t, nil
+}
+
+func CreateCertificateType(t *model.CertificateTypeDef) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateCertificateType(t *model.CertificateTypeDef) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+func DeleteCertificateType(id uint) error {
+	return errors.WithStack(db.Delete(&model.CertificateTypeDef{}, id).Error)
+}
@@ -0,0 +1,41 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateWatchesByUserID(userID uint) ([]model.CertificateWatch, error) {
+	var watches []model.CertificateWatch
+	if err := db.Where("user_id = ?", userID).Order(fmt.Sprintf("%s DESC", columnName("id"))).Find(&watches).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate watches of user id: %d", userID)
+	}
+	return watches, nil
+}
+
+// GetCertificateWatchesByTarget 获取某个证书或申请的所有关注者
+func GetCertificateWatchesByTarget(targetType model.CertificateWatchTarget, targetID uint) ([]model.CertificateWatch, error) {
+	var watches []model.CertificateWatch
+	if err := db.Where("target_type = ? AND target_id = ?", targetType, targetID).Find(&watches).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get watches of %s %d", targetType, targetID)
+	}
+	return watches, nil
+}
+
+func GetCertificateWatch(userID uint, targetType model.CertificateWatchTarget, targetID uint) (*model.CertificateWatch, error) {
+	var w model.CertificateWatch
+	if err := db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).First(&w).Error; err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func CreateCertificateWatch(w *model.CertificateWatch) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func DeleteCertificateWatch(userID uint, targetType model.CertificateWatchTarget, targetID uint) error {
+	return errors.WithStack(db.Where("user_id = ? AND target_type = ? AND target_id = ?", userID, targetType, targetID).Delete(&model.CertificateWatch{}).Error)
+}
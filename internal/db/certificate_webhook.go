@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetCertificateWebhooks() ([]model.CertificateWebhook, error) {
+	var hooks []model.CertificateWebhook
+	if err := db.Order(columnName("id")).Find(&hooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate webhooks")
+	}
+	return hooks, nil
+}
+
+// GetEnabledCertificateWebhooks 获取启用的接收端，事件推送时按订阅过滤
+func GetEnabledCertificateWebhooks() ([]model.CertificateWebhook, error) {
+	var hooks []model.CertificateWebhook
+	if err := db.Where("enabled = ?", true).Order(columnName("id")).Find(&hooks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get enabled certificate webhooks")
+	}
+	return hooks, nil
+}
+
+func GetCertificateWebhookByID(id uint) (*model.CertificateWebhook, error) {
+	var w model.CertificateWebhook
+	if err := db.First(&w, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate webhook by id: %d", id)
+	}
+	return &w, nil
+}
+
+func CreateCertificateWebhook(w *model.CertificateWebhook) error {
+	return errors.WithStack(db.Create(w).Error)
+}
+
+func UpdateCertificateWebhook(w *model.CertificateWebhook) error {
+	return errors.WithStack(db.Save(w).Error)
+}
+
+// DeleteCertificateWebhook 删除接收端及其推送记录
+func DeleteCertificateWebhook(id uint) error {
+	if err := db.Where("webhook_id = ?", id).Delete(&model.CertificateWebhookDelivery{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete deliveries of certificate webhook %d", id)
+	}
+	return errors.WithStack(db.Delete(&model.CertificateWebhook{}, id).Error)
+}
+
+func CreateCertificateWebhookDeliveries(deliveries []model.CertificateWebhookDelivery) error {
+	return errors.WithStack(db.Create(&deliveries).Error)
+}
+
+func GetCertificateWebhookDeliveryByID(id uint) (*model.CertificateWebhookDelivery, error) {
+	var d model.CertificateWebhookDelivery
+	if err := db.First(&d, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate webhook delivery by id: %d", id)
+	}
+	return &d, nil
+}
+
+// GetCertificateWebhookDeliveries 分页获取推送记录，webhookID 和 status 为零值时不过滤
+func GetCertificateWebhookDeliveries(webhookID uint, status string, pageIndex, pageSize int) (deliveries []model.CertificateWebhookDelivery, count int64, err error) {
+	deliveryDB := db.Model(&model.CertificateWebhookDelivery{})
+	if webhookID != 0 {
+		deliveryDB = deliveryDB.Where("webhook_id = ?", webhookID)
+	}
+	if status != "" {
+		deliveryDB = deliveryDB.Where("status = ?", status)
+	}
+	if err := deliveryDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get certificate webhook deliveries count")
+	}
+	if err := deliveryDB.Order(fmt.Sprintf("%s DESC", columnName("id"))).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&deliveries).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find certificate webhook deliveries")
+	}
+	return deliveries, count, nil
+}
+
+// GetDueCertificateWebhookDeliveries 获取到达重试时间、等待推送的记录
+func GetDueCertificateWebhookDeliveries(now time.Time, limit int) ([]model.CertificateWebhookDelivery, error) {
+	var deliveries []model.CertificateWebhookDelivery
+	if err := db.Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		Order(columnName("next_attempt_at")).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get due certificate webhook deliveries")
+	}
+	return deliveries, nil
+}
+
+// UpdateCertificateWebhookDeliveryResult 保存一次推送的结果
+func UpdateCertificateWebhookDeliveryResult(d *model.CertificateWebhookDelivery) error {
+	err := db.Model(&model.CertificateWebhookDelivery{}).Where("id = ?", d.ID).UpdateColumns(map[string]any{
+		"status":          d.Status,
+		"attempts":        d.Attempts,
+		"status_code":     d.StatusCode,
+		"response":        d.Response,
+		"last_error":      d.LastError,
+		"next_attempt_at": d.NextAttemptAt,
+		"delivered_at":    d.DeliveredAt,
+		"updated_at":      time.Now(),
+	}).Error
+	return errors.Wrapf(err, "failed update certificate webhook delivery %d", d.ID)
+}
+
+// DeleteCertificateWebhookDeliveriesBefore 清理 before 之前创建的已结束的推送记录
+func DeleteCertificateWebhookDeliveriesBefore(before time.Time) (int64, error) {
+	res := db.Where("status <> ? AND created_at < ?", model.WebhookDeliveryPending, before).Delete(&model.CertificateWebhookDelivery{})
+	return res.RowsAffected, errors.WithStack(res.Error)
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestDueCertificateWebhookDeliveries(t *testing.T) {
+	setupTestDB(t)
+	if err := AutoMigrate(new(model.CertificateWebhookDelivery)); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+	deliveries := []model.CertificateWebhookDelivery{
+		{WebhookID: 1, Event: "certificate.issued", Status: model.WebhookDeliveryPending, NextAttemptAt: &past},
+		{WebhookID: 1, Event: "certificate.issued", Status: model.WebhookDeliveryPending, NextAttemptAt: &future},
+		{WebhookID: 2, Event: "certificate.revoked", Status: model.WebhookDeliveryFailed, NextAttemptAt: &past},
+	}
+	if err := CreateCertificateWebhookDeliveries(deliveries); err != nil {
+		t.Fatal(err)
+	}
+	due, err := GetDueCertificateWebhookDeliveries(now, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].NextAttemptAt.After(now) {
+		t.Fatalf("got %d due deliveries, want the pending one that is due", len(due))
+	}
+
+	due[0].Status, due[0].Attempts, due[0].NextAttemptAt = model.WebhookDeliverySucceeded, 1, nil
+	if err := UpdateCertificateWebhookDeliveryResult(&due[0]); err != nil {
+		t.Fatal(err)
+	}
+	if due, _ = GetDueCertificateWebhookDeliveries(now, 10); len(due) != 0 {
+		t.Errorf("got %d due deliveries after success, want 0", len(due))
+	}
+	list, total, err := GetCertificateWebhookDeliveries(1, "", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || len(list) != 2 {
+		t.Errorf("got %d deliveries of webhook 1, want 2", total)
+	}
+}
@@ -10,9 +10,15 @@ import (
 
 var db *gorm.DB
 
+// models are migrated on startup and included in backups
+var models = []any{new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.Certificate), new(model.CertificateRequest), new(model.ApprovalDelegation), new(model.CertificateWatch), new(model.CertificateRequestComment), new(model.CertificateRequestMention), new(model.CertificateEvent), new(model.CertificateRequestField), new(model.CertificateTypeDef), new(model.CertificateApprovalNonce), new(model.NotifyDevice), new(model.CertificateDigestPref), new(model.CertificateFeedToken), new(model.CAMaintenanceWindow), new(model.CertificateContactDigest), new(model.CertificateRequestTemplate), new(model.CertificateAuthority), new(model.CARotation), new(model.LegalHold), new(model.CertificateDownload), new(model.CertificateHoneytoken), new(model.CertificateFreezeWindow), new(model.OwnershipTransfer), new(model.CertificateRequestNote), new(model.CertificateSignature), new(model.SSHHostPrincipal), new(model.CertificateAgent), new(model.SchedulerLease), new(model.CertificateExpiryNotice), new(model.CertificateAuditLog), new(model.CertificateDemoRecord), new(model.CertificateTenantQuota), new(model.CertificateProfile), new(model.CertificateTrustAnchor), new(model.CertificateWebhook), new(model.CertificateWebhookDelivery)}
+
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.Certificate), new(model.CertificateRequest))
+	if err := registerPrivateKeyCallbacks(d); err != nil {
+		log.Fatalf("failed register callbacks: %s", err.Error())
+	}
+	err := AutoMigrate(models...)
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -34,6 +40,7 @@ func GetDb() *gorm.DB {
 
 func Close() {
 	log.Info("closing db")
+	closeReplicas()
 	sqlDB, err := db.DB()
 	if err != nil {
 		log.Errorf("failed to get db: %s", err.Error())
@@ -44,4 +51,13 @@ func Close() {
 		log.Errorf("failed to close db: %s", err.Error())
 		return
 	}
-}
\ No newline at end of file
+}
+
+// Ping checks the database connection
+func Ping() error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetLegalHolds(activeOnly bool) ([]model.LegalHold, error) {
+	var holds []model.LegalHold
+	query := db.Order(fmt.Sprintf("%s DESC", columnName("id")))
+	if activeOnly {
+		query = query.Where("released_at IS NULL")
+	}
+	if err := query.Find(&holds).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get legal holds")
+	}
+	return holds, nil
+}
+
+func GetLegalHoldByID(id uint) (*model.LegalHold, error) {
+	var h model.LegalHold
+	if err := db.First(&h, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get legal hold by id: %d", id)
+	}
+	return &h, nil
+}
+
+// CountActiveLegalHolds 统计目标上生效中的法律保留数量
+func CountActiveLegalHolds(scope string, targetID uint) (int64, error) {
+	var count int64
+	if err := db.Model(&model.LegalHold{}).Where("scope = ? AND target_id = ? AND released_at IS NULL", scope, targetID).
+		Count(&count).Error; err != nil {
+		return 0, errors.Wrapf(err, "failed count legal holds")
+	}
+	return count, nil
+}
+
+// GetHeldTenantIDs 获取处于法律保留中的租户的用户 ID
+func GetHeldTenantIDs() ([]uint, error) {
+	var ids []uint
+	if err := db.Model(&model.LegalHold{}).Where("scope = ? AND released_at IS NULL", model.LegalHoldTenant).
+		Distinct().Pluck("target_id", &ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get held tenants")
+	}
+	return ids, nil
+}
+
+func CreateLegalHold(h *model.LegalHold) error {
+	return errors.WithStack(db.Create(h).Error)
+}
+
+func UpdateLegalHold(h *model.LegalHold) error {
+	return errors.WithStack(db.Save(h).Error)
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+func GetNotifyDevicesByUserID(userID uint) ([]model.NotifyDevice, error) {
+	var devices []model.NotifyDevice
+	if err := db.Where("user_id = ?", userID).Order(columnName("id")).Find(&devices).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get notify devices of user id: %d", userID)
+	}
+	return devices, nil
+}
+
+func GetNotifyDevicesByUserIDs(userIDs []uint) ([]model.NotifyDevice, error) {
+	var devices []model.NotifyDevice
+	if len(userIDs) == 0 {
+		return devices, nil
+	}
+	if err := db.Where("user_id IN ?", userIDs).Find(&devices).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get notify devices")
+	}
+	return devices, nil
+}
+
+// SaveNotifyDevice 注册设备，令牌已存在时转移到当前用户
+func SaveNotifyDevice(d *model.NotifyDevice) error {
+	return errors.WithStack(db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "token"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "platform", "name", "updated_at"}),
+	}).Create(d).Error)
+}
+
+func DeleteNotifyDeviceByToken(token string) error {
+	return errors.WithStack(db.Where("token = ?", token).Delete(&model.NotifyDevice{}).Error)
+}
+
+func DeleteNotifyDeviceByIDAndUserID(id, userID uint) error {
+	res := db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.NotifyDevice{})
+	if res.Error != nil {
+		return errors.WithStack(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("device not found")
+	}
+	return nil
+}
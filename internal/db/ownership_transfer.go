@@ -0,0 +1,71 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetOwnershipTransfers 获取全部所有权转移任务，不包含逐条结果
+func GetOwnershipTransfers() ([]model.OwnershipTransfer, error) {
+	var transfers []model.OwnershipTransfer
+	if err := db.Omit("results").Order(columnName("id") + " DESC").Find(&transfers).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ownership transfers")
+	}
+	return transfers, nil
+}
+
+func GetOwnershipTransferByID(id uint) (*model.OwnershipTransfer, error) {
+	var t model.OwnershipTransfer
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ownership transfer")
+	}
+	return &t, nil
+}
+
+func CreateOwnershipTransfer(t *model.OwnershipTransfer) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateOwnershipTransfer(t *model.OwnershipTransfer) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+// GetUsersByOrganization 获取组织内的全部用户
+func GetUsersByOrganization(org string) ([]model.User, error) {
+	var users []model.User
+	if err := db.Where("organization = ?", org).Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get users of organization")
+	}
+	return users, nil
+}
+
+// GetCertificatesByOwnerIDs 获取指定用户名下的全部证书
+func GetCertificatesByOwnerIDs(ownerIDs []uint) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("owner_id IN ?", ownerIDs).Order(columnName("id")).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates by owners")
+	}
+	return certs, nil
+}
+
+// GetOpenCertificateRequestsByUserIDs 获取指定用户尚未完成的申请，包括草稿、待审批和计划签发的申请
+func GetOpenCertificateRequestsByUserIDs(userIDs []uint) ([]model.CertificateRequest, error) {
+	var requests []model.CertificateRequest
+	statuses := []model.CertificateStatus{model.CertificateStatusDraft, model.CertificateStatusPending, model.CertificateStatusScheduled}
+	if err := db.Where("user_id IN ? AND status IN ?", userIDs, statuses).Order(columnName("id")).Find(&requests).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get open certificate requests by users")
+	}
+	return requests, nil
+}
+
+// TransferCertificateOwner 修改证书所有者，只更新所有者字段
+func TransferCertificateOwner(id, ownerID uint, owner string) error {
+	return errors.WithStack(db.Model(&model.Certificate{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"owner_id": ownerID, "owner": owner}).Error)
+}
+
+// TransferCertificateRequestOwner 修改申请的申请人，只更新申请人字段
+func TransferCertificateRequestOwner(id, userID uint, username string) error {
+	return errors.WithStack(db.Model(&model.CertificateRequest{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"user_id": userID, "user_name": username}).Error)
+}
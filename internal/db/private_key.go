@@ -0,0 +1,315 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/envelope"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// keyWrapper wraps the data keys of private keys stored in the database,
+// private keys are stored in plaintext when it is nil
+var keyWrapper envelope.KeyWrapper
+
+// previousKeyWrappers still open private keys sealed with rotated key encryption keys
+// until RewrapPrivateKeys seals them again with keyWrapper
+var previousKeyWrappers []envelope.KeyWrapper
+
+func init() {
+	schema.RegisterSerializer("privatekey", privateKeySerializer{})
+}
+
+// SetKeyWrapper sets the key encryption key used for private keys and the rotated keys that
+// may still be needed to read them, it must be called before Init
+func SetKeyWrapper(w envelope.KeyWrapper, previous ...envelope.KeyWrapper) {
+	keyWrapper = w
+	previousKeyWrappers = previous
+}
+
+// privateKeyColumns are the columns stored with the privatekey serializer
+var privateKeyColumns = []struct {
+	model  any
+	column string
+}{
+	{new(model.Certificate), "private_key"},
+	{new(model.CertificateAuthority), "private_key"},
+	{new(model.CertificateWebhook), "secret"},
+}
+
+// sealedSettings are the settings written with SealPrivateKey
+var sealedSettings = []string{conf.CertACMEAccountKey}
+
+// privateKeyAAD binds a sealed value to the table, column and primary key it is stored in,
+// so that it can not be copied onto another row. The model name is used instead of the
+// table name to stay independent of the table prefix
+func privateKeyAAD(name, column string, id any) []byte {
+	return []byte(fmt.Sprintf("%s.%s:%v", name, column, id))
+}
+
+// modelName returns the name of the schema of m as used by privateKeyAAD
+func modelName(m any) string {
+	return reflect.Indirect(reflect.ValueOf(m)).Type().Name()
+}
+
+// rowAAD returns the additional data of a private key column of the row dst
+func rowAAD(ctx context.Context, field *schema.Field, dst reflect.Value) []byte {
+	var id any
+	if pk := field.Schema.PrioritizedPrimaryField; pk != nil {
+		id, _ = pk.ValueOf(ctx, dst)
+	}
+	return privateKeyAAD(field.Schema.Name, field.DBName, id)
+}
+
+// settingAAD returns the additional data of a private key stored in a setting
+func settingAAD(key string) []byte {
+	return privateKeyAAD(modelName(model.SettingItem{}), "value", key)
+}
+
+// privateKeySerializer transparently encrypts private key columns on write and decrypts them on read.
+// Plaintext values written before encryption was enabled are read as is until EncryptPrivateKeys rewrites them.
+// The primary key of a new row is not known while it is inserted, bindPrivateKeys seals it again afterwards
+type privateKeySerializer struct{}
+
+func (privateKeySerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case nil:
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	default:
+		return fmt.Errorf("unsupported private key column value %T", dbValue)
+	}
+	value, err := openPrivateKey(value, rowAAD(ctx, field, dst))
+	if err != nil {
+		return err
+	}
+	return field.Set(ctx, dst, value)
+}
+
+func (privateKeySerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	return sealPrivateKey(value, rowAAD(ctx, field, dst))
+}
+
+// bindPrivateKeys seals the private keys of created rows again with their primary key,
+// it is registered after gorm:create
+func bindPrivateKeys(tx *gorm.DB) {
+	s := tx.Statement.Schema
+	if tx.Error != nil || keyWrapper == nil || s == nil || s.PrioritizedPrimaryField == nil {
+		return
+	}
+	var fields []*schema.Field
+	for _, field := range s.Fields {
+		if _, ok := field.Serializer.(privateKeySerializer); ok {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	ctx := tx.Statement.Context
+	var rows []reflect.Value
+	switch rv := reflect.Indirect(tx.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			rows = append(rows, reflect.Indirect(rv.Index(i)))
+		}
+	case reflect.Struct:
+		rows = append(rows, rv)
+	}
+	for _, row := range rows {
+		id, zero := s.PrioritizedPrimaryField.ValueOf(ctx, row)
+		if zero {
+			continue
+		}
+		for _, field := range fields {
+			value, _ := field.ReflectValueOf(ctx, row).Interface().(string)
+			// values that were already sealed are stored as given
+			if value == "" || envelope.IsSealed(value) {
+				continue
+			}
+			sealed, err := sealPrivateKey(value, privateKeyAAD(s.Name, field.DBName, id))
+			if err != nil {
+				_ = tx.AddError(err)
+				return
+			}
+			err = tx.Session(&gorm.Session{NewDB: true}).Table(tx.Statement.Table).
+				Where(s.PrioritizedPrimaryField.DBName+" = ?", id).UpdateColumn(field.DBName, sealed).Error
+			if err != nil {
+				_ = tx.AddError(errors.WithStack(err))
+				return
+			}
+		}
+	}
+}
+
+// registerPrivateKeyCallbacks runs bindPrivateKeys in the same transaction as the insert
+func registerPrivateKeyCallbacks(d *gorm.DB) error {
+	return d.Callback().Create().After("gorm:create").Before("gorm:commit_or_rollback_transaction").
+		Register("openlist:bind_private_keys", bindPrivateKeys)
+}
+
+// SealPrivateKey encrypts a private key stored in the setting key instead of a private key column.
+// The value is returned as is when it is empty, already sealed or no key encryption key is configured
+func SealPrivateKey(key, value string) (string, error) {
+	return sealPrivateKey(value, settingAAD(key))
+}
+
+// OpenPrivateKey decrypts a value written by SealPrivateKey, plaintext values are returned as is
+func OpenPrivateKey(key, value string) (string, error) {
+	return openPrivateKey(value, settingAAD(key))
+}
+
+func sealPrivateKey(value string, aad []byte) (string, error) {
+	if value == "" || keyWrapper == nil || envelope.IsSealed(value) {
+		return value, nil
+	}
+	sealed, err := envelope.Seal(keyWrapper, []byte(value), aad)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to encrypt private key")
+	}
+	return sealed, nil
+}
+
+func openPrivateKey(value string, aad []byte) (string, error) {
+	if !envelope.IsSealed(value) {
+		return value, nil
+	}
+	if keyWrapper == nil {
+		return "", errors.New("private key is encrypted but no key encryption key is configured")
+	}
+	plaintext, err := envelope.Open(wrapperOf(value), value, aad)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to decrypt private key")
+	}
+	return string(plaintext), nil
+}
+
+// wrapperOf returns the configured or rotated key encryption key a value was sealed with,
+// the current one is returned when none matches so that the error names both keys
+func wrapperOf(sealed string) envelope.KeyWrapper {
+	id := envelope.KeyID(sealed)
+	for _, w := range previousKeyWrappers {
+		if w.KeyID() == id && keyWrapper.KeyID() != id {
+			return w
+		}
+	}
+	return keyWrapper
+}
+
+// privateKeyRow reads the raw private key column, bypassing the serializer
+type privateKeyRow struct {
+	ID         uint
+	PrivateKey string
+}
+
+// EncryptPrivateKeys encrypts the private keys of certificates and certificate authorities
+// still stored in plaintext, returning the number of rows rewritten
+func EncryptPrivateKeys() (certs int, cas int, err error) {
+	if keyWrapper == nil {
+		return 0, 0, errors.New("no key encryption key is configured")
+	}
+	if certs, err = rewritePrivateKeys(new(model.Certificate), "private_key", encryptPlaintext); err != nil {
+		return 0, 0, err
+	}
+	if cas, err = rewritePrivateKeys(new(model.CertificateAuthority), "private_key", encryptPlaintext); err != nil {
+		return certs, 0, err
+	}
+	return certs, cas, nil
+}
+
+// RewrapPrivateKeys seals all encrypted private keys and secrets again with the configured
+// key encryption key, for rotating the key or binding values sealed before the additional
+// data was introduced to their rows. It returns the number of values rewritten
+func RewrapPrivateKeys() (int, error) {
+	if keyWrapper == nil {
+		return 0, errors.New("no key encryption key is configured")
+	}
+	n := 0
+	for _, c := range privateKeyColumns {
+		rewritten, err := rewritePrivateKeys(c.model, c.column, rewrapSealed)
+		n += rewritten
+		if err != nil {
+			return n, err
+		}
+	}
+	for _, key := range sealedSettings {
+		item, err := GetSettingItemByKey(key)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return n, err
+		}
+		value, ok, err := rewrapSealed(item.Value, settingAAD(key))
+		if err != nil {
+			return n, errors.WithMessagef(err, "failed to rewrap setting %s", key)
+		}
+		if !ok {
+			continue
+		}
+		item.Value = value
+		if err := SaveSettingItem(item); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// encryptPlaintext seals a value still stored in plaintext
+func encryptPlaintext(value string, aad []byte) (string, bool, error) {
+	if envelope.IsSealed(value) {
+		return "", false, nil
+	}
+	sealed, err := envelope.Seal(keyWrapper, []byte(value), aad)
+	return sealed, err == nil, err
+}
+
+// rewrapSealed seals a value again when it was sealed with a rotated key or without additional data
+func rewrapSealed(value string, aad []byte) (string, bool, error) {
+	if !envelope.IsSealed(value) || !envelope.NeedsRewrap(keyWrapper, value) {
+		return "", false, nil
+	}
+	plaintext, err := openPrivateKey(value, aad)
+	if err != nil {
+		return "", false, err
+	}
+	sealed, err := envelope.Seal(keyWrapper, []byte(plaintext), aad)
+	return sealed, err == nil, err
+}
+
+// rewritePrivateKeys applies fn to the raw values of a private key column, writing back the values it changed
+func rewritePrivateKeys(m any, column string, fn func(value string, aad []byte) (string, bool, error)) (int, error) {
+	var rows []privateKeyRow
+	err := db.Model(m).Unscoped().Select("id", column+" AS private_key").Where(column+" <> ?", "").Scan(&rows).Error
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	name := modelName(m)
+	n := 0
+	for _, row := range rows {
+		value, ok, err := fn(row.PrivateKey, privateKeyAAD(name, column, row.ID))
+		if err != nil {
+			return n, errors.WithMessagef(err, "failed to rewrite %s of %s %d", column, name, row.ID)
+		}
+		if !ok {
+			continue
+		}
+		// sealed values are passed through by the serializer
+		err = db.Model(m).Unscoped().Where("id = ?", row.ID).UpdateColumn(column, value).Error
+		if err != nil {
+			return n, errors.WithStack(err)
+		}
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var (
+	replicas    []*gorm.DB
+	replicaNext atomic.Uint64
+)
+
+// InitReplicas registers read replicas used by heavy read queries such as
+// inventory lists, exports and search. Mutations always go to the primary.
+func InitReplicas(rs []*gorm.DB) {
+	replicas = rs
+	if len(rs) > 0 {
+		log.Infof("using %d database read replica(s)", len(rs))
+	}
+}
+
+// readDB returns the next read replica in round-robin order, or the primary
+// when no replica is configured. Results may lag slightly behind the primary,
+// so it must not be used for reads that are followed by a write.
+func readDB() *gorm.DB {
+	if len(replicas) == 0 {
+		return db
+	}
+	return replicas[(replicaNext.Add(1)-1)%uint64(len(replicas))]
+}
+
+func closeReplicas() {
+	for _, r := range replicas {
+		sqlDB, err := r.DB()
+		if err != nil {
+			log.Errorf("failed to get replica db: %s", err.Error())
+			continue
+		}
+		if err := sqlDB.Close(); err != nil {
+			log.Errorf("failed to close replica db: %s", err.Error())
+		}
+	}
+}
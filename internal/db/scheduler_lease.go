@@ -0,0 +1,46 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm/clause"
+)
+
+// AcquireSchedulerLease takes or renews the lease when it is free, expired or already held by holder.
+// The conditional update is atomic, so at most one instance holds an unexpired lease.
+func AcquireSchedulerLease(name, holder string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	lease := model.SchedulerLease{Name: name, Holder: holder, ExpiresAt: now.Add(ttl)}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lease).Error; err != nil {
+		return false, errors.Wrapf(err, "failed create scheduler lease %s", name)
+	}
+	err := db.Model(&model.SchedulerLease{}).
+		Where(fmt.Sprintf("%s = ? AND (holder = ? OR expires_at < ?)", columnName("name")), name, holder, now).
+		UpdateColumns(map[string]any{"holder": holder, "expires_at": now.Add(ttl), "updated_at": now}).Error
+	if err != nil {
+		return false, errors.Wrapf(err, "failed update scheduler lease %s", name)
+	}
+	// RowsAffected is unreliable on MySQL when the values don't change, read the lease back instead
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("name")), name).First(&lease).Error; err != nil {
+		return false, errors.Wrapf(err, "failed get scheduler lease %s", name)
+	}
+	return lease.Holder == holder && lease.ExpiresAt.After(now), nil
+}
+
+// ReleaseSchedulerLease expires the lease if it is held by holder, so another instance can take over right away
+func ReleaseSchedulerLease(name, holder string) error {
+	return errors.WithStack(db.Model(&model.SchedulerLease{}).
+		Where(fmt.Sprintf("%s = ? AND holder = ?", columnName("name")), name, holder).
+		UpdateColumn("expires_at", time.Now()).Error)
+}
+
+func GetSchedulerLeases() ([]model.SchedulerLease, error) {
+	var leases []model.SchedulerLease
+	if err := db.Order(columnName("name")).Find(&leases).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get scheduler leases")
+	}
+	return leases, nil
+}
@@ -56,26 +56,27 @@ func GetSearchNodesByParent(parent string) ([]model.SearchNode, error) {
 
 func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int64, error) {
 	var searchDB *gorm.DB
+	rdb := readDB()
 	if !useFullText || conf.Conf.Database.Type == "sqlite3" {
-		keywordsClause := db.Where("1 = 1")
+		keywordsClause := rdb.Where("1 = 1")
 		for _, keyword := range strings.Fields(req.Keywords) {
 			keywordsClause = keywordsClause.Where("name LIKE ?", fmt.Sprintf("%%%s%%", keyword))
 		}
-		searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
+		searchDB = rdb.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).Where(keywordsClause)
 	} else {
 		switch conf.Conf.Database.Type {
 		case "mysql":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
+			searchDB = rdb.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
 				Where("MATCH (name) AGAINST (? IN BOOLEAN MODE)", "'*"+req.Keywords+"*'")
 		case "postgres":
-			searchDB = db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
+			searchDB = rdb.Model(&model.SearchNode{}).Where(whereInParent(req.Parent)).
 				Where("to_tsvector(name) @@ to_tsquery(?)", strings.Join(strings.Fields(req.Keywords), " & "))
 		}
 	}
 
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
-		searchDB.Where(db.Where("is_dir = ?", isDir))
+		searchDB.Where(rdb.Where("is_dir = ?", isDir))
 	}
 
 	var count int64
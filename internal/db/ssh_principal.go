@@ -0,0 +1,47 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetSSHHostPrincipals() ([]model.SSHHostPrincipal, error) {
+	var principals []model.SSHHostPrincipal
+	if err := db.Order(columnName("principal")).Find(&principals).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ssh host principals")
+	}
+	return principals, nil
+}
+
+func GetSSHHostPrincipalByID(id uint) (*model.SSHHostPrincipal, error) {
+	var p model.SSHHostPrincipal
+	if err := db.First(&p, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get ssh host principal by id: %d", id)
+	}
+	return &p, nil
+}
+
+func CreateSSHHostPrincipal(p *model.SSHHostPrincipal) error {
+	return errors.WithStack(db.Create(p).Error)
+}
+
+func UpdateSSHHostPrincipal(p *model.SSHHostPrincipal) error {
+	return errors.WithStack(db.Save(p).Error)
+}
+
+func DeleteSSHHostPrincipal(id uint) error {
+	return errors.WithStack(db.Delete(&model.SSHHostPrincipal{}, id).Error)
+}
+
+// GetActiveCertificatesByType 获取指定类型的有效且未过期的证书
+func GetActiveCertificatesByType(t model.CertificateType) ([]model.Certificate, error) {
+	var certs []model.Certificate
+	if err := db.Where("type = ? AND (status = ? OR status = ?) AND expiration_date > ?",
+		t, model.CertificateStatusValid, model.CertificateStatusExpiring, time.Now()).
+		Order(columnName("id")).Find(&certs).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificates of type: %s", t)
+	}
+	return certs, nil
+}
@@ -0,0 +1,60 @@
+package db
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Tx 在同一个数据库事务中执行的访问方法，包级的同名函数在事务外使用
+type Tx struct {
+	tx *gorm.DB
+}
+
+// Transaction 在一个数据库事务中执行 fn，fn 返回错误或 panic 时回滚
+func Transaction(fn func(tx Tx) error) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		return fn(Tx{tx: tx})
+	}))
+}
+
+// LockCertificateRequest 读取并锁定申请，直到事务结束，数据库不支持行锁时只读取
+func (t Tx) LockCertificateRequest(id uint) (*model.CertificateRequest, error) {
+	var req model.CertificateRequest
+	if err := t.tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&req, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate request by id: %d", id)
+	}
+	return &req, nil
+}
+
+// LockCertificate 读取并锁定证书，直到事务结束，数据库不支持行锁时只读取
+func (t Tx) LockCertificate(id uint) (*model.Certificate, error) {
+	var cert model.Certificate
+	if err := t.tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cert, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get certificate by id: %d", id)
+	}
+	return &cert, nil
+}
+
+func (t Tx) CreateCertificate(cert *model.Certificate) error {
+	return errors.WithStack(t.tx.Create(cert).Error)
+}
+
+func (t Tx) UpdateCertificate(cert *model.Certificate) error {
+	return errors.WithStack(t.tx.Save(cert).Error)
+}
+
+func (t Tx) CreateCertificateRequest(req *model.CertificateRequest) error {
+	return errors.WithStack(t.tx.Create(req).Error)
+}
+
+// UpdateCertificateRequest 保存申请，关联的外部工单只由 UpdateCertificateRequestTicket 更新，
+// 避免异步创建的工单被同时进行的审批覆盖
+func (t Tx) UpdateCertificateRequest(req *model.CertificateRequest) error {
+	return errors.WithStack(t.tx.Omit("ticket_id", "ticket_url").Save(req).Error)
+}
+
+func (t Tx) CreateCertificateEvent(e *model.CertificateEvent) error {
+	return errors.WithStack(t.tx.Create(e).Error)
+}
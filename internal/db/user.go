@@ -2,11 +2,14 @@ package db
 
 import (
 	"encoding/base64"
+	"fmt"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/go-webauthn/webauthn/webauthn"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 func GetUserByRole(role int) (*model.User, error) {
@@ -33,6 +36,26 @@ func GetUserBySSOID(ssoID string) (*model.User, error) {
 	return &user, nil
 }
 
+func GetUserBySlackID(slackID string) (*model.User, error) {
+	user := model.User{SlackID: slackID}
+	if err := db.Where(user).First(&user).Error; err != nil {
+		return nil, errors.Wrapf(err, "The slack user is not bound to any users")
+	}
+	return &user, nil
+}
+
+// GetUserByEmail 按邮箱查找用户，不区分大小写，多个用户使用同一邮箱时视为找不到
+func GetUserByEmail(email string) (*model.User, error) {
+	var users []model.User
+	if err := db.Where(fmt.Sprintf("LOWER(%s) = ?", columnName("email")), strings.ToLower(email)).Limit(2).Find(&users).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(users) != 1 {
+		return nil, errors.Wrapf(gorm.ErrRecordNotFound, "no unique user with email %s", email)
+	}
+	return &users[0], nil
+}
+
 func GetUserById(id uint) (*model.User, error) {
 	var u model.User
 	if err := db.First(&u, id).Error; err != nil {
@@ -100,3 +123,10 @@ func RemoveAuthn(u *model.User, id string) error {
 	}
 	return UpdateAuthn(u.ID, string(res))
 }
+
+func GetUsersByRole(role int) (users []model.User, err error) {
+	if err := db.Where(model.User{Role: role}).Find(&users).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get users by role")
+	}
+	return users, nil
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestGetUserByEmail(t *testing.T) {
+	setupTestDB(t)
+	users := []*model.User{
+		{Username: "alice", Email: "Alice@Example.com"},
+		{Username: "bob", Email: "shared@example.com"},
+		{Username: "carol", Email: "shared@example.com"},
+	}
+	for _, u := range users {
+		if err := CreateUser(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	u, err := GetUserByEmail("alice@example.COM")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Username != "alice" {
+		t.Errorf("found %s, want alice", u.Username)
+	}
+	// 多个用户使用同一邮箱时无法确定发件人
+	if _, err := GetUserByEmail("shared@example.com"); err == nil {
+		t.Error("expected an error for a shared email")
+	}
+	if _, err := GetUserByEmail("nobody@example.com"); err == nil {
+		t.Error("expected an error for an unknown email")
+	}
+}
@@ -0,0 +1,43 @@
+package errs
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	InvalidCertificateRequest = errors.New("invalid certificate request")
+	InvalidCertificateContent = errors.New("invalid certificate content")
+	// CertificateConflict means the operation does not fit the current state of the target,
+	// e.g. approving a request that is no longer pending
+	CertificateConflict = errors.New("certificate state conflict")
+)
+
+// CertificateContentProblem describes one problem found in the content of a certificate
+type CertificateContentProblem struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// CertificateContentError lists every problem found in the content of a certificate,
+// use errors.Is(err, InvalidCertificateContent) to check it
+type CertificateContentError struct {
+	Problems []CertificateContentProblem
+}
+
+func (e *CertificateContentError) Add(field, code, message string) {
+	e.Problems = append(e.Problems, CertificateContentProblem{Field: field, Code: code, Message: message})
+}
+
+func (e *CertificateContentError) Error() string {
+	msgs := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		msgs[i] = p.Field + ": " + p.Message
+	}
+	return InvalidCertificateContent.Error() + "; " + strings.Join(msgs, "; ")
+}
+
+func (e *CertificateContentError) Unwrap() error {
+	return InvalidCertificateContent
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// CA 轮换的状态
+const (
+	CARotationRunning   = "running"
+	CARotationCompleted = "completed"
+	CARotationCancelled = "cancelled"
+)
+
+// CARotation CA 轮换任务：新旧 CA 同时发布，后台分批将旧 CA 签发的证书重新签发到新 CA，
+// 旧 CA 不再被有效证书引用后自动停用
+type CARotation struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	FromCAID      uint       `json:"from_ca_id" gorm:"index"`
+	ToCAID        uint       `json:"to_ca_id"`
+	CrossSignedID uint       `json:"cross_signed_id,omitempty"` // 旧 CA 为新 CA 交叉签名的证书，轮换期间一起发布
+	Status        string     `json:"status" gorm:"index"`
+	BatchSize     int        `json:"batch_size"` // 每轮重新签发的证书数量
+	Total         int        `json:"total"`      // 开始时引用旧 CA 的有效证书数量
+	Reissued      int        `json:"reissued"`   // 已重新签发的数量
+	Failed        int        `json:"failed"`     // 重新签发失败的次数
+	LastError     string     `json:"last_error,omitempty" gorm:"type:text"`
+	StartedBy     string     `json:"started_by"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// IsRunning 检查轮换是否仍在进行
+func (r *CARotation) IsRunning() bool {
+	return r.Status == CARotationRunning
+}
+
+// CARotationProgress 轮换进度
+type CARotationProgress struct {
+	CARotation
+	Remaining int64   `json:"remaining"` // 仍引用旧 CA 的有效证书数量
+	Percent   float64 `json:"percent"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// CAStateFormat CA 状态导出文件的格式版本
+const CAStateFormat = "openlist-ca-state/v1"
+
+// CAState CA 的完整状态：签发证书索引（含吊销状态）、申请、生命周期事件、类型和字段定义以及 CA 证书
+type CAState struct {
+	Certificates []Certificate             `json:"certificates"`
+	Requests     []CertificateRequest      `json:"requests"`
+	Events       []CertificateEvent        `json:"events"`
+	Types        []CertificateTypeDef      `json:"types"`
+	Fields       []CertificateRequestField `json:"fields"`
+	Authorities  []CertificateAuthority    `json:"authorities"`
+	// AuthorityKeys 按指纹保存的 CA 签名私钥，私钥不随 CA 的 JSON 输出，只写入加密的导出文件
+	AuthorityKeys map[string]string `json:"authority_keys,omitempty"`
+	// CertificateKeys 按证书 ID 保存的服务端私钥
+	CertificateKeys map[uint]string `json:"certificate_keys,omitempty"`
+}
+
+// CAStateBundle 加密后的 CA 状态导出文件，数据使用口令派生的密钥以 AES-256-GCM 加密
+type CAStateBundle struct {
+	Format     string    `json:"format"`
+	ExportedAt time.Time `json:"exported_at"`
+	ExportedBy string    `json:"exported_by"`
+	KDF        string    `json:"kdf"`
+	Salt       []byte    `json:"salt"`
+	Nonce      []byte    `json:"nonce"`
+	Digest     string    `json:"digest"` // 明文状态的 sha256，导入时校验
+	Data       []byte    `json:"data"`
+}
+
+// CAStateSummary 导入结果
+type CAStateSummary struct {
+	Certificates int `json:"certificates"`
+	Requests     int `json:"requests"`
+	Events       int `json:"events"`
+	Types        int `json:"types"`
+	Fields       int `json:"fields"`
+	Authorities  int `json:"authorities"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// CA 状态页中组件的状态
+const (
+	CAStatusOperational = "operational"
+	CAStatusDegraded    = "degraded"
+	CAStatusDown        = "down"
+	CAStatusMaintenance = "maintenance"
+)
+
+// CAMaintenanceWindow 计划维护窗口，在公开状态页中展示
+type CAMaintenanceWindow struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Title       string    `json:"title" gorm:"not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	StartAt     time.Time `json:"start_at" gorm:"index"`
+	EndAt       time.Time `json:"end_at" gorm:"index"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// IsActive 检查维护窗口是否正在进行
+func (w *CAMaintenanceWindow) IsActive(t time.Time) bool {
+	return !t.Before(w.StartAt) && t.Before(w.EndAt)
+}
+
+// CAStatusComponent 状态页中单个组件的检查结果
+type CAStatusComponent struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// CAStatus 公开状态页的内容
+type CAStatus struct {
+	Status      string                `json:"status"`
+	Components  []CAStatusComponent   `json:"components"`
+	Maintenance []CAMaintenanceWindow `json:"maintenance"`
+	CheckedAt   time.Time             `json:"checked_at"`
+}
@@ -2,6 +2,8 @@ package model
 
 import (
 	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/certlint"
 	"gorm.io/gorm"
 )
 
@@ -9,53 +11,137 @@ import (
 type CertificateType string
 
 const (
-	CertificateTypeUser CertificateType = "user" // 用户证书
-	CertificateTypeNode CertificateType = "node" // 节点证书
+	CertificateTypeUser   CertificateType = "user"   // 用户证书
+	CertificateTypeNode   CertificateType = "node"   // 节点证书
+	CertificateTypeSSH    CertificateType = "ssh"    // SSH 证书，内容为 OpenSSH 证书(authorized_keys 格式)
+	CertificateTypePublic CertificateType = "public" // 公开信任的证书，批准后通过 ACME 向公共 CA 下单
 )
 
 // CertificateStatus 证书状态
 type CertificateStatus string
 
 const (
-	CertificateStatusPending  CertificateStatus = "pending"   // 待审批
-	CertificateStatusValid    CertificateStatus = "valid"     // 有效
-	CertificateStatusExpiring CertificateStatus = "expiring"  // 即将过期
-	CertificateStatusRevoked  CertificateStatus = "revoked"   // 已吊销
-	CertificateStatusRejected CertificateStatus = "rejected"  // 已拒绝
+	CertificateStatusDraft     CertificateStatus = "draft"     // 草稿，提交前审批人不可见
+	CertificateStatusPending   CertificateStatus = "pending"   // 待审批
+	CertificateStatusScheduled CertificateStatus = "scheduled" // 已批准，等待计划时间签发
+	CertificateStatusValid     CertificateStatus = "valid"     // 有效
+	CertificateStatusExpiring  CertificateStatus = "expiring"  // 即将过期
+	CertificateStatusExpired   CertificateStatus = "expired"   // 已过期，由定时任务在到期后标记
+	CertificateStatusRevoked   CertificateStatus = "revoked"   // 已吊销
+	CertificateStatusHold      CertificateStatus = "hold"      // 已挂起，可解除挂起恢复有效
+	CertificateStatusRejected  CertificateStatus = "rejected"  // 已拒绝
 )
 
+// RevocationReasonCertificateHold 证书挂起的吊销原因，对应 RFC 5280 的 certificateHold，
+// CRL 和 OCSP 输出时据此区分挂起与永久吊销
+const RevocationReasonCertificateHold = "certificateHold"
+
+// RevocationReasonSuperseded 证书已被重新签发的证书取代，例如 CA 轮换
+const RevocationReasonSuperseded = "superseded"
+
+// RevocationReasonKeyCompromise 证书私钥已泄露
+const RevocationReasonKeyCompromise = "keyCompromise"
+
 // Certificate 证书实体
 type Certificate struct {
-	ID             uint              `json:"id" gorm:"primaryKey"`                        // unique key
-	Name           string            `json:"name" gorm:"not null;index"`                 // 证书名称
-	Type           CertificateType   `json:"type" gorm:"not null;index"`                 // 证书类型
-	Status         CertificateStatus `json:"status" gorm:"not null;index"`               // 证书状态
-	Owner          string            `json:"owner" gorm:"not null;index"`                // 证书所有者(用户名)
-	OwnerID        uint              `json:"owner_id" gorm:"index"`                      // 证书所有者ID
-	Content        string            `json:"content" gorm:"type:text"`                   // 证书内容(PEM格式)
-	IssuedDate     time.Time         `json:"issued_date"`                                // 颁发日期
-	ExpirationDate time.Time         `json:"expiration_date"`                            // 过期日期
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt    `gorm:"index" json:"deleted_at,omitempty"`
+	ID                uint               `json:"id" gorm:"primaryKey"`                     // unique key
+	Name              string             `json:"name" gorm:"not null;index"`               // 证书名称
+	Type              CertificateType    `json:"type" gorm:"not null;index"`               // 证书类型
+	Status            CertificateStatus  `json:"status" gorm:"not null;index"`             // 证书状态
+	Owner             string             `json:"owner" gorm:"not null;index"`              // 证书所有者(用户名)
+	OwnerID           uint               `json:"owner_id" gorm:"index"`                    // 证书所有者ID
+	RequestID         uint               `json:"request_id" gorm:"index"`                  // 来源申请ID，手动创建的证书为0
+	IssuerID          uint               `json:"issuer_id" gorm:"index"`                   // 签发该证书的 CA，0 表示未关联 CA
+	ProfileID         uint               `json:"profile_id,omitempty"`                     // 签发时使用的签发配置，续期和重新签发沿用
+	SupersedesID      uint               `json:"supersedes_id,omitempty" gorm:"index"`     // 续期时被本证书取代的旧证书
+	SupersededByID    uint               `json:"superseded_by_id,omitempty"`               // 取代本证书的续期证书，重叠期内新旧证书同时有效
+	AutoRenew         bool               `json:"auto_renew"`                               // 到期前按续期提前天数自动续期，续期证书沿用该设置
+	Sandbox           bool               `json:"sandbox" gorm:"default:false;index"`       // 由不受信任的沙箱 CA 签发的测试证书，不计入配额，不提醒到期也不续期
+	Content           string             `json:"content" gorm:"type:text"`                 // 证书内容(PEM格式)
+	PrivateKey        string             `json:"-" gorm:"type:text;serializer:privatekey"` // 服务端保管的私钥(PEM格式)，用于代替租户签名文件，配置主密钥后加密存储
+	SerialNumber      string             `json:"serial_number" gorm:"index"`               // 证书序列号(十六进制)，由证书内容解析
+	Fingerprint       string             `json:"fingerprint" gorm:"index"`                 // DER 的 sha256 指纹(十六进制)，由证书内容解析
+	Lints             []certlint.Finding `json:"lints,omitempty" gorm:"serializer:json"`   // 签发或导入时对证书内容的 RFC 5280 和 BR 检查结果
+	ResponsibleTeam   string             `json:"responsible_team"`                         // 负责团队
+	ContactEmail      string             `json:"contact_email"`                            // 联系人邮箱，所有者账号失效时到期提醒和事件通知仍能送达
+	EscalationContact string             `json:"escalation_contact"`                       // 升级联系人邮箱，接收告警事件和临近到期的提醒
+	IssuedDate        time.Time          `json:"issued_date"`                              // 颁发日期
+	ExpirationDate    time.Time          `json:"expiration_date"`                          // 过期日期
+	ExpiryBucket      int                `json:"expiry_bucket" gorm:"index"`               // 到期分桶，见 ExpiryBucketOf
+	RevokedAt         *time.Time         `json:"revoked_at,omitempty"`                     // 吊销时间
+	RevocationReason  string             `json:"revocation_reason,omitempty"`              // 吊销原因，挂起时为 certificateHold
+	HeldAt            *time.Time         `json:"held_at,omitempty"`                        // 挂起时间
+	DownloadCount     int64              `json:"download_count"`                           // 下载次数
+	LastDownloadedAt  *time.Time         `json:"last_downloaded_at,omitempty"`             // 最近一次下载时间
+	LastDownloadedBy  string             `json:"last_downloaded_by,omitempty"`             // 最近一次下载的用户
+	CreatedAt         time.Time          `json:"created_at"`
+	UpdatedAt         time.Time          `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt     `gorm:"index" json:"deleted_at,omitempty"`
+
+	OwnerInfo  *CertificateUserInfo `json:"owner_info,omitempty" gorm:"-"`  // 列表中预加载的所有者信息
+	IssuerName string               `json:"issuer_name,omitempty" gorm:"-"` // 列表中预加载的签发 CA 名称
+}
+
+// CertificateUserInfo 证书列表中展示的用户信息
+type CertificateUserInfo struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+	Role     int    `json:"role"`
+	Disabled bool   `json:"disabled"`
+}
+
+// CertificateApprovalInput 审批时提交的内容
+type CertificateApprovalInput struct {
+	NotBefore      *time.Time `json:"not_before"`      // 计划签发时间，为空时立即签发
+	Justification  string     `json:"justification"`   // 批准理由
+	Checklist      []string   `json:"checklist"`       // 已勾选的检查项
+	FreezeOverride bool       `json:"freeze_override"` // 在允许例外的冻结窗口内仍然签发
+}
+
+// CertificateApprovalCheck 一级审批时记录的批准理由和已勾选的检查项，供事后审计
+type CertificateApprovalCheck struct {
+	Approver      string    `json:"approver"`
+	Justification string    `json:"justification"`
+	Checklist     []string  `json:"checklist"`
+	At            time.Time `json:"at"`
 }
 
 // CertificateRequest 证书申请实体
 type CertificateRequest struct {
-	ID             uint              `json:"id" gorm:"primaryKey"`                        // unique key
-	UserName       string            `json:"user_name" gorm:"not null;index"`            // 申请人用户名
-	UserID         uint              `json:"user_id" gorm:"index"`                       // 申请人用户ID
-	Type           CertificateType   `json:"type" gorm:"not null"`                       // 申请证书类型
-	Status         CertificateStatus `json:"status" gorm:"not null;index"`               // 申请状态
-	Reason         string            `json:"reason" gorm:"type:text"`                    // 申请理由
-	ApprovedBy     string            `json:"approved_by,omitempty"`                      // 审批人
-	ApprovedAt     *time.Time        `json:"approved_at,omitempty"`                      // 审批时间
-	RejectedBy     string            `json:"rejected_by,omitempty"`                      // 拒绝人
-	RejectedAt     *time.Time        `json:"rejected_at,omitempty"`                      // 拒绝时间
-	RejectedReason string            `json:"rejected_reason,omitempty" gorm:"type:text"` // 拒绝理由
-	CreatedAt      time.Time         `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`
-	DeletedAt      gorm.DeletedAt    `gorm:"index" json:"deleted_at,omitempty"`
+	ID             uint                       `json:"id" gorm:"primaryKey"`                             // unique key
+	UserName       string                     `json:"user_name" gorm:"not null;index"`                  // 申请人用户名
+	UserID         uint                       `json:"user_id" gorm:"index"`                             // 申请人用户ID
+	Type           CertificateType            `json:"type" gorm:"not null"`                             // 申请证书类型
+	ProfileID      uint                       `json:"profile_id,omitempty" gorm:"index"`                // 引用的签发配置，0 表示按证书类型签发
+	Status         CertificateStatus          `json:"status" gorm:"not null;index"`                     // 申请状态
+	Reason         string                     `json:"reason" gorm:"type:text"`                          // 申请理由
+	CustomFields   map[string]string          `json:"custom_fields" gorm:"serializer:json"`             // 自定义字段的值
+	ValidityPreset string                     `json:"validity_preset,omitempty"`                        // 申请的有效期预设
+	SANs           []string                   `json:"sans,omitempty" gorm:"serializer:json"`            // 申请的主题备用名称
+	CSR            string                     `json:"csr,omitempty" gorm:"type:text"`                   // PEM 格式的证书签名请求，提供时私钥由申请人保管
+	Sandbox        bool                       `json:"sandbox" gorm:"default:false;index"`               // 沙箱申请，提交后立即由沙箱 CA 签发
+	Approvals      []string                   `json:"approvals,omitempty" gorm:"serializer:json"`       // 已完成审批链的审批人
+	ApprovalChecks []CertificateApprovalCheck `json:"approval_checks,omitempty" gorm:"serializer:json"` // 每级审批记录的批准理由和检查项
+	ApprovedBy     string                     `json:"approved_by,omitempty"`                            // 审批人
+	ApprovedAt     *time.Time                 `json:"approved_at,omitempty"`                            // 审批时间
+	OnBehalfOf     string                     `json:"on_behalf_of,omitempty"`                           // 代理审批时的委托人
+	RejectedBy     string                     `json:"rejected_by,omitempty"`                            // 拒绝人
+	RejectedAt     *time.Time                 `json:"rejected_at,omitempty"`                            // 拒绝时间
+	RejectedReason string                     `json:"rejected_reason,omitempty" gorm:"type:text"`       // 拒绝理由
+	RemindedAt     *time.Time                 `json:"reminded_at,omitempty"`                            // 最近一次提醒审批人的时间
+	EscalatedAt    *time.Time                 `json:"escalated_at,omitempty"`                           // 升级通知的时间
+	ExpiredAt      *time.Time                 `json:"expired_at,omitempty"`                             // 长时间无人处理被自动过期的时间
+	ScheduledAt    *time.Time                 `json:"scheduled_at,omitempty" gorm:"index"`              // 计划签发时间，批准后到达该时间才签发证书
+	Assignee       string                     `json:"assignee,omitempty" gorm:"index"`                  // 认领或被指派处理申请的审批人
+	AssignedAt     *time.Time                 `json:"assigned_at,omitempty"`                            // 认领或指派的时间
+	TicketID       string                     `json:"ticket_id,omitempty" gorm:"index"`                 // 关联的外部工单编号，如 Jira 的 OPS-123 或 ServiceNow 的 INC0010001
+	TicketURL      string                     `json:"ticket_url,omitempty"`                             // 关联的外部工单链接
+	ExternalID     string                     `json:"external_id,omitempty" gorm:"index"`               // 声明式接口中客户端指定的资源标识，同一租户内重新签发的申请沿用
+	CreatedAt      time.Time                  `json:"created_at"`
+	UpdatedAt      time.Time                  `json:"updated_at"`
+	DeletedAt      gorm.DeletedAt             `gorm:"index" json:"deleted_at,omitempty"`
+
+	UserInfo *CertificateUserInfo `json:"user_info,omitempty" gorm:"-"` // 列表中预加载的申请人信息
 }
 
 // IsValid 检查证书是否有效
@@ -63,24 +149,46 @@ func (c *Certificate) IsValid() bool {
 	return c.Status == CertificateStatusValid || c.Status == CertificateStatusExpiring
 }
 
+// HasPrivateKey 检查服务端是否保管了证书的私钥
+func (c *Certificate) HasPrivateKey() bool {
+	return c.PrivateKey != ""
+}
+
+// IsSuperseded 检查证书是否已有续期证书
+func (c *Certificate) IsSuperseded() bool {
+	return c.SupersededByID != 0
+}
+
+// IsOnHold 检查证书是否处于挂起状态
+func (c *Certificate) IsOnHold() bool {
+	return c.Status == CertificateStatusHold
+}
+
 // IsExpired 检查证书是否已过期
 func (c *Certificate) IsExpired() bool {
 	return c.ExpirationDate.Before(time.Now())
 }
 
+// CertificateDate 证书的颁发和过期日期只保留日期部分
+func CertificateDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
 // BeforeCreate 在创建证书前确保日期字段正确处理
 func (c *Certificate) BeforeCreate(tx *gorm.DB) error {
 	// 确保证书日期字段被正确处理为日期（而非时间）
-	c.IssuedDate = time.Date(c.IssuedDate.Year(), c.IssuedDate.Month(), c.IssuedDate.Day(), 0, 0, 0, 0, time.UTC)
-	c.ExpirationDate = time.Date(c.ExpirationDate.Year(), c.ExpirationDate.Month(), c.ExpirationDate.Day(), 0, 0, 0, 0, time.UTC)
+	c.IssuedDate = CertificateDate(c.IssuedDate)
+	c.ExpirationDate = CertificateDate(c.ExpirationDate)
+	c.ExpiryBucket = ExpiryBucketOf(c.ExpirationDate, time.Now())
 	return nil
 }
 
 // BeforeUpdate 在更新证书前确保日期字段正确处理
 func (c *Certificate) BeforeUpdate(tx *gorm.DB) error {
 	// 确保证书日期字段被正确处理为日期（而非时间）
-	c.IssuedDate = time.Date(c.IssuedDate.Year(), c.IssuedDate.Month(), c.IssuedDate.Day(), 0, 0, 0, 0, time.UTC)
-	c.ExpirationDate = time.Date(c.ExpirationDate.Year(), c.ExpirationDate.Month(), c.ExpirationDate.Day(), 0, 0, 0, 0, time.UTC)
+	c.IssuedDate = CertificateDate(c.IssuedDate)
+	c.ExpirationDate = CertificateDate(c.ExpirationDate)
+	c.ExpiryBucket = ExpiryBucketOf(c.ExpirationDate, time.Now())
 	return nil
 }
 
@@ -89,6 +197,16 @@ func (cr *CertificateRequest) IsPending() bool {
 	return cr.Status == CertificateStatusPending
 }
 
+// IsScheduled 检查申请是否已批准并等待计划签发
+func (cr *CertificateRequest) IsScheduled() bool {
+	return cr.Status == CertificateStatusScheduled
+}
+
+// IsDraft 检查申请是否为未提交的草稿
+func (cr *CertificateRequest) IsDraft() bool {
+	return cr.Status == CertificateStatusDraft
+}
+
 // IsApproved 检查申请是否已批准
 func (cr *CertificateRequest) IsApproved() bool {
 	return cr.Status == CertificateStatusValid
@@ -97,4 +215,18 @@ func (cr *CertificateRequest) IsApproved() bool {
 // IsRejected 检查申请是否已拒绝
 func (cr *CertificateRequest) IsRejected() bool {
 	return cr.Status == CertificateStatusRejected
-}
\ No newline at end of file
+}
+
+// IsExpired 检查申请是否因长时间未审批而过期
+func (cr *CertificateRequest) IsExpired() bool {
+	return cr.Status == CertificateStatusExpired
+}
+
+// RevocationNotice 上游 CA 或安全工具报告的证书泄露通知，按序列号或指纹定位证书
+type RevocationNotice struct {
+	Serial      string `json:"serial"`      // 十六进制序列号，可以包含冒号
+	Fingerprint string `json:"fingerprint"` // DER 的 sha256 指纹
+	Reason      string `json:"reason"`      // RFC 5280 吊销原因，默认为 keyCompromise
+	Source      string `json:"source"`      // 报告方名称
+	Detail      string `json:"detail"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+// CertificateAgent 部署在服务器上的代理，轮询其部署的证书的吊销和续期并自动应用
+type CertificateAgent struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Name           string    `json:"name" gorm:"uniqueIndex;not null"`
+	TokenHash      string    `json:"-" gorm:"uniqueIndex;size:64"`           // 代理令牌的 sha256，令牌只在创建时返回一次
+	CertificateIDs []uint    `json:"certificate_ids" gorm:"serializer:json"` // 代理上报的已部署证书
+	Cursor         uint      `json:"cursor"`                                 // 代理已确认应用的最后一个证书事件 ID
+	Hostname       string    `json:"hostname"`
+	Version        string    `json:"version"`
+	LastIP         string    `json:"last_ip"`
+	LastSeenAt     time.Time `json:"last_seen_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// CertificateAgentUpdate 推送给代理的一条变更，Replacement 为续期后的新证书
+type CertificateAgentUpdate struct {
+	EventID     uint                  `json:"event_id"`
+	Event       string                `json:"event"`
+	Detail      string                `json:"detail"`
+	Time        time.Time             `json:"time"`
+	Certificate CertificateStatusInfo `json:"certificate"`
+	Replacement *Certificate          `json:"replacement,omitempty"`
+}
+
+// CertificateStatusInfo 代理判断如何处理变更所需的证书状态
+type CertificateStatusInfo struct {
+	ID             uint              `json:"id"`
+	Name           string            `json:"name"`
+	Status         CertificateStatus `json:"status"`
+	Fingerprint    string            `json:"fingerprint"`
+	SerialNumber   string            `json:"serial_number"`
+	ExpirationDate time.Time         `json:"expiration_date"`
+	SupersededByID uint              `json:"superseded_by_id,omitempty"`
+}
+
+// CertificateAgentPollResult 一次轮询的结果，代理应用完成后以 Cursor 确认
+type CertificateAgentPollResult struct {
+	Cursor  uint                     `json:"cursor"`
+	Updates []CertificateAgentUpdate `json:"updates"`
+}
+
+// CertificateAgentResult 代理应用一条变更的结果
+type CertificateAgentResult struct {
+	EventID       uint   `json:"event_id"`
+	CertificateID uint   `json:"certificate_id"`
+	Success       bool   `json:"success"`
+	Message       string `json:"message"`
+}
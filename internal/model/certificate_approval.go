@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+const (
+	CertificateApprovalActionApprove = "approve"
+	CertificateApprovalActionReject  = "reject"
+)
+
+// CertificateApprovalLink 邮件中一键审批链接携带的参数
+type CertificateApprovalLink struct {
+	RequestID uint   `json:"id" form:"id" binding:"required"`
+	User      string `json:"user" form:"user" binding:"required"`
+	Action    string `json:"action" form:"action" binding:"required"`
+	Nonce     string `json:"nonce" form:"nonce" binding:"required"`
+	Sign      string `json:"sign" form:"sign" binding:"required"`
+}
+
+// CertificateApprovalNonce 已使用的一键审批链接，保证每个链接只能使用一次
+type CertificateApprovalNonce struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Nonce     string    `json:"nonce" gorm:"uniqueIndex;size:64;not null"`
+	RequestID uint      `json:"request_id" gorm:"index"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// 证书审计日志的操作
+const (
+	CertificateAuditCreate   = "create"
+	CertificateAuditApprove  = "approve"
+	CertificateAuditReject   = "reject"
+	CertificateAuditRevoke   = "revoke"
+	CertificateAuditHold     = "hold"
+	CertificateAuditUnhold   = "unhold"
+	CertificateAuditDelete   = "delete"
+	CertificateAuditDownload = "download"
+)
+
+// 证书审计日志的操作对象类型
+const (
+	CertificateAuditTargetCertificate = "certificate"
+	CertificateAuditTargetRequest     = "certificate_request"
+)
+
+// CertificateAuditLog 由接口层记录的证书操作审计日志，包含操作人和客户端 IP，
+// 与证书时间线的事件不同，不随证书删除而失去意义
+type CertificateAuditLog struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Actor      string    `json:"actor" gorm:"index"`  // 操作人用户名
+	Action     string    `json:"action" gorm:"index"` // 操作，见 CertificateAudit* 常量
+	TargetType string    `json:"target_type"`         // 操作对象类型，证书或证书申请
+	TargetID   uint      `json:"target_id" gorm:"index"`
+	IP         string    `json:"ip"`
+	Detail     string    `json:"detail" gorm:"type:text"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// CA 证书的类型
+const (
+	CertificateAuthorityRoot         = "root"
+	CertificateAuthorityIntermediate = "intermediate"
+)
+
+// CA 证书的状态，只有 active 的证书会发布到信任包中
+const (
+	CertificateAuthorityActive  = "active"
+	CertificateAuthorityRetired = "retired"
+)
+
+// CertificateAuthority 受信任的根证书或中间证书
+type CertificateAuthority struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null"`
+	Kind        string    `json:"kind" gorm:"not null"`                     // root 或 intermediate
+	Status      string    `json:"status" gorm:"not null;index"`             // active 或 retired
+	Subject     string    `json:"subject"`                                  // 证书主题
+	Issuer      string    `json:"issuer"`                                   // 签发者主题
+	Fingerprint string    `json:"fingerprint" gorm:"uniqueIndex;size:64"`   // DER 的 sha256 指纹
+	Content     string    `json:"content" gorm:"type:text"`                 // 证书内容(PEM格式)
+	PrivateKey  string    `json:"-" gorm:"type:text;serializer:privatekey"` // 签名私钥(PEM格式)，只有 OpenList 持有私钥的 CA 才能签发 CRL 和 OCSP 响应
+	CrossSignOf uint      `json:"cross_sign_of,omitempty" gorm:"index"`     // 交叉证书对应的 CA，与其主题和公钥相同，由另一个 CA 签发
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// IsActive 检查 CA 证书是否在用且未过期
+func (ca *CertificateAuthority) IsActive(t time.Time) bool {
+	return ca.Status == CertificateAuthorityActive && t.Before(ca.NotAfter)
+}
+
+// CABundle 公开的 CA 信任包，版本号在在用 CA 集合变化时递增
+type CABundle struct {
+	Version      int64                  `json:"version"`
+	ETag         string                 `json:"etag"`
+	Certificates []CertificateAuthority `json:"certificates"`
+}
+
+// IsCrossSigned 检查是否为其它 CA 的交叉证书，交叉证书只用于构建备用证书链，不签发证书
+func (ca *CertificateAuthority) IsCrossSigned() bool {
+	return ca.CrossSignOf != 0
+}
+
+// HasPrivateKey 检查 OpenList 是否持有该 CA 的签名私钥
+func (ca *CertificateAuthority) HasPrivateKey() bool {
+	return ca.PrivateKey != ""
+}
+
+// CACrossSignInput 由 IssuerID 为 SubjectID 签发交叉证书的参数
+type CACrossSignInput struct {
+	Name      string `json:"name"`
+	SubjectID uint   `json:"subject_id" binding:"required"`
+	IssuerID  uint   `json:"issuer_id" binding:"required"`
+}
+
+// CACreateInput 创建 CA 的参数，ParentID 为 0 时创建根证书，否则创建由该 CA 签发的中间证书
+type CACreateInput struct {
+	Name         string `json:"name"`
+	CommonName   string `json:"common_name" binding:"required"`
+	Organization string `json:"organization"`
+	ParentID     uint   `json:"parent_id"`
+	ValidityDays int    `json:"validity_days"` // 0 时根证书为十年，中间证书为五年
+}
@@ -0,0 +1,8 @@
+package model
+
+// CertificateBatchResult 批量操作中单条记录的结果
+type CertificateBatchResult struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+// 证书链中证书的来源
+const (
+	ChainSourceUpload = "upload" // 随请求上传
+	ChainSourceCA     = "ca"     // OpenList 管理的 CA
+	ChainSourceCache  = "cache"  // 之前上传并验证过的中间证书
+	ChainSourceTrust  = "trust"  // 所在组织信任库中的 CA
+)
+
+// ChainCertificate 证书链中的一张证书
+type ChainCertificate struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	Fingerprint string    `json:"fingerprint"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Source      string    `json:"source"`
+	CAID        uint      `json:"ca_id,omitempty"`     // 来源为 ca 时对应的 CA
+	AnchorID    uint      `json:"anchor_id,omitempty"` // 来源为 trust 时对应的信任库证书
+}
+
+// CertificateChain 为上传的叶子证书构建的证书链，从叶子证书到根证书排列。
+// 找不到有效路径时 Valid 为 false，Chain 为按名称能找到的部分链，Diagnostics 说明原因
+type CertificateChain struct {
+	Valid       bool               `json:"valid"`
+	Chain       []ChainCertificate `json:"chain"`
+	PEM         string             `json:"pem,omitempty"` // 不含根证书的完整链，可直接用于部署
+	Diagnostics []string           `json:"diagnostics,omitempty"`
+}
+
+// CertificateChainOption 证书可以下载的一条证书链，CrossSignID 为 0 时为签发时保存的默认链，
+// 否则为经过该交叉证书、由另一个 CA 作为信任锚的备用链。列表只返回链的信息，链的内容通过下载接口的 chain 参数获取
+type CertificateChainOption struct {
+	CrossSignID   uint      `json:"cross_sign_id"`
+	CrossSignName string    `json:"cross_sign_name,omitempty"` // 交叉证书的名称
+	Anchor        string    `json:"anchor"`                    // 链顶端的信任锚主题，客户端需要信任该 CA
+	NotAfter      time.Time `json:"not_after"`
+	PEM           string    `json:"-"` // 从叶子证书开始、不含信任锚的证书链
+}
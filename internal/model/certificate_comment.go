@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// CertificateRequestComment 证书申请的评论
+type CertificateRequestComment struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RequestID uint      `json:"request_id" gorm:"index"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content" gorm:"type:text"`
+	Mentions  []string  `json:"mentions" gorm:"serializer:json"` // 评论中 @ 到的用户
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CertificateRequestMention 记录被 @ 的用户，策略允许时被 @ 的用户可以查看该申请
+type CertificateRequestMention struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RequestID uint      `json:"request_id" gorm:"uniqueIndex:idx_cert_mention"`
+	UserID    uint      `json:"user_id" gorm:"uniqueIndex:idx_cert_mention"`
+	Username  string    `json:"username"`
+	CommentID uint      `json:"comment_id"` // 首次被 @ 的评论
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CertificateRequestNote 管理员对申请的内部备注，与评论分开保存，申请人和被 @ 的用户不可见
+type CertificateRequestNote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	RequestID uint      `json:"request_id" gorm:"index"`
+	UserID    uint      `json:"user_id"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+}
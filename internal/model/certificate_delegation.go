@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// ApprovalDelegation 审批委托，审批人外出期间由代理人代为审批
+type ApprovalDelegation struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	UserID     uint      `json:"user_id" gorm:"index"`     // 委托人ID
+	Username   string    `json:"username" gorm:"not null"` // 委托人用户名
+	DelegateID uint      `json:"delegate_id" gorm:"index"` // 代理人ID
+	Delegate   string    `json:"delegate" gorm:"not null"` // 代理人用户名
+	StartAt    time.Time `json:"start_at" gorm:"index"`    // 委托开始时间
+	EndAt      time.Time `json:"end_at" gorm:"index"`      // 委托结束时间
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IsActive 检查委托在指定时间是否生效
+func (d *ApprovalDelegation) IsActive(t time.Time) bool {
+	return !t.Before(d.StartAt) && t.Before(d.EndAt)
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// CertificateDemoRecord 记录演示数据创建的证书和申请，清除演示数据时只删除这些记录
+type CertificateDemoRecord struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	TargetType string    `json:"target_type" gorm:"index:idx_cert_demo_target"` // 证书或证书申请，与审计日志的对象类型相同
+	TargetID   uint      `json:"target_id" gorm:"index:idx_cert_demo_target"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CertificateDemoSummary 填充或清除的演示数据数量
+type CertificateDemoSummary struct {
+	Certificates int `json:"certificates"`
+	Requests     int `json:"requests"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// 证书到期摘要的发送频率
+const (
+	CertificateDigestOff     = "off"
+	CertificateDigestDaily   = "daily"
+	CertificateDigestWeekly  = "weekly"
+	CertificateDigestMonthly = "monthly"
+)
+
+// CertificateDigestPref 用户的到期摘要订阅设置
+type CertificateDigestPref struct {
+	UserID     uint       `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Frequency  string     `json:"frequency"` // 为空时使用站点默认频率，off 表示退订
+	LastSentAt *time.Time `json:"last_sent_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// CertificateContactDigest 证书联系人的到期摘要发送记录，联系人按站点默认频率接收
+type CertificateContactDigest struct {
+	Email      string `gorm:"primaryKey"`
+	LastSentAt time.Time
+}
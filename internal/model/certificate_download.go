@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// CertificateDownload 证书的一次下载记录
+type CertificateDownload struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CertificateID uint      `json:"certificate_id" gorm:"index"`
+	Username      string    `json:"username" gorm:"index"`
+	IP            string    `json:"ip"`
+	UserAgent     string    `json:"user_agent"`
+	Revoked       bool      `json:"revoked"` // 下载时证书是否已吊销
+	CreatedAt     time.Time `json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// CertificateEmail 邮件服务商通过 webhook 转发的入站邮件
+type CertificateEmail struct {
+	From        string                       `json:"from"`  // 发件人
+	DMARC       string                       `json:"dmarc"` // 服务商对发件人域名的 DMARC 校验结果，只有 pass 时才信任发件人
+	Subject     string                       `json:"subject"`
+	Text        string                       `json:"text"` // 纯文本正文，每行一个 "字段: 值"
+	Attachments []CertificateEmailAttachment `json:"attachments"`
+}
+
+// CertificateEmailAttachment 入站邮件的附件
+type CertificateEmailAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"` // base64 编码的附件内容
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// CertificateEvent 证书生命周期事件，用于组装证书的活动时间线
+type CertificateEvent struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CertificateID uint      `json:"certificate_id" gorm:"index"`
+	Event         string    `json:"event" gorm:"index"`
+	Actor         string    `json:"actor"`
+	Detail        string    `json:"detail" gorm:"type:text"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// CertificateTimelineEntry 证书时间线中的一条记录
+type CertificateTimelineEntry struct {
+	Time   time.Time `json:"time"`
+	Event  string    `json:"event"`
+	Actor  string    `json:"actor"`
+	Detail string    `json:"detail"`
+}
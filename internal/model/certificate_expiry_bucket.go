@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// 证书的到期分桶，值为距到期天数的上限，由到期扫描任务随时间推移更新，
+// 仪表盘和提醒按分桶查询，不必对全表做日期计算
+const (
+	ExpiryBucketExpired = -1  // 已到期
+	ExpiryBucketUnknown = 0   // 尚未计算
+	ExpiryBucketLater   = 999 // 超过最大分桶的天数
+)
+
+// ExpiryBuckets 到期分桶的天数上限，从小到大排列
+var ExpiryBuckets = []int{1, 7, 30, 90}
+
+// ExpiryBucketOf 计算到期时间在 now 时所属的分桶
+func ExpiryBucketOf(expiration, now time.Time) int {
+	if !expiration.After(now) {
+		return ExpiryBucketExpired
+	}
+	for _, days := range ExpiryBuckets {
+		if !expiration.After(now.AddDate(0, 0, days)) {
+			return days
+		}
+	}
+	return ExpiryBucketLater
+}
+
+// ExpiryBucketCeil 返回包含 days 天内到期证书的最小分桶，
+// 分桶在两次扫描之间可能偏大，查询时应再放宽到下一个分桶并按到期时间精确过滤
+func ExpiryBucketCeil(days int) int {
+	for i, b := range ExpiryBuckets {
+		if days <= b {
+			if i+1 < len(ExpiryBuckets) {
+				return ExpiryBuckets[i+1]
+			}
+			return ExpiryBucketLater
+		}
+	}
+	return ExpiryBucketLater
+}
+
+// CertificateExpirySummary 各到期分桶中有效证书的数量
+type CertificateExpirySummary struct {
+	Bucket int   `json:"bucket"`
+	Count  int64 `json:"count"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+// CertificateExpiryNotice 已发送的到期提醒，同一证书的同一阶段只提醒一次
+type CertificateExpiryNotice struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CertificateID uint      `json:"certificate_id" gorm:"uniqueIndex:idx_certificate_expiry_notice"`
+	Stage         string    `json:"stage" gorm:"size:32;uniqueIndex:idx_certificate_expiry_notice"` // 提前天数如 "7d"，到期时为 "expired"
+	CreatedAt     time.Time `json:"created_at"`
+}
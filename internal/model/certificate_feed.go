@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// CertificateFeedToken 用户订阅证书日历和事件源使用的令牌，订阅无需登录
+type CertificateFeedToken struct {
+	UserID    uint      `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Token     string    `json:"token" gorm:"uniqueIndex;size:64;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CertificateFeedEntry 证书事件源中的一条记录
+type CertificateFeedEntry struct {
+	ID            string    `json:"id"`
+	Time          time.Time `json:"time"`
+	Event         string    `json:"event"`
+	CertificateID uint      `json:"certificate_id"`
+	Title         string    `json:"title"`
+	Summary       string    `json:"summary"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// CertificateFieldType 自定义字段类型
+type CertificateFieldType string
+
+const (
+	CertificateFieldText     CertificateFieldType = "text"     // 文本
+	CertificateFieldSelect   CertificateFieldType = "select"   // 下拉选择
+	CertificateFieldCheckbox CertificateFieldType = "checkbox" // 勾选框
+)
+
+// CertificateRequestField 管理员定义的申请表单自定义字段
+type CertificateRequestField struct {
+	ID        uint                 `json:"id" gorm:"primaryKey"`
+	Type      CertificateType      `json:"type" gorm:"index"`              // 适用的证书类型，为空时适用于所有类型
+	Key       string               `json:"key" gorm:"not null"`            // 字段标识，作为存储在申请中的键
+	Label     string               `json:"label"`                          // 显示名称
+	FieldType CertificateFieldType `json:"field_type" gorm:"not null"`     // 字段类型
+	Options   []string             `json:"options" gorm:"serializer:json"` // 下拉选项
+	Required  bool                 `json:"required"`                       // 是否必填
+	Order     int                  `json:"order"`                          // 显示顺序
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// AppliesTo 检查字段是否适用于指定的证书类型
+func (f *CertificateRequestField) AppliesTo(t CertificateType) bool {
+	return f.Type == "" || f.Type == t
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// CertificateFilter 证书列表的过滤和排序条件，零值字段不参与过滤
+type CertificateFilter struct {
+	Status         CertificateStatus `json:"status" form:"status"`
+	Type           CertificateType   `json:"type" form:"type"`
+	Owner          string            `json:"owner" form:"owner"`
+	Name           string            `json:"name" form:"name"`                     // 名称包含的子串
+	ExpiresAfter   time.Time         `json:"expires_after" form:"expires_after"`   // 到期时间不早于该时间，RFC 3339 格式
+	ExpiresBefore  time.Time         `json:"expires_before" form:"expires_before"` // 到期时间早于该时间，RFC 3339 格式
+	OrderBy        string            `json:"order_by" form:"order_by"`             // 见 CertificateOrderColumns，默认按 id
+	OrderDirection string            `json:"order_direction" form:"order_direction"`
+}
+
+// CertificateOrderColumns 证书列表允许排序的字段
+var CertificateOrderColumns = []string{"id", "name", "type", "status", "owner", "issued_date", "expiration_date", "created_at", "updated_at"}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// CertificateFreezeWindow 变更冻结窗口，期间暂停该组织的新签发和自动签发
+type CertificateFreezeWindow struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	Organization  string    `json:"organization" gorm:"index"` // 为空时对所有组织生效
+	Title         string    `json:"title" gorm:"not null"`
+	StartAt       time.Time `json:"start_at" gorm:"index"`
+	EndAt         time.Time `json:"end_at" gorm:"index"`
+	AllowOverride bool      `json:"allow_override"` // 允许管理员在冻结期间以额外的例外批准签发
+	CreatedBy     string    `json:"created_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// IsActive 检查冻结窗口是否正在生效
+func (w *CertificateFreezeWindow) IsActive(t time.Time) bool {
+	return !t.Before(w.StartAt) && t.Before(w.EndAt)
+}
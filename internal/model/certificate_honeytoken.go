@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// CertificateHoneytoken 诱饵证书，被下载或查询状态时立即触发安全告警。
+// 单独存储，不出现在证书本身的字段中，避免被枚举证书列表的攻击者识别
+type CertificateHoneytoken struct {
+	CertificateID uint       `json:"certificate_id" gorm:"primaryKey;autoIncrement:false"`
+	Note          string     `json:"note"`
+	CreatedBy     string     `json:"created_by"`
+	TripCount     int64      `json:"trip_count"`
+	LastTrippedAt *time.Time `json:"last_tripped_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
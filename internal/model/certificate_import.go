@@ -0,0 +1,15 @@
+package model
+
+// CertificateImportError 批量导入中未能导入的一条记录
+type CertificateImportError struct {
+	Index int    `json:"index"` // 在导入文件中的位置，从 0 开始
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// CertificateImportResult 批量导入结果，Skipped 为指纹已存在而跳过的证书数量
+type CertificateImportResult struct {
+	Imported int                      `json:"imported"`
+	Skipped  int                      `json:"skipped"`
+	Failed   []CertificateImportError `json:"failed"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// JSONWebKey RFC 7517 公钥，kid 为证书 DER 的 sha256 指纹
+type JSONWebKey struct {
+	Kty     string   `json:"kty"`
+	Kid     string   `json:"kid"`
+	Use     string   `json:"use"`
+	Alg     string   `json:"alg"`
+	N       string   `json:"n,omitempty"`
+	E       string   `json:"e,omitempty"`
+	Crv     string   `json:"crv,omitempty"`
+	X       string   `json:"x,omitempty"`
+	Y       string   `json:"y,omitempty"`
+	X5c     []string `json:"x5c,omitempty"`
+	X5tS256 string   `json:"x5t#S256,omitempty"`
+}
+
+// JSONWebKeySet 当前签名证书及其仍在有效期内的前后续期证书的公钥
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// CertificateJWT 签发的令牌
+type CertificateJWT struct {
+	Token         string    `json:"token"`
+	Kid           string    `json:"kid"`
+	CertificateID uint      `json:"certificate_id"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
@@ -0,0 +1,69 @@
+package model
+
+// PolicyBundleFormat 策略导出文件的格式版本
+const PolicyBundleFormat = "openlist-pki-policy/v1"
+
+// PolicyBundle 证书类型(有效期、配额和审批链)、申请表单字段、共享申请模板和通知路由的声明式配置，
+// 不含数据库 ID，导入时按类型名称、字段标识和模板名称匹配
+type PolicyBundle struct {
+	Format              string              `json:"format"`
+	Types               []PolicyType        `json:"types,omitempty"`
+	Fields              []PolicyField       `json:"fields,omitempty"`
+	Templates           []PolicyTemplate    `json:"templates,omitempty"`
+	NotificationRouting map[string][]string `json:"notification_routing,omitempty"` // 通知级别对应的渠道
+}
+
+type PolicyType struct {
+	Name            CertificateType `json:"name"`
+	Description     string          `json:"description,omitempty"`
+	Disabled        bool            `json:"disabled,omitempty"`
+	NameTemplate    string          `json:"name_template,omitempty"`
+	ValidityDays    int             `json:"validity_days,omitempty"`
+	ValidityPresets map[string]int  `json:"validity_presets,omitempty"`
+	ApprovalChain   []string        `json:"approval_chain,omitempty"`
+	Quota           int             `json:"quota,omitempty"`
+}
+
+type PolicyField struct {
+	Type      CertificateType      `json:"type,omitempty"`
+	Key       string               `json:"key"`
+	Label     string               `json:"label,omitempty"`
+	FieldType CertificateFieldType `json:"field_type"`
+	Options   []string             `json:"options,omitempty"`
+	Required  bool                 `json:"required,omitempty"`
+	Order     int                  `json:"order,omitempty"`
+}
+
+type PolicyTemplate struct {
+	Name           string            `json:"name"`
+	Type           CertificateType   `json:"type"`
+	Reason         string            `json:"reason,omitempty"`
+	SANs           []string          `json:"sans,omitempty"`
+	ValidityPreset string            `json:"validity_preset,omitempty"`
+	CustomFields   map[string]string `json:"custom_fields,omitempty"`
+}
+
+// 导入时每一项的处理结果
+const (
+	PolicyCreated   = "created"
+	PolicyUpdated   = "updated"
+	PolicyUnchanged = "unchanged"
+)
+
+// PolicyImportItem 导入的一项配置及其处理结果
+type PolicyImportItem struct {
+	Kind    string `json:"kind"` // type、field、template 或 notification_routing
+	Name    string `json:"name"`
+	Outcome string `json:"outcome"`
+}
+
+// PolicyImportResult 导入结果，DryRun 时只计算差异不写入
+type PolicyImportResult struct {
+	DryRun bool               `json:"dry_run"`
+	Items  []PolicyImportItem `json:"items"`
+}
+
+// Add 记录一项的处理结果
+func (r *PolicyImportResult) Add(kind, name, outcome string) {
+	r.Items = append(r.Items, PolicyImportItem{Kind: kind, Name: name, Outcome: outcome})
+}
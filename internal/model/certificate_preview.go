@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/certlint"
+)
+
+// CertificatePreview 按申请参数预览将要签发的证书，不签名也不保存
+type CertificatePreview struct {
+	Name             string             `json:"name"`
+	Type             CertificateType    `json:"type"`
+	Owner            string             `json:"owner"`
+	Subject          string             `json:"subject"`
+	Issuer           string             `json:"issuer,omitempty"` // 签发 CA 的主题，未配置 CA 时为空
+	IssuerID         uint               `json:"issuer_id,omitempty"`
+	SANs             []string           `json:"sans"`
+	KeyUsage         []string           `json:"key_usage"`
+	ExtKeyUsage      []string           `json:"ext_key_usage"`
+	NotBefore        time.Time          `json:"not_before"`
+	NotAfter         time.Time          `json:"not_after"`
+	ValidityPreset   string             `json:"validity_preset,omitempty"`
+	CustomFields     map[string]string  `json:"custom_fields,omitempty"`
+	PendingApprovers []string           `json:"pending_approvers,omitempty"` // 审批链中尚未审批的审批人
+	Warnings         []string           `json:"warnings,omitempty"`          // 按当前状态批准时会导致签发失败的问题
+	Lints            []certlint.Finding `json:"lints,omitempty"`             // 将要签发的证书违反 RFC 5280 和 BR 的检查结果
+}
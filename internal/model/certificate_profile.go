@@ -0,0 +1,58 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// CertificateProfile 管理员维护的签发配置，申请引用配置后按配置的密钥、有效期、备用名称和用途签发
+type CertificateProfile struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Name         string    `json:"name" gorm:"unique;not null"`
+	Description  string    `json:"description"`
+	KeyType      string    `json:"key_type"`                              // rsa、ecdsa 或 ed25519，为空时使用 cert_key_algorithm 设置
+	KeySize      int       `json:"key_size"`                              // rsa 为 2048/3072/4096，ecdsa 为 256/384，ed25519 忽略
+	ValidityDays int       `json:"validity_days"`                         // 有效期天数，0 表示使用证书类型的有效期
+	AllowedSANs  []string  `json:"allowed_sans" gorm:"serializer:json"`   // 允许的主题备用名称，支持 * 通配符和 {user} 占位符，为空时不限制
+	ExtKeyUsages []string  `json:"ext_key_usages" gorm:"serializer:json"` // 扩展密钥用途，如 serverAuth、clientAuth，为空时按证书类型
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// KeyAlgorithm 返回 certissuer 的密钥算法名，未指定密钥类型时为空
+func (p *CertificateProfile) KeyAlgorithm() string {
+	switch p.KeyType {
+	case "":
+		return ""
+	case "ed25519":
+		return "ed25519"
+	case "ecdsa":
+		return fmt.Sprintf("ecdsa-p%d", p.KeySize)
+	}
+	return fmt.Sprintf("%s-%d", p.KeyType, p.KeySize)
+}
+
+// Validity 按配置的有效期计算到期时间，ok 为 false 表示配置未指定有效期
+func (p *CertificateProfile) Validity(from time.Time) (time.Time, bool) {
+	if p.ValidityDays <= 0 {
+		return time.Time{}, false
+	}
+	return from.AddDate(0, 0, p.ValidityDays), true
+}
+
+// AllowsSAN 检查主题备用名称是否在允许范围内，username 用于替换 {user} 占位符
+func (p *CertificateProfile) AllowsSAN(san, username string) bool {
+	if len(p.AllowedSANs) == 0 {
+		return true
+	}
+	san = strings.ToLower(san)
+	for _, pattern := range p.AllowedSANs {
+		pattern = strings.ToLower(strings.ReplaceAll(pattern, "{user}", username))
+		if ok, err := path.Match(pattern, san); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// CertificateTypeQuotaUsage 证书类型的全局配额及当前使用量
+type CertificateTypeQuotaUsage struct {
+	Type   CertificateType `json:"type"`
+	Quota  int             `json:"quota"` // 0 表示不限
+	Active int64           `json:"active"`
+}
+
+// TenantCertificateQuota 租户的证书配额和当前使用量，便于自动化在触发策略拒绝前退避
+type TenantCertificateQuota struct {
+	MaxCertificates    int                         `json:"max_certificates"` // 同时有效的证书数量上限
+	ActiveCertificates int                         `json:"active_certificates"`
+	MaxOpenRequests    int                         `json:"max_open_requests"` // 同时处理中的申请数量上限
+	OpenRequests       int                         `json:"open_requests"`
+	RequestsPerDay     int                         `json:"requests_per_day"`   // 每 24 小时可提交的申请数量，0 表示不限
+	RequestsToday      int64                       `json:"requests_today"`     // 最近 24 小时提交的申请数量
+	ResetAt            *time.Time                  `json:"reset_at,omitempty"` // 达到每日上限时，最早可以再次提交的时间
+	Types              []CertificateTypeQuotaUsage `json:"types"`
+	CanRequest         bool                        `json:"can_request"`
+	Blockers           []string                    `json:"blockers,omitempty"` // 当前无法提交申请的原因
+}
+
+// CertificateTenantQuota 管理员为单个租户设置的有效证书数量上限，覆盖 cert_tenant_max_certificates
+type CertificateTenantQuota struct {
+	UserID          uint      `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	MaxCertificates int       `json:"max_certificates"` // 同时有效的证书数量上限
+	UpdatedBy       string    `json:"updated_by"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
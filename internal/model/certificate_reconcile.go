@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// 申请与证书不一致的类型
+const (
+	AnomalyApprovedWithoutCertificate = "approved_without_certificate" // 申请已批准但没有对应的证书
+	AnomalyCertificateWithoutRequest  = "certificate_without_request"  // 证书关联的申请不存在
+	AnomalyStatusMismatch             = "status_mismatch"              // 证书关联的申请未处于已批准状态
+)
+
+// 不一致的修复操作
+const (
+	RemediationIssue       = "issue"        // 为已批准的申请补签证书
+	RemediationReject      = "reject"       // 将已批准但没有证书的申请标记为拒绝
+	RemediationDetach      = "detach"       // 解除证书与申请的关联，视为手动创建的证书
+	RemediationSyncRequest = "sync_request" // 将申请状态同步为已批准
+)
+
+// CertificateAnomaly 一条不一致记录
+type CertificateAnomaly struct {
+	Kind          string   `json:"kind"`
+	RequestID     uint     `json:"request_id,omitempty"`
+	CertificateID uint     `json:"certificate_id,omitempty"`
+	Detail        string   `json:"detail"`
+	Actions       []string `json:"actions"` // 可用的修复操作
+}
+
+// CertificateReconciliation 申请与证书的一致性检查报告
+type CertificateReconciliation struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	Anomalies   []CertificateAnomaly `json:"anomalies"`
+}
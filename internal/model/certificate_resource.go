@@ -0,0 +1,38 @@
+package model
+
+// 声明式接口对资源执行的操作
+const (
+	CertificateResourceCreated   = "created"   // 资源不存在，已提交新申请
+	CertificateResourceUpdated   = "updated"   // 待审批的申请已按期望状态修改
+	CertificateResourceReplaced  = "replaced"  // 已签发或已拒绝的资源与期望状态不一致，已提交新申请
+	CertificateResourceUnchanged = "unchanged" // 资源与期望状态一致
+)
+
+// CertificateResourceSpec 声明式接口提交的期望状态
+type CertificateResourceSpec struct {
+	Type           CertificateType   `json:"type" binding:"required"`
+	Reason         string            `json:"reason"`
+	ValidityPreset string            `json:"validity_preset"`
+	CustomFields   map[string]string `json:"custom_fields"`
+	SANs           []string          `json:"sans"`
+	CSR            string            `json:"csr"`
+	ProfileID      uint              `json:"profile_id"`
+}
+
+// CertificateResourceDrift 期望状态与实际状态不一致的字段
+type CertificateResourceDrift struct {
+	Field   string `json:"field"`
+	Desired any    `json:"desired"`
+	Actual  any    `json:"actual"`
+}
+
+// CertificateResource 声明式资源的当前状态，Request 为该资源最近一次申请，Certificate 为其当前生效的证书
+type CertificateResource struct {
+	ExternalID  string                     `json:"external_id"`
+	Action      string                     `json:"action,omitempty"`
+	DryRun      bool                       `json:"dry_run,omitempty"`
+	Status      CertificateStatus          `json:"status,omitempty"`
+	Drift       []CertificateResourceDrift `json:"drift"`
+	Request     *CertificateRequest        `json:"request,omitempty"`
+	Certificate *Certificate               `json:"certificate,omitempty"`
+}
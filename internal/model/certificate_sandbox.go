@@ -0,0 +1,8 @@
+package model
+
+// SandboxCertificate 沙箱申请的签发结果，私钥只在此返回一次，申请人提供 CSR 时为空
+type SandboxCertificate struct {
+	Request     *CertificateRequest `json:"request"`
+	Certificate *Certificate        `json:"certificate"`
+	PrivateKey  string              `json:"private_key,omitempty"`
+}
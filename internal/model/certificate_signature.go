@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// CertificateSignature 使用服务端保管的证书私钥创建的签名记录，只记录摘要不保存被签名的内容
+type CertificateSignature struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CertificateID uint      `json:"certificate_id" gorm:"index"`
+	UserID        uint      `json:"user_id" gorm:"index"`
+	Username      string    `json:"username"`
+	Filename      string    `json:"filename,omitempty"`
+	Size          int64     `json:"size"`
+	Digest        string    `json:"digest"` // 被签名内容的 sha256(十六进制)
+	IP            string    `json:"ip"`
+	CreatedAt     time.Time `json:"created_at" gorm:"index"`
+}
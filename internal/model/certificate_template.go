@@ -0,0 +1,34 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// CertificateRequestTemplate 常用的申请配置，UserID 为 0 时为管理员维护的共享模板
+type CertificateRequestTemplate struct {
+	ID             uint              `json:"id" gorm:"primaryKey"`
+	UserID         uint              `json:"user_id" gorm:"uniqueIndex:idx_cert_template_name"`
+	Name           string            `json:"name" gorm:"not null;uniqueIndex:idx_cert_template_name"`
+	Type           CertificateType   `json:"type" gorm:"not null"`
+	Reason         string            `json:"reason" gorm:"type:text"`     // 默认的申请理由
+	SANs           []string          `json:"sans" gorm:"serializer:json"` // 主题备用名称，支持 {user} 占位符
+	ValidityPreset string            `json:"validity_preset"`             // 有效期预设
+	CustomFields   map[string]string `json:"custom_fields" gorm:"serializer:json"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// IsShared 是否为共享模板
+func (t *CertificateRequestTemplate) IsShared() bool {
+	return t.UserID == 0
+}
+
+// ExpandSANs 将主题备用名称中的 {user} 替换为申请人用户名
+func (t *CertificateRequestTemplate) ExpandSANs(username string) []string {
+	res := make([]string, 0, len(t.SANs))
+	for _, san := range t.SANs {
+		res = append(res, strings.ReplaceAll(san, "{user}", username))
+	}
+	return res
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// CertificateTrustAnchor 组织信任库中额外信任的 CA 证书，参与该组织成员的证书链验证
+type CertificateTrustAnchor struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Organization string    `json:"organization" gorm:"not null;index"`
+	Name         string    `json:"name"`
+	Content      string    `json:"content" gorm:"type:text"` // PEM 格式的 CA 证书
+	Subject      string    `json:"subject"`
+	Fingerprint  string    `json:"fingerprint" gorm:"index"` // DER 的 sha256 指纹(十六进制)
+	NotAfter     time.Time `json:"not_after"`
+	CreatedBy    string    `json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultValidityPresets 内置证书类型的有效期预设
+var DefaultValidityPresets = map[string]int{
+	"90d": 90,
+	"1y":  365,
+	"2y":  730,
+}
+
+// CertificateTypeDef 管理员维护的证书类型
+type CertificateTypeDef struct {
+	ID              uint            `json:"id" gorm:"primaryKey"`
+	Name            CertificateType `json:"name" gorm:"unique;not null"`             // 类型标识，即证书和申请中的 type
+	Description     string          `json:"description"`                             // 说明
+	Disabled        bool            `json:"disabled"`                                // 禁用后不能再申请或签发该类型证书
+	NameTemplate    string          `json:"name_template"`                           // 签发证书的默认名称模板，支持 {user} 和 {type}
+	ValidityDays    int             `json:"validity_days"`                           // 默认有效期天数，0 表示一年
+	ValidityPresets map[string]int  `json:"validity_presets" gorm:"serializer:json"` // 申请时可选的有效期预设，预设名对应天数，为空时只能使用默认有效期
+	ApprovalChain   []string        `json:"approval_chain" gorm:"serializer:json"`   // 依次审批的审批人用户名，为空时任一管理员审批即可
+	Quota           int             `json:"quota"`                                   // 同时有效的证书数量上限，0 表示不限
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// CertificateName 按模板生成签发证书的名称
+func (t *CertificateTypeDef) CertificateName(username string) string {
+	tmpl := t.NameTemplate
+	if tmpl == "" {
+		tmpl = "{user}-{type}-cert"
+	}
+	return strings.NewReplacer("{user}", username, "{type}", string(t.Name)).Replace(tmpl)
+}
+
+// HasValidityPreset 检查预设是否在该类型允许的范围内，空预设表示使用默认有效期
+func (t *CertificateTypeDef) HasValidityPreset(preset string) bool {
+	if preset == "" {
+		return true
+	}
+	_, ok := t.ValidityPresets[preset]
+	return ok
+}
+
+// Validity 按所选预设计算到期时间，未选择预设时使用默认有效期
+func (t *CertificateTypeDef) Validity(from time.Time, preset string) time.Time {
+	if days, ok := t.ValidityPresets[preset]; ok && days > 0 {
+		return from.AddDate(0, 0, days)
+	}
+	if t.ValidityDays <= 0 {
+		return from.AddDate(1, 0, 0)
+	}
+	return from.AddDate(0, 0, t.ValidityDays)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// CertificateWatchTarget 关注对象类型
+type CertificateWatchTarget string
+
+const (
+	CertificateWatchTargetCertificate CertificateWatchTarget = "certificate" // 证书
+	CertificateWatchTargetRequest     CertificateWatchTarget = "request"     // 证书申请
+)
+
+// CertificateWatch 用户对证书或申请的关注，关注者会收到其生命周期事件通知
+type CertificateWatch struct {
+	ID         uint                   `json:"id" gorm:"primaryKey"`
+	UserID     uint                   `json:"user_id" gorm:"uniqueIndex:idx_cert_watch"`
+	Username   string                 `json:"username"`
+	TargetType CertificateWatchTarget `json:"target_type" gorm:"uniqueIndex:idx_cert_watch;index:idx_cert_watch_target"`
+	TargetID   uint                   `json:"target_id" gorm:"uniqueIndex:idx_cert_watch;index:idx_cert_watch_target"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
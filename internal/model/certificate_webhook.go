@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// 推送记录的状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待推送或重试
+	WebhookDeliverySucceeded = "succeeded" // 接收端返回 2xx
+	WebhookDeliveryFailed    = "failed"    // 重试次数用尽
+)
+
+// CertificateWebhook 管理员注册的证书生命周期事件接收端，事件以 JSON 推送并用密钥签名
+type CertificateWebhook struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url" gorm:"not null"`
+	Secret    string    `json:"-" gorm:"type:text;serializer:privatekey"` // 签名密钥，配置主密钥后加密存储
+	Events    []string  `json:"events" gorm:"serializer:json"`            // 订阅的事件，为空时订阅所有事件
+	Enabled   bool      `json:"enabled" gorm:"default:true"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Subscribes 检查接收端是否订阅了事件
+func (w *CertificateWebhook) Subscribes(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// CertificateWebhookDelivery 一个事件向一个接收端的推送记录，失败时按退避时间重试
+type CertificateWebhookDelivery struct {
+	ID            uint       `json:"id" gorm:"primaryKey"`
+	WebhookID     uint       `json:"webhook_id" gorm:"index"`
+	EventID       string     `json:"event_id" gorm:"index"` // 同一事件推送到不同接收端时相同，接收端据此去重
+	Event         string     `json:"event" gorm:"index"`
+	Payload       string     `json:"payload" gorm:"type:text"`
+	Status        string     `json:"status" gorm:"index"`
+	Attempts      int        `json:"attempts"`
+	StatusCode    int        `json:"status_code"`
+	Response      string     `json:"response" gorm:"type:text"` // 接收端最近一次的响应，截断到 4 KiB
+	LastError     string     `json:"last_error,omitempty"`      // 最近一次推送失败的原因
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`
+	DeliveredAt   *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// CertificateWebhookPayload 推送给接收端的事件内容，证书事件带有证书，申请事件带有申请
+type CertificateWebhookPayload struct {
+	ID          string              `json:"id"`
+	Event       string              `json:"event"`
+	Time        time.Time           `json:"time"`
+	Actor       string              `json:"actor"`
+	Detail      string              `json:"detail,omitempty"`
+	Certificate *Certificate        `json:"certificate,omitempty"`
+	Request     *CertificateRequest `json:"request,omitempty"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// 法律保留的范围
+const (
+	LegalHoldCertificate = "certificate" // 单个证书
+	LegalHoldTenant      = "tenant"      // 租户的全部证书和申请记录
+)
+
+// LegalHold 法律保留，生效期间禁止删除、清理或匿名化相关记录，解除后保留历史
+type LegalHold struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Scope      string     `json:"scope" gorm:"not null;index:idx_legal_hold_target"`
+	TargetID   uint       `json:"target_id" gorm:"index:idx_legal_hold_target"` // 证书 ID 或租户的用户 ID
+	Reason     string     `json:"reason" gorm:"type:text"`
+	PlacedBy   string     `json:"placed_by"`
+	ReleasedBy string     `json:"released_by,omitempty"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// IsActive 检查法律保留是否仍然生效
+func (h *LegalHold) IsActive() bool {
+	return h.ReleasedAt == nil
+}
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// NotifyDevice 用户注册的接收推送通知的移动设备
+type NotifyDevice struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    uint      `json:"-" gorm:"index"`
+	Platform  string    `json:"platform" gorm:"not null"`               // fcm 或 apns
+	Token     string    `json:"-" gorm:"uniqueIndex;size:512;not null"` // 推送服务下发的设备令牌
+	Name      string    `json:"name"`                                   // 设备名称
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
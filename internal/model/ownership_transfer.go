@@ -0,0 +1,64 @@
+package model
+
+import "time"
+
+// 所有权转移任务的状态
+const (
+	OwnershipTransferRunning   = "running"
+	OwnershipTransferCompleted = "completed"
+	OwnershipTransferFailed    = "failed"
+)
+
+// 单条记录的转移结果
+const (
+	OwnershipTransferred     = "transferred"
+	OwnershipWouldTransfer   = "would_transfer" // 试运行时表示该记录会被转移
+	OwnershipTransferSkip    = "skipped"
+	OwnershipTransferFailure = "failed"
+)
+
+// OwnershipTransfer 批量所有权转移任务：将一个用户或组织名下的证书和未完成申请转移给另一个用户，
+// 例如组织调整后。任务在后台执行，试运行时只生成结果不做修改
+type OwnershipTransfer struct {
+	ID               uint                      `json:"id" gorm:"primaryKey"`
+	FromUserID       uint                      `json:"from_user_id,omitempty"` // 转出用户，与 FromOrganization 二选一
+	FromUser         string                    `json:"from_user,omitempty"`
+	FromOrganization string                    `json:"from_organization,omitempty"` // 转出组织，组织内所有用户的记录都会被转移
+	ToUserID         uint                      `json:"to_user_id"`
+	ToUser           string                    `json:"to_user"`
+	DryRun           bool                      `json:"dry_run"`
+	Status           string                    `json:"status" gorm:"index"`
+	Total            int                       `json:"total"`
+	Transferred      int                       `json:"transferred"`
+	Skipped          int                       `json:"skipped"`
+	Failed           int                       `json:"failed"`
+	Results          []OwnershipTransferResult `json:"results,omitempty" gorm:"serializer:json;type:text"` // 每条记录的结果
+	LastError        string                    `json:"last_error,omitempty" gorm:"type:text"`
+	StartedBy        string                    `json:"started_by"`
+	CompletedAt      *time.Time                `json:"completed_at,omitempty"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	UpdatedAt        time.Time                 `json:"updated_at"`
+}
+
+// OwnershipTransferResult 单条证书或申请的转移结果
+type OwnershipTransferResult struct {
+	Kind     string `json:"kind"` // certificate 或 request
+	ID       uint   `json:"id"`
+	Name     string `json:"name,omitempty"`
+	FromUser string `json:"from_user"`
+	Outcome  string `json:"outcome"`
+	Error    string `json:"error,omitempty"`
+}
+
+// AddResult 记录单条结果并更新计数，试运行时会被转移的记录计入 Transferred
+func (t *OwnershipTransfer) AddResult(r OwnershipTransferResult) {
+	t.Results = append(t.Results, r)
+	switch r.Outcome {
+	case OwnershipTransferred, OwnershipWouldTransfer:
+		t.Transferred++
+	case OwnershipTransferSkip:
+		t.Skipped++
+	default:
+		t.Failed++
+	}
+}
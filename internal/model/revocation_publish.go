@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// RevocationPublication 一个 CA 最近一次发布的 CRL 和 OCSP 响应
+type RevocationPublication struct {
+	CAID        uint      `json:"ca_id"`
+	CAName      string    `json:"ca_name"`
+	Fingerprint string    `json:"fingerprint"`
+	CRLPath     string    `json:"crl_path"`
+	OCSPPath    string    `json:"ocsp_path"` // 预签名 OCSP 响应所在目录，文件名为十六进制序列号
+	Revoked     int       `json:"revoked"`   // CRL 中的证书数量
+	OCSP        int       `json:"ocsp"`      // 发布的 OCSP 响应数量
+	ThisUpdate  time.Time `json:"this_update"`
+	NextUpdate  time.Time `json:"next_update"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// RevocationPublishStatus 吊销数据发布状态
+type RevocationPublishStatus struct {
+	Path         string                  `json:"path"` // 发布到的存储路径
+	PublishedAt  *time.Time              `json:"published_at,omitempty"`
+	Publications []RevocationPublication `json:"publications"`
+	Error        string                  `json:"error,omitempty"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// SchedulerLease is a lease on a scheduled job shared by all instances using the same
+// database, only the holder of an unexpired lease runs the job.
+type SchedulerLease struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:64"`
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
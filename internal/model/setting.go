@@ -13,6 +13,9 @@ const (
 	S3
 	FTP
 	TRAFFIC
+	NOTIFY
+	CERTIFICATE
+	AUDIT
 )
 
 const (
@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+// SSHHostPrincipal 管理员维护的 SSH 主机 principal，导出 known_hosts 时按 HostPattern 信任 SSH CA
+type SSHHostPrincipal struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Principal   string    `json:"principal" gorm:"uniqueIndex;not null"` // 主机证书中的 principal
+	HostPattern string    `json:"host_pattern"`                          // known_hosts 中的主机匹配模式，为空时使用 principal
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Pattern 返回 known_hosts 中使用的主机匹配模式
+func (p *SSHHostPrincipal) Pattern() string {
+	if p.HostPattern != "" {
+		return p.HostPattern
+	}
+	return p.Principal
+}
+
+// SSHCertificateInfo 从证书内容解析出的 SSH 证书信息
+type SSHCertificateInfo struct {
+	CertificateID uint      `json:"certificate_id"`
+	Name          string    `json:"name"`
+	Owner         string    `json:"owner"`
+	CertType      string    `json:"cert_type"` // user 或 host
+	KeyID         string    `json:"key_id"`
+	Serial        uint64    `json:"serial"`
+	Principals    []string  `json:"principals"`
+	ValidAfter    time.Time `json:"valid_after"`
+	ValidBefore   time.Time `json:"valid_before"`
+}
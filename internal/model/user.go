@@ -18,6 +18,7 @@ const (
 	GENERAL = iota
 	GUEST   // only one exists
 	ADMIN
+	AUDITOR // read-only access to all certificates, requests and audit logs
 )
 
 const StaticHashSalt = "https://github.com/alist-org/alist"
@@ -55,10 +56,19 @@ type User struct {
 	//   12: can read archives
 	//   13: can decompress archives
 	//   14: can share
-	Permission int32  `json:"permission"`
-	OtpSecret  string `json:"-"`
-	SsoID      string `json:"sso_id"` // unique by sso platform
-	Authn      string `gorm:"type:text" json:"-"`
+	//   15: can watch certificates of other users
+	//   16: can manage certificate freeze windows of own organization
+	//   17: can impersonate tenants
+	//   18: can read certificate content and private keys
+	//   19: can manage the certificate trust store of own organization
+	Permission   int32  `json:"permission"`
+	OtpSecret    string `json:"-"`
+	SsoID        string `json:"sso_id"`       // unique by sso platform
+	Email        string `json:"email"`        // receives email notifications
+	SlackID      string `json:"slack_id"`     // slack member id allowed to act on slack notifications
+	Phone        string `json:"phone"`        // receives sms notifications
+	Organization string `json:"organization"` // organization the user belongs to, used by certificate freeze windows and trust stores
+	Authn        string `gorm:"type:text" json:"-"`
 }
 
 func (u *User) IsGuest() bool {
@@ -69,6 +79,15 @@ func (u *User) IsAdmin() bool {
 	return u.Role == ADMIN
 }
 
+func (u *User) IsAuditor() bool {
+	return u.Role == AUDITOR
+}
+
+// CanViewAllCertificates reports whether the user can read every certificate and request
+func (u *User) CanViewAllCertificates() bool {
+	return u.IsAdmin() || u.IsAuditor()
+}
+
 func (u *User) ValidateRawPassword(password string) error {
 	return u.ValidatePwdStaticHash(StaticHash(password))
 }
@@ -150,6 +169,26 @@ func (u *User) CanShare() bool {
 	return (u.Permission>>14)&1 == 1
 }
 
+func (u *User) CanWatchCertificates() bool {
+	return (u.Permission>>15)&1 == 1
+}
+
+func (u *User) CanManageFreezeWindows() bool {
+	return (u.Permission>>16)&1 == 1
+}
+
+func (u *User) CanImpersonateTenants() bool {
+	return u.IsAdmin() || (u.Permission>>17)&1 == 1
+}
+
+func (u *User) CanReadCertificateContent() bool {
+	return (u.Permission>>18)&1 == 1
+}
+
+func (u *User) CanManageTrustStore() bool {
+	return (u.Permission>>19)&1 == 1
+}
+
 func (u *User) JoinPath(reqPath string) (string, error) {
 	return utils.JoinBasePath(u.BasePath, reqPath)
 }
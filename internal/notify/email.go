@@ -0,0 +1,130 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email sends the message to the mailbox of every recipient over smtp
+type Email struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	SSL      bool // implicit tls, otherwise starttls is used when the server supports it
+	// Addresses resolves the usernames of the recipients to their email addresses,
+	// users without an address are left out
+	Addresses func(usernames []string) map[string]string
+}
+
+func (e *Email) Name() string {
+	return "email"
+}
+
+func (e *Email) Send(ctx context.Context, msg *Message) error {
+	var errs []error
+	sent := make(map[string]bool)
+	for username, addr := range e.Addresses(msg.To) {
+		var links []Link
+		if msg.Links != nil {
+			links = msg.Links(username)
+		}
+		sent[strings.ToLower(addr)] = true
+		if err := e.send(ctx, addr, msg.Title, e.body(msg, links)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+		}
+	}
+	// personal links are never sent to addresses outside the users
+	for _, addr := range msg.Emails {
+		if addr == "" || sent[strings.ToLower(addr)] {
+			continue
+		}
+		sent[strings.ToLower(addr)] = true
+		if err := e.send(ctx, addr, msg.Title, e.body(msg, nil)); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *Email) body(msg *Message, links []Link) string {
+	var buf bytes.Buffer
+	buf.WriteString(msg.Content)
+	buf.WriteString("\r\n")
+	for _, link := range links {
+		buf.WriteString("\r\n")
+		buf.WriteString(link.Label)
+		buf.WriteString(": ")
+		buf.WriteString(link.URL)
+		buf.WriteString("\r\n")
+	}
+	return buf.String()
+}
+
+func (e *Email) dial(ctx context.Context) (net.Conn, error) {
+	addr := net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	if e.SSL {
+		return (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: e.Host}}).DialContext(ctx, "tcp", addr)
+	}
+	return dialer.DialContext(ctx, "tcp", addr)
+}
+
+func (e *Email) send(ctx context.Context, to, subject, body string) error {
+	conn, err := e.dial(ctx)
+	if err != nil {
+		return err
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(time.Minute)
+	}
+	_ = conn.SetDeadline(deadline)
+	c, err := smtp.NewClient(conn, e.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+	if !e.SSL {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err = c.StartTLS(&tls.Config{ServerName: e.Host}); err != nil {
+				return err
+			}
+		}
+	}
+	if e.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", e.Username, e.Password, e.Host)); err != nil {
+			return err
+		}
+	}
+	if err = c.Mail(e.From); err != nil {
+		return err
+	}
+	if err = c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nDate: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n",
+		e.From, to, mime.QEncoding.Encode("utf-8", subject), time.Now().Format(time.RFC1123Z))
+	if _, err = w.Write([]byte(header + body)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
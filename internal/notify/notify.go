@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Severity decides which channels a message is routed to
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Message is a notification delivered through the channels routed for its severity
+type Message struct {
+	Event    string    `json:"event"`
+	Severity Severity  `json:"severity"` // defaults to info
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	To       []string  `json:"to"` // usernames of the recipients
+	Time     time.Time `json:"time"`
+	// ApprovalID is the id of the certificate request waiting for approval,
+	// interactive channels render approve/reject buttons for it
+	ApprovalID uint `json:"approval_id,omitempty"`
+	// Test marks a sample message sent to validate a receiver, it carries no real event
+	Test bool `json:"test,omitempty"`
+	// Channels restricts the delivery to the named channels regardless of the routes
+	Channels []string `json:"-"`
+	// Emails are extra addresses not bound to any user, such as the contacts of a certificate.
+	// They are only delivered by the email channel.
+	Emails []string `json:"-"`
+	// Links returns the personal action links of a recipient, such as one-click approvals.
+	// It is only used by channels delivering to a single user.
+	Links func(username string) []Link `json:"-"`
+}
+
+// Link is an action link attached to a message
+type Link struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// Channel delivers messages to an external system, such as a webhook or mailbox
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, msg *Message) error
+}
+
+var (
+	channelsMu sync.RWMutex
+	channels   []Channel
+	routes     map[Severity][]string
+)
+
+// SetChannels replaces the enabled channels, called on startup and whenever settings change
+func SetChannels(chs ...Channel) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	channels = chs
+}
+
+// SetRoutes sets the names of the channels used for each severity.
+// Messages of a severity without routes are delivered through every channel.
+func SetRoutes(r map[Severity][]string) {
+	channelsMu.Lock()
+	defer channelsMu.Unlock()
+	routes = r
+}
+
+// Enabled reports whether any channel is configured
+func Enabled() bool {
+	channelsMu.RLock()
+	defer channelsMu.RUnlock()
+	return len(channels) > 0
+}
+
+// Send delivers the message through the channels routed for its severity.
+// A failing channel doesn't stop the others, all errors are joined.
+func Send(ctx context.Context, msg *Message) error {
+	if msg.Time.IsZero() {
+		msg.Time = time.Now()
+	}
+	if msg.Severity == "" {
+		msg.Severity = SeverityInfo
+	}
+	channelsMu.RLock()
+	chs := channels
+	names, routed := routes[msg.Severity]
+	channelsMu.RUnlock()
+	if len(msg.Channels) > 0 {
+		names, routed = msg.Channels, true
+	}
+	var errs []error
+	for _, ch := range chs {
+		if routed && !slices.Contains(names, ch.Name()) {
+			continue
+		}
+		if err := ch.Send(ctx, msg); err != nil {
+			errs = append(errs, errors.Join(errors.New(ch.Name()), err))
+		}
+	}
+	return errors.Join(errs...)
+}
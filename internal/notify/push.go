@@ -0,0 +1,273 @@
+package notify
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	PlatformFCM  = "fcm"
+	PlatformAPNs = "apns"
+)
+
+// ErrInvalidDeviceToken is returned when the push service no longer accepts the device token
+var ErrInvalidDeviceToken = errors.New("invalid device token")
+
+// Device is a mobile device registered by a user to receive push notifications
+type Device struct {
+	Username string
+	Platform string
+	Token    string
+}
+
+// Push delivers the message as push notifications to the registered devices of the recipients
+type Push struct {
+	FCM  *FCM  // nil if not configured
+	APNs *APNs // nil if not configured
+	// Devices returns the registered devices of the usernames
+	Devices func(usernames []string) []Device
+	// Unregister removes a device token rejected by the push service
+	Unregister func(token string)
+}
+
+func (p *Push) Name() string {
+	return "push"
+}
+
+func (p *Push) Send(ctx context.Context, msg *Message) error {
+	var errs []error
+	for _, device := range p.Devices(msg.To) {
+		var err error
+		switch {
+		case device.Platform == PlatformFCM && p.FCM != nil:
+			err = p.FCM.Send(ctx, device.Token, msg)
+		case device.Platform == PlatformAPNs && p.APNs != nil:
+			err = p.APNs.Send(ctx, device.Token, msg)
+		default:
+			continue
+		}
+		if errors.Is(err, ErrInvalidDeviceToken) {
+			if p.Unregister != nil {
+				p.Unregister(device.Token)
+			}
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s device of %s: %w", device.Platform, device.Username, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type fcmServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEMail string `json:"client_email"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCM sends push notifications through the firebase cloud messaging http v1 api
+type FCM struct {
+	account fcmServiceAccount
+
+	mu          sync.Mutex
+	accessToken string
+	expireAt    time.Time
+}
+
+// NewFCM creates the fcm sender from the json of a firebase service account
+func NewFCM(serviceAccount string) (*FCM, error) {
+	var account fcmServiceAccount
+	if err := json.Unmarshal([]byte(serviceAccount), &account); err != nil {
+		return nil, fmt.Errorf("invalid fcm service account: %w", err)
+	}
+	if account.ProjectID == "" || account.PrivateKey == "" || account.ClientEMail == "" {
+		return nil, fmt.Errorf("invalid fcm service account: missing project_id, private_key or client_email")
+	}
+	if account.TokenURI == "" {
+		account.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &FCM{account: account}, nil
+}
+
+func (f *FCM) token(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.accessToken != "" && time.Now().Before(f.expireAt) {
+		return f.accessToken, nil
+	}
+	block, _ := pem.Decode([]byte(f.account.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("invalid fcm private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.account.ClientEMail,
+		"scope": "https://www.googleapis.com/auth/firebase.messaging",
+		"aud":   f.account.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetFormData(map[string]string{
+			"grant_type": "urn:ietf:params:oauth:grant-type:jwt-bearer",
+			"assertion":  assertion,
+		}).
+		SetResult(&resp).
+		Post(f.account.TokenURI)
+	if err != nil {
+		return "", err
+	}
+	if res.IsError() || resp.AccessToken == "" {
+		return "", fmt.Errorf("failed to get fcm access token: %s", res.String())
+	}
+	f.accessToken = resp.AccessToken
+	// refresh a minute ahead of the expiration
+	f.expireAt = now.Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return f.accessToken, nil
+}
+
+func (f *FCM) Send(ctx context.Context, deviceToken string, msg *Message) error {
+	token, err := f.token(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetAuthToken(token).
+		SetBody(map[string]any{
+			"message": map[string]any{
+				"token": deviceToken,
+				"notification": map[string]string{
+					"title": msg.Title,
+					"body":  msg.Content,
+				},
+				"data": map[string]string{
+					"event": msg.Event,
+				},
+			},
+		}).
+		Post(fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", f.account.ProjectID))
+	if err != nil {
+		return err
+	}
+	if res.StatusCode() == http.StatusNotFound {
+		return ErrInvalidDeviceToken
+	}
+	if res.IsError() {
+		return fmt.Errorf("fcm responded with status %s: %s", res.Status(), res.String())
+	}
+	return nil
+}
+
+// APNs sends push notifications through the apple push notification service with token based authentication
+type APNs struct {
+	KeyID   string
+	TeamID  string
+	Topic   string // bundle id of the app
+	Sandbox bool
+	key     *ecdsa.PrivateKey
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+// NewAPNs creates the apns sender from the .p8 signing key
+func NewAPNs(key, keyID, teamID, topic string, sandbox bool) (*APNs, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, fmt.Errorf("invalid apns key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	ecKey, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("apns key is not an ecdsa key")
+	}
+	return &APNs{KeyID: keyID, TeamID: teamID, Topic: topic, Sandbox: sandbox, key: ecKey}, nil
+}
+
+func (a *APNs) authToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// apple rejects tokens older than an hour and refreshing more than once every 20 minutes
+	if a.token != "" && time.Since(a.issuedAt) < 50*time.Minute {
+		return a.token, nil
+	}
+	t := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.TeamID,
+		"iat": time.Now().Unix(),
+	})
+	t.Header["kid"] = a.KeyID
+	token, err := t.SignedString(a.key)
+	if err != nil {
+		return "", err
+	}
+	a.token = token
+	a.issuedAt = time.Now()
+	return token, nil
+}
+
+func (a *APNs) Send(ctx context.Context, deviceToken string, msg *Message) error {
+	token, err := a.authToken()
+	if err != nil {
+		return err
+	}
+	host := "https://api.push.apple.com"
+	if a.Sandbox {
+		host = "https://api.sandbox.push.apple.com"
+	}
+	var resp struct {
+		Reason string `json:"reason"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetHeader("authorization", "bearer "+token).
+		SetHeader("apns-topic", a.Topic).
+		SetHeader("apns-push-type", "alert").
+		SetBody(map[string]any{
+			"aps": map[string]any{
+				"alert": map[string]string{
+					"title": msg.Title,
+					"body":  msg.Content,
+				},
+				"sound": "default",
+			},
+			"event": msg.Event,
+		}).
+		SetError(&resp).
+		Post(host + "/3/device/" + deviceToken)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode() == http.StatusGone || resp.Reason == "BadDeviceToken" || resp.Reason == "Unregistered" {
+		return ErrInvalidDeviceToken
+	}
+	if res.IsError() {
+		return fmt.Errorf("apns responded with status %s: %s", res.Status(), resp.Reason)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+)
+
+const (
+	SlackActionApprove = "certificate_approve"
+	SlackActionReject  = "certificate_reject"
+)
+
+// Slack posts the message to a slack channel through a bot token.
+// Messages waiting for approval come with approve/reject buttons.
+type Slack struct {
+	Token   string
+	Channel string
+}
+
+func (s *Slack) Name() string {
+	return "slack"
+}
+
+func (s *Slack) Send(ctx context.Context, msg *Message) error {
+	blocks := []map[string]any{
+		{
+			"type": "section",
+			"text": map[string]any{"type": "mrkdwn", "text": fmt.Sprintf("*%s*\n%s", msg.Title, msg.Content)},
+		},
+	}
+	if msg.ApprovalID != 0 {
+		value := strconv.FormatUint(uint64(msg.ApprovalID), 10)
+		blocks = append(blocks, map[string]any{
+			"type": "actions",
+			"elements": []map[string]any{
+				{
+					"type":      "button",
+					"action_id": SlackActionApprove,
+					"style":     "primary",
+					"value":     value,
+					"text":      map[string]any{"type": "plain_text", "text": "Approve"},
+				},
+				{
+					"type":      "button",
+					"action_id": SlackActionReject,
+					"style":     "danger",
+					"value":     value,
+					"text":      map[string]any{"type": "plain_text", "text": "Reject"},
+				},
+			},
+		})
+	}
+	var resp struct {
+		Ok    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetAuthToken(s.Token).
+		SetBody(map[string]any{
+			"channel": s.Channel,
+			"text":    msg.Title,
+			"blocks":  blocks,
+		}).
+		SetResult(&resp).
+		Post("https://slack.com/api/chat.postMessage")
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return fmt.Errorf("slack responded with status %s", res.Status())
+	}
+	if !resp.Ok {
+		return fmt.Errorf("slack responded with error: %s", resp.Error)
+	}
+	return nil
+}
+
+// VerifySlackRequest checks the signature slack attached to an interaction callback,
+// see https://api.slack.com/authentication/verifying-requests-from-slack
+func VerifySlackRequest(secret, timestamp, signature string, body []byte) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid slack request timestamp")
+	}
+	if d := time.Since(time.Unix(ts, 0)); d > 5*time.Minute || d < -5*time.Minute {
+		return fmt.Errorf("slack request timestamp is too old")
+	}
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte("v0:" + timestamp + ":"))
+	h.Write(body)
+	expected := "v0=" + hex.EncodeToString(h.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("invalid slack request signature")
+	}
+	return nil
+}
+
+// RespondSlack replies to an interaction callback through its response url,
+// either replacing the original message or as an ephemeral message to the user
+func RespondSlack(ctx context.Context, responseURL, text string, replace bool) error {
+	body := map[string]any{"text": text}
+	if replace {
+		body["replace_original"] = true
+	} else {
+		body["response_type"] = "ephemeral"
+		body["replace_original"] = false
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetBody(body).
+		Post(responseURL)
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return fmt.Errorf("slack responded with status %s", res.Status())
+	}
+	return nil
+}
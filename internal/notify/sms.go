@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/google/uuid"
+)
+
+// SMSProvider sends a text message to a phone number
+type SMSProvider interface {
+	SendSMS(ctx context.Context, phone, text string) error
+}
+
+// SMS sends the message as a text message to the phone of every recipient.
+// Text messages are costly, it is usually routed for critical messages only.
+type SMS struct {
+	Provider SMSProvider
+	// Phones resolves the usernames of the recipients to their phone numbers,
+	// users without a phone number are left out
+	Phones func(usernames []string) map[string]string
+}
+
+func (s *SMS) Name() string {
+	return "sms"
+}
+
+func (s *SMS) Send(ctx context.Context, msg *Message) error {
+	text := msg.Title
+	if msg.Content != "" {
+		text += "\n" + msg.Content
+	}
+	var errs []error
+	for _, phone := range s.Phones(msg.To) {
+		if err := s.Provider.SendSMS(ctx, phone, text); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", phone, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Twilio sends text messages through the twilio messaging api
+type Twilio struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+}
+
+func (t *Twilio) SendSMS(ctx context.Context, phone, text string) error {
+	var resp struct {
+		Message string `json:"message"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetBasicAuth(t.AccountSID, t.AuthToken).
+		SetFormData(map[string]string{
+			"To":   phone,
+			"From": t.From,
+			"Body": text,
+		}).
+		SetError(&resp).
+		Post(fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", t.AccountSID))
+	if err != nil {
+		return err
+	}
+	if res.IsError() {
+		return fmt.Errorf("twilio responded with status %s: %s", res.Status(), resp.Message)
+	}
+	return nil
+}
+
+// Aliyun sends text messages through aliyun sms, the template should contain a ${content} variable
+type Aliyun struct {
+	AccessKeyID     string
+	AccessKeySecret string
+	SignName        string
+	TemplateCode    string
+}
+
+func aliyunEncode(s string) string {
+	s = url.QueryEscape(s)
+	s = strings.ReplaceAll(s, "+", "%20")
+	s = strings.ReplaceAll(s, "*", "%2A")
+	return strings.ReplaceAll(s, "%7E", "~")
+}
+
+func (a *Aliyun) SendSMS(ctx context.Context, phone, text string) error {
+	param, err := json.Marshal(map[string]string{"content": text})
+	if err != nil {
+		return err
+	}
+	params := map[string]string{
+		"AccessKeyId":      a.AccessKeyID,
+		"Action":           "SendSms",
+		"Format":           "JSON",
+		"PhoneNumbers":     phone,
+		"RegionId":         "cn-hangzhou",
+		"SignName":         a.SignName,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   uuid.NewString(),
+		"SignatureVersion": "1.0",
+		"TemplateCode":     a.TemplateCode,
+		"TemplateParam":    string(param),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"Version":          "2017-05-25",
+	}
+	// rpc signature, see https://help.aliyun.com/document_detail/315526.html
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, aliyunEncode(k)+"="+aliyunEncode(params[k]))
+	}
+	query := strings.Join(pairs, "&")
+	h := hmac.New(sha1.New, []byte(a.AccessKeySecret+"&"))
+	h.Write([]byte("GET&%2F&" + aliyunEncode(query)))
+	signature := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	var resp struct {
+		Code    string `json:"Code"`
+		Message string `json:"Message"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetResult(&resp).
+		SetError(&resp).
+		Get("https://dysmsapi.aliyuncs.com/?Signature=" + aliyunEncode(signature) + "&" + query)
+	if err != nil {
+		return err
+	}
+	if res.IsError() || resp.Code != "OK" {
+		return fmt.Errorf("aliyun sms responded with %s: %s", resp.Code, resp.Message)
+	}
+	return nil
+}
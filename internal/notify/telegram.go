@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+)
+
+// Telegram posts the message to a chat through a telegram bot
+type Telegram struct {
+	Token  string
+	ChatID string
+}
+
+func (t *Telegram) Name() string {
+	return "telegram"
+}
+
+func (t *Telegram) Send(ctx context.Context, msg *Message) error {
+	var resp struct {
+		Ok          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).
+		SetBody(map[string]any{
+			"chat_id":                  t.ChatID,
+			"text":                     fmt.Sprintf("%s\n\n%s", msg.Title, msg.Content),
+			"disable_web_page_preview": true,
+		}).
+		SetResult(&resp).
+		SetError(&resp).
+		Post("https://api.telegram.org/bot" + t.Token + "/sendMessage")
+	if err != nil {
+		return err
+	}
+	if res.IsError() || !resp.Ok {
+		return fmt.Errorf("telegram responded with status %s: %s", res.Status(), resp.Description)
+	}
+	return nil
+}
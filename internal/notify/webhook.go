@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+)
+
+// maxWebhookResultBody limits the response body kept in a WebhookResult
+const maxWebhookResultBody = 4096
+
+// Webhook posts the message as json to the configured url
+type Webhook struct {
+	URL string
+}
+
+// WebhookResult is the outcome of a single delivery, reported to integrators testing their receivers
+type WebhookResult struct {
+	StatusCode int    `json:"status_code"`
+	Body       string `json:"body"` // truncated to 4 KiB
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+func (w *Webhook) Name() string {
+	return "webhook"
+}
+
+func (w *Webhook) Send(ctx context.Context, msg *Message) error {
+	_, err := w.Deliver(ctx, msg)
+	return err
+}
+
+// Deliver posts the message and reports the response and latency. The result is always returned,
+// a transport error or an error status is also recorded in its Error field.
+func (w *Webhook) Deliver(ctx context.Context, msg *Message) (*WebhookResult, error) {
+	start := time.Now()
+	res, err := base.RestyClient.R().SetContext(ctx).SetBody(msg).Post(w.URL)
+	result := &WebhookResult{LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+	result.StatusCode = res.StatusCode()
+	if body := res.String(); len(body) > maxWebhookResultBody {
+		result.Body = body[:maxWebhookResultBody]
+	} else {
+		result.Body = body
+	}
+	if res.IsError() {
+		err = fmt.Errorf("webhook responded with status %s", res.Status())
+		result.Error = err.Error()
+	}
+	return result, err
+}
@@ -0,0 +1,261 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// StartCARotation 开始将 fromID 签发的证书轮换到 toID。新旧 CA 在轮换期间同时发布在信任包中，
+// crossSignedID 可选，为旧 CA 给新 CA 交叉签名的证书，便于只信任旧根的设备校验新证书
+func StartCARotation(fromID, toID, crossSignedID uint, batchSize int, operator *model.User) (*model.CARotation, error) {
+	if fromID == toID {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "the new ca must be different from the old one")
+	}
+	now := time.Now()
+	from, err := db.GetCertificateAuthorityByID(fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := db.GetCertificateAuthorityByID(toID)
+	if err != nil {
+		return nil, err
+	}
+	if !from.IsActive(now) || !to.IsActive(now) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "both cas must be active")
+	}
+	if crossSignedID != 0 {
+		cross, err := db.GetCertificateAuthorityByID(crossSignedID)
+		if err != nil {
+			return nil, err
+		}
+		if !cross.IsActive(now) || cross.Subject != to.Subject || cross.Issuer != from.Subject {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %d is not an active cross-signed certificate of the new ca by the old ca", crossSignedID)
+		}
+	}
+	running, err := db.GetRunningCARotations()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range running {
+		if r.FromCAID == fromID || r.ToCAID == fromID {
+			return nil, errs.NewErr(errs.CertificateConflict, "ca %d is already being rotated by rotation %d", fromID, r.ID)
+		}
+	}
+	if batchSize <= 0 {
+		batchSize = getSettingInt(conf.CertCARotationBatchSize, 50)
+	}
+	total, err := db.CountActiveCertificatesByIssuer(fromID)
+	if err != nil {
+		return nil, err
+	}
+	r := &model.CARotation{
+		FromCAID:      fromID,
+		ToCAID:        toID,
+		CrossSignedID: crossSignedID,
+		Status:        model.CARotationRunning,
+		BatchSize:     batchSize,
+		Total:         int(total),
+		StartedBy:     operator.Username,
+	}
+	if err := db.CreateCARotation(r); err != nil {
+		return nil, errors.WithMessage(err, "failed create ca rotation")
+	}
+	auditCARotation("certificate.ca.rotation_started", operator.Username, r,
+		fmt.Sprintf("from ca %d to ca %d, %d certificates", fromID, toID, total))
+	return r, nil
+}
+
+// CancelCARotation 取消轮换，已重新签发的证书保持不变，旧 CA 继续在用
+func CancelCARotation(id uint, operator *model.User) error {
+	r, err := db.GetCARotationByID(id)
+	if err != nil {
+		return err
+	}
+	if !r.IsRunning() {
+		return errs.NewErr(errs.CertificateConflict, "rotation is not running, current status: %s", r.Status)
+	}
+	r.Status = model.CARotationCancelled
+	if err := db.UpdateCARotation(r); err != nil {
+		return err
+	}
+	auditCARotation("certificate.ca.rotation_cancelled", operator.Username, r, "")
+	return nil
+}
+
+// GetCARotations 获取全部轮换及其进度
+func GetCARotations() ([]model.CARotationProgress, error) {
+	rotations, err := db.GetCARotations()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]model.CARotationProgress, 0, len(rotations))
+	for _, r := range rotations {
+		p, err := caRotationProgress(r)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *p)
+	}
+	return res, nil
+}
+
+// GetCARotationProgress 获取轮换进度
+func GetCARotationProgress(id uint) (*model.CARotationProgress, error) {
+	r, err := db.GetCARotationByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return caRotationProgress(*r)
+}
+
+func caRotationProgress(r model.CARotation) (*model.CARotationProgress, error) {
+	p := &model.CARotationProgress{CARotation: r, Percent: 100}
+	if r.IsRunning() {
+		remaining, err := db.CountActiveCertificatesByIssuer(r.FromCAID)
+		if err != nil {
+			return nil, err
+		}
+		p.Remaining = remaining
+	}
+	if total := int64(r.Reissued) + p.Remaining; total > 0 {
+		p.Percent = float64(r.Reissued) * 100 / float64(total)
+	}
+	return p, nil
+}
+
+// RunCARotations 推进正在进行的轮换，每个轮换每次重新签发一批证书，由定时任务调用
+func RunCARotations(ctx context.Context) error {
+	rotations, err := db.GetRunningCARotations()
+	if err != nil {
+		return err
+	}
+	for i := range rotations {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := advanceCARotation(&rotations[i]); err != nil {
+			log.Errorf("failed to advance ca rotation %d: %+v", rotations[i].ID, err)
+		}
+	}
+	return nil
+}
+
+func advanceCARotation(r *model.CARotation) error {
+	to, err := db.GetCertificateAuthorityByID(r.ToCAID)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if !to.IsActive(now) {
+		r.LastError = fmt.Sprintf("new ca %d is no longer active", to.ID)
+		return db.UpdateCARotation(r)
+	}
+	certs, err := db.GetActiveCertificatesByIssuer(r.FromCAID, r.BatchSize)
+	if err != nil {
+		return err
+	}
+	for i := range certs {
+		// 所有者的组织处于冻结期时跳过，冻结结束后再重新签发
+		if w, err := activeFreezeWindow(certs[i].OwnerID, now); err != nil || w != nil {
+			continue
+		}
+		if err := reissueCertificate(&certs[i], to, now); err != nil {
+			r.Failed++
+			r.LastError = err.Error()
+			continue
+		}
+		r.Reissued++
+	}
+	remaining, err := db.CountActiveCertificatesByIssuer(r.FromCAID)
+	if err != nil {
+		return err
+	}
+	if remaining == 0 {
+		if err := completeCARotation(r, now); err != nil {
+			return err
+		}
+	}
+	return db.UpdateCARotation(r)
+}
+
+// reissueCertificate 由新 CA 签发替代证书，有效期不超过原证书和新 CA 的有效期，原证书以 superseded 原因吊销
+func reissueCertificate(cert *model.Certificate, to *model.CertificateAuthority, now time.Time) error {
+	replacement := &model.Certificate{
+		Name:              cert.Name,
+		Type:              cert.Type,
+		Status:            model.CertificateStatusValid,
+		Owner:             cert.Owner,
+		OwnerID:           cert.OwnerID,
+		RequestID:         cert.RequestID,
+		ProfileID:         cert.ProfileID,
+		IssuerID:          to.ID,
+		ResponsibleTeam:   cert.ResponsibleTeam,
+		ContactEmail:      cert.ContactEmail,
+		EscalationContact: cert.EscalationContact,
+		IssuedDate:        now,
+		ExpirationDate:    cert.ExpirationDate,
+	}
+	if to.NotAfter.Before(replacement.ExpirationDate) {
+		replacement.ExpirationDate = to.NotAfter
+	}
+	if err := regenerateCertificate(cert, replacement); err != nil {
+		return err
+	}
+	cert.Status = model.CertificateStatusRevoked
+	cert.RevokedAt = &now
+	cert.RevocationReason = model.RevocationReasonSuperseded
+	if err := db.ReplaceCertificate(cert, replacement); err != nil {
+		return err
+	}
+	recordCertificateEvent(cert.ID, "certificate.superseded", "system", fmt.Sprintf("replaced by certificate %d", replacement.ID))
+	recordCertificateEvent(replacement.ID, "certificate.issued", "system", fmt.Sprintf("reissued from certificate %d by ca rotation", cert.ID))
+	emitCertificateEvent("certificate.reissued", replacement,
+		fmt.Sprintf("Certificate %s has been reissued", replacement.Name),
+		fmt.Sprintf("The certificate was reissued by the new CA %s, please download and deploy it.", to.Name))
+	reloadTLSCertificateAsync()
+	return nil
+}
+
+// completeCARotation 旧 CA 不再被有效证书引用，停用旧 CA 和交叉签名证书
+func completeCARotation(r *model.CARotation, now time.Time) error {
+	ids := []uint{r.FromCAID}
+	if r.CrossSignedID != 0 {
+		ids = append(ids, r.CrossSignedID)
+	}
+	for _, id := range ids {
+		ca, err := db.GetCertificateAuthorityByID(id)
+		if err != nil {
+			return err
+		}
+		ca.Status = model.CertificateAuthorityRetired
+		if err := db.UpdateCertificateAuthority(ca); err != nil {
+			return err
+		}
+	}
+	if err := bumpCABundleVersion(); err != nil {
+		return err
+	}
+	r.Status = model.CARotationCompleted
+	r.CompletedAt = &now
+	auditCARotation("certificate.ca.rotation_completed", "system", r,
+		fmt.Sprintf("%d certificates reissued, ca %d retired", r.Reissued, r.FromCAID))
+	return nil
+}
+
+func auditCARotation(event, actor string, r *model.CARotation, detail string) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("ca_rotation:%d", r.ID),
+		Detail: detail,
+	})
+}
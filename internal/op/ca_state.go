@@ -0,0 +1,176 @@
+package op
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	caStateKDF           = "scrypt-32768-8-1"
+	caStateMinPassphrase = 12
+)
+
+func caStateKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// caStateAAD 文件头参与认证，篡改任何字段都会导致解密失败
+func caStateAAD(b *model.CAStateBundle) []byte {
+	return []byte(b.Format + "|" + b.ExportedAt.UTC().Format(time.RFC3339Nano) + "|" + b.ExportedBy + "|" + b.KDF + "|" + b.Digest)
+}
+
+// checkCAStateOperator 导出和导入 CA 状态需要管理员再次输入登录密码
+func checkCAStateOperator(user *model.User, password string) error {
+	if !user.IsAdmin() {
+		return errs.PermissionDenied
+	}
+	if err := user.ValidateRawPassword(password); err != nil {
+		return errors.WithMessage(errs.PermissionDenied, "wrong password")
+	}
+	return nil
+}
+
+// ExportCAState 导出 CA 的完整状态用于灾备，导出文件使用口令加密
+func ExportCAState(user *model.User, password, passphrase string) (*model.CAStateBundle, error) {
+	if err := checkCAStateOperator(user, password); err != nil {
+		return nil, err
+	}
+	if len(passphrase) < caStateMinPassphrase {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "passphrase must be at least %d characters", caStateMinPassphrase)
+	}
+	state, err := db.GetCAState()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(state)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	digest := sha256.Sum256(plain)
+	b := &model.CAStateBundle{
+		Format:     model.CAStateFormat,
+		ExportedAt: time.Now(),
+		ExportedBy: user.Username,
+		KDF:        caStateKDF,
+		Salt:       make([]byte, 16),
+		Digest:     hex.EncodeToString(digest[:]),
+	}
+	if _, err := rand.Read(b.Salt); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	aead, err := caStateKey(passphrase, b.Salt)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(b.Nonce); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	b.Data = aead.Seal(nil, b.Nonce, plain, caStateAAD(b))
+	audit.Emit(&audit.Event{
+		Type:   "certificate.ca_state.exported",
+		Actor:  user.Username,
+		Detail: "digest " + b.Digest,
+	})
+	return b, nil
+}
+
+// decryptCAState 解密并校验导出文件的完整性
+func decryptCAState(b *model.CAStateBundle, passphrase string) (*model.CAState, error) {
+	if b.Format != model.CAStateFormat {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported ca state format: %s", b.Format)
+	}
+	if b.KDF != caStateKDF {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported ca state kdf: %s", b.KDF)
+	}
+	aead, err := caStateKey(passphrase, b.Salt)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if len(b.Nonce) != aead.NonceSize() {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid ca state nonce")
+	}
+	plain, err := aead.Open(nil, b.Nonce, b.Data, caStateAAD(b))
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "wrong passphrase or the ca state has been tampered with")
+	}
+	digest := sha256.Sum256(plain)
+	if hex.EncodeToString(digest[:]) != b.Digest {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "ca state digest mismatch")
+	}
+	var state model.CAState
+	if err := json.Unmarshal(plain, &state); err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid ca state: %s", err.Error())
+	}
+	return &state, nil
+}
+
+// ImportCAState 将导出的 CA 状态导入到新的实例，只能导入到尚未签发过证书的实例
+func ImportCAState(user *model.User, password, passphrase string, b *model.CAStateBundle) (*model.CAStateSummary, error) {
+	if err := checkCAStateOperator(user, password); err != nil {
+		return nil, err
+	}
+	state, err := decryptCAState(b, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	count, err := db.CountAllCertificates()
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errs.NewErr(errs.CertificateConflict, "ca state can only be imported into an instance without certificates")
+	}
+	// 类型按名称引用，ID 由目标实例分配
+	for i := range state.Types {
+		state.Types[i].ID = 0
+	}
+	for i := range state.Certificates {
+		state.Certificates[i].PrivateKey = state.CertificateKeys[state.Certificates[i].ID]
+	}
+	// CA 的 ID 由目标实例分配，引用在写入时按指纹映射
+	for i := range state.Authorities {
+		state.Authorities[i].PrivateKey = state.AuthorityKeys[state.Authorities[i].Fingerprint]
+	}
+	if err := db.RestoreCAState(state); err != nil {
+		return nil, err
+	}
+	if len(state.Authorities) > 0 {
+		if err := bumpCABundleVersion(); err != nil {
+			return nil, err
+		}
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.ca_state.imported",
+		Actor:  user.Username,
+		Detail: "digest " + b.Digest + ", exported by " + b.ExportedBy,
+	})
+	return &model.CAStateSummary{
+		Certificates: len(state.Certificates),
+		Requests:     len(state.Requests),
+		Events:       len(state.Events),
+		Types:        len(state.Types),
+		Fields:       len(state.Fields),
+		Authorities:  len(state.Authorities),
+	}, nil
+}
@@ -0,0 +1,126 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// CAStatusCheck 状态页中一个组件的健康检查
+type CAStatusCheck func(ctx context.Context) model.CAStatusComponent
+
+var (
+	caStatusChecksMu sync.RWMutex
+	caStatusChecks   = map[string]CAStatusCheck{}
+
+	caStatusMu     sync.Mutex
+	caStatusCache  *model.CAStatus
+	caStatusMaxAge = time.Minute
+)
+
+// RegisterCAStatusCheck 注册状态页的组件检查，CA、CRL 和 OCSP 等模块各自注册
+func RegisterCAStatusCheck(name string, check CAStatusCheck) {
+	caStatusChecksMu.Lock()
+	defer caStatusChecksMu.Unlock()
+	caStatusChecks[name] = check
+}
+
+func init() {
+	RegisterCAStatusCheck("issuance", func(ctx context.Context) model.CAStatusComponent {
+		c := model.CAStatusComponent{Name: "issuance", Status: model.CAStatusOperational}
+		if err := db.Ping(); err != nil {
+			c.Status = model.CAStatusDown
+			c.Detail = "certificate store is unavailable"
+		}
+		return c
+	})
+}
+
+var GetCAMaintenanceWindows = db.GetCAMaintenanceWindows
+var GetCAMaintenanceWindowByID = db.GetCAMaintenanceWindowByID
+var DeleteCAMaintenanceWindow = db.DeleteCAMaintenanceWindow
+
+func checkCAMaintenanceWindow(w *model.CAMaintenanceWindow) error {
+	if w.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if !w.EndAt.After(w.StartAt) {
+		return fmt.Errorf("end_at must be after start_at")
+	}
+	return nil
+}
+
+func CreateCAMaintenanceWindow(w *model.CAMaintenanceWindow) error {
+	if err := checkCAMaintenanceWindow(w); err != nil {
+		return err
+	}
+	return db.CreateCAMaintenanceWindow(w)
+}
+
+func UpdateCAMaintenanceWindow(w *model.CAMaintenanceWindow) error {
+	if err := checkCAMaintenanceWindow(w); err != nil {
+		return err
+	}
+	return db.UpdateCAMaintenanceWindow(w)
+}
+
+// GetCAStatus 汇总各组件的健康状态和维护计划，结果缓存一分钟，避免公开接口被频繁触发检查
+func GetCAStatus(ctx context.Context) (*model.CAStatus, error) {
+	caStatusMu.Lock()
+	defer caStatusMu.Unlock()
+	if caStatusCache != nil && time.Since(caStatusCache.CheckedAt) < caStatusMaxAge {
+		return caStatusCache, nil
+	}
+	now := time.Now()
+	windows, err := db.GetUpcomingCAMaintenanceWindows(now)
+	if err != nil {
+		return nil, err
+	}
+	inMaintenance := false
+	for i := range windows {
+		if windows[i].IsActive(now) {
+			inMaintenance = true
+		}
+	}
+
+	caStatusChecksMu.RLock()
+	checks := make(map[string]CAStatusCheck, len(caStatusChecks))
+	for name, check := range caStatusChecks {
+		checks[name] = check
+	}
+	caStatusChecksMu.RUnlock()
+
+	status := &model.CAStatus{
+		Status:      model.CAStatusOperational,
+		Components:  make([]model.CAStatusComponent, 0, len(checks)),
+		Maintenance: windows,
+		CheckedAt:   now,
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for _, check := range checks {
+		c := check(ctx)
+		switch c.Status {
+		case model.CAStatusDown:
+			status.Status = model.CAStatusDown
+		case model.CAStatusDegraded:
+			if status.Status == model.CAStatusOperational {
+				status.Status = model.CAStatusDegraded
+			}
+		}
+		status.Components = append(status.Components, c)
+	}
+	sort.Slice(status.Components, func(i, j int) bool {
+		return status.Components[i].Name < status.Components[j].Name
+	})
+	if inMaintenance {
+		status.Status = model.CAStatusMaintenance
+	}
+	caStatusCache = status
+	return status, nil
+}
@@ -2,10 +2,15 @@ package op
 
 import (
 	"fmt"
+	"net/mail"
+	"strings"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 )
@@ -13,22 +18,92 @@ import (
 // --- Certificate Service ---
 
 var GetCertificateByID = db.GetCertificateByID
-var GetCertificates = db.GetCertificates
-var CreateCertificate = db.CreateCertificate
-var UpdateCertificate = db.UpdateCertificate
 
-// GetCertificateForTenant 是租户端调用的核心服务
-func GetCertificateForTenant(ownerID uint) (*model.Certificate, error) {
-	cert, err := db.GetCertificateByOwnerID(ownerID)
+// certificateListStatuses 证书列表可以过滤的状态
+var certificateListStatuses = []model.CertificateStatus{
+	model.CertificateStatusValid, model.CertificateStatusExpiring, model.CertificateStatusExpired,
+	model.CertificateStatusRevoked, model.CertificateStatusHold,
+}
+
+// GetCertificates 分页获取证书，filter 为 nil 时不过滤并按 id 倒序
+func GetCertificates(pageIndex, pageSize int, filter *model.CertificateFilter) ([]model.Certificate, int64, error) {
+	if err := checkCertificateFilter(filter); err != nil {
+		return nil, 0, err
+	}
+	return db.GetCertificates(pageIndex, pageSize, filter)
+}
+
+// checkCertificateFilter 校验过滤条件，排序字段只允许白名单中的列
+func checkCertificateFilter(filter *model.CertificateFilter) error {
+	if filter == nil {
+		return nil
+	}
+	if filter.Status != "" && !utils.SliceContains(certificateListStatuses, filter.Status) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "invalid status: %s", filter.Status)
+	}
+	if filter.OrderBy != "" && !utils.SliceContains(model.CertificateOrderColumns, filter.OrderBy) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "invalid order_by: %s", filter.OrderBy)
+	}
+	filter.OrderDirection = strings.ToLower(filter.OrderDirection)
+	if filter.OrderDirection != "" && filter.OrderDirection != "asc" && filter.OrderDirection != "desc" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "invalid order_direction: %s", filter.OrderDirection)
+	}
+	if !filter.ExpiresAfter.IsZero() && !filter.ExpiresBefore.IsZero() && !filter.ExpiresBefore.After(filter.ExpiresAfter) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "expires_before must be after expires_after")
+	}
+	return nil
+}
+
+// checkCertificateContacts 校验证书联系人，只接受不带显示名的邮箱地址
+func checkCertificateContacts(cert *model.Certificate) error {
+	for _, addr := range []string{cert.ContactEmail, cert.EscalationContact} {
+		if addr == "" {
+			continue
+		}
+		if parsed, err := mail.ParseAddress(addr); err != nil || parsed.Address != addr {
+			return errs.NewErr(errs.InvalidCertificateRequest, "invalid contact email: %s", addr)
+		}
+	}
+	return nil
+}
+
+func UpdateCertificate(cert *model.Certificate) error {
+	if err := checkCertificateContacts(cert); err != nil {
+		return err
+	}
+	if err := checkCertificateContent(cert); err != nil {
+		return err
+	}
+	return db.UpdateCertificate(cert)
+}
+
+// CreateCertificate 管理员手动创建证书
+func CreateCertificate(cert *model.Certificate, operator *model.User) error {
+	t, err := CheckCertificateType(cert.Type)
 	if err != nil {
-		// 如果错误不是 "记录未找到"，则是一个真正的数据库错误
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, err
+		return err
+	}
+	if err := checkCertificateContacts(cert); err != nil {
+		return err
+	}
+	if err := checkCertificateContent(cert); err != nil {
+		return err
+	}
+	if cert.IsValid() {
+		if err := checkCertificateTypeQuota(t); err != nil {
+			return err
 		}
-		// 如果是 "记录未找到"，是正常情况，说明租户没有证书
-		return nil, nil
 	}
-	return cert, nil
+	if err := db.CreateCertificate(cert); err != nil {
+		return err
+	}
+	recordCertificateEvent(cert.ID, "certificate.created", operator.Username, "")
+	return nil
+}
+
+// GetCertificateForTenant 是租户端调用的核心服务，返回租户的所有有效证书，没有证书时为空列表
+func GetCertificateForTenant(ownerID uint) ([]model.Certificate, error) {
+	return db.GetCertificatesByOwnerID(ownerID)
 }
 
 func UpdateCertificateDetails(id uint, name string, expirationDate time.Time) (*model.Certificate, error) {
@@ -42,17 +117,141 @@ func UpdateCertificateDetails(id uint, name string, expirationDate time.Time) (*
 	return cert, err
 }
 
-func RevokeCertificate(id uint) error {
-	cert, err := db.GetCertificateByID(id)
+func RevokeCertificate(id uint, operator *model.User) error {
+	_, err := revokeCertificate(id, operator.Username, "", "")
+	return err
+}
+
+// revokeCertificate 吊销证书，reason 为 RFC 5280 的吊销原因，为空表示未指定。
+// 在事务中锁定证书并确认仍有效或挂起，避免同时进行的吊销重复写入吊销时间和事件
+func revokeCertificate(id uint, actor, reason, detail string) (*model.Certificate, error) {
+	var cert *model.Certificate
+	err := db.Transaction(func(tx db.Tx) error {
+		var err error
+		cert, err = tx.LockCertificate(id)
+		if err != nil {
+			return err
+		}
+		if (!cert.IsValid() && !cert.IsOnHold()) || cert.IsExpired() {
+			return errs.NewErr(errs.CertificateConflict, "only valid or held certificates can be revoked, current status: %s", cert.Status)
+		}
+		// 公开信任的证书由公共 CA 发布吊销状态，先在公共 CA 吊销成功再记录
+		if cert.Type == model.CertificateTypePublic {
+			if err := revokeACMECertificate(cert, reason); err != nil {
+				return err
+			}
+		}
+		now := time.Now()
+		cert.Status = model.CertificateStatusRevoked
+		cert.RevokedAt = &now
+		// 挂起中的证书被吊销后转为永久吊销
+		cert.RevocationReason = reason
+		if err := tx.UpdateCertificate(cert); err != nil {
+			return err
+		}
+		return tx.CreateCertificateEvent(&model.CertificateEvent{
+			CertificateID: cert.ID,
+			Event:         "certificate.revoked",
+			Actor:         actor,
+			Detail:        detail,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	announceCertificateEvent(cert.ID, "certificate.revoked", actor, detail)
+	emitCertificateEvent("certificate.revoked", cert,
+		fmt.Sprintf("Certificate %s has been revoked", cert.Name), detail)
+	PublishRevocationDataAsync()
+	return cert, nil
+}
+
+// HoldCertificate 挂起证书，挂起期间证书视为以 certificateHold 原因吊销，可通过 ReleaseCertificateHold 恢复
+func HoldCertificate(id uint, operator *model.User, reason string) error {
+	cert, err := updateCertificateHold(id, "certificate.held", operator.Username, reason, func(cert *model.Certificate) error {
+		if !cert.IsValid() {
+			return errs.NewErr(errs.CertificateConflict, "only valid certificates can be put on hold, current status: %s", cert.Status)
+		}
+		now := time.Now()
+		cert.Status = model.CertificateStatusHold
+		cert.RevocationReason = model.RevocationReasonCertificateHold
+		cert.HeldAt = &now
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	cert.Status = model.CertificateStatusRevoked
-	return db.UpdateCertificate(cert)
+	emitCertificateEvent("certificate.held", cert,
+		fmt.Sprintf("Certificate %s has been put on hold", cert.Name), reason)
+	PublishRevocationDataAsync()
+	return nil
+}
+
+// ReleaseCertificateHold 解除证书挂起，恢复为有效状态
+func ReleaseCertificateHold(id uint, operator *model.User) error {
+	cert, err := updateCertificateHold(id, "certificate.unheld", operator.Username, "", func(cert *model.Certificate) error {
+		if !cert.IsOnHold() {
+			return errs.NewErr(errs.CertificateConflict, "certificate is not on hold, current status: %s", cert.Status)
+		}
+		cert.Status = model.CertificateStatusValid
+		cert.RevocationReason = ""
+		cert.HeldAt = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	emitCertificateEvent("certificate.unheld", cert,
+		fmt.Sprintf("Certificate %s is valid again", cert.Name), "")
+	PublishRevocationDataAsync()
+	return nil
 }
 
-func DeleteCertificate(id uint) error {
-	return db.DeleteCertificate(id)
+// updateCertificateHold 在一个事务中锁定证书，由 fn 检查当前状态并修改，再写入状态和时间线事件，
+// 避免与同时进行的吊销等操作交错
+func updateCertificateHold(id uint, event, actor, detail string, fn func(cert *model.Certificate) error) (*model.Certificate, error) {
+	var cert *model.Certificate
+	err := db.Transaction(func(tx db.Tx) error {
+		var err error
+		cert, err = tx.LockCertificate(id)
+		if err != nil {
+			return err
+		}
+		if err := fn(cert); err != nil {
+			return err
+		}
+		if err := tx.UpdateCertificate(cert); err != nil {
+			return err
+		}
+		return tx.CreateCertificateEvent(&model.CertificateEvent{
+			CertificateID: cert.ID,
+			Event:         event,
+			Actor:         actor,
+			Detail:        detail,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	announceCertificateEvent(cert.ID, event, actor, detail)
+	return cert, nil
+}
+
+func DeleteCertificate(id uint, operator *model.User) error {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return err
+	}
+	if err := checkCertificateLegalHold(cert); err != nil {
+		return err
+	}
+	if err := db.DeleteCertificate(id); err != nil {
+		return err
+	}
+	recordCertificateEvent(cert.ID, "certificate.deleted", operator.Username, "")
+	emitCertificateEvent("certificate.deleted", cert,
+		fmt.Sprintf("Certificate %s has been deleted", cert.Name), "")
+	return nil
 }
 
 // --- CertificateRequest Service ---
@@ -60,45 +259,175 @@ func DeleteCertificate(id uint) error {
 var GetCertificateRequests = db.GetCertificateRequests
 var GetCertificateRequestByID = db.GetCertificateRequestByID
 var GetTenantCertificateRequests = db.GetCertificateRequestsByUserID
-var CreateCertificateRequest = db.CreateCertificateRequest
 
-// CreateTenantCertificateRequest 租户申请证书的业务逻辑
-func CreateTenantCertificateRequest(user *model.User, reqType model.CertificateType, reason string) (*model.CertificateRequest, error) {
-	// 1. 检查租户是否已经有了一个有效的证书
-	existingCert, err := db.GetCertificateByOwnerID(user.ID)
-	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, errors.Wrap(err, "failed to check existing certificate")
+// CreateCertificateRequest 管理员代为创建证书申请
+func CreateCertificateRequest(req *model.CertificateRequest) error {
+	t, err := CheckCertificateType(req.Type)
+	if err != nil {
+		return err
+	}
+	if err := checkValidityPreset(t, req.ValidityPreset); err != nil {
+		return err
+	}
+	customFields, err := validateCustomFields(req.Type, req.CustomFields)
+	if err != nil {
+		return err
+	}
+	req.CustomFields = customFields
+	if req.SANs, err = checkSANs(req.SANs); err != nil {
+		return err
+	}
+	if req.CSR, req.SANs, err = checkRequestCSR(req.CSR, req.SANs); err != nil {
+		return err
+	}
+	if _, err := checkRequestProfile(req.ProfileID, req.UserName, req.SANs, req.CSR); err != nil {
+		return err
+	}
+	if err := checkPublicCertificateRequest(req.Type, req.SANs, req.CSR); err != nil {
+		return err
+	}
+	if err := db.CreateCertificateRequest(req); err != nil {
+		return err
+	}
+	auditCertificateRequest("certificate.request.created", req.UserName, req, string(req.Type))
+	notifyCertificateApprovers(req)
+	openCertificateTicket(req)
+	detectRequestSpike(req)
+	return nil
+}
+
+// GetPendingCertificateRequests 获取所有待审批的申请
+func GetPendingCertificateRequests() ([]model.CertificateRequest, error) {
+	return db.GetPendingCertificateRequestsBefore(time.Now())
+}
+
+// CreateTenantCertificateRequest 租户申请证书的业务逻辑，csr 不为空时按 CSR 中的公钥签发，私钥由租户保管，
+// profileID 不为 0 时按引用的签发配置签发
+func CreateTenantCertificateRequest(user *model.User, reqType model.CertificateType, reason, validityPreset string, customFields map[string]string, sans []string, csr string, profileID uint) (*model.CertificateRequest, error) {
+	return createTenantCertificateRequest(user, &model.CertificateRequest{
+		Type:           reqType,
+		ProfileID:      profileID,
+		Reason:         reason,
+		CustomFields:   customFields,
+		ValidityPreset: validityPreset,
+		SANs:           sans,
+		CSR:            csr,
+	})
+}
+
+// createTenantCertificateRequest 校验并以租户身份提交申请，申请人和状态由此处填写
+func createTenantCertificateRequest(user *model.User, request *model.CertificateRequest) (*model.CertificateRequest, error) {
+	customFields, err := checkTenantCertificateRequest(user, request.Type, request.Reason, request.ValidityPreset, request.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	sans, err := checkSANs(request.SANs)
+	if err != nil {
+		return nil, err
+	}
+	csr, sans, err := checkRequestCSR(request.CSR, sans)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkRequestProfile(request.ProfileID, user.Username, sans, csr); err != nil {
+		return nil, err
+	}
+	if err := checkPublicCertificateRequest(request.Type, sans, csr); err != nil {
+		return nil, err
+	}
+
+	request.UserName = user.Username
+	request.UserID = user.ID
+	request.Status = model.CertificateStatusPending
+	request.CustomFields = customFields
+	request.SANs = sans
+	request.CSR = csr
+
+	if err := db.CreateCertificateRequest(request); err != nil {
+		return nil, err
 	}
-	if existingCert != nil && (existingCert.Status == model.CertificateStatusValid || existingCert.Status == model.CertificateStatusExpiring) {
-		return nil, fmt.Errorf("certificate already exists for user")
+	auditCertificateRequest("certificate.request.created", user.Username, request, string(request.Type))
+	notifyCertificateApprovers(request)
+	openCertificateTicket(request)
+	detectRequestSpike(request)
+	return request, nil
+}
+
+// maxSANs 单个申请最多的主题备用名称数量
+const maxSANs = 100
+
+// checkSANs 校验并去重主题备用名称
+func checkSANs(sans []string) ([]string, error) {
+	var res []string
+	for _, san := range sans {
+		san = strings.TrimSpace(san)
+		if san == "" || utils.SliceContains(res, san) {
+			continue
+		}
+		if len(san) > 253 || strings.ContainsAny(san, " \t\r\n,") {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid subject alternative name: %q", san)
+		}
+		res = append(res, san)
+	}
+	if len(res) > maxSANs {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "at most %d subject alternative names are allowed", maxSANs)
+	}
+	return res, nil
+}
+
+// checkTenantCertificateRequest 提交申请前的校验，返回校验后的自定义字段
+func checkTenantCertificateRequest(user *model.User, reqType model.CertificateType, reason, validityPreset string, customFields map[string]string) (map[string]string, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "reason is required")
+	}
+
+	// 1. 检查租户的有效证书是否已达到配额
+	if err := checkTenantCertificateQuota(user); err != nil {
+		return nil, err
 	}
 
 	// 2. 检查租户是否已经有一个正在处理的申请
-	_, err = db.GetPendingCertificateRequestByUserID(user.ID)
+	_, err := db.GetPendingCertificateRequestByUserID(user.ID)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, errors.Wrap(err, "failed to check pending request")
 	}
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, fmt.Errorf("certificate request is pending for user")
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate request is pending for user")
 	}
-
-	// 3. 创建新的申请
-	request := &model.CertificateRequest{
-		UserName: user.Username,
-		UserID:   user.ID,
-		Type:     reqType,
-		Status:   model.CertificateStatusPending,
-		Reason:   reason,
+	if err := checkTenantDailyRequests(user); err != nil {
+		return nil, err
 	}
 
-	if err := db.CreateCertificateRequest(request); err != nil {
+	// 3. 校验证书类型、有效期预设和配额
+	t, err := CheckCertificateType(reqType)
+	if err != nil {
 		return nil, err
 	}
-	return request, nil
+	if err := checkValidityPreset(t, validityPreset); err != nil {
+		return nil, err
+	}
+	if err := checkCertificateTypeQuota(t); err != nil {
+		return nil, err
+	}
+
+	// 4. 校验自定义字段
+	return validateCustomFields(reqType, customFields)
 }
 
-// ApproveAndCreateCertificate 将批准和创建证书合并为一个事务性操作
+// ApproveAndCreateCertificate 将批准和创建证书合并为一个操作，证书和申请状态在同一个事务中写入，
+// 要求填写批准理由或检查项时无法通过该方式批准
 func ApproveAndCreateCertificate(reqID uint, adminUser *model.User) (*model.Certificate, error) {
+	return approveAndCreateCertificate(reqID, adminUser.Username, "", nil, "")
+}
+
+// ApproveCertificateRequestWith 按提交的批准理由和检查项批准申请，指定了 NotBefore 时到达该时间才签发证书，
+// 计划签发时返回的证书为 nil
+func ApproveCertificateRequestWith(reqID uint, adminUser *model.User, in *model.CertificateApprovalInput) (*model.Certificate, error) {
+	return approveAndCreateCertificate(reqID, adminUser.Username, "", in, "")
+}
+
+// approveAndCreateCertificate nonce 不为空时在保存审批结果的事务中标记一键审批链接已使用
+func approveAndCreateCertificate(reqID uint, approvedBy, onBehalfOf string, in *model.CertificateApprovalInput, nonce string) (*model.Certificate, error) {
 	// 1. 获取申请信息
 	req, err := db.GetCertificateRequestByID(reqID)
 	if err != nil {
@@ -107,59 +436,272 @@ func ApproveAndCreateCertificate(reqID uint, adminUser *model.User) (*model.Cert
 
 	// 2. 检查申请状态
 	if !req.IsPending() {
-		return nil, fmt.Errorf("request is not pending, current status: %s", req.Status)
+		return nil, errs.NewErr(errs.CertificateConflict, "request is not pending, current status: %s", req.Status)
+	}
+
+	// 3. 按证书类型的审批链逐级审批
+	t, err := CheckCertificateType(req.Type)
+	if err != nil {
+		return nil, err
+	}
+	check, err := checkApprovalInput(in, approvedBy, onBehalfOf)
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		req.ApprovalChecks = append(req.ApprovalChecks, *check)
+	}
+	// 审批链中任意一级都可以指定计划签发时间，后指定的覆盖先前的
+	if in != nil && in.NotBefore != nil {
+		scheduledAt := *in.NotBefore
+		req.ScheduledAt = &scheduledAt
+	}
+	approvals := len(req.Approvals)
+	if len(req.Approvals) < len(t.ApprovalChain) {
+		approver := approvedBy
+		if onBehalfOf != "" {
+			approver = onBehalfOf
+		}
+		next := t.ApprovalChain[len(req.Approvals)]
+		if approver != next {
+			return nil, errs.NewErr(errs.PermissionDenied, "request is waiting for approval of %s", next)
+		}
+		req.Approvals = append(req.Approvals, approver)
+		if len(req.Approvals) < len(t.ApprovalChain) {
+			if err := savePendingCertificateRequest(req, approvals, nonce); err != nil {
+				return nil, err
+			}
+			label := approver
+			if onBehalfOf != "" {
+				label = fmt.Sprintf("%s on behalf of %s", approvedBy, onBehalfOf)
+			}
+			next = t.ApprovalChain[len(req.Approvals)]
+			auditCertificateRequest("certificate.request.step_approved", label, req, "")
+			sendAsync(&notify.Message{
+				Event:      "certificate.request.step_approved",
+				Title:      fmt.Sprintf("Certificate request #%d is waiting for your approval", req.ID),
+				Content:    fmt.Sprintf("Approved by %s, waiting for approval of %s.", label, next),
+				To:         withDelegates([]string{next}),
+				Links:      approvalLinks(req),
+				ApprovalID: req.ID,
+			})
+			return nil, nil
+		}
+	}
+	if err := checkCertificateTypeQuota(t); err != nil {
+		return nil, err
+	}
+	// 审批期间类型的预设可能已被调整
+	if err := checkValidityPreset(t, req.ValidityPreset); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	req.ApprovedBy = approvedBy
+	req.OnBehalfOf = onBehalfOf
+	req.ApprovedAt = &now
+
+	// 4. 计划签发：先记录批准，由定时任务在计划时间到达后签发
+	if req.ScheduledAt != nil && req.ScheduledAt.After(now) {
+		req.Status = model.CertificateStatusScheduled
+		if err := savePendingCertificateRequest(req, approvals, nonce); err != nil {
+			req.Status = model.CertificateStatusPending
+			return nil, err
+		}
+		detail := fmt.Sprintf("scheduled at %s", req.ScheduledAt.Format(time.RFC3339))
+		auditCertificateRequest("certificate.request.scheduled", approverLabel(req), req, detail)
+		emitCertificateRequestEvent("certificate.request.scheduled", req,
+			fmt.Sprintf("Certificate request #%d has been approved", req.ID),
+			fmt.Sprintf("Approved by %s, the certificate will be issued at %s.", approverLabel(req), req.ScheduledAt.Format(time.RFC3339)))
+		postCertificateTicketUpdate(req, fmt.Sprintf("approved by %s, the certificate will be issued at %s.", approverLabel(req), req.ScheduledAt.Format(time.RFC3339)))
+		return nil, nil
+	}
+
+	// 代理审批不能使用冻结例外
+	overridden, err := checkIssuanceFreeze(req.UserID, now, in != nil && in.FreezeOverride && onBehalfOf == "")
+	if err != nil {
+		return nil, err
 	}
+	if overridden {
+		auditCertificateRequest("certificate.request.freeze_override", approverLabel(req), req, "")
+	}
+	// 5. 公开信任的证书需要等待公共 CA 完成域名验证，记录批准后在后台签发，失败时由定时任务重试
+	if req.Type == model.CertificateTypePublic {
+		scheduledAt := req.ScheduledAt
+		req.Status = model.CertificateStatusScheduled
+		req.ScheduledAt = &now
+		if err := savePendingCertificateRequest(req, approvals, nonce); err != nil {
+			req.Status = model.CertificateStatusPending
+			req.ScheduledAt = scheduledAt
+			return nil, err
+		}
+		auditCertificateRequest("certificate.request.approved", approverLabel(req), req, "")
+		emitCertificateRequestEvent("certificate.request.approved", req,
+			fmt.Sprintf("Certificate request #%d has been approved", req.ID),
+			fmt.Sprintf("Approved by %s, the certificate is being issued by the public CA.", approverLabel(req)))
+		postCertificateTicketUpdate(req, fmt.Sprintf("approved by %s, the certificate is being issued by the public CA.", approverLabel(req)))
+		issueApprovedCertificateAsync(req, now)
+		return nil, nil
+	}
+	cert, err := issueCertificate(req, t, now, nonce)
+	if err != nil {
+		return nil, err
+	}
+	auditCertificateRequest("certificate.request.approved", approverLabel(req), req, "")
+	recordCertificateEvent(cert.ID, "certificate.issued", approverLabel(req), "")
+	emitCertificateRequestEvent("certificate.request.approved", req,
+		fmt.Sprintf("Certificate request #%d has been approved", req.ID),
+		fmt.Sprintf("Approved by %s, certificate %s has been issued.", approverLabel(req), cert.Name))
+	postCertificateTicketUpdate(req, fmt.Sprintf("approved by %s, certificate %s has been issued.", approverLabel(req), cert.Name))
+	detectOffHoursIssuance(cert, approverLabel(req), now)
+	return cert, nil
+}
 
-	// 3. 创建证书
+// savePendingCertificateRequest 在事务中锁定申请，确认读取后未被其它审批或拒绝修改后保存，
+// approvals 为读取时已完成的审批级数，nonce 不为空时同时标记一键审批链接已使用
+func savePendingCertificateRequest(req *model.CertificateRequest, approvals int, nonce string) error {
+	return db.Transaction(func(tx db.Tx) error {
+		current, err := tx.LockCertificateRequest(req.ID)
+		if err != nil {
+			return err
+		}
+		if !current.IsPending() {
+			return errs.NewErr(errs.CertificateConflict, "request is not pending, current status: %s", current.Status)
+		}
+		if len(current.Approvals) != approvals {
+			return errs.NewErr(errs.CertificateConflict, "request has been approved by another operation")
+		}
+		if err := useApprovalNonce(tx, nonce, req.ID); err != nil {
+			return err
+		}
+		return errors.Wrap(tx.UpdateCertificateRequest(req), "failed to update request")
+	})
+}
+
+// issueCertificate 按类型模板和申请引用的签发配置为已批准的申请创建证书，并将申请标记为已签发，
+// nonce 不为空时同时标记一键审批链接已使用
+func issueCertificate(req *model.CertificateRequest, t *model.CertificateTypeDef, now time.Time, nonce string) (*model.Certificate, error) {
 	cert := &model.Certificate{
-		Name:           fmt.Sprintf("%s-%s-cert", req.UserName, req.Type),
+		Name:           t.CertificateName(req.UserName),
 		Type:           req.Type,
 		Status:         model.CertificateStatusValid,
 		Owner:          req.UserName,
 		OwnerID:        req.UserID,
-		Content:        "", // 实际使用中这里应该是生成的证书内容
-		IssuedDate:     time.Now(),
-		ExpirationDate: time.Now().AddDate(1, 0, 0), // 默认一年有效期
+		RequestID:      req.ID,
+		IssuerID:       issuingCertificateAuthorityID(),
+		IssuedDate:     now,
+		ExpirationDate: t.Validity(now, req.ValidityPreset),
+		ProfileID:      req.ProfileID,
+		// 公开信任的证书有效期短，默认在到期前自动续期
+		AutoRenew: req.Type == model.CertificateTypePublic,
 	}
-
-	// 4. 更新申请状态
-	req.Status = model.CertificateStatusValid
-	req.ApprovedBy = adminUser.Username
-	now := time.Now()
-	req.ApprovedAt = &now
-
-	// 5. 保存证书和更新申请状态
-	if err := db.CreateCertificate(cert); err != nil {
-		return nil, errors.Wrap(err, "failed to create certificate")
+	// 审批期间签发配置可能已被调整，签发前按当前配置重新校验
+	profile, err := checkRequestProfile(req.ProfileID, req.UserName, req.SANs, req.CSR)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		if expiration, ok := profile.Validity(now); ok {
+			cert.ExpirationDate = expiration
+		}
+	}
+	commonName := req.UserName
+	if req.Type == model.CertificateTypeNode && len(req.SANs) > 0 {
+		commonName = req.SANs[0]
+	}
+	publicKey, err := requestPublicKey(req)
+	if err != nil {
+		return nil, err
 	}
-	
-	if err := db.UpdateCertificateRequest(req); err != nil {
-		return nil, errors.Wrap(err, "failed to update request")
+	if err := generateCertificate(cert, commonName, req.SANs, publicKey); err != nil {
+		return nil, err
 	}
 
+	// 5. 在一个事务中保存证书和更新申请状态，申请已被其它操作处理时放弃签发
+	from := req.Status
+	req.Status = model.CertificateStatusValid
+	err = db.Transaction(func(tx db.Tx) error {
+		current, err := tx.LockCertificateRequest(req.ID)
+		if err != nil {
+			return err
+		}
+		if current.Status != from {
+			return errs.NewErr(errs.CertificateConflict, "request has been changed to %s by another operation", current.Status)
+		}
+		if err := useApprovalNonce(tx, nonce, req.ID); err != nil {
+			return err
+		}
+		if err := tx.CreateCertificate(cert); err != nil {
+			return errors.Wrap(err, "failed to create certificate")
+		}
+		return errors.Wrap(tx.UpdateCertificateRequest(req), "failed to update request")
+	})
+	if err != nil {
+		req.Status = from
+		return nil, err
+	}
 	return cert, nil
 }
 
 // RejectCertificateRequest 拒绝证书申请
 func RejectCertificateRequest(reqID uint, adminUser *model.User, reason string) error {
-	// 1. 获取申请信息
-	req, err := db.GetCertificateRequestByID(reqID)
+	return rejectCertificateRequest(reqID, adminUser.Username, "", reason, "")
+}
+
+// rejectCertificateRequest nonce 不为空时在同一事务中标记一键审批链接已使用
+func rejectCertificateRequest(reqID uint, rejectedBy, onBehalfOf, reason, nonce string) error {
+	// 在一个事务中锁定、检查并更新申请，避免与同时进行的批准交错
+	var req *model.CertificateRequest
+	err := db.Transaction(func(tx db.Tx) error {
+		// 1. 获取申请信息
+		var err error
+		req, err = tx.LockCertificateRequest(reqID)
+		if err != nil {
+			return err
+		}
+
+		// 2. 检查申请状态，计划签发的申请在签发前仍可被拒绝以取消签发
+		if !req.IsPending() && !req.IsScheduled() {
+			return errs.NewErr(errs.CertificateConflict, "request is not pending, current status: %s", req.Status)
+		}
+		if err := useApprovalNonce(tx, nonce, req.ID); err != nil {
+			return err
+		}
+
+		// 3. 更新申请状态
+		req.Status = model.CertificateStatusRejected
+		req.RejectedBy = rejectedBy
+		req.OnBehalfOf = onBehalfOf
+		now := time.Now()
+		req.RejectedAt = &now
+		req.RejectedReason = reason
+
+		// 4. 保存更新
+		return tx.UpdateCertificateRequest(req)
+	})
 	if err != nil {
-		return errors.Wrapf(err, "failed to get request by id: %d", reqID)
+		return err
 	}
+	auditCertificateRequest("certificate.request.rejected", rejecterLabel(req), req, reason)
+	emitCertificateRequestEvent("certificate.request.rejected", req,
+		fmt.Sprintf("Certificate request #%d has been rejected", req.ID),
+		fmt.Sprintf("Rejected by %s.\nReason: %s", rejecterLabel(req), reason))
+	postCertificateTicketUpdate(req, fmt.Sprintf("rejected by %s.\nReason: %s", rejecterLabel(req), reason))
+	detectRepeatedRejections(req)
+	return nil
+}
 
-	// 2. 检查申请状态
-	if !req.IsPending() {
-		return fmt.Errorf("request is not pending, current status: %s", req.Status)
+// approverLabel 审批人描述，代理审批时为 "X on behalf of Y"
+func approverLabel(req *model.CertificateRequest) string {
+	if req.OnBehalfOf != "" {
+		return fmt.Sprintf("%s on behalf of %s", req.ApprovedBy, req.OnBehalfOf)
 	}
+	return req.ApprovedBy
+}
 
-	// 3. 更新申请状态
-	req.Status = model.CertificateStatusRejected
-	req.RejectedBy = adminUser.Username
-	now := time.Now()
-	req.RejectedAt = &now
-	req.RejectedReason = reason
-
-	// 4. 保存更新
-	return db.UpdateCertificateRequest(req)
-}
\ No newline at end of file
+func rejecterLabel(req *model.CertificateRequest) string {
+	if req.OnBehalfOf != "" {
+		return fmt.Sprintf("%s on behalf of %s", req.RejectedBy, req.OnBehalfOf)
+	}
+	return req.RejectedBy
+}
@@ -0,0 +1,318 @@
+package op
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+)
+
+// 支持的 ACME 验证方式
+const (
+	ACMEChallengeHTTP01 = "http-01"
+	ACMEChallengeDNS01  = "dns-01"
+)
+
+// acmeOrderTimeout 单个 ACME 订单从下单到取得证书的最长时间
+const acmeOrderTimeout = 3 * time.Minute
+
+// acmeHTTPTokens 进行中的 HTTP-01 验证，token 对应 key authorization
+var acmeHTTPTokens generic_sync.MapOf[string, string]
+
+// acmeAccount 已注册的 ACME 账号，目录或账号密钥设置变化后重新注册
+var acmeAccount struct {
+	sync.Mutex
+	client    *acme.Client
+	directory string
+	key       string
+}
+
+// ACMEHTTPChallengeResponse 返回 HTTP-01 验证 token 的 key authorization
+func ACMEHTTPChallengeResponse(token string) (string, bool) {
+	return acmeHTTPTokens.Load(token)
+}
+
+// checkPublicCertificateRequest 公开信任的证书只能包含域名，私钥由服务端生成
+func checkPublicCertificateRequest(reqType model.CertificateType, sans []string, csr string) error {
+	if reqType != model.CertificateTypePublic {
+		return nil
+	}
+	if csr != "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "publicly trusted certificates do not accept a csr")
+	}
+	_, err := acmeDomains(sans)
+	return err
+}
+
+// acmeDomains 校验主题备用名称都是域名，ACME 订单按这些域名逐一验证
+func acmeDomains(sans []string) ([]string, error) {
+	if len(sans) == 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "publicly trusted certificates require at least one domain name")
+	}
+	domains := make([]string, 0, len(sans))
+	for _, san := range sans {
+		if net.ParseIP(san) != nil || strings.ContainsAny(san, "@/:") || !strings.Contains(san, ".") {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "%q is not a domain name, publicly trusted certificates only accept domain names", san)
+		}
+		domains = append(domains, strings.ToLower(san))
+	}
+	return domains, nil
+}
+
+// getACMEClient 返回已注册账号的 ACME 客户端，账号密钥未设置时生成新的密钥并保存到设置中，
+// 配置主密钥后账号密钥与证书私钥一样加密存储
+func getACMEClient(ctx context.Context) (*acme.Client, error) {
+	acmeAccount.Lock()
+	defer acmeAccount.Unlock()
+	directory := getSettingStr(conf.CertACMEDirectoryURL, acme.LetsEncryptURL)
+	stored := getSettingStr(conf.CertACMEAccountKey, "")
+	if acmeAccount.client != nil && acmeAccount.directory == directory && acmeAccount.key == stored {
+		return acmeAccount.client, nil
+	}
+	keyPEM, err := db.OpenPrivateKey(conf.CertACMEAccountKey, stored)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed read acme account key")
+	}
+	var key crypto.Signer
+	if keyPEM == "" {
+		generated, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(generated)
+		if err != nil {
+			return nil, err
+		}
+		keyPEM = strings.TrimSpace(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+		key = generated
+	} else {
+		block, _ := pem.Decode([]byte(keyPEM))
+		if block == nil {
+			return nil, errors.New("acme account key is not PEM encoded")
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid acme account key")
+		}
+		signer, ok := parsed.(crypto.Signer)
+		if !ok {
+			return nil, errors.Errorf("unsupported acme account key type %T", parsed)
+		}
+		key = signer
+	}
+	// 新生成的密钥和配置主密钥之前保存的明文密钥加密后写回设置
+	sealed, err := db.SealPrivateKey(conf.CertACMEAccountKey, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if sealed != stored {
+		item, err := GetSettingItemByKey(conf.CertACMEAccountKey)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed get acme account key setting")
+		}
+		item.Value = sealed
+		if err := SaveSettingItem(item); err != nil {
+			return nil, errors.WithMessage(err, "failed save acme account key")
+		}
+		stored = sealed
+	}
+	client := &acme.Client{Key: key, DirectoryURL: directory}
+	account := &acme.Account{}
+	if email := getSettingStr(conf.CertACMEEmail, ""); email != "" {
+		account.Contact = []string{"mailto:" + email}
+	}
+	if _, err := client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return nil, errors.Wrap(err, "failed register acme account")
+	}
+	acmeAccount.client, acmeAccount.directory, acmeAccount.key = client, directory, stored
+	return client, nil
+}
+
+// issueACMECertificate 通过 ACME 为公开信任的证书下单，完成域名验证后保存 CA 返回的证书链和服务端生成的私钥，
+// 颁发和到期时间以 CA 签发的证书为准
+func issueACMECertificate(cert *model.Certificate, sans []string, publicKey crypto.PublicKey) error {
+	if publicKey != nil {
+		return errs.NewErr(errs.InvalidCertificateRequest, "publicly trusted certificates do not support tenant held keys")
+	}
+	domains, err := acmeDomains(sans)
+	if err != nil {
+		return err
+	}
+	keyAlgorithm, _, err := certificateIssuance(cert)
+	if err != nil {
+		return err
+	}
+	if keyAlgorithm == certissuer.KeyEd25519 {
+		return errs.NewErr(errs.InvalidCertificateRequest, "publicly trusted certificates do not support %s keys", keyAlgorithm)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), acmeOrderTimeout)
+	defer cancel()
+	client, err := getACMEClient(ctx)
+	if err != nil {
+		return err
+	}
+	order, err := client.AuthorizeOrder(ctx, acme.DomainIDs(domains...))
+	if err != nil {
+		return errors.Wrap(err, "failed create acme order")
+	}
+	for _, u := range order.AuthzURLs {
+		if err := authorizeACMEDomain(ctx, client, u); err != nil {
+			return err
+		}
+	}
+	if order, err = client.WaitOrder(ctx, order.URI); err != nil {
+		return errors.Wrap(err, "acme order is not ready")
+	}
+
+	key, err := certissuer.GenerateKey(keyAlgorithm)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: domains[0]},
+		DNSNames: domains,
+	}, key)
+	if err != nil {
+		return err
+	}
+	chain, _, err := client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return errors.Wrap(err, "failed finalize acme order")
+	}
+	leaf, err := x509.ParseCertificate(chain[0])
+	if err != nil {
+		return errors.Wrap(err, "invalid certificate from acme ca")
+	}
+	var buf strings.Builder
+	for _, der := range chain {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+			return err
+		}
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	cert.Content = buf.String()
+	cert.PrivateKey = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	// 公共 CA 签发的证书不属于内部 CA，由公共 CA 负责吊销状态
+	cert.IssuerID = 0
+	cert.IssuedDate = leaf.NotBefore
+	cert.ExpirationDate = leaf.NotAfter
+	fillCertificateIdentity(cert)
+	return nil
+}
+
+// revokeACMECertificate 请求公共 CA 吊销其签发的证书，reason 为 RFC 5280 的吊销原因
+func revokeACMECertificate(cert *model.Certificate, reason string) error {
+	block, _ := pem.Decode([]byte(cert.Content))
+	if block == nil {
+		return errors.New("certificate content is not PEM encoded")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), acmeOrderTimeout)
+	defer cancel()
+	client, err := getACMEClient(ctx)
+	if err != nil {
+		return err
+	}
+	// 未知的原因按未指定处理
+	code := acme.CRLReasonCode(revocationReasonCodes[reason])
+	if err := client.RevokeCert(ctx, nil, block.Bytes, code); err != nil {
+		return errors.Wrap(err, "failed revoke certificate at acme ca")
+	}
+	return nil
+}
+
+// authorizeACMEDomain 按设置的验证方式完成一个域名的验证，验证结束后清理 token 或 TXT 记录
+func authorizeACMEDomain(ctx context.Context, client *acme.Client, url string) error {
+	z, err := client.GetAuthorization(ctx, url)
+	if err != nil {
+		return errors.Wrap(err, "failed get acme authorization")
+	}
+	if z.Status == acme.StatusValid {
+		return nil
+	}
+	challengeType := getSettingStr(conf.CertACMEChallenge, ACMEChallengeHTTP01)
+	var chal *acme.Challenge
+	for _, c := range z.Challenges {
+		if c.Type == challengeType {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return errors.Errorf("acme ca does not offer %s challenge for %s", challengeType, z.Identifier.Value)
+	}
+	switch challengeType {
+	case ACMEChallengeHTTP01:
+		response, err := client.HTTP01ChallengeResponse(chal.Token)
+		if err != nil {
+			return err
+		}
+		acmeHTTPTokens.Store(chal.Token, response)
+		defer acmeHTTPTokens.Delete(chal.Token)
+	case ACMEChallengeDNS01:
+		value, err := client.DNS01ChallengeRecord(chal.Token)
+		if err != nil {
+			return err
+		}
+		fqdn := "_acme-challenge." + z.Identifier.Value
+		if err := callACMEDNSWebhook(ctx, "present", fqdn, value); err != nil {
+			return err
+		}
+		defer func() {
+			if err := callACMEDNSWebhook(context.Background(), "cleanup", fqdn, value); err != nil {
+				log.Warnf("failed clean up acme dns record %s: %+v", fqdn, err)
+			}
+		}()
+	default:
+		return errors.Errorf("unsupported acme challenge: %s", challengeType)
+	}
+	if _, err := client.Accept(ctx, chal); err != nil {
+		return errors.Wrapf(err, "failed accept acme challenge for %s", z.Identifier.Value)
+	}
+	if _, err := client.WaitAuthorization(ctx, z.URI); err != nil {
+		return errors.Wrapf(err, "acme validation of %s failed", z.Identifier.Value)
+	}
+	return nil
+}
+
+// callACMEDNSWebhook 请求 DNS webhook 创建或删除 DNS-01 验证的 TXT 记录
+func callACMEDNSWebhook(ctx context.Context, action, fqdn, value string) error {
+	url := getSettingStr(conf.CertACMEDNSWebhook, "")
+	if url == "" {
+		return errors.New("dns-01 challenge requires cert_acme_dns_webhook")
+	}
+	res, err := base.RestyClient.R().SetContext(ctx).SetBody(map[string]string{
+		"action": action,
+		"fqdn":   fqdn,
+		"value":  value,
+	}).Post(url)
+	if err != nil {
+		return errors.Wrapf(err, "failed call acme dns webhook")
+	}
+	if res.IsError() {
+		return errors.Errorf("acme dns webhook responded with status %s", res.Status())
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package op
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// 代理长轮询的最长等待时间和单次返回的变更数量上限
+const (
+	certificateAgentMaxWait = 60 * time.Second
+	certificateAgentBatch   = 100
+)
+
+// certificateAgentEvents 需要代理处理的证书事件
+var certificateAgentEvents = []string{
+	"certificate.revoked",
+	"certificate.held",
+	"certificate.unheld",
+	"certificate.superseded",
+}
+
+// certificateAgentWake 有新的代理事件时关闭当前通道唤醒所有等待中的长轮询。
+// 多实例部署时其它实例上的轮询不会被唤醒，等待超时后由代理重新轮询
+var certificateAgentWake = struct {
+	sync.Mutex
+	ch chan struct{}
+}{ch: make(chan struct{})}
+
+func wakeCertificateAgents(event string) {
+	if !slices.Contains(certificateAgentEvents, event) {
+		return
+	}
+	certificateAgentWake.Lock()
+	close(certificateAgentWake.ch)
+	certificateAgentWake.ch = make(chan struct{})
+	certificateAgentWake.Unlock()
+}
+
+func certificateAgentWait() <-chan struct{} {
+	certificateAgentWake.Lock()
+	defer certificateAgentWake.Unlock()
+	return certificateAgentWake.ch
+}
+
+func hashCertificateAgentToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var GetCertificateAgents = db.GetCertificateAgents
+
+// CreateCertificateAgent 注册代理并返回只显示一次的令牌，代理从注册时起接收变更
+func CreateCertificateAgent(name string, operator *model.User) (*model.CertificateAgent, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", errs.NewErr(errs.InvalidCertificateRequest, "agent name is required")
+	}
+	cursor, err := db.GetLastCertificateEventID()
+	if err != nil {
+		return nil, "", err
+	}
+	token := random.String(40)
+	agent := &model.CertificateAgent{
+		Name:           name,
+		TokenHash:      hashCertificateAgentToken(token),
+		CertificateIDs: []uint{},
+		Cursor:         cursor,
+	}
+	if err := db.CreateCertificateAgent(agent); err != nil {
+		return nil, "", err
+	}
+	auditCertificateAgent("certificate.agent.created", operator.Username, agent, agent.Name)
+	return agent, token, nil
+}
+
+func DeleteCertificateAgent(id uint, operator *model.User) error {
+	agent, err := db.GetCertificateAgentByID(id)
+	if err != nil {
+		return err
+	}
+	if err := db.DeleteCertificateAgent(id); err != nil {
+		return err
+	}
+	auditCertificateAgent("certificate.agent.deleted", operator.Username, agent, "")
+	return nil
+}
+
+// AuthenticateCertificateAgent 根据令牌获取代理并记录最后在线时间
+func AuthenticateCertificateAgent(token, ip string) (*model.CertificateAgent, error) {
+	if token == "" {
+		return nil, errs.PermissionDenied
+	}
+	agent, err := db.GetCertificateAgentByTokenHash(hashCertificateAgentToken(token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithMessage(errs.PermissionDenied, "invalid agent token")
+		}
+		return nil, err
+	}
+	agent.LastIP, agent.LastSeenAt = ip, time.Now()
+	if err := db.TouchCertificateAgent(agent.ID, agent.LastIP, agent.LastSeenAt); err != nil {
+		log.Warnf("failed update last seen of certificate agent %d: %+v", agent.ID, err)
+	}
+	return agent, nil
+}
+
+// ReportCertificateAgent 代理上报主机信息和已部署证书的指纹或序列号，返回无法识别的证书
+func ReportCertificateAgent(agent *model.CertificateAgent, hostname, version string, certificates []string) ([]string, error) {
+	ids := []uint{}
+	unknown := []string{}
+	for _, s := range certificates {
+		// 上报的可能是序列号也可能是指纹，先按序列号查找，规范化后为空的值无法识别
+		normalized := normalizeHex(s)
+		serial := strings.TrimLeft(normalized, "0")
+		if serial == "" {
+			unknown = append(unknown, s)
+			continue
+		}
+		cert, err := db.GetCertificateBySerialOrFingerprint(serial, "")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			cert, err = db.GetCertificateBySerialOrFingerprint("", normalized)
+		}
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			unknown = append(unknown, s)
+			continue
+		}
+		if !slices.Contains(ids, cert.ID) {
+			ids = append(ids, cert.ID)
+		}
+	}
+	agent.Hostname, agent.Version, agent.CertificateIDs = hostname, version, ids
+	if err := db.UpdateCertificateAgent(agent); err != nil {
+		return nil, err
+	}
+	return unknown, nil
+}
+
+// PollCertificateAgent 获取代理确认位置之后影响其部署证书的变更，没有变更时最多等待 wait
+func PollCertificateAgent(ctx context.Context, agent *model.CertificateAgent, wait time.Duration) (*model.CertificateAgentPollResult, error) {
+	if wait > certificateAgentMaxWait {
+		wait = certificateAgentMaxWait
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		// 先取通道再查询，避免查询和等待之间产生的事件被错过
+		wake := certificateAgentWait()
+		events, err := db.GetCertificateEventsAfter(agent.CertificateIDs, agent.Cursor, certificateAgentEvents, certificateAgentBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) > 0 {
+			return certificateAgentUpdates(agent, events)
+		}
+		select {
+		case <-wake:
+		case <-timer.C:
+			return &model.CertificateAgentPollResult{Cursor: agent.Cursor, Updates: []model.CertificateAgentUpdate{}}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func certificateAgentUpdates(agent *model.CertificateAgent, events []model.CertificateEvent) (*model.CertificateAgentPollResult, error) {
+	res := &model.CertificateAgentPollResult{Cursor: agent.Cursor, Updates: make([]model.CertificateAgentUpdate, 0, len(events))}
+	for _, e := range events {
+		res.Cursor = e.ID
+		cert, err := db.GetCertificateByID(e.CertificateID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		update := model.CertificateAgentUpdate{
+			EventID: e.ID,
+			Event:   e.Event,
+			Detail:  e.Detail,
+			Time:    e.CreatedAt,
+			Certificate: model.CertificateStatusInfo{
+				ID:             cert.ID,
+				Name:           cert.Name,
+				Status:         cert.Status,
+				Fingerprint:    cert.Fingerprint,
+				SerialNumber:   cert.SerialNumber,
+				ExpirationDate: cert.ExpirationDate,
+				SupersededByID: cert.SupersededByID,
+			},
+		}
+		if e.Event == "certificate.superseded" && cert.IsSuperseded() {
+			if replacement, err := db.GetCertificateByID(cert.SupersededByID); err == nil {
+				update.Replacement = replacement
+			}
+		}
+		res.Updates = append(res.Updates, update)
+	}
+	return res, nil
+}
+
+// AckCertificateAgent 代理确认已处理到 cursor 的变更并上报应用结果，成功应用续期证书后改为跟踪新证书
+func AckCertificateAgent(agent *model.CertificateAgent, cursor uint, results []model.CertificateAgentResult) error {
+	if cursor < agent.Cursor {
+		return errs.NewErr(errs.InvalidCertificateRequest, "cursor %d is behind the acknowledged cursor %d", cursor, agent.Cursor)
+	}
+	for _, r := range results {
+		if !slices.Contains(agent.CertificateIDs, r.CertificateID) {
+			continue
+		}
+		event := "certificate.agent_applied"
+		if !r.Success {
+			event = "certificate.agent_failed"
+		}
+		recordCertificateEvent(r.CertificateID, event, "agent:"+agent.Name, fmt.Sprintf("event %d: %s", r.EventID, r.Message))
+		if !r.Success {
+			continue
+		}
+		cert, err := db.GetCertificateByID(r.CertificateID)
+		if err != nil || !cert.IsSuperseded() {
+			continue
+		}
+		agent.CertificateIDs = slices.DeleteFunc(agent.CertificateIDs, func(id uint) bool { return id == cert.ID })
+		if !slices.Contains(agent.CertificateIDs, cert.SupersededByID) {
+			agent.CertificateIDs = append(agent.CertificateIDs, cert.SupersededByID)
+		}
+	}
+	agent.Cursor = cursor
+	return db.UpdateCertificateAgent(agent)
+}
+
+func auditCertificateAgent(event, actor string, agent *model.CertificateAgent, detail string) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("certificate_agent:%d", agent.ID),
+		Detail: detail,
+	})
+}
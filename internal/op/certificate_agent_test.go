@@ -0,0 +1,54 @@
+package op_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestReportCertificateAgent(t *testing.T) {
+	newCert := func(name, serial, fingerprint string) *model.Certificate {
+		cert := &model.Certificate{Name: name, Type: model.CertificateTypeNode, Status: model.CertificateStatusValid,
+			SerialNumber: serial, Fingerprint: fingerprint, ExpirationDate: time.Now().AddDate(1, 0, 0)}
+		if err := db.CreateCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	// 内容无法解析的证书序列号和指纹为空，不能被空值匹配
+	newCert("agent-unparsed", "", "")
+	bySerial := newCert("agent-serial", "1f2e3d", "aa11")
+	byFingerprint := newCert("agent-fingerprint", "4c5b6a", "bb22cc33")
+	agent, _, err := op.CreateCertificateAgent("report-test", &model.User{Username: "admin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		reported    []string
+		wantIDs     []uint
+		wantUnknown []string
+	}{
+		{name: "serial with colons and leading zero", reported: []string{"00:1F:2E:3D"}, wantIDs: []uint{bySerial.ID}},
+		{name: "fingerprint", reported: []string{"BB:22:CC:33"}, wantIDs: []uint{byFingerprint.ID}},
+		{name: "duplicates", reported: []string{"1f2e3d", "aa11"}, wantIDs: []uint{bySerial.ID}},
+		{name: "empty after normalizing", reported: []string{"", "0", ":", "00:00"}, wantIDs: []uint{}, wantUnknown: []string{"", "0", ":", "00:00"}},
+		{name: "unknown", reported: []string{"deadbeef"}, wantIDs: []uint{}, wantUnknown: []string{"deadbeef"}},
+	}
+	for _, tt := range tests {
+		unknown, err := op.ReportCertificateAgent(agent, "host", "1.0", tt.reported)
+		if err != nil {
+			t.Fatalf("%s: %+v", tt.name, err)
+		}
+		if !slices.Equal(agent.CertificateIDs, tt.wantIDs) {
+			t.Errorf("%s: got certificates %v, want %v", tt.name, agent.CertificateIDs, tt.wantIDs)
+		}
+		if !slices.Equal(unknown, tt.wantUnknown) {
+			t.Errorf("%s: got unknown %v, want %v", tt.name, unknown, tt.wantUnknown)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package op
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	log "github.com/sirupsen/logrus"
+)
+
+// anomalyAlertInterval 同一异常在该时间内只告警一次
+const anomalyAlertInterval = 24 * time.Hour
+
+var (
+	anomalyAlertMu sync.Mutex
+	anomalyAlerts  = map[string]time.Time{}
+)
+
+// detectRequestSpike 检查租户 24 小时内的申请数量是否突增
+func detectRequestSpike(req *model.CertificateRequest) {
+	limit := getSettingInt(conf.CertAnomalyRequestSpike, 5)
+	if limit <= 0 {
+		return
+	}
+	count, err := db.CountCertificateRequestsByUserSince(req.UserID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		log.Warnf("failed count certificate requests of %s: %+v", req.UserName, err)
+		return
+	}
+	if count >= int64(limit) {
+		raiseCertificateAnomaly("request_spike:"+req.UserName, req.UserName,
+			fmt.Sprintf("Unusual number of certificate requests from %s", req.UserName),
+			fmt.Sprintf("%s submitted %d certificate requests in the last 24 hours, the latest is #%d.", req.UserName, count, req.ID))
+	}
+}
+
+// detectRepeatedRejections 检查租户 7 天内被拒绝的申请数量
+func detectRepeatedRejections(req *model.CertificateRequest) {
+	limit := getSettingInt(conf.CertAnomalyRejections, 3)
+	if limit <= 0 {
+		return
+	}
+	count, err := db.CountRejectedCertificateRequestsByUserSince(req.UserID, time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		log.Warnf("failed count rejected certificate requests of %s: %+v", req.UserName, err)
+		return
+	}
+	if count >= int64(limit) {
+		raiseCertificateAnomaly("repeated_rejections:"+req.UserName, req.UserName,
+			fmt.Sprintf("Repeated rejected certificate requests from %s", req.UserName),
+			fmt.Sprintf("%d certificate requests of %s were rejected in the last 7 days, the latest is #%d.", count, req.UserName, req.ID))
+	}
+}
+
+// detectOffHoursIssuance 检查证书是否在工作时间以外签发，周末视为工作时间以外
+func detectOffHoursIssuance(cert *model.Certificate, approver string, at time.Time) {
+	start, end, ok := parseBusinessHours(getSettingStr(conf.CertAnomalyBusinessHours, ""))
+	if !ok {
+		return
+	}
+	weekend := at.Weekday() == time.Saturday || at.Weekday() == time.Sunday
+	if !weekend && at.Hour() >= start && at.Hour() < end {
+		return
+	}
+	raiseCertificateAnomaly(fmt.Sprintf("off_hours:%d", cert.ID), approver,
+		fmt.Sprintf("Certificate %s was issued outside business hours", cert.Name),
+		fmt.Sprintf("Certificate %s of %s was issued at %s, approved by %s.", cert.Name, cert.Owner, at.Format(time.RFC1123), approver))
+}
+
+// parseBusinessHours 解析形如 9-18 的工作时间
+func parseBusinessHours(s string) (int, int, bool) {
+	from, to, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err1 := strconv.Atoi(strings.TrimSpace(from))
+	end, err2 := strconv.Atoi(strings.TrimSpace(to))
+	if err1 != nil || err2 != nil || start < 0 || end > 24 || start >= end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// raiseCertificateAnomaly 记录异常并通知安全审查人，未配置审查人时通知管理员
+func raiseCertificateAnomaly(key, actor, title, content string) {
+	now := time.Now()
+	anomalyAlertMu.Lock()
+	if last, ok := anomalyAlerts[key]; ok && now.Sub(last) < anomalyAlertInterval {
+		anomalyAlertMu.Unlock()
+		return
+	}
+	anomalyAlerts[key] = now
+	anomalyAlertMu.Unlock()
+
+	audit.Emit(&audit.Event{
+		Type:   "certificate.anomaly",
+		Actor:  actor,
+		Target: key,
+		Detail: content,
+	})
+	sendAsync(&notify.Message{
+		Event:   "certificate.anomaly",
+		Title:   title,
+		Content: content,
+		To:      anomalyReviewers(),
+	})
+}
+
+// anomalyReviewers 接收安全告警的用户，未配置时为全部管理员
+func anomalyReviewers() []string {
+	reviewers := splitLines(getSettingStr(conf.CertAnomalyReviewers, ""))
+	if len(reviewers) > 0 {
+		return reviewers
+	}
+	admins, err := getCertificateApprovers()
+	if err != nil {
+		log.Warnf("failed get certificate approvers: %+v", err)
+	}
+	return admins
+}
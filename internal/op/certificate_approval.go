@@ -0,0 +1,144 @@
+package op
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+func approvalLinkSign() sign.Sign {
+	return sign.NewHMACSign([]byte(getSettingStr(conf.Token, "") + "-cert-approval"))
+}
+
+func approvalLinkData(reqID uint, username, action, nonce string) string {
+	return fmt.Sprintf("%d:%s:%s:%s", reqID, username, action, nonce)
+}
+
+// approvalLinks 为通知邮件生成审批人专属的一键审批链接，链接签名、限时且只能使用一次。
+// 只有可以直接审批的管理员和持有当前审批人委托的代理人才会收到链接，未配置 site_url 时不生成
+func approvalLinks(req *model.CertificateRequest) func(username string) []notify.Link {
+	base := strings.TrimSuffix(conf.Conf.SiteURL, "/")
+	expire := time.Duration(getSettingInt(conf.CertApprovalLinkHours, 72)) * time.Hour
+	if base == "" || expire <= 0 {
+		return nil
+	}
+	return func(username string) []notify.Link {
+		user, err := GetUserByName(username)
+		if err != nil || user.Disabled {
+			return nil
+		}
+		onBehalfOf, err := approvalLinkPrincipal(user, req)
+		if err != nil {
+			return nil
+		}
+		// 要求填写批准理由或检查项时无法通过链接批准，只发送拒绝链接
+		actions := []string{model.CertificateApprovalActionApprove, model.CertificateApprovalActionReject}
+		if _, err := checkApprovalInput(nil, username, onBehalfOf); err != nil {
+			actions = actions[1:]
+		}
+		s := approvalLinkSign()
+		expireAt := time.Now().Add(expire).Unix()
+		links := make([]notify.Link, 0, len(actions))
+		for _, action := range actions {
+			nonce := random.String(32)
+			q := url.Values{}
+			q.Set("id", strconv.FormatUint(uint64(req.ID), 10))
+			q.Set("user", username)
+			q.Set("action", action)
+			q.Set("nonce", nonce)
+			q.Set("sign", s.Sign(approvalLinkData(req.ID, username, action, nonce), expireAt))
+			label := "Approve"
+			if action == model.CertificateApprovalActionReject {
+				label = "Reject"
+			}
+			links = append(links, notify.Link{
+				Label: label,
+				URL:   base + "/api/public/certificate/approval?" + q.Encode(),
+			})
+		}
+		return links
+	}
+}
+
+// VerifyCertificateApprovalLink 校验一键审批链接，返回对应的申请和审批人
+func VerifyCertificateApprovalLink(link *model.CertificateApprovalLink) (*model.CertificateRequest, *model.User, error) {
+	if link.Action != model.CertificateApprovalActionApprove && link.Action != model.CertificateApprovalActionReject {
+		return nil, nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid action: %s", link.Action)
+	}
+	// 签名中带有过期时间，过期的链接同样会校验失败
+	if err := approvalLinkSign().Verify(approvalLinkData(link.RequestID, link.User, link.Action, link.Nonce), link.Sign); err != nil {
+		return nil, nil, errors.WithMessage(errs.PermissionDenied, err.Error())
+	}
+	used, err := db.IsCertificateApprovalNonceUsed(link.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	if used {
+		return nil, nil, errors.WithMessage(errs.PermissionDenied, "approval link has already been used")
+	}
+	user, err := GetUserByName(link.User)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Disabled {
+		return nil, nil, errs.PermissionDenied
+	}
+	req, err := db.GetCertificateRequestByID(link.RequestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !req.IsPending() {
+		return nil, nil, errs.NewErr(errs.CertificateConflict, "request is not pending, current status: %s", req.Status)
+	}
+	if _, err := approvalLinkPrincipal(user, req); err != nil {
+		return nil, nil, err
+	}
+	return req, user, nil
+}
+
+// UseCertificateApprovalLink 确认后执行一键审批链接对应的操作，链接在审批或拒绝成功的同一事务中失效，
+// 操作失败时链接仍可再次使用。代理人通过链接处理时同样记录为代委托人审批
+func UseCertificateApprovalLink(link *model.CertificateApprovalLink, reason string) (*model.CertificateRequest, error) {
+	req, user, err := VerifyCertificateApprovalLink(link)
+	if err != nil {
+		return nil, err
+	}
+	onBehalfOf, err := approvalLinkPrincipal(user, req)
+	if err != nil {
+		return nil, err
+	}
+	if link.Action == model.CertificateApprovalActionReject {
+		if reason == "" {
+			reason = "rejected from the email approval link"
+		}
+		return req, rejectCertificateRequest(req.ID, user.Username, onBehalfOf, reason, link.Nonce)
+	}
+	_, err = approveAndCreateCertificate(req.ID, user.Username, onBehalfOf, nil, link.Nonce)
+	return req, err
+}
+
+// useApprovalNonce 标记一键审批链接已使用，nonce 为空时不处理
+func useApprovalNonce(tx db.Tx, nonce string, reqID uint) error {
+	if nonce == "" {
+		return nil
+	}
+	ok, err := tx.UseCertificateApprovalNonce(nonce, reqID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithMessage(errs.PermissionDenied, "approval link has already been used")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestApprovalLinkPrincipal(t *testing.T) {
+	users := map[string]*model.User{}
+	for _, u := range []model.User{
+		{Username: "link-first", Role: model.ADMIN},
+		{Username: "link-second", Role: model.ADMIN},
+		{Username: "link-other", Role: model.ADMIN},
+		{Username: "link-delegate", Role: model.GENERAL},
+	} {
+		u := u
+		if err := db.CreateUser(&u); err != nil {
+			t.Fatal(err)
+		}
+		users[u.Username] = &u
+	}
+	if err := db.CreateCertificateType(&model.CertificateTypeDef{Name: "link-chain", ApprovalChain: []string{"link-first", "link-second"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.CreateApprovalDelegation(users["link-second"], "link-delegate", time.Now().Add(-time.Hour), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		user      string
+		approvals []string
+		want      string
+		denied    bool
+	}{
+		{name: "pending approver", user: "link-first", want: ""},
+		{name: "admin not in step", user: "link-other", want: ""},
+		{name: "delegate before step", user: "link-delegate", denied: true},
+		{name: "delegate at step", user: "link-delegate", approvals: []string{"link-first"}, want: "link-second"},
+	}
+	for _, tt := range tests {
+		req := &model.CertificateRequest{Type: "link-chain", Approvals: tt.approvals}
+		got, err := op.ApprovalLinkPrincipal(users[tt.user], req)
+		if tt.denied {
+			if !errors.Is(err, errs.PermissionDenied) {
+				t.Errorf("%s: got %q, %v, want permission denied", tt.name, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/pkg/errors"
+)
+
+// isCertificateApprover 管理员和当前持有有效委托的代理人可以处理申请
+func isCertificateApprover(user *model.User) (bool, error) {
+	if user.Disabled {
+		return false, nil
+	}
+	if user.IsAdmin() {
+		return true, nil
+	}
+	delegations, err := GetActiveDelegationsForDelegate(user)
+	if err != nil {
+		return false, err
+	}
+	return len(delegations) > 0, nil
+}
+
+// assignPendingCertificateRequest 在事务中锁定申请并确认仍待审批，fn 修改认领人后保存，fn 返回 false 时不保存
+func assignPendingCertificateRequest(id uint, fn func(req *model.CertificateRequest) (bool, error)) (*model.CertificateRequest, bool, error) {
+	var req *model.CertificateRequest
+	var changed bool
+	err := db.Transaction(func(tx db.Tx) error {
+		var err error
+		req, err = tx.LockCertificateRequest(id)
+		if err != nil {
+			return err
+		}
+		if !req.IsPending() {
+			return errs.NewErr(errs.CertificateConflict, "request is not pending, current status: %s", req.Status)
+		}
+		if changed, err = fn(req); err != nil || !changed {
+			return err
+		}
+		return errors.Wrap(tx.UpdateCertificateRequest(req), "failed to update request")
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return req, changed, nil
+}
+
+// ClaimCertificateRequest 审批人认领待审批的申请，避免多人重复审核，已被他人认领的申请需要管理员重新指派
+func ClaimCertificateRequest(id uint, user *model.User) (*model.CertificateRequest, error) {
+	ok, err := isCertificateApprover(user)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.PermissionDenied
+	}
+	req, changed, err := assignPendingCertificateRequest(id, func(req *model.CertificateRequest) (bool, error) {
+		if req.Assignee == user.Username {
+			return false, nil
+		}
+		if req.Assignee != "" {
+			return false, errs.NewErr(errs.CertificateConflict, "request has already been claimed by %s", req.Assignee)
+		}
+		now := time.Now()
+		req.Assignee = user.Username
+		req.AssignedAt = &now
+		return true, nil
+	})
+	if err != nil || !changed {
+		return req, err
+	}
+	auditCertificateRequest("certificate.request.claimed", user.Username, req, "")
+	return req, nil
+}
+
+// AssignCertificateRequest 管理员将申请指派给其他审批人，assignee 为空时取消认领
+func AssignCertificateRequest(id uint, operator *model.User, assignee string) (*model.CertificateRequest, error) {
+	if !operator.IsAdmin() {
+		return nil, errs.PermissionDenied
+	}
+	if assignee != "" {
+		user, err := GetUserByName(assignee)
+		if err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "user %s not found", assignee)
+		}
+		ok, err := isCertificateApprover(user)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "%s can't approve certificate requests", assignee)
+		}
+	}
+	var previous string
+	req, changed, err := assignPendingCertificateRequest(id, func(req *model.CertificateRequest) (bool, error) {
+		previous = req.Assignee
+		if previous == assignee {
+			return false, nil
+		}
+		req.Assignee = assignee
+		req.AssignedAt = nil
+		if assignee != "" {
+			now := time.Now()
+			req.AssignedAt = &now
+		}
+		return true, nil
+	})
+	if err != nil || !changed {
+		return req, err
+	}
+	auditCertificateRequest("certificate.request.assigned", operator.Username, req,
+		fmt.Sprintf("reassigned from %q to %q", previous, assignee))
+	if assignee != "" && assignee != operator.Username {
+		sendAsync(&notify.Message{
+			Event:      "certificate.request.assigned",
+			Title:      fmt.Sprintf("Certificate request #%d has been assigned to you", req.ID),
+			Content:    fmt.Sprintf("%s assigned the %s certificate request of %s to you.", operator.Username, req.Type, req.UserName),
+			To:         []string{assignee},
+			Links:      approvalLinks(req),
+			ApprovalID: req.ID,
+		})
+	}
+	return req, nil
+}
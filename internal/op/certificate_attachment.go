@@ -0,0 +1,37 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// PutCertificateRequestAttachment 将申请的附件写入配置的附件存储路径下的 <申请ID>/ 目录，
+// 附件内容由存储驱动直接从上传流中读取，不会在内存中完整缓存
+func PutCertificateRequestAttachment(ctx context.Context, user *model.User, id uint, file model.FileStreamer) error {
+	dir := getSettingStr(conf.CertAttachmentPath, "")
+	if dir == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "attachment storage is not configured")
+	}
+	req, err := GetCertificateRequestForUser(user, id)
+	if err != nil {
+		return err
+	}
+	dir = stdpath.Join(dir, strconv.FormatUint(uint64(req.ID), 10))
+	storage, actualPath, err := GetStorageAndActualPath(dir)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get storage of %s", dir)
+	}
+	if storage.Config().NoUpload {
+		return errors.Errorf("storage of %s doesn't support upload", dir)
+	}
+	if err := MakeDir(ctx, storage, actualPath); err != nil {
+		return errors.WithMessagef(err, "failed make dir %s", dir)
+	}
+	return Put(ctx, storage, actualPath, file, nil)
+}
@@ -0,0 +1,28 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// RecordCertAudit 记录证书操作的审计日志，由接口层在操作成功后调用，记录失败不影响业务操作。
+// 审计事件已由生命周期事件发送到审计日志输出端，这里只写入数据库供管理端查询
+func RecordCertAudit(actor, action, targetType string, targetID uint, ip, detail string) {
+	err := db.CreateCertificateAuditLog(&model.CertificateAuditLog{
+		Actor:      actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IP:         ip,
+		Detail:     detail,
+	})
+	if err != nil {
+		log.Warnf("failed to record certificate audit log [%s] of %s %d: %+v", action, targetType, targetID, err)
+	}
+}
+
+// GetCertAuditLogs 分页获取证书审计日志，可按操作人和操作过滤
+func GetCertAuditLogs(pageIndex, pageSize int, actor, action string) ([]model.CertificateAuditLog, int64, error) {
+	return db.GetCertificateAuditLogs(pageIndex, pageSize, actor, action)
+}
@@ -0,0 +1,190 @@
+package op
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var GetCertificateAuthorities = db.GetCertificateAuthorities
+
+// parseCertificateAuthority 解析 PEM 格式的 CA 证书，自签名的证书视为根证书
+func parseCertificateAuthority(content string) (*model.CertificateAuthority, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(content)))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "content is not a PEM encoded certificate")
+	}
+	cert, err := parseX509(block.Bytes)
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid certificate: %v", err)
+	}
+	if !cert.IsCA {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s is not a CA certificate", cert.Subject)
+	}
+	sum := sha256.Sum256(cert.Raw)
+	ca := &model.CertificateAuthority{
+		Kind:        model.CertificateAuthorityIntermediate,
+		Status:      model.CertificateAuthorityActive,
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		Fingerprint: hex.EncodeToString(sum[:]),
+		Content:     string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+	}
+	if cert.CheckSignatureFrom(cert) == nil {
+		ca.Kind = model.CertificateAuthorityRoot
+	}
+	return ca, nil
+}
+
+// AddCertificateAuthority 导入 CA 证书并发布到信任包
+func AddCertificateAuthority(name, content string, operator *model.User) (*model.CertificateAuthority, error) {
+	ca, err := parseCertificateAuthority(content)
+	if err != nil {
+		return nil, err
+	}
+	ca.Name = strings.TrimSpace(name)
+	if ca.Name == "" {
+		ca.Name = ca.Subject
+	}
+	if err := db.CreateCertificateAuthority(ca); err != nil {
+		return nil, errors.WithMessage(err, "failed create certificate authority")
+	}
+	if err := bumpCABundleVersion(); err != nil {
+		return nil, err
+	}
+	auditCertificateAuthority("certificate.ca.added", operator, ca)
+	return ca, nil
+}
+
+// RetireCertificateAuthority 停用 CA 证书，停用后不再出现在信任包中
+func RetireCertificateAuthority(id uint, operator *model.User) error {
+	ca, err := db.GetCertificateAuthorityByID(id)
+	if err != nil {
+		return err
+	}
+	if ca.Status == model.CertificateAuthorityRetired {
+		return nil
+	}
+	ca.Status = model.CertificateAuthorityRetired
+	if err := db.UpdateCertificateAuthority(ca); err != nil {
+		return err
+	}
+	if err := bumpCABundleVersion(); err != nil {
+		return err
+	}
+	auditCertificateAuthority("certificate.ca.retired", operator, ca)
+	return nil
+}
+
+// issuingCertificateAuthorityID 新签发证书关联的 CA：轮换进行中时为新 CA，
+// 否则为最近导入的在用中间证书，没有中间证书时为根证书
+func issuingCertificateAuthorityID() uint {
+	rotations, err := db.GetRunningCARotations()
+	if err != nil {
+		log.Warnf("failed get running ca rotations: %+v", err)
+		return 0
+	}
+	if len(rotations) > 0 {
+		return rotations[len(rotations)-1].ToCAID
+	}
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		log.Warnf("failed get active certificate authorities: %+v", err)
+		return 0
+	}
+	now := time.Now()
+	if id := latestCertificateAuthorityID(cas, model.CertificateAuthorityIntermediate, now); id != 0 {
+		return id
+	}
+	return latestCertificateAuthorityID(cas, model.CertificateAuthorityRoot, now)
+}
+
+func latestCertificateAuthorityID(cas []model.CertificateAuthority, kind string, now time.Time) uint {
+	var id uint
+	for _, ca := range cas {
+		if ca.Kind == kind && ca.IsActive(now) && !ca.IsCrossSigned() && ca.ID > id {
+			id = ca.ID
+		}
+	}
+	return id
+}
+
+func auditCertificateAuthority(event string, operator *model.User, ca *model.CertificateAuthority) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  operator.Username,
+		Target: fmt.Sprintf("certificate_authority:%d", ca.ID),
+		Detail: "fingerprint " + ca.Fingerprint,
+	})
+}
+
+// bumpCABundleVersion 在用 CA 集合变化时递增信任包版本号
+func bumpCABundleVersion() error {
+	item, err := GetSettingItemByKey(conf.CertCABundleVersion)
+	if err != nil {
+		return errors.WithMessage(err, "failed get ca bundle version")
+	}
+	v, _ := strconv.ParseInt(item.Value, 10, 64)
+	updated := *item
+	updated.Value = strconv.FormatInt(v+1, 10)
+	return SaveSettingItem(&updated)
+}
+
+// GetCABundle 获取在用且未过期的根证书和中间证书。
+// ETag 由证书内容计算，CA 过期导致集合变化时 ETag 也会随之变化
+func GetCABundle() (*model.CABundle, error) {
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	bundle := &model.CABundle{
+		Version:      int64(getSettingInt(conf.CertCABundleVersion, 0)),
+		Certificates: []model.CertificateAuthority{},
+	}
+	h := sha256.New()
+	for _, ca := range cas {
+		if !ca.IsActive(now) {
+			continue
+		}
+		bundle.Certificates = append(bundle.Certificates, ca)
+		h.Write([]byte(ca.Content))
+	}
+	bundle.ETag = fmt.Sprintf(`"%d-%s"`, bundle.Version, hex.EncodeToString(h.Sum(nil))[:32])
+	return bundle, nil
+}
+
+// CABundlePEM 将信任包中的证书拼接为 PEM 文本
+func CABundlePEM(bundle *model.CABundle) string {
+	var b strings.Builder
+	for _, ca := range bundle.Certificates {
+		b.WriteString(ca.Content)
+	}
+	return b.String()
+}
+
+// GetPublishedCertificateAuthority 按指纹获取在用且未过期的 CA 证书，用于公开分发
+func GetPublishedCertificateAuthority(fingerprint string) (*model.CertificateAuthority, error) {
+	ca, err := db.GetCertificateAuthorityByFingerprint(strings.ToLower(fingerprint))
+	if err != nil {
+		return nil, err
+	}
+	if !ca.IsActive(time.Now()) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate authority %s is not published", fingerprint)
+	}
+	return ca, nil
+}
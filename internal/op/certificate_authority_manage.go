@@ -0,0 +1,165 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/pkg/errors"
+)
+
+// 未指定有效期时 CA 的默认有效期
+const (
+	defaultRootCAValidityDays         = 3650
+	defaultIntermediateCAValidityDays = 1825
+)
+
+// CreateCA 生成密钥对并创建根证书或中间证书，私钥由 OpenList 保管，创建后发布到信任包
+func CreateCA(in *model.CACreateInput, operator *model.User) (*model.CertificateAuthority, error) {
+	var parent *certissuer.Issuer
+	days := in.ValidityDays
+	if in.ParentID != 0 {
+		var err error
+		if parent, _, err = certificateIssuer(in.ParentID); err != nil {
+			return nil, err
+		}
+		if days <= 0 {
+			days = defaultIntermediateCAValidityDays
+		}
+	} else if days <= 0 {
+		days = defaultRootCAValidityDays
+	}
+	now := time.Now().Truncate(time.Second)
+	notAfter := now.AddDate(0, 0, days)
+	if parent != nil && notAfter.After(parent.Certificate.NotAfter) {
+		notAfter = parent.Certificate.NotAfter
+	}
+	res, err := certissuer.NewCA(&certissuer.CARequest{
+		CommonName:   strings.TrimSpace(in.CommonName),
+		Organization: strings.TrimSpace(in.Organization),
+		KeyAlgorithm: getSettingStr(conf.CertKeyAlgorithm, certissuer.KeyECDSAP256),
+		NotBefore:    now,
+		NotAfter:     notAfter,
+	}, parent)
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "failed create ca: %v", err)
+	}
+	return saveCertificateAuthority(in.Name, res, 0, "certificate.ca.created", operator)
+}
+
+// saveCertificateAuthority 保存新生成的 CA 证书和私钥并发布到信任包，crossSignOf 不为 0 时为该 CA 的交叉证书
+func saveCertificateAuthority(name string, res *certissuer.Result, crossSignOf uint, event string, operator *model.User) (*model.CertificateAuthority, error) {
+	ca, err := parseCertificateAuthority(res.CertificatePEM)
+	if err != nil {
+		return nil, err
+	}
+	ca.Name = strings.TrimSpace(name)
+	if ca.Name == "" {
+		ca.Name = ca.Subject
+	}
+	ca.PrivateKey = res.PrivateKeyPEM
+	ca.CrossSignOf = crossSignOf
+	if err := db.CreateCertificateAuthority(ca); err != nil {
+		return nil, errors.WithMessage(err, "failed create certificate authority")
+	}
+	if err := bumpCABundleVersion(); err != nil {
+		return nil, err
+	}
+	auditCertificateAuthority(event, operator, ca)
+	return ca, nil
+}
+
+// GetActiveCA 获取当前签发新证书的 CA
+func GetActiveCA() (*model.CertificateAuthority, error) {
+	id := issuingCertificateAuthorityID()
+	if id == 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "no active certificate authority")
+	}
+	return db.GetCertificateAuthorityByID(id)
+}
+
+// RotateCA 为当前签发 CA 生成替代的新 CA 并开始轮换。轮换根证书时由旧根为新根交叉签名，
+// 只信任旧根的客户端在轮换期间仍能校验新证书；轮换中间证书时新中间证书由同一个上级签发。
+// 已签发的证书记录了签发 CA，旧 CA 在其证书全部重新签发前保持发布
+func RotateCA(commonName string, validityDays int, operator *model.User) (*model.CARotation, error) {
+	active, err := GetActiveCA()
+	if err != nil {
+		return nil, err
+	}
+	running, err := db.GetRunningCARotations()
+	if err != nil {
+		return nil, err
+	}
+	if len(running) > 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "rotation %d is still running", running[len(running)-1].ID)
+	}
+	certs, err := parseCertificates(active.Content)
+	if err != nil {
+		return nil, err
+	}
+	old := certs[0]
+	if commonName = strings.TrimSpace(commonName); commonName == "" {
+		commonName = fmt.Sprintf("%s %s", old.Subject.CommonName, time.Now().Format("20060102"))
+	}
+	in := &model.CACreateInput{
+		Name:         commonName,
+		CommonName:   commonName,
+		ValidityDays: validityDays,
+	}
+	if len(old.Subject.Organization) > 0 {
+		in.Organization = old.Subject.Organization[0]
+	}
+	if in.ValidityDays <= 0 {
+		in.ValidityDays = int(old.NotAfter.Sub(old.NotBefore).Hours() / 24)
+	}
+	if active.Kind == model.CertificateAuthorityIntermediate {
+		parents, err := certificateAuthorityParents(active)
+		if err != nil {
+			return nil, err
+		}
+		if len(parents) == 0 {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "the issuer of certificate authority %s is not available", active.Name)
+		}
+		in.ParentID = parents[0].ID
+	}
+	ca, err := CreateCA(in, operator)
+	if err != nil {
+		return nil, err
+	}
+	var crossSignedID uint
+	if active.Kind == model.CertificateAuthorityRoot && active.HasPrivateKey() {
+		issuer, _, err := certificateIssuer(active.ID)
+		if err != nil {
+			return nil, err
+		}
+		cross, err := crossSignCA(ca, issuer, ca.Name+" (cross-signed)", operator)
+		if err != nil {
+			return nil, err
+		}
+		crossSignedID = cross.ID
+	}
+	return StartCARotation(active.ID, ca.ID, crossSignedID, 0, operator)
+}
+
+// ExportCA 导出 CA 证书及其上级证书(PEM 格式)，不包含私钥
+func ExportCA(id uint) (string, error) {
+	ca, err := db.GetCertificateAuthorityByID(id)
+	if err != nil {
+		return "", err
+	}
+	parents, err := certificateAuthorityParents(ca)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(ca.Content)
+	for _, parent := range parents {
+		b.WriteString(parent.Content)
+	}
+	return b.String(), nil
+}
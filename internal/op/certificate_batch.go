@@ -0,0 +1,48 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// maxCertificateBatchSize 一次批量审批的申请数量上限
+const maxCertificateBatchSize = 100
+
+// BatchApproveCertificateRequests 逐个批准申请，每个申请与单独批准一样在自己的事务中处理，
+// 一个申请失败不影响其它申请，返回每个申请的结果
+func BatchApproveCertificateRequests(ids []uint, adminUser *model.User, in *model.CertificateApprovalInput) ([]model.CertificateBatchResult, error) {
+	return runCertificateBatch(ids, func(id uint) error {
+		_, err := ApproveCertificateRequestWith(id, adminUser, in)
+		return err
+	})
+}
+
+// BatchRejectCertificateRequests 以同一个理由逐个拒绝申请，返回每个申请的结果
+func BatchRejectCertificateRequests(ids []uint, adminUser *model.User, reason string) ([]model.CertificateBatchResult, error) {
+	return runCertificateBatch(ids, func(id uint) error {
+		return RejectCertificateRequest(id, adminUser, reason)
+	})
+}
+
+func runCertificateBatch(ids []uint, fn func(id uint) error) ([]model.CertificateBatchResult, error) {
+	if len(ids) == 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "ids is required")
+	}
+	if len(ids) > maxCertificateBatchSize {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "at most %d requests can be processed at once", maxCertificateBatchSize)
+	}
+	results := make([]model.CertificateBatchResult, 0, len(ids))
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result := model.CertificateBatchResult{ID: id, Success: true}
+		if err := fn(id); err != nil {
+			result.Success, result.Error = false, err.Error()
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,239 @@
+package op
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// chainCacheSize 缓存的中间证书数量上限
+const chainCacheSize = 256
+
+// chainCache 缓存上传时随叶子证书一起提供并验证通过的中间证书，后续上传可以只提供叶子证书
+var chainCache = struct {
+	sync.RWMutex
+	certs map[string]*x509.Certificate
+}{certs: map[string]*x509.Certificate{}}
+
+// chainCandidate 构建证书链时可用的证书及其来源
+type chainCandidate struct {
+	cert     *x509.Certificate
+	source   string
+	caID     uint
+	anchorID uint
+}
+
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// parseCertificates 解析 PEM 中的全部证书，忽略其它类型的块
+func parseCertificates(content string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(strings.TrimSpace(content))
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := parseX509(block.Bytes)
+		if err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid certificate #%d: %v", len(certs)+1, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "content is not a PEM encoded certificate")
+	}
+	return certs, nil
+}
+
+// BuildCertificateChain 为上传的叶子证书构建证书链。content 中第一张证书为叶子证书，
+// 其余证书作为额外的中间证书参与构建；信任锚为 OpenList 管理的根 CA，organization 不为空时还包括该组织信任库中的 CA
+func BuildCertificateChain(content, organization string) (*model.CertificateChain, error) {
+	certs, err := parseCertificates(content)
+	if err != nil {
+		return nil, err
+	}
+	leaf := certs[0]
+	now := time.Now()
+	cas, err := GetCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	candidates := map[string]chainCandidate{}
+	for i := range cas {
+		ca := &cas[i]
+		if !ca.IsActive(now) {
+			continue
+		}
+		parsed, err := parseCertificates(ca.Content)
+		if err != nil {
+			continue
+		}
+		if ca.Kind == model.CertificateAuthorityRoot {
+			roots.AddCert(parsed[0])
+		} else {
+			intermediates.AddCert(parsed[0])
+		}
+		candidates[ca.Fingerprint] = chainCandidate{cert: parsed[0], source: model.ChainSourceCA, caID: ca.ID}
+	}
+	if organization != "" {
+		anchors, err := db.GetCertificateTrustAnchors(&organization)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range anchors {
+			parsed, err := parseCertificates(a.Content)
+			if err != nil || now.After(parsed[0].NotAfter) {
+				continue
+			}
+			if _, ok := candidates[a.Fingerprint]; !ok {
+				roots.AddCert(parsed[0])
+				candidates[a.Fingerprint] = chainCandidate{cert: parsed[0], source: model.ChainSourceTrust, anchorID: a.ID}
+			}
+		}
+	}
+	chainCache.RLock()
+	for fp, cert := range chainCache.certs {
+		if _, ok := candidates[fp]; !ok {
+			intermediates.AddCert(cert)
+			candidates[fp] = chainCandidate{cert: cert, source: model.ChainSourceCache}
+		}
+	}
+	chainCache.RUnlock()
+	for _, cert := range certs[1:] {
+		fp := certificateFingerprint(cert)
+		if _, ok := candidates[fp]; !ok {
+			intermediates.AddCert(cert)
+			candidates[fp] = chainCandidate{cert: cert, source: model.ChainSourceUpload}
+		}
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		return &model.CertificateChain{
+			Chain:       describeChain(partialChain(leaf, candidates), candidates),
+			Diagnostics: diagnoseChain(leaf, err, candidates, now),
+		}, nil
+	}
+	best := chains[0]
+	for _, chain := range chains[1:] {
+		if len(chain) < len(best) {
+			best = chain
+		}
+	}
+	cacheIntermediates(best, candidates)
+	result := &model.CertificateChain{Valid: true, Chain: describeChain(best, candidates)}
+	// 链的最后一张总是根证书，部署时由客户端的信任库提供
+	var buf bytes.Buffer
+	for _, cert := range best[:len(best)-1] {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	result.PEM = buf.String()
+	return result, nil
+}
+
+// partialChain 按颁发者名称尽可能向上查找，用于在验证失败时展示能找到的部分链
+func partialChain(leaf *x509.Certificate, candidates map[string]chainCandidate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	seen := map[string]bool{certificateFingerprint(leaf): true}
+	for cur := leaf; !bytes.Equal(cur.RawIssuer, cur.RawSubject); {
+		var next *x509.Certificate
+		for fp, c := range candidates {
+			if !seen[fp] && bytes.Equal(c.cert.RawSubject, cur.RawIssuer) {
+				next = c.cert
+				seen[fp] = true
+				break
+			}
+		}
+		if next == nil {
+			break
+		}
+		chain = append(chain, next)
+		cur = next
+	}
+	return chain
+}
+
+// diagnoseChain 将验证错误转换为便于排查的说明
+func diagnoseChain(leaf *x509.Certificate, err error, candidates map[string]chainCandidate, now time.Time) []string {
+	diagnostics := []string{err.Error()}
+	if now.Before(leaf.NotBefore) {
+		diagnostics = append(diagnostics, fmt.Sprintf("the certificate is not valid until %s", leaf.NotBefore.Format(time.RFC3339)))
+	}
+	if now.After(leaf.NotAfter) {
+		diagnostics = append(diagnostics, fmt.Sprintf("the certificate expired at %s", leaf.NotAfter.Format(time.RFC3339)))
+	}
+	chain := partialChain(leaf, candidates)
+	top := chain[len(chain)-1]
+	for _, cert := range chain[1:] {
+		if now.After(cert.NotAfter) {
+			diagnostics = append(diagnostics, fmt.Sprintf("intermediate %s expired at %s", cert.Subject, cert.NotAfter.Format(time.RFC3339)))
+		}
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		if chain[i].CheckSignatureFrom(chain[i+1]) != nil {
+			diagnostics = append(diagnostics, fmt.Sprintf("%s is not signed by %s although the names match", chain[i].Subject, chain[i+1].Subject))
+		}
+	}
+	if !bytes.Equal(top.RawIssuer, top.RawSubject) {
+		diagnostics = append(diagnostics, fmt.Sprintf("no known ca or cached intermediate has the subject %s, upload the missing intermediate together with the certificate", top.Issuer))
+	} else if c, ok := candidates[certificateFingerprint(top)]; !ok || (c.source != model.ChainSourceCA && c.source != model.ChainSourceTrust) {
+		diagnostics = append(diagnostics, fmt.Sprintf("root %s is neither a ca managed by openlist nor in the trust store of the organization", top.Subject))
+	}
+	return diagnostics
+}
+
+func describeChain(chain []*x509.Certificate, candidates map[string]chainCandidate) []model.ChainCertificate {
+	result := make([]model.ChainCertificate, 0, len(chain))
+	for i, cert := range chain {
+		fp := certificateFingerprint(cert)
+		item := model.ChainCertificate{
+			Subject:     cert.Subject.String(),
+			Issuer:      cert.Issuer.String(),
+			Fingerprint: fp,
+			NotBefore:   cert.NotBefore,
+			NotAfter:    cert.NotAfter,
+			Source:      model.ChainSourceUpload,
+		}
+		if c, ok := candidates[fp]; ok && i > 0 {
+			item.Source, item.CAID, item.AnchorID = c.source, c.caID, c.anchorID
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// cacheIntermediates 缓存有效链中上传的中间证书，缓存满时不再加入
+func cacheIntermediates(chain []*x509.Certificate, candidates map[string]chainCandidate) {
+	chainCache.Lock()
+	defer chainCache.Unlock()
+	for _, cert := range chain[1:] {
+		fp := certificateFingerprint(cert)
+		if c, ok := candidates[fp]; ok && c.source == model.ChainSourceUpload && len(chainCache.certs) < chainCacheSize {
+			chainCache.certs[fp] = cert
+		}
+	}
+}
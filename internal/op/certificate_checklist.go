@@ -0,0 +1,57 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// GetCertificateApprovalChecklist 批准申请时需要逐项勾选的检查项
+func GetCertificateApprovalChecklist() []string {
+	return splitLines(getSettingStr(conf.CertApprovalChecklist, ""))
+}
+
+// checkApprovalInput 校验批准理由和检查项，返回需要记录到申请上的内容；
+// 未要求且未填写时返回 nil
+func checkApprovalInput(in *model.CertificateApprovalInput, approvedBy, onBehalfOf string) (*model.CertificateApprovalCheck, error) {
+	checklist := GetCertificateApprovalChecklist()
+	requireJustification := getSettingBool(conf.CertApprovalJustification)
+	if in == nil {
+		if requireJustification || len(checklist) > 0 {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "approval requires a justification and the checklist, please approve in the web console")
+		}
+		return nil, nil
+	}
+	justification := strings.TrimSpace(in.Justification)
+	if requireJustification && justification == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "justification is required")
+	}
+	for _, item := range in.Checklist {
+		if !utils.SliceContains(checklist, item) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "unknown checklist item: %s", item)
+		}
+	}
+	for _, item := range checklist {
+		if !utils.SliceContains(in.Checklist, item) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "checklist item is not ticked: %s", item)
+		}
+	}
+	if justification == "" && len(checklist) == 0 {
+		return nil, nil
+	}
+	approver := approvedBy
+	if onBehalfOf != "" {
+		approver = fmt.Sprintf("%s on behalf of %s", approvedBy, onBehalfOf)
+	}
+	return &model.CertificateApprovalCheck{
+		Approver:      approver,
+		Justification: justification,
+		Checklist:     checklist,
+		At:            time.Now(),
+	}, nil
+}
@@ -0,0 +1,134 @@
+package op
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+var mentionReg = regexp.MustCompile(`(?:^|[^\w@])@([\w.\-]+)`)
+
+// parseMentions 解析评论中 @ 的用户名
+func parseMentions(content string) []string {
+	var names []string
+	for _, m := range mentionReg.FindAllStringSubmatch(content, -1) {
+		name := strings.TrimRight(m[1], ".-")
+		if name != "" && !utils.SliceContains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// CanReadCertificateRequest 检查用户是否可以查看申请：
+// 管理员、审计员、申请人、具备关注权限的用户、委托人可以审批该申请的代理人，以及策略允许时被 @ 的用户
+func CanReadCertificateRequest(user *model.User, req *model.CertificateRequest) (bool, error) {
+	// 草稿只有申请人可以查看
+	if req.IsDraft() {
+		return user.ID == req.UserID, nil
+	}
+	if user.CanViewAllCertificates() || user.ID == req.UserID || user.CanWatchCertificates() {
+		return true, nil
+	}
+	delegations, err := GetActiveDelegationsForDelegate(user)
+	if err != nil {
+		return false, err
+	}
+	// 代理人只能查看委托人可以审批的申请
+	for _, d := range delegations {
+		ok, err := canApproveCertificateRequest(d.Username, req)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	if getSettingBool(conf.CertMentionGrantsAccess) {
+		return db.IsMentionedInCertificateRequest(req.ID, user.ID)
+	}
+	return false, nil
+}
+
+// GetCertificateRequestForUser 获取用户有权查看的申请
+func GetCertificateRequestForUser(user *model.User, id uint) (*model.CertificateRequest, error) {
+	req, err := db.GetCertificateRequestByID(id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := CanReadCertificateRequest(user, req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.PermissionDenied
+	}
+	return req, nil
+}
+
+// GetCertificateRequestComments 获取申请的评论
+func GetCertificateRequestComments(user *model.User, id uint) ([]model.CertificateRequestComment, error) {
+	if _, err := GetCertificateRequestForUser(user, id); err != nil {
+		return nil, err
+	}
+	return db.GetCertificateRequestComments(id)
+}
+
+// AddCertificateRequestComment 发表评论，被 @ 的用户会收到通知，策略允许时同时获得该申请的查看权限
+func AddCertificateRequestComment(user *model.User, id uint, content string) (*model.CertificateRequestComment, error) {
+	req, err := GetCertificateRequestForUser(user, id)
+	if err != nil {
+		return nil, err
+	}
+	var mentioned []*model.User
+	for _, name := range parseMentions(content) {
+		u, err := GetUserByName(name)
+		if err != nil || u.ID == user.ID || u.IsGuest() || u.Disabled {
+			continue
+		}
+		mentioned = append(mentioned, u)
+	}
+	comment := &model.CertificateRequestComment{
+		RequestID: req.ID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Content:   content,
+		Mentions:  utils.MustSliceConvert(mentioned, func(u *model.User) string { return u.Username }),
+	}
+	if err := db.CreateCertificateRequestComment(comment); err != nil {
+		return nil, err
+	}
+	if getSettingBool(conf.CertMentionGrantsAccess) {
+		mentions := utils.MustSliceConvert(mentioned, func(u *model.User) model.CertificateRequestMention {
+			return model.CertificateRequestMention{RequestID: req.ID, UserID: u.ID, Username: u.Username, CommentID: comment.ID}
+		})
+		if err := db.CreateCertificateRequestMentions(mentions); err != nil {
+			log.Warnf("failed to record mentions of comment %d: %+v", comment.ID, err)
+		}
+	}
+
+	sendAsync(&notify.Message{
+		Event:   "certificate.request.mentioned",
+		Title:   fmt.Sprintf("%s mentioned you on certificate request #%d", user.Username, req.ID),
+		Content: content,
+		To:      comment.Mentions,
+	})
+	var others []string
+	for _, name := range mergeRecipients([]string{req.UserName}, getWatchers(model.CertificateWatchTargetRequest, req.ID)) {
+		if name != user.Username && !utils.SliceContains(comment.Mentions, name) {
+			others = append(others, name)
+		}
+	}
+	sendAsync(&notify.Message{
+		Event:   "certificate.request.commented",
+		Title:   fmt.Sprintf("%s commented on certificate request #%d", user.Username, req.ID),
+		Content: content,
+		To:      others,
+	})
+	return comment, nil
+}
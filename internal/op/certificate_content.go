@@ -0,0 +1,72 @@
+package op
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// checkCertificateContent 校验管理员提交的证书内容：必须是可解析的 PEM 证书（可附带证书链），
+// 过期日期需与证书的 NotAfter 一致，未填写的颁发和过期日期从证书中补全。校验通过后写入指纹和序列号，
+// 所有问题汇总在 errs.CertificateContentError 中一次返回。SSH 证书不是 X.509 格式，不做解析
+func checkCertificateContent(cert *model.Certificate) error {
+	if cert.Type == model.CertificateTypeSSH {
+		fillCertificateIdentity(cert)
+		return nil
+	}
+	problems := &errs.CertificateContentError{}
+	rest := []byte(strings.TrimSpace(cert.Content))
+	if len(rest) == 0 {
+		problems.Add("content", "required", "certificate content is empty")
+		return problems
+	}
+	var blocks []*pem.Block
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			problems.Add("content", "malformed_pem", "content is not valid pem or contains trailing data")
+			return problems
+		}
+		blocks = append(blocks, block)
+	}
+	for i, block := range blocks {
+		if block.Type != "CERTIFICATE" {
+			problems.Add("content", "unexpected_block", fmt.Sprintf("pem block %d is %q, only CERTIFICATE blocks are allowed", i+1, block.Type))
+		}
+	}
+	if len(problems.Problems) > 0 {
+		return problems
+	}
+	leaf, err := parseX509(blocks[0].Bytes)
+	if err != nil {
+		problems.Add("content", "invalid_x509", fmt.Sprintf("failed to parse certificate: %v", err))
+		return problems
+	}
+	for i, block := range blocks[1:] {
+		if _, err := parseX509(block.Bytes); err != nil {
+			problems.Add("content", "invalid_x509", fmt.Sprintf("failed to parse chain certificate %d: %v", i+1, err))
+		}
+	}
+	if leaf.IsCA {
+		problems.Add("content", "ca_certificate", "content is a ca certificate, manage it as a certificate authority instead")
+	}
+	// 日期只保留到天，按提交值所在时区比较
+	if cert.ExpirationDate.IsZero() {
+		cert.ExpirationDate = leaf.NotAfter
+	} else if want := model.CertificateDate(leaf.NotAfter.In(cert.ExpirationDate.Location())); !model.CertificateDate(cert.ExpirationDate).Equal(want) {
+		problems.Add("expiration_date", "expiration_mismatch", fmt.Sprintf("expiration date %s does not match the certificate's not after %s",
+			cert.ExpirationDate.Format("2006-01-02"), want.Format("2006-01-02")))
+	}
+	if cert.IssuedDate.IsZero() {
+		cert.IssuedDate = leaf.NotBefore
+	}
+	if len(problems.Problems) > 0 {
+		return problems
+	}
+	fillCertificateIdentity(cert)
+	return nil
+}
@@ -0,0 +1,156 @@
+package op
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// CRL 的输出格式
+const (
+	CRLFormatDER = "der"
+	CRLFormatPEM = "pem"
+)
+
+// cachedCRL 接口提供的 CRL，refreshAt 之后或吊销状态变化后重新生成
+type cachedCRL struct {
+	der       []byte
+	refreshAt time.Time
+}
+
+// crlCache 按 CA 缓存的 CRL，generation 在失效时递增，生成期间发生的吊销不会被旧的 CRL 覆盖
+var crlCache = struct {
+	sync.Mutex
+	crls       map[uint]*cachedCRL
+	generation uint64
+}{crls: map[uint]*cachedCRL{}}
+
+func invalidateCRLCache() {
+	crlCache.Lock()
+	crlCache.crls = map[uint]*cachedCRL{}
+	crlCache.generation++
+	crlCache.Unlock()
+}
+
+// revocationListEntry 返回已吊销或挂起证书在 CRL 中的条目，挂起的原因为 certificateHold
+func revocationListEntry(cert *model.Certificate, serial *big.Int) (x509.RevocationListEntry, bool) {
+	if cert.Status != model.CertificateStatusRevoked && !cert.IsOnHold() {
+		return x509.RevocationListEntry{}, false
+	}
+	revokedAt := cert.UpdatedAt
+	if cert.RevokedAt != nil {
+		revokedAt = *cert.RevokedAt
+	} else if cert.HeldAt != nil {
+		revokedAt = *cert.HeldAt
+	}
+	return x509.RevocationListEntry{
+		SerialNumber:   serial,
+		RevocationTime: revokedAt,
+		ReasonCode:     revocationReasonCodes[cert.RevocationReason],
+	}, true
+}
+
+// signCRL 签发 CRL，以秒级时间戳作为 CRL 编号，保证单调递增
+func signCRL(issuer *x509.Certificate, signer crypto.Signer, entries []x509.RevocationListEntry, thisUpdate, nextUpdate time.Time) ([]byte, error) {
+	var crl []byte
+	err := runSigning(func() error {
+		var err error
+		crl, err = x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+			RevokedCertificateEntries: entries,
+			Number:                    big.NewInt(thisUpdate.Unix()),
+			ThisUpdate:                thisUpdate,
+			NextUpdate:                nextUpdate,
+		}, issuer, signer)
+		return err
+	})
+	if IsSigningBusy(err) {
+		return nil, err
+	}
+	return crl, errors.Wrap(err, "failed to sign crl")
+}
+
+// GetCRL 获取 CA 签发的 CRL，caID 为 0 时为当前签发证书的 CA。CRL 在缓存中保留
+// cert_crl_refresh_minutes 分钟，证书吊销、挂起或解除挂起后立即失效
+func GetCRL(caID uint, format string) ([]byte, error) {
+	if format != CRLFormatDER && format != CRLFormatPEM {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported crl format: %s", format)
+	}
+	if caID == 0 {
+		caID = issuingCertificateAuthorityID()
+		if caID == 0 {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "no active certificate authority is available")
+		}
+	}
+	der, err := getCRL(caID)
+	if err != nil {
+		return nil, err
+	}
+	if format == CRLFormatPEM {
+		return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der}), nil
+	}
+	return der, nil
+}
+
+func getCRL(caID uint) ([]byte, error) {
+	now := time.Now()
+	crlCache.Lock()
+	cached, generation := crlCache.crls[caID], crlCache.generation
+	crlCache.Unlock()
+	if cached != nil && now.Before(cached.refreshAt) {
+		return cached.der, nil
+	}
+	ca, err := db.GetCertificateAuthorityByID(caID)
+	if err != nil {
+		return nil, err
+	}
+	if !ca.HasPrivateKey() {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "the private key of certificate authority %s is not held by OpenList", ca.Name)
+	}
+	signer, err := parsePrivateKey(ca.Content, ca.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := parseCertificates(ca.Content)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := db.GetPublishableCertificatesByIssuer(ca.ID)
+	if err != nil {
+		return nil, err
+	}
+	var entries []x509.RevocationListEntry
+	for i := range certs {
+		serial, ok := new(big.Int).SetString(certs[i].SerialNumber, 16)
+		if !ok {
+			continue
+		}
+		if entry, ok := revocationListEntry(&certs[i], serial); ok {
+			entries = append(entries, entry)
+		}
+	}
+	nextUpdate := now.Add(time.Duration(getSettingInt(conf.CertCRLValidityHours, 24)) * time.Hour)
+	der, err := signCRL(parsed[0], signer, entries, now, nextUpdate)
+	if err != nil {
+		return nil, err
+	}
+	refreshAt := now.Add(time.Duration(getSettingInt(conf.CertCRLRefreshMinutes, 60)) * time.Minute)
+	if refreshAt.After(nextUpdate) {
+		refreshAt = nextUpdate
+	}
+	crlCache.Lock()
+	if crlCache.generation == generation {
+		crlCache.crls[caID] = &cachedCRL{der: der, refreshAt: refreshAt}
+	}
+	crlCache.Unlock()
+	return der, nil
+}
@@ -0,0 +1,255 @@
+package op
+
+import (
+	"bytes"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// CrossSignCA 由签发者 CA 为另一个 CA 签发交叉证书并发布到信任包，只信任签发者的客户端也能校验
+// 被交叉签名的 CA 签发的证书，信任迁移期间证书可以同时提供两条证书链
+func CrossSignCA(in *model.CACrossSignInput, operator *model.User) (*model.CertificateAuthority, error) {
+	if in.SubjectID == in.IssuerID {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "a certificate authority can not cross sign itself")
+	}
+	now := time.Now()
+	subject, err := db.GetCertificateAuthorityByID(in.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !subject.IsActive(now) || subject.IsCrossSigned() {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate authority %s can not be cross signed", subject.Name)
+	}
+	issuer, issuerCA, err := certificateIssuer(in.IssuerID)
+	if err != nil {
+		return nil, err
+	}
+	if !issuerCA.IsActive(now) || issuerCA.IsCrossSigned() {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate authority %s can not sign cross certificates", issuerCA.Name)
+	}
+	if issuerCA.Subject == subject.Subject || issuerCA.Subject == subject.Issuer {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate authority %s is already trusted through %s", subject.Name, issuerCA.Name)
+	}
+	parents, err := certificateAuthorityParents(issuerCA)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range parents {
+		if p.ID == subject.ID {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate authority %s is issued by %s", issuerCA.Name, subject.Name)
+		}
+	}
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	for _, ca := range cas {
+		if ca.CrossSignOf == subject.ID && ca.Issuer == issuerCA.Subject && ca.IsActive(now) {
+			return nil, errs.NewErr(errs.CertificateConflict, "certificate authority %s is already cross signed by %s", subject.Name, issuerCA.Name)
+		}
+	}
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		name = fmt.Sprintf("%s (cross-signed by %s)", subject.Name, issuerCA.Name)
+	}
+	return crossSignCA(subject, issuer, name, operator)
+}
+
+// crossSignCA 由 issuer 为 subject 签发交叉证书并保存
+func crossSignCA(subject *model.CertificateAuthority, issuer *certissuer.Issuer, name string, operator *model.User) (*model.CertificateAuthority, error) {
+	certs, err := parseCertificates(subject.Content)
+	if err != nil {
+		return nil, err
+	}
+	var res *certissuer.Result
+	err = runSigning(func() error {
+		var err error
+		res, err = issuer.CrossSign(certs[0])
+		return err
+	})
+	if IsSigningBusy(err) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "failed cross sign ca %s: %v", subject.Name, err)
+	}
+	return saveCertificateAuthority(name, res, subject.ID, "certificate.ca.cross_signed", operator)
+}
+
+// ImportCrossSignedCA 导入外部 CA 为 OpenList 管理的 CA 签发的交叉证书，按主题和公钥匹配对应的 CA
+func ImportCrossSignedCA(name, content string, operator *model.User) (*model.CertificateAuthority, error) {
+	cross, err := parseCertificateAuthority(content)
+	if err != nil {
+		return nil, err
+	}
+	crossCerts, err := parseCertificates(cross.Content)
+	if err != nil {
+		return nil, err
+	}
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	var target *model.CertificateAuthority
+	for i := range cas {
+		ca := &cas[i]
+		if ca.IsCrossSigned() || ca.Subject != cross.Subject {
+			continue
+		}
+		if ca.Fingerprint == cross.Fingerprint {
+			return nil, errs.NewErr(errs.CertificateConflict, "certificate %s is already managed as %s", cross.Subject, ca.Name)
+		}
+		caCerts, err := parseCertificates(ca.Content)
+		if err != nil || !bytes.Equal(caCerts[0].RawSubjectPublicKeyInfo, crossCerts[0].RawSubjectPublicKeyInfo) {
+			continue
+		}
+		target = ca
+		break
+	}
+	if target == nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "no certificate authority has the subject and public key of %s", cross.Subject)
+	}
+	if cross.Issuer == target.Issuer {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s has the same issuer as %s and is not a cross certificate", cross.Subject, target.Name)
+	}
+	cross.Kind = model.CertificateAuthorityIntermediate
+	cross.CrossSignOf = target.ID
+	if cross.Name = strings.TrimSpace(name); cross.Name == "" {
+		cross.Name = fmt.Sprintf("%s (cross-signed by %s)", target.Name, cross.Issuer)
+	}
+	if err := db.CreateCertificateAuthority(cross); err != nil {
+		return nil, errors.WithMessage(err, "failed create certificate authority")
+	}
+	if err := bumpCABundleVersion(); err != nil {
+		return nil, err
+	}
+	auditCertificateAuthority("certificate.ca.cross_sign_imported", operator, cross)
+	return cross, nil
+}
+
+// CertificateChainOptions 列出证书可以下载的证书链：签发时保存的默认链，以及签发路径上的 CA
+// 被交叉签名后经过交叉证书、以另一个 CA 为信任锚的备用链
+func CertificateChainOptions(cert *model.Certificate) ([]model.CertificateChainOption, error) {
+	if strings.TrimSpace(cert.Content) == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s has no content yet", cert.Name)
+	}
+	certs, err := parseCertificates(cert.Content)
+	if err != nil {
+		return nil, err
+	}
+	leaf, top := certs[0], certs[len(certs)-1]
+	options := []model.CertificateChainOption{{
+		Anchor:   top.Issuer.String(),
+		NotAfter: leaf.NotAfter,
+		PEM:      cert.Content,
+	}}
+	for _, c := range certs[1:] {
+		if c.NotAfter.Before(options[0].NotAfter) {
+			options[0].NotAfter = c.NotAfter
+		}
+	}
+	if cert.IssuerID == 0 {
+		return options, nil
+	}
+	issuing, err := db.GetCertificateAuthorityByID(cert.IssuerID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return options, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	parents, err := certificateAuthorityParents(issuing)
+	if err != nil {
+		return nil, err
+	}
+	path := append([]model.CertificateAuthority{*issuing}, parents...)
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	leafPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: leaf.Raw}))
+	now := time.Now()
+	for i := range path {
+		for j := range cas {
+			cross := &cas[j]
+			if cross.CrossSignOf != path[i].ID || !cross.IsActive(now) {
+				continue
+			}
+			// 交叉证书替代路径上的 CA，其上级按交叉证书的签发者查找，信任锚不包含在链中
+			chain := append(append([]model.CertificateAuthority{}, path[:i]...), *cross)
+			crossParents, err := certificateAuthorityParents(cross)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range crossParents {
+				if p.Kind == model.CertificateAuthorityRoot {
+					break
+				}
+				chain = append(chain, p)
+			}
+			option := model.CertificateChainOption{
+				CrossSignID:   cross.ID,
+				CrossSignName: cross.Name,
+				Anchor:        chain[len(chain)-1].Issuer,
+				NotAfter:      leaf.NotAfter,
+				PEM:           leafPEM,
+			}
+			for _, ca := range chain {
+				option.PEM += ca.Content
+				if ca.NotAfter.Before(option.NotAfter) {
+					option.NotAfter = ca.NotAfter
+				}
+			}
+			options = append(options, option)
+		}
+	}
+	return options, nil
+}
+
+// GetCertificateChainOptions 获取证书可以下载的证书链，只返回链的信息，链的内容通过下载接口获取。
+// 与下载相同，租户只能查看自己的证书，管理端只有管理员和证书所有者可以查看，id 为 0 时为租户当前的证书
+func GetCertificateChainOptions(id uint, user *model.User, tenant bool) ([]model.CertificateChainOption, error) {
+	var cert *model.Certificate
+	var err error
+	if id == 0 {
+		cert, err = getTenantCertificateForDownload(user.ID)
+	} else {
+		cert, err = db.GetCertificateByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	owner := cert.OwnerID == user.ID
+	if !owner && (tenant || !user.IsAdmin()) {
+		return nil, errs.PermissionDenied
+	}
+	return CertificateChainOptions(cert)
+}
+
+// CertificateWithChain 返回内容替换为指定备用链的证书副本，crossSignID 为 0 时返回原证书
+func CertificateWithChain(cert *model.Certificate, crossSignID uint) (*model.Certificate, error) {
+	if crossSignID == 0 {
+		return cert, nil
+	}
+	options, err := CertificateChainOptions(cert)
+	if err != nil {
+		return nil, err
+	}
+	for _, option := range options {
+		if option.CrossSignID == crossSignID {
+			c := *cert
+			c.Content = option.PEM
+			return &c, nil
+		}
+	}
+	return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s has no chain through cross certificate %d", cert.Name, crossSignID)
+}
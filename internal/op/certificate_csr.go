@@ -0,0 +1,115 @@
+package op
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// minCSRRSABits CSR 中 RSA 公钥的最小长度
+const minCSRRSABits = 2048
+
+// parseCSR 解析 PEM 格式的证书签名请求并校验签名、主题和公钥强度
+func parseCSR(content string) (*x509.CertificateRequest, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(content)))
+	if block == nil || (block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST") {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "csr is not a PEM encoded certificate request")
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid csr: %v", err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "csr signature is invalid: %v", err)
+	}
+	if strings.TrimSpace(csr.Subject.CommonName) == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "csr subject must contain a common name")
+	}
+	if err := checkCSRPublicKey(csr.PublicKey); err != nil {
+		return nil, err
+	}
+	return csr, nil
+}
+
+// checkCSRPublicKey 只接受不低于 2048 位的 RSA、P-256 及以上的 ECDSA 和 Ed25519 公钥
+func checkCSRPublicKey(pub crypto.PublicKey) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minCSRRSABits {
+			return errs.NewErr(errs.InvalidCertificateRequest, "csr RSA key must be at least %d bits, got %d", minCSRRSABits, k.N.BitLen())
+		}
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return errs.NewErr(errs.InvalidCertificateRequest, "csr ECDSA curve %s is not supported", k.Curve.Params().Name)
+		}
+	case ed25519.PublicKey:
+	default:
+		return errs.NewErr(errs.InvalidCertificateRequest, "csr public key type %T is not supported", pub)
+	}
+	return nil
+}
+
+// csrSANs 返回 CSR 中的全部主题备用名称
+func csrSANs(csr *x509.CertificateRequest) []string {
+	var sans []string
+	sans = append(sans, csr.DNSNames...)
+	sans = append(sans, csr.EmailAddresses...)
+	for _, ip := range csr.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, u := range csr.URIs {
+		sans = append(sans, u.String())
+	}
+	return sans
+}
+
+// checkRequestCSR 校验申请附带的 CSR，返回规范化后的 PEM 和主题备用名称。
+// 申请未填写备用名称时使用 CSR 中的名称，填写时 CSR 中的名称必须都在申请中，
+// 签发证书的主题仍按申请确定，CSR 只提供公钥
+func checkRequestCSR(content string, sans []string) (string, []string, error) {
+	if strings.TrimSpace(content) == "" {
+		return "", sans, nil
+	}
+	csr, err := parseCSR(content)
+	if err != nil {
+		return "", nil, err
+	}
+	requested, err := checkSANs(csrSANs(csr))
+	if err != nil {
+		return "", nil, err
+	}
+	if len(sans) == 0 {
+		sans = requested
+	} else {
+		for _, san := range requested {
+			if !utils.SliceContains(sans, san) {
+				return "", nil, errs.NewErr(errs.InvalidCertificateRequest, "csr contains subject alternative name %q that is not in the request", san)
+			}
+		}
+	}
+	normalized := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr.Raw}))
+	return normalized, sans, nil
+}
+
+// requestPublicKey 返回申请 CSR 中的公钥，没有 CSR 时返回 nil 表示由服务端生成密钥对
+func requestPublicKey(req *model.CertificateRequest) (crypto.PublicKey, error) {
+	if req.CSR == "" {
+		return nil, nil
+	}
+	csr, err := parseCSR(req.CSR)
+	if err != nil {
+		return nil, err
+	}
+	return csr.PublicKey, nil
+}
@@ -0,0 +1,193 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var GetApprovalDelegations = db.GetApprovalDelegations
+var GetApprovalDelegationByID = db.GetApprovalDelegationByID
+
+// CreateApprovalDelegation 审批人设置外出期间的代理人
+func CreateApprovalDelegation(approver *model.User, delegateName string, startAt, endAt time.Time) (*model.ApprovalDelegation, error) {
+	if !endAt.After(startAt) {
+		return nil, fmt.Errorf("end_at must be after start_at")
+	}
+	delegate, err := GetUserByName(delegateName)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed get delegate %s", delegateName)
+	}
+	if delegate.ID == approver.ID {
+		return nil, fmt.Errorf("can not delegate to yourself")
+	}
+	if delegate.IsGuest() || delegate.Disabled {
+		return nil, fmt.Errorf("delegate %s is not allowed", delegateName)
+	}
+	d := &model.ApprovalDelegation{
+		UserID:     approver.ID,
+		Username:   approver.Username,
+		DelegateID: delegate.ID,
+		Delegate:   delegate.Username,
+		StartAt:    startAt,
+		EndAt:      endAt,
+	}
+	if err := db.CreateApprovalDelegation(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// DeleteApprovalDelegation 删除委托，只有委托人本人可以删除
+func DeleteApprovalDelegation(id uint, approver *model.User) error {
+	d, err := db.GetApprovalDelegationByID(id)
+	if err != nil {
+		return err
+	}
+	if d.UserID != approver.ID {
+		return errs.PermissionDenied
+	}
+	return db.DeleteApprovalDelegation(id)
+}
+
+// GetActiveDelegationsForDelegate 获取代理人当前可以代为审批的委托，
+// 委托人已被删除、禁用或不再是管理员时委托不再生效
+func GetActiveDelegationsForDelegate(delegate *model.User) ([]model.ApprovalDelegation, error) {
+	delegations, err := db.GetActiveApprovalDelegationsByDelegateID(delegate.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	res := delegations[:0]
+	for _, d := range delegations {
+		approver, err := GetUserById(d.UserID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if approver.Disabled || !approver.IsAdmin() {
+			continue
+		}
+		res = append(res, d)
+	}
+	return res, nil
+}
+
+// canApproveCertificateRequest 审批人是否可以处理该申请：类型未配置审批链时任一管理员均可审批，
+// 否则只有审批链中的审批人可以
+func canApproveCertificateRequest(approver string, req *model.CertificateRequest) (bool, error) {
+	t, err := db.GetCertificateTypeByName(req.Type)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(t.ApprovalChain) == 0 || utils.SliceContains(t.ApprovalChain, approver), nil
+}
+
+// checkDelegation 校验代理人当前是否持有委托人的有效委托
+func checkDelegation(delegate *model.User, onBehalfOf string) error {
+	delegations, err := GetActiveDelegationsForDelegate(delegate)
+	if err != nil {
+		return err
+	}
+	for _, d := range delegations {
+		if d.Username == onBehalfOf {
+			return nil
+		}
+	}
+	return errors.WithMessagef(errs.PermissionDenied, "no active delegation from %s", onBehalfOf)
+}
+
+// ApproveCertificateRequestOnBehalf 代理人代委托人批准申请，记录为 "X 代 Y 批准"
+func ApproveCertificateRequestOnBehalf(reqID uint, delegate *model.User, onBehalfOf string, in *model.CertificateApprovalInput) (*model.Certificate, error) {
+	if err := checkDelegation(delegate, onBehalfOf); err != nil {
+		return nil, err
+	}
+	return approveAndCreateCertificate(reqID, delegate.Username, onBehalfOf, in, "")
+}
+
+// RejectCertificateRequestOnBehalf 代理人代委托人拒绝申请
+func RejectCertificateRequestOnBehalf(reqID uint, delegate *model.User, onBehalfOf, reason string) error {
+	if err := checkDelegation(delegate, onBehalfOf); err != nil {
+		return err
+	}
+	return rejectCertificateRequest(reqID, delegate.Username, onBehalfOf, reason, "")
+}
+
+// GetDelegatedCertificateRequests 获取代理人的委托人可以审批的待审批申请
+func GetDelegatedCertificateRequests(delegations []model.ApprovalDelegation) ([]model.CertificateRequest, error) {
+	requests, err := GetPendingCertificateRequests()
+	if err != nil {
+		return nil, err
+	}
+	res := requests[:0]
+	for _, req := range requests {
+		for _, d := range delegations {
+			ok, err := canApproveCertificateRequest(d.Username, &req)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				res = append(res, req)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+// withDelegates 将外出审批人的代理人加入通知对象
+func withDelegates(usernames []string) []string {
+	delegations, err := db.GetActiveApprovalDelegations(time.Now())
+	if err != nil || len(delegations) == 0 {
+		return usernames
+	}
+	res := append([]string{}, usernames...)
+	for _, name := range usernames {
+		for _, d := range delegations {
+			if d.Username == name && !utils.SliceContains(res, d.Delegate) {
+				res = append(res, d.Delegate)
+			}
+		}
+	}
+	return res
+}
+
+// approvalLinkPrincipal 解析用户通过一键审批链接处理申请时代表的委托人：用户本人就是当前这一级的审批人时返回空，
+// 持有当前审批人的有效委托时返回该委托人，与页面上的代理审批一致
+func approvalLinkPrincipal(user *model.User, req *model.CertificateRequest) (string, error) {
+	t, err := CheckCertificateType(req.Type)
+	if err != nil {
+		return "", err
+	}
+	next := ""
+	if len(req.Approvals) < len(t.ApprovalChain) {
+		next = t.ApprovalChain[len(req.Approvals)]
+	}
+	if next == user.Username || (next == "" && user.IsAdmin()) {
+		return "", nil
+	}
+	delegations, err := GetActiveDelegationsForDelegate(user)
+	if err != nil {
+		return "", err
+	}
+	for _, d := range delegations {
+		if next == "" || d.Username == next {
+			return d.Username, nil
+		}
+	}
+	// 管理员不在当前这一级时仍可以拒绝申请
+	if user.IsAdmin() {
+		return "", nil
+	}
+	return "", errs.PermissionDenied
+}
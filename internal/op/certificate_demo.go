@@ -0,0 +1,193 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/pkg/errors"
+)
+
+// demoCertificate 演示证书，时间均相对于填充时刻
+type demoCertificate struct {
+	owner     string
+	typ       model.CertificateType
+	status    model.CertificateStatus
+	issued    time.Duration
+	expires   time.Duration
+	sans      []string
+	reason    string
+	autoRenew bool
+}
+
+// demoRequest 演示申请，certificate 不为 -1 时为对应演示证书的来源申请
+type demoRequest struct {
+	user        string
+	typ         model.CertificateType
+	status      model.CertificateStatus
+	created     time.Duration
+	reason      string
+	sans        []string
+	certificate int
+}
+
+const demoDay = 24 * time.Hour
+
+var demoCertificates = []demoCertificate{
+	{owner: "demo-alice", typ: model.CertificateTypeUser, status: model.CertificateStatusValid, issued: -30 * demoDay, expires: 335 * demoDay},
+	{owner: "demo-bob", typ: model.CertificateTypeNode, status: model.CertificateStatusValid, issued: -90 * demoDay, expires: 275 * demoDay,
+		sans: []string{"bob.demo.example", "10.0.0.12"}, autoRenew: true},
+	{owner: "demo-carol", typ: model.CertificateTypeUser, status: model.CertificateStatusExpiring, issued: -358 * demoDay, expires: 7 * demoDay},
+	{owner: "demo-dave", typ: model.CertificateTypeNode, status: model.CertificateStatusExpiring, issued: -363 * demoDay, expires: 2 * demoDay,
+		sans: []string{"dave.demo.example"}},
+	{owner: "demo-erin", typ: model.CertificateTypeUser, status: model.CertificateStatusExpired, issued: -400 * demoDay, expires: -35 * demoDay},
+	{owner: "demo-frank", typ: model.CertificateTypeNode, status: model.CertificateStatusRevoked, issued: -60 * demoDay, expires: 305 * demoDay,
+		sans: []string{"frank.demo.example"}, reason: model.RevocationReasonKeyCompromise},
+	{owner: "demo-grace", typ: model.CertificateTypeUser, status: model.CertificateStatusHold, issued: -20 * demoDay, expires: 345 * demoDay,
+		reason: model.RevocationReasonCertificateHold},
+}
+
+var demoRequests = []demoRequest{
+	{user: "demo-alice", typ: model.CertificateTypeUser, status: model.CertificateStatusValid, created: -31 * demoDay,
+		reason: "Access to the build cluster", certificate: 0},
+	{user: "demo-heidi", typ: model.CertificateTypeUser, status: model.CertificateStatusPending, created: -3 * time.Hour,
+		reason: "New team member onboarding", certificate: -1},
+	{user: "demo-ivan", typ: model.CertificateTypeNode, status: model.CertificateStatusPending, created: -26 * time.Hour,
+		reason: "Staging ingress node", sans: []string{"ivan.demo.example"}, certificate: -1},
+	{user: "demo-judy", typ: model.CertificateTypeUser, status: model.CertificateStatusRejected, created: -5 * demoDay,
+		reason: "Testing", certificate: -1},
+}
+
+// SeedCertificateDemoData 填充覆盖各种状态的演示证书和申请，用于评估实例和前端开发。
+// 证书由一次性的演示 CA 签发，不会被任何客户端信任；已有演示数据时需要先清除
+func SeedCertificateDemoData(operator *model.User) (*model.CertificateDemoSummary, error) {
+	count, err := db.CountCertificateDemoRecords()
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errs.NewErr(errs.CertificateConflict, "demo data has already been seeded, wipe it first")
+	}
+	now := time.Now()
+	ca, err := certissuer.NewCA(&certissuer.CARequest{
+		CommonName:   "OpenList Demo CA (untrusted)",
+		Organization: "OpenList Demo",
+		KeyAlgorithm: certissuer.KeyECDSAP256,
+		NotBefore:    now.Add(-2 * 365 * demoDay),
+		NotAfter:     now.Add(3 * 365 * demoDay),
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate demo ca")
+	}
+	key, err := parsePrivateKey(ca.CertificatePEM, ca.PrivateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	issuer := &certissuer.Issuer{Certificate: ca.Certificate, Key: key}
+
+	certs := make([]*model.Certificate, len(demoCertificates))
+	for i, d := range demoCertificates {
+		cert := &model.Certificate{
+			Name:           fmt.Sprintf("%s-%s-cert", d.owner, d.typ),
+			Type:           d.typ,
+			Status:         d.status,
+			Owner:          d.owner,
+			AutoRenew:      d.autoRenew,
+			IssuedDate:     now.Add(d.issued),
+			ExpirationDate: now.Add(d.expires),
+		}
+		commonName := d.owner
+		if len(d.sans) > 0 && d.typ == model.CertificateTypeNode {
+			commonName = d.sans[0]
+		}
+		if err := signCertificate(issuer, cert, commonName, d.sans, nil); err != nil {
+			return nil, err
+		}
+		changedAt := now.Add(-time.Duration(i+1) * demoDay)
+		switch d.status {
+		case model.CertificateStatusRevoked:
+			cert.RevokedAt, cert.RevocationReason = &changedAt, d.reason
+		case model.CertificateStatusHold:
+			cert.HeldAt, cert.RevocationReason = &changedAt, d.reason
+		}
+		certs[i] = cert
+	}
+	reqs := make([]*model.CertificateRequest, len(demoRequests))
+	for i, d := range demoRequests {
+		created := now.Add(d.created)
+		req := &model.CertificateRequest{
+			UserName:  d.user,
+			Type:      d.typ,
+			Status:    d.status,
+			Reason:    d.reason,
+			SANs:      d.sans,
+			CreatedAt: created,
+		}
+		decided := created.Add(time.Hour)
+		switch d.status {
+		case model.CertificateStatusValid:
+			req.ApprovedBy, req.ApprovedAt = operator.Username, &decided
+		case model.CertificateStatusRejected:
+			req.RejectedBy, req.RejectedAt, req.RejectedReason = operator.Username, &decided, "Please use the sandbox for tests"
+		}
+		reqs[i] = req
+	}
+
+	err = db.Transaction(func(tx db.Tx) error {
+		for _, req := range reqs {
+			if err := tx.CreateCertificateRequest(req); err != nil {
+				return err
+			}
+			if err := tx.CreateCertificateDemoRecord(model.CertificateAuditTargetRequest, req.ID); err != nil {
+				return err
+			}
+		}
+		for i, d := range demoRequests {
+			if d.certificate >= 0 {
+				certs[d.certificate].RequestID = reqs[i].ID
+			}
+		}
+		for _, cert := range certs {
+			if err := tx.CreateCertificate(cert); err != nil {
+				return err
+			}
+			if err := tx.CreateCertificateDemoRecord(model.CertificateAuditTargetCertificate, cert.ID); err != nil {
+				return err
+			}
+			if err := tx.CreateCertificateEvent(&model.CertificateEvent{
+				CertificateID: cert.ID, Event: "certificate.created", Actor: operator.Username, Detail: "demo data",
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to seed demo data")
+	}
+	summary := &model.CertificateDemoSummary{Certificates: len(certs), Requests: len(reqs)}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.demo.seeded",
+		Actor:  operator.Username,
+		Detail: fmt.Sprintf("%d certificates, %d requests", summary.Certificates, summary.Requests),
+	})
+	return summary, nil
+}
+
+// WipeCertificateDemoData 彻底删除演示数据及其关联记录，不影响真实数据
+func WipeCertificateDemoData(operator *model.User) (*model.CertificateDemoSummary, error) {
+	summary, err := db.WipeCertificateDemoRecords()
+	if err != nil {
+		return nil, err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.demo.wiped",
+		Actor:  operator.Username,
+		Detail: fmt.Sprintf("%d certificates, %d requests", summary.Certificates, summary.Requests),
+	})
+	return summary, nil
+}
@@ -0,0 +1,195 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// certificateDigestBuckets 摘要中按剩余天数分组
+var certificateDigestBuckets = []int{30, 60, 90}
+
+var certificateDigestIntervals = map[string]time.Duration{
+	model.CertificateDigestDaily:   24 * time.Hour,
+	model.CertificateDigestWeekly:  7 * 24 * time.Hour,
+	model.CertificateDigestMonthly: 30 * 24 * time.Hour,
+}
+
+func GetCertificateDigestPref(user *model.User) (*model.CertificateDigestPref, error) {
+	return db.GetCertificateDigestPref(user.ID)
+}
+
+// UpdateCertificateDigestPref 更新用户的摘要频率，为空时恢复站点默认，off 表示退订
+func UpdateCertificateDigestPref(user *model.User, frequency string) (*model.CertificateDigestPref, error) {
+	if _, ok := certificateDigestIntervals[frequency]; !ok && frequency != "" && frequency != model.CertificateDigestOff {
+		return nil, fmt.Errorf("invalid digest frequency: %s", frequency)
+	}
+	pref, err := db.GetCertificateDigestPref(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	pref.Frequency = frequency
+	if err := db.SaveCertificateDigestPref(pref); err != nil {
+		return nil, err
+	}
+	return pref, nil
+}
+
+// formatCertificateDigest 按剩余天数分组列出证书
+func formatCertificateDigest(certs []model.Certificate, withOwner bool) string {
+	var buf strings.Builder
+	from := 0
+	for _, days := range certificateDigestBuckets {
+		var lines []string
+		for _, cert := range certs {
+			left := int(time.Until(cert.ExpirationDate).Hours() / 24)
+			if left < from || left >= days {
+				continue
+			}
+			line := fmt.Sprintf("- %s (%s) expires on %s", cert.Name, cert.Type, cert.ExpirationDate.Format("2006-01-02"))
+			if withOwner {
+				line += ", owner " + cert.Owner
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&buf, "Expiring in %d-%d days:\n%s\n\n", from, days, strings.Join(lines, "\n"))
+		}
+		from = days
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// SendCertificateDigests 向租户发送其证书的到期摘要，管理员和审计员收到全部证书的摘要，
+// 按用户设置的频率发送，证书的联系人另外收到其负责证书的摘要，由定时任务调用
+func SendCertificateDigests(ctx context.Context) error {
+	if !notify.Enabled() {
+		return nil
+	}
+	defaultFrequency := getSettingStr(conf.CertDigestFrequency, model.CertificateDigestWeekly)
+	maxDays := certificateDigestBuckets[len(certificateDigestBuckets)-1]
+	certs, err := db.GetCertificatesExpiringBefore(time.Now().AddDate(0, 0, maxDays))
+	if err != nil {
+		return err
+	}
+	byOwner := make(map[uint][]model.Certificate)
+	for _, cert := range certs {
+		byOwner[cert.OwnerID] = append(byOwner[cert.OwnerID], cert)
+	}
+	admins, err := db.GetUsersByRole(model.ADMIN)
+	if err != nil {
+		return errors.WithMessage(err, "failed get admins")
+	}
+	auditors, err := db.GetUsersByRole(model.AUDITOR)
+	if err != nil {
+		return errors.WithMessage(err, "failed get auditors")
+	}
+	admins = append(admins, auditors...)
+	recipients := make(map[uint]*model.User, len(byOwner)+len(admins))
+	for i := range admins {
+		recipients[admins[i].ID] = &admins[i]
+	}
+	for ownerID := range byOwner {
+		if _, ok := recipients[ownerID]; ok {
+			continue
+		}
+		user, err := GetUserById(ownerID)
+		if err != nil {
+			continue
+		}
+		recipients[ownerID] = user
+	}
+	for _, user := range recipients {
+		if user.Disabled {
+			continue
+		}
+		list := byOwner[user.ID]
+		if user.CanViewAllCertificates() {
+			list = certs
+		}
+		if len(list) == 0 {
+			continue
+		}
+		pref, err := db.GetCertificateDigestPref(user.ID)
+		if err != nil {
+			return err
+		}
+		frequency := pref.Frequency
+		if frequency == "" {
+			frequency = defaultFrequency
+		}
+		interval, ok := certificateDigestIntervals[frequency]
+		if !ok || (pref.LastSentAt != nil && time.Since(*pref.LastSentAt) < interval) {
+			continue
+		}
+		err = notify.Send(ctx, &notify.Message{
+			Event:    "certificate.digest",
+			Title:    fmt.Sprintf("%d certificates expire in the next %d days", len(list), maxDays),
+			Content:  formatCertificateDigest(list, user.CanViewAllCertificates()),
+			To:       []string{user.Username},
+			Channels: []string{"email"},
+		})
+		if err != nil {
+			log.Warnf("failed to send certificate digest to %s: %+v", user.Username, err)
+			continue
+		}
+		now := time.Now()
+		pref.LastSentAt = &now
+		if err := db.SaveCertificateDigestPref(pref); err != nil {
+			return err
+		}
+	}
+	return sendCertificateContactDigests(ctx, certs, defaultFrequency, maxDays)
+}
+
+// certificateEscalationDays 距离到期不足该天数的证书同时提醒升级联系人
+const certificateEscalationDays = 7
+
+// sendCertificateContactDigests 按站点默认频率向证书的联系人发送到期摘要，不依赖所有者账号，
+// 升级联系人只收到即将到期的证书
+func sendCertificateContactDigests(ctx context.Context, certs []model.Certificate, frequency string, maxDays int) error {
+	interval, ok := certificateDigestIntervals[frequency]
+	if !ok {
+		return nil
+	}
+	byContact := make(map[string][]model.Certificate)
+	for _, cert := range certs {
+		escalate := time.Until(cert.ExpirationDate) < certificateEscalationDays*24*time.Hour
+		for _, addr := range certificateContacts(&cert, escalate) {
+			byContact[addr] = append(byContact[addr], cert)
+		}
+	}
+	for addr, list := range byContact {
+		d, err := db.GetCertificateContactDigest(addr)
+		if err != nil {
+			return err
+		}
+		if !d.LastSentAt.IsZero() && time.Since(d.LastSentAt) < interval {
+			continue
+		}
+		err = notify.Send(ctx, &notify.Message{
+			Event:    "certificate.digest",
+			Title:    fmt.Sprintf("%d certificates expire in the next %d days", len(list), maxDays),
+			Content:  formatCertificateDigest(list, true),
+			Emails:   []string{addr},
+			Channels: []string{"email"},
+		})
+		if err != nil {
+			log.Warnf("failed to send certificate digest to contact %s: %+v", addr, err)
+			continue
+		}
+		d.LastSentAt = time.Now()
+		if err := db.SaveCertificateContactDigest(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
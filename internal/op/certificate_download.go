@@ -0,0 +1,216 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/pkcs12"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// 吊销后下载策略
+const (
+	RevokedDownloadBlock = "block" // 吊销后立即禁止下载
+	RevokedDownloadGrace = "grace" // 吊销后宽限期内仍可下载
+	RevokedDownloadAllow = "allow" // 吊销后始终可下载，用于取证
+)
+
+// GetCertificateForDownload 管理端按 id 获取要下载的证书并执行吊销后下载策略，只有管理员和证书所有者可以下载，
+// 包含私钥的格式还要求是所有者或有权查看证书内容；返回的 revoked 为 true 时下载内容需标记为已吊销，
+// 已吊销的证书只能下载不含私钥的格式
+func GetCertificateForDownload(id uint, user *model.User, format, ip string) (*model.Certificate, bool, error) {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return nil, false, err
+	}
+	// 无权下载的尝试同样触发诱饵证书告警
+	TripCertificateHoneytoken(cert, user.Username, "download", ip)
+	owner := cert.OwnerID == user.ID
+	if !user.IsAdmin() && !owner {
+		return nil, false, errs.PermissionDenied
+	}
+	if format == CertificateFormatPKCS12 && !owner && !user.CanReadCertificateContent() {
+		return nil, false, errors.WithMessage(errs.PermissionDenied, "only the owner can download the private key")
+	}
+	return checkCertificateDownload(cert, user, format)
+}
+
+// GetTenantCertificateForDownload 租户获取要下载的证书，不论角色只能下载自己的证书，
+// id 为 0 时获取当前的证书
+func GetTenantCertificateForDownload(id uint, user *model.User, format, ip string) (*model.Certificate, bool, error) {
+	var cert *model.Certificate
+	var err error
+	if id == 0 {
+		cert, err = getTenantCertificateForDownload(user.ID)
+	} else {
+		cert, err = db.GetCertificateByID(id)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	TripCertificateHoneytoken(cert, user.Username, "download", ip)
+	if cert.OwnerID != user.ID {
+		return nil, false, errs.PermissionDenied
+	}
+	return checkCertificateDownload(cert, user, format)
+}
+
+// checkCertificateDownload 执行吊销后下载策略，策略允许下载时已吊销的证书也只提供证书内容，不再导出私钥
+func checkCertificateDownload(cert *model.Certificate, user *model.User, format string) (*model.Certificate, bool, error) {
+	if cert.Status != model.CertificateStatusRevoked {
+		return cert, false, nil
+	}
+	if format == CertificateFormatPKCS12 {
+		return nil, true, errs.NewErr(errs.PermissionDenied, "certificate %s has been revoked, its private key can no longer be downloaded", cert.Name)
+	}
+	if err := checkRevokedDownload(cert, time.Now()); err != nil {
+		return nil, true, err
+	}
+	recordCertificateEvent(cert.ID, "certificate.revoked_downloaded", user.Username, "")
+	return cert, true, nil
+}
+
+// getTenantCertificateForDownload 优先返回租户的有效证书，没有时返回最近吊销的证书交由策略判断
+func getTenantCertificateForDownload(ownerID uint) (*model.Certificate, error) {
+	cert, err := db.GetCertificateByOwnerID(ownerID)
+	if err == nil {
+		return cert, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return db.GetLatestRevokedCertificateByOwnerID(ownerID)
+}
+
+func checkRevokedDownload(cert *model.Certificate, now time.Time) error {
+	switch getSettingStr(conf.CertRevokedDownloadPolicy, RevokedDownloadBlock) {
+	case RevokedDownloadAllow:
+		return nil
+	case RevokedDownloadGrace:
+		grace := time.Duration(getSettingInt(conf.CertRevokedDownloadGrace, 72)) * time.Hour
+		// 早期吊销的证书没有记录吊销时间，视为宽限期已过
+		if cert.RevokedAt != nil && now.Before(cert.RevokedAt.Add(grace)) {
+			return nil
+		}
+		return errs.NewErr(errs.PermissionDenied, "certificate %s was revoked and the download grace period has ended", cert.Name)
+	default:
+		return errs.NewErr(errs.PermissionDenied, "certificate %s has been revoked", cert.Name)
+	}
+}
+
+// RecordCertificateDownload 记录一次下载，统计失败不影响下载
+func RecordCertificateDownload(cert *model.Certificate, user *model.User, revoked bool, ip, userAgent string) {
+	d := &model.CertificateDownload{
+		CertificateID: cert.ID,
+		Username:      user.Username,
+		IP:            ip,
+		UserAgent:     userAgent,
+		Revoked:       revoked,
+		CreatedAt:     time.Now(),
+	}
+	if err := db.RecordCertificateDownload(d); err != nil {
+		log.Errorf("failed to record download of certificate %d: %+v", cert.ID, err)
+	}
+}
+
+var GetCertificateDownloads = db.GetCertificateDownloads
+
+// GetUnusedCertificates 获取签发超过 days 天且最近 days 天内没有被下载过的有效证书
+func GetUnusedCertificates(days int) ([]model.Certificate, error) {
+	if days <= 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "days must be positive")
+	}
+	return db.GetUnusedCertificates(time.Now().AddDate(0, 0, -days))
+}
+
+// 证书下载格式
+const (
+	CertificateFormatPEM    = "pem"
+	CertificateFormatDER    = "der"
+	CertificateFormatPKCS12 = "pkcs12"
+)
+
+// CertificateFile 转换后的证书下载内容
+type CertificateFile struct {
+	Data        []byte
+	ContentType string
+	Filename    string
+}
+
+// ValidateCertificateFormat 检查下载格式及其参数，空格式视为 pem
+func ValidateCertificateFormat(format, password string) error {
+	switch format {
+	case "", CertificateFormatPEM, CertificateFormatDER:
+		return nil
+	case CertificateFormatPKCS12:
+		if password == "" {
+			return errs.NewErr(errs.InvalidCertificateRequest, "password is required for pkcs12 format")
+		}
+		return nil
+	default:
+		return errs.NewErr(errs.InvalidCertificateRequest, "unsupported certificate format %s", format)
+	}
+}
+
+// ConvertCertificateForDownload 按下载格式转换证书内容。der 只包含叶子证书，
+// pkcs12 需要服务端保存了私钥，并将证书链中其余证书一并打包
+func ConvertCertificateForDownload(cert *model.Certificate, format, password string) (*CertificateFile, error) {
+	if err := ValidateCertificateFormat(format, password); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(cert.Content) == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s has no content yet", cert.Name)
+	}
+	name := certificateFileName(cert)
+	switch format {
+	case CertificateFormatDER:
+		certs, err := parseCertificates(cert.Content)
+		if err != nil {
+			return nil, err
+		}
+		return &CertificateFile{Data: certs[0].Raw, ContentType: "application/pkix-cert", Filename: name + ".der"}, nil
+	case CertificateFormatPKCS12:
+		if !cert.HasPrivateKey() {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "private key of certificate %s is not held by the server", cert.Name)
+		}
+		certs, err := parseCertificates(cert.Content)
+		if err != nil {
+			return nil, err
+		}
+		key, err := parsePrivateKey(cert.Content, cert.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		data, err := pkcs12.Encode(key, certs[0], certs[1:], cert.Name, password)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return &CertificateFile{Data: data, ContentType: "application/x-pkcs12", Filename: name + ".p12"}, nil
+	default:
+		return &CertificateFile{Data: []byte(cert.Content), ContentType: "application/x-pem-file", Filename: name + ".pem"}, nil
+	}
+}
+
+// certificateFileName 由证书名称生成下载文件名，只保留文件名中安全的字符
+func certificateFileName(cert *model.Certificate) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.TrimSpace(cert.Name))
+	name = strings.Trim(name, "._")
+	if name == "" {
+		return fmt.Sprintf("certificate-%d", cert.ID)
+	}
+	return name
+}
@@ -0,0 +1,88 @@
+package op_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func selfSignedCertificatePEM(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "download"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestRevokedCertificateDownloadInGracePeriod(t *testing.T) {
+	for key, value := range map[string]string{conf.CertRevokedDownloadPolicy: op.RevokedDownloadGrace, conf.CertRevokedDownloadGrace: "72"} {
+		if err := op.SaveSettingItem(&model.SettingItem{Key: key, Value: value, Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owner := &model.User{ID: 4242, Username: "download-owner", Role: model.GENERAL}
+	content, privateKey := selfSignedCertificatePEM(t)
+	revokedAt := time.Now().Add(-time.Hour)
+	cert := &model.Certificate{Name: "revoked-download", Type: model.CertificateTypeUser, Status: model.CertificateStatusRevoked,
+		Owner: owner.Username, OwnerID: owner.ID, Content: content, PrivateKey: privateKey, RevokedAt: &revokedAt,
+		ExpirationDate: time.Now().Add(time.Hour)}
+	if err := db.CreateCertificate(cert); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		format  string
+		wantErr bool
+	}{
+		{format: op.CertificateFormatPEM},
+		{format: op.CertificateFormatDER},
+		{format: op.CertificateFormatPKCS12, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, revoked, err := op.GetTenantCertificateForDownload(cert.ID, owner, tt.format, "127.0.0.1")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.format, err, tt.wantErr)
+			continue
+		}
+		if !revoked {
+			t.Errorf("%s: download is not marked revoked", tt.format)
+		}
+		if tt.wantErr {
+			continue
+		}
+		file, err := op.ConvertCertificateForDownload(got, tt.format, "")
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if len(file.Data) == 0 {
+			t.Errorf("%s: empty download", tt.format)
+		}
+	}
+}
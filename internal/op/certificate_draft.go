@@ -0,0 +1,153 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// CreateCertificateRequestDraft 保存申请草稿，草稿不通知审批人，提交时才做完整校验
+func CreateCertificateRequestDraft(user *model.User, reqType model.CertificateType, reason, validityPreset string, customFields map[string]string, sans []string, csr string, profileID uint) (*model.CertificateRequest, error) {
+	if _, err := CheckCertificateType(reqType); err != nil {
+		return nil, err
+	}
+	sans, err := checkSANs(sans)
+	if err != nil {
+		return nil, err
+	}
+	csr, sans, err = checkRequestCSR(csr, sans)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkRequestProfile(profileID, user.Username, sans, csr); err != nil {
+		return nil, err
+	}
+	draft := &model.CertificateRequest{
+		UserName:       user.Username,
+		UserID:         user.ID,
+		Type:           reqType,
+		ProfileID:      profileID,
+		Status:         model.CertificateStatusDraft,
+		Reason:         reason,
+		CustomFields:   customFields,
+		ValidityPreset: validityPreset,
+		SANs:           sans,
+		CSR:            csr,
+	}
+	if err := db.CreateCertificateRequest(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// getCertificateRequestDraft 获取用户自己的草稿
+func getCertificateRequestDraft(id uint, user *model.User) (*model.CertificateRequest, error) {
+	draft, err := db.GetCertificateRequestByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.UserID != user.ID {
+		return nil, errs.PermissionDenied
+	}
+	if !draft.IsDraft() {
+		return nil, errs.NewErr(errs.CertificateConflict, "request is not a draft, current status: %s", draft.Status)
+	}
+	return draft, nil
+}
+
+// UpdateCertificateRequestDraft 更新申请草稿
+func UpdateCertificateRequestDraft(id uint, user *model.User, reqType model.CertificateType, reason, validityPreset string, customFields map[string]string, sans []string, csr string, profileID uint) (*model.CertificateRequest, error) {
+	draft, err := getCertificateRequestDraft(id, user)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := CheckCertificateType(reqType); err != nil {
+		return nil, err
+	}
+	if draft.SANs, err = checkSANs(sans); err != nil {
+		return nil, err
+	}
+	if draft.CSR, draft.SANs, err = checkRequestCSR(csr, draft.SANs); err != nil {
+		return nil, err
+	}
+	if _, err := checkRequestProfile(profileID, user.Username, draft.SANs, draft.CSR); err != nil {
+		return nil, err
+	}
+	draft.Type = reqType
+	draft.ProfileID = profileID
+	draft.Reason = reason
+	draft.ValidityPreset = validityPreset
+	draft.CustomFields = customFields
+	if err := db.UpdateCertificateRequest(draft); err != nil {
+		return nil, errors.Wrap(err, "failed to update draft")
+	}
+	return draft, nil
+}
+
+// SubmitCertificateRequestDraft 提交草稿，校验与直接申请相同，提交后进入待审批
+func SubmitCertificateRequestDraft(id uint, user *model.User) (*model.CertificateRequest, error) {
+	draft, err := getCertificateRequestDraft(id, user)
+	if err != nil {
+		return nil, err
+	}
+	customFields, err := checkTenantCertificateRequest(user, draft.Type, draft.Reason, draft.ValidityPreset, draft.CustomFields)
+	if err != nil {
+		return nil, err
+	}
+	// 保存草稿后签发配置可能已被调整
+	if _, err := checkRequestProfile(draft.ProfileID, user.Username, draft.SANs, draft.CSR); err != nil {
+		return nil, err
+	}
+	if err := checkPublicCertificateRequest(draft.Type, draft.SANs, draft.CSR); err != nil {
+		return nil, err
+	}
+	draft.CustomFields = customFields
+	draft.Status = model.CertificateStatusPending
+	// 审批提醒和升级从提交时开始计时
+	draft.CreatedAt = time.Now()
+	if err := db.UpdateCertificateRequest(draft); err != nil {
+		return nil, errors.Wrap(err, "failed to submit draft")
+	}
+	auditCertificateRequest("certificate.request.created", user.Username, draft, string(draft.Type))
+	notifyCertificateApprovers(draft)
+	openCertificateTicket(draft)
+	detectRequestSpike(draft)
+	return draft, nil
+}
+
+// DeleteCertificateRequestDraft 删除申请草稿
+func DeleteCertificateRequestDraft(id uint, user *model.User) error {
+	if _, err := getCertificateRequestDraft(id, user); err != nil {
+		return err
+	}
+	if err := checkTenantLegalHold(user.ID); err != nil {
+		return err
+	}
+	return db.DeleteCertificateRequest(id)
+}
+
+// ExpireCertificateRequestDrafts 删除长时间未更新的草稿，由定时任务调用
+func ExpireCertificateRequestDrafts() error {
+	days := getSettingInt(conf.CertDraftExpireDays, 30)
+	if days <= 0 {
+		return nil
+	}
+	// 法律保留中的租户的草稿不清理
+	held, err := db.GetHeldTenantIDs()
+	if err != nil {
+		return err
+	}
+	n, err := db.DeleteCertificateRequestDraftsBefore(time.Now().AddDate(0, 0, -days), held)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Infof("deleted %d stale certificate request drafts", n)
+	}
+	return nil
+}
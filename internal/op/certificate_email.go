@@ -0,0 +1,142 @@
+package op
+
+import (
+	"encoding/base64"
+	"net/mail"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// VerifyCertificateEmail 校验邮件服务商转发入站邮件的签名
+func VerifyCertificateEmail(body []byte, timestamp, signature string) error {
+	return verifyWebhookSignature(getSettingStr(conf.CertEmailIntakeSecret, ""), "email intake", body, timestamp, signature)
+}
+
+// CertificateEmailResult 入站邮件的处理结果，Ignored 为未能映射的附件
+type CertificateEmailResult struct {
+	Request *model.CertificateRequest `json:"request"`
+	User    string                    `json:"user"`
+	Ignored []string                  `json:"ignored,omitempty"`
+}
+
+// CreateCertificateRequestFromEmail 将已知租户发来的结构化邮件转换为证书申请，发件人地址可以伪造，
+// 服务商的 DMARC 校验结果为 pass 时才按发件人匹配租户。
+// 正文每行一个 "字段: 值"，type 必填，reason 为空时使用邮件主题，sans 以逗号分隔，
+// profile 为签发配置 id，与该类型自定义字段同名的行作为字段的值，其余行忽略。
+// 附件中的 CSR 作为申请的 CSR，文件名（不含扩展名）与自定义字段同名的附件作为该字段的值
+func CreateCertificateRequestFromEmail(email *model.CertificateEmail) (*CertificateEmailResult, error) {
+	if !strings.EqualFold(strings.TrimSpace(email.DMARC), "pass") {
+		return nil, errors.WithMessagef(errs.PermissionDenied, "sender is not authenticated, dmarc: %q", email.DMARC)
+	}
+	from, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid sender: %s", email.From)
+	}
+	user, err := db.GetUserByEmail(from.Address)
+	if err != nil {
+		return nil, errors.WithMessage(errs.PermissionDenied, "sender is not a known tenant")
+	}
+	if user.Disabled || user.IsGuest() {
+		return nil, errs.PermissionDenied
+	}
+
+	fields := parseCertificateEmailText(email.Text)
+	reqType := model.CertificateType(fields["type"])
+	if reqType == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "type is required")
+	}
+	// 只取该类型定义了的自定义字段，正文中的其它行忽略
+	defs, err := GetCertificateRequestFields(reqType)
+	if err != nil {
+		return nil, err
+	}
+	customFields := make(map[string]string)
+	for _, f := range defs {
+		if v, ok := fields[f.Key]; ok {
+			customFields[f.Key] = v
+		}
+	}
+
+	res := &CertificateEmailResult{User: user.Username}
+	var csr string
+	for _, a := range email.Attachments {
+		content, err := base64.StdEncoding.DecodeString(a.Content)
+		if err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "attachment %s is not base64 encoded", a.Filename)
+		}
+		key := certificateEmailFieldKey(strings.TrimSuffix(a.Filename, path.Ext(a.Filename)))
+		switch {
+		case strings.Contains(string(content), "CERTIFICATE REQUEST-----"):
+			if csr != "" {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "only one csr attachment is allowed")
+			}
+			csr = string(content)
+		case hasCertificateRequestField(defs, key) && customFields[key] == "":
+			customFields[key] = strings.TrimSpace(string(content))
+		default:
+			res.Ignored = append(res.Ignored, a.Filename)
+		}
+	}
+
+	reason := fields["reason"]
+	if reason == "" {
+		reason = strings.TrimSpace(email.Subject)
+	}
+	var profileID uint
+	if fields["profile"] != "" {
+		id, err := strconv.ParseUint(fields["profile"], 10, 64)
+		if err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid profile: %s", fields["profile"])
+		}
+		profileID = uint(id)
+	}
+	sans := strings.FieldsFunc(fields["sans"], func(r rune) bool { return r == ',' || r == ';' || r == ' ' })
+	res.Request, err = CreateTenantCertificateRequest(user, reqType, reason, fields["validity"], customFields, sans, csr, profileID)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func hasCertificateRequestField(fields []model.CertificateRequestField, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// certificateEmailFieldKey 统一字段名：小写，空格和连字符替换为下划线
+func certificateEmailFieldKey(s string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(s)))
+}
+
+// parseCertificateEmailText 解析正文中的 "字段: 值"，遇到签名分隔线 "-- " 后停止，重复的字段以第一次出现为准
+func parseCertificateEmailText(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line == "-- " || line == "--" {
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = certificateEmailFieldKey(key), strings.TrimSpace(value)
+		if key == "" || value == "" || strings.HasPrefix(key, ">") {
+			continue
+		}
+		if _, exists := fields[key]; !exists {
+			fields[key] = value
+		}
+	}
+	return fields
+}
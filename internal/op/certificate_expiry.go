@@ -0,0 +1,80 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// UpdateCertificateExpiryStatuses 将进入到期窗口的有效证书标记为即将过期，将已到期的证书标记为已过期，
+// 随后发送到期提醒，由定时任务调用
+func UpdateCertificateExpiryStatuses(ctx context.Context) error {
+	now := time.Now()
+	window := getSettingInt(conf.CertExpiringWindowDays, 30)
+	if window < 0 {
+		window = 0
+	}
+	certs, err := db.GetCertificatesExpiringByStatus(now.AddDate(0, 0, window))
+	if err != nil {
+		return err
+	}
+	var expiring, expired int
+	for i := range certs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cert := &certs[i]
+		status := certificateExpiryStatus(cert, now, window)
+		if status == cert.Status {
+			continue
+		}
+		updated, err := db.UpdateCertificateStatus(cert.ID, cert.Status, status)
+		if err != nil {
+			log.Errorf("failed to mark certificate %d as %s: %+v", cert.ID, status, err)
+			continue
+		}
+		if !updated {
+			continue
+		}
+		if status == model.CertificateStatusExpired {
+			expired++
+			recordCertificateEvent(cert.ID, "certificate.expired", "system",
+				fmt.Sprintf("expired at %s", cert.ExpirationDate.Format(time.RFC3339)))
+		} else {
+			expiring++
+			recordCertificateEvent(cert.ID, "certificate.expiring", "system",
+				fmt.Sprintf("expires at %s", cert.ExpirationDate.Format(time.RFC3339)))
+		}
+	}
+	if expiring > 0 || expired > 0 {
+		log.Infof("marked %d certificates as expiring and %d as expired", expiring, expired)
+	}
+	// 先更新到期分桶，到期提醒和续期按分桶查询
+	if _, err := db.UpdateCertificateExpiryBuckets(now); err != nil {
+		return err
+	}
+	return NotifyExpiring(ctx)
+}
+
+// certificateExpiryStatus 按到期时间计算有效证书应处于的状态
+func certificateExpiryStatus(cert *model.Certificate, now time.Time, windowDays int) model.CertificateStatus {
+	switch {
+	case !cert.ExpirationDate.After(now):
+		return model.CertificateStatusExpired
+	case cert.ExpirationDate.Before(now.AddDate(0, 0, windowDays)):
+		return model.CertificateStatusExpiring
+	default:
+		return cert.Status
+	}
+}
+
+// GetCertificateExpirySummary 按到期分桶统计有效证书的数量，供仪表盘使用，
+// 分桶由到期扫描任务维护，两次扫描之间可能有少量证书仍在较大的分桶中
+func GetCertificateExpirySummary() ([]model.CertificateExpirySummary, error) {
+	return db.GetCertificateExpirySummary()
+}
@@ -0,0 +1,153 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	log "github.com/sirupsen/logrus"
+)
+
+// expiryNoticeExpired 到期当天提醒的阶段
+const expiryNoticeExpired = "expired"
+
+// expiredNoticeLookback 只提醒最近到期的证书，避免首次启用时为历史上过期的证书发送大量提醒
+const expiredNoticeLookback = 7 * 24 * time.Hour
+
+// expiryNoticeDays 解析到期前提醒的天数，从大到小排列
+func expiryNoticeDays() []int {
+	var days []int
+	for _, s := range strings.Split(getSettingStr(conf.CertExpiryNotifyDays, ""), ",") {
+		d, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || d <= 0 {
+			continue
+		}
+		days = append(days, d)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+	return days
+}
+
+// expiryNoticeStage 返回剩余时间对应的提醒阶段，即不小于剩余天数的最小提醒天数
+func expiryNoticeStage(days []int, left time.Duration) (int, bool) {
+	remaining := int(math.Ceil(left.Hours() / 24))
+	stage, ok := 0, false
+	for _, d := range days {
+		if d >= remaining {
+			stage, ok = d, true
+		}
+	}
+	return stage, ok
+}
+
+// NotifyExpiring 在证书到期前的各个提醒阶段和到期后通知所有者、管理员和证书联系人，
+// 每个证书的每个阶段只提醒一次，由到期状态扫描任务调用
+func NotifyExpiring(ctx context.Context) error {
+	days := expiryNoticeDays()
+	if len(days) == 0 || !notify.Enabled() {
+		return nil
+	}
+	admins, err := certificateAdmins()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	certs, err := db.GetCertificatesExpiringBefore(now.AddDate(0, 0, days[0]))
+	if err != nil {
+		return err
+	}
+	for i := range certs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cert := &certs[i]
+		// 已续期的证书由新证书接替，不再提醒
+		if cert.IsSuperseded() {
+			continue
+		}
+		stage, ok := expiryNoticeStage(days, cert.ExpirationDate.Sub(now))
+		if !ok {
+			continue
+		}
+		left := cert.ExpirationDate.Sub(now).Round(time.Hour)
+		sendExpiryNotice(ctx, cert, fmt.Sprintf("%dd", stage), admins, &notify.Message{
+			Event:    "certificate.expiring",
+			Severity: notify.SeverityWarning,
+			Title:    fmt.Sprintf("Certificate %s expires in %s", cert.Name, formatExpiryDuration(left)),
+			Content: fmt.Sprintf("Certificate %s of %s expires at %s, please renew it in time.",
+				cert.Name, cert.Owner, cert.ExpirationDate.Format(time.RFC3339)),
+		})
+	}
+
+	expired, err := db.GetCertificatesExpiredSince(now.Add(-expiredNoticeLookback))
+	if err != nil {
+		return err
+	}
+	for i := range expired {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cert := &expired[i]
+		if cert.IsSuperseded() {
+			continue
+		}
+		sendExpiryNotice(ctx, cert, expiryNoticeExpired, admins, &notify.Message{
+			Event:    "certificate.expired",
+			Severity: notify.SeverityWarning,
+			Title:    fmt.Sprintf("Certificate %s has expired", cert.Name),
+			Content: fmt.Sprintf("Certificate %s of %s expired at %s.",
+				cert.Name, cert.Owner, cert.ExpirationDate.Format(time.RFC3339)),
+		})
+	}
+	return nil
+}
+
+// sendExpiryNotice 先记录提醒再发送，发送失败时删除记录以便下次扫描重试
+func sendExpiryNotice(ctx context.Context, cert *model.Certificate, stage string, admins []string, msg *notify.Message) {
+	claimed, err := db.ClaimCertificateExpiryNotice(cert.ID, stage)
+	if err != nil {
+		log.Errorf("failed to record expiry notice of certificate %d: %+v", cert.ID, err)
+		return
+	}
+	if !claimed {
+		return
+	}
+	msg.To = mergeRecipients([]string{cert.Owner}, admins, getWatchers(model.CertificateWatchTargetCertificate, cert.ID))
+	msg.Emails = certificateContacts(cert, true)
+	if err := notify.Send(ctx, msg); err != nil {
+		log.Warnf("failed to send expiry notice of certificate %d: %+v", cert.ID, err)
+		if err := db.ReleaseCertificateExpiryNotice(cert.ID, stage); err != nil {
+			log.Errorf("failed to release expiry notice of certificate %d: %+v", cert.ID, err)
+		}
+	}
+}
+
+// certificateAdmins 获取接收证书到期提醒的管理员
+func certificateAdmins() ([]string, error) {
+	admins, err := db.GetUsersByRole(model.ADMIN)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(admins))
+	for _, u := range admins {
+		if !u.Disabled {
+			names = append(names, u.Username)
+		}
+	}
+	return names, nil
+}
+
+func formatExpiryDuration(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(d.Hours()/24))
+	}
+	return d.String()
+}
@@ -0,0 +1,172 @@
+package op
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/xlsx"
+)
+
+// 证书清单的导出格式
+const (
+	CertificateExportCSV  = "csv"
+	CertificateExportJSON = "json"
+	CertificateExportXLSX = "xlsx"
+)
+
+// certificateExportFlushRows 每写出多少行刷新一次输出，避免内容积压在缓冲区中
+const certificateExportFlushRows = 500
+
+// certificateExportColumns CSV 和 XLSX 导出的列，不包含证书内容和私钥
+var certificateExportColumns = []string{
+	"id", "name", "type", "status", "owner", "owner_id", "issuer_id", "serial_number", "fingerprint",
+	"issued_date", "expiration_date", "revoked_at", "revocation_reason", "responsible_team", "contact_email",
+}
+
+// CheckCertificateExport 校验导出格式和过滤条件，导出开始写出后无法再返回错误响应
+func CheckCertificateExport(format string, filter *model.CertificateFilter) error {
+	if format != CertificateExportCSV && format != CertificateExportJSON && format != CertificateExportXLSX {
+		return errs.NewErr(errs.InvalidCertificateRequest, "unsupported export format %s", format)
+	}
+	return checkCertificateFilter(filter)
+}
+
+// ExportCertificates 将符合证书列表过滤条件的证书清单逐行写入 w，内存占用与证书数量无关。
+// 响应不经过脱敏中间件的缓冲，operator 无权查看证书内容时由导出自行去掉内容。
+// w 实现了 Flush 时每写出一批证书刷新一次，使大量证书的导出能边查询边发送
+func ExportCertificates(w io.Writer, format string, filter *model.CertificateFilter, operator *model.User) (int, error) {
+	if err := CheckCertificateExport(format, filter); err != nil {
+		return 0, err
+	}
+	flusher, _ := w.(interface{ Flush() })
+	var write func(*model.Certificate) error
+	var finish func() error
+	switch format {
+	case CertificateExportCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(certificateExportColumns); err != nil {
+			return 0, err
+		}
+		write = func(cert *model.Certificate) error {
+			return cw.Write(certificateExportRecord(cert))
+		}
+		finish = func() error {
+			cw.Flush()
+			return cw.Error()
+		}
+		// csv.Writer 自带缓冲，刷新时需先写出到 w
+		next := flusher
+		flusher = flushFunc(func() {
+			cw.Flush()
+			if next != nil {
+				next.Flush()
+			}
+		})
+	case CertificateExportXLSX:
+		xw, err := xlsx.NewWriter(w, "certificates")
+		if err != nil {
+			return 0, err
+		}
+		if err := xw.Write(certificateExportColumns); err != nil {
+			return 0, err
+		}
+		write = func(cert *model.Certificate) error {
+			return xw.Write(certificateExportRecord(cert))
+		}
+		finish = xw.Close
+		// 压缩流同样需要先写出到 w
+		next := flusher
+		flusher = flushFunc(func() {
+			_ = xw.Flush()
+			if next != nil {
+				next.Flush()
+			}
+		})
+	default:
+		// 没有查看证书内容权限的用户导出时去掉证书内容，私钥不会被序列化
+		redact := !operator.CanReadCertificateContent()
+		enc := json.NewEncoder(w)
+		if _, err := io.WriteString(w, "["); err != nil {
+			return 0, err
+		}
+		first := true
+		write = func(cert *model.Certificate) error {
+			if !first {
+				if _, err := io.WriteString(w, ","); err != nil {
+					return err
+				}
+			}
+			first = false
+			if redact {
+				cert.Content = ""
+			}
+			return enc.Encode(cert)
+		}
+		finish = func() error {
+			_, err := io.WriteString(w, "]\n")
+			return err
+		}
+	}
+
+	count := 0
+	err := db.StreamCertificates(filter, func(cert *model.Certificate) error {
+		if err := write(cert); err != nil {
+			return err
+		}
+		count++
+		if count%certificateExportFlushRows == 0 && flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = finish()
+	}
+	outcome := audit.OutcomeSuccess
+	if err != nil {
+		outcome = audit.OutcomeFailure
+	}
+	audit.Emit(&audit.Event{
+		Type:    "certificate.exported",
+		Actor:   operator.Username,
+		Outcome: outcome,
+		Detail:  fmt.Sprintf("format %s, %d certificates", format, count),
+	})
+	return count, err
+}
+
+type flushFunc func()
+
+func (f flushFunc) Flush() { f() }
+
+func certificateExportRecord(cert *model.Certificate) []string {
+	revokedAt := ""
+	if cert.RevokedAt != nil {
+		revokedAt = cert.RevokedAt.Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatUint(uint64(cert.ID), 10),
+		cert.Name,
+		string(cert.Type),
+		string(cert.Status),
+		cert.Owner,
+		strconv.FormatUint(uint64(cert.OwnerID), 10),
+		strconv.FormatUint(uint64(cert.IssuerID), 10),
+		cert.SerialNumber,
+		cert.Fingerprint,
+		cert.IssuedDate.Format(time.RFC3339),
+		cert.ExpirationDate.Format(time.RFC3339),
+		revokedAt,
+		cert.RevocationReason,
+		cert.ResponsibleTeam,
+		cert.ContactEmail,
+	}
+}
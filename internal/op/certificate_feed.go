@@ -0,0 +1,221 @@
+package op
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GetCertificateFeedToken 获取用户的订阅令牌，不存在时生成
+func GetCertificateFeedToken(user *model.User) (*model.CertificateFeedToken, error) {
+	t, err := db.GetCertificateFeedTokenByUserID(user.ID)
+	if err == nil {
+		return t, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return ResetCertificateFeedToken(user)
+}
+
+// ResetCertificateFeedToken 重新生成订阅令牌，旧的订阅地址随即失效
+func ResetCertificateFeedToken(user *model.User) (*model.CertificateFeedToken, error) {
+	t := &model.CertificateFeedToken{
+		UserID:    user.ID,
+		Token:     random.String(32),
+		CreatedAt: time.Now(),
+	}
+	if err := db.SaveCertificateFeedToken(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetUserByCertificateFeedToken 根据订阅令牌获取用户
+func GetUserByCertificateFeedToken(token string) (*model.User, error) {
+	if token == "" {
+		return nil, errs.PermissionDenied
+	}
+	t, err := db.GetCertificateFeedToken(token)
+	if err != nil {
+		return nil, errors.WithMessage(errs.PermissionDenied, "invalid feed token")
+	}
+	user, err := GetUserById(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled || user.IsGuest() {
+		return nil, errs.PermissionDenied
+	}
+	return user, nil
+}
+
+// icsEscape 转义 iCalendar 文本值
+func icsEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// icsFold 按 RFC 5545 将超过 75 字节的行折叠
+func icsFold(line string) string {
+	if len(line) <= 75 {
+		return line + "\r\n"
+	}
+	var buf strings.Builder
+	n := 0
+	for _, r := range line {
+		size := len(string(r))
+		if n+size > 75 {
+			buf.WriteString("\r\n ")
+			n = 1
+		}
+		buf.WriteRune(r)
+		n += size
+	}
+	buf.WriteString("\r\n")
+	return buf.String()
+}
+
+// certificateCalendarAlarmDays 到期提醒的提前天数
+func certificateCalendarAlarmDays() []int {
+	var days []int
+	for _, s := range strings.Split(getSettingStr(conf.CertCalendarAlarmDays, "30,7"), ",") {
+		if d, err := strconv.Atoi(strings.TrimSpace(s)); err == nil && d >= 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// GetCertificateCalendar 生成用户可见证书到期日的 iCalendar 日历，管理员和审计员可见全部证书
+func GetCertificateCalendar(user *model.User) (string, error) {
+	var ownerID uint
+	if !user.CanViewAllCertificates() {
+		ownerID = user.ID
+	}
+	certs, err := db.GetActiveCertificates(ownerID)
+	if err != nil {
+		return "", err
+	}
+	alarms := certificateCalendarAlarmDays()
+	now := time.Now().UTC().Format("20060102T150405Z")
+	var buf strings.Builder
+	buf.WriteString(icsFold("BEGIN:VCALENDAR"))
+	buf.WriteString(icsFold("VERSION:2.0"))
+	buf.WriteString(icsFold("PRODID:-//OpenList//Certificates//EN"))
+	buf.WriteString(icsFold("CALSCALE:GREGORIAN"))
+	buf.WriteString(icsFold("X-WR-CALNAME:Certificate expirations"))
+	for _, cert := range certs {
+		expire := cert.ExpirationDate
+		buf.WriteString(icsFold("BEGIN:VEVENT"))
+		buf.WriteString(icsFold(fmt.Sprintf("UID:certificate-%d@openlist", cert.ID)))
+		buf.WriteString(icsFold("DTSTAMP:" + now))
+		buf.WriteString(icsFold("DTSTART;VALUE=DATE:" + expire.Format("20060102")))
+		buf.WriteString(icsFold("DTEND;VALUE=DATE:" + expire.AddDate(0, 0, 1).Format("20060102")))
+		buf.WriteString(icsFold("SUMMARY:" + icsEscape(fmt.Sprintf("Certificate %s expires", cert.Name))))
+		buf.WriteString(icsFold("DESCRIPTION:" + icsEscape(fmt.Sprintf("Type: %s\nOwner: %s\nExpires at: %s",
+			cert.Type, cert.Owner, expire.Format(time.RFC3339)))))
+		for _, d := range alarms {
+			buf.WriteString(icsFold("BEGIN:VALARM"))
+			buf.WriteString(icsFold("ACTION:DISPLAY"))
+			buf.WriteString(icsFold(fmt.Sprintf("TRIGGER:-P%dD", d)))
+			buf.WriteString(icsFold("DESCRIPTION:" + icsEscape(fmt.Sprintf("Certificate %s expires in %d days", cert.Name, d))))
+			buf.WriteString(icsFold("END:VALARM"))
+		}
+		buf.WriteString(icsFold("END:VEVENT"))
+	}
+	buf.WriteString(icsFold("END:VCALENDAR"))
+	return buf.String(), nil
+}
+
+// certificateFeedEvents 事件源中包含的生命周期事件
+var certificateFeedEvents = map[string]string{
+	"certificate.created": "created",
+	"certificate.issued":  "issued",
+	"certificate.renewed": "renewed",
+	"certificate.revoked": "revoked",
+	"certificate.held":    "held",
+	"certificate.unheld":  "unheld",
+	"certificate.deleted": "deleted",
+}
+
+// GetCertificateFeed 获取最近的签发、吊销和到期事件，管理员和审计员获取全部证书的事件
+func GetCertificateFeed(user *model.User, days, limit int) ([]model.CertificateFeedEntry, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	var ownerID uint
+	var certIDs []uint
+	if !user.CanViewAllCertificates() {
+		ownerID = user.ID
+		ids, err := db.GetCertificateIDsByOwnerID(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		certIDs = ids
+	}
+	events, err := db.GetCertificateEventsSince(certIDs, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.CertificateID)
+	}
+	certs, err := db.GetCertificatesByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[uint]string, len(certs))
+	for _, cert := range certs {
+		names[cert.ID] = cert.Name
+	}
+	entries := make([]model.CertificateFeedEntry, 0, len(events))
+	for _, e := range events {
+		action, ok := certificateFeedEvents[e.Event]
+		if !ok {
+			continue
+		}
+		summary := fmt.Sprintf("by %s", e.Actor)
+		if e.Detail != "" {
+			summary += ": " + e.Detail
+		}
+		entries = append(entries, model.CertificateFeedEntry{
+			ID:            fmt.Sprintf("event-%d", e.ID),
+			Time:          e.CreatedAt,
+			Event:         e.Event,
+			CertificateID: e.CertificateID,
+			Title:         fmt.Sprintf("Certificate %s %s", names[e.CertificateID], action),
+			Summary:       summary,
+		})
+	}
+	// 到期没有对应的事件记录，按证书到期时间生成
+	expired, err := db.GetCertificatesExpiredBetween(ownerID, since, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range expired {
+		entries = append(entries, model.CertificateFeedEntry{
+			ID:            fmt.Sprintf("expired-%d", cert.ID),
+			Time:          cert.ExpirationDate,
+			Event:         "certificate.expired",
+			CertificateID: cert.ID,
+			Title:         fmt.Sprintf("Certificate %s expired", cert.Name),
+			Summary:       fmt.Sprintf("%s certificate of %s expired", cert.Type, cert.Owner),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries, nil
+}
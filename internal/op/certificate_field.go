@@ -0,0 +1,110 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+var GetCertificateRequestFieldByID = db.GetCertificateRequestFieldByID
+var DeleteCertificateRequestField = db.DeleteCertificateRequestField
+
+// GetCertificateRequestFields 获取适用于指定证书类型的自定义字段，类型为空时返回全部
+func GetCertificateRequestFields(reqType model.CertificateType) ([]model.CertificateRequestField, error) {
+	fields, err := db.GetCertificateRequestFields()
+	if err != nil {
+		return nil, err
+	}
+	if reqType == "" {
+		return fields, nil
+	}
+	res := make([]model.CertificateRequestField, 0, len(fields))
+	for _, f := range fields {
+		if f.AppliesTo(reqType) {
+			res = append(res, f)
+		}
+	}
+	return res, nil
+}
+
+func checkCertificateRequestField(f *model.CertificateRequestField) error {
+	f.Key = strings.TrimSpace(f.Key)
+	if f.Key == "" {
+		return fmt.Errorf("field key is required")
+	}
+	switch f.FieldType {
+	case model.CertificateFieldText, model.CertificateFieldCheckbox:
+	case model.CertificateFieldSelect:
+		if len(f.Options) == 0 {
+			return fmt.Errorf("select field %s requires options", f.Key)
+		}
+	default:
+		return fmt.Errorf("invalid field type: %s", f.FieldType)
+	}
+	fields, err := db.GetCertificateRequestFields()
+	if err != nil {
+		return err
+	}
+	for _, other := range fields {
+		if other.ID != f.ID && other.Key == f.Key && (other.Type == "" || f.Type == "" || other.Type == f.Type) {
+			return errs.NewErr(errs.CertificateConflict, "field key %s already exists", f.Key)
+		}
+	}
+	return nil
+}
+
+func CreateCertificateRequestField(f *model.CertificateRequestField) error {
+	if err := checkCertificateRequestField(f); err != nil {
+		return err
+	}
+	return db.CreateCertificateRequestField(f)
+}
+
+func UpdateCertificateRequestField(f *model.CertificateRequestField) error {
+	if err := checkCertificateRequestField(f); err != nil {
+		return err
+	}
+	return db.UpdateCertificateRequestField(f)
+}
+
+// validateCustomFields 校验申请提交的自定义字段，返回只包含已定义字段的值
+func validateCustomFields(reqType model.CertificateType, values map[string]string) (map[string]string, error) {
+	fields, err := GetCertificateRequestFields(reqType)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string]string, len(fields))
+	for _, f := range fields {
+		v := strings.TrimSpace(values[f.Key])
+		switch f.FieldType {
+		case model.CertificateFieldCheckbox:
+			if v == "" {
+				v = "false"
+			}
+			if v != "true" && v != "false" {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "field %s must be true or false", f.Key)
+			}
+			if f.Required && v != "true" {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "field %s must be checked", f.Key)
+			}
+		case model.CertificateFieldSelect:
+			if v != "" && !utils.SliceContains(f.Options, v) {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "field %s must be one of %s", f.Key, strings.Join(f.Options, ","))
+			}
+		}
+		if f.Required && v == "" {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "field %s is required", f.Key)
+		}
+		res[f.Key] = v
+	}
+	for k := range values {
+		if _, ok := res[k]; !ok {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "unknown field %s", k)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,144 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GetCertificateFreezeWindows 管理员和审计员获取全部冻结窗口，其他用户获取所在组织和全局的冻结窗口
+func GetCertificateFreezeWindows(user *model.User) ([]model.CertificateFreezeWindow, error) {
+	if user.CanViewAllCertificates() {
+		return db.GetCertificateFreezeWindows(nil)
+	}
+	return db.GetCertificateFreezeWindows(&user.Organization)
+}
+
+// checkFreezeWindowPermission 管理员可以管理所有组织的冻结窗口，组织管理员只能管理本组织的
+func checkFreezeWindowPermission(user *model.User, organization string) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	if user.CanManageFreezeWindows() && user.Organization != "" && user.Organization == organization {
+		return nil
+	}
+	return errs.PermissionDenied
+}
+
+func checkCertificateFreezeWindow(w *model.CertificateFreezeWindow) error {
+	w.Title = strings.TrimSpace(w.Title)
+	w.Organization = strings.TrimSpace(w.Organization)
+	if w.Title == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "title is required")
+	}
+	if !w.EndAt.After(w.StartAt) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "end time must be after start time")
+	}
+	return nil
+}
+
+func CreateCertificateFreezeWindow(user *model.User, w *model.CertificateFreezeWindow) error {
+	if err := checkCertificateFreezeWindow(w); err != nil {
+		return err
+	}
+	if err := checkFreezeWindowPermission(user, w.Organization); err != nil {
+		return err
+	}
+	w.CreatedBy = user.Username
+	if err := db.CreateCertificateFreezeWindow(w); err != nil {
+		return err
+	}
+	auditFreezeWindow("certificate.freeze_window.created", user, w)
+	return nil
+}
+
+func UpdateCertificateFreezeWindow(user *model.User, w *model.CertificateFreezeWindow) error {
+	old, err := db.GetCertificateFreezeWindowByID(w.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkFreezeWindowPermission(user, old.Organization); err != nil {
+		return err
+	}
+	if err := checkCertificateFreezeWindow(w); err != nil {
+		return err
+	}
+	if err := checkFreezeWindowPermission(user, w.Organization); err != nil {
+		return err
+	}
+	w.CreatedBy = old.CreatedBy
+	w.CreatedAt = old.CreatedAt
+	if err := db.UpdateCertificateFreezeWindow(w); err != nil {
+		return err
+	}
+	auditFreezeWindow("certificate.freeze_window.updated", user, w)
+	return nil
+}
+
+func DeleteCertificateFreezeWindow(user *model.User, id uint) error {
+	w, err := db.GetCertificateFreezeWindowByID(id)
+	if err != nil {
+		return err
+	}
+	if err := checkFreezeWindowPermission(user, w.Organization); err != nil {
+		return err
+	}
+	if err := db.DeleteCertificateFreezeWindow(id); err != nil {
+		return err
+	}
+	auditFreezeWindow("certificate.freeze_window.deleted", user, w)
+	return nil
+}
+
+func auditFreezeWindow(event string, user *model.User, w *model.CertificateFreezeWindow) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  user.Username,
+		Target: fmt.Sprintf("freeze_window:%d", w.ID),
+		Detail: fmt.Sprintf("%s: %s - %s, organization %q", w.Title, w.StartAt.Format(time.RFC3339), w.EndAt.Format(time.RFC3339), w.Organization),
+	})
+}
+
+// activeFreezeWindow 获取 t 时刻对用户所在组织生效的冻结窗口，有多个时优先返回不允许例外的
+func activeFreezeWindow(userID uint, t time.Time) (*model.CertificateFreezeWindow, error) {
+	var organization string
+	u, err := db.GetUserById(userID)
+	if err == nil {
+		organization = u.Organization
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	windows, err := db.GetActiveCertificateFreezeWindows(organization, t)
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+	for i := range windows {
+		if !windows[i].AllowOverride {
+			return &windows[i], nil
+		}
+	}
+	return &windows[0], nil
+}
+
+// checkIssuanceFreeze 冻结期间禁止签发，允许例外的窗口可由管理员显式例外批准，返回是否使用了例外
+func checkIssuanceFreeze(userID uint, t time.Time, override bool) (bool, error) {
+	w, err := activeFreezeWindow(userID, t)
+	if err != nil || w == nil {
+		return false, err
+	}
+	if w.AllowOverride && override {
+		return true, nil
+	}
+	msg := "issuance is frozen by %q until %s, schedule the issuance after the freeze window"
+	if w.AllowOverride {
+		msg += " or approve with a freeze override"
+	}
+	return false, errs.NewErr(errs.InvalidCertificateRequest, msg, w.Title, w.EndAt.Format(time.RFC3339))
+}
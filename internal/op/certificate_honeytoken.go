@@ -0,0 +1,93 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+var GetCertificateHoneytokens = db.GetCertificateHoneytokens
+
+// MarkCertificateHoneytoken 将证书标记为诱饵证书。只写入审计日志，不记录到证书时间线，避免所有者察觉
+func MarkCertificateHoneytoken(certID uint, note string, operator *model.User) (*model.CertificateHoneytoken, error) {
+	if _, err := db.GetCertificateByID(certID); err != nil {
+		return nil, err
+	}
+	if _, err := db.GetCertificateHoneytoken(certID); err == nil {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate %d is already a honeytoken", certID)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	token := &model.CertificateHoneytoken{
+		CertificateID: certID,
+		Note:          note,
+		CreatedBy:     operator.Username,
+	}
+	if err := db.CreateCertificateHoneytoken(token); err != nil {
+		return nil, err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.honeytoken.marked",
+		Actor:  operator.Username,
+		Target: fmt.Sprintf("certificate:%d", certID),
+		Detail: note,
+	})
+	return token, nil
+}
+
+// UnmarkCertificateHoneytoken 取消诱饵证书标记
+func UnmarkCertificateHoneytoken(certID uint, operator *model.User) error {
+	if err := db.DeleteCertificateHoneytoken(certID); err != nil {
+		return err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.honeytoken.unmarked",
+		Actor:  operator.Username,
+		Target: fmt.Sprintf("certificate:%d", certID),
+	})
+	return nil
+}
+
+// TripCertificateHoneytoken 证书被下载或查询状态时调用，诱饵证书会立即向安全审查人告警，不做告警去重。
+// source 为触发方式，如 download、ocsp
+func TripCertificateHoneytoken(cert *model.Certificate, actor, source, ip string) {
+	token, err := db.GetCertificateHoneytoken(cert.ID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Warnf("failed get honeytoken of certificate %d: %+v", cert.ID, err)
+		}
+		return
+	}
+	now := time.Now()
+	if err := db.TripCertificateHoneytoken(cert.ID, now); err != nil {
+		log.Warnf("failed update honeytoken of certificate %d: %+v", cert.ID, err)
+	}
+	if actor == "" {
+		actor = "anonymous"
+	}
+	detail := fmt.Sprintf("%s by %s from %s", source, actor, ip)
+	audit.Emit(&audit.Event{
+		Type:    "certificate.honeytoken.tripped",
+		Actor:   actor,
+		Target:  fmt.Sprintf("certificate:%d", cert.ID),
+		Outcome: audit.OutcomeFailure,
+		IP:      ip,
+		Detail:  detail,
+	})
+	log.Warnf("honeytoken certificate %s tripped: %s", cert.Name, detail)
+	sendAsync(&notify.Message{
+		Event:    "certificate.honeytoken.tripped",
+		Severity: notify.SeverityCritical,
+		Title:    fmt.Sprintf("Honeytoken certificate %s was accessed", cert.Name),
+		Content:  fmt.Sprintf("Decoy certificate %s was accessed: %s at %s.\nNote: %s", cert.Name, detail, now.Format(time.RFC1123), token.Note),
+		To:       anomalyReviewers(),
+	})
+}
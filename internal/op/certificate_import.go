@@ -0,0 +1,185 @@
+package op
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// certificateImportBatchSize 每个事务写入的证书数量
+const certificateImportBatchSize = 500
+
+// maxCertificateImportErrors 导入结果中最多返回的错误数量
+const maxCertificateImportErrors = 100
+
+// importableCertificateStatuses 允许导入的证书状态
+var importableCertificateStatuses = map[model.CertificateStatus]bool{
+	model.CertificateStatusValid:    true,
+	model.CertificateStatusExpiring: true,
+	model.CertificateStatusExpired:  true,
+	model.CertificateStatusRevoked:  true,
+	model.CertificateStatusHold:     true,
+}
+
+// certificateImporter 在一次导入中缓存类型、用户和 CA 的查询结果
+type certificateImporter struct {
+	operator *model.User
+	result   *model.CertificateImportResult
+	batch    []model.Certificate
+	types    map[model.CertificateType]error
+	owners   map[string]uint
+	issuers  map[uint]bool
+}
+
+// ImportCertificates 从 JSON 数组（与 json 格式的导出相同）中逐条读取证书并分批写入，
+// 每批在一个事务中写入，指纹已存在的证书跳过。证书 ID、续期关系和下载统计不会导入，
+// 所有者按用户名重新关联，签发 CA 不存在时置为 0
+func ImportCertificates(r io.Reader, operator *model.User) (*model.CertificateImportResult, error) {
+	im := &certificateImporter{
+		operator: operator,
+		result:   &model.CertificateImportResult{Failed: []model.CertificateImportError{}},
+		types:    map[model.CertificateType]error{},
+		owners:   map[string]uint{},
+		issuers:  map[uint]bool{},
+	}
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "import file must be a JSON array of certificates")
+	}
+	for index := 0; dec.More(); index++ {
+		var cert model.Certificate
+		if err := dec.Decode(&cert); err != nil {
+			// JSON 格式错误后无法继续定位下一条记录，已写入的批次保留
+			return im.result, errs.NewErr(errs.InvalidCertificateRequest, "invalid certificate #%d: %v", index, err)
+		}
+		if err := im.prepare(&cert); err != nil {
+			im.fail(index, cert.Name, err)
+			continue
+		}
+		im.batch = append(im.batch, cert)
+		if len(im.batch) >= certificateImportBatchSize {
+			if err := im.flush(); err != nil {
+				return im.result, err
+			}
+		}
+	}
+	if err := im.flush(); err != nil {
+		return im.result, err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.imported",
+		Actor:  operator.Username,
+		Detail: fmt.Sprintf("%d imported, %d skipped, %d failed", im.result.Imported, im.result.Skipped, len(im.result.Failed)),
+	})
+	return im.result, nil
+}
+
+// prepare 校验一条导入的证书并清除不能跨实例沿用的字段
+func (im *certificateImporter) prepare(cert *model.Certificate) error {
+	certs, err := parseCertificates(cert.Content)
+	if err != nil {
+		return err
+	}
+	fillCertificateIdentity(cert)
+	if cert.Fingerprint == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "content must start with a certificate")
+	}
+	if strings.TrimSpace(cert.Name) == "" {
+		cert.Name = certs[0].Subject.CommonName
+	}
+	if cert.Name == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "name is required")
+	}
+	if cert.Status == "" {
+		cert.Status = model.CertificateStatusValid
+	}
+	if !importableCertificateStatuses[cert.Status] {
+		return errs.NewErr(errs.InvalidCertificateRequest, "status %s can not be imported", cert.Status)
+	}
+	if err := im.checkType(cert.Type); err != nil {
+		return err
+	}
+	if err := checkCertificateContacts(cert); err != nil {
+		return err
+	}
+	if cert.IssuedDate.IsZero() {
+		cert.IssuedDate = certs[0].NotBefore
+	}
+	if cert.ExpirationDate.IsZero() {
+		cert.ExpirationDate = certs[0].NotAfter
+	}
+	cert.OwnerID = im.ownerID(cert.Owner)
+	if cert.IssuerID != 0 && !im.issuerExists(cert.IssuerID) {
+		cert.IssuerID = 0
+	}
+	cert.ID, cert.RequestID, cert.SupersedesID, cert.SupersededByID = 0, 0, 0, 0
+	cert.PrivateKey = ""
+	cert.DownloadCount, cert.LastDownloadedAt, cert.LastDownloadedBy = 0, nil, ""
+	cert.DeletedAt = gorm.DeletedAt{}
+	return nil
+}
+
+func (im *certificateImporter) checkType(t model.CertificateType) error {
+	if err, ok := im.types[t]; ok {
+		return err
+	}
+	_, err := CheckCertificateType(t)
+	im.types[t] = err
+	return err
+}
+
+func (im *certificateImporter) ownerID(owner string) uint {
+	if id, ok := im.owners[owner]; ok {
+		return id
+	}
+	var id uint
+	if user, err := db.GetUserByName(owner); err == nil {
+		id = user.ID
+	}
+	im.owners[owner] = id
+	return id
+}
+
+func (im *certificateImporter) issuerExists(id uint) bool {
+	if ok, cached := im.issuers[id]; cached {
+		return ok
+	}
+	_, err := db.GetCertificateAuthorityByID(id)
+	im.issuers[id] = err == nil
+	return err == nil
+}
+
+func (im *certificateImporter) fail(index int, name string, err error) {
+	if len(im.result.Failed) < maxCertificateImportErrors {
+		im.result.Failed = append(im.result.Failed, model.CertificateImportError{Index: index, Name: name, Error: err.Error()})
+	}
+}
+
+// flush 写入当前批次
+func (im *certificateImporter) flush() error {
+	if len(im.batch) == 0 {
+		return nil
+	}
+	n, err := db.ImportCertificateBatch(im.batch, func(cert *model.Certificate) model.CertificateEvent {
+		return model.CertificateEvent{
+			CertificateID: cert.ID,
+			Event:         "certificate.imported",
+			Actor:         im.operator.Username,
+		}
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed import certificates after %d imported", im.result.Imported)
+	}
+	im.result.Imported += n
+	im.result.Skipped += len(im.batch) - n
+	im.batch = im.batch[:0]
+	return nil
+}
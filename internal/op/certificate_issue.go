@@ -0,0 +1,200 @@
+package op
+
+import (
+	"crypto"
+	"crypto/x509"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+)
+
+// maxCAChainDepth 查找上级 CA 的最大层数
+const maxCAChainDepth = 8
+
+// certificateExtKeyUsages 各证书类型的扩展密钥用途，未列出的类型只用于客户端认证
+var certificateExtKeyUsages = map[model.CertificateType][]x509.ExtKeyUsage{
+	model.CertificateTypeUser: {x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageEmailProtection},
+	model.CertificateTypeNode: {x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+}
+
+// certificateIssuer 获取 CA 证书、私钥和上级中间证书，根证书不放入证书链
+func certificateIssuer(caID uint) (*certissuer.Issuer, *model.CertificateAuthority, error) {
+	if caID == 0 {
+		return nil, nil, errs.NewErr(errs.InvalidCertificateRequest, "no active certificate authority is available for issuance")
+	}
+	ca, err := db.GetCertificateAuthorityByID(caID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ca.HasPrivateKey() {
+		return nil, nil, errs.NewErr(errs.InvalidCertificateRequest, "the private key of certificate authority %s is not held by OpenList", ca.Name)
+	}
+	certs, err := parseCertificates(ca.Content)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKey(ca.Content, ca.PrivateKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	issuer := &certissuer.Issuer{Certificate: certs[0], Key: key}
+	parents, err := certificateAuthorityParents(ca)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, parent := range parents {
+		if parent.Kind == model.CertificateAuthorityRoot {
+			break
+		}
+		parentCerts, err := parseCertificates(parent.Content)
+		if err != nil {
+			break
+		}
+		issuer.Chain = append(issuer.Chain, parentCerts[0])
+	}
+	return issuer, ca, nil
+}
+
+// certificateAuthorityParents 按主题查找在用的上级 CA，依次为直接上级到根证书
+func certificateAuthorityParents(ca *model.CertificateAuthority) ([]model.CertificateAuthority, error) {
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	var parents []model.CertificateAuthority
+	for current, i := ca, 0; current.Subject != current.Issuer && i < maxCAChainDepth; i++ {
+		var parent *model.CertificateAuthority
+		for j := range cas {
+			// 交叉证书只出现在备用链中，见 CertificateChainOptions
+			if cas[j].Subject == current.Issuer && cas[j].ID != current.ID && !cas[j].IsCrossSigned() {
+				parent = &cas[j]
+				if parent.Kind == model.CertificateAuthorityRoot {
+					break
+				}
+			}
+		}
+		if parent == nil {
+			break
+		}
+		parents = append(parents, *parent)
+		current = parent
+	}
+	return parents, nil
+}
+
+// generateCertificate 生成密钥对并由证书的 CA 签发，填充证书内容、私钥、序列号和指纹，
+// 到期时间超过 CA 有效期时缩短到 CA 到期。publicKey 不为空时为申请人提供的公钥，服务端不保存私钥。
+// 公开信任的证书通过 ACME 由公共 CA 签发
+func generateCertificate(cert *model.Certificate, commonName string, sans []string, publicKey crypto.PublicKey) error {
+	// SSH 证书由 SSH CA 在 OpenList 之外签发，内容由管理员上传
+	if cert.Type == model.CertificateTypeSSH {
+		return nil
+	}
+	if cert.Type == model.CertificateTypePublic {
+		return issueACMECertificate(cert, sans, publicKey)
+	}
+	issuer, _, err := certificateIssuer(cert.IssuerID)
+	if err != nil {
+		return err
+	}
+	return signCertificate(issuer, cert, commonName, sans, publicKey)
+}
+
+// signCertificate 由 issuer 签发证书并填充证书内容、私钥、序列号和指纹，到期时间超过 CA 有效期时缩短到 CA 到期
+func signCertificate(issuer *certissuer.Issuer, cert *model.Certificate, commonName string, sans []string, publicKey crypto.PublicKey) error {
+	req, err := issuanceRequest(issuer, cert, commonName, sans, publicKey)
+	if err != nil {
+		return err
+	}
+	cert.ExpirationDate = req.NotAfter
+	if err := checkIssuanceLints(issuer, cert, req); err != nil {
+		return err
+	}
+	var res *certissuer.Result
+	err = runSigning(func() error {
+		var err error
+		res, err = issuer.Issue(req)
+		return err
+	})
+	if IsSigningBusy(err) {
+		return err
+	}
+	if err != nil {
+		return errs.NewErr(errs.InvalidCertificateRequest, "failed issue certificate %s: %v", cert.Name, err)
+	}
+	cert.Content = res.CertificatePEM
+	cert.PrivateKey = res.PrivateKeyPEM
+	fillCertificateIdentity(cert)
+	return nil
+}
+
+// issuanceRequest 按证书、签发配置和全局设置生成签发请求，到期时间不超过 CA 到期
+func issuanceRequest(issuer *certissuer.Issuer, cert *model.Certificate, commonName string, sans []string, publicKey crypto.PublicKey) (*certissuer.Request, error) {
+	keyAlgorithm, usages, err := certificateIssuance(cert)
+	if err != nil {
+		return nil, err
+	}
+	notAfter := cert.ExpirationDate
+	if notAfter.After(issuer.Certificate.NotAfter) {
+		notAfter = issuer.Certificate.NotAfter
+	}
+	return &certissuer.Request{
+		CommonName:   commonName,
+		SANs:         sans,
+		KeyAlgorithm: keyAlgorithm,
+		ExtKeyUsage:  usages,
+		NotBefore:    cert.IssuedDate.Truncate(time.Second),
+		NotAfter:     notAfter,
+		PublicKey:    publicKey,
+	}, nil
+}
+
+// certificateIssuance 返回签发证书使用的密钥算法和扩展密钥用途，
+// 签发配置指定的优先于全局设置和证书类型
+func certificateIssuance(cert *model.Certificate) (string, []x509.ExtKeyUsage, error) {
+	usages, ok := certificateExtKeyUsages[cert.Type]
+	if !ok {
+		usages = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	keyAlgorithm := getSettingStr(conf.CertKeyAlgorithm, certissuer.KeyECDSAP256)
+	profileKeyAlgorithm, profileUsages, err := certificateProfileIssuance(cert)
+	if err != nil {
+		return "", nil, err
+	}
+	if profileKeyAlgorithm != "" {
+		keyAlgorithm = profileKeyAlgorithm
+	}
+	if len(profileUsages) > 0 {
+		usages = profileUsages
+	}
+	return keyAlgorithm, usages, nil
+}
+
+// regenerateCertificate 续期或重新签发时沿用原证书的主题和备用名称，
+// 私钥由申请人保管的证书沿用原证书的公钥
+func regenerateCertificate(old, cert *model.Certificate) error {
+	commonName, sans := old.Owner, []string{}
+	var publicKey crypto.PublicKey
+	if certs, err := parseCertificates(old.Content); err == nil {
+		c := certs[0]
+		if !old.HasPrivateKey() {
+			publicKey = c.PublicKey
+		}
+		commonName = c.Subject.CommonName
+		sans = append(sans, c.DNSNames...)
+		sans = append(sans, c.EmailAddresses...)
+		for _, ip := range c.IPAddresses {
+			sans = append(sans, ip.String())
+		}
+		for _, u := range c.URIs {
+			sans = append(sans, u.String())
+		}
+	} else if req, err := db.GetCertificateRequestByID(old.RequestID); err == nil {
+		sans = req.SANs
+	}
+	return generateCertificate(cert, commonName, sans, publicKey)
+}
@@ -0,0 +1,220 @@
+package op
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// maxJWTRenewalChain 沿续期关系查找签名证书的最大步数，防止数据异常时无限循环
+const maxJWTRenewalChain = 32
+
+// jwtSigningKey 可用于签名的证书及其私钥
+type jwtSigningKey struct {
+	cert   *model.Certificate
+	x509   *x509.Certificate
+	signer crypto.Signer
+	method jwt.SigningMethod
+	kid    string
+}
+
+// jwtSigningMethod 按私钥类型选择签名算法
+func jwtSigningMethod(key crypto.Signer) (jwt.SigningMethod, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PrivateKey:
+		return jwt.SigningMethodEdDSA, nil
+	}
+	return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported private key type %T for JWT", key)
+}
+
+// getJWTCertificates 获取配置的证书所在续期链上的全部证书，按签发先后排列
+func getJWTCertificates() ([]*model.Certificate, error) {
+	id := getSettingInt(conf.CertJWTCertificate, 0)
+	if id <= 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "JWT signing is disabled")
+	}
+	cert, err := db.GetCertificateByID(uint(id))
+	if err != nil {
+		return nil, err
+	}
+	certs := []*model.Certificate{cert}
+	for c, i := cert, 0; c.SupersedesID != 0 && i < maxJWTRenewalChain; i++ {
+		if c, err = db.GetCertificateByID(c.SupersedesID); err != nil {
+			break
+		}
+		certs = append([]*model.Certificate{c}, certs...)
+	}
+	for c, i := cert, 0; c.IsSuperseded() && i < maxJWTRenewalChain; i++ {
+		if c, err = db.GetCertificateByID(c.SupersededByID); err != nil {
+			break
+		}
+		certs = append(certs, c)
+	}
+	return certs, nil
+}
+
+// getJWTSigningKeys 获取续期链上有效且服务端保管了私钥的证书，新签发的证书在前
+func getJWTSigningKeys() ([]*jwtSigningKey, error) {
+	certs, err := getJWTCertificates()
+	if err != nil {
+		return nil, err
+	}
+	var keys []*jwtSigningKey
+	for i := len(certs) - 1; i >= 0; i-- {
+		cert := certs[i]
+		if !cert.IsValid() || cert.IsExpired() || !cert.HasPrivateKey() {
+			continue
+		}
+		parsed, err := parseCertificates(cert.Content)
+		if err != nil {
+			continue
+		}
+		signer, err := parsePrivateKey(cert.Content, cert.PrivateKey)
+		if err != nil {
+			continue
+		}
+		method, err := jwtSigningMethod(signer)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, &jwtSigningKey{
+			cert:   cert,
+			x509:   parsed[0],
+			signer: signer,
+			method: method,
+			kid:    certificateFingerprint(parsed[0]),
+		})
+	}
+	return keys, nil
+}
+
+// GetJSONWebKeySet 获取用于校验令牌的公钥集合，续期后旧证书在过期前仍然发布，已签发的令牌可以继续校验
+func GetJSONWebKeySet() (*model.JSONWebKeySet, error) {
+	set := &model.JSONWebKeySet{Keys: []model.JSONWebKey{}}
+	if getSettingInt(conf.CertJWTCertificate, 0) <= 0 {
+		return set, nil
+	}
+	keys, err := getJWTSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, jsonWebKey(key))
+	}
+	return set, nil
+}
+
+func jsonWebKey(key *jwtSigningKey) model.JSONWebKey {
+	b64 := base64.RawURLEncoding.EncodeToString
+	sum := sha256.Sum256(key.x509.Raw)
+	jwk := model.JSONWebKey{
+		Kid:     key.kid,
+		Use:     "sig",
+		Alg:     key.method.Alg(),
+		X5c:     []string{base64.StdEncoding.EncodeToString(key.x509.Raw)},
+		X5tS256: b64(sum[:]),
+	}
+	switch pub := key.signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = b64(pub.N.Bytes())
+		jwk.E = b64(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = pub.Curve.Params().Name
+		jwk.X = b64(pub.X.FillBytes(make([]byte, size)))
+		jwk.Y = b64(pub.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = b64(pub)
+	}
+	return jwk
+}
+
+// SignJWT 使用续期链上最新的签名证书签发令牌，iss、iat、nbf、exp 和 jti 由服务端设置
+func SignJWT(subject string, audience []string, ttl time.Duration, claims map[string]any, operator *model.User) (*model.CertificateJWT, error) {
+	if subject == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "subject is required")
+	}
+	maxTTL := time.Duration(getSettingInt(conf.CertJWTMaxTTL, 3600)) * time.Second
+	if ttl <= 0 || ttl > maxTTL {
+		ttl = maxTTL
+	}
+	keys, err := getJWTSigningKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "no valid certificate with a server-held key is available for JWT signing")
+	}
+	key := keys[0]
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+	// 令牌不应比签名证书活得更久，否则证书过期后将无法校验
+	if expiresAt.After(key.cert.ExpirationDate) {
+		expiresAt = key.cert.ExpirationDate
+	}
+	mapClaims := jwt.MapClaims{}
+	for k, v := range claims {
+		mapClaims[k] = v
+	}
+	jti := random.String(16)
+	mapClaims["iss"] = getSettingStr(conf.CertJWTIssuer, "openlist")
+	mapClaims["sub"] = subject
+	mapClaims["iat"] = now.Unix()
+	mapClaims["nbf"] = now.Unix()
+	mapClaims["exp"] = expiresAt.Unix()
+	mapClaims["jti"] = jti
+	if len(audience) > 0 {
+		mapClaims["aud"] = audience
+	} else {
+		delete(mapClaims, "aud")
+	}
+	token := jwt.NewWithClaims(key.method, mapClaims)
+	token.Header["kid"] = key.kid
+	signed, err := token.SignedString(key.signer)
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "failed sign JWT with certificate %d: %v", key.cert.ID, err)
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.jwt.issued",
+		Actor:  operator.Username,
+		Target: fmt.Sprintf("certificate:%d", key.cert.ID),
+		Detail: fmt.Sprintf("jti %s sub %s expires %s", jti, subject, expiresAt.Format(time.RFC3339)),
+	})
+	return &model.CertificateJWT{
+		Token:         signed,
+		Kid:           key.kid,
+		CertificateID: key.cert.ID,
+		ExpiresAt:     expiresAt,
+	}, nil
+}
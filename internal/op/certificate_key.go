@@ -0,0 +1,52 @@
+package op
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+)
+
+// parsePrivateKey 解析 PEM 格式的私钥并确认与证书的公钥匹配，certContent 中第一张证书为私钥对应的证书
+func parsePrivateKey(certContent, content string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(strings.TrimSpace(content)))
+	if block == nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "private key is not PEM encoded")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid private key: %v", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported private key type %T", key)
+	}
+	certs, err := parseCertificates(certContent)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !pub.Equal(certs[0].PublicKey) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "private key does not match the certificate %s", certs[0].Subject)
+	}
+	switch signer.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+	default:
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported private key type %T", key)
+	}
+	return signer, nil
+}
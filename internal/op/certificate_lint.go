@@ -0,0 +1,140 @@
+package op
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/OpenListTeam/OpenList/v4/pkg/certlint"
+)
+
+// lintKey 签发预检证书使用的临时密钥，预检证书只用于检查，不保存也不对外提供
+var lintKey = sync.OnceValues(func() (crypto.Signer, error) {
+	return certissuer.GenerateKey(certissuer.KeyECDSAP256)
+})
+
+// lintIssuer 生成与 issuer 主题、密钥标识符和有效期相同，但由临时密钥签名的 CA，
+// 预检证书的签发者字段与正式签发一致，CA 私钥不会为未通过检查的证书签名
+func lintIssuer(issuer *certissuer.Issuer) (*certissuer.Issuer, error) {
+	key, err := lintKey()
+	if err != nil {
+		return nil, err
+	}
+	ca := issuer.Certificate
+	tmpl := &x509.Certificate{
+		SerialNumber:          ca.SerialNumber,
+		RawSubject:            ca.RawSubject,
+		SubjectKeyId:          ca.SubjectKeyId,
+		NotBefore:             ca.NotBefore,
+		NotAfter:              ca.NotAfter,
+		KeyUsage:              ca.KeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &certissuer.Issuer{Certificate: cert, Key: key}, nil
+}
+
+// lintIssuance 检查按 req 将要签发的证书。没有申请人公钥时使用临时密钥的公钥代替，
+// 服务端生成的密钥由 cert_key_algorithm 限定为合规的算法，不影响检查结果
+func lintIssuance(issuer *certissuer.Issuer, req *certissuer.Request) ([]certlint.Finding, error) {
+	li, err := lintIssuer(issuer)
+	if err != nil {
+		return nil, err
+	}
+	r := *req
+	if r.PublicKey == nil {
+		r.PublicKey = li.Key.Public()
+	}
+	res, err := li.Issue(&r)
+	if err != nil {
+		return nil, err
+	}
+	return certlint.Lint(res.Certificate), nil
+}
+
+// certificateLintBlockSeverity 阻止签发的最低检查级别，为空时只记录检查结果
+func certificateLintBlockSeverity() certlint.Severity {
+	return certlint.Severity(getSettingStr(conf.CertLintBlockSeverity, ""))
+}
+
+// checkIssuanceLints 配置了阻止签发的检查级别时，在 CA 签名前检查将要签发的证书，
+// 存在该级别及以上的问题时拒绝签发
+func checkIssuanceLints(issuer *certissuer.Issuer, cert *model.Certificate, req *certissuer.Request) error {
+	severity := certificateLintBlockSeverity()
+	if !severity.Valid() {
+		return nil
+	}
+	findings, err := lintIssuance(issuer, req)
+	if err != nil {
+		return errs.NewErr(errs.InvalidCertificateRequest, "failed lint certificate %s: %v", cert.Name, err)
+	}
+	return lintBlockingError(cert.Name, certlint.Blocking(findings, severity))
+}
+
+// lintBlockingError 汇总阻止签发的检查结果，没有时返回 nil
+func lintBlockingError(name string, blocking []certlint.Finding) error {
+	if len(blocking) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(blocking))
+	for _, f := range blocking {
+		messages = append(messages, f.Lint+": "+f.Message)
+	}
+	return errs.NewErr(errs.InvalidCertificateRequest, "certificate %s fails lint: %s", name, strings.Join(messages, "; "))
+}
+
+// lintCertificateRequest 检查批准申请时将要签发的证书，与 issueCertificate 使用相同的参数。
+// SSH 和公开信任的证书不由内部 CA 签发，不做检查
+func lintCertificateRequest(req *model.CertificateRequest, t *model.CertificateTypeDef, issueAt time.Time) ([]certlint.Finding, error) {
+	if req.Type == model.CertificateTypeSSH || req.Type == model.CertificateTypePublic {
+		return nil, nil
+	}
+	issuer, _, err := certificateIssuer(issuingCertificateAuthorityID())
+	if err != nil {
+		return nil, err
+	}
+	cert := &model.Certificate{
+		Name:           t.CertificateName(req.UserName),
+		Type:           req.Type,
+		IssuedDate:     issueAt,
+		ExpirationDate: t.Validity(issueAt, req.ValidityPreset),
+		ProfileID:      req.ProfileID,
+	}
+	profile, err := checkRequestProfile(req.ProfileID, req.UserName, req.SANs, req.CSR)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		if expiration, ok := profile.Validity(issueAt); ok {
+			cert.ExpirationDate = expiration
+		}
+	}
+	commonName := req.UserName
+	if req.Type == model.CertificateTypeNode && len(req.SANs) > 0 {
+		commonName = req.SANs[0]
+	}
+	publicKey, err := requestPublicKey(req)
+	if err != nil {
+		return nil, err
+	}
+	r, err := issuanceRequest(issuer, cert, commonName, req.SANs, publicKey)
+	if err != nil {
+		return nil, err
+	}
+	return lintIssuance(issuer, r)
+}
@@ -0,0 +1,40 @@
+package op
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// GetCertificateRequestNotes 获取申请的内部备注，只有管理员和审计员可以查看
+func GetCertificateRequestNotes(id uint) ([]model.CertificateRequestNote, error) {
+	if _, err := db.GetCertificateRequestByID(id); err != nil {
+		return nil, err
+	}
+	return db.GetCertificateRequestNotes(id)
+}
+
+// AddCertificateRequestNote 添加内部备注。备注不通知任何人，但会完整写入审计日志，随审计导出
+func AddCertificateRequestNote(user *model.User, id uint, content string) (*model.CertificateRequestNote, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "content is required")
+	}
+	req, err := db.GetCertificateRequestByID(id)
+	if err != nil {
+		return nil, err
+	}
+	note := &model.CertificateRequestNote{
+		RequestID: req.ID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Content:   content,
+	}
+	if err := db.CreateCertificateRequestNote(note); err != nil {
+		return nil, err
+	}
+	auditCertificateRequest("certificate.request.note_added", user.Username, req, content)
+	return note, nil
+}
@@ -0,0 +1,192 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// getCertificateApprovers 获取证书审批人，目前即所有管理员，外出审批人的代理人同样会收到通知
+func getCertificateApprovers() ([]string, error) {
+	names, err := certificateAdmins()
+	if err != nil {
+		return nil, err
+	}
+	return withDelegates(names), nil
+}
+
+// splitLines 将多行设置值拆分为去除空白的列表
+func splitLines(s string) []string {
+	var res []string
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+// RemindPendingCertificateRequests 提醒审批人处理超过阈值仍未审批的申请，
+// 超过升级天数后同时通知升级组，由定时任务调用
+func RemindPendingCertificateRequests(ctx context.Context) error {
+	remindAfter := time.Duration(getSettingInt(conf.CertApprovalRemindHours, 24)) * time.Hour
+	if remindAfter <= 0 || !notify.Enabled() {
+		return nil
+	}
+	escalateAfter := time.Duration(getSettingInt(conf.CertApprovalEscalateDays, 3)) * 24 * time.Hour
+	escalationUsers := splitLines(getSettingStr(conf.CertApprovalEscalationUsers, ""))
+
+	requests, err := db.GetPendingCertificateRequestsBefore(time.Now().Add(-remindAfter))
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	approvers, err := getCertificateApprovers()
+	if err != nil {
+		return errors.WithMessage(err, "failed get certificate approvers")
+	}
+	for i := range requests {
+		req := &requests[i]
+		// 同一申请在一个提醒周期内只提醒一次
+		if req.RemindedAt != nil && time.Since(*req.RemindedAt) < remindAfter {
+			continue
+		}
+		pending := time.Since(req.CreatedAt)
+		escalate := escalateAfter > 0 && pending >= escalateAfter && len(escalationUsers) > 0
+		to := approvers
+		event := "certificate.request.reminder"
+		if escalate {
+			to = withDelegates(append(append([]string{}, approvers...), escalationUsers...))
+			event = "certificate.request.escalation"
+		}
+		err := notify.Send(ctx, &notify.Message{
+			Event:    event,
+			Severity: eventSeverity(event),
+			Title:    fmt.Sprintf("Certificate request #%d is waiting for approval", req.ID),
+			Content: fmt.Sprintf("%s requested a %s certificate %s ago and it is still pending.\nReason: %s",
+				req.UserName, req.Type, pending.Round(time.Hour), req.Reason),
+			To:         to,
+			Links:      approvalLinks(req),
+			ApprovalID: req.ID,
+		})
+		if err != nil {
+			log.Warnf("failed to remind approvers of certificate request %d: %+v", req.ID, err)
+			continue
+		}
+		// 只更新提醒时间，发送期间申请可能已被审批或拒绝，保存整行会把它改回待审批
+		now := time.Now()
+		var escalatedAt *time.Time
+		if escalate && req.EscalatedAt == nil {
+			escalatedAt = &now
+		}
+		if err := db.MarkCertificateRequestReminded(req.ID, now, escalatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventSeverities 需要提升通知级别的事件，未列出的事件为 info
+var eventSeverities = map[string]notify.Severity{
+	"certificate.revoked":            notify.SeverityWarning,
+	"certificate.held":               notify.SeverityWarning,
+	"certificate.anomaly":            notify.SeverityCritical,
+	"certificate.request.escalation": notify.SeverityWarning,
+}
+
+func eventSeverity(event string) notify.Severity {
+	if severity, ok := eventSeverities[event]; ok {
+		return severity
+	}
+	return notify.SeverityInfo
+}
+
+// sendAsync 异步发送通知，避免阻塞请求处理
+func sendAsync(msg *notify.Message) {
+	if !notify.Enabled() || (len(msg.To) == 0 && len(msg.Emails) == 0) {
+		return
+	}
+	if msg.Severity == "" {
+		msg.Severity = eventSeverity(msg.Event)
+	}
+	go func() {
+		if err := notify.Send(context.Background(), msg); err != nil {
+			log.Warnf("failed to send notification [%s]: %+v", msg.Event, err)
+		}
+	}()
+}
+
+// mergeRecipients 合并通知对象并去重
+func mergeRecipients(lists ...[]string) []string {
+	var res []string
+	for _, list := range lists {
+		for _, name := range list {
+			if name != "" && !utils.SliceContains(res, name) {
+				res = append(res, name)
+			}
+		}
+	}
+	return res
+}
+
+// notifyCertificateApprovers 通知审批人有新的待审批申请
+func notifyCertificateApprovers(req *model.CertificateRequest) {
+	if !notify.Enabled() {
+		return
+	}
+	approvers, err := getCertificateApprovers()
+	if err != nil {
+		log.Warnf("failed get certificate approvers: %+v", err)
+		return
+	}
+	sendAsync(&notify.Message{
+		Event: "certificate.request.created",
+		Title: fmt.Sprintf("New certificate request #%d is waiting for approval", req.ID),
+		Content: fmt.Sprintf("%s requested a %s certificate.\nReason: %s",
+			req.UserName, req.Type, req.Reason),
+		To:         approvers,
+		Links:      approvalLinks(req),
+		ApprovalID: req.ID,
+	})
+}
+
+// certificateContacts 证书的联系人邮箱，告警级别的事件同时发给升级联系人
+func certificateContacts(cert *model.Certificate, escalate bool) []string {
+	contacts := []string{cert.ContactEmail}
+	if escalate {
+		contacts = append(contacts, cert.EscalationContact)
+	}
+	return mergeRecipients(contacts)
+}
+
+// emitCertificateEvent 将证书生命周期事件通知给所有者、关注者和证书的联系人
+func emitCertificateEvent(event string, cert *model.Certificate, title, content string) {
+	sendAsync(&notify.Message{
+		Event:   event,
+		Title:   title,
+		Content: content,
+		To:      mergeRecipients([]string{cert.Owner}, getWatchers(model.CertificateWatchTargetCertificate, cert.ID)),
+		Emails:  certificateContacts(cert, eventSeverity(event) != notify.SeverityInfo),
+	})
+}
+
+// emitCertificateRequestEvent 将证书申请事件通知给申请人和关注者
+func emitCertificateRequestEvent(event string, req *model.CertificateRequest, title, content string) {
+	sendAsync(&notify.Message{
+		Event:   event,
+		Title:   title,
+		Content: content,
+		To:      mergeRecipients([]string{req.UserName}, getWatchers(model.CertificateWatchTargetRequest, req.ID)),
+	})
+}
@@ -0,0 +1,200 @@
+package op
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ocsp"
+	"gorm.io/gorm"
+)
+
+// OCSP 委托签名证书的有效期，剩余不足 ocspResponderRenewBefore 时重新签发
+const (
+	ocspResponderValidity    = 7 * 24 * time.Hour
+	ocspResponderRenewBefore = 24 * time.Hour
+)
+
+// oidOCSPNoCheck id-pkix-ocsp-nocheck，客户端无需检查委托签名证书自身的吊销状态
+var oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// ocspResponder 签名 OCSP 响应的证书和私钥，CA 自身签名时 certificate 为 nil
+type ocspResponder struct {
+	certificate *x509.Certificate
+	key         crypto.Signer
+}
+
+// ocspResponders 按 CA 缓存的委托签名证书，只保存在内存中，重启后重新签发
+var ocspResponders = struct {
+	sync.Mutex
+	responders map[uint]*ocspResponder
+}{responders: map[uint]*ocspResponder{}}
+
+// RespondOCSP 处理 DER 编码的 OCSP 请求并返回签名后的响应。请求无法处理时返回 OCSP 规定的
+// 未签名错误响应
+func RespondOCSP(der []byte, ip string) []byte {
+	req, err := ocsp.ParseRequest(der)
+	if err != nil {
+		return ocsp.MalformedRequestErrorResponse
+	}
+	ca, issuer, err := ocspIssuer(req)
+	if err != nil {
+		log.Errorf("failed to find the issuer of ocsp request: %+v", err)
+		return ocsp.InternalErrorErrorResponse
+	}
+	if ca == nil {
+		return ocsp.UnauthorizedErrorResponse
+	}
+	now := time.Now()
+	tmpl := ocsp.Response{
+		Status:       ocsp.Unknown,
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   now,
+		NextUpdate:   now.Add(time.Duration(getSettingInt(conf.CertCRLValidityHours, 24)) * time.Hour),
+	}
+	cert, err := db.GetCertificateByIssuerAndSerial(ca.ID, req.SerialNumber.Text(16))
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		log.Errorf("failed to get certificate %s for ocsp: %+v", req.SerialNumber.Text(16), err)
+		return ocsp.InternalErrorErrorResponse
+	default:
+		TripCertificateHoneytoken(cert, "ocsp", "ocsp", ip)
+		if entry, ok := revocationListEntry(cert, req.SerialNumber); ok {
+			tmpl.Status = ocsp.Revoked
+			tmpl.RevokedAt = entry.RevocationTime
+			tmpl.RevocationReason = entry.ReasonCode
+		} else if cert.IsValid() {
+			tmpl.Status = ocsp.Good
+		}
+	}
+	responder, err := getOCSPResponder(ca, issuer, now)
+	if err != nil {
+		log.Errorf("failed to get ocsp responder of certificate authority %s: %+v", ca.Name, err)
+		return ocsp.InternalErrorErrorResponse
+	}
+	if responder.certificate != nil {
+		tmpl.Certificate = responder.certificate
+		// 响应中的证书有效期不应晚于委托签名证书
+		if tmpl.NextUpdate.After(responder.certificate.NotAfter) {
+			tmpl.NextUpdate = responder.certificate.NotAfter
+		}
+	}
+	var resp []byte
+	err = runSigning(func() error {
+		var err error
+		resp, err = ocsp.CreateResponse(issuer.Certificate, responderCertificate(responder, issuer), tmpl, responder.key)
+		return err
+	})
+	if IsSigningBusy(err) {
+		return ocsp.TryLaterErrorResponse
+	}
+	if err != nil {
+		log.Errorf("failed to sign ocsp response: %+v", err)
+		return ocsp.InternalErrorErrorResponse
+	}
+	return resp
+}
+
+func responderCertificate(responder *ocspResponder, issuer *certissuer.Issuer) *x509.Certificate {
+	if responder.certificate != nil {
+		return responder.certificate
+	}
+	return issuer.Certificate
+}
+
+// ocspIssuer 按请求中的签发者公钥摘要查找持有私钥的 CA，找不到时返回 nil。
+// 已停用的 CA 在其签发的最后一张证书过期前仍需应答
+func ocspIssuer(req *ocsp.Request) (*model.CertificateAuthority, *certissuer.Issuer, error) {
+	if !req.HashAlgorithm.Available() {
+		return nil, nil, nil
+	}
+	cas, err := db.GetServingCertificateAuthorities(time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range cas {
+		if !cas[i].HasPrivateKey() {
+			continue
+		}
+		certs, err := parseCertificates(cas[i].Content)
+		if err != nil {
+			continue
+		}
+		var spki struct {
+			Algorithm pkix.AlgorithmIdentifier
+			PublicKey asn1.BitString
+		}
+		if _, err := asn1.Unmarshal(certs[0].RawSubjectPublicKeyInfo, &spki); err != nil {
+			continue
+		}
+		h := req.HashAlgorithm.New()
+		h.Write(spki.PublicKey.RightAlign())
+		if !bytes.Equal(h.Sum(nil), req.IssuerKeyHash) {
+			continue
+		}
+		issuer, ca, err := certificateIssuer(cas[i].ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return ca, issuer, nil
+	}
+	return nil, nil, nil
+}
+
+// getOCSPResponder 获取 CA 的 OCSP 签名者，开启 cert_ocsp_delegated_signer 后使用 CA 签发的
+// 短期委托签名证书，避免每次响应都动用 CA 私钥
+func getOCSPResponder(ca *model.CertificateAuthority, issuer *certissuer.Issuer, now time.Time) (*ocspResponder, error) {
+	if !getSettingBool(conf.CertOCSPDelegatedSigner) {
+		return &ocspResponder{key: issuer.Key}, nil
+	}
+	ocspResponders.Lock()
+	defer ocspResponders.Unlock()
+	// CA 临近过期时委托证书无法续期到更晚，继续使用即可
+	if r := ocspResponders.responders[ca.ID]; r != nil && r.certificate.CheckSignatureFrom(issuer.Certificate) == nil &&
+		(r.certificate.NotAfter.Sub(now) > ocspResponderRenewBefore || !r.certificate.NotAfter.Before(issuer.Certificate.NotAfter)) &&
+		now.Before(r.certificate.NotAfter) {
+		return r, nil
+	}
+	// 留出一分钟应对客户端时钟偏差
+	notBefore, notAfter := now.Add(-time.Minute), now.Add(ocspResponderValidity)
+	if notBefore.Before(issuer.Certificate.NotBefore) {
+		notBefore = issuer.Certificate.NotBefore
+	}
+	if notAfter.After(issuer.Certificate.NotAfter) {
+		notAfter = issuer.Certificate.NotAfter
+	}
+	var res *certissuer.Result
+	err := runSigning(func() error {
+		var err error
+		res, err = issuer.Issue(&certissuer.Request{
+			CommonName:      ca.Name + " OCSP Responder",
+			KeyAlgorithm:    certissuer.KeyECDSAP256,
+			ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+			NotBefore:       notBefore,
+			NotAfter:        notAfter,
+			ExtraExtensions: []pkix.Extension{{Id: oidOCSPNoCheck, Value: asn1.NullBytes}},
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey(res.CertificatePEM, res.PrivateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	r := &ocspResponder{certificate: res.Certificate, key: key}
+	ocspResponders.responders[ca.ID] = r
+	return r, nil
+}
@@ -0,0 +1,165 @@
+package op
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// pemPrivateKeyTypes PEM 包中可以携带的私钥块类型
+var pemPrivateKeyTypes = []string{"PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY"}
+
+// ImportCertificatePEM 导入 PEM 包中的证书，包中依次为证书、可选的证书链和可选的私钥。
+// 名称、签发和到期日期、类型、序列号和指纹都从证书中解析，不接受客户端填写；
+// 格式错误、已过期、证书链或私钥不匹配以及已存在的证书都会被拒绝。owner 为空时归属操作人
+func ImportCertificatePEM(bundle, owner string, operator *model.User) (*model.Certificate, error) {
+	var certs []*x509.Certificate
+	var keyPEM string
+	rest := []byte(strings.TrimSpace(bundle))
+	for len(rest) > 0 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "malformed PEM bundle")
+		}
+		rest = []byte(strings.TrimSpace(string(rest)))
+		switch {
+		case block.Type == "CERTIFICATE":
+			if keyPEM != "" {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "the private key must follow the certificates")
+			}
+			c, err := parseX509(block.Bytes)
+			if err != nil {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid certificate #%d: %v", len(certs), err)
+			}
+			certs = append(certs, c)
+		case slices.Contains(pemPrivateKeyTypes, block.Type):
+			if keyPEM != "" {
+				return nil, errs.NewErr(errs.InvalidCertificateRequest, "PEM bundle contains more than one private key")
+			}
+			keyPEM = string(pem.EncodeToMemory(block))
+		default:
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "unexpected PEM block %s", block.Type)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "PEM bundle contains no certificate")
+	}
+	leaf := certs[0]
+	if leaf.IsCA {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "%s is a CA certificate, import it as a certificate authority", leaf.Subject)
+	}
+	if !time.Now().Before(leaf.NotAfter) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s expired at %s", leaf.Subject, leaf.NotAfter.Format(time.RFC3339))
+	}
+	// 证书链中每张证书都必须由下一张签发
+	for i := 1; i < len(certs); i++ {
+		if err := certs[i-1].CheckSignatureFrom(certs[i]); err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate #%d is not issued by the next certificate in the chain: %v", i-1, err)
+		}
+	}
+	var content strings.Builder
+	for _, c := range certs {
+		if err := pem.Encode(&content, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}); err != nil {
+			return nil, err
+		}
+	}
+	cert := &model.Certificate{
+		Name:           certificateNameOf(leaf),
+		Type:           certificateTypeOf(leaf),
+		Content:        content.String(),
+		IssuedDate:     leaf.NotBefore,
+		ExpirationDate: leaf.NotAfter,
+		Status:         model.CertificateStatusValid,
+	}
+	if cert.Name == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate has neither a common name nor a DNS name")
+	}
+	if keyPEM != "" {
+		if _, err := parsePrivateKey(cert.Content, keyPEM); err != nil {
+			return nil, err
+		}
+		cert.PrivateKey = keyPEM
+	}
+	fillCertificateIdentity(cert)
+	existing, err := db.GetCertificateBySerialOrFingerprint("", cert.Fingerprint)
+	if err == nil {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate has already been imported as #%d", existing.ID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if owner == "" {
+		owner = operator.Username
+	}
+	user, err := db.GetUserByName(owner)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "owner %s does not exist", owner)
+		}
+		return nil, err
+	}
+	cert.Owner, cert.OwnerID = user.Username, user.ID
+	if cert.IssuerID, err = importedCertificateIssuer(leaf); err != nil {
+		return nil, err
+	}
+	t, err := CheckCertificateType(cert.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCertificateTypeQuota(t); err != nil {
+		return nil, err
+	}
+	if err := db.CreateCertificate(cert); err != nil {
+		return nil, err
+	}
+	recordCertificateEvent(cert.ID, "certificate.imported", operator.Username, "")
+	return cert, nil
+}
+
+// certificateNameOf 证书名称，没有通用名称时使用第一个域名
+func certificateNameOf(c *x509.Certificate) string {
+	if name := strings.TrimSpace(c.Subject.CommonName); name != "" {
+		return name
+	}
+	if len(c.DNSNames) > 0 {
+		return c.DNSNames[0]
+	}
+	return ""
+}
+
+// certificateTypeOf 按扩展密钥用途推断证书类型，可用于服务端认证的为节点证书，其余为用户证书
+func certificateTypeOf(c *x509.Certificate) model.CertificateType {
+	if slices.Contains(c.ExtKeyUsage, x509.ExtKeyUsageServerAuth) {
+		return model.CertificateTypeNode
+	}
+	return model.CertificateTypeUser
+}
+
+// importedCertificateIssuer 查找签发了导入证书的 CA，外部 CA 签发的证书返回 0
+func importedCertificateIssuer(leaf *x509.Certificate) (uint, error) {
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return 0, err
+	}
+	for i := range cas {
+		// 交叉证书与原 CA 的公钥相同，证书归属原 CA
+		if cas[i].IsCrossSigned() {
+			continue
+		}
+		caCerts, err := parseCertificates(cas[i].Content)
+		if err != nil || leaf.CheckSignatureFrom(caCerts[0]) != nil {
+			continue
+		}
+		return cas[i].ID, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,326 @@
+package op
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"gopkg.in/yaml.v3"
+)
+
+// ExportPolicyBundle 导出证书类型、申请表单字段、共享申请模板和通知路由
+func ExportPolicyBundle() (*model.PolicyBundle, error) {
+	bundle := &model.PolicyBundle{Format: model.PolicyBundleFormat}
+	types, err := db.GetCertificateTypes()
+	if err != nil {
+		return nil, err
+	}
+	for i := range types {
+		bundle.Types = append(bundle.Types, policyType(&types[i]))
+	}
+	fields, err := db.GetCertificateRequestFields()
+	if err != nil {
+		return nil, err
+	}
+	for i := range fields {
+		bundle.Fields = append(bundle.Fields, policyField(&fields[i]))
+	}
+	templates, err := db.GetCertificateRequestTemplates(0)
+	if err != nil {
+		return nil, err
+	}
+	for i := range templates {
+		bundle.Templates = append(bundle.Templates, policyTemplate(&templates[i]))
+	}
+	bundle.NotificationRouting = parseNotifyRouting(getSettingStr(conf.NotifyRouting, ""))
+	return bundle, nil
+}
+
+// MarshalPolicyBundle 按 json 或 yaml 格式输出，yaml 使用与 json 相同的字段名
+func MarshalPolicyBundle(bundle *model.PolicyBundle, format string) ([]byte, error) {
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil || format != "yaml" {
+		return data, err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(v)
+}
+
+// UnmarshalPolicyBundle 解析 json 或 yaml 格式的策略文件
+func UnmarshalPolicyBundle(data []byte, format string) (*model.PolicyBundle, error) {
+	if format == "yaml" {
+		var v any
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid yaml: %v", err)
+		}
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid yaml: %v", err)
+		}
+	}
+	var bundle model.PolicyBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid policy bundle: %v", err)
+	}
+	if bundle.Format != model.PolicyBundleFormat {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported policy bundle format: %q", bundle.Format)
+	}
+	return &bundle, nil
+}
+
+// ImportPolicyBundle 按名称创建或更新配置，内容相同的项保持不变，因此重复导入同一文件不会产生变更。
+// 文件中未列出的配置不会被删除
+func ImportPolicyBundle(bundle *model.PolicyBundle, dryRun bool, operator *model.User) (*model.PolicyImportResult, error) {
+	res := &model.PolicyImportResult{DryRun: dryRun, Items: []model.PolicyImportItem{}}
+	// 字段和模板引用证书类型，先导入类型
+	if err := importPolicyTypes(bundle.Types, dryRun, res); err != nil {
+		return res, err
+	}
+	if err := importPolicyFields(bundle.Fields, dryRun, res); err != nil {
+		return res, err
+	}
+	if err := importPolicyTemplates(bundle.Templates, dryRun, res); err != nil {
+		return res, err
+	}
+	if bundle.NotificationRouting != nil {
+		if err := importNotifyRouting(bundle.NotificationRouting, dryRun, res); err != nil {
+			return res, err
+		}
+	}
+	if !dryRun {
+		changed := 0
+		for _, item := range res.Items {
+			if item.Outcome != model.PolicyUnchanged {
+				changed++
+			}
+		}
+		audit.Emit(&audit.Event{
+			Type:   "certificate.policy.imported",
+			Actor:  operator.Username,
+			Target: "certificate_policy",
+			Detail: fmt.Sprintf("%d items, %d changed", len(res.Items), changed),
+		})
+	}
+	return res, nil
+}
+
+// samePolicy 比较两项配置的 json 表示，避免 nil 和空集合被视为不同
+func samePolicy(a, b any) bool {
+	x, err1 := json.Marshal(a)
+	y, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(x) == string(y)
+}
+
+func policyType(t *model.CertificateTypeDef) model.PolicyType {
+	return model.PolicyType{
+		Name:            t.Name,
+		Description:     t.Description,
+		Disabled:        t.Disabled,
+		NameTemplate:    t.NameTemplate,
+		ValidityDays:    t.ValidityDays,
+		ValidityPresets: t.ValidityPresets,
+		ApprovalChain:   t.ApprovalChain,
+		Quota:           t.Quota,
+	}
+}
+
+func importPolicyTypes(specs []model.PolicyType, dryRun bool, res *model.PolicyImportResult) error {
+	types, err := db.GetCertificateTypes()
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		t := &model.CertificateTypeDef{}
+		outcome := model.PolicyCreated
+		for i := range types {
+			if types[i].Name == spec.Name {
+				t, outcome = &types[i], model.PolicyUpdated
+				break
+			}
+		}
+		if outcome == model.PolicyUpdated && samePolicy(policyType(t), spec) {
+			res.Add("type", string(spec.Name), model.PolicyUnchanged)
+			continue
+		}
+		t.Name, t.Description, t.Disabled, t.NameTemplate = spec.Name, spec.Description, spec.Disabled, spec.NameTemplate
+		t.ValidityDays, t.ValidityPresets, t.ApprovalChain, t.Quota = spec.ValidityDays, spec.ValidityPresets, spec.ApprovalChain, spec.Quota
+		if dryRun {
+			err = checkCertificateTypeDef(t)
+		} else if outcome == model.PolicyCreated {
+			err = CreateCertificateType(t)
+		} else {
+			err = UpdateCertificateType(t)
+		}
+		if err != nil {
+			return errs.NewErr(errs.InvalidCertificateRequest, "type %s: %v", spec.Name, err)
+		}
+		res.Add("type", string(spec.Name), outcome)
+	}
+	return nil
+}
+
+func policyField(f *model.CertificateRequestField) model.PolicyField {
+	return model.PolicyField{
+		Type:      f.Type,
+		Key:       f.Key,
+		Label:     f.Label,
+		FieldType: f.FieldType,
+		Options:   f.Options,
+		Required:  f.Required,
+		Order:     f.Order,
+	}
+}
+
+func importPolicyFields(specs []model.PolicyField, dryRun bool, res *model.PolicyImportResult) error {
+	fields, err := db.GetCertificateRequestFields()
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		name := spec.Key
+		if spec.Type != "" {
+			name = string(spec.Type) + "/" + spec.Key
+		}
+		f := &model.CertificateRequestField{}
+		outcome := model.PolicyCreated
+		for i := range fields {
+			if fields[i].Type == spec.Type && fields[i].Key == spec.Key {
+				f, outcome = &fields[i], model.PolicyUpdated
+				break
+			}
+		}
+		if outcome == model.PolicyUpdated && samePolicy(policyField(f), spec) {
+			res.Add("field", name, model.PolicyUnchanged)
+			continue
+		}
+		f.Type, f.Key, f.Label, f.FieldType = spec.Type, spec.Key, spec.Label, spec.FieldType
+		f.Options, f.Required, f.Order = spec.Options, spec.Required, spec.Order
+		if dryRun {
+			err = checkCertificateRequestField(f)
+		} else if outcome == model.PolicyCreated {
+			err = CreateCertificateRequestField(f)
+		} else {
+			err = UpdateCertificateRequestField(f)
+		}
+		if err != nil {
+			return errs.NewErr(errs.InvalidCertificateRequest, "field %s: %v", name, err)
+		}
+		res.Add("field", name, outcome)
+	}
+	return nil
+}
+
+func policyTemplate(t *model.CertificateRequestTemplate) model.PolicyTemplate {
+	return model.PolicyTemplate{
+		Name:           t.Name,
+		Type:           t.Type,
+		Reason:         t.Reason,
+		SANs:           t.SANs,
+		ValidityPreset: t.ValidityPreset,
+		CustomFields:   t.CustomFields,
+	}
+}
+
+// importPolicyTemplates 导入共享模板，试运行时新建的类型尚未写入，引用这些类型的模板不做校验
+func importPolicyTemplates(specs []model.PolicyTemplate, dryRun bool, res *model.PolicyImportResult) error {
+	templates, err := db.GetCertificateRequestTemplates(0)
+	if err != nil {
+		return err
+	}
+	for _, spec := range specs {
+		t := &model.CertificateRequestTemplate{}
+		outcome := model.PolicyCreated
+		for i := range templates {
+			if templates[i].Name == spec.Name {
+				t, outcome = &templates[i], model.PolicyUpdated
+				break
+			}
+		}
+		if outcome == model.PolicyUpdated && samePolicy(policyTemplate(t), spec) {
+			res.Add("template", spec.Name, model.PolicyUnchanged)
+			continue
+		}
+		t.Name, t.Type, t.Reason = spec.Name, spec.Type, spec.Reason
+		t.SANs, t.ValidityPreset, t.CustomFields = spec.SANs, spec.ValidityPreset, spec.CustomFields
+		if dryRun {
+			if _, err = db.GetCertificateTypeByName(t.Type); err == nil {
+				err = checkCertificateRequestTemplate(t)
+			} else {
+				err = nil
+			}
+		} else if err = checkCertificateRequestTemplate(t); err == nil {
+			if outcome == model.PolicyCreated {
+				err = db.CreateCertificateRequestTemplate(t)
+			} else {
+				err = db.UpdateCertificateRequestTemplate(t)
+			}
+		}
+		if err != nil {
+			return errs.NewErr(errs.InvalidCertificateRequest, "template %s: %v", spec.Name, err)
+		}
+		res.Add("template", spec.Name, outcome)
+	}
+	return nil
+}
+
+// parseNotifyRouting 解析每行 "severity=channel,channel" 格式的通知路由
+func parseNotifyRouting(value string) map[string][]string {
+	routes := map[string][]string{}
+	for _, line := range strings.Split(value, "\n") {
+		severity, names, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		list := []string{}
+		for _, name := range strings.Split(names, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				list = append(list, name)
+			}
+		}
+		routes[strings.TrimSpace(severity)] = list
+	}
+	return routes
+}
+
+func formatNotifyRouting(routes map[string][]string) string {
+	severities := make([]string, 0, len(routes))
+	for severity := range routes {
+		severities = append(severities, severity)
+	}
+	sort.Strings(severities)
+	lines := make([]string, 0, len(severities))
+	for _, severity := range severities {
+		lines = append(lines, severity+"="+strings.Join(routes[severity], ","))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func importNotifyRouting(routes map[string][]string, dryRun bool, res *model.PolicyImportResult) error {
+	item, err := GetSettingItemByKey(conf.NotifyRouting)
+	if err != nil {
+		return err
+	}
+	value := formatNotifyRouting(routes)
+	if formatNotifyRouting(parseNotifyRouting(item.Value)) == value {
+		res.Add("notification_routing", conf.NotifyRouting, model.PolicyUnchanged)
+		return nil
+	}
+	if !dryRun {
+		updated := *item
+		updated.Value = value
+		if err := SaveSettingItem(&updated); err != nil {
+			return err
+		}
+	}
+	res.Add("notification_routing", conf.NotifyRouting, model.PolicyUpdated)
+	return nil
+}
@@ -0,0 +1,128 @@
+package op
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/certlint"
+)
+
+// 签发的终端证书使用的密钥用途
+var (
+	certificateKeyUsage    = []string{"digitalSignature", "keyEncipherment"}
+	certificateExtKeyUsage = []string{"serverAuth", "clientAuth"}
+)
+
+// PreviewCertificateRequest 预览批准已有申请时将签发的证书，notBefore 为计划签发时间，为空时以当前时间计算
+func PreviewCertificateRequest(id uint, user *model.User, notBefore *time.Time) (*model.CertificatePreview, error) {
+	req, err := db.GetCertificateRequestByID(id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := CanReadCertificateRequest(user, req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errs.PermissionDenied
+	}
+	t, err := CheckCertificateType(req.Type)
+	if err != nil {
+		return nil, err
+	}
+	issueAt := time.Now()
+	if notBefore != nil {
+		issueAt = *notBefore
+	} else if req.ScheduledAt != nil {
+		issueAt = *req.ScheduledAt
+	}
+	p := buildCertificatePreview(t, req.UserName, req.ValidityPreset, req.CustomFields, req.SANs, issueAt)
+	if len(req.Approvals) < len(t.ApprovalChain) {
+		p.PendingApprovers = t.ApprovalChain[len(req.Approvals):]
+	}
+	if !req.IsPending() && !req.IsScheduled() {
+		p.Warnings = append(p.Warnings, "request is not pending, current status: "+string(req.Status))
+	}
+	if p.Lints, err = lintCertificateRequest(req, t, issueAt); err != nil {
+		p.Warnings = append(p.Warnings, "failed lint certificate: "+err.Error())
+	} else if err := lintBlockingError(p.Name, certlint.Blocking(p.Lints, certificateLintBlockSeverity())); err != nil {
+		p.Warnings = append(p.Warnings, err.Error())
+	}
+	return p, nil
+}
+
+// PreviewCertificate 按申请参数预览将签发的证书，参数的校验与提交申请一致
+func PreviewCertificate(user *model.User, reqType model.CertificateType, validityPreset string, customFields map[string]string, sans []string) (*model.CertificatePreview, error) {
+	t, err := CheckCertificateType(reqType)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkValidityPreset(t, validityPreset); err != nil {
+		return nil, err
+	}
+	customFields, err = validateCustomFields(reqType, customFields)
+	if err != nil {
+		return nil, err
+	}
+	sans, err = checkSANs(sans)
+	if err != nil {
+		return nil, err
+	}
+	p := buildCertificatePreview(t, user.Username, validityPreset, customFields, sans, time.Now())
+	p.PendingApprovers = t.ApprovalChain
+	return p, nil
+}
+
+// PreviewCertificateFromTemplate 按模板预览将签发的证书，customFields 覆盖模板中的同名字段
+func PreviewCertificateFromTemplate(user *model.User, id uint, customFields map[string]string) (*model.CertificatePreview, error) {
+	tmpl, err := getCertificateRequestTemplate(id, user, false)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(tmpl.CustomFields)+len(customFields))
+	for k, v := range tmpl.CustomFields {
+		fields[k] = v
+	}
+	for k, v := range customFields {
+		fields[k] = v
+	}
+	return PreviewCertificate(user, tmpl.Type, tmpl.ValidityPreset, fields, tmpl.ExpandSANs(user.Username))
+}
+
+// buildCertificatePreview 与 issueCertificate 使用相同的规则生成证书内容
+func buildCertificatePreview(t *model.CertificateTypeDef, owner, preset string, customFields map[string]string, sans []string, issueAt time.Time) *model.CertificatePreview {
+	name := t.CertificateName(owner)
+	p := &model.CertificatePreview{
+		Name:           name,
+		Type:           t.Name,
+		Owner:          owner,
+		Subject:        "CN=" + name,
+		SANs:           sans,
+		KeyUsage:       certificateKeyUsage,
+		ExtKeyUsage:    certificateExtKeyUsage,
+		NotBefore:      model.CertificateDate(issueAt),
+		NotAfter:       model.CertificateDate(t.Validity(issueAt, preset)),
+		ValidityPreset: preset,
+		CustomFields:   customFields,
+	}
+	if p.SANs == nil {
+		p.SANs = []string{}
+	}
+	if id := issuingCertificateAuthorityID(); id != 0 {
+		if ca, err := db.GetCertificateAuthorityByID(id); err == nil {
+			p.Issuer = ca.Subject
+			p.IssuerID = ca.ID
+		}
+	} else {
+		p.Warnings = append(p.Warnings, "no active ca is configured, the certificate will not be linked to a ca")
+	}
+	if err := checkCertificateTypeQuota(t); err != nil {
+		p.Warnings = append(p.Warnings, err.Error())
+	}
+	if err := checkValidityPreset(t, preset); err != nil {
+		p.Warnings = append(p.Warnings, err.Error())
+	}
+	return p
+}
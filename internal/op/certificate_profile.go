@@ -0,0 +1,169 @@
+package op
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var GetCertificateProfiles = db.GetCertificateProfiles
+
+// certificateProfileKeyTypes 签发配置可选的密钥类型
+var certificateProfileKeyTypes = []string{"rsa", "ecdsa", "ed25519"}
+
+// certificateProfileExtKeyUsages 签发配置中扩展密钥用途的名称，与 RFC 5280 的命名一致
+var certificateProfileExtKeyUsages = map[string]x509.ExtKeyUsage{
+	"serverAuth":      x509.ExtKeyUsageServerAuth,
+	"clientAuth":      x509.ExtKeyUsageClientAuth,
+	"codeSigning":     x509.ExtKeyUsageCodeSigning,
+	"emailProtection": x509.ExtKeyUsageEmailProtection,
+	"timeStamping":    x509.ExtKeyUsageTimeStamping,
+}
+
+func checkCertificateProfile(p *model.CertificateProfile) error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "profile name is required")
+	}
+	p.KeyType = strings.ToLower(strings.TrimSpace(p.KeyType))
+	switch p.KeyType {
+	case "", "ed25519":
+		p.KeySize = 0
+	default:
+		if !utils.SliceContains(certificateProfileKeyTypes, p.KeyType) {
+			return errs.NewErr(errs.InvalidCertificateRequest, "unsupported key type: %s", p.KeyType)
+		}
+		if !utils.SliceContains(certissuer.KeyAlgorithms, p.KeyAlgorithm()) {
+			return errs.NewErr(errs.InvalidCertificateRequest, "unsupported key size %d for key type %s", p.KeySize, p.KeyType)
+		}
+	}
+	if p.ValidityDays < 0 {
+		return errs.NewErr(errs.InvalidCertificateRequest, "validity_days must not be negative")
+	}
+	var err error
+	if p.AllowedSANs, err = checkSANs(p.AllowedSANs); err != nil {
+		return err
+	}
+	for _, pattern := range p.AllowedSANs {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errs.NewErr(errs.InvalidCertificateRequest, "invalid allowed subject alternative name pattern: %q", pattern)
+		}
+	}
+	for _, usage := range p.ExtKeyUsages {
+		if _, ok := certificateProfileExtKeyUsages[usage]; !ok {
+			return errs.NewErr(errs.InvalidCertificateRequest, "unsupported extended key usage: %s", usage)
+		}
+	}
+	return nil
+}
+
+func CreateCertificateProfile(p *model.CertificateProfile) error {
+	if err := checkCertificateProfile(p); err != nil {
+		return err
+	}
+	return db.CreateCertificateProfile(p)
+}
+
+// UpdateCertificateProfile 更新签发配置，已签发的证书续期时按新配置签发
+func UpdateCertificateProfile(p *model.CertificateProfile) error {
+	old, err := db.GetCertificateProfileByID(p.ID)
+	if err != nil {
+		return err
+	}
+	p.CreatedAt = old.CreatedAt
+	if err := checkCertificateProfile(p); err != nil {
+		return err
+	}
+	return db.UpdateCertificateProfile(p)
+}
+
+// DeleteCertificateProfile 删除签发配置，仍有未签发的申请引用时不允许删除
+func DeleteCertificateProfile(id uint) error {
+	p, err := db.GetCertificateProfileByID(id)
+	if err != nil {
+		return err
+	}
+	count, err := db.CountOpenCertificateRequestsByProfileID(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errs.NewErr(errs.CertificateConflict, "certificate profile %s is still used by %d requests", p.Name, count)
+	}
+	return db.DeleteCertificateProfile(id)
+}
+
+// checkRequestProfile 校验申请引用的签发配置，备用名称必须在配置允许的范围内，
+// CSR 的公钥必须符合配置的密钥类型。profileID 为 0 时返回 nil
+func checkRequestProfile(profileID uint, username string, sans []string, csr string) (*model.CertificateProfile, error) {
+	if profileID == 0 {
+		return nil, nil
+	}
+	p, err := db.GetCertificateProfileByID(profileID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "unknown certificate profile: %d", profileID)
+		}
+		return nil, err
+	}
+	for _, san := range sans {
+		if !p.AllowsSAN(san, username) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "subject alternative name %q is not allowed by certificate profile %s", san, p.Name)
+		}
+	}
+	if csr != "" && p.KeyAlgorithm() != "" {
+		parsed, err := parseCSR(csr)
+		if err != nil {
+			return nil, err
+		}
+		if alg := publicKeyAlgorithm(parsed.PublicKey); alg != p.KeyAlgorithm() {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "csr key %s does not match %s required by certificate profile %s", alg, p.KeyAlgorithm(), p.Name)
+		}
+	}
+	return p, nil
+}
+
+// publicKeyAlgorithm 返回公钥对应的 certissuer 密钥算法名
+func publicKeyAlgorithm(pub crypto.PublicKey) string {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("rsa-%d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ecdsa-p%d", k.Curve.Params().BitSize)
+	case ed25519.PublicKey:
+		return certissuer.KeyEd25519
+	}
+	return fmt.Sprintf("%T", pub)
+}
+
+// certificateProfileIssuance 返回证书签发配置的密钥算法和扩展密钥用途，配置未指定或已删除时返回空值
+func certificateProfileIssuance(cert *model.Certificate) (string, []x509.ExtKeyUsage, error) {
+	if cert.ProfileID == 0 {
+		return "", nil, nil
+	}
+	p, err := db.GetCertificateProfileByID(cert.ProfileID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	var usages []x509.ExtKeyUsage
+	for _, name := range p.ExtKeyUsages {
+		usages = append(usages, certificateProfileExtKeyUsages[name])
+	}
+	return p.KeyAlgorithm(), usages, nil
+}
@@ -0,0 +1,160 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// 租户同时只能有一个处理中的申请，有效证书数量见 tenantCertificateQuota
+const tenantMaxOpenRequests = 1
+
+// tenantCertificateQuota 租户可同时持有的有效证书数量，优先使用管理员为该租户单独设置的上限
+func tenantCertificateQuota(userID uint) (int, error) {
+	q, err := db.GetCertificateTenantQuota(userID)
+	if err != nil {
+		return 0, err
+	}
+	if q.MaxCertificates > 0 {
+		return q.MaxCertificates, nil
+	}
+	return getSettingInt(conf.CertTenantMaxCertificates, 1), nil
+}
+
+// checkTenantCertificateQuota 校验租户的有效证书数量未达到上限，续期重叠期内被取代的旧证书不计入
+func checkTenantCertificateQuota(user *model.User) error {
+	limit, err := tenantCertificateQuota(user.ID)
+	if err != nil {
+		return err
+	}
+	count, err := db.CountActiveCertificatesByOwnerID(user.ID)
+	if err != nil {
+		return errors.WithMessage(err, "failed to count active certificates")
+	}
+	if count >= int64(limit) {
+		return errs.NewErr(errs.CertificateConflict, tenantCertificateQuotaReached, limit)
+	}
+	return nil
+}
+
+const tenantCertificateQuotaReached = "at most %d valid certificates are allowed for user"
+
+// SetCertificateTenantQuota 管理员设置租户的有效证书数量上限，为 0 时恢复使用全局设置
+func SetCertificateTenantQuota(userID uint, maxCertificates int, operator *model.User) error {
+	if maxCertificates < 0 {
+		return errs.NewErr(errs.InvalidCertificateRequest, "max certificates can not be negative")
+	}
+	user, err := db.GetUserById(userID)
+	if err != nil {
+		return err
+	}
+	if maxCertificates == 0 {
+		err = db.DeleteCertificateTenantQuota(userID)
+	} else {
+		err = db.SaveCertificateTenantQuota(&model.CertificateTenantQuota{
+			UserID:          userID,
+			MaxCertificates: maxCertificates,
+			UpdatedBy:       operator.Username,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.tenant_quota.updated",
+		Actor:  operator.Username,
+		Target: user.Username,
+		Detail: fmt.Sprintf("max certificates %d", maxCertificates),
+	})
+	return nil
+}
+
+// GetCertificateTenantQuotas 获取单独设置了配额的租户
+func GetCertificateTenantQuotas() ([]model.CertificateTenantQuota, error) {
+	return db.GetCertificateTenantQuotas()
+}
+
+// checkTenantDailyRequests 校验租户最近 24 小时提交的申请数量未超过上限
+func checkTenantDailyRequests(user *model.User) error {
+	limit := getSettingInt(conf.CertTenantDailyRequests, 0)
+	if limit <= 0 {
+		return nil
+	}
+	count, err := db.CountCertificateRequestsByUserSince(user.ID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "at most %d certificate requests are allowed in 24 hours", limit)
+	}
+	return nil
+}
+
+// GetTenantCertificateQuota 获取租户的配额和当前使用量
+func GetTenantCertificateQuota(user *model.User) (*model.TenantCertificateQuota, error) {
+	now := time.Now()
+	maxCertificates, err := tenantCertificateQuota(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	active, err := db.CountActiveCertificatesByOwnerID(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	quota := &model.TenantCertificateQuota{
+		MaxCertificates:    maxCertificates,
+		ActiveCertificates: int(active),
+		MaxOpenRequests:    tenantMaxOpenRequests,
+		RequestsPerDay:     getSettingInt(conf.CertTenantDailyRequests, 0),
+		Types:              []model.CertificateTypeQuotaUsage{},
+	}
+	if _, err := db.GetPendingCertificateRequestByUserID(user.ID); err == nil {
+		quota.OpenRequests = 1
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	times, err := db.GetCertificateRequestTimesByUserSince(user.ID, now.Add(-24*time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	quota.RequestsToday = int64(len(times))
+
+	if quota.ActiveCertificates >= quota.MaxCertificates {
+		quota.Blockers = append(quota.Blockers, fmt.Sprintf(tenantCertificateQuotaReached, quota.MaxCertificates))
+	}
+	if quota.OpenRequests >= quota.MaxOpenRequests {
+		quota.Blockers = append(quota.Blockers, "certificate request is pending for user")
+	}
+	if quota.RequestsPerDay > 0 && len(times) >= quota.RequestsPerDay {
+		// 最早的一个申请移出 24 小时窗口后即可再次提交
+		resetAt := times[len(times)-quota.RequestsPerDay].Add(24 * time.Hour)
+		quota.ResetAt = &resetAt
+		quota.Blockers = append(quota.Blockers, fmt.Sprintf("at most %d certificate requests are allowed in 24 hours", quota.RequestsPerDay))
+	}
+
+	types, err := GetCertificateTypes()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range types {
+		if t.Disabled {
+			continue
+		}
+		usage := model.CertificateTypeQuotaUsage{Type: t.Name, Quota: t.Quota}
+		if t.Quota > 0 {
+			if usage.Active, err = db.CountActiveCertificatesByType(t.Name); err != nil {
+				return nil, err
+			}
+		}
+		quota.Types = append(quota.Types, usage)
+	}
+	quota.CanRequest = len(quota.Blockers) == 0
+	return quota, nil
+}
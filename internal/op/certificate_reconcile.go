@@ -0,0 +1,164 @@
+package op
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+var (
+	reconciliationMu   sync.Mutex
+	lastReconciliation *model.CertificateReconciliation
+)
+
+// ReconcileCertificates 检查申请与证书之间的不一致并保存报告，由定时任务调用
+func ReconcileCertificates() (*model.CertificateReconciliation, error) {
+	report := &model.CertificateReconciliation{
+		GeneratedAt: time.Now(),
+		Anomalies:   []model.CertificateAnomaly{},
+	}
+	requests, err := db.GetApprovedCertificateRequestsWithoutCertificate()
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range requests {
+		report.Anomalies = append(report.Anomalies, model.CertificateAnomaly{
+			Kind:      model.AnomalyApprovedWithoutCertificate,
+			RequestID: req.ID,
+			Detail:    fmt.Sprintf("request #%d of %s was approved by %s but no certificate was issued", req.ID, req.UserName, req.ApprovedBy),
+			Actions:   []string{model.RemediationIssue, model.RemediationReject},
+		})
+	}
+	certs, err := db.GetCertificatesWithoutRequest()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		report.Anomalies = append(report.Anomalies, model.CertificateAnomaly{
+			Kind:          model.AnomalyCertificateWithoutRequest,
+			RequestID:     cert.RequestID,
+			CertificateID: cert.ID,
+			Detail:        fmt.Sprintf("certificate %s references request #%d which does not exist", cert.Name, cert.RequestID),
+			Actions:       []string{model.RemediationDetach},
+		})
+	}
+	certs, err = db.GetCertificatesWithUnapprovedRequest()
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		status := "unknown"
+		if req, err := db.GetCertificateRequestByID(cert.RequestID); err == nil {
+			status = string(req.Status)
+		}
+		report.Anomalies = append(report.Anomalies, model.CertificateAnomaly{
+			Kind:          model.AnomalyStatusMismatch,
+			RequestID:     cert.RequestID,
+			CertificateID: cert.ID,
+			Detail:        fmt.Sprintf("certificate %s was issued but request #%d is %s", cert.Name, cert.RequestID, status),
+			Actions:       []string{model.RemediationSyncRequest, model.RemediationDetach},
+		})
+	}
+	reconciliationMu.Lock()
+	lastReconciliation = report
+	reconciliationMu.Unlock()
+	return report, nil
+}
+
+// GetCertificateReconciliation 获取最近一次检查的报告，refresh 为 true 或尚未检查过时立即重新检查
+func GetCertificateReconciliation(refresh bool) (*model.CertificateReconciliation, error) {
+	reconciliationMu.Lock()
+	report := lastReconciliation
+	reconciliationMu.Unlock()
+	if report != nil && !refresh {
+		return report, nil
+	}
+	return ReconcileCertificates()
+}
+
+// RemediateCertificateAnomaly 对一条不一致记录执行修复操作，完成后重新检查并返回新的报告
+func RemediateCertificateAnomaly(kind, action string, requestID, certID uint, operator *model.User) (*model.CertificateReconciliation, error) {
+	report, err := ReconcileCertificates()
+	if err != nil {
+		return nil, err
+	}
+	var anomaly *model.CertificateAnomaly
+	for i := range report.Anomalies {
+		a := &report.Anomalies[i]
+		if a.Kind == kind && a.RequestID == requestID && a.CertificateID == certID {
+			anomaly = a
+			break
+		}
+	}
+	if anomaly == nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "anomaly %s not found, it may have been resolved", kind)
+	}
+	if !utils.SliceContains(anomaly.Actions, action) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "action %s is not available for %s", action, kind)
+	}
+	if err := remediate(anomaly, action, operator); err != nil {
+		return nil, err
+	}
+	return ReconcileCertificates()
+}
+
+func remediate(a *model.CertificateAnomaly, action string, operator *model.User) error {
+	detail := fmt.Sprintf("reconciliation %s: %s", action, a.Kind)
+	switch action {
+	case model.RemediationIssue:
+		req, err := db.GetCertificateRequestByID(a.RequestID)
+		if err != nil {
+			return err
+		}
+		t, err := CheckCertificateType(req.Type)
+		if err != nil {
+			return err
+		}
+		cert, err := issueCertificate(req, t, time.Now(), "")
+		if err != nil {
+			return err
+		}
+		auditCertificateRequest("certificate.request.reconciled", operator.Username, req, detail)
+		recordCertificateEvent(cert.ID, "certificate.issued", operator.Username, detail)
+	case model.RemediationReject:
+		req, err := db.GetCertificateRequestByID(a.RequestID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		req.Status = model.CertificateStatusRejected
+		req.RejectedBy = operator.Username
+		req.RejectedAt = &now
+		req.RejectedReason = "no certificate was issued for the approved request"
+		if err := db.UpdateCertificateRequest(req); err != nil {
+			return err
+		}
+		auditCertificateRequest("certificate.request.reconciled", operator.Username, req, detail)
+	case model.RemediationDetach:
+		cert, err := db.GetCertificateByID(a.CertificateID)
+		if err != nil {
+			return err
+		}
+		cert.RequestID = 0
+		if err := db.UpdateCertificate(cert); err != nil {
+			return err
+		}
+		recordCertificateEvent(cert.ID, "certificate.reconciled", operator.Username, detail)
+	case model.RemediationSyncRequest:
+		req, err := db.GetCertificateRequestByID(a.RequestID)
+		if err != nil {
+			return err
+		}
+		req.Status = model.CertificateStatusValid
+		if err := db.UpdateCertificateRequest(req); err != nil {
+			return err
+		}
+		auditCertificateRequest("certificate.request.reconciled", operator.Username, req, detail)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RenewExpiringCertificates 在开启了自动续期的证书到期前若干天签发续期证书，由定时任务调用。
+// 旧证书在到期前保持有效，新旧证书通过 SupersedesID 和 SupersededByID 关联，部署方可在重叠期内切换
+func RenewExpiringCertificates(ctx context.Context) error {
+	days := getSettingInt(conf.CertRenewOverlapDays, 0)
+	if days <= 0 {
+		return nil
+	}
+	now := time.Now()
+	certs, err := db.GetCertificatesToRenew(now.AddDate(0, 0, days))
+	if err != nil {
+		return err
+	}
+	for i := range certs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		cert := &certs[i]
+		// 冻结期间推迟续期，冻结结束后的下一轮再续期
+		if w, err := activeFreezeWindow(cert.OwnerID, now); err != nil || w != nil {
+			if err != nil {
+				log.Errorf("failed to check freeze window of certificate %d: %+v", cert.ID, err)
+			}
+			continue
+		}
+		// 蜜罐证书只用于诱捕，不续期
+		if isHoneytoken, err := isCertificateHoneytoken(cert.ID); err != nil || isHoneytoken {
+			if err != nil {
+				log.Errorf("failed to check honeytoken of certificate %d: %+v", cert.ID, err)
+			}
+			continue
+		}
+		if _, err := renewCertificate(cert, now, "system"); err != nil {
+			log.Errorf("failed to renew certificate %d: %+v", cert.ID, err)
+		}
+	}
+	return nil
+}
+
+// RenewCertificateByID 管理员手动续期证书
+func RenewCertificateByID(id uint, operator *model.User, ip string) (*model.Certificate, error) {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return renewCertificateManually(cert, operator, ip)
+}
+
+// getTenantCertificate 获取租户自己的证书，id 为 0 时使用到期最晚的有效证书
+func getTenantCertificate(user *model.User, id uint) (*model.Certificate, error) {
+	if id == 0 {
+		return db.GetCertificateByOwnerID(user.ID)
+	}
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if cert.OwnerID != user.ID {
+		return nil, errs.PermissionDenied
+	}
+	return cert, nil
+}
+
+// RenewTenantCertificate 租户手动续期自己的证书，id 为 0 时续期到期最晚的有效证书
+func RenewTenantCertificate(user *model.User, id uint, ip string) (*model.Certificate, error) {
+	cert, err := getTenantCertificate(user, id)
+	if err != nil {
+		return nil, err
+	}
+	return renewCertificateManually(cert, user, ip)
+}
+
+// SetTenantCertificateAutoRenew 租户开启或关闭证书的自动续期，id 为 0 时使用到期最晚的有效证书
+func SetTenantCertificateAutoRenew(user *model.User, id uint, autoRenew bool) (*model.Certificate, error) {
+	cert, err := getTenantCertificate(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !cert.IsValid() || cert.IsExpired() {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate %s is not valid, current status: %s", cert.Name, cert.Status)
+	}
+	if err := db.SetCertificateAutoRenew(cert.ID, autoRenew); err != nil {
+		return nil, err
+	}
+	cert.AutoRenew = autoRenew
+	recordCertificateEvent(cert.ID, "certificate.auto_renew", user.Username, fmt.Sprintf("auto renew %t", autoRenew))
+	return cert, nil
+}
+
+func renewCertificateManually(cert *model.Certificate, operator *model.User, ip string) (*model.Certificate, error) {
+	if !cert.IsValid() || cert.IsExpired() {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate %s is not valid, current status: %s", cert.Name, cert.Status)
+	}
+	if cert.IsSuperseded() {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate %s has already been renewed as certificate %d", cert.Name, cert.SupersededByID)
+	}
+	now := time.Now()
+	if w, err := activeFreezeWindow(cert.OwnerID, now); err != nil {
+		return nil, err
+	} else if w != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate issuance is frozen until %s", w.EndAt.Format(time.RFC3339))
+	}
+	if isHoneytoken, err := isCertificateHoneytoken(cert.ID); err != nil {
+		return nil, err
+	} else if isHoneytoken {
+		// 蜜罐证书对外表现为普通证书，续期尝试同样触发告警
+		TripCertificateHoneytoken(cert, operator.Username, "renew", ip)
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s can not be renewed", cert.Name)
+	}
+	return renewCertificate(cert, now, operator.Username)
+}
+
+func isCertificateHoneytoken(certID uint) (bool, error) {
+	if _, err := db.GetCertificateHoneytoken(certID); err == nil {
+		return true, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	return false, nil
+}
+
+func renewCertificate(cert *model.Certificate, now time.Time, actor string) (*model.Certificate, error) {
+	renewal := &model.Certificate{
+		Name:              cert.Name,
+		Type:              cert.Type,
+		Status:            model.CertificateStatusValid,
+		Owner:             cert.Owner,
+		OwnerID:           cert.OwnerID,
+		RequestID:         cert.RequestID,
+		ProfileID:         cert.ProfileID,
+		IssuerID:          issuingCertificateAuthorityID(),
+		SupersedesID:      cert.ID,
+		AutoRenew:         cert.AutoRenew,
+		ResponsibleTeam:   cert.ResponsibleTeam,
+		ContactEmail:      cert.ContactEmail,
+		EscalationContact: cert.EscalationContact,
+		IssuedDate:        now,
+		// 续期证书沿用旧证书的有效期长度
+		ExpirationDate: now.Add(cert.ExpirationDate.Sub(cert.IssuedDate)),
+	}
+	if err := regenerateCertificate(cert, renewal); err != nil {
+		return nil, err
+	}
+	if err := db.RenewCertificate(cert, renewal); err != nil {
+		return nil, err
+	}
+	recordCertificateEvent(cert.ID, "certificate.superseded", actor,
+		fmt.Sprintf("renewed as certificate %d, stays valid until %s", renewal.ID, cert.ExpirationDate.Format(time.DateOnly)))
+	recordCertificateEvent(renewal.ID, "certificate.renewed", actor, fmt.Sprintf("renewal of certificate %d", cert.ID))
+	emitCertificateEvent("certificate.renewed", renewal,
+		fmt.Sprintf("Certificate %s has been renewed", renewal.Name),
+		fmt.Sprintf("A renewed certificate valid until %s has been issued. The current certificate stays valid until %s, please deploy the new one before then.",
+			renewal.ExpirationDate.Format(time.DateOnly), cert.ExpirationDate.Format(time.DateOnly)))
+	reloadTLSCertificateAsync()
+	return renewal, nil
+}
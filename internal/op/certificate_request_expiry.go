@@ -0,0 +1,70 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// ExpireCertificateRequests 将提交后超过 cert_request_expire_days 天仍未审批的申请标记为过期并通知申请人，
+// 过期的申请不再占用待处理名额，申请人可以重新提交。由定时任务调用
+func ExpireCertificateRequests(ctx context.Context) error {
+	days := getSettingInt(conf.CertRequestExpireDays, 30)
+	if days <= 0 {
+		return nil
+	}
+	requests, err := db.GetPendingCertificateRequestsBefore(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return err
+	}
+	n := 0
+	for i := range requests {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := expireCertificateRequest(requests[i].ID, days); err != nil {
+			log.Warnf("failed to expire certificate request %d: %+v", requests[i].ID, err)
+			continue
+		}
+		n++
+	}
+	if n > 0 {
+		log.Infof("expired %d stale certificate requests", n)
+	}
+	return nil
+}
+
+// expireCertificateRequest 在事务中锁定申请，申请已被审批或拒绝时跳过
+func expireCertificateRequest(id uint, days int) error {
+	var req *model.CertificateRequest
+	err := db.Transaction(func(tx db.Tx) error {
+		var err error
+		req, err = tx.LockCertificateRequest(id)
+		if err != nil {
+			return err
+		}
+		if !req.IsPending() {
+			return errs.NewErr(errs.CertificateConflict, "request is not pending, current status: %s", req.Status)
+		}
+		now := time.Now()
+		req.Status = model.CertificateStatusExpired
+		req.ExpiredAt = &now
+		return tx.UpdateCertificateRequest(req)
+	})
+	if err != nil {
+		return err
+	}
+	detail := fmt.Sprintf("not approved within %d days", days)
+	auditCertificateRequest("certificate.request.expired", "system", req, detail)
+	emitCertificateRequestEvent("certificate.request.expired", req,
+		fmt.Sprintf("Certificate request #%d has expired", req.ID),
+		fmt.Sprintf("Certificate request #%d of %s was not approved within %d days and has expired, please submit a new request if the certificate is still needed.", req.ID, req.UserName, days))
+	postCertificateTicketUpdate(req, fmt.Sprintf("expired, %s.", detail))
+	return nil
+}
@@ -0,0 +1,102 @@
+package op_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func createExpiryTestRequest(t *testing.T, status model.CertificateStatus, age time.Duration) *model.CertificateRequest {
+	t.Helper()
+	req := &model.CertificateRequest{
+		UserName:  "expiry",
+		Type:      model.CertificateTypeUser,
+		Status:    status,
+		CreatedAt: time.Now().Add(-age),
+	}
+	if err := db.CreateCertificateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestExpireCertificateRequests(t *testing.T) {
+	err := op.SaveSettingItem(&model.SettingItem{Key: conf.CertRequestExpireDays, Value: "30", Type: conf.TypeNumber, Group: model.CERTIFICATE, Flag: model.PRIVATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := 31 * 24 * time.Hour
+	tests := []struct {
+		name   string
+		status model.CertificateStatus
+		age    time.Duration
+		want   model.CertificateStatus
+	}{
+		{name: "stale pending", status: model.CertificateStatusPending, age: stale, want: model.CertificateStatusExpired},
+		{name: "recent pending", status: model.CertificateStatusPending, age: time.Hour, want: model.CertificateStatusPending},
+		{name: "stale scheduled", status: model.CertificateStatusScheduled, age: stale, want: model.CertificateStatusScheduled},
+		{name: "stale rejected", status: model.CertificateStatusRejected, age: stale, want: model.CertificateStatusRejected},
+		{name: "stale draft", status: model.CertificateStatusDraft, age: stale, want: model.CertificateStatusDraft},
+	}
+	ids := make([]uint, len(tests))
+	for i, tt := range tests {
+		ids[i] = createExpiryTestRequest(t, tt.status, tt.age).ID
+	}
+	if err := op.ExpireCertificateRequests(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		req, err := db.GetCertificateRequestByID(ids[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Status != tt.want {
+			t.Errorf("%s: got status %s, want %s", tt.name, req.Status, tt.want)
+		}
+		if (req.ExpiredAt != nil) != (tt.want == model.CertificateStatusExpired) {
+			t.Errorf("%s: unexpected expired at %v", tt.name, req.ExpiredAt)
+		}
+	}
+}
+
+func TestExpireCertificateRequestRecheck(t *testing.T) {
+	// 查询到过期申请后、锁定前申请可能已被审批或拒绝，锁定后重新检查状态
+	tests := []struct {
+		status   model.CertificateStatus
+		conflict bool
+	}{
+		{status: model.CertificateStatusPending},
+		{status: model.CertificateStatusScheduled, conflict: true},
+		{status: model.CertificateStatusValid, conflict: true},
+		{status: model.CertificateStatusRejected, conflict: true},
+		{status: model.CertificateStatusExpired, conflict: true},
+	}
+	for _, tt := range tests {
+		req := createExpiryTestRequest(t, tt.status, 31*24*time.Hour)
+		err := op.ExpireCertificateRequest(req.ID, 30)
+		if tt.conflict != errors.Is(err, errs.CertificateConflict) || (!tt.conflict && err != nil) {
+			t.Errorf("%s: unexpected error %v", tt.status, err)
+		}
+		got, err := db.GetCertificateRequestByID(req.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := tt.status
+		if !tt.conflict {
+			want = model.CertificateStatusExpired
+		}
+		if got.Status != want {
+			t.Errorf("%s: got status %s, want %s", tt.status, got.Status, want)
+		}
+	}
+	if err := op.ExpireCertificateRequest(0, 30); err == nil {
+		t.Error("expired a missing request")
+	}
+}
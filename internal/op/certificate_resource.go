@@ -0,0 +1,243 @@
+package op
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// maxExternalIDLength 声明式资源标识的最大长度
+const maxExternalIDLength = 128
+
+func checkExternalID(externalID string) error {
+	if externalID == "" || len(externalID) > maxExternalIDLength || strings.ContainsAny(externalID, " \t\r\n") {
+		return errs.NewErr(errs.InvalidCertificateRequest, "invalid external id: %q", externalID)
+	}
+	return nil
+}
+
+// GetCertificateResource 获取租户声明式资源的当前状态，Drift 只包含证书不再有效的情况
+func GetCertificateResource(user *model.User, externalID string) (*model.CertificateResource, error) {
+	if err := checkExternalID(externalID); err != nil {
+		return nil, err
+	}
+	req, err := db.GetCertificateRequestByExternalID(user.ID, externalID)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.CertificateResource{ExternalID: externalID, Request: req, Status: req.Status, Drift: []model.CertificateResourceDrift{}}
+	if err := loadCertificateResource(res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// loadCertificateResource 加载已签发申请的当前证书，证书不再有效时记录状态的偏差
+func loadCertificateResource(res *model.CertificateResource) error {
+	if res.Request.Status != model.CertificateStatusValid {
+		return nil
+	}
+	cert, err := db.GetCurrentCertificateByRequestID(res.Request.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if cert == nil {
+		res.Status = ""
+		res.Drift = append(res.Drift, model.CertificateResourceDrift{Field: "status", Desired: model.CertificateStatusValid, Actual: nil})
+		return nil
+	}
+	res.Certificate, res.Status = cert, cert.Status
+	if !cert.IsValid() {
+		res.Drift = append(res.Drift, model.CertificateResourceDrift{Field: "status", Desired: model.CertificateStatusValid, Actual: cert.Status})
+	}
+	return nil
+}
+
+// ApplyCertificateResource 将租户声明式资源调整为期望状态，同一期望状态重复提交不会产生新的申请：
+// 资源不存在时提交新申请；待审批的申请与期望状态不一致时原地修改并重新审批；
+// 已签发的证书与期望状态不一致或不再有效、以及已拒绝的申请被修改时提交新申请，原证书保持不变。
+// dryRun 时只计算偏差和将要执行的操作
+func ApplyCertificateResource(user *model.User, externalID string, spec *model.CertificateResourceSpec, dryRun bool) (*model.CertificateResource, error) {
+	if err := checkExternalID(externalID); err != nil {
+		return nil, err
+	}
+	desired, err := desiredCertificateRequest(externalID, spec)
+	if err != nil {
+		return nil, err
+	}
+	res := &model.CertificateResource{ExternalID: externalID, DryRun: dryRun, Drift: []model.CertificateResourceDrift{}}
+	current, err := db.GetCertificateRequestByExternalID(user.ID, externalID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if current == nil {
+		res.Action = model.CertificateResourceCreated
+		return applyCertificateResource(user, res, desired, nil)
+	}
+
+	res.Request, res.Status = current, current.Status
+	res.Drift = certificateRequestDrift(current, desired)
+	switch current.Status {
+	case model.CertificateStatusPending:
+		if len(res.Drift) == 0 {
+			res.Action = model.CertificateResourceUnchanged
+			return res, nil
+		}
+		res.Action = model.CertificateResourceUpdated
+		return applyCertificateResource(user, res, desired, current)
+	case model.CertificateStatusScheduled:
+		if len(res.Drift) > 0 {
+			return nil, errs.NewErr(errs.CertificateConflict, "request %d is scheduled for issuance, apply again after it is issued", current.ID)
+		}
+		res.Action = model.CertificateResourceUnchanged
+		return res, nil
+	case model.CertificateStatusValid:
+		if err := loadCertificateResource(res); err != nil {
+			return nil, err
+		}
+	}
+	// 已拒绝的申请只有期望状态变化后才重新提交，避免重复提交被拒绝的申请
+	if len(res.Drift) == 0 {
+		res.Action = model.CertificateResourceUnchanged
+		return res, nil
+	}
+	res.Action = model.CertificateResourceReplaced
+	return applyCertificateResource(user, res, desired, nil)
+}
+
+// applyCertificateResource 执行计算出的操作，current 不为 nil 时原地修改待审批的申请，否则提交新申请
+func applyCertificateResource(user *model.User, res *model.CertificateResource, desired, current *model.CertificateRequest) (*model.CertificateResource, error) {
+	if res.DryRun {
+		return res, nil
+	}
+	if current == nil {
+		req, err := createTenantCertificateRequest(user, desired)
+		if err != nil {
+			return nil, err
+		}
+		res.Request, res.Certificate, res.Status = req, nil, req.Status
+		return res, nil
+	}
+	if err := updatePendingCertificateRequest(user, current, desired); err != nil {
+		return nil, err
+	}
+	res.Request, res.Status = current, current.Status
+	return res, nil
+}
+
+// updatePendingCertificateRequest 按期望状态修改待审批的申请，已完成的审批作废，需要重新按审批链审批
+func updatePendingCertificateRequest(user *model.User, req, desired *model.CertificateRequest) error {
+	t, err := CheckCertificateType(desired.Type)
+	if err != nil {
+		return err
+	}
+	if err := checkValidityPreset(t, desired.ValidityPreset); err != nil {
+		return err
+	}
+	customFields, err := validateCustomFields(desired.Type, desired.CustomFields)
+	if err != nil {
+		return err
+	}
+	if _, err := checkRequestProfile(desired.ProfileID, user.Username, desired.SANs, desired.CSR); err != nil {
+		return err
+	}
+	if err := checkPublicCertificateRequest(desired.Type, desired.SANs, desired.CSR); err != nil {
+		return err
+	}
+	if strings.TrimSpace(desired.Reason) != "" {
+		req.Reason = desired.Reason
+	}
+	req.Type = desired.Type
+	req.ProfileID = desired.ProfileID
+	req.ValidityPreset = desired.ValidityPreset
+	req.CustomFields = customFields
+	req.SANs = desired.SANs
+	req.CSR = desired.CSR
+	req.Approvals, req.ApprovalChecks = nil, nil
+	if err := db.UpdateCertificateRequest(req); err != nil {
+		return errors.Wrap(err, "failed to update request")
+	}
+	auditCertificateRequest("certificate.request.updated", user.Username, req, "declarative apply")
+	return nil
+}
+
+// desiredCertificateRequest 规范化期望状态，与保存的申请使用相同的格式以便比较
+func desiredCertificateRequest(externalID string, spec *model.CertificateResourceSpec) (*model.CertificateRequest, error) {
+	sans, err := checkSANs(spec.SANs)
+	if err != nil {
+		return nil, err
+	}
+	csr, sans, err := checkRequestCSR(spec.CSR, sans)
+	if err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(spec.Reason)
+	if reason == "" {
+		reason = "declared as " + externalID
+	}
+	return &model.CertificateRequest{
+		ExternalID:     externalID,
+		Type:           spec.Type,
+		ProfileID:      spec.ProfileID,
+		Reason:         reason,
+		CustomFields:   spec.CustomFields,
+		ValidityPreset: spec.ValidityPreset,
+		SANs:           sans,
+		CSR:            csr,
+	}, nil
+}
+
+// certificateRequestDrift 比较申请与期望状态，申请理由不属于证书的属性，不参与比较
+func certificateRequestDrift(actual, desired *model.CertificateRequest) []model.CertificateResourceDrift {
+	drift := []model.CertificateResourceDrift{}
+	add := func(field string, d, a any) {
+		drift = append(drift, model.CertificateResourceDrift{Field: field, Desired: d, Actual: a})
+	}
+	if actual.Type != desired.Type {
+		add("type", desired.Type, actual.Type)
+	}
+	if actual.ProfileID != desired.ProfileID {
+		add("profile_id", desired.ProfileID, actual.ProfileID)
+	}
+	if actual.ValidityPreset != desired.ValidityPreset {
+		add("validity_preset", desired.ValidityPreset, actual.ValidityPreset)
+	}
+	if !sameStrings(actual.SANs, desired.SANs) {
+		add("sans", desired.SANs, actual.SANs)
+	}
+	if actual.CSR != desired.CSR {
+		add("csr", desired.CSR, actual.CSR)
+	}
+	if !sameCustomFields(actual.CustomFields, desired.CustomFields) {
+		add("custom_fields", desired.CustomFields, actual.CustomFields)
+	}
+	return drift
+}
+
+// sameStrings 忽略顺序比较两个列表
+func sameStrings(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
+
+// sameCustomFields 比较自定义字段，值为空的字段视为未填写
+func sameCustomFields(a, b map[string]string) bool {
+	nonEmpty := func(m map[string]string) map[string]string {
+		res := make(map[string]string, len(m))
+		for k, v := range m {
+			if v = strings.TrimSpace(v); v != "" {
+				res[k] = v
+			}
+		}
+		return res
+	}
+	return maps.Equal(nonEmpty(a), nonEmpty(b))
+}
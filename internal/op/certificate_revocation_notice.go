@@ -0,0 +1,126 @@
+package op
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/certlint"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// webhookMaxSkew 入站 webhook 时间戳允许的最大偏差，防止重放
+const webhookMaxSkew = 5 * time.Minute
+
+// revocationReasons RFC 5280 定义的吊销原因
+var revocationReasons = []string{
+	"unspecified", model.RevocationReasonKeyCompromise, "cACompromise", "affiliationChanged",
+	model.RevocationReasonSuperseded, "cessationOfOperation", "privilegeWithdrawn", "aACompromise",
+}
+
+// fillCertificateIdentity 从证书内容解析序列号和指纹并检查证书，内容不是有效证书时清空
+func fillCertificateIdentity(cert *model.Certificate) {
+	cert.SerialNumber, cert.Fingerprint, cert.Lints = "", "", nil
+	block, _ := pem.Decode([]byte(strings.TrimSpace(cert.Content)))
+	if block == nil || block.Type != "CERTIFICATE" {
+		return
+	}
+	c, err := parseX509(block.Bytes)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(c.Raw)
+	cert.SerialNumber = c.SerialNumber.Text(16)
+	cert.Fingerprint = hex.EncodeToString(sum[:])
+	cert.Lints = certlint.Lint(c)
+}
+
+// normalizeHex 统一序列号和指纹的格式：去掉冒号和空白并转为小写
+func normalizeHex(s string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", " ", "", "-", "").Replace(strings.TrimSpace(s)))
+}
+
+// VerifyRevocationNotice 校验上游发送的吊销通知签名
+func VerifyRevocationNotice(body []byte, timestamp, signature string) error {
+	return verifyWebhookSignature(getSettingStr(conf.CertRevocationWebhookSecret, ""), "revocation webhook", body, timestamp, signature)
+}
+
+// verifyWebhookSignature 校验入站 webhook 的签名，签名为 hex(HMAC-SHA256(secret, timestamp + "." + body))，
+// secret 为空表示该 webhook 未启用
+func verifyWebhookSignature(secret, name string, body []byte, timestamp, signature string) error {
+	if secret == "" {
+		return errs.NewErr(errs.PermissionDenied, "%s is disabled", name)
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errs.NewErr(errs.PermissionDenied, "invalid timestamp")
+	}
+	if skew := time.Since(time.Unix(ts, 0)); skew > webhookMaxSkew || skew < -webhookMaxSkew {
+		return errs.NewErr(errs.PermissionDenied, "timestamp is out of range")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.TrimPrefix(signature, "sha256="))) {
+		return errs.NewErr(errs.PermissionDenied, "invalid signature")
+	}
+	return nil
+}
+
+// HandleRevocationNotice 处理上游 CA 或安全工具报告的证书泄露，吊销证书并通知所有者和安全审查人。
+// 已吊销的证书不会重复处理，返回的 bool 表示本次是否吊销了证书，未填写报告方时按 external 记录
+func HandleRevocationNotice(notice *model.RevocationNotice) (*model.Certificate, bool, error) {
+	// 证书的序列号按不带前导零的十六进制保存，去掉前导零后再校验，"0" 不能作为序列号
+	serial, fingerprint := strings.TrimLeft(normalizeHex(notice.Serial), "0"), normalizeHex(notice.Fingerprint)
+	if serial == "" && fingerprint == "" {
+		return nil, false, errs.NewErr(errs.InvalidCertificateRequest, "serial or fingerprint is required")
+	}
+	reason := notice.Reason
+	if reason == "" {
+		reason = model.RevocationReasonKeyCompromise
+	}
+	if !utils.SliceContains(revocationReasons, reason) {
+		return nil, false, errs.NewErr(errs.InvalidCertificateRequest, "unknown revocation reason: %s", reason)
+	}
+	cert, err := db.GetCertificateBySerialOrFingerprint(serial, fingerprint)
+	if err != nil {
+		return nil, false, err
+	}
+	if cert.Status == model.CertificateStatusRevoked {
+		return cert, false, nil
+	}
+	if notice.Source == "" {
+		notice.Source = "external"
+	}
+	source := notice.Source
+	detail := fmt.Sprintf("reported by %s: %s", source, reason)
+	if notice.Detail != "" {
+		detail += ", " + notice.Detail
+	}
+	revoked, err := revokeCertificate(cert.ID, "webhook:"+source, reason, detail)
+	if err != nil {
+		// 同时到达的通知已经吊销了证书
+		if errors.Is(err, errs.CertificateConflict) {
+			if current, _ := db.GetCertificateByID(cert.ID); current != nil && current.Status == model.CertificateStatusRevoked {
+				return current, false, nil
+			}
+		}
+		return nil, false, err
+	}
+	cert = revoked
+	raiseCertificateAnomaly(fmt.Sprintf("revocation_notice:%d", cert.ID), "webhook:"+source,
+		fmt.Sprintf("Certificate %s was revoked by an external notice", cert.Name),
+		fmt.Sprintf("Certificate %s of %s was revoked, %s.", cert.Name, cert.Owner, detail))
+	return cert, true, nil
+}
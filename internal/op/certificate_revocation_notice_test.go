@@ -0,0 +1,61 @@
+package op_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func signRevocationNotice(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyRevocationNotice(t *testing.T) {
+	err := op.SaveSettingItem(&model.SettingItem{Key: conf.CertRevocationWebhookSecret, Value: "secret", Type: conf.TypeString, Group: model.CERTIFICATE, Flag: model.PRIVATE})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := []byte(`{"serial":"0a"}`)
+	now := time.Now()
+	ts := func(d time.Duration) string { return strconv.FormatInt(now.Add(d).Unix(), 10) }
+	tests := []struct {
+		name      string
+		timestamp string
+		signature string
+		wantErr   bool
+	}{
+		{name: "valid", timestamp: ts(0), signature: signRevocationNotice("secret", ts(0), body)},
+		{name: "valid with prefix", timestamp: ts(0), signature: "sha256=" + signRevocationNotice("secret", ts(0), body)},
+		{name: "wrong secret", timestamp: ts(0), signature: signRevocationNotice("other", ts(0), body), wantErr: true},
+		{name: "signature of another timestamp", timestamp: ts(0), signature: signRevocationNotice("secret", ts(-time.Second), body), wantErr: true},
+		{name: "too old", timestamp: ts(-6 * time.Minute), signature: signRevocationNotice("secret", ts(-6*time.Minute), body), wantErr: true},
+		{name: "too far in the future", timestamp: ts(6 * time.Minute), signature: signRevocationNotice("secret", ts(6*time.Minute), body), wantErr: true},
+		{name: "invalid timestamp", timestamp: "now", signature: signRevocationNotice("secret", "now", body), wantErr: true},
+		{name: "missing signature", timestamp: ts(0), wantErr: true},
+	}
+	for _, tt := range tests {
+		err := op.VerifyRevocationNotice(body, tt.timestamp, tt.signature)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v, want error %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestHandleRevocationNoticeRequiresIdentifier(t *testing.T) {
+	for _, serial := range []string{"", "0", "00", ":", "0:00", " - "} {
+		cert, revoked, err := op.HandleRevocationNotice(&model.RevocationNotice{Serial: serial})
+		if err == nil || cert != nil || revoked {
+			t.Errorf("serial %q: got certificate %v revoked %v error %v, want an error", serial, cert, revoked, err)
+		}
+	}
+}
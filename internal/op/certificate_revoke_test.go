@@ -0,0 +1,54 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+func TestRevokeCertificateRechecksStatus(t *testing.T) {
+	operator := &model.User{Username: "admin", Role: model.ADMIN}
+	tests := []struct {
+		name       string
+		status     model.CertificateStatus
+		expiration time.Duration
+		conflict   bool
+	}{
+		{name: "valid", status: model.CertificateStatusValid, expiration: time.Hour},
+		{name: "held", status: model.CertificateStatusHold, expiration: time.Hour},
+		{name: "revoked", status: model.CertificateStatusRevoked, expiration: time.Hour, conflict: true},
+		{name: "expired", status: model.CertificateStatusExpired, expiration: -time.Hour, conflict: true},
+		{name: "past expiration", status: model.CertificateStatusValid, expiration: -time.Hour, conflict: true},
+	}
+	for _, tt := range tests {
+		revokedAt := time.Now().Add(-24 * time.Hour)
+		cert := &model.Certificate{Name: "revoke-" + tt.name, Type: model.CertificateTypeUser, Status: tt.status,
+			ExpirationDate: time.Now().Add(tt.expiration)}
+		if tt.status == model.CertificateStatusRevoked {
+			cert.RevokedAt = &revokedAt
+		}
+		if err := db.CreateCertificate(cert); err != nil {
+			t.Fatal(err)
+		}
+		err := op.RevokeCertificate(cert.ID, operator)
+		if tt.conflict != errors.Is(err, errs.CertificateConflict) || (!tt.conflict && err != nil) {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		}
+		got, err := db.GetCertificateByID(cert.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tt.conflict && got.Status != model.CertificateStatusRevoked {
+			t.Errorf("%s: got status %s, want revoked", tt.name, got.Status)
+		}
+		// 重复吊销不能刷新吊销时间，否则下载宽限期会重新计算
+		if tt.status == model.CertificateStatusRevoked && (got.RevokedAt == nil || !got.RevokedAt.Equal(revokedAt)) {
+			t.Errorf("%s: revoked at changed to %v", tt.name, got.RevokedAt)
+		}
+	}
+}
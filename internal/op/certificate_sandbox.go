@@ -0,0 +1,171 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+	"github.com/pkg/errors"
+)
+
+// 沙箱 CA 的名称和有效期，剩余有效期不足以签发证书时重新生成
+const (
+	sandboxCACommonName = "OpenList Sandbox CA (untrusted)"
+	sandboxCAValidity   = 10 * 365 * 24 * time.Hour
+)
+
+// sandboxIssuer 缓存由设置解析出的沙箱 CA，设置内容变化后重新解析
+var sandboxIssuer = struct {
+	sync.Mutex
+	content string
+	issuer  *certissuer.Issuer
+}{}
+
+// CreateSandboxCertificateRequest 提交沙箱申请并立即由沙箱 CA 签发短期测试证书。沙箱申请不需要审批，
+// 不计入证书、申请和类型的配额，也不影响租户的正式证书。私钥只在结果中返回一次，服务端不保存
+func CreateSandboxCertificateRequest(user *model.User, reqType model.CertificateType, reason string, sans []string, csr string) (*model.SandboxCertificate, error) {
+	if !getSettingBool(conf.CertSandboxEnabled) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "sandbox issuance is disabled")
+	}
+	if _, err := CheckCertificateType(reqType); err != nil {
+		return nil, err
+	}
+	if reqType == model.CertificateTypeSSH {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "ssh certificates can not be issued in the sandbox")
+	}
+	sans, err := checkSANs(sans)
+	if err != nil {
+		return nil, err
+	}
+	if csr, sans, err = checkRequestCSR(csr, sans); err != nil {
+		return nil, err
+	}
+	req := &model.CertificateRequest{
+		UserName: user.Username,
+		UserID:   user.ID,
+		Type:     reqType,
+		Status:   model.CertificateStatusValid,
+		Reason:   reason,
+		SANs:     sans,
+		CSR:      csr,
+		Sandbox:  true,
+	}
+	now := time.Now()
+	req.ApprovedBy, req.ApprovedAt = "sandbox", &now
+	issuer, err := getSandboxIssuer(now)
+	if err != nil {
+		return nil, err
+	}
+	cert := &model.Certificate{
+		Name:           fmt.Sprintf("%s-%s-sandbox", user.Username, reqType),
+		Type:           reqType,
+		Status:         model.CertificateStatusValid,
+		Owner:          user.Username,
+		OwnerID:        user.ID,
+		IssuedDate:     now,
+		ExpirationDate: now.Add(time.Duration(getSettingInt(conf.CertSandboxValidityHours, 24)) * time.Hour),
+		Sandbox:        true,
+	}
+	commonName := user.Username
+	if reqType == model.CertificateTypeNode && len(sans) > 0 {
+		commonName = sans[0]
+	}
+	publicKey, err := requestPublicKey(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := signCertificate(issuer, cert, commonName, sans, publicKey); err != nil {
+		return nil, err
+	}
+	res := &model.SandboxCertificate{Request: req, Certificate: cert, PrivateKey: cert.PrivateKey}
+	cert.PrivateKey = ""
+	err = db.Transaction(func(tx db.Tx) error {
+		if err := tx.CreateCertificateRequest(req); err != nil {
+			return errors.Wrap(err, "failed to create request")
+		}
+		cert.RequestID = req.ID
+		return errors.Wrap(tx.CreateCertificate(cert), "failed to create certificate")
+	})
+	if err != nil {
+		return nil, err
+	}
+	auditCertificateRequest("certificate.request.created", user.Username, req, "sandbox")
+	recordCertificateEvent(cert.ID, "certificate.issued", "sandbox", "sandbox")
+	return res, nil
+}
+
+// getSandboxIssuer 获取沙箱 CA，未配置或有效期不足时生成新的自签名 CA 并保存到设置中
+func getSandboxIssuer(now time.Time) (*certissuer.Issuer, error) {
+	sandboxIssuer.Lock()
+	defer sandboxIssuer.Unlock()
+	content := getSettingStr(conf.CertSandboxCACertificate, "")
+	validity := time.Duration(getSettingInt(conf.CertSandboxValidityHours, 24)) * time.Hour
+	if sandboxIssuer.issuer == nil || sandboxIssuer.content != content {
+		sandboxIssuer.issuer, sandboxIssuer.content = nil, content
+		if content != "" {
+			issuer, err := parseSandboxIssuer(content, getSettingStr(conf.CertSandboxCAPrivateKey, ""))
+			if err != nil {
+				return nil, err
+			}
+			sandboxIssuer.issuer = issuer
+		}
+	}
+	if sandboxIssuer.issuer != nil && now.Add(validity).Before(sandboxIssuer.issuer.Certificate.NotAfter) {
+		return sandboxIssuer.issuer, nil
+	}
+	res, err := certissuer.NewCA(&certissuer.CARequest{
+		CommonName:   sandboxCACommonName,
+		Organization: "OpenList Sandbox",
+		KeyAlgorithm: certissuer.KeyECDSAP256,
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(sandboxCAValidity),
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate sandbox ca")
+	}
+	if err := saveSandboxCA(res.CertificatePEM, res.PrivateKeyPEM); err != nil {
+		return nil, err
+	}
+	issuer, err := parseSandboxIssuer(res.CertificatePEM, res.PrivateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	sandboxIssuer.issuer, sandboxIssuer.content = issuer, strings.TrimSpace(res.CertificatePEM)
+	return issuer, nil
+}
+
+func parseSandboxIssuer(content, keyPEM string) (*certissuer.Issuer, error) {
+	certs, err := parseCertificates(content)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid sandbox ca certificate")
+	}
+	key, err := parsePrivateKey(content, keyPEM)
+	if err != nil {
+		return nil, errors.WithMessage(err, "invalid sandbox ca private key")
+	}
+	return &certissuer.Issuer{Certificate: certs[0], Key: key}, nil
+}
+
+func saveSandboxCA(content, keyPEM string) error {
+	items, err := GetSettingItemInKeys([]string{conf.CertSandboxCACertificate, conf.CertSandboxCAPrivateKey})
+	if err != nil {
+		return err
+	}
+	if len(items) != 2 {
+		return errors.New("sandbox ca settings are missing")
+	}
+	for i := range items {
+		if items[i].Key == conf.CertSandboxCACertificate {
+			items[i].Value = strings.TrimSpace(content)
+		} else {
+			items[i].Value = strings.TrimSpace(keyPEM)
+		}
+	}
+	return SaveSettingItems(items)
+}
@@ -0,0 +1,82 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
+	log "github.com/sirupsen/logrus"
+)
+
+// IssueScheduledCertificates 为计划签发时间已到的申请签发证书，由定时任务调用。
+// 签发失败的申请保持计划状态，下一轮继续重试
+func IssueScheduledCertificates(ctx context.Context) error {
+	now := time.Now()
+	requests, err := db.GetScheduledCertificateRequestsBefore(now)
+	if err != nil {
+		return err
+	}
+	for i := range requests {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		req := &requests[i]
+		if err := issueScheduledCertificate(req, now); err != nil {
+			log.Errorf("failed to issue scheduled certificate for request %d: %+v", req.ID, err)
+		}
+	}
+	return nil
+}
+
+func issueScheduledCertificate(req *model.CertificateRequest, now time.Time) error {
+	// 冻结期间推迟签发，冻结结束后的下一轮再签发
+	if w, err := activeFreezeWindow(req.UserID, now); err != nil || w != nil {
+		return err
+	}
+	return issueApprovedCertificate(req, now)
+}
+
+// issuingRequests 正在签发证书的申请，避免后台签发与定时任务同时为一个申请向公共 CA 下单
+var issuingRequests generic_sync.MapOf[uint, struct{}]
+
+// issueApprovedCertificateAsync 在后台为已批准的申请签发证书，签发失败的申请保持计划状态，由定时任务重试
+func issueApprovedCertificateAsync(req *model.CertificateRequest, now time.Time) {
+	r := *req
+	req = &r
+	go func() {
+		if err := issueApprovedCertificate(req, now); err != nil {
+			log.Errorf("failed to issue certificate for request %d: %+v", req.ID, err)
+		}
+	}()
+}
+
+func issueApprovedCertificate(req *model.CertificateRequest, now time.Time) error {
+	if _, loaded := issuingRequests.LoadOrStore(req.ID, struct{}{}); loaded {
+		return nil
+	}
+	defer issuingRequests.Delete(req.ID)
+	t, err := CheckCertificateType(req.Type)
+	if err != nil {
+		return err
+	}
+	// 批准到签发之间配额和预设都可能发生变化
+	if err := checkCertificateTypeQuota(t); err != nil {
+		return err
+	}
+	if err := checkValidityPreset(t, req.ValidityPreset); err != nil {
+		return err
+	}
+	cert, err := issueCertificate(req, t, now, "")
+	if err != nil {
+		return err
+	}
+	auditCertificateRequest("certificate.request.issued", "system", req, "")
+	recordCertificateEvent(cert.ID, "certificate.issued", approverLabel(req), "scheduled")
+	emitCertificateRequestEvent("certificate.request.issued", req,
+		fmt.Sprintf("Certificate for request #%d has been issued", req.ID),
+		fmt.Sprintf("Certificate %s has been issued as scheduled, approved by %s.", cert.Name, approverLabel(req)))
+	return nil
+}
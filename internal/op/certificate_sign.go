@@ -0,0 +1,124 @@
+package op
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cms"
+)
+
+// SetCertificatePrivateKey 设置服务端保管的证书私钥，设置后租户可以使用该证书签名文件
+func SetCertificatePrivateKey(id uint, content string, operator *model.User) error {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return err
+	}
+	if _, err := parsePrivateKey(cert.Content, content); err != nil {
+		return err
+	}
+	cert.PrivateKey = strings.TrimSpace(content) + "\n"
+	if err := db.UpdateCertificate(cert); err != nil {
+		return err
+	}
+	recordCertificateEvent(cert.ID, "certificate.key_set", operator.Username, "")
+	return nil
+}
+
+// SignWithCertificate 使用服务端保管的证书私钥对内容创建分离式 CMS 签名，id 为 0 时使用租户当前的有效证书
+func SignWithCertificate(id uint, user *model.User, payload []byte, filename, ip string) ([]byte, error) {
+	var cert *model.Certificate
+	var err error
+	if id == 0 {
+		cert, err = db.GetCertificateByOwnerID(user.ID)
+	} else {
+		cert, err = db.GetCertificateByID(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	TripCertificateHoneytoken(cert, user.Username, "sign", ip)
+	if cert.OwnerID != user.ID {
+		return nil, errs.PermissionDenied
+	}
+	if !cert.IsValid() || cert.IsExpired() {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate %d is %s and can not be used for signing", cert.ID, cert.Status)
+	}
+	if !cert.HasPrivateKey() {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "the private key of certificate %d is not held by the server", cert.ID)
+	}
+	if err := checkSignRate(user); err != nil {
+		return nil, err
+	}
+	certs, err := parseCertificates(cert.Content)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey(cert.Content, cert.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	signature, err := cms.Sign(payload, certs[0], key, &cms.SignOptions{
+		Detached:     true,
+		SigningTime:  now,
+		Certificates: certs,
+	})
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "failed sign with certificate %d: %v", cert.ID, err)
+	}
+	sum := sha256.Sum256(payload)
+	record := &model.CertificateSignature{
+		CertificateID: cert.ID,
+		UserID:        user.ID,
+		Username:      user.Username,
+		Filename:      filename,
+		Size:          int64(len(payload)),
+		Digest:        hex.EncodeToString(sum[:]),
+		IP:            ip,
+		CreatedAt:     now,
+	}
+	if err := db.CreateCertificateSignature(record); err != nil {
+		return nil, err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.signed",
+		Actor:  user.Username,
+		Target: fmt.Sprintf("certificate:%d", cert.ID),
+		IP:     ip,
+		Detail: fmt.Sprintf("sha256 %s size %d %s", record.Digest, record.Size, filename),
+	})
+	return signature, nil
+}
+
+// checkSignRate 校验用户最近一小时的签名数量未超过上限
+func checkSignRate(user *model.User) error {
+	limit := getSettingInt(conf.CertSignPerHour, 100)
+	if limit <= 0 {
+		return nil
+	}
+	count, err := db.CountCertificateSignaturesByUserSince(user.ID, time.Now().Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if count >= int64(limit) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "at most %d signatures are allowed in an hour", limit)
+	}
+	return nil
+}
+
+// GetCertificateSignatures 获取签名记录，无权查看全部证书的用户只能看到自己的记录
+func GetCertificateSignatures(certID uint, user *model.User) ([]model.CertificateSignature, error) {
+	var userID uint
+	if !user.CanViewAllCertificates() {
+		userID = user.ID
+	}
+	return db.GetCertificateSignatures(certID, userID, 200)
+}
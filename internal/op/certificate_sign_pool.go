@@ -0,0 +1,27 @@
+package op
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op/certissuer"
+)
+
+// signingPool 所有使用 CA 私钥的签名操作共用的签名池，按配置文件创建
+var signingPool = sync.OnceValue(func() *certissuer.Pool {
+	c := conf.Conf.Certificate
+	return certissuer.NewPool(c.SignWorkers, c.SignQueue, time.Duration(c.SignTimeoutSeconds)*time.Second)
+})
+
+// runSigning 在签名池中执行签名，签名池繁忙或超时时返回 certissuer.ErrQueueFull 或 certissuer.ErrTimeout
+func runSigning(fn func() error) error {
+	return signingPool().Do(context.Background(), fn)
+}
+
+// IsSigningBusy 检查错误是否由签名池繁忙或超时引起，调用方可以稍后重试
+func IsSigningBusy(err error) bool {
+	return errors.Is(err, certissuer.ErrQueueFull) || errors.Is(err, certissuer.ErrTimeout)
+}
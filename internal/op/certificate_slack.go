@@ -0,0 +1,53 @@
+package op
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/pkg/errors"
+)
+
+// SlackCertificateAction slack 审批按钮的处理结果
+type SlackCertificateAction struct {
+	Actor     string
+	Action    string // model.CertificateAuditApprove 或 model.CertificateAuditReject
+	RequestID uint
+	Text      string // 用于替换原消息的文本
+}
+
+// HandleSlackCertificateAction 处理 slack 消息中审批按钮的回调，slack 用户需绑定到管理员账号
+func HandleSlackCertificateAction(slackUserID, actionID, value string) (*SlackCertificateAction, error) {
+	user, err := db.GetUserBySlackID(slackUserID)
+	if err != nil {
+		return nil, errors.WithMessage(errs.PermissionDenied, err.Error())
+	}
+	if !user.IsAdmin() || user.Disabled {
+		return nil, errs.PermissionDenied
+	}
+	reqID, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid request id: %s", value)
+	}
+	res := &SlackCertificateAction{Actor: user.Username, RequestID: uint(reqID)}
+	switch actionID {
+	case notify.SlackActionApprove:
+		if _, err := ApproveAndCreateCertificate(res.RequestID, user); err != nil {
+			return nil, err
+		}
+		res.Action = model.CertificateAuditApprove
+		res.Text = fmt.Sprintf("Certificate request #%d has been approved by %s", reqID, user.Username)
+	case notify.SlackActionReject:
+		if err := RejectCertificateRequest(res.RequestID, user, "rejected from slack"); err != nil {
+			return nil, err
+		}
+		res.Action = model.CertificateAuditReject
+		res.Text = fmt.Sprintf("Certificate request #%d has been rejected by %s", reqID, user.Username)
+	default:
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unknown slack action: %s", actionID)
+	}
+	return res, nil
+}
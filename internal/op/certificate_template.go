@@ -0,0 +1,107 @@
+package op
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func GetCertificateRequestTemplates(user *model.User) ([]model.CertificateRequestTemplate, error) {
+	return db.GetCertificateRequestTemplates(user.ID)
+}
+
+func checkCertificateRequestTemplate(t *model.CertificateRequestTemplate) error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "template name is required")
+	}
+	def, err := CheckCertificateType(t.Type)
+	if err != nil {
+		return err
+	}
+	if err := checkValidityPreset(def, t.ValidityPreset); err != nil {
+		return err
+	}
+	t.SANs, err = checkSANs(t.SANs)
+	return err
+}
+
+// getCertificateRequestTemplate 获取用户可使用的模板，write 为 true 时要求可以修改：
+// 自己的模板，或者管理员修改共享模板
+func getCertificateRequestTemplate(id uint, user *model.User, write bool) (*model.CertificateRequestTemplate, error) {
+	t, err := db.GetCertificateRequestTemplateByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.IsShared() {
+		if write && !user.IsAdmin() {
+			return nil, errs.PermissionDenied
+		}
+		return t, nil
+	}
+	if t.UserID != user.ID {
+		return nil, errs.PermissionDenied
+	}
+	return t, nil
+}
+
+// CreateCertificateRequestTemplate 保存申请模板，只有管理员可以创建共享模板
+func CreateCertificateRequestTemplate(user *model.User, t *model.CertificateRequestTemplate, shared bool) error {
+	if shared && !user.IsAdmin() {
+		return errs.PermissionDenied
+	}
+	t.ID = 0
+	t.UserID = user.ID
+	if shared {
+		t.UserID = 0
+	}
+	if err := checkCertificateRequestTemplate(t); err != nil {
+		return err
+	}
+	return db.CreateCertificateRequestTemplate(t)
+}
+
+func UpdateCertificateRequestTemplate(user *model.User, t *model.CertificateRequestTemplate) error {
+	old, err := getCertificateRequestTemplate(t.ID, user, true)
+	if err != nil {
+		return err
+	}
+	t.UserID = old.UserID
+	t.CreatedAt = old.CreatedAt
+	if err := checkCertificateRequestTemplate(t); err != nil {
+		return err
+	}
+	return db.UpdateCertificateRequestTemplate(t)
+}
+
+func DeleteCertificateRequestTemplate(user *model.User, id uint) error {
+	if _, err := getCertificateRequestTemplate(id, user, true); err != nil {
+		return err
+	}
+	return db.DeleteCertificateRequestTemplate(id)
+}
+
+// CreateCertificateRequestFromTemplate 按模板创建申请，reason 为空时使用模板的申请理由，
+// customFields 覆盖模板中的同名字段
+func CreateCertificateRequestFromTemplate(user *model.User, id uint, reason string, customFields map[string]string, draft bool) (*model.CertificateRequest, error) {
+	t, err := getCertificateRequestTemplate(id, user, false)
+	if err != nil {
+		return nil, err
+	}
+	if reason == "" {
+		reason = t.Reason
+	}
+	fields := make(map[string]string, len(t.CustomFields)+len(customFields))
+	for k, v := range t.CustomFields {
+		fields[k] = v
+	}
+	for k, v := range customFields {
+		fields[k] = v
+	}
+	if draft {
+		return CreateCertificateRequestDraft(user, t.Type, reason, t.ValidityPreset, fields, t.ExpandSANs(user.Username), "", 0)
+	}
+	return CreateTenantCertificateRequest(user, t.Type, reason, t.ValidityPreset, fields, t.ExpandSANs(user.Username), "", 0)
+}
@@ -0,0 +1,239 @@
+package op
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/go-resty/resty/v2"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// 支持的外部工单系统
+const (
+	TicketSystemJira       = "jira"
+	TicketSystemServiceNow = "servicenow"
+)
+
+// certificateTicketTimeout 单次调用工单系统接口的最长时间
+const certificateTicketTimeout = 30 * time.Second
+
+// maxTicketIDLength 工单编号的最大长度
+const maxTicketIDLength = 64
+
+// LinkCertificateRequestTicket 将申请关联到外部工单，申请人和管理员可以修改，ticketID 和 ticketURL 都为空时取消关联。
+// 只填写编号且配置了工单系统时按工单系统生成链接
+func LinkCertificateRequestTicket(user *model.User, id uint, ticketID, ticketURL string) (*model.CertificateRequest, error) {
+	req, err := GetCertificateRequestForUser(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.ID != req.UserID && !user.IsAdmin() {
+		return nil, errs.PermissionDenied
+	}
+	ticketID, ticketURL = strings.TrimSpace(ticketID), strings.TrimSpace(ticketURL)
+	if len(ticketID) > maxTicketIDLength || strings.ContainsAny(ticketID, " \t\r\n/") {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid ticket id: %q", ticketID)
+	}
+	if ticketURL != "" {
+		u, err := url.Parse(ticketURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "ticket url must be an http or https url")
+		}
+	} else if ticketID != "" {
+		ticketURL = certificateTicketURL(ticketID)
+	}
+	if err := db.UpdateCertificateRequestTicket(req.ID, ticketID, ticketURL); err != nil {
+		return nil, err
+	}
+	req.TicketID, req.TicketURL = ticketID, ticketURL
+	detail := ticketID
+	if detail == "" {
+		detail = ticketURL
+	}
+	auditCertificateRequest("certificate.request.ticket_linked", user.Username, req, detail)
+	return req, nil
+}
+
+// certificateTicketURL 按配置的工单系统生成工单链接，未配置时为空
+func certificateTicketURL(ticketID string) string {
+	baseURL := strings.TrimSuffix(getSettingStr(conf.CertTicketBaseURL, ""), "/")
+	if baseURL == "" {
+		return ""
+	}
+	switch getSettingStr(conf.CertTicketSystem, "") {
+	case TicketSystemJira:
+		return baseURL + "/browse/" + url.PathEscape(ticketID)
+	case TicketSystemServiceNow:
+		return baseURL + "/nav_to.do?uri=" + url.QueryEscape(serviceNowTable()+".do?sysparm_query=number="+ticketID)
+	}
+	return ""
+}
+
+// serviceNowTable ServiceNow 中创建工单的表
+func serviceNowTable() string {
+	return getSettingStr(conf.CertTicketProject, "incident")
+}
+
+// ticketRequest 带认证信息的工单系统请求，设置了用户名时使用 basic 认证，否则将 token 作为 bearer token
+func ticketRequest(ctx context.Context) *resty.Request {
+	r := base.RestyClient.R().SetContext(ctx)
+	token := getSettingStr(conf.CertTicketToken, "")
+	if username := getSettingStr(conf.CertTicketUsername, ""); username != "" {
+		r.SetBasicAuth(username, token)
+	} else if token != "" {
+		r.SetAuthToken(token)
+	}
+	return r
+}
+
+// openCertificateTicket 配置了工单系统时为提交的申请异步创建工单，申请已关联工单时跳过
+func openCertificateTicket(req *model.CertificateRequest) {
+	system := getSettingStr(conf.CertTicketSystem, "")
+	if system == "" || req.TicketID != "" || req.Sandbox {
+		return
+	}
+	summary := fmt.Sprintf("Certificate request #%d: %s certificate for %s", req.ID, req.Type, req.UserName)
+	description := fmt.Sprintf("%s requested a %s certificate.\nReason: %s", req.UserName, req.Type, req.Reason)
+	if len(req.SANs) > 0 {
+		description += "\nSubject alternative names: " + strings.Join(req.SANs, ", ")
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), certificateTicketTimeout)
+		defer cancel()
+		ticketID, ticketURL, err := createCertificateTicket(ctx, system, summary, description)
+		if err != nil {
+			log.Warnf("failed create %s ticket for certificate request %d: %+v", system, req.ID, err)
+			return
+		}
+		if err := db.UpdateCertificateRequestTicket(req.ID, ticketID, ticketURL); err != nil {
+			log.Warnf("failed link ticket %s to certificate request %d: %+v", ticketID, req.ID, err)
+			return
+		}
+		auditCertificateRequest("certificate.request.ticket_linked", "system", req, ticketID)
+	}()
+}
+
+// createCertificateTicket 在工单系统中创建工单，返回工单编号和链接
+func createCertificateTicket(ctx context.Context, system, summary, description string) (string, string, error) {
+	baseURL := strings.TrimSuffix(getSettingStr(conf.CertTicketBaseURL, ""), "/")
+	if baseURL == "" {
+		return "", "", errors.New("cert_ticket_base_url is not configured")
+	}
+	switch system {
+	case TicketSystemJira:
+		project := getSettingStr(conf.CertTicketProject, "")
+		if project == "" {
+			return "", "", errors.New("jira tickets require cert_ticket_project")
+		}
+		var created struct {
+			Key string `json:"key"`
+		}
+		res, err := ticketRequest(ctx).SetResult(&created).SetBody(map[string]any{
+			"fields": map[string]any{
+				"project":     map[string]string{"key": project},
+				"summary":     summary,
+				"description": description,
+				"issuetype":   map[string]string{"name": "Task"},
+			},
+		}).Post(baseURL + "/rest/api/2/issue")
+		if err := ticketResponseError(res, err); err != nil {
+			return "", "", err
+		}
+		if created.Key == "" {
+			return "", "", errors.New("jira did not return the issue key")
+		}
+		return created.Key, certificateTicketURL(created.Key), nil
+	case TicketSystemServiceNow:
+		var created struct {
+			Result struct {
+				Number string `json:"number"`
+				SysID  string `json:"sys_id"`
+			} `json:"result"`
+		}
+		table := serviceNowTable()
+		res, err := ticketRequest(ctx).SetResult(&created).SetBody(map[string]string{
+			"short_description": summary,
+			"description":       description,
+		}).Post(baseURL + "/api/now/table/" + url.PathEscape(table))
+		if err := ticketResponseError(res, err); err != nil {
+			return "", "", err
+		}
+		if created.Result.Number == "" {
+			return "", "", errors.New("servicenow did not return the record number")
+		}
+		return created.Result.Number, baseURL + "/nav_to.do?uri=" + url.QueryEscape(table+".do?sys_id="+created.Result.SysID), nil
+	}
+	return "", "", errors.Errorf("unsupported ticket system: %s", system)
+}
+
+// postCertificateTicketUpdate 将申请的状态变化异步回写到关联的工单，Jira 添加评论，ServiceNow 添加工作备注
+func postCertificateTicketUpdate(req *model.CertificateRequest, content string) {
+	system := getSettingStr(conf.CertTicketSystem, "")
+	if system == "" || req.TicketID == "" {
+		return
+	}
+	ticketID := req.TicketID
+	content = fmt.Sprintf("Certificate request #%d: %s", req.ID, content)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), certificateTicketTimeout)
+		defer cancel()
+		if err := commentCertificateTicket(ctx, system, ticketID, content); err != nil {
+			log.Warnf("failed post update of certificate request %d to ticket %s: %+v", req.ID, ticketID, err)
+		}
+	}()
+}
+
+func commentCertificateTicket(ctx context.Context, system, ticketID, content string) error {
+	baseURL := strings.TrimSuffix(getSettingStr(conf.CertTicketBaseURL, ""), "/")
+	if baseURL == "" {
+		return errors.New("cert_ticket_base_url is not configured")
+	}
+	switch system {
+	case TicketSystemJira:
+		res, err := ticketRequest(ctx).SetBody(map[string]string{"body": content}).
+			Post(baseURL + "/rest/api/2/issue/" + url.PathEscape(ticketID) + "/comment")
+		return ticketResponseError(res, err)
+	case TicketSystemServiceNow:
+		// 表接口按 sys_id 更新记录，先按工单编号查出 sys_id
+		var found struct {
+			Result []struct {
+				SysID string `json:"sys_id"`
+			} `json:"result"`
+		}
+		table := baseURL + "/api/now/table/" + url.PathEscape(serviceNowTable())
+		res, err := ticketRequest(ctx).SetResult(&found).SetQueryParams(map[string]string{
+			"sysparm_query":  "number=" + ticketID,
+			"sysparm_fields": "sys_id",
+			"sysparm_limit":  "1",
+		}).Get(table)
+		if err := ticketResponseError(res, err); err != nil {
+			return err
+		}
+		if len(found.Result) == 0 {
+			return errors.Errorf("servicenow record %s not found", ticketID)
+		}
+		res, err = ticketRequest(ctx).SetBody(map[string]string{"work_notes": content}).
+			Patch(table + "/" + url.PathEscape(found.Result[0].SysID))
+		return ticketResponseError(res, err)
+	}
+	return errors.Errorf("unsupported ticket system: %s", system)
+}
+
+func ticketResponseError(res *resty.Response, err error) error {
+	if err != nil {
+		return errors.Wrap(err, "failed call ticket system")
+	}
+	if res.IsError() {
+		return errors.Errorf("ticket system responded with status %s", res.Status())
+	}
+	return nil
+}
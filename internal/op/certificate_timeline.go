@@ -0,0 +1,126 @@
+package op
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// recordCertificateEvent 记录证书生命周期事件并发送到审计日志，记录失败不影响业务操作
+func recordCertificateEvent(certID uint, event, actor, detail string) {
+	err := db.CreateCertificateEvent(&model.CertificateEvent{
+		CertificateID: certID,
+		Event:         event,
+		Actor:         actor,
+		Detail:        detail,
+	})
+	if err != nil {
+		log.Warnf("failed to record certificate event [%s] of %d: %+v", event, certID, err)
+	}
+	announceCertificateEvent(certID, event, actor, detail)
+}
+
+// announceCertificateEvent 将已写入数据库的证书事件发送到审计日志和 webhook，并唤醒等待事件的代理
+func announceCertificateEvent(certID uint, event, actor, detail string) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("certificate:%d", certID),
+		Detail: detail,
+	})
+	wakeCertificateAgents(event)
+	dispatchCertificateWebhooks(&model.CertificateWebhookPayload{Event: event, Actor: actor, Detail: detail, Certificate: &model.Certificate{ID: certID}})
+}
+
+// auditCertificateRequest 将证书申请的创建和审批操作发送到审计日志和 webhook
+func auditCertificateRequest(event, actor string, req *model.CertificateRequest, detail string) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("certificate_request:%d", req.ID),
+		Detail: detail,
+	})
+	dispatchCertificateWebhooks(&model.CertificateWebhookPayload{Event: event, Actor: actor, Detail: detail, Request: req})
+}
+
+// GetCertificateTimeline 按时间顺序合并证书的申请、审批、评论以及生命周期事件
+// 非管理员只能查看自己的证书
+func GetCertificateTimeline(id uint, user *model.User) ([]model.CertificateTimelineEntry, error) {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !user.CanViewAllCertificates() && cert.OwnerID != user.ID {
+		return nil, errs.PermissionDenied
+	}
+	var entries []model.CertificateTimelineEntry
+	if cert.RequestID != 0 {
+		req, err := db.GetCertificateRequestByID(cert.RequestID)
+		if err != nil {
+			log.Warnf("failed get origin request %d of certificate %d: %+v", cert.RequestID, cert.ID, err)
+		} else {
+			entries = append(entries, model.CertificateTimelineEntry{
+				Time:   req.CreatedAt,
+				Event:  "certificate.request.created",
+				Actor:  req.UserName,
+				Detail: req.Reason,
+			})
+			comments, err := db.GetCertificateRequestComments(req.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, comment := range comments {
+				entries = append(entries, model.CertificateTimelineEntry{
+					Time:   comment.CreatedAt,
+					Event:  "certificate.request.commented",
+					Actor:  comment.Username,
+					Detail: comment.Content,
+				})
+			}
+			// 内部备注只对管理员和审计员可见
+			if user.CanViewAllCertificates() {
+				notes, err := db.GetCertificateRequestNotes(req.ID)
+				if err != nil {
+					return nil, err
+				}
+				for _, note := range notes {
+					entries = append(entries, model.CertificateTimelineEntry{
+						Time:   note.CreatedAt,
+						Event:  "certificate.request.note_added",
+						Actor:  note.Username,
+						Detail: note.Content,
+					})
+				}
+			}
+			if req.ApprovedAt != nil {
+				entries = append(entries, model.CertificateTimelineEntry{
+					Time:   *req.ApprovedAt,
+					Event:  "certificate.request.approved",
+					Actor:  approverLabel(req),
+					Detail: fmt.Sprintf("request #%d approved", req.ID),
+				})
+			}
+		}
+	}
+	events, err := db.GetCertificateEvents(cert.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		entries = append(entries, model.CertificateTimelineEntry{
+			Time:   e.CreatedAt,
+			Event:  e.Event,
+			Actor:  e.Actor,
+			Detail: e.Detail,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
@@ -0,0 +1,175 @@
+package op
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// managedTLSCertificate HTTPS 监听当前使用的托管证书
+type managedTLSCertificate struct {
+	id          uint
+	fingerprint string
+	cert        *tls.Certificate
+}
+
+var (
+	managedTLS     atomic.Pointer[managedTLSCertificate]
+	managedTLSLock sync.Mutex
+)
+
+// ManagedTLSCertificate 返回 HTTPS 监听使用的托管证书，未启用托管证书时返回 nil，供 tls.Config.GetCertificate 使用
+func ManagedTLSCertificate() *tls.Certificate {
+	if m := managedTLS.Load(); m != nil {
+		return m.cert
+	}
+	return nil
+}
+
+// ActivateTLSCertificate 将服务端保管私钥的有效证书设为 HTTPS 监听的证书，立即生效，
+// 证书续期或被 CA 轮换重新签发后自动切换到新证书
+func ActivateTLSCertificate(id uint, operator *model.User) (*model.Certificate, error) {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := checkTLSCertificate(cert); err != nil {
+		return nil, err
+	}
+	if err := saveTLSCertificateID(cert.ID); err != nil {
+		return nil, err
+	}
+	if err := ReloadTLSCertificate(); err != nil {
+		return nil, err
+	}
+	recordCertificateEvent(cert.ID, "certificate.tls_activated", operator.Username, "")
+	return cert, nil
+}
+
+// checkTLSCertificate 校验证书可以作为 TLS 服务端证书，返回包含证书链和私钥的 tls.Certificate
+func checkTLSCertificate(cert *model.Certificate) (*tls.Certificate, error) {
+	if !cert.IsValid() || cert.IsExpired() {
+		return nil, errs.NewErr(errs.CertificateConflict, "certificate %s is not valid, current status: %s", cert.Name, cert.Status)
+	}
+	if !cert.HasPrivateKey() {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "the private key of certificate %s is not held by the server", cert.Name)
+	}
+	if cert.Sandbox {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "sandbox certificates can not be used for https")
+	}
+	// 优先使用补全了中间证书的链，无法构建时按保存的内容部署，例如公共 CA 签发的证书已包含完整链
+	content := cert.Content
+	if chain, err := BuildCertificateChain(cert.Content, ""); err == nil && chain.Valid && chain.PEM != "" {
+		content = chain.PEM
+	}
+	pair, err := tls.X509KeyPair([]byte(content), []byte(cert.PrivateKey))
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s can not be used for https: %v", cert.Name, err)
+	}
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+	if len(leaf.ExtKeyUsage) > 0 && !slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageServerAuth) && !slices.Contains(leaf.ExtKeyUsage, x509.ExtKeyUsageAny) {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate %s is not allowed for server authentication", cert.Name)
+	}
+	pair.Leaf = leaf
+	return &pair, nil
+}
+
+func saveTLSCertificateID(id uint) error {
+	item, err := GetSettingItemByKey(conf.CertTLSCertificateID)
+	if err != nil {
+		return errors.WithMessage(err, "failed get tls certificate setting")
+	}
+	item.Value = strconv.FormatUint(uint64(id), 10)
+	return SaveSettingItem(item)
+}
+
+// currentTLSCertificate 沿续期和 CA 轮换找到托管证书当前生效的版本
+func currentTLSCertificate(id uint) (*model.Certificate, error) {
+	cert, err := db.GetCertificateByID(id)
+	if err != nil {
+		return nil, err
+	}
+	for seen := 0; seen < 100; seen++ {
+		next := cert
+		switch {
+		case cert.IsSuperseded():
+			// 续期证书签发后立即切换，旧证书在重叠期内仍然有效
+			if next, err = db.GetCertificateByID(cert.SupersededByID); err != nil {
+				return nil, err
+			}
+		case cert.Status == model.CertificateStatusRevoked && cert.RevocationReason == model.RevocationReasonSuperseded && cert.RequestID != 0:
+			// CA 轮换时替代证书沿用来源申请
+			if next, err = db.GetCurrentCertificateByRequestID(cert.RequestID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, err
+			}
+			if next == nil {
+				return cert, nil
+			}
+		}
+		if next.ID == cert.ID {
+			return cert, nil
+		}
+		cert = next
+	}
+	return cert, nil
+}
+
+// ReloadTLSCertificate 按设置加载 HTTPS 监听的托管证书，证书已续期或被替代时切换到新证书并更新设置，
+// 新证书无法使用时继续使用已加载的证书
+func ReloadTLSCertificate() error {
+	managedTLSLock.Lock()
+	defer managedTLSLock.Unlock()
+	id := uint(getSettingInt(conf.CertTLSCertificateID, 0))
+	if id == 0 {
+		managedTLS.Store(nil)
+		return nil
+	}
+	cert, err := currentTLSCertificate(id)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get tls certificate %d", id)
+	}
+	loaded := managedTLS.Load()
+	if loaded != nil && loaded.id == cert.ID && loaded.fingerprint == cert.Fingerprint {
+		return nil
+	}
+	pair, err := checkTLSCertificate(cert)
+	if err != nil {
+		return err
+	}
+	if cert.ID != id {
+		if err := saveTLSCertificateID(cert.ID); err != nil {
+			return err
+		}
+		recordCertificateEvent(cert.ID, "certificate.tls_activated", "system", fmt.Sprintf("replaces certificate %d", id))
+	}
+	managedTLS.Store(&managedTLSCertificate{id: cert.ID, fingerprint: cert.Fingerprint, cert: pair})
+	log.Infof("https listener uses certificate %s (%d)", cert.Name, cert.ID)
+	return nil
+}
+
+// reloadTLSCertificateAsync 证书续期或重新签发后刷新 HTTPS 监听的证书
+func reloadTLSCertificateAsync() {
+	if managedTLS.Load() == nil {
+		return
+	}
+	go func() {
+		if err := ReloadTLSCertificate(); err != nil {
+			log.Warnf("failed reload tls certificate: %+v", err)
+		}
+	}()
+}
@@ -0,0 +1,142 @@
+package op
+
+import (
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+// GetCertificateTrustAnchors 管理员和审计员获取所有组织的信任库，其他用户获取所在组织的
+func GetCertificateTrustAnchors(user *model.User) ([]model.CertificateTrustAnchor, error) {
+	if user.CanViewAllCertificates() {
+		return db.GetCertificateTrustAnchors(nil)
+	}
+	if user.Organization == "" {
+		return []model.CertificateTrustAnchor{}, nil
+	}
+	return db.GetCertificateTrustAnchors(&user.Organization)
+}
+
+// checkTrustStorePermission 管理员可以管理所有组织的信任库，组织管理员只能管理本组织的
+func checkTrustStorePermission(user *model.User, organization string) error {
+	if user.IsAdmin() {
+		return nil
+	}
+	if user.CanManageTrustStore() && user.Organization != "" && user.Organization == organization {
+		return nil
+	}
+	return errs.PermissionDenied
+}
+
+// AddCertificateTrustAnchors 将上传的 PEM 中的 CA 证书加入组织信任库，content 可以包含多张证书，
+// 只接受 CA 证书，组织中已存在的证书跳过
+func AddCertificateTrustAnchors(user *model.User, organization, name, content string) ([]model.CertificateTrustAnchor, error) {
+	organization = strings.TrimSpace(organization)
+	if organization == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "organization is required")
+	}
+	if err := checkTrustStorePermission(user, organization); err != nil {
+		return nil, err
+	}
+	certs, err := parseCertificates(content)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	anchors := make([]model.CertificateTrustAnchor, 0, len(certs))
+	for i, cert := range certs {
+		if !cert.BasicConstraintsValid || !cert.IsCA {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate #%d %s is not a ca certificate", i+1, cert.Subject)
+		}
+		if now.After(cert.NotAfter) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate #%d %s expired at %s", i+1, cert.Subject, cert.NotAfter.Format(time.RFC3339))
+		}
+		fp := certificateFingerprint(cert)
+		count, err := db.CountCertificateTrustAnchorsByFingerprint(organization, fp)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 || containsTrustAnchor(anchors, fp) {
+			continue
+		}
+		anchorName := strings.TrimSpace(name)
+		if anchorName == "" || len(certs) > 1 {
+			anchorName = cert.Subject.CommonName
+		}
+		anchors = append(anchors, model.CertificateTrustAnchor{
+			Organization: organization,
+			Name:         anchorName,
+			Content:      string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+			Subject:      cert.Subject.String(),
+			Fingerprint:  fp,
+			NotAfter:     cert.NotAfter,
+			CreatedBy:    user.Username,
+		})
+	}
+	if len(anchors) == 0 {
+		return anchors, nil
+	}
+	if err := db.CreateCertificateTrustAnchors(anchors); err != nil {
+		return nil, err
+	}
+	for i := range anchors {
+		auditTrustAnchor("certificate.trust_anchor.added", user, &anchors[i])
+	}
+	return anchors, nil
+}
+
+func containsTrustAnchor(anchors []model.CertificateTrustAnchor, fingerprint string) bool {
+	for _, a := range anchors {
+		if a.Fingerprint == fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+func DeleteCertificateTrustAnchor(user *model.User, id uint) error {
+	a, err := db.GetCertificateTrustAnchorByID(id)
+	if err != nil {
+		return err
+	}
+	if err := checkTrustStorePermission(user, a.Organization); err != nil {
+		return err
+	}
+	if err := db.DeleteCertificateTrustAnchor(id); err != nil {
+		return err
+	}
+	auditTrustAnchor("certificate.trust_anchor.deleted", user, a)
+	return nil
+}
+
+func auditTrustAnchor(event string, user *model.User, a *model.CertificateTrustAnchor) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  user.Username,
+		Target: fmt.Sprintf("trust_anchor:%d", a.ID),
+		Detail: fmt.Sprintf("%s (%s), organization %q", a.Subject, a.Fingerprint, a.Organization),
+	})
+}
+
+// CertificateTrustStoreBundle 返回组织信任库中未过期的 CA 证书拼接成的 PEM，组织没有信任的 CA 时返回空字符串
+func CertificateTrustStoreBundle(organization string) (string, error) {
+	anchors, err := db.GetCertificateTrustAnchors(&organization)
+	if err != nil {
+		return "", err
+	}
+	var buf strings.Builder
+	now := time.Now()
+	for _, a := range anchors {
+		if now.After(a.NotAfter) {
+			continue
+		}
+		buf.WriteString(a.Content)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,111 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var GetCertificateTypes = db.GetCertificateTypes
+var GetCertificateTypeByID = db.GetCertificateTypeByID
+
+// CheckCertificateType 校验证书类型已在注册表中且未被禁用
+func CheckCertificateType(name model.CertificateType) (*model.CertificateTypeDef, error) {
+	t, err := db.GetCertificateTypeByName(name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "unknown certificate type: %s", name)
+		}
+		return nil, err
+	}
+	if t.Disabled {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "certificate type %s is disabled", name)
+	}
+	return t, nil
+}
+
+// checkCertificateTypeQuota 校验该类型有效证书数量是否已达上限
+func checkCertificateTypeQuota(t *model.CertificateTypeDef) error {
+	if t.Quota <= 0 {
+		return nil
+	}
+	count, err := db.CountActiveCertificatesByType(t.Name)
+	if err != nil {
+		return err
+	}
+	if count >= int64(t.Quota) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "quota of certificate type %s exceeded (%d)", t.Name, t.Quota)
+	}
+	return nil
+}
+
+// checkValidityPreset 校验申请的有效期预设在该类型允许的范围内
+func checkValidityPreset(t *model.CertificateTypeDef, preset string) error {
+	if !t.HasValidityPreset(preset) {
+		return errs.NewErr(errs.InvalidCertificateRequest, "validity preset %s is not allowed for certificate type %s", preset, t.Name)
+	}
+	return nil
+}
+
+func checkCertificateTypeDef(t *model.CertificateTypeDef) error {
+	t.Name = model.CertificateType(strings.TrimSpace(string(t.Name)))
+	if t.Name == "" {
+		return fmt.Errorf("type name is required")
+	}
+	if t.ValidityDays < 0 || t.Quota < 0 {
+		return fmt.Errorf("validity_days and quota must not be negative")
+	}
+	for preset, days := range t.ValidityPresets {
+		if days <= 0 {
+			return fmt.Errorf("validity preset %s must be at least one day", preset)
+		}
+	}
+	for _, name := range t.ApprovalChain {
+		if _, err := GetUserByName(name); err != nil {
+			return errors.WithMessagef(err, "failed get approver %s", name)
+		}
+	}
+	return nil
+}
+
+func CreateCertificateType(t *model.CertificateTypeDef) error {
+	if err := checkCertificateTypeDef(t); err != nil {
+		return err
+	}
+	return db.CreateCertificateType(t)
+}
+
+// UpdateCertificateType 更新证书类型，类型标识不可修改
+func UpdateCertificateType(t *model.CertificateTypeDef) error {
+	old, err := db.GetCertificateTypeByID(t.ID)
+	if err != nil {
+		return err
+	}
+	t.Name = old.Name
+	t.CreatedAt = old.CreatedAt
+	if err := checkCertificateTypeDef(t); err != nil {
+		return err
+	}
+	return db.UpdateCertificateType(t)
+}
+
+// DeleteCertificateType 删除证书类型，仍有有效证书时不允许删除
+func DeleteCertificateType(id uint) error {
+	t, err := db.GetCertificateTypeByID(id)
+	if err != nil {
+		return err
+	}
+	count, err := db.CountActiveCertificatesByType(t.Name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errs.NewErr(errs.CertificateConflict, "certificate type %s still has %d valid certificates", t.Name, count)
+	}
+	return db.DeleteCertificateType(id)
+}
@@ -0,0 +1,81 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var GetCertificateWatchesByUserID = db.GetCertificateWatchesByUserID
+
+// checkWatchTarget 检查关注对象是否存在，以及用户是否有权关注
+// 管理员和所有者总是可以关注，其他用户需要具备关注证书的权限
+func checkWatchTarget(user *model.User, targetType model.CertificateWatchTarget, targetID uint) error {
+	var ownerID uint
+	switch targetType {
+	case model.CertificateWatchTargetCertificate:
+		cert, err := db.GetCertificateByID(targetID)
+		if err != nil {
+			return err
+		}
+		ownerID = cert.OwnerID
+	case model.CertificateWatchTargetRequest:
+		req, err := db.GetCertificateRequestByID(targetID)
+		if err != nil {
+			return err
+		}
+		ownerID = req.UserID
+	default:
+		return fmt.Errorf("invalid watch target type: %s", targetType)
+	}
+	if user.CanViewAllCertificates() || user.ID == ownerID || user.CanWatchCertificates() {
+		return nil
+	}
+	return errs.PermissionDenied
+}
+
+// WatchCertificateTarget 关注证书或申请，重复关注不会报错
+func WatchCertificateTarget(user *model.User, targetType model.CertificateWatchTarget, targetID uint) (*model.CertificateWatch, error) {
+	if err := checkWatchTarget(user, targetType, targetID); err != nil {
+		return nil, err
+	}
+	w, err := db.GetCertificateWatch(user.ID, targetType, targetID)
+	if err == nil {
+		return w, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.WithStack(err)
+	}
+	w = &model.CertificateWatch{
+		UserID:     user.ID,
+		Username:   user.Username,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if err := db.CreateCertificateWatch(w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// UnwatchCertificateTarget 取消关注
+func UnwatchCertificateTarget(user *model.User, targetType model.CertificateWatchTarget, targetID uint) error {
+	return db.DeleteCertificateWatch(user.ID, targetType, targetID)
+}
+
+// getWatchers 获取关注者用户名
+func getWatchers(targetType model.CertificateWatchTarget, targetID uint) []string {
+	watches, err := db.GetCertificateWatchesByTarget(targetType, targetID)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(watches))
+	for _, w := range watches {
+		names = append(names, w.Username)
+	}
+	return names
+}
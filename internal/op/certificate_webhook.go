@@ -0,0 +1,320 @@
+package op
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/base"
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// certificateWebhookEvents 可以订阅的证书生命周期事件
+var certificateWebhookEvents = []string{
+	"certificate.issued",
+	"certificate.revoked",
+	"certificate.expiring",
+	"certificate.request.created",
+	"certificate.request.rejected",
+	"certificate.request.expired",
+}
+
+// certificateWebhookBackoff 推送失败后第 n 次重试前的等待时间，用尽后推送记录标记为失败
+var certificateWebhookBackoff = []time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	12 * time.Hour,
+}
+
+const (
+	// certificateWebhookTimeout 单次推送等待接收端响应的最长时间
+	certificateWebhookTimeout = 15 * time.Second
+	// maxWebhookDeliveryResponse 推送记录保存的响应的最大长度
+	maxWebhookDeliveryResponse = 4096
+	// certificateWebhookRetryBatch 每次重试的最大推送记录数
+	certificateWebhookRetryBatch = 100
+	// certificateWebhookDeliveryRetention 已结束的推送记录的保留时间
+	certificateWebhookDeliveryRetention = 30 * 24 * time.Hour
+)
+
+// CertificateWebhookSubscribableEvents 接收端可以订阅的事件
+func CertificateWebhookSubscribableEvents() []string {
+	return slices.Clone(certificateWebhookEvents)
+}
+
+func GetCertificateWebhooks() ([]model.CertificateWebhook, error) {
+	return db.GetCertificateWebhooks()
+}
+
+// checkCertificateWebhook 校验接收端地址和订阅的事件
+func checkCertificateWebhook(w *model.CertificateWebhook) error {
+	w.Name, w.URL = strings.TrimSpace(w.Name), strings.TrimSpace(w.URL)
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "webhook url must be an http or https url")
+	}
+	for _, event := range w.Events {
+		if !slices.Contains(certificateWebhookEvents, event) {
+			return errs.NewErr(errs.InvalidCertificateRequest, "unsupported webhook event: %s", event)
+		}
+	}
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+	return nil
+}
+
+// CreateCertificateWebhook 注册接收端，未指定签名密钥时生成新的密钥，密钥只在此时返回
+func CreateCertificateWebhook(w *model.CertificateWebhook, operator *model.User) (string, error) {
+	if err := checkCertificateWebhook(w); err != nil {
+		return "", err
+	}
+	w.ID = 0
+	if w.Secret = strings.TrimSpace(w.Secret); w.Secret == "" {
+		w.Secret = random.String(32)
+	}
+	w.CreatedBy = operator.Username
+	if err := db.CreateCertificateWebhook(w); err != nil {
+		return "", err
+	}
+	auditCertificateWebhook("certificate.webhook.created", operator.Username, w)
+	return w.Secret, nil
+}
+
+// UpdateCertificateWebhook 修改接收端的地址、订阅的事件和启用状态，secret 为空时保留原来的签名密钥
+func UpdateCertificateWebhook(w *model.CertificateWebhook, operator *model.User) error {
+	old, err := db.GetCertificateWebhookByID(w.ID)
+	if err != nil {
+		return err
+	}
+	if err := checkCertificateWebhook(w); err != nil {
+		return err
+	}
+	if w.Secret = strings.TrimSpace(w.Secret); w.Secret == "" {
+		w.Secret = old.Secret
+	}
+	w.CreatedBy, w.CreatedAt = old.CreatedBy, old.CreatedAt
+	if err := db.UpdateCertificateWebhook(w); err != nil {
+		return err
+	}
+	auditCertificateWebhook("certificate.webhook.updated", operator.Username, w)
+	return nil
+}
+
+// DeleteCertificateWebhook 删除接收端，未完成的推送随之取消
+func DeleteCertificateWebhook(id uint, operator *model.User) error {
+	w, err := db.GetCertificateWebhookByID(id)
+	if err != nil {
+		return err
+	}
+	if err := db.DeleteCertificateWebhook(id); err != nil {
+		return err
+	}
+	auditCertificateWebhook("certificate.webhook.deleted", operator.Username, w)
+	return nil
+}
+
+func auditCertificateWebhook(event, actor string, w *model.CertificateWebhook) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("certificate_webhook:%d", w.ID),
+		Detail: w.URL,
+	})
+}
+
+var GetCertificateWebhookDeliveries = db.GetCertificateWebhookDeliveries
+
+// dispatchCertificateWebhooks 为订阅了事件的接收端创建推送记录并立即异步推送，推送失败的由定时任务重试。
+// payload 中的证书只有 ID 时在推送前加载，沙箱证书和申请的事件不推送
+func dispatchCertificateWebhooks(payload *model.CertificateWebhookPayload) {
+	if !slices.Contains(certificateWebhookEvents, payload.Event) {
+		return
+	}
+	if payload.Request != nil {
+		if payload.Request.Sandbox {
+			return
+		}
+		// 调用方可能在推送前继续修改申请
+		req := *payload.Request
+		payload.Request = &req
+	}
+	payload.ID, payload.Time = uuid.NewString(), time.Now()
+	go func() {
+		hooks, err := db.GetEnabledCertificateWebhooks()
+		if err != nil {
+			log.Warnf("failed get certificate webhooks for [%s]: %+v", payload.Event, err)
+			return
+		}
+		hooks = slices.DeleteFunc(hooks, func(w model.CertificateWebhook) bool {
+			return !w.Subscribes(payload.Event)
+		})
+		if len(hooks) == 0 {
+			return
+		}
+		if payload.Certificate != nil && payload.Certificate.Content == "" {
+			cert, err := db.GetCertificateByID(payload.Certificate.ID)
+			if err != nil {
+				log.Warnf("failed get certificate %d for webhook [%s]: %+v", payload.Certificate.ID, payload.Event, err)
+				return
+			}
+			payload.Certificate = cert
+		}
+		if payload.Certificate != nil && payload.Certificate.Sandbox {
+			return
+		}
+		body, err := json.Marshal(payload)
+		if err != nil {
+			log.Warnf("failed marshal webhook payload [%s]: %+v", payload.Event, err)
+			return
+		}
+		// 立即推送失败或进程退出时由定时任务在第一个退避时间后重试
+		next := time.Now().Add(certificateWebhookBackoff[0])
+		deliveries := make([]model.CertificateWebhookDelivery, 0, len(hooks))
+		for _, w := range hooks {
+			deliveries = append(deliveries, model.CertificateWebhookDelivery{
+				WebhookID:     w.ID,
+				EventID:       payload.ID,
+				Event:         payload.Event,
+				Payload:       string(body),
+				Status:        model.WebhookDeliveryPending,
+				NextAttemptAt: &next,
+			})
+		}
+		if err := db.CreateCertificateWebhookDeliveries(deliveries); err != nil {
+			log.Warnf("failed create webhook deliveries [%s]: %+v", payload.Event, err)
+			return
+		}
+		for i := range deliveries {
+			if err := deliverCertificateWebhook(context.Background(), &hooks[i], &deliveries[i]); err != nil {
+				log.Warnf("failed save webhook delivery %d: %+v", deliveries[i].ID, err)
+			}
+		}
+	}()
+}
+
+// signCertificateWebhook 与接收 webhook 相同的签名方式：hex(HMAC-SHA256(secret, timestamp + "." + body))
+func signCertificateWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverCertificateWebhook 推送一次并保存结果，失败时按退避时间安排下一次重试，重试次数用尽后标记为失败
+func deliverCertificateWebhook(ctx context.Context, w *model.CertificateWebhook, d *model.CertificateWebhookDelivery) error {
+	ctx, cancel := context.WithTimeout(ctx, certificateWebhookTimeout)
+	defer cancel()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	res, err := base.RestyClient.R().SetContext(ctx).SetHeaders(map[string]string{
+		"Content-Type":         "application/json",
+		"X-OpenList-Event":     d.Event,
+		"X-OpenList-Delivery":  d.EventID,
+		"X-OpenList-Timestamp": timestamp,
+		"X-OpenList-Signature": signCertificateWebhook(w.Secret, timestamp, []byte(d.Payload)),
+	}).SetBody(d.Payload).Post(w.URL)
+
+	d.Attempts++
+	d.StatusCode, d.Response, d.LastError = 0, "", ""
+	if err != nil {
+		d.LastError = err.Error()
+	} else {
+		d.StatusCode = res.StatusCode()
+		if d.Response = res.String(); len(d.Response) > maxWebhookDeliveryResponse {
+			d.Response = d.Response[:maxWebhookDeliveryResponse]
+		}
+		if res.IsError() {
+			d.LastError = fmt.Sprintf("webhook responded with status %s", res.Status())
+		}
+	}
+	now := time.Now()
+	switch {
+	case d.LastError == "":
+		d.Status, d.DeliveredAt, d.NextAttemptAt = model.WebhookDeliverySucceeded, &now, nil
+	case d.Attempts > len(certificateWebhookBackoff):
+		d.Status, d.NextAttemptAt = model.WebhookDeliveryFailed, nil
+		log.Warnf("webhook delivery %d of [%s] to %s failed after %d attempts: %s", d.ID, d.Event, w.URL, d.Attempts, d.LastError)
+	default:
+		next := now.Add(certificateWebhookBackoff[d.Attempts-1])
+		d.Status, d.NextAttemptAt = model.WebhookDeliveryPending, &next
+	}
+	return db.UpdateCertificateWebhookDeliveryResult(d)
+}
+
+// RetryCertificateWebhookDeliveries 重试到达重试时间的推送，接收端已删除或停用时标记为失败
+func RetryCertificateWebhookDeliveries(ctx context.Context) error {
+	deliveries, err := db.GetDueCertificateWebhookDeliveries(time.Now(), certificateWebhookRetryBatch)
+	if err != nil {
+		return err
+	}
+	hooks := make(map[uint]*model.CertificateWebhook)
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		d := &deliveries[i]
+		w, ok := hooks[d.WebhookID]
+		if !ok {
+			if w, err = db.GetCertificateWebhookByID(d.WebhookID); err != nil {
+				w = nil
+			}
+			hooks[d.WebhookID] = w
+		}
+		if w == nil || !w.Enabled {
+			d.Status, d.NextAttemptAt, d.LastError = model.WebhookDeliveryFailed, nil, "webhook is deleted or disabled"
+			err = db.UpdateCertificateWebhookDeliveryResult(d)
+		} else {
+			err = deliverCertificateWebhook(ctx, w, d)
+		}
+		if err != nil {
+			log.Warnf("failed save webhook delivery %d: %+v", d.ID, err)
+		}
+	}
+	if _, err := db.DeleteCertificateWebhookDeliveriesBefore(time.Now().Add(-certificateWebhookDeliveryRetention)); err != nil {
+		return errors.WithMessage(err, "failed clean up webhook deliveries")
+	}
+	return nil
+}
+
+// RedeliverCertificateWebhook 立即重新推送一条推送记录，用于接收端修复后补发失败的事件
+func RedeliverCertificateWebhook(ctx context.Context, id uint, operator *model.User) (*model.CertificateWebhookDelivery, error) {
+	d, err := db.GetCertificateWebhookDeliveryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	w, err := db.GetCertificateWebhookByID(d.WebhookID)
+	if err != nil {
+		return nil, err
+	}
+	if !w.Enabled {
+		return nil, errs.NewErr(errs.CertificateConflict, "webhook %s is disabled", w.URL)
+	}
+	// 重试次数用尽的记录补发失败后不再自动重试
+	if err := deliverCertificateWebhook(ctx, w, d); err != nil {
+		return nil, err
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.webhook.redelivered",
+		Actor:  operator.Username,
+		Target: fmt.Sprintf("certificate_webhook:%d", w.ID),
+		Detail: fmt.Sprintf("delivery %d (%s)", d.ID, d.Event),
+	})
+	return d, nil
+}
@@ -0,0 +1,117 @@
+package op
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+)
+
+// certificateWebhookFireTimeout 测试推送等待接收端响应的最长时间
+const certificateWebhookFireTimeout = 15 * time.Second
+
+// certificateWebhookSamples 可以测试推送的事件及其示例内容，与真实通知的格式一致
+var certificateWebhookSamples = map[string]notify.Message{
+	"certificate.issued": {
+		Title:   "Certificate example.com has been issued",
+		Content: "Certificate example.com of alice has been issued and is valid until 2027-01-01T00:00:00Z.",
+	},
+	"certificate.renewed": {
+		Title:   "Certificate example.com has been renewed",
+		Content: "Certificate example.com of alice has been renewed, the previous certificate is superseded.",
+	},
+	"certificate.revoked": {
+		Title:   "Certificate example.com has been revoked",
+		Content: "Certificate example.com of alice has been revoked by admin.\nReason: keyCompromise",
+	},
+	"certificate.held": {
+		Title:   "Certificate example.com has been put on hold",
+		Content: "Certificate example.com of alice has been put on hold by admin.",
+	},
+	"certificate.unheld": {
+		Title:   "Certificate example.com has been released from hold",
+		Content: "Certificate example.com of alice has been released from hold by admin.",
+	},
+	"certificate.expiring": {
+		Severity: notify.SeverityWarning,
+		Title:    "Certificate example.com expires in 7 days",
+		Content:  "Certificate example.com of alice expires at 2027-01-01T00:00:00Z, please renew it in time.",
+	},
+	"certificate.expired": {
+		Severity: notify.SeverityWarning,
+		Title:    "Certificate example.com has expired",
+		Content:  "Certificate example.com of alice expired at 2027-01-01T00:00:00Z.",
+	},
+	"certificate.anomaly": {
+		Title:   "Unusual certificate activity detected",
+		Content: "alice created 20 certificate requests in the last hour.",
+	},
+	"certificate.honeytoken.tripped": {
+		Severity: notify.SeverityCritical,
+		Title:    "Honeytoken certificate example.com was accessed",
+		Content:  "Decoy certificate example.com was accessed: ocsp from 192.0.2.1 at Fri, 01 Jan 2027 00:00:00 UTC.\nNote: example",
+	},
+	"certificate.request.created": {
+		Title:   "New certificate request #1 is waiting for approval",
+		Content: "alice requested a user certificate.\nReason: example",
+	},
+	"certificate.request.approved": {
+		Title:   "Certificate request #1 has been approved",
+		Content: "Certificate request #1 of alice has been approved by admin.",
+	},
+	"certificate.request.rejected": {
+		Title:   "Certificate request #1 has been rejected",
+		Content: "Certificate request #1 of alice has been rejected by admin.\nReason: example",
+	},
+	"certificate.request.expired": {
+		Title:   "Certificate request #1 has expired",
+		Content: "Certificate request #1 of alice was not approved within 30 days and has expired, please submit a new request if the certificate is still needed.",
+	},
+	"certificate.request.commented": {
+		Title:   "New comment on certificate request #1",
+		Content: "admin: example comment",
+	},
+	"certificate.request.reminder": {
+		Title:   "Certificate request #1 is waiting for approval",
+		Content: "alice requested a user certificate 24h0m0s ago and it is still pending.\nReason: example",
+	},
+	"certificate.request.escalation": {
+		Title:   "Certificate request #1 is waiting for approval",
+		Content: "alice requested a user certificate 72h0m0s ago and it is still pending.\nReason: example",
+	},
+}
+
+// CertificateWebhookEvents 可以测试推送的事件
+func CertificateWebhookEvents() []string {
+	events := make([]string, 0, len(certificateWebhookSamples))
+	for event := range certificateWebhookSamples {
+		events = append(events, event)
+	}
+	slices.Sort(events)
+	return events
+}
+
+// FireCertificateWebhook 向配置的通知 webhook 推送事件的示例消息并返回接收端的响应和耗时，
+// 消息带有 test 标记。推送失败不作为错误返回，失败原因记录在结果中
+func FireCertificateWebhook(ctx context.Context, event string) (*notify.WebhookResult, error) {
+	sample, ok := certificateWebhookSamples[event]
+	if !ok {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unsupported webhook event: %s", event)
+	}
+	url := getSettingStr(conf.NotifyWebhookUrl, "")
+	if url == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "notify webhook is not configured")
+	}
+	msg := sample
+	msg.Event, msg.Test, msg.Time = event, true, time.Now()
+	if msg.Severity == "" {
+		msg.Severity = eventSeverity(event)
+	}
+	ctx, cancel := context.WithTimeout(ctx, certificateWebhookFireTimeout)
+	defer cancel()
+	res, _ := (&notify.Webhook{URL: url}).Deliver(ctx, &msg)
+	return res, nil
+}
@@ -0,0 +1,29 @@
+package op
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic"
+)
+
+// x509CacheSize 缓存的已解析证书数量上限
+const x509CacheSize = 4096
+
+// x509Cache 按 DER 的 sha256 指纹缓存解析后的证书，详情、证书链构建和校验时不必重复解析同一证书。
+// 缓存的证书在多个请求间共享，调用方不能修改
+var x509Cache = generic.NewLRU[[sha256.Size]byte, *x509.Certificate](x509CacheSize)
+
+// parseX509 解析 DER 编码的证书，优先使用缓存，解析失败的结果不缓存
+func parseX509(der []byte) (*x509.Certificate, error) {
+	key := sha256.Sum256(der)
+	if cert, ok := x509Cache.Get(key); ok {
+		return cert, nil
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	x509Cache.Add(key, cert)
+	return cert, nil
+}
@@ -0,0 +1,288 @@
+// Package certissuer 使用内部 CA 的私钥生成密钥对并签发 X.509 证书
+package certissuer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 支持的密钥算法
+const (
+	KeyRSA2048   = "rsa-2048"
+	KeyRSA3072   = "rsa-3072"
+	KeyRSA4096   = "rsa-4096"
+	KeyECDSAP256 = "ecdsa-p256"
+	KeyECDSAP384 = "ecdsa-p384"
+	KeyEd25519   = "ed25519"
+)
+
+// KeyAlgorithms 可选的密钥算法
+var KeyAlgorithms = []string{KeyRSA2048, KeyRSA3072, KeyRSA4096, KeyECDSAP256, KeyECDSAP384, KeyEd25519}
+
+// Issuer 签发证书的 CA，Chain 为 CA 证书到根证书的链，不包含根证书时客户端需自行信任
+type Issuer struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+	Chain       []*x509.Certificate
+}
+
+// Request 待签发证书的内容，SANs 中的 IP、邮箱和 URI 会分别放入对应字段，其余视为域名
+type Request struct {
+	CommonName   string
+	Organization string
+	SANs         []string
+	KeyAlgorithm string
+	ExtKeyUsage  []x509.ExtKeyUsage
+	NotBefore    time.Time
+	NotAfter     time.Time
+	// PublicKey 为空时生成新的密钥对，否则为 CSR 等外部提供的公钥，结果中不含私钥
+	PublicKey crypto.PublicKey
+	// ExtraExtensions 额外的证书扩展，如 OCSP 签名证书的 id-pkix-ocsp-nocheck
+	ExtraExtensions []pkix.Extension
+}
+
+// Result 签发结果，CertificatePEM 依次为新证书和 CA 证书链
+type Result struct {
+	Certificate    *x509.Certificate
+	CertificatePEM string
+	PrivateKeyPEM  string
+}
+
+// GenerateKey 按算法生成私钥
+func GenerateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case KeyRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyRSA3072:
+		return rsa.GenerateKey(rand.Reader, 3072)
+	case KeyRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyECDSAP256, "":
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	}
+	return nil, fmt.Errorf("unsupported key algorithm: %s", algorithm)
+}
+
+// serialNumber 生成 RFC 5280 要求的不超过 20 字节的正整数序列号
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 159))
+	if err != nil {
+		return nil, err
+	}
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+// applySANs 按格式将主题备用名称放入证书模板
+func applySANs(tmpl *x509.Certificate, sans []string) error {
+	for _, san := range sans {
+		san = strings.TrimSpace(san)
+		switch {
+		case san == "":
+		case net.ParseIP(san) != nil:
+			tmpl.IPAddresses = append(tmpl.IPAddresses, net.ParseIP(san))
+		case strings.Contains(san, "://"):
+			u, err := url.Parse(san)
+			if err != nil {
+				return fmt.Errorf("invalid URI SAN %q: %w", san, err)
+			}
+			tmpl.URIs = append(tmpl.URIs, u)
+		case strings.Contains(san, "@"):
+			tmpl.EmailAddresses = append(tmpl.EmailAddresses, san)
+		default:
+			tmpl.DNSNames = append(tmpl.DNSNames, strings.ToLower(san))
+		}
+	}
+	return nil
+}
+
+// Issue 按请求签发证书，证书有效期不会超过 CA 证书的有效期
+func (i *Issuer) Issue(req *Request) (*Result, error) {
+	if req.CommonName == "" {
+		return nil, fmt.Errorf("common name is required")
+	}
+	if !req.NotAfter.After(req.NotBefore) {
+		return nil, fmt.Errorf("not after %s must be later than not before %s", req.NotAfter, req.NotBefore)
+	}
+	if req.NotBefore.Before(i.Certificate.NotBefore) || req.NotAfter.After(i.Certificate.NotAfter) {
+		return nil, fmt.Errorf("validity exceeds the validity of CA %s", i.Certificate.Subject)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: req.CommonName},
+		NotBefore:    req.NotBefore,
+		NotAfter:     req.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  req.ExtKeyUsage,
+
+		ExtraExtensions: req.ExtraExtensions,
+	}
+	if req.Organization != "" {
+		tmpl.Subject.Organization = []string{req.Organization}
+	}
+	if err := applySANs(tmpl, req.SANs); err != nil {
+		return nil, err
+	}
+	var key crypto.Signer
+	pub := req.PublicKey
+	if pub == nil {
+		if key, err = GenerateKey(req.KeyAlgorithm); err != nil {
+			return nil, err
+		}
+		pub = key.Public()
+	}
+	// RSA 密钥还可以用于密钥交换
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		tmpl.KeyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	if tmpl.SubjectKeyId, err = subjectKeyID(pub); err != nil {
+		return nil, err
+	}
+	return i.sign(tmpl, pub, key)
+}
+
+// subjectKeyID 按 RFC 5280 4.2.1.2 的方法计算主题密钥标识符，x509 只会为 CA 证书自动生成
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &spki); err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum(spki.PublicKey.Bytes)
+	return sum[:], nil
+}
+
+// sign 由 i 签发模板对应的证书，i 为 nil 时使用 key 自签名
+func (i *Issuer) sign(tmpl *x509.Certificate, pub crypto.PublicKey, key crypto.Signer) (*Result, error) {
+	parent, parentKey, chain := tmpl, key, []*x509.Certificate{}
+	if i != nil {
+		parent, parentKey = i.Certificate, i.Key
+		chain = append([]*x509.Certificate{i.Certificate}, i.Chain...)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed create certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	var buf strings.Builder
+	for _, c := range append([]*x509.Certificate{cert}, chain...) {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: c.Raw}); err != nil {
+			return nil, err
+		}
+	}
+	res := &Result{Certificate: cert, CertificatePEM: buf.String()}
+	if key != nil {
+		keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		res.PrivateKeyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	}
+	return res, nil
+}
+
+// CARequest 待创建 CA 的内容
+type CARequest struct {
+	CommonName   string
+	Organization string
+	KeyAlgorithm string
+	NotBefore    time.Time
+	NotAfter     time.Time
+}
+
+func caTemplate(req *CARequest) (*x509.Certificate, error) {
+	if req.CommonName == "" {
+		return nil, fmt.Errorf("common name is required")
+	}
+	if !req.NotAfter.After(req.NotBefore) {
+		return nil, fmt.Errorf("not after %s must be later than not before %s", req.NotAfter, req.NotBefore)
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: req.CommonName},
+		NotBefore:             req.NotBefore,
+		NotAfter:              req.NotAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if req.Organization != "" {
+		tmpl.Subject.Organization = []string{req.Organization}
+	}
+	return tmpl, nil
+}
+
+// NewCA 生成密钥对并创建 CA 证书，parent 为 nil 时为自签名的根证书，否则为 parent 签发的中间证书
+func NewCA(req *CARequest, parent *Issuer) (*Result, error) {
+	if parent != nil && (req.NotBefore.Before(parent.Certificate.NotBefore) || req.NotAfter.After(parent.Certificate.NotAfter)) {
+		return nil, fmt.Errorf("validity exceeds the validity of CA %s", parent.Certificate.Subject)
+	}
+	key, err := GenerateKey(req.KeyAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := caTemplate(req)
+	if err != nil {
+		return nil, err
+	}
+	return parent.sign(tmpl, key.Public(), key)
+}
+
+// CrossSign 为另一个 CA 证书签发交叉证书，沿用其主题和公钥，有效期不超过 i 的有效期
+func (i *Issuer) CrossSign(ca *x509.Certificate) (*Result, error) {
+	notAfter := ca.NotAfter
+	if notAfter.After(i.Certificate.NotAfter) {
+		notAfter = i.Certificate.NotAfter
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               ca.Subject,
+		SubjectKeyId:          ca.SubjectKeyId,
+		NotBefore:             ca.NotBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              ca.KeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	return i.sign(tmpl, ca.PublicKey, nil)
+}
@@ -0,0 +1,146 @@
+package certissuer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testIssuer(t *testing.T) *Issuer {
+	key, err := GenerateKey(KeyECDSAP256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Issuer{Certificate: cert, Key: key}
+}
+
+func TestIssue(t *testing.T) {
+	issuer := testIssuer(t)
+	now := time.Now()
+	for _, alg := range []string{KeyECDSAP256, KeyRSA2048, KeyEd25519} {
+		res, err := issuer.Issue(&Request{
+			CommonName:   "node-1",
+			SANs:         []string{"node-1.example.com", "10.0.0.1", "ops@example.com", "spiffe://example.com/node-1"},
+			KeyAlgorithm: alg,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			NotBefore:    now,
+			NotAfter:     now.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if strings.Count(res.CertificatePEM, "BEGIN CERTIFICATE") != 2 {
+			t.Errorf("%s: chain should contain the certificate and the CA", alg)
+		}
+		if block, _ := pem.Decode([]byte(res.PrivateKeyPEM)); block == nil {
+			t.Errorf("%s: private key is not PEM encoded", alg)
+		}
+		c := res.Certificate
+		if len(c.DNSNames) != 1 || len(c.IPAddresses) != 1 || len(c.EmailAddresses) != 1 || len(c.URIs) != 1 {
+			t.Errorf("%s: unexpected SANs %v %v %v %v", alg, c.DNSNames, c.IPAddresses, c.EmailAddresses, c.URIs)
+		}
+		roots := x509.NewCertPool()
+		roots.AddCert(issuer.Certificate)
+		if _, err := c.Verify(x509.VerifyOptions{Roots: roots, DNSName: "node-1.example.com"}); err != nil {
+			t.Errorf("%s: %v", alg, err)
+		}
+	}
+}
+
+func TestIssueRejectsValidityBeyondCA(t *testing.T) {
+	issuer := testIssuer(t)
+	now := time.Now()
+	_, err := issuer.Issue(&Request{
+		CommonName: "node-1",
+		NotBefore:  now,
+		NotAfter:   now.Add(48 * time.Hour),
+	})
+	if err == nil {
+		t.Fatal("expected an error for validity beyond the CA")
+	}
+}
+
+func TestIssueWithPublicKey(t *testing.T) {
+	issuer := testIssuer(t)
+	key, err := GenerateKey(KeyECDSAP384)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	res, err := issuer.Issue(&Request{CommonName: "csr", PublicKey: key.Public(), NotBefore: now, NotAfter: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.PrivateKeyPEM != "" {
+		t.Error("no private key should be returned for a provided public key")
+	}
+}
+
+func TestNewCAAndCrossSign(t *testing.T) {
+	now := time.Now()
+	oldRoot := testIssuer(t)
+	res, err := NewCA(&CARequest{CommonName: "New Root", NotBefore: now, NotAfter: now.Add(time.Hour)}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Certificate.IsCA || res.Certificate.CheckSignatureFrom(res.Certificate) != nil {
+		t.Fatal("new root should be a self-signed CA")
+	}
+	cross, err := oldRoot.CrossSign(res.Certificate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cross.PrivateKeyPEM != "" {
+		t.Error("cross signing must not return a private key")
+	}
+
+	// 新根签发的证书可以通过交叉证书链到只被信任的旧根
+	block, _ := pem.Decode([]byte(res.PrivateKeyPEM))
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := (&Issuer{Certificate: res.Certificate, Key: key.(crypto.Signer)}).Issue(&Request{
+		CommonName: "leaf", NotBefore: now, NotAfter: now.Add(time.Minute),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(oldRoot.Certificate)
+	intermediates.AddCert(cross.Certificate)
+	if _, err := leaf.Certificate.Verify(x509.VerifyOptions{
+		Roots: roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	intermediate, err := NewCA(&CARequest{CommonName: "Intermediate", NotBefore: now, NotAfter: now.Add(48 * time.Hour)}, oldRoot)
+	if err == nil {
+		t.Fatalf("intermediate outliving its parent should be rejected, got %s", intermediate.Certificate.Subject)
+	}
+}
@@ -0,0 +1,79 @@
+package certissuer
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrQueueFull 等待签名的请求已达到队列上限
+	ErrQueueFull = errors.New("signing queue is full, try again later")
+	// ErrTimeout 在超时前没有完成签名
+	ErrTimeout = errors.New("signing timed out")
+)
+
+// Pool 限制同时进行的签名操作数量，避免突发的审批或签发请求耗尽 HSM 等签名后端的会话。
+// 超出并发数的请求排队等待，队列满时立即失败，等待和签名的总时间超过 timeout 时返回 ErrTimeout
+type Pool struct {
+	workers chan struct{}
+	queue   chan struct{}
+	timeout time.Duration
+}
+
+// NewPool 创建签名池，workers 为并发签名数，queue 为最多排队的请求数
+func NewPool(workers, queue int, timeout time.Duration) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	return &Pool{
+		workers: make(chan struct{}, workers),
+		queue:   make(chan struct{}, workers+queue),
+		timeout: timeout,
+	}
+}
+
+// Do 在签名池中执行 fn。超时后立即返回，fn 无法中断，会在后台执行完毕后才释放占用的并发数，
+// 超时后 fn 的结果会被丢弃
+func (p *Pool) Do(ctx context.Context, fn func() error) error {
+	select {
+	case p.queue <- struct{}{}:
+	default:
+		return ErrQueueFull
+	}
+	if p.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.timeout)
+		defer cancel()
+	}
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		<-p.queue
+		return waitError(ctx)
+	}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			<-p.workers
+			<-p.queue
+		}()
+		done <- fn()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return waitError(ctx)
+	}
+}
+
+func waitError(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return ErrTimeout
+	}
+	return ctx.Err()
+}
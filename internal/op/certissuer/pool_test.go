@@ -0,0 +1,55 @@
+package certissuer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPoolBackpressure(t *testing.T) {
+	p := NewPool(1, 1, 200*time.Millisecond)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	first := make(chan error, 1)
+	go func() {
+		first <- p.Do(context.Background(), func() error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	// 第二个请求排队，第三个请求因队列已满立即失败
+	second := make(chan error, 1)
+	go func() {
+		second <- p.Do(context.Background(), func() error { return nil })
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := p.Do(context.Background(), func() error { return nil }); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Do() = %v, want ErrQueueFull", err)
+	}
+
+	// 排队的请求等不到空闲的并发数时超时
+	if err := <-second; !errors.Is(err, ErrTimeout) {
+		t.Fatalf("queued Do() = %v, want ErrTimeout", err)
+	}
+	if err := <-first; !errors.Is(err, ErrTimeout) {
+		t.Fatalf("running Do() = %v, want ErrTimeout", err)
+	}
+
+	// 超时的签名执行完毕后释放并发数
+	close(release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := p.Do(context.Background(), func() error { return nil })
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pool was not released: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,7 @@
+package op
+
+// ExpireCertificateRequest 供测试直接调用单个申请的过期处理，模拟查询后状态已被其它操作修改的情况
+var ExpireCertificateRequest = expireCertificateRequest
+
+// ApprovalLinkPrincipal 供测试解析一键审批链接代表的委托人
+var ApprovalLinkPrincipal = approvalLinkPrincipal
@@ -0,0 +1,41 @@
+package op
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetImpersonatedTenant 校验支持人员可以以租户视角查看，管理员和审计员账号不能被模拟
+func GetImpersonatedTenant(operator *model.User, username string) (*model.User, error) {
+	if !operator.CanImpersonateTenants() {
+		return nil, errors.WithMessage(errs.PermissionDenied, "impersonation is not allowed")
+	}
+	target, err := GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == operator.ID || target.CanViewAllCertificates() || target.IsGuest() {
+		return nil, errors.WithMessagef(errs.PermissionDenied, "user %s can not be impersonated", username)
+	}
+	return target, nil
+}
+
+// AuditImpersonation 记录模拟租户期间的每个请求，blocked 表示请求因会改变数据而被拒绝
+func AuditImpersonation(operator, target *model.User, method, path, ip string, blocked bool) {
+	event, outcome := "tenant.impersonation.viewed", audit.OutcomeSuccess
+	if blocked {
+		event, outcome = "tenant.impersonation.blocked", audit.OutcomeFailure
+	}
+	audit.Emit(&audit.Event{
+		Type:    event,
+		Actor:   operator.Username,
+		Target:  fmt.Sprintf("user:%d", target.ID),
+		Outcome: outcome,
+		IP:      ip,
+		Detail:  fmt.Sprintf("%s %s as %s", method, path, target.Username),
+	})
+}
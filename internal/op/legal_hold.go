@@ -0,0 +1,108 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+var GetLegalHolds = db.GetLegalHolds
+
+// PlaceLegalHold 对证书或租户设置法律保留
+func PlaceLegalHold(scope string, targetID uint, reason string, operator *model.User) (*model.LegalHold, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "reason is required")
+	}
+	switch scope {
+	case model.LegalHoldCertificate:
+		if _, err := db.GetCertificateByID(targetID); err != nil {
+			return nil, err
+		}
+	case model.LegalHoldTenant:
+		if _, err := db.GetUserById(targetID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "unknown legal hold scope: %s", scope)
+	}
+	count, err := db.CountActiveLegalHolds(scope, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errs.NewErr(errs.CertificateConflict, "%s %d is already under legal hold", scope, targetID)
+	}
+	h := &model.LegalHold{
+		Scope:    scope,
+		TargetID: targetID,
+		Reason:   reason,
+		PlacedBy: operator.Username,
+	}
+	if err := db.CreateLegalHold(h); err != nil {
+		return nil, errors.WithMessage(err, "failed create legal hold")
+	}
+	auditLegalHold("certificate.legal_hold.placed", operator.Username, h, reason)
+	return h, nil
+}
+
+// ReleaseLegalHold 解除法律保留
+func ReleaseLegalHold(id uint, operator *model.User) error {
+	h, err := db.GetLegalHoldByID(id)
+	if err != nil {
+		return err
+	}
+	if !h.IsActive() {
+		return errs.NewErr(errs.CertificateConflict, "legal hold %d has already been released", id)
+	}
+	now := time.Now()
+	h.ReleasedBy = operator.Username
+	h.ReleasedAt = &now
+	if err := db.UpdateLegalHold(h); err != nil {
+		return err
+	}
+	auditLegalHold("certificate.legal_hold.released", operator.Username, h, "")
+	return nil
+}
+
+func auditLegalHold(event, actor string, h *model.LegalHold, detail string) {
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("%s:%d", h.Scope, h.TargetID),
+		Detail: detail,
+	})
+	if h.Scope == model.LegalHoldCertificate {
+		recordCertificateEvent(h.TargetID, event, actor, detail)
+	}
+}
+
+// checkTenantLegalHold 租户处于法律保留中时禁止删除、清理或匿名化其记录
+func checkTenantLegalHold(userID uint) error {
+	count, err := db.CountActiveLegalHolds(model.LegalHoldTenant, userID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errs.NewErr(errs.PermissionDenied, "records of user %d are under legal hold", userID)
+	}
+	return nil
+}
+
+// checkCertificateLegalHold 证书或其所有者处于法律保留中时禁止删除证书
+func checkCertificateLegalHold(cert *model.Certificate) error {
+	count, err := db.CountActiveLegalHolds(model.LegalHoldCertificate, cert.ID)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errs.NewErr(errs.PermissionDenied, "certificate %s is under legal hold", cert.Name)
+	}
+	return checkTenantLegalHold(cert.OwnerID)
+}
@@ -0,0 +1,70 @@
+package op
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	log "github.com/sirupsen/logrus"
+)
+
+var GetNotifyDevicesByUserID = db.GetNotifyDevicesByUserID
+var DeleteNotifyDevice = db.DeleteNotifyDeviceByIDAndUserID
+
+// RegisterNotifyDevice 注册用户接收推送通知的设备
+func RegisterNotifyDevice(user *model.User, platform, token, name string) (*model.NotifyDevice, error) {
+	if platform != notify.PlatformFCM && platform != notify.PlatformAPNs {
+		return nil, fmt.Errorf("invalid platform: %s", platform)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("device token is required")
+	}
+	d := &model.NotifyDevice{
+		UserID:   user.ID,
+		Platform: platform,
+		Token:    token,
+		Name:     name,
+	}
+	if err := db.SaveNotifyDevice(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetPushDevices 获取用户注册的推送设备，供推送通知渠道使用
+func GetPushDevices(usernames []string) []notify.Device {
+	names := make(map[uint]string, len(usernames))
+	ids := make([]uint, 0, len(usernames))
+	for _, name := range usernames {
+		user, err := GetUserByName(name)
+		if err != nil || user.Disabled {
+			continue
+		}
+		names[user.ID] = user.Username
+		ids = append(ids, user.ID)
+	}
+	devices, err := db.GetNotifyDevicesByUserIDs(ids)
+	if err != nil {
+		log.Warnf("failed get push devices: %+v", err)
+		return nil
+	}
+	res := make([]notify.Device, 0, len(devices))
+	for _, d := range devices {
+		res = append(res, notify.Device{
+			Username: names[d.UserID],
+			Platform: d.Platform,
+			Token:    d.Token,
+		})
+	}
+	return res
+}
+
+// UnregisterPushDevice 删除推送服务不再接受的设备令牌
+func UnregisterPushDevice(token string) {
+	if err := db.DeleteNotifyDeviceByToken(token); err != nil {
+		log.Warnf("failed delete invalid push device: %+v", err)
+	}
+}
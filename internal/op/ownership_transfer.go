@@ -0,0 +1,202 @@
+package op
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StartOwnershipTransfer 在后台将 fromUser 或 fromOrganization 名下的证书和未完成申请转移给 toUser。
+// 两个来源只能指定一个，dryRun 为 true 时只生成逐条结果不做修改
+func StartOwnershipTransfer(fromUser, fromOrganization, toUser string, dryRun bool, operator *model.User) (*model.OwnershipTransfer, error) {
+	if (fromUser == "") == (fromOrganization == "") {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "exactly one of from_user and from_organization is required")
+	}
+	to, err := db.GetUserByName(toUser)
+	if err != nil {
+		return nil, err
+	}
+	if to.Disabled {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "user %s is disabled", to.Username)
+	}
+	t := &model.OwnershipTransfer{
+		FromOrganization: fromOrganization,
+		ToUserID:         to.ID,
+		ToUser:           to.Username,
+		DryRun:           dryRun,
+		Status:           model.OwnershipTransferRunning,
+		StartedBy:        operator.Username,
+	}
+	if fromUser != "" {
+		from, err := db.GetUserByName(fromUser)
+		if err != nil {
+			return nil, err
+		}
+		if from.ID == to.ID {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "cannot transfer to the same user")
+		}
+		t.FromUserID, t.FromUser = from.ID, from.Username
+	}
+	if err := db.CreateOwnershipTransfer(t); err != nil {
+		return nil, errors.WithMessage(err, "failed create ownership transfer")
+	}
+	auditOwnershipTransfer("certificate.ownership_transfer.started", operator.Username, t, "")
+	go runOwnershipTransfer(t)
+	return t, nil
+}
+
+// GetOwnershipTransfers 获取全部所有权转移任务
+func GetOwnershipTransfers() ([]model.OwnershipTransfer, error) {
+	return db.GetOwnershipTransfers()
+}
+
+// GetOwnershipTransfer 获取所有权转移任务及逐条结果
+func GetOwnershipTransfer(id uint) (*model.OwnershipTransfer, error) {
+	return db.GetOwnershipTransferByID(id)
+}
+
+func runOwnershipTransfer(t *model.OwnershipTransfer) {
+	err := transferOwnership(t)
+	now := time.Now()
+	t.CompletedAt = &now
+	t.Status = model.OwnershipTransferCompleted
+	if err != nil {
+		log.Errorf("ownership transfer %d failed: %+v", t.ID, err)
+		t.Status = model.OwnershipTransferFailed
+		t.LastError = err.Error()
+	}
+	if err := db.UpdateOwnershipTransfer(t); err != nil {
+		log.Errorf("failed to save ownership transfer %d: %+v", t.ID, err)
+	}
+	auditOwnershipTransfer("certificate.ownership_transfer.completed", t.StartedBy, t,
+		fmt.Sprintf("%s: %d transferred, %d skipped, %d failed", t.Status, t.Transferred, t.Skipped, t.Failed))
+}
+
+func transferOwnership(t *model.OwnershipTransfer) error {
+	users := map[uint]string{}
+	if t.FromOrganization != "" {
+		members, err := db.GetUsersByOrganization(t.FromOrganization)
+		if err != nil {
+			return err
+		}
+		for _, u := range members {
+			if u.ID != t.ToUserID {
+				users[u.ID] = u.Username
+			}
+		}
+	} else {
+		users[t.FromUserID] = t.FromUser
+	}
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	certs, err := db.GetCertificatesByOwnerIDs(ids)
+	if err != nil {
+		return err
+	}
+	requests, err := db.GetOpenCertificateRequestsByUserIDs(ids)
+	if err != nil {
+		return err
+	}
+	t.Total = len(certs) + len(requests)
+	for i := range certs {
+		t.AddResult(transferCertificate(t, &certs[i]))
+	}
+	// 每个用户只能有一个待处理的申请，目标用户已有待处理申请时跳过
+	open, err := hasOpenCertificateRequest(t.ToUserID)
+	if err != nil {
+		return err
+	}
+	for i := range requests {
+		req := &requests[i]
+		r := transferCertificateRequest(t, req, open)
+		if r.Outcome != model.OwnershipTransferSkip && req.Status != model.CertificateStatusDraft {
+			open = true
+		}
+		t.AddResult(r)
+	}
+	return nil
+}
+
+func transferCertificate(t *model.OwnershipTransfer, cert *model.Certificate) model.OwnershipTransferResult {
+	r := model.OwnershipTransferResult{Kind: "certificate", ID: cert.ID, Name: cert.Name, FromUser: cert.Owner}
+	// 法律保留中的证书需保持原样
+	if err := checkCertificateLegalHold(cert); err != nil {
+		r.Outcome, r.Error = model.OwnershipTransferSkip, err.Error()
+		return r
+	}
+	if t.DryRun {
+		r.Outcome = model.OwnershipWouldTransfer
+		return r
+	}
+	if err := db.TransferCertificateOwner(cert.ID, t.ToUserID, t.ToUser); err != nil {
+		r.Outcome, r.Error = model.OwnershipTransferFailure, err.Error()
+		return r
+	}
+	r.Outcome = model.OwnershipTransferred
+	recordCertificateEvent(cert.ID, "certificate.transferred", t.StartedBy,
+		fmt.Sprintf("from %s to %s by transfer %d", cert.Owner, t.ToUser, t.ID))
+	return r
+}
+
+func transferCertificateRequest(t *model.OwnershipTransfer, req *model.CertificateRequest, targetHasOpen bool) model.OwnershipTransferResult {
+	r := model.OwnershipTransferResult{Kind: "request", ID: req.ID, FromUser: req.UserName}
+	if targetHasOpen && req.Status != model.CertificateStatusDraft {
+		r.Outcome, r.Error = model.OwnershipTransferSkip, fmt.Sprintf("user %s already has an open request", t.ToUser)
+		return r
+	}
+	if err := checkTenantLegalHold(req.UserID); err != nil {
+		r.Outcome, r.Error = model.OwnershipTransferSkip, err.Error()
+		return r
+	}
+	if t.DryRun {
+		r.Outcome = model.OwnershipWouldTransfer
+		return r
+	}
+	if err := db.TransferCertificateRequestOwner(req.ID, t.ToUserID, t.ToUser); err != nil {
+		r.Outcome, r.Error = model.OwnershipTransferFailure, err.Error()
+		return r
+	}
+	r.Outcome = model.OwnershipTransferred
+	auditCertificateRequest("certificate.request.transferred", t.StartedBy, req,
+		fmt.Sprintf("from %s to %s by transfer %d", req.UserName, t.ToUser, t.ID))
+	return r
+}
+
+func hasOpenCertificateRequest(userID uint) (bool, error) {
+	_, err := db.GetPendingCertificateRequestByUserID(userID)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	return false, err
+}
+
+func auditOwnershipTransfer(event, actor string, t *model.OwnershipTransfer, detail string) {
+	from := t.FromUser
+	if t.FromOrganization != "" {
+		from = "organization " + t.FromOrganization
+	}
+	if detail == "" {
+		detail = fmt.Sprintf("from %s to %s, dry run: %t", from, t.ToUser, t.DryRun)
+	}
+	audit.Emit(&audit.Event{
+		Type:   event,
+		Actor:  actor,
+		Target: fmt.Sprintf("ownership_transfer:%d", t.ID),
+		Detail: detail,
+	})
+}
@@ -0,0 +1,221 @@
+package op
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"math/big"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ocsp"
+)
+
+// revocationReasonCodes RFC 5280 吊销原因对应的 CRLReason 代码
+var revocationReasonCodes = map[string]int{
+	"unspecified":                         ocsp.Unspecified,
+	model.RevocationReasonKeyCompromise:   ocsp.KeyCompromise,
+	"cACompromise":                        ocsp.CACompromise,
+	"affiliationChanged":                  ocsp.AffiliationChanged,
+	model.RevocationReasonSuperseded:      ocsp.Superseded,
+	"cessationOfOperation":                ocsp.CessationOfOperation,
+	model.RevocationReasonCertificateHold: ocsp.CertificateHold,
+	"privilegeWithdrawn":                  ocsp.PrivilegeWithdrawn,
+	"aACompromise":                        ocsp.AACompromise,
+}
+
+var (
+	revocationPublishMu   sync.Mutex
+	revocationPublishing  sync.Mutex
+	lastRevocationPublish *model.RevocationPublishStatus
+)
+
+// SetCertificateAuthorityKey 为 CA 设置签名私钥，设置后 OpenList 为其签发 CRL 和 OCSP 响应
+func SetCertificateAuthorityKey(id uint, content string, operator *model.User) error {
+	ca, err := db.GetCertificateAuthorityByID(id)
+	if err != nil {
+		return err
+	}
+	if _, err := parsePrivateKey(ca.Content, content); err != nil {
+		return err
+	}
+	ca.PrivateKey = strings.TrimSpace(content) + "\n"
+	if err := db.UpdateCertificateAuthority(ca); err != nil {
+		return err
+	}
+	auditCertificateAuthority("certificate.ca.key_set", operator, ca)
+	PublishRevocationDataAsync()
+	return nil
+}
+
+// GetRevocationPublishStatus 获取最近一次发布 CRL 和 OCSP 响应的结果
+func GetRevocationPublishStatus() *model.RevocationPublishStatus {
+	revocationPublishMu.Lock()
+	defer revocationPublishMu.Unlock()
+	if lastRevocationPublish == nil {
+		return &model.RevocationPublishStatus{
+			Path:         getSettingStr(conf.CertRevocationPublishPath, ""),
+			Publications: []model.RevocationPublication{},
+		}
+	}
+	return lastRevocationPublish
+}
+
+// PublishRevocationDataAsync 吊销状态变化后在后台重新发布，正在发布时跳过，由下一轮定时任务补齐。
+// 同时使接口提供的 CRL 失效，下次请求时重新生成
+func PublishRevocationDataAsync() {
+	invalidateCRLCache()
+	if getSettingStr(conf.CertRevocationPublishPath, "") == "" {
+		return
+	}
+	go func() {
+		if _, err := PublishRevocationData(context.Background()); err != nil {
+			log.Errorf("failed to publish revocation data: %+v", err)
+		}
+	}()
+}
+
+// PublishRevocationData 为持有私钥的在用 CA 重新生成 CRL 和预签名的 OCSP 响应，并写入配置的存储路径，
+// 该路径可以是挂载的对象存储或 CDN 源站，OpenList 实例不可用时依赖方仍能获取吊销数据。
+// CRL 写入 crl/<CA 指纹>.crl，OCSP 响应写入 ocsp/<CA 指纹>/<十六进制序列号>.der，均为 DER 编码
+func PublishRevocationData(ctx context.Context) (*model.RevocationPublishStatus, error) {
+	dir := getSettingStr(conf.CertRevocationPublishPath, "")
+	if dir == "" {
+		return nil, nil
+	}
+	if !revocationPublishing.TryLock() {
+		return GetRevocationPublishStatus(), nil
+	}
+	defer revocationPublishing.Unlock()
+
+	now := time.Now()
+	status := &model.RevocationPublishStatus{Path: dir, PublishedAt: &now, Publications: []model.RevocationPublication{}}
+	cas, err := db.GetActiveCertificateAuthorities()
+	if err != nil {
+		return nil, err
+	}
+	for i := range cas {
+		ca := &cas[i]
+		if !ca.IsActive(now) || !ca.HasPrivateKey() {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		pub := publishCertificateAuthorityRevocations(ctx, dir, ca, now)
+		if pub.Error != "" {
+			status.Error = fmt.Sprintf("failed to publish revocation data of ca %s", ca.Name)
+		}
+		status.Publications = append(status.Publications, pub)
+	}
+	revocationPublishMu.Lock()
+	lastRevocationPublish = status
+	revocationPublishMu.Unlock()
+	if status.Error != "" {
+		audit.Emit(&audit.Event{
+			Type:    "certificate.revocation.publish_failed",
+			Actor:   "system",
+			Target:  dir,
+			Outcome: audit.OutcomeFailure,
+			Detail:  status.Error,
+		})
+	}
+	return status, nil
+}
+
+func publishCertificateAuthorityRevocations(ctx context.Context, dir string, ca *model.CertificateAuthority, now time.Time) model.RevocationPublication {
+	pub := model.RevocationPublication{
+		CAID:        ca.ID,
+		CAName:      ca.Name,
+		Fingerprint: ca.Fingerprint,
+		CRLPath:     stdpath.Join(dir, "crl", ca.Fingerprint+".crl"),
+		OCSPPath:    stdpath.Join(dir, "ocsp", ca.Fingerprint),
+		ThisUpdate:  now,
+		NextUpdate:  now.Add(time.Duration(getSettingInt(conf.CertCRLValidityHours, 24)) * time.Hour),
+	}
+	if err := publishCertificateAuthorityRevocationsTo(ctx, &pub, ca); err != nil {
+		log.Errorf("failed to publish revocation data of ca %d: %+v", ca.ID, err)
+		pub.Error = err.Error()
+	}
+	return pub
+}
+
+func publishCertificateAuthorityRevocationsTo(ctx context.Context, pub *model.RevocationPublication, ca *model.CertificateAuthority) error {
+	signer, err := parsePrivateKey(ca.Content, ca.PrivateKey)
+	if err != nil {
+		return err
+	}
+	parsed, err := parseCertificates(ca.Content)
+	if err != nil {
+		return err
+	}
+	issuer := parsed[0]
+	certs, err := db.GetPublishableCertificatesByIssuer(ca.ID)
+	if err != nil {
+		return err
+	}
+	var entries []x509.RevocationListEntry
+	for i := range certs {
+		cert := &certs[i]
+		serial, ok := new(big.Int).SetString(cert.SerialNumber, 16)
+		if !ok {
+			continue
+		}
+		template := ocsp.Response{
+			Status:       ocsp.Good,
+			SerialNumber: serial,
+			ThisUpdate:   pub.ThisUpdate,
+			NextUpdate:   pub.NextUpdate,
+		}
+		if entry, ok := revocationListEntry(cert, serial); ok {
+			entries = append(entries, entry)
+			template.Status, template.RevokedAt, template.RevocationReason = ocsp.Revoked, entry.RevocationTime, entry.ReasonCode
+		}
+		resp, err := ocsp.CreateResponse(issuer, issuer, template, signer)
+		if err != nil {
+			return errors.Wrapf(err, "failed to sign ocsp response for certificate %d", cert.ID)
+		}
+		if err := putRevocationFile(ctx, pub.OCSPPath, cert.SerialNumber+".der", "application/ocsp-response", resp); err != nil {
+			return err
+		}
+		pub.OCSP++
+	}
+	crl, err := signCRL(issuer, signer, entries, pub.ThisUpdate, pub.NextUpdate)
+	if err != nil {
+		return err
+	}
+	pub.Revoked = len(entries)
+	return putRevocationFile(ctx, stdpath.Dir(pub.CRLPath), stdpath.Base(pub.CRLPath), "application/pkix-crl", crl)
+}
+
+// putRevocationFile 将文件写入 OpenList 存储，已存在的同名文件会被覆盖
+func putRevocationFile(ctx context.Context, dir, name, mimetype string, data []byte) error {
+	storage, actualPath, err := GetStorageAndActualPath(dir)
+	if err != nil {
+		return errors.WithMessagef(err, "failed get storage of %s", dir)
+	}
+	if err := MakeDir(ctx, storage, actualPath); err != nil {
+		return errors.WithMessagef(err, "failed make dir %s", dir)
+	}
+	file := &stream.FileStream{
+		Ctx: ctx,
+		Obj: &model.Object{
+			Name:     name,
+			Size:     int64(len(data)),
+			Modified: time.Now(),
+		},
+		Reader:   bytes.NewReader(data),
+		Mimetype: mimetype,
+	}
+	return Put(ctx, storage, actualPath, file, nil)
+}
@@ -0,0 +1,42 @@
+package op
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	log "github.com/sirupsen/logrus"
+)
+
+// InstanceID identifies this process among the instances sharing the database
+var InstanceID = func() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), random.String(6))
+}()
+
+var GetSchedulerLeases = db.GetSchedulerLeases
+
+// AcquireSchedulerLease elects this instance to run the named job. The ttl should be longer
+// than the interval of the job, so the leader keeps renewing it and another instance only
+// takes over once the leader has stopped. Database errors count as not acquired, running a
+// job twice is worse than skipping it once.
+func AcquireSchedulerLease(name string, ttl time.Duration) bool {
+	ok, err := db.AcquireSchedulerLease(name, InstanceID, ttl)
+	if err != nil {
+		log.Errorf("failed to acquire scheduler lease %s: %+v", name, err)
+		return false
+	}
+	return ok
+}
+
+// ReleaseSchedulerLease gives up the lease on shutdown
+func ReleaseSchedulerLease(name string) {
+	if err := db.ReleaseSchedulerLease(name, InstanceID); err != nil {
+		log.Warnf("failed to release scheduler lease %s: %+v", name, err)
+	}
+}
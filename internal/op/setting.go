@@ -206,3 +206,24 @@ type MigrationValueItem struct {
 }
 
 var MigrationSettingItems map[string]MigrationValueItem
+
+func getSettingStr(key string, defaultValue string) string {
+	item, err := GetSettingItemByKey(key)
+	if err != nil {
+		return defaultValue
+	}
+	return item.Value
+}
+
+func getSettingInt(key string, defaultValue int) int {
+	i, err := strconv.Atoi(getSettingStr(key, ""))
+	if err != nil {
+		return defaultValue
+	}
+	return i
+}
+
+func getSettingBool(key string) bool {
+	v := getSettingStr(key, "")
+	return v == "true" || v == "1"
+}
@@ -0,0 +1,157 @@
+package op
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"golang.org/x/crypto/ssh"
+)
+
+var GetSSHHostPrincipals = db.GetSSHHostPrincipals
+
+func checkSSHHostPrincipal(p *model.SSHHostPrincipal) error {
+	p.Principal = strings.TrimSpace(p.Principal)
+	p.HostPattern = strings.TrimSpace(p.HostPattern)
+	if p.Principal == "" {
+		return errs.NewErr(errs.InvalidCertificateRequest, "principal is required")
+	}
+	if strings.ContainsAny(p.Principal, ", \t\n") {
+		return errs.NewErr(errs.InvalidCertificateRequest, "principal %q must not contain commas or whitespace", p.Principal)
+	}
+	if strings.ContainsAny(p.HostPattern, " \t\n") {
+		return errs.NewErr(errs.InvalidCertificateRequest, "host pattern %q must not contain whitespace", p.HostPattern)
+	}
+	return nil
+}
+
+func CreateSSHHostPrincipal(p *model.SSHHostPrincipal) error {
+	if err := checkSSHHostPrincipal(p); err != nil {
+		return err
+	}
+	return db.CreateSSHHostPrincipal(p)
+}
+
+// UpdateSSHHostPrincipal 更新主机 principal 的匹配模式和说明
+func UpdateSSHHostPrincipal(p *model.SSHHostPrincipal) error {
+	old, err := db.GetSSHHostPrincipalByID(p.ID)
+	if err != nil {
+		return err
+	}
+	p.CreatedAt = old.CreatedAt
+	if err := checkSSHHostPrincipal(p); err != nil {
+		return err
+	}
+	return db.UpdateSSHHostPrincipal(p)
+}
+
+func DeleteSSHHostPrincipal(id uint) error {
+	if _, err := db.GetSSHHostPrincipalByID(id); err != nil {
+		return err
+	}
+	return db.DeleteSSHHostPrincipal(id)
+}
+
+// getSSHCAPublicKey 读取配置的 SSH CA 公钥
+func getSSHCAPublicKey() (ssh.PublicKey, error) {
+	content := strings.TrimSpace(getSettingStr(conf.CertSSHCAPublicKey, ""))
+	if content == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "SSH CA public key is not configured")
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(content))
+	if err != nil {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid SSH CA public key: %v", err)
+	}
+	return key, nil
+}
+
+// GetSSHKnownHosts 生成信任 SSH CA 的 @cert-authority known_hosts 行，ids 为空时包含全部主机 principal
+func GetSSHKnownHosts(ids []uint) (string, error) {
+	key, err := getSSHCAPublicKey()
+	if err != nil {
+		return "", err
+	}
+	principals, err := db.GetSSHHostPrincipals()
+	if err != nil {
+		return "", err
+	}
+	var patterns []string
+	for _, p := range principals {
+		if len(ids) > 0 && !slices.Contains(ids, p.ID) {
+			continue
+		}
+		if !slices.Contains(patterns, p.Pattern()) {
+			patterns = append(patterns, p.Pattern())
+		}
+	}
+	if len(patterns) == 0 {
+		return "", errs.NewErr(errs.InvalidCertificateRequest, "no SSH host principal is selected")
+	}
+	return fmt.Sprintf("@cert-authority %s %s openlist-ssh-ca\n", strings.Join(patterns, ","),
+		strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))), nil
+}
+
+// parseSSHCertificate 解析证书内容中的 OpenSSH 证书
+func parseSSHCertificate(content string) (*ssh.Certificate, error) {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(content)))
+	if err != nil {
+		return nil, err
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an SSH certificate", key.Type())
+	}
+	return cert, nil
+}
+
+// sshCertTime 转换 SSH 证书中的时间，永久有效的证书按 9999 年年底处理
+func sshCertTime(t uint64) time.Time {
+	forever := time.Date(9999, 12, 31, 23, 59, 59, 0, time.UTC)
+	if t >= uint64(forever.Unix()) {
+		return forever
+	}
+	return time.Unix(int64(t), 0)
+}
+
+// GetSSHCertificatesByPrincipal 获取包含指定 principal 的有效 SSH 证书，principal 为空时返回全部
+func GetSSHCertificatesByPrincipal(principal string) ([]model.SSHCertificateInfo, error) {
+	certs, err := db.GetActiveCertificatesByType(model.CertificateTypeSSH)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	res := []model.SSHCertificateInfo{}
+	for _, cert := range certs {
+		sshCert, err := parseSSHCertificate(cert.Content)
+		if err != nil {
+			continue
+		}
+		info := model.SSHCertificateInfo{
+			CertificateID: cert.ID,
+			Name:          cert.Name,
+			Owner:         cert.Owner,
+			CertType:      "user",
+			KeyID:         sshCert.KeyId,
+			Serial:        sshCert.Serial,
+			Principals:    sshCert.ValidPrincipals,
+			ValidAfter:    sshCertTime(sshCert.ValidAfter),
+			ValidBefore:   sshCertTime(sshCert.ValidBefore),
+		}
+		if sshCert.CertType == ssh.HostCert {
+			info.CertType = "host"
+		}
+		if !info.ValidBefore.After(now) {
+			continue
+		}
+		if principal != "" && !slices.Contains(sshCert.ValidPrincipals, principal) {
+			continue
+		}
+		res = append(res, info)
+	}
+	return res, nil
+}
@@ -0,0 +1,243 @@
+package op
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cms"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	oidTSTInfo              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidExtKeyUsage          = asn1.ObjectIdentifier{2, 5, 29, 37}
+	// timestampDigests 接受的消息摘要算法及其长度
+	timestampDigests = map[string]int{
+		"1.3.14.3.2.26":          20, // SHA-1，仅为兼容旧的签名工具
+		"2.16.840.1.101.3.4.2.1": 32, // SHA-256
+		"2.16.840.1.101.3.4.2.2": 48, // SHA-384
+		"2.16.840.1.101.3.4.2.3": 64, // SHA-512
+	}
+)
+
+// RFC 3161 PKIStatus 和 PKIFailureInfo
+const (
+	timestampGranted   = 0
+	timestampRejection = 2
+
+	timestampBadAlg           = 0
+	timestampBadRequest       = 2
+	timestampBadDataFormat    = 5
+	timestampUnacceptedPolicy = 15
+	timestampSystemFailure    = 25
+)
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+	Extensions     []pkix.Extension      `asn1:"optional,tag:0"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+}
+
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time `asn1:"generalized"`
+	Accuracy       accuracy  `asn1:"optional"`
+	Nonce          *big.Int  `asn1:"optional"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []asn1.RawValue `asn1:"optional"` // PKIFreeText，UTF8String 序列
+	FailInfo     asn1.BitString  `asn1:"optional"`
+}
+
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+// timestampAuthority 由设置中的 TSA 证书和私钥解析出的签名者，设置不变时复用
+type timestampAuthority struct {
+	source string
+	chain  []*x509.Certificate
+	key    crypto.Signer
+	policy asn1.ObjectIdentifier
+}
+
+var (
+	timestampAuthorityMu     sync.Mutex
+	cachedTimestampAuthority *timestampAuthority
+)
+
+// getTimestampAuthority 加载专用的 TSA 证书，证书必须且只能用于时间戳（扩展密钥用法为关键的 timeStamping）
+func getTimestampAuthority() (*timestampAuthority, error) {
+	content := getSettingStr(conf.CertTSACertificate, "")
+	keyPEM := getSettingStr(conf.CertTSAPrivateKey, "")
+	policy := getSettingStr(conf.CertTSAPolicy, "2.5.29.32.0")
+	if content == "" || keyPEM == "" {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "timestamp authority is not configured")
+	}
+	source := content + "\x00" + keyPEM + "\x00" + policy
+	timestampAuthorityMu.Lock()
+	defer timestampAuthorityMu.Unlock()
+	if cachedTimestampAuthority != nil && cachedTimestampAuthority.source == source {
+		return cachedTimestampAuthority, nil
+	}
+	chain, err := parseCertificates(content)
+	if err != nil {
+		return nil, err
+	}
+	cert := chain[0]
+	critical := false
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtKeyUsage) {
+			critical = ext.Critical
+		}
+	}
+	if !critical || len(cert.ExtKeyUsage) != 1 || cert.ExtKeyUsage[0] != x509.ExtKeyUsageTimeStamping {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "tsa certificate must only have the critical timeStamping extended key usage")
+	}
+	key, err := parsePrivateKey(content, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	oid, err := parseOID(policy)
+	if err != nil {
+		return nil, err
+	}
+	cachedTimestampAuthority = &timestampAuthority{source: source, chain: chain, key: key, policy: oid}
+	return cachedTimestampAuthority, nil
+}
+
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	for _, part := range strings.Split(strings.TrimSpace(s), ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid object identifier: %s", s)
+		}
+		oid = append(oid, n)
+	}
+	if len(oid) < 2 {
+		return nil, errs.NewErr(errs.InvalidCertificateRequest, "invalid object identifier: %s", s)
+	}
+	return oid, nil
+}
+
+func timestampFailure(failInfo int, text string) ([]byte, error) {
+	bits := make([]byte, failInfo/8+1)
+	bits[failInfo/8] = 0x80 >> (failInfo % 8)
+	return asn1.Marshal(timeStampResp{Status: pkiStatusInfo{
+		Status:       timestampRejection,
+		StatusString: []asn1.RawValue{{Tag: asn1.TagUTF8String, Bytes: []byte(text)}},
+		FailInfo:     asn1.BitString{Bytes: bits, BitLength: failInfo + 1},
+	}})
+}
+
+// Timestamp 处理 DER 编码的 RFC 3161 TimeStampReq，返回 DER 编码的 TimeStampResp。
+// 请求本身有问题时返回带失败原因的响应而不是 error，只有无法编码响应时才返回 error
+func Timestamp(reqDER []byte, ip string) ([]byte, error) {
+	var req timeStampReq
+	if rest, err := asn1.Unmarshal(reqDER, &req); err != nil || len(rest) > 0 || req.Version != 1 {
+		return timestampFailure(timestampBadDataFormat, "malformed timestamp request")
+	}
+	size, ok := timestampDigests[req.MessageImprint.HashAlgorithm.Algorithm.String()]
+	if !ok {
+		return timestampFailure(timestampBadAlg, "unsupported hash algorithm")
+	}
+	if len(req.MessageImprint.HashedMessage) != size {
+		return timestampFailure(timestampBadRequest, "hashed message does not match the hash algorithm")
+	}
+	tsa, err := getTimestampAuthority()
+	if err != nil {
+		log.Warnf("failed to load timestamp authority: %+v", err)
+		return timestampFailure(timestampSystemFailure, "timestamp authority is not available")
+	}
+	if len(req.ReqPolicy) > 0 && !req.ReqPolicy.Equal(tsa.policy) {
+		return timestampFailure(timestampUnacceptedPolicy, "requested policy is not supported")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	info, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         tsa.policy,
+		MessageImprint: req.MessageImprint,
+		SerialNumber:   serial,
+		GenTime:        now,
+		Accuracy:       accuracy{Seconds: 1},
+		Nonce:          req.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	signer := tsa.chain[0]
+	certHash := sha256.Sum256(signer.Raw)
+	opts := &cms.SignOptions{
+		ContentType: oidTSTInfo,
+		SigningTime: now,
+		Attributes: []cms.Attribute{{
+			Type:  oidSigningCertificateV2,
+			Value: signingCertificateV2{Certs: []essCertIDv2{{CertHash: certHash[:]}}},
+		}},
+	}
+	// 请求要求时附带 TSA 证书链，便于验证方在没有预置证书时校验
+	if req.CertReq {
+		opts.Certificates = tsa.chain
+	}
+	token, err := cms.Sign(info, signer, tsa.key, opts)
+	if err != nil {
+		log.Errorf("failed to sign timestamp: %+v", err)
+		return timestampFailure(timestampSystemFailure, "failed to sign timestamp")
+	}
+	audit.Emit(&audit.Event{
+		Type:   "certificate.timestamp.issued",
+		Actor:  "anonymous",
+		Target: "timestamp:" + serial.Text(16),
+		IP:     ip,
+		Detail: fmt.Sprintf("%s hash %x", req.MessageImprint.HashAlgorithm.Algorithm, req.MessageImprint.HashedMessage),
+	})
+	return asn1.Marshal(timeStampResp{
+		Status:         pkiStatusInfo{Status: timestampGranted},
+		TimeStampToken: asn1.RawValue{FullBytes: token},
+	})
+}
@@ -81,6 +81,9 @@ func DeleteUserById(id uint) error {
 	if old.IsAdmin() || old.IsGuest() {
 		return errs.DeleteAdminOrGuest
 	}
+	if err := checkTenantLegalHold(id); err != nil {
+		return err
+	}
 	userCache.Del(old.Username)
 	return db.DeleteUserById(id)
 }
@@ -0,0 +1,309 @@
+// Package certlint checks X.509 certificates against RFC 5280 and the CA/Browser
+// Forum Baseline Requirements. Lint names, sources and severities follow zlint so
+// findings can be compared with zlint reports, only a subset of its lints that
+// matters for the certificates this project issues and imports is implemented.
+package certlint
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Severity of a finding, in increasing order
+type Severity string
+
+const (
+	Notice Severity = "notice"
+	Warn   Severity = "warn"
+	Error  Severity = "error"
+	Fatal  Severity = "fatal"
+)
+
+var severityRank = map[Severity]int{Notice: 1, Warn: 2, Error: 3, Fatal: 4}
+
+// Valid reports whether s is a known severity
+func (s Severity) Valid() bool {
+	_, ok := severityRank[s]
+	return ok
+}
+
+// AtLeast reports whether s is as severe as min or more
+func (s Severity) AtLeast(min Severity) bool {
+	return min.Valid() && severityRank[s] >= severityRank[min]
+}
+
+// Sources of the requirements checked by the lints
+const (
+	SourceRFC5280 = "RFC5280"
+	SourceCABFBR  = "CABF_BR"
+)
+
+// Finding is a requirement the certificate violates
+type Finding struct {
+	Lint     string   `json:"lint"`
+	Source   string   `json:"source"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// maxTLSServerValidity is the longest validity of TLS server certificates allowed by the Baseline Requirements
+const maxTLSServerValidity = 398 * 24 * time.Hour
+
+type lint struct {
+	name     string
+	source   string
+	severity Severity
+	// applies filters the certificates the lint is meant for
+	applies func(c *x509.Certificate) bool
+	// check returns a message for every violation
+	check func(c *x509.Certificate) []string
+}
+
+func always(*x509.Certificate) bool { return true }
+
+func isCA(c *x509.Certificate) bool {
+	return c.BasicConstraintsValid && c.IsCA
+}
+
+func isSubscriber(c *x509.Certificate) bool {
+	return !isCA(c)
+}
+
+func isServer(c *x509.Certificate) bool {
+	return isSubscriber(c) && (slices.Contains(c.ExtKeyUsage, x509.ExtKeyUsageServerAuth) || slices.Contains(c.ExtKeyUsage, x509.ExtKeyUsageAny))
+}
+
+func isSelfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawIssuer, c.RawSubject)
+}
+
+func failIf(cond bool, format string, args ...any) []string {
+	if cond {
+		return []string{fmt.Sprintf(format, args...)}
+	}
+	return nil
+}
+
+var lints = []lint{
+	{
+		name: "e_serial_number_not_positive", source: SourceRFC5280, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			return failIf(c.SerialNumber == nil || c.SerialNumber.Sign() <= 0, "serial number must be a positive integer")
+		},
+	},
+	{
+		name: "e_serial_number_longer_than_20_octets", source: SourceRFC5280, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			return failIf(c.SerialNumber != nil && len(c.SerialNumber.Bytes()) > 20, "serial number is longer than 20 octets")
+		},
+	},
+	{
+		name: "w_serial_number_low_entropy", source: SourceCABFBR, severity: Warn, applies: always,
+		check: func(c *x509.Certificate) []string {
+			return failIf(c.SerialNumber != nil && len(c.SerialNumber.Bytes()) < 8, "serial number should contain at least 64 bits of output from a CSPRNG")
+		},
+	},
+	{
+		name: "e_validity_time_not_positive", source: SourceRFC5280, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			return failIf(!c.NotAfter.After(c.NotBefore), "notAfter %s is not after notBefore %s", c.NotAfter.Format(time.RFC3339), c.NotBefore.Format(time.RFC3339))
+		},
+	},
+	{
+		name: "e_tls_server_cert_valid_time_longer_than_398_days", source: SourceCABFBR, severity: Error, applies: isServer,
+		check: func(c *x509.Certificate) []string {
+			// 有效期包含 notAfter 当秒
+			validity := c.NotAfter.Sub(c.NotBefore) + time.Second
+			return failIf(validity > maxTLSServerValidity, "validity of %d days is longer than 398 days", int(validity.Hours()/24))
+		},
+	},
+	{
+		name: "e_ext_san_missing", source: SourceCABFBR, severity: Error, applies: isServer,
+		check: func(c *x509.Certificate) []string {
+			return failIf(len(c.DNSNames) == 0 && len(c.IPAddresses) == 0, "tls server certificates must contain a subject alternative name")
+		},
+	},
+	{
+		name: "e_subject_common_name_not_exactly_from_san", source: SourceCABFBR, severity: Error, applies: isServer,
+		check: func(c *x509.Certificate) []string {
+			cn := c.Subject.CommonName
+			if cn == "" || slices.Contains(c.DNSNames, cn) {
+				return nil
+			}
+			if ip := net.ParseIP(cn); ip != nil && slices.ContainsFunc(c.IPAddresses, ip.Equal) {
+				return nil
+			}
+			return []string{fmt.Sprintf("common name %q is not one of the subject alternative names", cn)}
+		},
+	},
+	{
+		name: "n_subject_common_name_included", source: SourceCABFBR, severity: Notice, applies: isServer,
+		check: func(c *x509.Certificate) []string {
+			return failIf(c.Subject.CommonName != "", "common name is deprecated in tls server certificates")
+		},
+	},
+	{
+		name: "e_dnsname_bad_character_in_label", source: SourceCABFBR, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			var res []string
+			for _, name := range c.DNSNames {
+				for _, label := range strings.Split(strings.TrimPrefix(name, "*."), ".") {
+					if !validLabel(label) {
+						res = append(res, fmt.Sprintf("dns name %q contains an invalid label %q", name, label))
+						break
+					}
+				}
+			}
+			return res
+		},
+	},
+	{
+		name: "e_dnsname_label_too_long", source: SourceCABFBR, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			var res []string
+			for _, name := range c.DNSNames {
+				for _, label := range strings.Split(name, ".") {
+					if len(label) > 63 {
+						res = append(res, fmt.Sprintf("dns name %q contains a label longer than 63 characters", name))
+						break
+					}
+				}
+			}
+			return res
+		},
+	},
+	{
+		name: "e_dnsname_wildcard_only_in_left_label", source: SourceCABFBR, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			var res []string
+			for _, name := range c.DNSNames {
+				if strings.Contains(strings.TrimPrefix(name, "*."), "*") {
+					res = append(res, fmt.Sprintf("dns name %q has a wildcard outside the left most label", name))
+				}
+			}
+			return res
+		},
+	},
+	{
+		name: "e_rsa_mod_less_than_2048_bits", source: SourceCABFBR, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			key, ok := c.PublicKey.(*rsa.PublicKey)
+			return failIf(ok && key.N.BitLen() < 2048, "rsa modulus of %d bits is shorter than 2048 bits", bitLen(key))
+		},
+	},
+	{
+		name: "e_ec_improper_curves", source: SourceCABFBR, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			key, ok := c.PublicKey.(*ecdsa.PublicKey)
+			if !ok {
+				return nil
+			}
+			switch key.Curve {
+			case elliptic.P256(), elliptic.P384(), elliptic.P521():
+				return nil
+			}
+			return []string{fmt.Sprintf("ecdsa curve %s is not P-256, P-384 or P-521", key.Curve.Params().Name)}
+		},
+	},
+	{
+		name: "e_sub_cert_or_sub_ca_using_sha1", source: SourceCABFBR, severity: Error, applies: always,
+		check: func(c *x509.Certificate) []string {
+			switch c.SignatureAlgorithm {
+			case x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+				return []string{fmt.Sprintf("certificate is signed with %s", c.SignatureAlgorithm)}
+			}
+			return nil
+		},
+	},
+	{
+		name: "e_sub_cert_key_usage_cert_sign_bit_set", source: SourceCABFBR, severity: Error, applies: isSubscriber,
+		check: func(c *x509.Certificate) []string {
+			return failIf(c.KeyUsage&x509.KeyUsageCertSign != 0, "subscriber certificates must not assert keyCertSign")
+		},
+	},
+	{
+		name: "e_sub_cert_eku_missing", source: SourceCABFBR, severity: Error, applies: isSubscriber,
+		check: func(c *x509.Certificate) []string {
+			return failIf(len(c.ExtKeyUsage) == 0 && len(c.UnknownExtKeyUsage) == 0, "subscriber certificates must contain the extended key usage extension")
+		},
+	},
+	{
+		name: "e_ca_key_cert_sign_not_set", source: SourceRFC5280, severity: Error, applies: isCA,
+		check: func(c *x509.Certificate) []string {
+			return failIf(c.KeyUsage&x509.KeyUsageCertSign == 0, "ca certificates must assert keyCertSign")
+		},
+	},
+	{
+		name: "e_ext_authority_key_identifier_missing", source: SourceRFC5280, severity: Error,
+		applies: func(c *x509.Certificate) bool { return !isSelfSigned(c) },
+		check: func(c *x509.Certificate) []string {
+			return failIf(len(c.AuthorityKeyId) == 0, "authority key identifier is missing")
+		},
+	},
+	{
+		name: "e_ext_subject_key_identifier_missing_ca", source: SourceRFC5280, severity: Error, applies: isCA,
+		check: func(c *x509.Certificate) []string {
+			return failIf(len(c.SubjectKeyId) == 0, "subject key identifier is missing")
+		},
+	},
+	{
+		name: "w_ext_subject_key_identifier_missing_sub_cert", source: SourceRFC5280, severity: Warn, applies: isSubscriber,
+		check: func(c *x509.Certificate) []string {
+			return failIf(len(c.SubjectKeyId) == 0, "subject key identifier is missing")
+		},
+	},
+}
+
+func bitLen(key *rsa.PublicKey) int {
+	if key == nil || key.N == nil {
+		return 0
+	}
+	return key.N.BitLen()
+}
+
+// validLabel checks a dns label only contains letters, digits and hyphens and does not start or end with a hyphen
+func validLabel(label string) bool {
+	if label == "" || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Lint runs every lint that applies to the certificate and returns the violations,
+// an empty slice means the certificate passed
+func Lint(c *x509.Certificate) []Finding {
+	findings := []Finding{}
+	for _, l := range lints {
+		if !l.applies(c) {
+			continue
+		}
+		for _, msg := range l.check(c) {
+			findings = append(findings, Finding{Lint: l.name, Source: l.source, Severity: l.severity, Message: msg})
+		}
+	}
+	return findings
+}
+
+// Blocking returns the findings as severe as min or more, none when min is empty
+func Blocking(findings []Finding, min Severity) []Finding {
+	var res []Finding
+	for _, f := range findings {
+		if f.Severity.AtLeast(min) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
@@ -0,0 +1,94 @@
+package certlint
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func issue(t *testing.T, tmpl *x509.Certificate) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func serverTemplate() *x509.Certificate {
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: new(big.Int).Lsh(big.NewInt(1), 100),
+		Subject:      pkix.Name{CommonName: "www.example.com"},
+		NotBefore:    now,
+		NotAfter:     now.Add(90 * 24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"www.example.com", "*.example.com"},
+		SubjectKeyId: []byte{1, 2, 3},
+	}
+}
+
+func lintNames(findings []Finding) map[string]bool {
+	names := map[string]bool{}
+	for _, f := range findings {
+		names[f.Lint] = true
+	}
+	return names
+}
+
+func TestLintCompliant(t *testing.T) {
+	for _, f := range Lint(issue(t, serverTemplate())) {
+		if f.Severity != Notice {
+			t.Errorf("unexpected finding %+v", f)
+		}
+	}
+}
+
+func TestLintViolations(t *testing.T) {
+	tmpl := serverTemplate()
+	tmpl.SerialNumber = big.NewInt(7)
+	tmpl.NotAfter = tmpl.NotBefore.Add(400 * 24 * time.Hour)
+	tmpl.Subject.CommonName = "other.example.com"
+	tmpl.DNSNames = []string{"www.*.example.com", "bad_label.example.com"}
+	tmpl.KeyUsage |= x509.KeyUsageCertSign
+	names := lintNames(Lint(issue(t, tmpl)))
+	for _, want := range []string{
+		"w_serial_number_low_entropy",
+		"e_tls_server_cert_valid_time_longer_than_398_days",
+		"e_subject_common_name_not_exactly_from_san",
+		"e_dnsname_wildcard_only_in_left_label",
+		"e_dnsname_bad_character_in_label",
+		"e_sub_cert_key_usage_cert_sign_bit_set",
+	} {
+		if !names[want] {
+			t.Errorf("missing finding %s, got %v", want, names)
+		}
+	}
+}
+
+func TestBlocking(t *testing.T) {
+	findings := []Finding{{Lint: "a", Severity: Notice}, {Lint: "b", Severity: Warn}, {Lint: "c", Severity: Error}}
+	if got := Blocking(findings, ""); len(got) != 0 {
+		t.Errorf("empty severity blocked %v", got)
+	}
+	if got := Blocking(findings, Warn); len(got) != 2 || got[0].Lint != "b" {
+		t.Errorf("got %v", got)
+	}
+	if got := Blocking(findings, Fatal); len(got) != 0 {
+		t.Errorf("got %v", got)
+	}
+}
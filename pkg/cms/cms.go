@@ -0,0 +1,354 @@
+// Package cms implements the subset of the Cryptographic Message Syntax (RFC 5652)
+// needed to produce and check SignedData: a single signer, SHA-256 digests,
+// RSA or ECDSA keys and the issuer-and-serial-number signer identifier.
+package cms
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+var (
+	OIDData            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	OIDSignedData      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	OIDContentType     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	OIDMessageDigest   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	OIDSigningTime     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	OIDSHA256          = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+var (
+	ErrNotSignedData       = errors.New("cms: content is not signed data")
+	ErrSignerNotFound      = errors.New("cms: signer certificate not found")
+	ErrUnsupportedKey      = errors.New("cms: unsupported key type")
+	ErrUnsupportedDigest   = errors.New("cms: unsupported digest algorithm")
+	ErrDigestMismatch      = errors.New("cms: message digest mismatch")
+	ErrMissingContent      = errors.New("cms: content is detached but was not provided")
+	ErrMissingSignedAttrs  = errors.New("cms: signed attributes are required")
+	ErrMalformedSignedData = errors.New("cms: malformed signed data")
+)
+
+// Attribute is an additional signed attribute. Value is DER encoded with encoding/asn1.
+type Attribute struct {
+	Type  asn1.ObjectIdentifier
+	Value any
+}
+
+// SignOptions controls how content is signed.
+type SignOptions struct {
+	// ContentType of the encapsulated content, id-data when empty.
+	ContentType asn1.ObjectIdentifier
+	// Detached omits the content from the result.
+	Detached bool
+	// SigningTime is added as a signed attribute unless zero.
+	SigningTime time.Time
+	// Attributes are added to the signed attributes.
+	Attributes []Attribute
+	// Certificates are embedded in the result, usually the signer and its intermediates.
+	Certificates []*x509.Certificate
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     asn1.RawValue `asn1:"optional"`
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue `asn1:"optional"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+func sha256Algorithm() pkix.AlgorithmIdentifier {
+	return pkix.AlgorithmIdentifier{Algorithm: OIDSHA256, Parameters: asn1.NullRawValue}
+}
+
+func signatureAlgorithm(key crypto.PublicKey) (pkix.AlgorithmIdentifier, x509.SignatureAlgorithm, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue}, x509.SHA256WithRSA, nil
+	case *ecdsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}, x509.ECDSAWithSHA256, nil
+	default:
+		return pkix.AlgorithmIdentifier{}, x509.UnknownSignatureAlgorithm, ErrUnsupportedKey
+	}
+}
+
+// marshalAttributes encodes the attributes as the content of a DER SET OF, sorted as DER requires.
+func marshalAttributes(attrs []Attribute) ([]byte, error) {
+	encoded := make([][]byte, 0, len(attrs))
+	for _, a := range attrs {
+		value, err := asn1.Marshal(a.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cms: failed to encode attribute %s: %w", a.Type, err)
+		}
+		der, err := asn1.Marshal(attribute{
+			Type:   a.Type,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, der)
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	return bytes.Join(encoded, nil), nil
+}
+
+// Sign signs content with key and returns a DER encoded ContentInfo holding SignedData.
+// cert must be the certificate of key; it is only embedded when listed in opts.Certificates.
+func Sign(content []byte, cert *x509.Certificate, key crypto.Signer, opts *SignOptions) ([]byte, error) {
+	if opts == nil {
+		opts = &SignOptions{}
+	}
+	contentType := opts.ContentType
+	if len(contentType) == 0 {
+		contentType = OIDData
+	}
+	sigAlg, _, err := signatureAlgorithm(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(content)
+	attrs := []Attribute{
+		{Type: OIDContentType, Value: contentType},
+		{Type: OIDMessageDigest, Value: digest[:]},
+	}
+	if !opts.SigningTime.IsZero() {
+		attrs = append(attrs, Attribute{Type: OIDSigningTime, Value: opts.SigningTime.UTC()})
+	}
+	attrs = append(attrs, opts.Attributes...)
+	signedAttrs, err := marshalAttributes(attrs)
+	if err != nil {
+		return nil, err
+	}
+	// the signature covers the attributes encoded as a SET, not with the [0] tag used in SignerInfo
+	toSign, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signedAttrs})
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(toSign)
+	signature, err := key.Sign(rand.Reader, h[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("cms: failed to sign: %w", err)
+	}
+
+	sd := signedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{sha256Algorithm()},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: contentType},
+		SignerInfos: []signerInfo{{
+			Version:            1,
+			SID:                issuerAndSerialNumber{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			DigestAlgorithm:    sha256Algorithm(),
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedAttrs},
+			SignatureAlgorithm: sigAlg,
+			Signature:          signature,
+		}},
+	}
+	// RFC 5652 5.1: version 3 when the encapsulated content is not id-data
+	if !contentType.Equal(OIDData) {
+		sd.Version = 3
+	}
+	if !opts.Detached {
+		octets, err := asn1.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		sd.EncapContentInfo.EContent = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: octets}
+	}
+	if len(opts.Certificates) > 0 {
+		var raw []byte
+		for _, c := range opts.Certificates {
+			raw = append(raw, c.Raw...)
+		}
+		sd.Certificates = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: raw}
+	}
+	inner, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: OIDSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
+
+// SignedMessage is the result of a successful Verify.
+type SignedMessage struct {
+	ContentType  asn1.ObjectIdentifier
+	Content      []byte
+	Signer       *x509.Certificate
+	Certificates []*x509.Certificate
+	SigningTime  time.Time // zero when the signing time attribute is absent
+}
+
+// elements splits the content of a constructed value into its elements.
+func elements(der []byte) ([]asn1.RawValue, error) {
+	var res []asn1.RawValue
+	for len(der) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(der, &v)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+		der = rest
+	}
+	return res, nil
+}
+
+// Verify checks the signature of a DER encoded SignedData. detached is the signed content
+// when it is not encapsulated. The signer must be one of the embedded certificates or extra;
+// whether the signer is trusted is left to the caller.
+func Verify(der, detached []byte, extra ...*x509.Certificate) (*SignedMessage, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(der, &ci); err != nil || len(rest) > 0 {
+		return nil, ErrNotSignedData
+	}
+	if !ci.ContentType.Equal(OIDSignedData) || ci.Content.Class != asn1.ClassContextSpecific {
+		return nil, ErrNotSignedData
+	}
+	var seq asn1.RawValue
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &seq); err != nil {
+		return nil, ErrMalformedSignedData
+	}
+	fields, err := elements(seq.Bytes)
+	if err != nil || len(fields) < 4 {
+		return nil, ErrMalformedSignedData
+	}
+	var encap encapsulatedContentInfo
+	if _, err := asn1.Unmarshal(fields[2].FullBytes, &encap); err != nil {
+		return nil, ErrMalformedSignedData
+	}
+	msg := &SignedMessage{ContentType: encap.EContentType, Content: detached}
+	if len(encap.EContent.Bytes) > 0 {
+		if _, err := asn1.Unmarshal(encap.EContent.Bytes, &msg.Content); err != nil {
+			return nil, ErrMalformedSignedData
+		}
+	}
+	if msg.Content == nil {
+		return nil, ErrMissingContent
+	}
+	var signerInfos asn1.RawValue
+	for _, f := range fields[3:] {
+		switch {
+		case f.Class == asn1.ClassContextSpecific && f.Tag == 0:
+			if msg.Certificates, err = x509.ParseCertificates(f.Bytes); err != nil {
+				return nil, fmt.Errorf("cms: invalid embedded certificate: %w", err)
+			}
+		case f.Class == asn1.ClassUniversal && f.Tag == asn1.TagSet:
+			signerInfos = f
+		}
+	}
+	infos, err := elements(signerInfos.Bytes)
+	if err != nil || len(infos) != 1 {
+		return nil, ErrMalformedSignedData
+	}
+	si, err := elements(infos[0].Bytes)
+	if err != nil || len(si) < 6 {
+		return nil, ErrMalformedSignedData
+	}
+	var sid issuerAndSerialNumber
+	if _, err := asn1.Unmarshal(si[1].FullBytes, &sid); err != nil {
+		return nil, ErrMalformedSignedData
+	}
+	var digestAlg pkix.AlgorithmIdentifier
+	if _, err := asn1.Unmarshal(si[2].FullBytes, &digestAlg); err != nil || !digestAlg.Algorithm.Equal(OIDSHA256) {
+		return nil, ErrUnsupportedDigest
+	}
+	signedAttrs := si[3]
+	if signedAttrs.Class != asn1.ClassContextSpecific || signedAttrs.Tag != 0 {
+		return nil, ErrMissingSignedAttrs
+	}
+	var signature []byte
+	if _, err := asn1.Unmarshal(si[5].FullBytes, &signature); err != nil {
+		return nil, ErrMalformedSignedData
+	}
+
+	for _, c := range append(msg.Certificates, extra...) {
+		if bytes.Equal(c.RawIssuer, sid.Issuer.FullBytes) && c.SerialNumber.Cmp(sid.SerialNumber) == 0 {
+			msg.Signer = c
+			break
+		}
+	}
+	if msg.Signer == nil {
+		return nil, ErrSignerNotFound
+	}
+	_, algo, err := signatureAlgorithm(msg.Signer.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: signedAttrs.Bytes})
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.Signer.CheckSignature(algo, signed, signature); err != nil {
+		return nil, fmt.Errorf("cms: invalid signature: %w", err)
+	}
+
+	attrs, err := elements(signedAttrs.Bytes)
+	if err != nil {
+		return nil, ErrMalformedSignedData
+	}
+	var digest []byte
+	for _, raw := range attrs {
+		var a attribute
+		if _, err := asn1.Unmarshal(raw.FullBytes, &a); err != nil {
+			return nil, ErrMalformedSignedData
+		}
+		switch {
+		case a.Type.Equal(OIDMessageDigest):
+			if _, err := asn1.Unmarshal(a.Values.Bytes, &digest); err != nil {
+				return nil, ErrMalformedSignedData
+			}
+		case a.Type.Equal(OIDSigningTime):
+			_, _ = asn1.Unmarshal(a.Values.Bytes, &msg.SigningTime)
+		}
+	}
+	sum := sha256.Sum256(msg.Content)
+	if !bytes.Equal(digest, sum[:]) {
+		return nil, ErrDigestMismatch
+	}
+	return msg, nil
+}
@@ -0,0 +1,79 @@
+package cms
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func newSigner(t *testing.T, key crypto.Signer) *x509.Certificate {
+	t.Helper()
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "cms test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestSignVerify(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := []byte("hello openlist")
+	now := time.Now().Truncate(time.Second)
+	for name, key := range map[string]crypto.Signer{"ecdsa": ecKey, "rsa": rsaKey} {
+		t.Run(name, func(t *testing.T) {
+			cert := newSigner(t, key)
+			der, err := Sign(content, cert, key, &SignOptions{SigningTime: now, Certificates: []*x509.Certificate{cert}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := Verify(der, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(msg.Content) != string(content) || !msg.SigningTime.Equal(now) || msg.Signer.SerialNumber.Int64() != 42 {
+				t.Errorf("unexpected message: %+v", msg)
+			}
+
+			detached, err := Sign(content, cert, key, &SignOptions{Detached: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Verify(detached, nil, cert); err != ErrMissingContent {
+				t.Errorf("expected missing content, got %v", err)
+			}
+			if _, err := Verify(detached, content); err != ErrSignerNotFound {
+				t.Errorf("expected signer not found, got %v", err)
+			}
+			if _, err := Verify(detached, content, cert); err != nil {
+				t.Error(err)
+			}
+			if _, err := Verify(detached, []byte("tampered"), cert); err != ErrDigestMismatch {
+				t.Errorf("expected digest mismatch, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+// Package envelope implements envelope encryption: every value is encrypted with a fresh
+// AES-256-GCM data key, and the data key is wrapped by a key encryption key held in the
+// config or in a KMS.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// prefix marks sealed values, followed by the format version, the key id, the wrapped data key
+// and the ciphertext. Values of version 1 were sealed without additional data, version 2 binds
+// the ciphertext to the additional data given to Seal, such as the row and column it is stored in
+const (
+	prefix   = "enc:"
+	prefixV1 = prefix + "v1:"
+	prefixV2 = prefix + "v2:"
+)
+
+// KeyWrapper wraps and unwraps data keys with a key encryption key
+type KeyWrapper interface {
+	// KeyID identifies the key encryption key, it is stored with every sealed value
+	KeyID() string
+	Wrap(dataKey []byte) ([]byte, error)
+	Unwrap(wrapped []byte) ([]byte, error)
+}
+
+// IsSealed reports whether s was produced by Seal
+func IsSealed(s string) bool {
+	return strings.HasPrefix(s, prefix)
+}
+
+// KeyID returns the id of the key encryption key a sealed value was sealed with
+func KeyID(sealed string) string {
+	_, parts, err := split(sealed)
+	if err != nil {
+		return ""
+	}
+	return parts[0]
+}
+
+// NeedsRewrap reports whether a sealed value should be sealed again with w, because it
+// was sealed with another key encryption key or without additional data
+func NeedsRewrap(w KeyWrapper, sealed string) bool {
+	version, parts, err := split(sealed)
+	return err == nil && (version != prefixV2 || parts[0] != w.KeyID())
+}
+
+// Seal encrypts plaintext with a new data key wrapped by w. The additional data is not
+// stored, the same additional data must be given to Open
+func Seal(w KeyWrapper, plaintext, additional []byte) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	ciphertext, err := gcmSeal(dataKey, plaintext, additional)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := w.Wrap(dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return prefixV2 + w.KeyID() + ":" + base64.StdEncoding.EncodeToString(wrapped) + ":" +
+		base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Open decrypts a value produced by Seal, w must hold the key encryption key it was sealed with.
+// The additional data is ignored for values of version 1
+func Open(w KeyWrapper, sealed string, additional []byte) ([]byte, error) {
+	version, parts, err := split(sealed)
+	if err != nil {
+		return nil, err
+	}
+	if parts[0] != w.KeyID() {
+		return nil, fmt.Errorf("value is sealed with key %s, but key %s is configured", parts[0], w.KeyID())
+	}
+	wrapped, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed wrapped data key: %w", err)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ciphertext: %w", err)
+	}
+	dataKey, err := w.Unwrap(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	if version == prefixV1 {
+		additional = nil
+	}
+	return gcmOpen(dataKey, ciphertext, additional)
+}
+
+// split returns the version prefix and the key id, wrapped data key and ciphertext of a sealed value
+func split(sealed string) (string, []string, error) {
+	var version string
+	switch {
+	case strings.HasPrefix(sealed, prefixV1):
+		version = prefixV1
+	case strings.HasPrefix(sealed, prefixV2):
+		version = prefixV2
+	case IsSealed(sealed):
+		return "", nil, errors.New("unsupported sealed value version")
+	default:
+		return "", nil, errors.New("value is not sealed")
+	}
+	parts := strings.Split(strings.TrimPrefix(sealed, version), ":")
+	if len(parts) != 3 {
+		return "", nil, errors.New("malformed sealed value")
+	}
+	return version, parts, nil
+}
+
+// gcmSeal returns the random nonce followed by the ciphertext
+func gcmSeal(key, plaintext, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func gcmOpen(key, ciphertext, additional []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,40 @@
+package envelope
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// localWrapper wraps data keys with a 256-bit master key kept in the config
+type localWrapper struct {
+	id  string
+	key []byte
+}
+
+// NewLocalWrapper returns a KeyWrapper using a base64 encoded 256-bit master key
+func NewLocalWrapper(masterKey string) (KeyWrapper, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(masterKey))
+	if err != nil {
+		return nil, fmt.Errorf("master key is not valid base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("master key must be 32 bytes, got %d", len(key))
+	}
+	sum := sha256.Sum256(key)
+	return &localWrapper{id: "local-" + hex.EncodeToString(sum[:4]), key: key}, nil
+}
+
+func (w *localWrapper) KeyID() string {
+	return w.id
+}
+
+func (w *localWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	return gcmSeal(w.key, dataKey, []byte(w.id))
+}
+
+func (w *localWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	return gcmOpen(w.key, wrapped, []byte(w.id))
+}
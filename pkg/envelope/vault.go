@@ -0,0 +1,84 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// vaultWrapper wraps data keys with a HashiCorp Vault transit key, the key encryption key
+// never leaves Vault
+type vaultWrapper struct {
+	addr   string
+	token  string
+	key    string
+	client *http.Client
+}
+
+// NewVaultWrapper returns a KeyWrapper using the transit key named key of the Vault server at addr
+func NewVaultWrapper(addr, token, key string) (KeyWrapper, error) {
+	if addr == "" || token == "" || key == "" {
+		return nil, fmt.Errorf("vault address, token and key are required")
+	}
+	return &vaultWrapper{
+		addr:   strings.TrimSuffix(addr, "/"),
+		token:  token,
+		key:    key,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (w *vaultWrapper) KeyID() string {
+	return "vault-" + w.key
+}
+
+func (w *vaultWrapper) Wrap(dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	err := w.call("encrypt", map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+func (w *vaultWrapper) Unwrap(wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := w.call("decrypt", map[string]string{"ciphertext": string(wrapped)}, &resp); err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+}
+
+func (w *vaultWrapper) call(op string, body any, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/v1/transit/%s/%s", w.addr, op, w.key), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", w.token)
+	req.Header.Set("Content-Type", "application/json")
+	res, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault transit %s responded with status %s", op, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
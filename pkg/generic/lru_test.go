@@ -0,0 +1,31 @@
+package generic
+
+import "testing"
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	// b is now the least recently used
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("b should have been evicted")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v", v, ok)
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Errorf("Get(c) = %d, %v", v, ok)
+	}
+	c.Add("a", 10)
+	if v, _ := c.Get("a"); v != 10 {
+		t.Errorf("Get(a) = %d after replace, want 10", v)
+	}
+	c.Remove("a")
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", c.Len())
+	}
+}
@@ -0,0 +1,373 @@
+// Package pkcs12 encodes a private key and its certificate chain as a
+// password protected PKCS#12 (RFC 7292) file. Keys are shrouded with PBES2
+// (PBKDF2-HMAC-SHA256, AES-256-CBC) and the file is integrity protected with
+// an HMAC-SHA256 MAC, which is what current OpenSSL and most platforms expect.
+package pkcs12
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"math/big"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	oidDataContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidShroudedKeyBag    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidCertTypeX509      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidFriendlyName      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidLocalKeyID        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+	oidPBES2             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2            = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256    = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC         = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidSHA256            = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	defaultIterations    = 10000
+	saltLength           = 16
+	macKeyLength         = sha256.Size
+	encryptionKeyLength  = 32
+	ErrEmptyPassword     = errors.New("pkcs12: password is required")
+	ErrMissingCert       = errors.New("pkcs12: certificate is required")
+	ErrUnsupportedKey    = errors.New("pkcs12: unsupported private key type")
+	ErrInvalidIterations = errors.New("pkcs12: iteration count must be positive")
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	ID    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	ID   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	Kdf              pkix.AlgorithmIdentifier
+	EncryptionScheme pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int `asn1:"optional"`
+	Prf        pkix.AlgorithmIdentifier
+}
+
+// Encode builds a PKCS#12 file holding key, its certificate cert and the
+// optional chain certificates. friendlyName is attached to the key and leaf
+// certificate when not empty. key must be a type supported by
+// x509.MarshalPKCS8PrivateKey.
+func Encode(key any, cert *x509.Certificate, chain []*x509.Certificate, friendlyName, password string) ([]byte, error) {
+	return encode(key, cert, chain, friendlyName, password, defaultIterations)
+}
+
+func encode(key any, cert *x509.Certificate, chain []*x509.Certificate, friendlyName, password string, iterations int) ([]byte, error) {
+	if password == "" {
+		return nil, ErrEmptyPassword
+	}
+	if cert == nil {
+		return nil, ErrMissingCert
+	}
+	if iterations <= 0 {
+		return nil, ErrInvalidIterations
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, ErrUnsupportedKey
+	}
+	localKeyID := sha1.Sum(cert.Raw)
+	attrs, err := bagAttributes(localKeyID[:], friendlyName)
+	if err != nil {
+		return nil, err
+	}
+
+	certBags := make([]safeBag, 0, len(chain)+1)
+	leafBag, err := makeCertBag(cert.Raw, attrs)
+	if err != nil {
+		return nil, err
+	}
+	certBags = append(certBags, leafBag)
+	for _, c := range chain {
+		if c == nil {
+			continue
+		}
+		bag, err := makeCertBag(c.Raw, nil)
+		if err != nil {
+			return nil, err
+		}
+		certBags = append(certBags, bag)
+	}
+
+	shrouded, err := shroudKey(keyDER, []byte(password), iterations)
+	if err != nil {
+		return nil, err
+	}
+	keyBag := safeBag{ID: oidShroudedKeyBag, Value: explicitValue(shrouded), Attributes: attrs}
+
+	certContent, err := dataContentInfo(certBags)
+	if err != nil {
+		return nil, err
+	}
+	keyContent, err := dataContentInfo([]safeBag{keyBag})
+	if err != nil {
+		return nil, err
+	}
+	authSafe, err := asn1.Marshal([]contentInfo{certContent, keyContent})
+	if err != nil {
+		return nil, err
+	}
+	authSafeOctets, err := asn1.Marshal(authSafe)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	macKey := deriveKey(sha256.New, bmpPassword(password), salt, 3, iterations, macKeyLength)
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(authSafe)
+
+	pfx := pfxPdu{
+		Version: 3,
+		AuthSafe: contentInfo{
+			ContentType: oidDataContentType,
+			Content:     explicitValue(authSafeOctets),
+		},
+		MacData: macData{
+			Mac: digestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue},
+				Digest:    mac.Sum(nil),
+			},
+			MacSalt:    salt,
+			Iterations: iterations,
+		},
+	}
+	return asn1.Marshal(pfx)
+}
+
+// explicitValue wraps DER encoded bytes in a [0] EXPLICIT context tag.
+func explicitValue(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+func dataContentInfo(bags []safeBag) (contentInfo, error) {
+	safeContents, err := asn1.Marshal(bags)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	octets, err := asn1.Marshal(safeContents)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{ContentType: oidDataContentType, Content: explicitValue(octets)}, nil
+}
+
+func makeCertBag(der []byte, attrs []pkcs12Attribute) (safeBag, error) {
+	value, err := asn1.Marshal(certBag{ID: oidCertTypeX509, Data: der})
+	if err != nil {
+		return safeBag{}, err
+	}
+	return safeBag{ID: oidCertBag, Value: explicitValue(value), Attributes: attrs}, nil
+}
+
+func bagAttributes(localKeyID []byte, friendlyName string) ([]pkcs12Attribute, error) {
+	idValue, err := asn1.Marshal(localKeyID)
+	if err != nil {
+		return nil, err
+	}
+	attrs := []pkcs12Attribute{{ID: oidLocalKeyID, Value: setValue(idValue)}}
+	if friendlyName != "" {
+		nameValue, err := asn1.Marshal(asn1.RawValue{
+			Class: asn1.ClassUniversal,
+			Tag:   asn1.TagBMPString,
+			Bytes: bmpString(friendlyName),
+		})
+		if err != nil {
+			return nil, err
+		}
+		attrs = append(attrs, pkcs12Attribute{ID: oidFriendlyName, Value: setValue(nameValue)})
+	}
+	return attrs, nil
+}
+
+func setValue(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: der}
+}
+
+// shroudKey encrypts a PKCS#8 key with PBES2 and returns the DER encoded
+// EncryptedPrivateKeyInfo. Per RFC 8018 the password is used as raw bytes.
+func shroudKey(keyDER, password []byte, iterations int) ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	key := pbkdf2.Key(password, salt, iterations, encryptionKeyLength, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	padding := aes.BlockSize - len(keyDER)%aes.BlockSize
+	plain := make([]byte, len(keyDER)+padding)
+	copy(plain, keyDER)
+	for i := len(keyDER); i < len(plain); i++ {
+		plain[i] = byte(padding)
+	}
+	encrypted := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, plain)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:       salt,
+		Iterations: iterations,
+		Prf:        pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		Kdf:              pkix.AlgorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme: pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+}
+
+// deriveKey implements the PKCS#12 key derivation function (RFC 7292
+// appendix B.2). id is 1 for encryption keys, 2 for IVs and 3 for MAC keys.
+func deriveKey(h func() hash.Hash, password, salt []byte, id byte, iterations, size int) []byte {
+	u := h().Size()
+	v := h().BlockSize()
+
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+	s := repeatToMultiple(salt, v)
+	p := repeatToMultiple(password, v)
+	i := append(s, p...)
+
+	one := big.NewInt(1)
+	out := make([]byte, 0, size+u)
+	for len(out) < size {
+		hasher := h()
+		hasher.Write(d)
+		hasher.Write(i)
+		a := hasher.Sum(nil)
+		for r := 1; r < iterations; r++ {
+			hasher = h()
+			hasher.Write(a)
+			a = hasher.Sum(a[:0])
+		}
+		out = append(out, a...)
+		if len(out) >= size {
+			break
+		}
+
+		b := new(big.Int).SetBytes(repeatToLength(a, v))
+		b.Add(b, one)
+		for j := 0; j < len(i); j += v {
+			block := new(big.Int).SetBytes(i[j : j+v])
+			block.Add(block, b)
+			sum := block.Bytes()
+			if len(sum) > v {
+				sum = sum[len(sum)-v:]
+			}
+			chunk := i[j : j+v]
+			for k := range chunk {
+				chunk[k] = 0
+			}
+			copy(chunk[v-len(sum):], sum)
+		}
+	}
+	return out[:size]
+}
+
+func repeatToMultiple(data []byte, v int) []byte {
+	if len(data) == 0 {
+		return nil
+	}
+	return repeatToLength(data, v*((len(data)+v-1)/v))
+}
+
+func repeatToLength(data []byte, n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = data[i%len(data)]
+	}
+	return out
+}
+
+func bmpString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	out := make([]byte, 0, len(units)*2)
+	for _, r := range units {
+		out = append(out, byte(r>>8), byte(r))
+	}
+	return out
+}
+
+// bmpPassword encodes the password as a null terminated BMPString, as
+// required by the PKCS#12 key derivation function.
+func bmpPassword(password string) []byte {
+	return append(bmpString(password), 0, 0)
+}
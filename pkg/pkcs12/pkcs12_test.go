@@ -0,0 +1,151 @@
+package pkcs12
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	gopkcs12 "software.sslmate.com/src/go-pkcs12"
+)
+
+func testCertificate(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "pkcs12.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, tpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+func TestEncodeDecode(t *testing.T) {
+	// decoded with an independent implementation, unlike TestEncodeOpenSSL it never skips
+	key, cert := testCertificate(t)
+	tests := []struct {
+		name     string
+		password string
+		caCerts  []*x509.Certificate
+	}{
+		{name: "leaf only", password: "secret"},
+		{name: "with chain", password: "secret", caCerts: []*x509.Certificate{cert}},
+		{name: "non ascii password", password: "pässwörd"},
+	}
+	for _, tt := range tests {
+		data, err := Encode(key, cert, tt.caCerts, "test", tt.password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		gotKey, gotCert, gotCAs, err := gopkcs12.DecodeChain(data, tt.password)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if !key.Equal(gotKey) {
+			t.Errorf("%s: private key does not match", tt.name)
+		}
+		if !gotCert.Equal(cert) {
+			t.Errorf("%s: certificate does not match", tt.name)
+		}
+		if len(gotCAs) != len(tt.caCerts) {
+			t.Errorf("%s: got %d ca certificates, want %d", tt.name, len(gotCAs), len(tt.caCerts))
+		}
+		if _, _, _, err := gopkcs12.DecodeChain(data, tt.password+"x"); err == nil {
+			t.Errorf("%s: decoded with a wrong password", tt.name)
+		}
+	}
+}
+
+func TestEncodeMAC(t *testing.T) {
+	key, cert := testCertificate(t)
+	data, err := encode(key, cert, nil, "test", "secret", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pfx pfxPdu
+	if rest, err := asn1.Unmarshal(data, &pfx); err != nil || len(rest) != 0 {
+		t.Fatalf("unmarshal pfx: %v", err)
+	}
+	if pfx.Version != 3 || pfx.MacData.Iterations != 2 {
+		t.Fatalf("unexpected pfx header %+v", pfx.MacData)
+	}
+	var authSafe []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		t.Fatal(err)
+	}
+	macKey := deriveKey(sha256.New, bmpPassword("secret"), pfx.MacData.MacSalt, 3, 2, macKeyLength)
+	if !verifyMAC(macKey, authSafe, pfx.MacData.Mac.Digest) {
+		t.Fatal("mac does not verify")
+	}
+	id := sha1.Sum(cert.Raw)
+	if !bytes.Contains(data, id[:]) {
+		t.Fatal("local key id missing")
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	key, cert := testCertificate(t)
+	if _, err := Encode(key, cert, nil, "", ""); err != ErrEmptyPassword {
+		t.Fatalf("expected ErrEmptyPassword, got %v", err)
+	}
+	if _, err := Encode(key, nil, nil, "", "secret"); err != ErrMissingCert {
+		t.Fatalf("expected ErrMissingCert, got %v", err)
+	}
+	if _, err := Encode(struct{}{}, cert, nil, "", "secret"); err != ErrUnsupportedKey {
+		t.Fatalf("expected ErrUnsupportedKey, got %v", err)
+	}
+}
+
+func TestEncodeOpenSSL(t *testing.T) {
+	openssl, err := exec.LookPath("openssl")
+	if err != nil {
+		t.Skip("openssl not available")
+	}
+	key, cert := testCertificate(t)
+	data, err := Encode(key, cert, []*x509.Certificate{cert}, "test", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.p12")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command(openssl, "pkcs12", "-in", path, "-passin", "pass:secret", "-nodes").CombinedOutput()
+	if err != nil {
+		t.Fatalf("openssl could not read the file (%v): %s", err, out)
+	}
+	if !strings.Contains(string(out), "PRIVATE KEY") || !strings.Contains(string(out), "pkcs12.test") {
+		t.Fatalf("unexpected openssl output: %s", out)
+	}
+}
+
+func verifyMAC(key, data, digest []byte) bool {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hmac.Equal(mac.Sum(nil), digest)
+}
@@ -38,3 +38,12 @@ func init() {
 	s := mathRand.NewSource(time.Now().UnixNano())
 	Rand = mathRand.New(s)
 }
+
+// Bytes returns n cryptographically secure random bytes
+func Bytes(n int) []byte {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,148 @@
+// Package xlsx writes a single-sheet Office Open XML workbook row by row.
+// Rows are written straight into the zip stream, so memory use does not grow
+// with the number of rows. All cells are written as inline strings, which is
+// enough for exports that are opened in a spreadsheet and filtered by hand.
+package xlsx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ContentType is the MIME type of the generated workbook
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+	sheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetFooter = `</sheetData></worksheet>`
+)
+
+// Writer streams rows of a single worksheet into an xlsx file
+type Writer struct {
+	zw     *zip.Writer
+	sheet  io.Writer
+	rows   int
+	closed bool
+}
+
+// NewWriter writes the workbook structure to w and prepares the sheet for rows.
+// sheetName is shown on the sheet tab and truncated to the 31 characters Excel allows
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+	if sheetName = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, sheetName); sheetName == "" {
+		sheetName = "Sheet1"
+	}
+	if r := []rune(sheetName); len(r) > 31 {
+		sheetName = string(r[:31])
+	}
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+	workbookXML := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="` + name.String() + `" sheetId="1" r:id="rId1"/></sheets></workbook>`
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", workbookXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	} {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, part.content); err != nil {
+			return nil, err
+		}
+	}
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.WriteString(sheet, sheetHeader); err != nil {
+		return nil, err
+	}
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// Write appends a row of string cells
+func (w *Writer) Write(cells []string) error {
+	if w.closed {
+		return errors.New("xlsx: write after close")
+	}
+	w.rows++
+	var b strings.Builder
+	b.WriteString(`<row r="` + strconv.Itoa(w.rows) + `">`)
+	for i, cell := range cells {
+		b.WriteString(`<c r="` + columnName(i) + strconv.Itoa(w.rows) + `" t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(&b, []byte(sanitize(cell))); err != nil {
+			return err
+		}
+		b.WriteString(`</t></is></c>`)
+	}
+	b.WriteString(`</row>`)
+	_, err := io.WriteString(w.sheet, b.String())
+	return err
+}
+
+// Flush writes buffered compressed data to the underlying writer
+func (w *Writer) Flush() error {
+	return w.zw.Flush()
+}
+
+// Close finishes the sheet and the zip archive, it does not close the underlying writer
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if _, err := io.WriteString(w.sheet, sheetFooter); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
+
+// columnName converts a zero based column index to its letters, 0 is A and 26 is AA
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// sanitize drops characters that are not allowed in XML 1.0 documents
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' || (r >= 0x20 && r <= 0xD7FF) || (r >= 0xE000 && r <= 0xFFFD) || (r >= 0x10000 && r <= 0x10FFFF) {
+			return r
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,76 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, want %s", i, got, want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "certificates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]string{{"id", "name"}, {"1", "a<b> & \"c\"\x00"}}
+	for _, row := range rows {
+		if err := w.Write(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sheet []byte
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// 每个部件都必须是格式正确的 XML
+		if err := xml.Unmarshal(data, new(struct{})); err != nil {
+			t.Fatalf("%s is not well formed: %v", f.Name, err)
+		}
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = data
+		}
+	}
+	var parsed struct {
+		Rows []struct {
+			Cells []struct {
+				Ref  string `xml:"r,attr"`
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(sheet, &parsed); err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2", len(parsed.Rows))
+	}
+	cell := parsed.Rows[1].Cells[1]
+	if cell.Ref != "B2" || cell.Text != "a<b> & \"c\"" {
+		t.Errorf("got cell %s = %q", cell.Ref, cell.Text)
+	}
+}
@@ -0,0 +1,21 @@
+package common
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/gin-gonic/gin"
+)
+
+// Versions of the certificate api, routes without a version prefix are APIVersionLegacy
+const (
+	APIVersionLegacy = 0
+	APIVersion1      = 1
+)
+
+// APIVersion returns the api version of the route serving the request, handlers that
+// change a field or the error shape keep the old behavior for APIVersionLegacy
+func APIVersion(c *gin.Context) int {
+	if v, ok := c.Request.Context().Value(conf.APIVersionKey).(int); ok {
+		return v
+	}
+	return APIVersionLegacy
+}
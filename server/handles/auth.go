@@ -5,6 +5,7 @@ import (
 	"encoding/base64"
 	"image/png"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -40,12 +41,23 @@ func LoginHash(c *gin.Context) {
 	loginHash(c, &req)
 }
 
+func auditLogin(c *gin.Context, username, outcome, detail string) {
+	audit.Emit(&audit.Event{
+		Type:    "auth.login",
+		Actor:   username,
+		Outcome: outcome,
+		IP:      c.ClientIP(),
+		Detail:  detail,
+	})
+}
+
 func loginHash(c *gin.Context, req *LoginReq) {
 	// check count of login
 	ip := c.ClientIP()
 	count, ok := model.LoginCache.Get(ip)
 	if ok && count >= model.DefaultMaxAuthRetries {
 		common.ErrorStrResp(c, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.", 429)
+		auditLogin(c, req.Username, audit.OutcomeFailure, "too many attempts")
 		model.LoginCache.Expire(ip, model.DefaultLockDuration)
 		return
 	}
@@ -53,12 +65,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	user, err := op.GetUserByName(req.Username)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
+		auditLogin(c, req.Username, audit.OutcomeFailure, "unknown user")
 		model.LoginCache.Set(ip, count+1)
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorResp(c, err, 400)
+		auditLogin(c, req.Username, audit.OutcomeFailure, "wrong password")
 		model.LoginCache.Set(ip, count+1)
 		return
 	}
@@ -66,6 +80,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	if user.OtpSecret != "" {
 		if !totp.Validate(req.OtpCode, user.OtpSecret) {
 			common.ErrorStrResp(c, "Invalid 2FA code", 402)
+			auditLogin(c, req.Username, audit.OutcomeFailure, "invalid 2fa code")
 			model.LoginCache.Set(ip, count+1)
 			return
 		}
@@ -77,6 +92,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	auditLogin(c, user.Username, audit.OutcomeSuccess, "")
 	model.LoginCache.Del(ip)
 }
 
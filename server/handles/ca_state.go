@@ -0,0 +1,73 @@
+package handles
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+type caStateReq struct {
+	Password   string `json:"password" form:"password" binding:"required"`
+	Passphrase string `json:"passphrase" form:"passphrase" binding:"required"`
+}
+
+// ExportCAState 导出加密的 CA 完整状态用于灾备
+func ExportCAState(c *gin.Context) {
+	var req caStateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	bundle, err := op.ExportCAState(user, req.Password, req.Passphrase)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ca-state-%s.json"`, bundle.ExportedAt.Format("20060102-150405")))
+	c.Header("Cache-Control", "no-store")
+	c.Data(200, "application/json", data)
+}
+
+// ImportCAState 从导出文件恢复 CA 状态，文件以 multipart 的 file 字段上传
+func ImportCAState(c *gin.Context) {
+	var req caStateReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	defer f.Close()
+	var bundle model.CAStateBundle
+	if err := json.NewDecoder(f).Decode(&bundle); err != nil {
+		common.ErrorStrResp(c, "invalid ca state file: "+err.Error(), 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	summary, err := op.ImportCAState(user, req.Password, req.Passphrase, &bundle)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, summary)
+}
@@ -0,0 +1,102 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CAStatus 公开的 CA 状态，供依赖方自查证书校验失败的原因
+func CAStatus(c *gin.Context) {
+	status, err := op.GetCAStatus(c.Request.Context())
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=60")
+	common.SuccessResp(c, status)
+}
+
+// --- Admin Handlers ---
+
+// CAMaintenanceWindowList 获取维护窗口列表
+func CAMaintenanceWindowList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	windows, total, err := op.GetCAMaintenanceWindows(req.Page, req.PerPage)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: windows,
+		Total:   total,
+	})
+}
+
+// CreateCAMaintenanceWindow 新增维护窗口
+func CreateCAMaintenanceWindow(c *gin.Context) {
+	var req model.CAMaintenanceWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateCAMaintenanceWindow(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateCAMaintenanceWindow 更新维护窗口
+func UpdateCAMaintenanceWindow(c *gin.Context) {
+	var req model.CAMaintenanceWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	window, err := op.GetCAMaintenanceWindowByID(uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	req.ID = window.ID
+	req.CreatedAt = window.CreatedAt
+	if err := op.UpdateCAMaintenanceWindow(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// DeleteCAMaintenanceWindow 删除维护窗口
+func DeleteCAMaintenanceWindow(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteCAMaintenanceWindow(uint(id)); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
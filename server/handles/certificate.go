@@ -1,6 +1,8 @@
 package handles
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -11,21 +13,27 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"gorm.io/gorm"
 )
 
 // --- Admin Handlers ---
 
 // CertificateList 获取证书列表，增加了分页功能，与ListUsers风格统一
+// 支持按 status、type、owner、名称子串 name 和到期时间范围 expires_after、expires_before 过滤，
+// 并通过 order_by 和 order_direction 排序
 func CertificateList(c *gin.Context) {
-	var req model.PageReq
-	if err := c.ShouldBind(&req); err != nil {
+	var req struct {
+		model.PageReq
+		model.CertificateFilter
+	}
+	if err := c.ShouldBindQuery(&req); err != nil {
 		common.ErrorResp(c, err, 400)
 		return
 	}
 	req.Validate()
-	certs, total, err := op.GetCertificates(req.Page, req.PerPage)
+	certs, total, err := op.GetCertificates(req.Page, req.PerPage, &req.CertificateFilter)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
 	common.SuccessResp(c, common.PageResp{
@@ -43,7 +51,11 @@ func CreateCertificate(c *gin.Context) {
 		OwnerID        uint      `json:"owner_id"`
 		Content        string    `json:"content" binding:"required"`
 		IssuedDate     time.Time `json:"issued_date"`
-		ExpirationDate time.Time `json:"expiration_date" binding:"required"`
+		ExpirationDate time.Time `json:"expiration_date"`
+
+		ResponsibleTeam   string `json:"responsible_team"`
+		ContactEmail      string `json:"contact_email"`
+		EscalationContact string `json:"escalation_contact"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResp(c, err, 400)
@@ -59,22 +71,32 @@ func CreateCertificate(c *gin.Context) {
 		IssuedDate:     req.IssuedDate,
 		ExpirationDate: req.ExpirationDate,
 		Status:         model.CertificateStatusValid,
+
+		ResponsibleTeam:   req.ResponsibleTeam,
+		ContactEmail:      req.ContactEmail,
+		EscalationContact: req.EscalationContact,
 	}
 
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
 	// 调用服务层创建证书
-	err := op.CreateCertificate(cert)
+	err := op.CreateCertificate(cert, user)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditCreate, model.CertificateAuditTargetCertificate, cert.ID, c.ClientIP(), cert.Name)
 	common.SuccessResp(c, cert)
 }
 
 // UpdateCertificate 更新证书
 func UpdateCertificate(c *gin.Context) {
 	var req struct {
-		Name           string    `json:"name"`
-		ExpirationDate time.Time `json:"expiration_date"`
+		Name              string    `json:"name"`
+		ExpirationDate    time.Time `json:"expiration_date"`
+		ResponsibleTeam   string    `json:"responsible_team"`
+		ContactEmail      string    `json:"contact_email"`
+		EscalationContact string    `json:"escalation_contact"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResp(c, err, 400)
@@ -90,15 +112,18 @@ func UpdateCertificate(c *gin.Context) {
 
 	cert, err := op.GetCertificateByID(uint(id))
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
 
 	cert.Name = req.Name
 	cert.ExpirationDate = req.ExpirationDate
+	cert.ResponsibleTeam = req.ResponsibleTeam
+	cert.ContactEmail = req.ContactEmail
+	cert.EscalationContact = req.EscalationContact
 	err = op.UpdateCertificate(cert)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
 	common.SuccessResp(c, cert)
@@ -113,11 +138,14 @@ func DeleteCertificate(c *gin.Context) {
 		return
 	}
 
-	err = op.DeleteCertificate(uint(id))
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	err = op.DeleteCertificate(uint(id), user)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditDelete, model.CertificateAuditTargetCertificate, uint(id), c.ClientIP(), "")
 	common.SuccessResp(c)
 }
 
@@ -130,11 +158,61 @@ func RevokeCertificate(c *gin.Context) {
 		return
 	}
 
-	err = op.RevokeCertificate(uint(id))
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	err = op.RevokeCertificate(uint(id), user)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditRevoke, model.CertificateAuditTargetCertificate, uint(id), c.ClientIP(), "")
+	common.SuccessResp(c)
+}
+
+// HoldCertificate 挂起证书
+func HoldCertificate(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.HoldCertificate(uint(id), user, req.Reason); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditHold, model.CertificateAuditTargetCertificate, uint(id), c.ClientIP(), req.Reason)
+	common.SuccessResp(c)
+}
+
+// UnholdCertificate 解除证书挂起
+func UnholdCertificate(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.ReleaseCertificateHold(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditUnhold, model.CertificateAuditTargetCertificate, uint(id), c.ClientIP(), "")
 	common.SuccessResp(c)
 }
 
@@ -146,9 +224,17 @@ func CertificateRequestList(c *gin.Context) {
 		return
 	}
 	req.Validate()
-	requests, total, err := op.GetCertificateRequests(req.Page, req.PerPage)
+	// assignee=me 只看自己认领的申请，assignee 为空时只看未认领的申请
+	var assignee *string
+	if name, ok := c.GetQuery("assignee"); ok {
+		if name == "me" {
+			name = c.Request.Context().Value(conf.UserKey).(*model.User).Username
+		}
+		assignee = &name
+	}
+	requests, total, err := op.GetCertificateRequests(req.Page, req.PerPage, assignee)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
 	common.SuccessResp(c, common.PageResp{
@@ -160,10 +246,15 @@ func CertificateRequestList(c *gin.Context) {
 // CreateCertificateRequest 创建证书申请
 func CreateCertificateRequest(c *gin.Context) {
 	var req struct {
-		UserName string                `json:"user_name" binding:"required"`
-		UserID   uint                  `json:"user_id"`
-		Type     model.CertificateType `json:"type" binding:"required"`
-		Reason   string                `json:"reason" binding:"required"`
+		UserName       string                `json:"user_name" binding:"required"`
+		UserID         uint                  `json:"user_id"`
+		Type           model.CertificateType `json:"type" binding:"required"`
+		Reason         string                `json:"reason" binding:"required"`
+		ValidityPreset string                `json:"validity_preset"`
+		CustomFields   map[string]string     `json:"custom_fields"`
+		SANs           []string              `json:"sans"`
+		CSR            string                `json:"csr"`        // PEM 格式的证书签名请求，可选
+		ProfileID      uint                  `json:"profile_id"` // 引用的签发配置，可选
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResp(c, err, 400)
@@ -171,19 +262,26 @@ func CreateCertificateRequest(c *gin.Context) {
 	}
 
 	request := &model.CertificateRequest{
-		UserName: req.UserName,
-		UserID:   req.UserID,
-		Type:     req.Type,
-		Reason:   req.Reason,
-		Status:   model.CertificateStatusPending,
+		UserName:       req.UserName,
+		UserID:         req.UserID,
+		Type:           req.Type,
+		Reason:         req.Reason,
+		Status:         model.CertificateStatusPending,
+		ValidityPreset: req.ValidityPreset,
+		CustomFields:   req.CustomFields,
+		SANs:           req.SANs,
+		CSR:            req.CSR,
+		ProfileID:      req.ProfileID,
 	}
 
 	// 调用服务层创建证书申请
 	err := op.CreateCertificateRequest(request)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	op.RecordCertAudit(user.Username, model.CertificateAuditCreate, model.CertificateAuditTargetRequest, request.ID, c.ClientIP(), request.UserName)
 	common.SuccessResp(c, request)
 }
 
@@ -196,17 +294,32 @@ func ApproveCertificateRequest(c *gin.Context) {
 		return
 	}
 
+	// 批准理由、检查项以及可选的计划签发时间 not_before
+	var req model.CertificateApprovalInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+
 	// 使用与项目其他部分一致的方式获取用户上下文
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	
-	_, err = op.ApproveAndCreateCertificate(uint(id), user)
+
+	_, err = op.ApproveCertificateRequestWith(uint(id), user, &req)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditApprove, model.CertificateAuditTargetRequest, uint(id), c.ClientIP(), req.Justification)
 	common.SuccessResp(c)
 }
 
+// GetCertificateApprovalChecklist 获取批准申请时需要勾选的检查项
+func GetCertificateApprovalChecklist(c *gin.Context) {
+	common.SuccessResp(c, op.GetCertificateApprovalChecklist())
+}
+
 // RejectCertificateRequest 拒绝证书申请
 func RejectCertificateRequest(c *gin.Context) {
 	var req struct {
@@ -226,20 +339,186 @@ func RejectCertificateRequest(c *gin.Context) {
 
 	// 使用与项目其他部分一致的方式获取用户上下文
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	
+
 	err = op.RejectCertificateRequest(uint(id), user, req.Reason)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditReject, model.CertificateAuditTargetRequest, uint(id), c.ClientIP(), req.Reason)
 	common.SuccessResp(c)
 }
 
-// DownloadCertificate 下载证书
+// DownloadCertificate 下载证书，管理端按 id 下载，租户端不论角色只能下载自己的证书。
+// 通过 format 参数选择 pem、der 或 pkcs12 格式，pkcs12 的密码通过 X-OpenList-Password 请求头传递，
+// 不能放在 URL 中以免写入访问日志和浏览器历史
 func DownloadCertificate(c *gin.Context) {
-	// 这里应该实现证书下载逻辑
-	// 为了简化示例，我们返回一个模拟的证书内容
-	c.String(http.StatusOK, "-----BEGIN CERTIFICATE-----\nMIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA...\n-----END CERTIFICATE-----")
+	var id uint
+	// 管理端通过路径参数指定证书，租户可以通过 id 查询参数选择自己的证书
+	idParam := c.Param("id")
+	tenant := idParam == ""
+	if tenant {
+		idParam = c.Query("id")
+	}
+	if idParam != "" {
+		i, err := strconv.Atoi(idParam)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		id = uint(i)
+	}
+	format := c.DefaultQuery("format", op.CertificateFormatPEM)
+	if _, ok := c.GetQuery("password"); ok {
+		common.ErrorStrResp(c, "password must be sent in the X-OpenList-Password header", 400)
+		return
+	}
+	password := c.GetHeader("X-OpenList-Password")
+	if err := op.ValidateCertificateFormat(format, password); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	// chain 为交叉证书的 ID 时下载经过该交叉证书的备用链，见 CertificateChainOptionList
+	var crossSignID uint
+	if chainParam := c.Query("chain"); chainParam != "" {
+		i, err := strconv.Atoi(chainParam)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		crossSignID = uint(i)
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	var cert *model.Certificate
+	var revoked bool
+	var err error
+	if tenant {
+		cert, revoked, err = op.GetTenantCertificateForDownload(id, user, format, c.ClientIP())
+	} else {
+		cert, revoked, err = op.GetCertificateForDownload(id, user, format, c.ClientIP())
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "certificate not found", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	if cert, err = op.CertificateWithChain(cert, crossSignID); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	file, err := op.ConvertCertificateForDownload(cert, format, password)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	op.RecordCertificateDownload(cert, user, revoked, c.ClientIP(), c.Request.UserAgent())
+	op.RecordCertAudit(user.Username, model.CertificateAuditDownload, model.CertificateAuditTargetCertificate, cert.ID, c.ClientIP(), format)
+	// 已吊销的证书只返回内容，并通过响应头标记吊销状态
+	if revoked {
+		c.Header("X-Certificate-Status", string(model.CertificateStatusRevoked))
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Filename))
+	c.Data(http.StatusOK, file.ContentType, file.Data)
+}
+
+// CertificateChainOptionList 获取证书可以下载的证书链，下载时通过 chain 参数选择
+func CertificateChainOptionList(c *gin.Context) {
+	var id uint
+	idParam := c.Param("id")
+	// 租户路由没有路径参数，只能查看自己的证书
+	tenant := idParam == ""
+	if tenant {
+		idParam = c.Query("id")
+	}
+	if idParam != "" {
+		i, err := strconv.Atoi(idParam)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		id = uint(i)
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	options, err := op.GetCertificateChainOptions(id, user, tenant)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "certificate not found", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, options)
+}
+
+// CertificateDownloadList 获取证书的下载记录
+func CertificateDownloadList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	downloads, total, err := op.GetCertificateDownloads(uint(id), req.Page, req.PerPage)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: downloads,
+		Total:   total,
+	})
+}
+
+// UnusedCertificateList 获取长时间未被下载的有效证书，days 默认为 90
+func UnusedCertificateList(c *gin.Context) {
+	days := 90
+	if v := c.Query("days"); v != "" {
+		d, err := strconv.Atoi(v)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		days = d
+	}
+	certs, err := op.GetUnusedCertificates(days)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, certs)
+}
+
+// GetCertificateTimeline 获取证书的活动时间线，租户只能查看自己的证书
+func GetCertificateTimeline(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	timeline, err := op.GetCertificateTimeline(uint(id), user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, timeline)
 }
 
 // --- Tenant Handlers ---
@@ -247,8 +526,15 @@ func DownloadCertificate(c *gin.Context) {
 // CreateTenantCertificateRequest 租户申请证书
 func CreateTenantCertificateRequest(c *gin.Context) {
 	var req struct {
-		Type   model.CertificateType `json:"type" binding:"required"`
-		Reason string                `json:"reason" binding:"required"`
+		Type           model.CertificateType `json:"type" binding:"required"`
+		Reason         string                `json:"reason"`
+		ValidityPreset string                `json:"validity_preset"`
+		CustomFields   map[string]string     `json:"custom_fields"`
+		SANs           []string              `json:"sans"`
+		CSR            string                `json:"csr"`        // PEM 格式的证书签名请求，提供时私钥由租户保管
+		ProfileID      uint                  `json:"profile_id"` // 引用的签发配置，可选
+		Draft          bool                  `json:"draft"`      // 保存为草稿，稍后提交
+		Sandbox        bool                  `json:"sandbox"`    // 由沙箱 CA 立即签发测试证书，不能保存为草稿
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ErrorResp(c, err, 400)
@@ -257,28 +543,43 @@ func CreateTenantCertificateRequest(c *gin.Context) {
 
 	// 使用与项目其他部分一致的方式获取用户上下文
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	
-	request, err := op.CreateTenantCertificateRequest(user, req.Type, req.Reason)
-	if err != nil {
-		// 检查特定的错误类型
-		if err.Error() == "certificate already exists for user" || err.Error() == "certificate request is pending for user" {
-			common.ErrorResp(c, err, 400)
+
+	if req.Sandbox {
+		if req.Draft {
+			common.ErrorStrResp(c, "sandbox requests can not be saved as drafts", 400)
 			return
 		}
-		common.ErrorResp(c, err, 500)
+		res, err := op.CreateSandboxCertificateRequest(user, req.Type, req.Reason, req.SANs, req.CSR)
+		if err != nil {
+			certificateErrorResp(c, err)
+			return
+		}
+		op.RecordCertAudit(user.Username, model.CertificateAuditCreate, model.CertificateAuditTargetRequest, res.Request.ID, c.ClientIP(), "sandbox")
+		common.SuccessResp(c, res)
+		return
+	}
+
+	create := op.CreateTenantCertificateRequest
+	if req.Draft {
+		create = op.CreateCertificateRequestDraft
+	}
+	request, err := create(user, req.Type, req.Reason, req.ValidityPreset, req.CustomFields, req.SANs, req.CSR, req.ProfileID)
+	if err != nil {
+		certificateErrorResp(c, err)
 		return
 	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditCreate, model.CertificateAuditTargetRequest, request.ID, c.ClientIP(), string(request.Status))
 	common.SuccessResp(c, request)
 }
 
-// GetTenantCertificate 获取租户证书
+// GetTenantCertificate 获取租户的所有有效证书
 func GetTenantCertificate(c *gin.Context) {
 	// 使用与项目其他部分一致的方式获取用户上下文
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	
+
 	cert, err := op.GetCertificateForTenant(user.ID)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
 	common.SuccessResp(c, cert)
@@ -288,11 +589,11 @@ func GetTenantCertificate(c *gin.Context) {
 func GetTenantCertificateRequests(c *gin.Context) {
 	// 使用与项目其他部分一致的方式获取用户上下文
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	
+
 	requests, err := op.GetTenantCertificateRequests(user.ID)
 	if err != nil {
-		common.ErrorResp(c, err, 500)
+		certificateErrorResp(c, err)
 		return
 	}
 	common.SuccessResp(c, requests)
-}
\ No newline at end of file
+}
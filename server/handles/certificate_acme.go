@@ -0,0 +1,17 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+// ACMEChallenge 响应公共 CA 的 HTTP-01 验证请求
+func ACMEChallenge(c *gin.Context) {
+	response, ok := op.ACMEHTTPChallengeResponse(c.Param("token"))
+	if !ok {
+		c.Status(404)
+		return
+	}
+	c.String(200, response)
+}
@@ -0,0 +1,149 @@
+package handles
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// --- Admin Handlers ---
+
+// CertificateAgentList 获取已注册的代理及其最后在线时间
+func CertificateAgentList(c *gin.Context) {
+	agents, err := op.GetCertificateAgents()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, agents)
+}
+
+// CreateCertificateAgent 注册代理，令牌只在此时返回
+func CreateCertificateAgent(c *gin.Context) {
+	var req struct {
+		Name string `json:"name" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	agent, token, err := op.CreateCertificateAgent(req.Name, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"agent": agent,
+		"token": token,
+	})
+}
+
+// DeleteCertificateAgent 删除代理，其令牌随即失效
+func DeleteCertificateAgent(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.DeleteCertificateAgent(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// --- Agent Handlers ---
+
+// certificateAgent 校验 Authorization: Bearer <token> 中的代理令牌
+func certificateAgent(c *gin.Context) (*model.CertificateAgent, bool) {
+	token := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	agent, err := op.AuthenticateCertificateAgent(token, c.ClientIP())
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return nil, false
+	}
+	return agent, true
+}
+
+// CertificateAgentReport 代理上报主机信息和已部署证书
+func CertificateAgentReport(c *gin.Context) {
+	agent, ok := certificateAgent(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Hostname     string   `json:"hostname"`
+		Version      string   `json:"version"`
+		Certificates []string `json:"certificates"` // 证书指纹或序列号
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	unknown, err := op.ReportCertificateAgent(agent, req.Hostname, req.Version, req.Certificates)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"certificate_ids": agent.CertificateIDs,
+		"unknown":         unknown,
+	})
+}
+
+// CertificateAgentPoll 长轮询获取影响代理部署证书的吊销和续期，wait 为最长等待秒数
+func CertificateAgentPoll(c *gin.Context) {
+	agent, ok := certificateAgent(c)
+	if !ok {
+		return
+	}
+	wait, err := strconv.Atoi(c.DefaultQuery("wait", "30"))
+	if err != nil || wait < 0 {
+		common.ErrorStrResp(c, "invalid wait", 400)
+		return
+	}
+	res, err := op.PollCertificateAgent(c.Request.Context(), agent, time.Duration(wait)*time.Second)
+	if err != nil {
+		if c.Request.Context().Err() != nil {
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, res)
+}
+
+// CertificateAgentAck 代理确认已处理的变更并上报应用结果
+func CertificateAgentAck(c *gin.Context) {
+	agent, ok := certificateAgent(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Cursor  uint                           `json:"cursor"`
+		Results []model.CertificateAgentResult `json:"results"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.AckCertificateAgent(agent, req.Cursor, req.Results); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
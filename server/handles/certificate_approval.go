@@ -0,0 +1,89 @@
+package handles
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+var certificateApprovalTmpl = template.Must(template.New("approval").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Certificate request approval</title>
+</head>
+<body style="font-family: sans-serif; max-width: 480px; margin: 2em auto; padding: 0 1em;">
+{{if .Error}}
+<p>{{.Error}}</p>
+{{else if .Done}}
+<p>Certificate request #{{.Request.ID}} has been {{if eq .Link.Action "approve"}}approved{{else}}rejected{{end}}.</p>
+{{else}}
+<h3>{{if eq .Link.Action "approve"}}Approve{{else}}Reject{{end}} certificate request #{{.Request.ID}}?</h3>
+<p>Applicant: {{.Request.UserName}}<br>Type: {{.Request.Type}}<br>Reason: {{.Request.Reason}}</p>
+<form method="post">
+<input type="hidden" name="id" value="{{.Link.RequestID}}">
+<input type="hidden" name="user" value="{{.Link.User}}">
+<input type="hidden" name="action" value="{{.Link.Action}}">
+<input type="hidden" name="nonce" value="{{.Link.Nonce}}">
+<input type="hidden" name="sign" value="{{.Link.Sign}}">
+{{if eq .Link.Action "reject"}}<p><textarea name="reason" rows="3" style="width: 100%;" placeholder="Reason"></textarea></p>{{end}}
+<button type="submit">Confirm</button>
+</form>
+{{end}}
+</body>
+</html>`))
+
+type certificateApprovalPage struct {
+	Link    *model.CertificateApprovalLink
+	Request *model.CertificateRequest
+	Done    bool
+	Error   string
+}
+
+func renderCertificateApproval(c *gin.Context, code int, page certificateApprovalPage) {
+	c.Status(code)
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	_ = certificateApprovalTmpl.Execute(c.Writer, page)
+}
+
+// CertificateApprovalLinkPage 邮件中一键审批链接的落地页，确认后才会执行操作
+func CertificateApprovalLinkPage(c *gin.Context) {
+	var link model.CertificateApprovalLink
+	if err := c.ShouldBindQuery(&link); err != nil {
+		renderCertificateApproval(c, http.StatusBadRequest, certificateApprovalPage{Error: "invalid approval link"})
+		return
+	}
+	req, _, err := op.VerifyCertificateApprovalLink(&link)
+	if err != nil {
+		renderCertificateApproval(c, certificateErrorCode(err), certificateApprovalPage{Error: err.Error()})
+		return
+	}
+	renderCertificateApproval(c, http.StatusOK, certificateApprovalPage{Link: &link, Request: req})
+}
+
+// ConfirmCertificateApprovalLink 确认执行一键审批
+func ConfirmCertificateApprovalLink(c *gin.Context) {
+	var link model.CertificateApprovalLink
+	if err := c.ShouldBind(&link); err != nil {
+		renderCertificateApproval(c, http.StatusBadRequest, certificateApprovalPage{Error: "invalid approval link"})
+		return
+	}
+	reason := c.PostForm("reason")
+	req, err := op.UseCertificateApprovalLink(&link, reason)
+	if err != nil {
+		renderCertificateApproval(c, certificateErrorCode(err), certificateApprovalPage{Error: err.Error()})
+		return
+	}
+	if link.Action == model.CertificateApprovalActionReject {
+		op.RecordCertAudit(link.User, model.CertificateAuditReject, model.CertificateAuditTargetRequest, req.ID, c.ClientIP(), reason)
+	} else {
+		op.RecordCertAudit(link.User, model.CertificateAuditApprove, model.CertificateAuditTargetRequest, req.ID, c.ClientIP(), "email link")
+	}
+	renderCertificateApproval(c, http.StatusOK, certificateApprovalPage{Link: &link, Request: req, Done: true})
+}
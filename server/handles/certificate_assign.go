@@ -0,0 +1,51 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// ClaimCertificateRequest 审批人认领待审批的申请
+func ClaimCertificateRequest(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	req, err := op.ClaimCertificateRequest(uint(id), user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// AssignCertificateRequest 管理员重新指派申请的审批人，assignee 为空时取消认领
+func AssignCertificateRequest(c *gin.Context) {
+	var req struct {
+		Assignee string `json:"assignee"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	r, err := op.AssignCertificateRequest(uint(id), user, req.Assignee)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, r)
+}
@@ -0,0 +1,116 @@
+package handles
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// UploadCertificateRequestAttachment 上传申请的附件。表单的 size 字段需在 file 之前，
+// 文件部分不解析整个表单，直接作为流交给存储驱动写入
+func UploadCertificateRequestAttachment(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	size := int64(-1)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			certificateInputErrorResp(c, err)
+			return
+		}
+		switch part.FormName() {
+		case "size":
+			value, err := io.ReadAll(io.LimitReader(part, 32))
+			if err == nil {
+				size, err = strconv.ParseInt(string(value), 10, 64)
+			}
+			if err != nil || size < 0 {
+				common.ErrorStrResp(c, "invalid size", 400)
+				return
+			}
+		case "file":
+			if size < 0 {
+				common.ErrorStrResp(c, "size must be sent before file", 400)
+				return
+			}
+			name := path.Base(part.FileName())
+			if name == "." || name == "/" {
+				common.ErrorStrResp(c, "file name is required", 400)
+				return
+			}
+			mimetype := part.Header.Get("Content-Type")
+			if mimetype == "" {
+				mimetype = utils.GetMimeType(name)
+			}
+			s := &stream.FileStream{
+				Ctx: c.Request.Context(),
+				Obj: &model.Object{
+					Name:     name,
+					Size:     size,
+					Modified: time.Now(),
+				},
+				Reader:   &sizedReader{r: part, n: size},
+				Mimetype: mimetype,
+			}
+			if err := op.PutCertificateRequestAttachment(c.Request.Context(), user, uint(id), s); err != nil {
+				certificateErrorResp(c, err)
+				return
+			}
+			common.SuccessResp(c, gin.H{"name": name, "size": size})
+			return
+		}
+		part.Close()
+	}
+	common.ErrorStrResp(c, "file is required", 400)
+}
+
+// sizedReader 读取声明大小的内容，实际内容与 size 不一致时返回错误，避免存储驱动写入截断的文件
+type sizedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.n <= 0 {
+		var b [1]byte
+		if n, _ := s.r.Read(b[:]); n > 0 {
+			return 0, fmt.Errorf("file is larger than the declared size")
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.n {
+		p = p[:s.n]
+	}
+	n, err := s.r.Read(p)
+	s.n -= int64(n)
+	if err == io.EOF && s.n > 0 {
+		return n, fmt.Errorf("file is smaller than the declared size")
+	}
+	return n, err
+}
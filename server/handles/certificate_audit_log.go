@@ -0,0 +1,28 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateAuditLogList 分页获取证书审计日志，可通过 user 和 action 参数过滤
+func CertificateAuditLogList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	logs, total, err := op.GetCertAuditLogs(req.Page, req.PerPage, c.Query("user"), c.Query("action"))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
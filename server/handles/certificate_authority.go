@@ -0,0 +1,303 @@
+package handles
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CABundle 公开的 CA 信任包，默认返回 PEM，format=json 时返回带版本信息的 JSON。
+// 支持 If-None-Match 条件请求，便于设备定期轮询更新信任库
+func CABundle(c *gin.Context) {
+	bundle, err := op.GetCABundle()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("ETag", bundle.ETag)
+	c.Header("X-CA-Bundle-Version", strconv.FormatInt(bundle.Version, 10))
+	c.Header("Cache-Control", "public, max-age=300")
+	if c.GetHeader("If-None-Match") == bundle.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if c.Query("format") == "json" {
+		common.SuccessResp(c, bundle)
+		return
+	}
+	c.Data(http.StatusOK, "application/x-pem-file", []byte(op.CABundlePEM(bundle)))
+}
+
+// --- Admin Handlers ---
+
+// CertificateAuthorityList 获取全部 CA 证书
+func CertificateAuthorityList(c *gin.Context) {
+	cas, err := op.GetCertificateAuthorities()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, cas)
+}
+
+// AddCertificateAuthority 导入 PEM 格式的根证书或中间证书
+func AddCertificateAuthority(c *gin.Context) {
+	var req struct {
+		Name    string `json:"name"`
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	ca, err := op.AddCertificateAuthority(req.Name, req.Content, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, ca)
+}
+
+// RetireCertificateAuthority 停用 CA 证书
+func RetireCertificateAuthority(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.RetireCertificateAuthority(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// CARotationList 获取 CA 轮换及进度
+func CARotationList(c *gin.Context) {
+	rotations, err := op.GetCARotations()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, rotations)
+}
+
+// GetCARotation 获取单个 CA 轮换的进度
+func GetCARotation(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	progress, err := op.GetCARotationProgress(uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, progress)
+}
+
+// StartCARotation 开始 CA 轮换
+func StartCARotation(c *gin.Context) {
+	var req struct {
+		FromCAID      uint `json:"from_ca_id" binding:"required"`
+		ToCAID        uint `json:"to_ca_id" binding:"required"`
+		CrossSignedID uint `json:"cross_signed_id"`
+		BatchSize     int  `json:"batch_size"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	rotation, err := op.StartCARotation(req.FromCAID, req.ToCAID, req.CrossSignedID, req.BatchSize, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, rotation)
+}
+
+// CancelCARotation 取消 CA 轮换
+func CancelCARotation(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.CancelCARotation(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// SetCertificateAuthorityKey 为 CA 设置签名私钥，用于签发 CRL 和 OCSP 响应
+func SetCertificateAuthorityKey(c *gin.Context) {
+	var req struct {
+		PrivateKey string `json:"private_key" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.SetCertificateAuthorityKey(uint(id), req.PrivateKey, user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// GetRevocationPublishStatus 获取最近一次发布 CRL 和 OCSP 响应的结果
+func GetRevocationPublishStatus(c *gin.Context) {
+	common.SuccessResp(c, op.GetRevocationPublishStatus())
+}
+
+// PublishRevocationData 立即重新发布 CRL 和 OCSP 响应
+func PublishRevocationData(c *gin.Context) {
+	status, err := op.PublishRevocationData(c.Request.Context())
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	if status == nil {
+		common.ErrorStrResp(c, "revocation publish path is not configured", 400)
+		return
+	}
+	common.SuccessResp(c, status)
+}
+
+// CrossSignCertificateAuthority 由一个 CA 为另一个 CA 签发交叉证书
+func CrossSignCertificateAuthority(c *gin.Context) {
+	var req model.CACrossSignInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	ca, err := op.CrossSignCA(&req, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, ca)
+}
+
+// ImportCrossSignedCertificateAuthority 导入外部 CA 签发的交叉证书
+func ImportCrossSignedCertificateAuthority(c *gin.Context) {
+	var req struct {
+		Name    string `json:"name"`
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	ca, err := op.ImportCrossSignedCA(req.Name, req.Content, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, ca)
+}
+
+// CreateCertificateAuthority 生成新的根证书或中间证书
+func CreateCertificateAuthority(c *gin.Context) {
+	var req model.CACreateInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	ca, err := op.CreateCA(&req, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, ca)
+}
+
+// GetActiveCertificateAuthority 获取当前签发新证书的 CA
+func GetActiveCertificateAuthority(c *gin.Context) {
+	ca, err := op.GetActiveCA()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, ca)
+}
+
+// RotateCertificateAuthority 生成替代当前签发 CA 的新 CA 并开始轮换
+func RotateCertificateAuthority(c *gin.Context) {
+	var req struct {
+		CommonName   string `json:"common_name"`
+		ValidityDays int    `json:"validity_days"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	rotation, err := op.RotateCA(req.CommonName, req.ValidityDays, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, rotation)
+}
+
+// ExportCertificateAuthority 下载 CA 证书及其上级证书
+func ExportCertificateAuthority(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	content, err := op.ExportCA(uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="ca-`+idParam+`.pem"`)
+	c.Data(http.StatusOK, "application/x-pem-file", []byte(content))
+}
@@ -0,0 +1,58 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// BatchApproveCertificateRequests 批量批准证书申请，批准理由和检查项对所有申请生效，返回每个申请的结果
+func BatchApproveCertificateRequests(c *gin.Context) {
+	var req struct {
+		IDs []uint `json:"ids" binding:"required"`
+		model.CertificateApprovalInput
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	results, err := op.BatchApproveCertificateRequests(req.IDs, user, &req.CertificateApprovalInput)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	recordCertificateBatchAudit(c, user, model.CertificateAuditApprove, results, req.Justification)
+	common.SuccessResp(c, results)
+}
+
+// BatchRejectCertificateRequests 以同一个理由批量拒绝证书申请，返回每个申请的结果
+func BatchRejectCertificateRequests(c *gin.Context) {
+	var req struct {
+		IDs    []uint `json:"ids" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	results, err := op.BatchRejectCertificateRequests(req.IDs, user, req.Reason)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	recordCertificateBatchAudit(c, user, model.CertificateAuditReject, results, req.Reason)
+	common.SuccessResp(c, results)
+}
+
+func recordCertificateBatchAudit(c *gin.Context, user *model.User, action string, results []model.CertificateBatchResult, detail string) {
+	for _, r := range results {
+		if r.Success {
+			op.RecordCertAudit(user.Username, action, model.CertificateAuditTargetRequest, r.ID, c.ClientIP(), detail)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// BuildCertificateChain 为上传的叶子证书构建证书链，可以附带额外的中间证书
+func BuildCertificateChain(c *gin.Context) {
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	// 所在组织信任库中的 CA 同样作为信任锚
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	chain, err := op.BuildCertificateChain(req.Content, user.Organization)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, chain)
+}
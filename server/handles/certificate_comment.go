@@ -0,0 +1,149 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// GetCertificateRequestDetail 获取有权查看的证书申请详情
+func GetCertificateRequestDetail(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	request, err := op.GetCertificateRequestForUser(user, uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, request)
+}
+
+// ListCertificateRequestComments 获取证书申请的评论
+func ListCertificateRequestComments(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	comments, err := op.GetCertificateRequestComments(user, uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, comments)
+}
+
+// AddCertificateRequestComment 发表评论，支持 @用户名
+func AddCertificateRequestComment(c *gin.Context) {
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	comment, err := op.AddCertificateRequestComment(user, uint(id), req.Content)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, comment)
+}
+
+// ListCertificateRequestNotes 获取申请的内部备注
+func ListCertificateRequestNotes(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	notes, err := op.GetCertificateRequestNotes(uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, notes)
+}
+
+// AddCertificateRequestNote 添加申请的内部备注，申请人不可见
+func AddCertificateRequestNote(c *gin.Context) {
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	note, err := op.AddCertificateRequestNote(user, uint(id), req.Content)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, note)
+}
+
+// LinkCertificateRequestTicket 关联或取消关联申请的外部工单
+func LinkCertificateRequestTicket(c *gin.Context) {
+	var req struct {
+		TicketID  string `json:"ticket_id"`
+		TicketURL string `json:"ticket_url"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	request, err := op.LinkCertificateRequestTicket(user, uint(id), req.TicketID, req.TicketURL)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, request)
+}
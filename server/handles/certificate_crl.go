@@ -0,0 +1,43 @@
+package handles
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateCRL 获取 CA 签发的 CRL，ca 参数为空时为当前签发证书的 CA，
+// format 为 der(默认) 或 pem
+func CertificateCRL(c *gin.Context) {
+	var caID uint
+	if caParam := c.Query("ca"); caParam != "" {
+		id, err := strconv.ParseUint(caParam, 10, 64)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		caID = uint(id)
+	}
+	format := c.DefaultQuery("format", op.CRLFormatDER)
+	crl, err := op.GetCRL(caID, format)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "certificate authority not found", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	contentType := "application/pkix-crl"
+	if format == op.CRLFormatPEM {
+		contentType = "application/x-pem-file"
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, contentType, crl)
+}
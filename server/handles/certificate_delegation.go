@@ -0,0 +1,157 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// --- Admin Handlers ---
+
+// ApprovalDelegationList 获取审批委托列表
+func ApprovalDelegationList(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	delegations, total, err := op.GetApprovalDelegations(req.Page, req.PerPage)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: delegations,
+		Total:   total,
+	})
+}
+
+// CreateApprovalDelegation 当前审批人设置外出期间的代理人
+func CreateApprovalDelegation(c *gin.Context) {
+	var req struct {
+		Delegate string    `json:"delegate" binding:"required"`
+		StartAt  time.Time `json:"start_at" binding:"required"`
+		EndAt    time.Time `json:"end_at" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	delegation, err := op.CreateApprovalDelegation(user, req.Delegate, req.StartAt, req.EndAt)
+	if err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, delegation)
+}
+
+// DeleteApprovalDelegation 删除审批委托
+func DeleteApprovalDelegation(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	err = op.DeleteApprovalDelegation(uint(id), user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// --- Delegate Handlers ---
+
+// DelegatedCertificateRequestList 代理人查看当前生效的委托和待审批的申请
+func DelegatedCertificateRequestList(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	delegations, err := op.GetActiveDelegationsForDelegate(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	if len(delegations) == 0 {
+		common.ErrorStrResp(c, "no active approval delegation", 403)
+		return
+	}
+	requests, err := op.GetDelegatedCertificateRequests(delegations)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"delegations": delegations,
+		"requests":    requests,
+	})
+}
+
+// ApproveCertificateRequestOnBehalf 代理人代为批准证书申请
+func ApproveCertificateRequestOnBehalf(c *gin.Context) {
+	var req struct {
+		model.CertificateApprovalInput
+		OnBehalfOf string `json:"on_behalf_of" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	_, err = op.ApproveCertificateRequestOnBehalf(uint(id), user, req.OnBehalfOf, &req.CertificateApprovalInput)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RejectCertificateRequestOnBehalf 代理人代为拒绝证书申请
+func RejectCertificateRequestOnBehalf(c *gin.Context) {
+	var req struct {
+		OnBehalfOf string `json:"on_behalf_of" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	err = op.RejectCertificateRequestOnBehalf(uint(id), user, req.OnBehalfOf, req.Reason)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
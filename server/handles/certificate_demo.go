@@ -0,0 +1,32 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// SeedCertificateDemoData 填充演示证书和申请
+func SeedCertificateDemoData(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	summary, err := op.SeedCertificateDemoData(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, summary)
+}
+
+// WipeCertificateDemoData 清除演示证书和申请
+func WipeCertificateDemoData(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	summary, err := op.WipeCertificateDemoData(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, summary)
+}
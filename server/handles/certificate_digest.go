@@ -0,0 +1,40 @@
+package handles
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type CertificateDigestReq struct {
+	Frequency string `json:"frequency"`
+}
+
+// GetMyCertificateDigest 获取当前用户的到期摘要订阅设置
+func GetMyCertificateDigest(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	pref, err := op.GetCertificateDigestPref(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, pref)
+}
+
+// UpdateMyCertificateDigest 修改当前用户的摘要频率，off 表示退订
+func UpdateMyCertificateDigest(c *gin.Context) {
+	var req CertificateDigestReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	pref, err := op.UpdateCertificateDigestPref(user, req.Frequency)
+	if err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, pref)
+}
@@ -0,0 +1,72 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// UpdateCertificateRequestDraft 更新申请草稿
+func UpdateCertificateRequestDraft(c *gin.Context) {
+	var req struct {
+		Type           model.CertificateType `json:"type" binding:"required"`
+		Reason         string                `json:"reason"`
+		ValidityPreset string                `json:"validity_preset"`
+		CustomFields   map[string]string     `json:"custom_fields"`
+		SANs           []string              `json:"sans"`
+		CSR            string                `json:"csr"`
+		ProfileID      uint                  `json:"profile_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	draft, err := op.UpdateCertificateRequestDraft(uint(id), user, req.Type, req.Reason, req.ValidityPreset, req.CustomFields, req.SANs, req.CSR, req.ProfileID)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, draft)
+}
+
+// SubmitCertificateRequestDraft 提交申请草稿
+func SubmitCertificateRequestDraft(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	request, err := op.SubmitCertificateRequestDraft(uint(id), user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, request)
+}
+
+// DeleteCertificateRequestDraft 删除申请草稿
+func DeleteCertificateRequestDraft(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.DeleteCertificateRequestDraft(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
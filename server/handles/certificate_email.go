@@ -0,0 +1,50 @@
+package handles
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// maxCertificateEmailSize 入站邮件的大小上限，邮件只携带申请字段和一个 CSR 附件
+const maxCertificateEmailSize = 1 << 20
+
+// CertificateEmailIntake 接收邮件服务商转发的入站邮件，将已知租户的结构化邮件转换为证书申请。
+// 请求需携带 X-OpenList-Timestamp 和 X-OpenList-Signature 头，签名方式与吊销通知相同。
+// 签名需要完整的请求体，因此在读取前单独限制邮件大小
+func CertificateEmailIntake(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxCertificateEmailSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			common.ErrorStrResp(c, fmt.Sprintf("email exceeds %d bytes", maxCertificateEmailSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.VerifyCertificateEmail(body, c.GetHeader("X-OpenList-Timestamp"), c.GetHeader("X-OpenList-Signature")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	var email model.CertificateEmail
+	if err := json.Unmarshal(body, &email); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	res, err := op.CreateCertificateRequestFromEmail(&email)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	op.RecordCertAudit(res.User, model.CertificateAuditCreate, model.CertificateAuditTargetRequest, res.Request.ID, c.ClientIP(), "email")
+	common.SuccessResp(c, res)
+}
@@ -0,0 +1,68 @@
+package handles
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// classifyCertificateError 按服务层错误的类别映射 HTTP 状态码：记录不存在 404，无权限 403，
+// 状态冲突 409，证书内容无效 422，请求参数无效 400，请求体超过大小限制 413，签名繁忙 503。无法归类时返回 false
+func classifyCertificateError(err error) (int, bool) {
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, errs.PermissionDenied):
+		return http.StatusForbidden, true
+	case errors.Is(err, errs.CertificateConflict):
+		return http.StatusConflict, true
+	case errors.Is(err, errs.InvalidCertificateContent):
+		return http.StatusUnprocessableEntity, true
+	case errors.Is(err, errs.InvalidCertificateRequest):
+		return http.StatusBadRequest, true
+	case errors.As(err, &maxBytesErr):
+		return http.StatusRequestEntityTooLarge, true
+	case op.IsSigningBusy(err):
+		return http.StatusServiceUnavailable, true
+	}
+	return 0, false
+}
+
+// certificateErrorCode 无法归类的错误视为服务端故障
+func certificateErrorCode(err error) int {
+	if code, ok := classifyCertificateError(err); ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+// certificateErrorResp 返回服务层错误，无法归类时为 500
+func certificateErrorResp(c *gin.Context, err error) {
+	certificateErrorRespOr(c, err, http.StatusInternalServerError)
+}
+
+// certificateInputErrorResp 用于校验错误未归类的服务层函数，无法归类时为 400
+func certificateInputErrorResp(c *gin.Context, err error) {
+	certificateErrorRespOr(c, err, http.StatusBadRequest)
+}
+
+func certificateErrorRespOr(c *gin.Context, err error, fallback int) {
+	code, ok := classifyCertificateError(err)
+	if !ok {
+		code = fallback
+	}
+	// 证书内容校验失败时附带问题列表
+	var contentErr *errs.CertificateContentError
+	if errors.As(err, &contentErr) {
+		common.ErrorWithDataResp(c, err, code, contentErr.Problems)
+		return
+	}
+	common.ErrorResp(c, err, code)
+}
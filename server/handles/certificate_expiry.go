@@ -0,0 +1,18 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateExpirySummary 按到期分桶统计有效证书的数量
+func CertificateExpirySummary(c *gin.Context) {
+	summary, err := op.GetCertificateExpirySummary()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, summary)
+}
@@ -0,0 +1,47 @@
+package handles
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/xlsx"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// ExportCertificates 以 csv、xlsx 或 json 格式流式导出证书清单，过滤和排序参数与证书列表相同
+func ExportCertificates(c *gin.Context) {
+	var filter model.CertificateFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	format := c.DefaultQuery("format", op.CertificateExportCSV)
+	if err := op.CheckCertificateExport(format, &filter); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case op.CertificateExportJSON:
+		contentType = "application/json; charset=utf-8"
+	case op.CertificateExportXLSX:
+		contentType = xlsx.ContentType
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="certificates-%s.%s"`, time.Now().Format("20060102-150405"), format))
+	c.Header("Cache-Control", "no-store")
+	c.Status(200)
+	// 响应头已发送，导出中途出错时只能中断连接，由客户端发现文件不完整
+	n, err := op.ExportCertificates(c.Writer, format, &filter, user)
+	if err != nil {
+		log.Errorf("certificate export aborted after %d rows: %+v", n, err)
+		c.Abort()
+	}
+}
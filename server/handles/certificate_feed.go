@@ -0,0 +1,152 @@
+package handles
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyCertificateFeedToken 获取当前用户的订阅令牌
+func GetMyCertificateFeedToken(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	t, err := op.GetCertificateFeedToken(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, t)
+}
+
+// ResetMyCertificateFeedToken 重置当前用户的订阅令牌
+func ResetMyCertificateFeedToken(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	t, err := op.ResetCertificateFeedToken(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, t)
+}
+
+// CertificateCalendarFeed 证书到期日历，供日历应用通过令牌订阅
+func CertificateCalendarFeed(c *gin.Context) {
+	user, err := op.GetUserByCertificateFeedToken(c.Query("token"))
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	calendar, err := op.GetCertificateCalendar(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="certificates.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", []byte(calendar))
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Summary string `xml:"summary"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type rssItem struct {
+	GUID        string `xml:"guid"`
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Category    string `xml:"category"`
+	PubDate     string `xml:"pubDate"`
+}
+
+type rssFeed struct {
+	XMLName     xml.Name  `xml:"rss"`
+	Version     string    `xml:"version,attr"`
+	Title       string    `xml:"channel>title"`
+	Link        string    `xml:"channel>link"`
+	Description string    `xml:"channel>description"`
+	Items       []rssItem `xml:"channel>item"`
+}
+
+// CertificateEventFeed 证书签发、吊销和到期事件的 Atom/RSS 订阅源，通过令牌订阅
+func CertificateEventFeed(c *gin.Context) {
+	user, err := op.GetUserByCertificateFeedToken(c.Query("token"))
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	entries, err := op.GetCertificateFeed(user, 30, 100)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	title := "Certificate events of " + user.Username
+	if user.CanViewAllCertificates() {
+		title = "Certificate events"
+	}
+	site := strings.TrimSuffix(conf.Conf.SiteURL, "/")
+	var body any
+	contentType := "application/atom+xml; charset=utf-8"
+	if c.Query("format") == "rss" {
+		feed := rssFeed{Version: "2.0", Title: title, Link: site + "/", Description: title}
+		for _, e := range entries {
+			feed.Items = append(feed.Items, rssItem{
+				GUID:        e.ID,
+				Title:       e.Title,
+				Description: e.Summary,
+				Category:    e.Event,
+				PubDate:     e.Time.Format(time.RFC1123Z),
+			})
+		}
+		body = feed
+		contentType = "application/rss+xml; charset=utf-8"
+	} else {
+		updated := time.Now()
+		if len(entries) > 0 {
+			updated = entries[0].Time
+		}
+		feed := atomFeed{
+			ID:      "urn:openlist:certificate-events:" + user.Username,
+			Title:   title,
+			Updated: updated.Format(time.RFC3339),
+		}
+		if site != "" {
+			feed.Link = &atomLink{Href: site + "/"}
+		}
+		for _, e := range entries {
+			feed.Entries = append(feed.Entries, atomEntry{
+				ID:      "urn:openlist:certificate-event:" + e.ID,
+				Title:   e.Title,
+				Updated: e.Time.Format(time.RFC3339),
+				Summary: e.Summary,
+			})
+		}
+		body = feed
+	}
+	data, err := xml.MarshalIndent(body, "", "  ")
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Data(200, contentType, append([]byte(xml.Header), data...))
+}
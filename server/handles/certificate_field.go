@@ -0,0 +1,95 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// --- Admin Handlers ---
+
+// CertificateRequestFieldList 获取全部自定义字段
+func CertificateRequestFieldList(c *gin.Context) {
+	fields, err := op.GetCertificateRequestFields("")
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, fields)
+}
+
+// CreateCertificateRequestField 创建自定义字段
+func CreateCertificateRequestField(c *gin.Context) {
+	var req model.CertificateRequestField
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateCertificateRequestField(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateCertificateRequestField 更新自定义字段
+func UpdateCertificateRequestField(c *gin.Context) {
+	var req model.CertificateRequestField
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	field, err := op.GetCertificateRequestFieldByID(uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	req.ID = field.ID
+	req.CreatedAt = field.CreatedAt
+	if err := op.UpdateCertificateRequestField(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// DeleteCertificateRequestField 删除自定义字段
+func DeleteCertificateRequestField(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteCertificateRequestField(uint(id)); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// --- Tenant Handlers ---
+
+// GetTenantCertificateRequestFields 获取指定证书类型申请表单的自定义字段
+func GetTenantCertificateRequestFields(c *gin.Context) {
+	reqType := model.CertificateType(c.Query("type"))
+	fields, err := op.GetCertificateRequestFields(reqType)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, fields)
+}
@@ -0,0 +1,77 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateFreezeWindowList 获取当前用户可见的冻结窗口
+func CertificateFreezeWindowList(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	windows, err := op.GetCertificateFreezeWindows(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, windows)
+}
+
+// CreateCertificateFreezeWindow 新增冻结窗口
+func CreateCertificateFreezeWindow(c *gin.Context) {
+	var req model.CertificateFreezeWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.CreateCertificateFreezeWindow(user, &req); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateCertificateFreezeWindow 更新冻结窗口
+func UpdateCertificateFreezeWindow(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	var req model.CertificateFreezeWindow
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = uint(id)
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.UpdateCertificateFreezeWindow(user, &req); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// DeleteCertificateFreezeWindow 删除冻结窗口
+func DeleteCertificateFreezeWindow(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.DeleteCertificateFreezeWindow(user, uint(id)); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
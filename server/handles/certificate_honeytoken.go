@@ -0,0 +1,68 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateHoneytokenList 获取诱饵证书及其触发次数
+func CertificateHoneytokenList(c *gin.Context) {
+	tokens, err := op.GetCertificateHoneytokens()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, tokens)
+}
+
+// MarkCertificateHoneytoken 将证书标记为诱饵证书
+func MarkCertificateHoneytoken(c *gin.Context) {
+	var req struct {
+		Note string `json:"note"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	token, err := op.MarkCertificateHoneytoken(uint(id), req.Note, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, token)
+}
+
+// UnmarkCertificateHoneytoken 取消诱饵证书标记
+func UnmarkCertificateHoneytoken(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.UnmarkCertificateHoneytoken(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
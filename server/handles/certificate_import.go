@@ -0,0 +1,73 @@
+package handles
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// ImportCertificates 导入证书，请求体为 JSON 数组时批量导入，为 PEM 包时导入其中的证书，
+// 也可以 multipart 的 file 字段上传。PEM 包的所有者由 owner 参数指定，默认为操作人
+func ImportCertificates(c *gin.Context) {
+	var r io.Reader = c.Request.Body
+	if file, err := c.FormFile("file"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+			return
+		}
+		defer f.Close()
+		r = f
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	br := bufio.NewReader(r)
+	if isPEMBundle(br) {
+		importCertificatePEM(c, br, user)
+		return
+	}
+	res, err := op.ImportCertificates(br, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, res)
+}
+
+// isPEMBundle 跳过开头的空白后检查内容是否以 PEM 块开始
+func isPEMBundle(br *bufio.Reader) bool {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return false
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			break
+		}
+		_, _ = br.ReadByte()
+	}
+	prefix, _ := br.Peek(len("-----BEGIN"))
+	return string(prefix) == "-----BEGIN"
+}
+
+func importCertificatePEM(c *gin.Context, r io.Reader, user *model.User) {
+	bundle, err := io.ReadAll(r)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	owner := c.DefaultPostForm("owner", c.Query("owner"))
+	cert, err := op.ImportCertificatePEM(string(bundle), owner, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	op.RecordCertAudit(user.Username, model.CertificateAuditCreate, model.CertificateAuditTargetCertificate, cert.ID, c.ClientIP(), cert.Name)
+	common.SuccessResp(c, cert)
+}
@@ -0,0 +1,46 @@
+package handles
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// WellKnownJWKS 发布校验令牌所需的公钥集合，格式遵循 RFC 7517，不使用统一的响应包装
+func WellKnownJWKS(c *gin.Context) {
+	set, err := op.GetJSONWebKeySet()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, set)
+}
+
+// SignJWT 使用证书私钥签发令牌
+func SignJWT(c *gin.Context) {
+	var req struct {
+		Subject  string         `json:"subject" binding:"required"`
+		Audience []string       `json:"audience"`
+		TTL      int            `json:"ttl"` // 秒，0 表示使用最大有效期
+		Claims   map[string]any `json:"claims"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	token, err := op.SignJWT(req.Subject, req.Audience, time.Duration(req.TTL)*time.Second, req.Claims, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, token)
+}
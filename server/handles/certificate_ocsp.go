@@ -0,0 +1,39 @@
+package handles
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// maxOCSPRequestSize OCSP 请求体的上限，正常的请求只有几百字节
+const maxOCSPRequestSize = 64 << 10
+
+// CertificateOCSP 按 RFC 6960 响应 OCSP 请求，POST 时请求体为 DER 编码的请求，
+// GET 时请求为路径中经过 URL 编码的 base64
+func CertificateOCSP(c *gin.Context) {
+	var der []byte
+	var err error
+	if c.Request.Method == http.MethodGet {
+		var raw string
+		raw, err = url.PathUnescape(strings.TrimPrefix(c.Param("request"), "/"))
+		if err == nil {
+			der, err = base64.StdEncoding.DecodeString(raw)
+		}
+	} else {
+		der, err = io.ReadAll(io.LimitReader(c.Request.Body, maxOCSPRequestSize))
+	}
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	c.Header("Cache-Control", "no-cache")
+	c.Data(http.StatusOK, "application/ocsp-response", op.RespondOCSP(der, c.ClientIP()))
+}
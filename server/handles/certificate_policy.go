@@ -0,0 +1,75 @@
+package handles
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// policyBundleMaxBody 策略文件的最大长度
+const policyBundleMaxBody = 4 << 20
+
+// policyBundleFormat 从 format 参数或请求的 Content-Type 判断使用 yaml 还是 json
+func policyBundleFormat(c *gin.Context) string {
+	if format := c.Query("format"); format != "" {
+		return format
+	}
+	if strings.Contains(c.ContentType(), "yaml") {
+		return "yaml"
+	}
+	return "json"
+}
+
+// ExportPolicyBundle 导出证书策略、模板和通知路由，format=yaml 时输出 YAML
+func ExportPolicyBundle(c *gin.Context) {
+	format := policyBundleFormat(c)
+	if format != "json" && format != "yaml" {
+		common.ErrorStrResp(c, "format must be json or yaml", 400)
+		return
+	}
+	bundle, err := op.ExportPolicyBundle()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	data, err := op.MarshalPolicyBundle(bundle, format)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	contentType := "application/json"
+	if format == "yaml" {
+		contentType = "application/yaml"
+	}
+	c.Header("Content-Disposition", `attachment; filename="openlist-pki-policy.`+format+`"`)
+	c.Data(200, contentType, data)
+}
+
+// ImportPolicyBundle 导入策略文件，dry_run=true 时只返回将要发生的变更
+func ImportPolicyBundle(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, policyBundleMaxBody))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	bundle, err := op.UnmarshalPolicyBundle(data, policyBundleFormat(c))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	res, err := op.ImportPolicyBundle(bundle, c.Query("dry_run") == "true", user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, res)
+}
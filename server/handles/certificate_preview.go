@@ -0,0 +1,71 @@
+package handles
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// PreviewCertificateRequest 预览批准申请后将签发的证书，可通过 not_before 预览计划签发
+func PreviewCertificateRequest(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	var notBefore *time.Time
+	if v := c.Query("not_before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		notBefore = &t
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	preview, err := op.PreviewCertificateRequest(uint(id), user, notBefore)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, preview)
+}
+
+// PreviewTenantCertificate 按申请参数或模板预览将签发的证书，不会创建申请
+func PreviewTenantCertificate(c *gin.Context) {
+	var req struct {
+		TemplateID     uint                  `json:"template_id"`
+		Type           model.CertificateType `json:"type"`
+		ValidityPreset string                `json:"validity_preset"`
+		CustomFields   map[string]string     `json:"custom_fields"`
+		SANs           []string              `json:"sans"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	var preview *model.CertificatePreview
+	var err error
+	if req.TemplateID != 0 {
+		preview, err = op.PreviewCertificateFromTemplate(user, req.TemplateID, req.CustomFields)
+	} else {
+		preview, err = op.PreviewCertificate(user, req.Type, req.ValidityPreset, req.CustomFields, req.SANs)
+	}
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, preview)
+}
@@ -0,0 +1,70 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateProfileList 获取签发配置，租户申请时从中选择
+func CertificateProfileList(c *gin.Context) {
+	profiles, err := op.GetCertificateProfiles()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, profiles)
+}
+
+// CreateCertificateProfile 新增签发配置
+func CreateCertificateProfile(c *gin.Context) {
+	var req model.CertificateProfile
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateCertificateProfile(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateCertificateProfile 更新签发配置的密钥、有效期、备用名称和用途
+func UpdateCertificateProfile(c *gin.Context) {
+	var req model.CertificateProfile
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = uint(id)
+	if err := op.UpdateCertificateProfile(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// DeleteCertificateProfile 删除签发配置
+func DeleteCertificateProfile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteCertificateProfile(uint(id)); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
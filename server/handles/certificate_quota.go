@@ -0,0 +1,50 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// GetTenantCertificateQuota 获取租户的证书配额和当前使用量
+func GetTenantCertificateQuota(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	quota, err := op.GetTenantCertificateQuota(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, quota)
+}
+
+// CertificateTenantQuotaList 列出单独设置了证书配额的租户
+func CertificateTenantQuotaList(c *gin.Context) {
+	quotas, err := op.GetCertificateTenantQuotas()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, quotas)
+}
+
+// SetCertificateTenantQuota 设置租户的有效证书数量上限，max_certificates 为 0 时恢复使用全局设置
+func SetCertificateTenantQuota(c *gin.Context) {
+	var req struct {
+		UserID          uint `json:"user_id" binding:"required"`
+		MaxCertificates int  `json:"max_certificates"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.SetCertificateTenantQuota(req.UserID, req.MaxCertificates, user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,43 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// GetCertificateReconciliation 获取申请与证书的一致性检查报告，refresh=true 时立即重新检查
+func GetCertificateReconciliation(c *gin.Context) {
+	report, err := op.GetCertificateReconciliation(c.Query("refresh") == "true")
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, report)
+}
+
+// RemediateCertificateAnomaly 对报告中的一条不一致记录执行修复操作
+func RemediateCertificateAnomaly(c *gin.Context) {
+	var req struct {
+		Kind          string `json:"kind" binding:"required"`
+		Action        string `json:"action" binding:"required"`
+		RequestID     uint   `json:"request_id"`
+		CertificateID uint   `json:"certificate_id"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	report, err := op.RemediateCertificateAnomaly(req.Kind, req.Action, req.RequestID, req.CertificateID, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, report)
+}
@@ -0,0 +1,77 @@
+package handles
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// RenewCertificate 管理员手动续期证书
+func RenewCertificate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	renewal, err := op.RenewCertificateByID(uint(id), user, c.ClientIP())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "certificate not found", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, renewal)
+}
+
+// RenewTenantCertificate 租户手动续期自己的证书，可以通过 id 参数指定证书，默认为到期最晚的有效证书
+func RenewTenantCertificate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.DefaultQuery("id", "0"), 10, 64)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	renewal, err := op.RenewTenantCertificate(user, uint(id), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "no valid certificate to renew", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, renewal)
+}
+
+// SetTenantCertificateAutoRenew 租户开启或关闭证书的自动续期，未指定 certificate_id 时为到期最晚的有效证书
+func SetTenantCertificateAutoRenew(c *gin.Context) {
+	var req struct {
+		CertificateID uint `json:"certificate_id"`
+		AutoRenew     bool `json:"auto_renew"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	cert, err := op.SetTenantCertificateAutoRenew(user, req.CertificateID, req.AutoRenew)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "no valid certificate", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, cert)
+}
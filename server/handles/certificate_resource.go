@@ -0,0 +1,44 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// GetCertificateResource 获取声明式资源的当前状态，供 Terraform 等工具读取
+func GetCertificateResource(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	res, err := op.GetCertificateResource(user, c.Param("external_id"))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, res)
+}
+
+// ApplyCertificateResource 按期望状态创建或更新声明式资源，重复提交相同的期望状态是幂等的，
+// dry_run=true 时只返回偏差和将要执行的操作
+func ApplyCertificateResource(c *gin.Context) {
+	var spec model.CertificateResourceSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	res, err := op.ApplyCertificateResource(user, c.Param("external_id"), &spec, c.Query("dry_run") == "true")
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	if !res.DryRun && (res.Action == model.CertificateResourceCreated || res.Action == model.CertificateResourceReplaced) {
+		op.RecordCertAudit(user.Username, model.CertificateAuditCreate, model.CertificateAuditTargetRequest, res.Request.ID, c.ClientIP(), "resource "+res.Action)
+	}
+	common.SuccessResp(c, res)
+}
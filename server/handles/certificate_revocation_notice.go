@@ -0,0 +1,53 @@
+package handles
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// revocationNoticeMaxBody 吊销通知请求体的最大长度
+const revocationNoticeMaxBody = 64 << 10
+
+// CertificateRevocationNotice 接收上游 CA 或安全工具发送的证书泄露通知。
+// 请求需携带 X-OpenList-Timestamp 和 X-OpenList-Signature 头，签名校验通过后吊销对应证书
+func CertificateRevocationNotice(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, revocationNoticeMaxBody))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.VerifyRevocationNotice(body, c.GetHeader("X-OpenList-Timestamp"), c.GetHeader("X-OpenList-Signature")); err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	var notice model.RevocationNotice
+	if err := json.Unmarshal(body, &notice); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	cert, revoked, err := op.HandleRevocationNotice(&notice)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			common.ErrorStrResp(c, "certificate not found", 404)
+			return
+		}
+		certificateErrorResp(c, err)
+		return
+	}
+	if revoked {
+		op.RecordCertAudit("webhook:"+notice.Source, model.CertificateAuditRevoke, model.CertificateAuditTargetCertificate, cert.ID, c.ClientIP(), cert.RevocationReason)
+	}
+	common.SuccessResp(c, gin.H{
+		"certificate_id": cert.ID,
+		"status":         cert.Status,
+		"revoked":        revoked,
+	})
+}
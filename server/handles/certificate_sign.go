@@ -0,0 +1,162 @@
+package handles
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// maxSignPayloadSize 单次签名内容的大小上限
+const maxSignPayloadSize = 32 << 20
+
+// SignWithCertificate 使用服务端保管的证书私钥签名 base64 编码的内容，返回 base64 编码的分离式 CMS 签名
+func SignWithCertificate(c *gin.Context) {
+	var req struct {
+		CertificateID uint   `json:"certificate_id"`
+		Payload       string `json:"payload" binding:"required"`
+		Filename      string `json:"filename"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	payload, err := base64.StdEncoding.DecodeString(req.Payload)
+	if err != nil {
+		common.ErrorStrResp(c, "payload is not base64 encoded", 400)
+		return
+	}
+	if len(payload) > maxSignPayloadSize {
+		common.ErrorStrResp(c, fmt.Sprintf("payload exceeds %d bytes", maxSignPayloadSize), 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	signature, err := op.SignWithCertificate(req.CertificateID, user, payload, req.Filename, c.ClientIP())
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"signature": base64.StdEncoding.EncodeToString(signature),
+	})
+}
+
+// SignFileWithCertificate 签名上传的文件，以 .p7s 文件返回分离式 CMS 签名。
+// 逐个读取 multipart 的各部分，文件内容只在内存中保留一份，不会先写入临时文件
+func SignFileWithCertificate(c *gin.Context) {
+	mr, err := c.Request.MultipartReader()
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	var id int
+	var payload []byte
+	var filename string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+		switch part.FormName() {
+		case "certificate_id":
+			value, err := io.ReadAll(io.LimitReader(part, 32))
+			if err == nil && len(value) > 0 {
+				id, err = strconv.Atoi(string(value))
+			}
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+		case "file":
+			filename = part.FileName()
+			payload, err = io.ReadAll(io.LimitReader(part, maxSignPayloadSize+1))
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+			if len(payload) > maxSignPayloadSize {
+				common.ErrorStrResp(c, fmt.Sprintf("file exceeds %d bytes", maxSignPayloadSize), 400)
+				return
+			}
+		}
+		part.Close()
+	}
+	if filename == "" {
+		common.ErrorStrResp(c, "file is required", 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	name := path.Base(filename)
+
+	signature, err := op.SignWithCertificate(uint(id), user, payload, name, c.ClientIP())
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".p7s"))
+	c.Data(http.StatusOK, "application/pkcs7-signature", signature)
+}
+
+// CertificateSignatureList 获取签名记录，管理员通过证书 ID 查询，租户只能查看自己的记录
+func CertificateSignatureList(c *gin.Context) {
+	var id int
+	if idParam := c.Param("id"); idParam != "" {
+		var err error
+		id, err = strconv.Atoi(idParam)
+		if err != nil {
+			common.ErrorResp(c, err, 400)
+			return
+		}
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	signatures, err := op.GetCertificateSignatures(uint(id), user)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, signatures)
+}
+
+// SetCertificatePrivateKey 设置服务端保管的证书私钥
+func SetCertificatePrivateKey(c *gin.Context) {
+	var req struct {
+		PrivateKey string `json:"private_key" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.SetCertificatePrivateKey(uint(id), req.PrivateKey, user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,88 @@
+package handles
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/notify"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+type slackInteraction struct {
+	Type string `json:"type"`
+	User struct {
+		ID string `json:"id"`
+	} `json:"user"`
+	ResponseURL string `json:"response_url"`
+	Actions     []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+}
+
+// SlackInteraction 接收 slack 审批按钮的回调，校验签名后以绑定的管理员身份执行审批
+func SlackInteraction(c *gin.Context) {
+	secret := setting.GetStr(conf.NotifySlackSecret)
+	if secret == "" {
+		common.ErrorStrResp(c, "slack integration is not configured", 404)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, 1<<20))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	err = notify.VerifySlackRequest(secret, c.GetHeader("X-Slack-Request-Timestamp"), c.GetHeader("X-Slack-Signature"), body)
+	if err != nil {
+		common.ErrorResp(c, err, 401)
+		return
+	}
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	var payload slackInteraction
+	if err := json.Unmarshal([]byte(values.Get("payload")), &payload); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		c.Status(200)
+		return
+	}
+	action := payload.Actions[0]
+	ip := c.ClientIP()
+	// slack 要求 3 秒内响应，审批结果通过 response_url 异步回写
+	go func() {
+		res, err := op.HandleSlackCertificateAction(payload.User.ID, action.ActionID, action.Value)
+		// 失败时保留原消息的按钮，只回复给操作者
+		replace := err == nil
+		var text string
+		if err != nil {
+			text = "Failed: " + err.Error()
+		} else {
+			text = res.Text
+			op.RecordCertAudit(res.Actor, res.Action, model.CertificateAuditTargetRequest, res.RequestID, ip, "slack")
+		}
+		if payload.ResponseURL == "" {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := notify.RespondSlack(ctx, payload.ResponseURL, text, replace); err != nil {
+			log.Warnf("failed to respond slack interaction: %+v", err)
+		}
+	}()
+	c.Status(200)
+}
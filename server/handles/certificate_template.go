@@ -0,0 +1,100 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateRequestTemplateList 获取自己的申请模板和共享模板
+func CertificateRequestTemplateList(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	templates, err := op.GetCertificateRequestTemplates(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, templates)
+}
+
+// CreateCertificateRequestTemplate 保存申请模板，shared 为 true 时创建共享模板（仅管理员）
+func CreateCertificateRequestTemplate(c *gin.Context) {
+	var req struct {
+		model.CertificateRequestTemplate
+		Shared bool `json:"shared"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.CreateCertificateRequestTemplate(user, &req.CertificateRequestTemplate, req.Shared); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req.CertificateRequestTemplate)
+}
+
+func UpdateCertificateRequestTemplate(c *gin.Context) {
+	var t model.CertificateRequestTemplate
+	if err := c.ShouldBindJSON(&t); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	t.ID = uint(id)
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.UpdateCertificateRequestTemplate(user, &t); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, t)
+}
+
+func DeleteCertificateRequestTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.DeleteCertificateRequestTemplate(user, uint(id)); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// CreateCertificateRequestFromTemplate 按模板一次创建申请
+func CreateCertificateRequestFromTemplate(c *gin.Context) {
+	var req struct {
+		Reason       string            `json:"reason"`
+		CustomFields map[string]string `json:"custom_fields"`
+		Draft        bool              `json:"draft"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	request, err := op.CreateCertificateRequestFromTemplate(user, uint(id), req.Reason, req.CustomFields, req.Draft)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, request)
+}
@@ -0,0 +1,84 @@
+package handles
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateTrustAnchorList 获取当前用户可见的组织信任库
+func CertificateTrustAnchorList(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	anchors, err := op.GetCertificateTrustAnchors(user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, anchors)
+}
+
+// AddCertificateTrustAnchors 上传 CA 证书加入组织信任库
+func AddCertificateTrustAnchors(c *gin.Context) {
+	var req struct {
+		Organization string `json:"organization" binding:"required"`
+		Name         string `json:"name"`
+		Content      string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	anchors, err := op.AddCertificateTrustAnchors(user, req.Organization, req.Name, req.Content)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, anchors)
+}
+
+// DeleteCertificateTrustAnchor 从组织信任库移除 CA 证书
+func DeleteCertificateTrustAnchor(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.DeleteCertificateTrustAnchor(user, uint(id)); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// CertificateTrustStoreBundle 以 PEM 格式公开组织信任库，地址固定，供客户端定期拉取
+func CertificateTrustStoreBundle(c *gin.Context) {
+	bundle, err := op.CertificateTrustStoreBundle(c.Param("organization"))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	if bundle == "" {
+		common.ErrorStrResp(c, "the organization has no trusted ca", 404)
+		return
+	}
+	sum := sha256.Sum256([]byte(bundle))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/x-pem-file", []byte(bundle))
+}
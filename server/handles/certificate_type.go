@@ -0,0 +1,94 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// --- Admin Handlers ---
+
+// CertificateTypeList 获取证书类型注册表
+func CertificateTypeList(c *gin.Context) {
+	types, err := op.GetCertificateTypes()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, types)
+}
+
+// CreateCertificateType 新增证书类型
+func CreateCertificateType(c *gin.Context) {
+	var req model.CertificateTypeDef
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateCertificateType(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateCertificateType 更新证书类型的模板、审批链和配额
+func UpdateCertificateType(c *gin.Context) {
+	var req model.CertificateTypeDef
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	req.ID = uint(id)
+	if err := op.UpdateCertificateType(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// DeleteCertificateType 删除证书类型
+func DeleteCertificateType(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteCertificateType(uint(id)); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// --- Tenant Handlers ---
+
+// GetTenantCertificateTypes 获取租户可以申请的证书类型
+func GetTenantCertificateTypes(c *gin.Context) {
+	types, err := op.GetCertificateTypes()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	res := make([]model.CertificateTypeDef, 0, len(types))
+	for _, t := range types {
+		if !t.Disabled {
+			res = append(res, t)
+		}
+	}
+	common.SuccessResp(c, res)
+}
@@ -0,0 +1,62 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+type CertificateWatchReq struct {
+	TargetType model.CertificateWatchTarget `json:"target_type" binding:"required"`
+	TargetID   uint                         `json:"target_id" binding:"required"`
+}
+
+// ListCertificateWatches 获取当前用户的关注列表
+func ListCertificateWatches(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	watches, err := op.GetCertificateWatchesByUserID(user.ID)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, watches)
+}
+
+// WatchCertificate 关注证书或申请
+func WatchCertificate(c *gin.Context) {
+	var req CertificateWatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	watch, err := op.WatchCertificateTarget(user, req.TargetType, req.TargetID)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, watch)
+}
+
+// UnwatchCertificate 取消关注
+func UnwatchCertificate(c *gin.Context) {
+	var req CertificateWatchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.UnwatchCertificateTarget(user, req.TargetType, req.TargetID); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
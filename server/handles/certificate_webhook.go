@@ -0,0 +1,138 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// CertificateWebhookReq 注册或修改接收端，secret 为空时注册生成新的密钥、修改保留原来的密钥
+type CertificateWebhookReq struct {
+	Name    string   `json:"name"`
+	URL     string   `json:"url" binding:"required"`
+	Secret  string   `json:"secret"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+func (r *CertificateWebhookReq) webhook() *model.CertificateWebhook {
+	enabled := r.Enabled == nil || *r.Enabled
+	return &model.CertificateWebhook{Name: r.Name, URL: r.URL, Secret: r.Secret, Events: r.Events, Enabled: enabled}
+}
+
+// CertificateWebhookList 获取注册的接收端和可以订阅的事件
+func CertificateWebhookList(c *gin.Context) {
+	hooks, err := op.GetCertificateWebhooks()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"webhooks": hooks,
+		"events":   op.CertificateWebhookSubscribableEvents(),
+	})
+}
+
+// CreateCertificateWebhook 注册接收端，签名密钥只在此时返回
+func CreateCertificateWebhook(c *gin.Context) {
+	var req CertificateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	w := req.webhook()
+	secret, err := op.CreateCertificateWebhook(w, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"webhook": w,
+		"secret":  secret,
+	})
+}
+
+// UpdateCertificateWebhook 修改接收端
+func UpdateCertificateWebhook(c *gin.Context) {
+	var req CertificateWebhookReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	w := req.webhook()
+	w.ID = uint(id)
+	if err := op.UpdateCertificateWebhook(w, user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, w)
+}
+
+// DeleteCertificateWebhook 删除接收端及其推送记录
+func DeleteCertificateWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.DeleteCertificateWebhook(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// CertificateWebhookDeliveryListReq 推送记录的筛选条件，webhook_id 和 status 为空时不筛选
+type CertificateWebhookDeliveryListReq struct {
+	model.PageReq
+	WebhookID uint   `json:"webhook_id" form:"webhook_id"`
+	Status    string `json:"status" form:"status"`
+}
+
+// CertificateWebhookDeliveryList 分页获取推送记录
+func CertificateWebhookDeliveryList(c *gin.Context) {
+	var req CertificateWebhookDeliveryListReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	deliveries, total, err := op.GetCertificateWebhookDeliveries(req.WebhookID, req.Status, req.Page, req.PerPage)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: deliveries,
+		Total:   total,
+	})
+}
+
+// RedeliverCertificateWebhook 立即重新推送一条推送记录
+func RedeliverCertificateWebhook(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	d, err := op.RedeliverCertificateWebhook(c.Request.Context(), uint(id), user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, d)
+}
@@ -0,0 +1,33 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// FireCertificateWebhookReq 测试推送的事件
+type FireCertificateWebhookReq struct {
+	Event string `json:"event" binding:"required"`
+}
+
+// CertificateWebhookEvents 获取可以测试推送的事件
+func CertificateWebhookEvents(c *gin.Context) {
+	common.SuccessResp(c, op.CertificateWebhookEvents())
+}
+
+// FireCertificateWebhook 向通知 webhook 推送事件的示例消息，返回接收端的响应和耗时
+func FireCertificateWebhook(c *gin.Context) {
+	var req FireCertificateWebhookReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	res, err := op.FireCertificateWebhook(c.Request.Context(), req.Event)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, res)
+}
@@ -0,0 +1,62 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// LegalHoldList 获取法律保留记录，active=true 时只返回生效中的
+func LegalHoldList(c *gin.Context) {
+	holds, err := op.GetLegalHolds(c.Query("active") == "true")
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, holds)
+}
+
+// PlaceLegalHold 对证书或租户设置法律保留
+func PlaceLegalHold(c *gin.Context) {
+	var req struct {
+		Scope    string `json:"scope" binding:"required"`
+		TargetID uint   `json:"target_id" binding:"required"`
+		Reason   string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	hold, err := op.PlaceLegalHold(req.Scope, req.TargetID, req.Reason, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, hold)
+}
+
+// ReleaseLegalHold 解除法律保留
+func ReleaseLegalHold(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	if err := op.ReleaseLegalHold(uint(id), user); err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
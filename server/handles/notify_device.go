@@ -0,0 +1,68 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type NotifyDeviceRegisterReq struct {
+	Platform string `json:"platform" binding:"required"`
+	Token    string `json:"token" binding:"required"`
+	Name     string `json:"name"`
+}
+
+func RegisterMyDevice(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	var req NotifyDeviceRegisterReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, "request invalid", 400)
+		return
+	}
+	device, err := op.RegisterNotifyDevice(userObj, req.Platform, req.Token, req.Name)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, device)
+}
+
+func ListMyDevices(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	devices, err := op.GetNotifyDevicesByUserID(userObj.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, devices)
+}
+
+func DeleteMyDevice(c *gin.Context) {
+	userObj, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || userObj.IsGuest() {
+		common.ErrorStrResp(c, "user invalid", 401)
+		return
+	}
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorStrResp(c, "id format invalid", 400)
+		return
+	}
+	if err := op.DeleteNotifyDevice(uint(id), userObj.ID); err != nil {
+		common.ErrorResp(c, err, 404)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,62 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// OwnershipTransferList 获取所有权转移任务列表
+func OwnershipTransferList(c *gin.Context) {
+	transfers, err := op.GetOwnershipTransfers()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, transfers)
+}
+
+// GetOwnershipTransfer 获取所有权转移任务及逐条结果
+func GetOwnershipTransfer(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	transfer, err := op.GetOwnershipTransfer(uint(id))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, transfer)
+}
+
+// StartOwnershipTransfer 开始批量转移证书和未完成申请的所有权
+func StartOwnershipTransfer(c *gin.Context) {
+	var req struct {
+		FromUser         string `json:"from_user"`
+		FromOrganization string `json:"from_organization"`
+		ToUser           string `json:"to_user" binding:"required"`
+		DryRun           bool   `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+
+	transfer, err := op.StartOwnershipTransfer(req.FromUser, req.FromOrganization, req.ToUser, req.DryRun, user)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, transfer)
+}
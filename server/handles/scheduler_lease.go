@@ -0,0 +1,21 @@
+package handles
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// SchedulerLeaseList shows which instance runs each scheduled job
+func SchedulerLeaseList(c *gin.Context) {
+	leases, err := op.GetSchedulerLeases()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"instance": op.InstanceID,
+		"leases":   leases,
+	})
+}
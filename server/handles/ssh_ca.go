@@ -0,0 +1,107 @@
+package handles
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// SSHHostPrincipalList 获取 SSH 主机 principal
+func SSHHostPrincipalList(c *gin.Context) {
+	principals, err := op.GetSSHHostPrincipals()
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, principals)
+}
+
+// CreateSSHHostPrincipal 新增 SSH 主机 principal
+func CreateSSHHostPrincipal(c *gin.Context) {
+	var req model.SSHHostPrincipal
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.ID = 0
+	if err := op.CreateSSHHostPrincipal(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// UpdateSSHHostPrincipal 更新 SSH 主机 principal
+func UpdateSSHHostPrincipal(c *gin.Context) {
+	var req model.SSHHostPrincipal
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+
+	req.ID = uint(id)
+	if err := op.UpdateSSHHostPrincipal(&req); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, req)
+}
+
+// DeleteSSHHostPrincipal 删除 SSH 主机 principal
+func DeleteSSHHostPrincipal(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.Atoi(idParam)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := op.DeleteSSHHostPrincipal(uint(id)); err != nil {
+		certificateInputErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// ExportSSHKnownHosts 导出信任 SSH CA 的 known_hosts 行，可以通过 ids 参数(逗号分隔)只导出部分主机 principal
+func ExportSSHKnownHosts(c *gin.Context) {
+	var ids []uint
+	if idsParam := c.Query("ids"); idsParam != "" {
+		for _, s := range strings.Split(idsParam, ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				common.ErrorResp(c, err, 400)
+				return
+			}
+			ids = append(ids, uint(id))
+		}
+	}
+	line, err := op.GetSSHKnownHosts(ids)
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="known_hosts"`)
+	c.String(200, line)
+}
+
+// SSHCertificateList 获取包含指定 principal 的有效 SSH 证书
+func SSHCertificateList(c *gin.Context) {
+	certs, err := op.GetSSHCertificatesByPrincipal(c.Query("principal"))
+	if err != nil {
+		certificateErrorResp(c, err)
+		return
+	}
+	common.SuccessResp(c, certs)
+}
@@ -0,0 +1,34 @@
+package handles
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// timestampQueryMaxBody TimeStampReq 只包含摘要，正常请求远小于该长度
+const timestampQueryMaxBody = 16 << 10
+
+// Timestamp RFC 3161 时间戳服务，请求体为 application/timestamp-query，
+// 响应为 application/timestamp-reply，可直接用于代码签名和文档签名工具
+func Timestamp(c *gin.Context) {
+	if ct := c.ContentType(); ct != "application/timestamp-query" {
+		common.ErrorStrResp(c, "content type must be application/timestamp-query", 415)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, timestampQueryMaxBody))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	resp, err := op.Timestamp(body, c.ClientIP())
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Data(http.StatusOK, "application/timestamp-reply", resp)
+}
@@ -0,0 +1,103 @@
+package handles
+
+import (
+	"bytes"
+	"encoding/pem"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+)
+
+// wellKnownCAContentTypes 按扩展名返回的 CA 证书格式，DER 使用 RFC 2585 定义的类型
+var wellKnownCAContentTypes = map[string]string{
+	".pem": "application/x-pem-file",
+	".crt": "application/pkix-cert",
+	".cer": "application/pkix-cert",
+	".der": "application/pkix-cert",
+}
+
+// WellKnownCABundle 在 /.well-known/pki/ca-bundle.pem 分发信任包，
+// 内容变化时 ETag 随之变化，客户端可以通过 If-None-Match 条件请求定期更新
+func WellKnownCABundle(c *gin.Context) {
+	bundle, err := op.GetCABundle()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.Header("Content-Type", "application/pem-certificate-chain")
+	c.Header("ETag", bundle.ETag)
+	c.Header("Cache-Control", "public, max-age=3600, must-revalidate")
+	// 信任包没有可靠的修改时间，只依赖 ETag 做条件请求
+	http.ServeContent(c.Writer, c.Request, "ca-bundle.pem", time.Time{}, strings.NewReader(op.CABundlePEM(bundle)))
+}
+
+// WellKnownCACertificate 在 /.well-known/pki/ca/<sha256 指纹>.<pem|crt|cer|der> 分发单个 CA 证书。
+// 同一地址的内容永远不变，因此允许长期缓存
+func WellKnownCACertificate(c *gin.Context) {
+	file := c.Param("file")
+	ext := path.Ext(file)
+	contentType, ok := wellKnownCAContentTypes[ext]
+	if !ok {
+		common.ErrorStrResp(c, "unsupported format", 404)
+		return
+	}
+	ca, err := op.GetPublishedCertificateAuthority(strings.TrimSuffix(file, ext))
+	if err != nil {
+		common.ErrorStrResp(c, "certificate authority not found", 404)
+		return
+	}
+	data := []byte(ca.Content)
+	if ext != ".pem" {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			common.ErrorStrResp(c, "invalid certificate authority content", 500)
+			return
+		}
+		data = block.Bytes
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("ETag", `"`+ca.Fingerprint+`"`)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(c.Writer, c.Request, file, ca.CreatedAt, bytes.NewReader(data))
+}
+
+// WellKnownCAIndex 列出当前分发的 CA 证书及其地址，便于部署工具发现信任锚
+func WellKnownCAIndex(c *gin.Context) {
+	bundle, err := op.GetCABundle()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	type entry struct {
+		Name        string    `json:"name"`
+		Kind        string    `json:"kind"`
+		Subject     string    `json:"subject"`
+		Fingerprint string    `json:"fingerprint"`
+		NotAfter    time.Time `json:"not_after"`
+		URL         string    `json:"url"`
+	}
+	entries := make([]entry, 0, len(bundle.Certificates))
+	for _, ca := range bundle.Certificates {
+		entries = append(entries, entry{
+			Name:        ca.Name,
+			Kind:        ca.Kind,
+			Subject:     ca.Subject,
+			Fingerprint: ca.Fingerprint,
+			NotAfter:    ca.NotAfter,
+			URL:         "/.well-known/pki/ca/" + ca.Fingerprint + ".crt",
+		})
+	}
+	c.Header("ETag", bundle.ETag)
+	c.Header("Cache-Control", "public, max-age=3600, must-revalidate")
+	if c.GetHeader("If-None-Match") == bundle.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"version": bundle.Version, "bundle": "/.well-known/pki/ca-bundle.pem", "certificates": entries})
+}
@@ -0,0 +1,27 @@
+package middlewares
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion records the api version of the route group in the request context
+func APIVersion(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.GinWithValue(c, conf.APIVersionKey, version)
+		c.Next()
+	}
+}
+
+// Deprecated marks a legacy route as deprecated (RFC 9745) and links to its /api/v1 successor
+func Deprecated(c *gin.Context) {
+	c.Header("Deprecation", "true")
+	if successor := strings.Replace(c.Request.URL.Path, "/api/", "/api/v1/", 1); successor != c.Request.URL.Path {
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+	}
+	c.Next()
+}
@@ -2,7 +2,9 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"net/http"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/audit"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
@@ -12,6 +14,18 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// auditDenied records a rejected request in the audit log
+func auditDenied(c *gin.Context, event, username, detail string) {
+	audit.Emit(&audit.Event{
+		Type:    event,
+		Actor:   username,
+		Target:  c.Request.Method + " " + c.Request.URL.Path,
+		Outcome: audit.OutcomeFailure,
+		IP:      c.ClientIP(),
+		Detail:  detail,
+	})
+}
+
 // Auth is a middleware that checks if the user is logged in.
 // if token is empty, set user to guest
 func Auth(allowDisabledGuest bool) func(c *gin.Context) {
@@ -48,6 +62,7 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 		}
 		userClaims, err := common.ParseToken(token)
 		if err != nil {
+			auditDenied(c, "auth.token_rejected", "", err.Error())
 			common.ErrorResp(c, err, 401)
 			c.Abort()
 			return
@@ -142,9 +157,33 @@ func AuthNotGuest(c *gin.Context) {
 func AuthAdmin(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.IsAdmin() {
+		auditDenied(c, "auth.permission_denied", user.Username, "")
 		common.ErrorStrResp(c, "You are not an admin", 403)
 		c.Abort()
 	} else {
 		c.Next()
 	}
 }
+
+// AuthAuditor allows admins, and auditors for read-only requests
+func AuthAuditor(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.IsAdmin() {
+		c.Next()
+		return
+	}
+	if !user.IsAuditor() {
+		auditDenied(c, "auth.permission_denied", user.Username, "")
+		common.ErrorStrResp(c, "You are not an admin or auditor", 403)
+		c.Abort()
+		return
+	}
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		c.Next()
+	default:
+		auditDenied(c, "auth.permission_denied", user.Username, "auditors have read-only access")
+		common.ErrorStrResp(c, "Auditors have read-only access", 403)
+		c.Abort()
+	}
+}
@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const originalBodyKey = "original_body"
+
+// BodyLimit rejects request bodies larger than n bytes. A BodyLimit on a route replaces
+// the one of its group, so a group can set a small default that single routes raise.
+func BodyLimit(n int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body := c.Request.Body
+		if original, ok := c.Get(originalBodyKey); ok {
+			body = original.(io.ReadCloser)
+		} else {
+			c.Set(originalBodyKey, body)
+		}
+		if c.Request.ContentLength > n {
+			common.ErrorStrResp(c, fmt.Sprintf("request body exceeds %d bytes", n), http.StatusRequestEntityTooLarge)
+			c.Abort()
+			return
+		}
+		// bodies without a content length fail to read once they exceed the limit
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, n)
+		c.Next()
+	}
+}
+
+// CertificateBodyLimit is the default limit of the certificate JSON endpoints
+func CertificateBodyLimit() gin.HandlerFunc {
+	return BodyLimit(int64(conf.Conf.Certificate.MaxBodyKB) << 10)
+}
+
+// CertificateUploadLimit is the limit of certificate imports and file uploads
+func CertificateUploadLimit() gin.HandlerFunc {
+	return BodyLimit(int64(conf.Conf.Certificate.MaxUploadMB) << 20)
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonateHeader names the tenant whose view support staff want to see.
+const ImpersonateHeader = "X-OpenList-View-As"
+
+// ImpersonateTenant lets users allowed to impersonate see the tenant endpoints exactly as the
+// tenant named in ImpersonateHeader would. The session is read-only: only GET and HEAD
+// requests pass, downloads are refused as they are recorded in the tenant's name, and every
+// request is written to the audit log either way. Certificate content is redacted unless the
+// operator may read it.
+func ImpersonateTenant(c *gin.Context) {
+	username := strings.TrimSpace(c.GetHeader(ImpersonateHeader))
+	if username == "" {
+		c.Next()
+		return
+	}
+	operator := c.Request.Context().Value(conf.UserKey).(*model.User)
+	target, err := op.GetImpersonatedTenant(operator, username)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		c.Abort()
+		return
+	}
+	readOnly := c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead
+	if !readOnly || strings.HasSuffix(c.FullPath(), "/download") {
+		op.AuditImpersonation(operator, target, c.Request.Method, c.Request.URL.Path, c.ClientIP(), true)
+		common.ErrorStrResp(c, "actions are not allowed while viewing as another user", 403)
+		c.Abort()
+		return
+	}
+	op.AuditImpersonation(operator, target, c.Request.Method, c.Request.URL.Path, c.ClientIP(), false)
+	common.GinWithValue(c, conf.UserKey, target)
+	common.GinWithValue(c, conf.ImpersonatorKey, operator)
+	c.Header("X-OpenList-Viewing-As", target.Username)
+	if !operator.CanReadCertificateContent() {
+		redactResponse(c)
+		return
+	}
+	c.Next()
+}
@@ -0,0 +1,117 @@
+package middlewares
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// redactedFields are removed from json responses sent to users who can not read certificate content
+var redactedFields = []string{"content", "private_key"}
+
+// streamedRoutes write their response while reading the database and redact certificate content
+// themselves, buffering them here would hold the whole response in memory
+var streamedRoutes = []string{"/export"}
+
+// RedactCertificateContent strips certificate content and private material from the responses of
+// users without the permission to read certificate content, and refuses certificate downloads.
+// Listing and array fields named content, such as the page content, are kept.
+func RedactCertificateContent(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if user.CanReadCertificateContent() {
+		c.Next()
+		return
+	}
+	if strings.HasSuffix(c.FullPath(), "/download/:id") {
+		auditDenied(c, "auth.permission_denied", user.Username, "certificate content is not readable")
+		common.ErrorStrResp(c, "You are not allowed to read certificate content", 403)
+		c.Abort()
+		return
+	}
+	for _, route := range streamedRoutes {
+		if strings.HasSuffix(c.FullPath(), route) {
+			c.Next()
+			return
+		}
+	}
+	redactResponse(c)
+}
+
+// redactResponse buffers json responses and writes them with the redacted fields cleared,
+// other responses pass through unchanged
+func redactResponse(c *gin.Context) {
+	w := &redactWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+	c.Next()
+	c.Writer = w.ResponseWriter
+	if !w.buffering {
+		return
+	}
+	body := w.buf.Bytes()
+	var data any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err == nil && redactJSON(data) {
+		if redacted, err := json.Marshal(data); err == nil {
+			body = redacted
+			c.Header("X-OpenList-Redacted", strings.Join(redactedFields, ","))
+		}
+	}
+	_, _ = w.ResponseWriter.Write(body)
+}
+
+type redactWriter struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	buffering bool
+	decided   bool
+}
+
+func (w *redactWriter) Write(p []byte) (int, error) {
+	if !w.decided {
+		w.decided = true
+		w.buffering = strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+	}
+	if w.buffering {
+		return w.buf.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *redactWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// redactJSON clears the string values of redacted fields in place and reports whether any was found
+func redactJSON(v any) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]any:
+		for k, value := range v {
+			if s, ok := value.(string); ok {
+				for _, f := range redactedFields {
+					if k == f && s != "" {
+						v[k] = ""
+						found = true
+					}
+				}
+				continue
+			}
+			if redactJSON(value) {
+				found = true
+			}
+		}
+	case []any:
+		for _, value := range v {
+			if redactJSON(value) {
+				found = true
+			}
+		}
+	}
+	return found
+}
@@ -0,0 +1,98 @@
+package middlewares
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/generic_sync"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+)
+
+type userThrottle struct {
+	sem        chan struct{}
+	limiter    *rate.Limiter
+	retryAfter int
+}
+
+type throttleLimits struct {
+	concurrency int
+	perMinute   int
+}
+
+var (
+	userThrottles generic_sync.MapOf[uint, *userThrottle]
+	// appliedLimits holds the throttleLimits the current throttles were built with
+	appliedLimits atomic.Value
+)
+
+func init() {
+	// rebuild throttles with the new limits next time they are used. Other settings changing
+	// must not reset them, that would refill every bucket and drop the in-flight counts
+	op.RegisterSettingChangingCallback(func() {
+		limits := currentThrottleLimits()
+		if old := appliedLimits.Swap(limits); old == limits {
+			return
+		}
+		userThrottles.Clear()
+	})
+}
+
+func currentThrottleLimits() throttleLimits {
+	return throttleLimits{
+		concurrency: setting.GetInt(conf.UserMaxConcurrency, -1),
+		perMinute:   setting.GetInt(conf.UserRateLimit, -1),
+	}
+}
+
+func newUserThrottle() *userThrottle {
+	limits := currentThrottleLimits()
+	appliedLimits.CompareAndSwap(nil, limits)
+	t := &userThrottle{}
+	if n := limits.concurrency; n > 0 {
+		t.sem = make(chan struct{}, n)
+	}
+	if perMinute := limits.perMinute; perMinute > 0 {
+		t.limiter = rate.NewLimiter(rate.Limit(float64(perMinute)/60), perMinute)
+		t.retryAfter = int(math.Ceil(60 / float64(perMinute)))
+	}
+	return t
+}
+
+// UserThrottle limits the concurrency and request rate of every user on expensive endpoints,
+// so that one user's automation can't monopolize the instance.
+// It is independent of the global MaxAllowed and traffic limits.
+func UserThrottle(c *gin.Context) {
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok {
+		c.Next()
+		return
+	}
+	t, ok := userThrottles.Load(user.ID)
+	if !ok {
+		t, _ = userThrottles.LoadOrStore(user.ID, newUserThrottle())
+	}
+	if t.limiter != nil && !t.limiter.Allow() {
+		c.Header("Retry-After", strconv.Itoa(t.retryAfter))
+		common.ErrorStrResp(c, "too many requests, slow down please", 429)
+		c.Abort()
+		return
+	}
+	if t.sem != nil {
+		select {
+		case t.sem <- struct{}{}:
+			defer func() { <-t.sem }()
+		default:
+			common.ErrorStrResp(c, "too many concurrent requests", 429)
+			c.Abort()
+			return
+		}
+	}
+	c.Next()
+}
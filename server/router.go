@@ -34,6 +34,16 @@ func Init(e *gin.Engine) {
 	g.GET("/robots.txt", handles.Robots)
 	g.GET("/manifest.json", static.ManifestJSON)
 	g.GET("/i/:link_name", handles.Plist)
+	// CA 证书固定发布在站点根路径的 /.well-known 下，不受站点子路径影响
+	wellKnown := e.Group("/.well-known/pki")
+	wellKnown.GET("/ca-bundle.pem", handles.WellKnownCABundle)
+	wellKnown.HEAD("/ca-bundle.pem", handles.WellKnownCABundle)
+	wellKnown.GET("/ca.json", handles.WellKnownCAIndex)
+	wellKnown.GET("/ca/:file", handles.WellKnownCACertificate)
+	wellKnown.HEAD("/ca/:file", handles.WellKnownCACertificate)
+	e.GET("/.well-known/jwks.json", handles.WellKnownJWKS)
+	// ACME 的 HTTP-01 验证固定访问域名根路径
+	e.GET("/.well-known/acme-challenge/:token", handles.ACMEChallenge)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
 	g.Use(middlewares.StoragesLoaded)
 	if conf.Conf.MaxConnections > 0 {
@@ -77,6 +87,13 @@ func Init(e *gin.Engine) {
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
 	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
 	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
+	auth.GET("/me/device/list", handles.ListMyDevices)
+	auth.POST("/me/device/register", handles.RegisterMyDevice)
+	auth.POST("/me/device/delete", handles.DeleteMyDevice)
+	auth.GET("/me/certificate/digest", middlewares.AuthNotGuest, handles.GetMyCertificateDigest)
+	auth.POST("/me/certificate/digest", middlewares.AuthNotGuest, handles.UpdateMyCertificateDigest)
+	auth.GET("/me/certificate/feed_token", middlewares.AuthNotGuest, handles.GetMyCertificateFeedToken)
+	auth.POST("/me/certificate/feed_token/reset", middlewares.AuthNotGuest, handles.ResetMyCertificateFeedToken)
 	auth.POST("/auth/2fa/generate", handles.Generate2FA)
 	auth.POST("/auth/2fa/verify", handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
@@ -100,22 +117,89 @@ func Init(e *gin.Engine) {
 	public.Any("/settings", handles.PublicSettings)
 	public.Any("/offline_download_tools", handles.OfflineDownloadTools)
 	public.Any("/archive_extensions", handles.ArchiveExtensions)
+	public.GET("/certificate/approval", handles.CertificateApprovalLinkPage)
+	public.POST("/certificate/approval", handles.ConfirmCertificateApprovalLink)
+	public.POST("/slack/interaction", handles.SlackInteraction)
+	public.GET("/certificate/calendar.ics", handles.CertificateCalendarFeed)
+	public.GET("/certificate/feed", handles.CertificateEventFeed)
+	public.GET("/certificate/status", handles.CAStatus)
+	public.GET("/ca-bundle", handles.CABundle)
+	public.GET("/certificate/trust/:organization", handles.CertificateTrustStoreBundle)
+	public.GET("/certificate/crl", handles.CertificateCRL)
+	public.POST("/certificate/ocsp", handles.CertificateOCSP)
+	public.GET("/certificate/ocsp/*request", handles.CertificateOCSP)
+	public.POST("/timestamp", handles.Timestamp)
+	public.POST("/certificate/revocation_notice", handles.CertificateRevocationNotice)
+	public.POST("/certificate/email", middlewares.CertificateUploadLimit(), handles.CertificateEmailIntake)
+	public.POST("/certificate/agent/report", handles.CertificateAgentReport)
+	public.GET("/certificate/agent/poll", handles.CertificateAgentPoll)
+	public.POST("/certificate/agent/ack", handles.CertificateAgentAck)
 
 	_fs(auth.Group("/fs"))
 	fsAndShare(api.Group("/fs", middlewares.Auth(true)))
 	_task(auth.Group("/task", middlewares.AuthNotGuest))
 	_sharing(auth.Group("/share", middlewares.AuthNotGuest))
 	
-	// 租户证书路由应该在auth路由组下，确保认证中间件被正确应用
-	tenant := auth.Group("/tenant")
+	// 租户证书路由应该在auth路由组下，确保认证中间件被正确应用。
+	// 未带版本的证书接口保留给已有的自动化脚本，响应头中标记为弃用并指向 /api/v1
+	_certificateTenant(auth.Group("/tenant", middlewares.ImpersonateTenant, middlewares.CertificateBodyLimit(), middlewares.Deprecated))
+
+	// 审批代理人代为处理证书申请
+	delegate := auth.Group("/certificate/delegate", middlewares.AuthNotGuest)
 	{
-		tenant.POST("/certificate/request", handles.CreateTenantCertificateRequest)
-		tenant.GET("/certificate", handles.GetTenantCertificate)
-		tenant.GET("/certificate/requests", handles.GetTenantCertificateRequests)
-		tenant.GET("/certificate/download", handles.DownloadCertificate)
+		delegate.GET("/requests", handles.DelegatedCertificateRequestList)
+		delegate.POST("/approve/:id", handles.ApproveCertificateRequestOnBehalf)
+		delegate.POST("/reject/:id", handles.RejectCertificateRequestOnBehalf)
+		delegate.POST("/claim/:id", handles.ClaimCertificateRequest)
+		delegate.GET("/preview/:id", handles.PreviewCertificateRequest)
+		delegate.GET("/checklist", handles.GetCertificateApprovalChecklist)
 	}
 
-	admin(auth.Group("/admin", middlewares.AuthAdmin))
+	// 证书签发的变更冻结窗口，管理员和组织管理员维护
+	freeze := auth.Group("/certificate/freeze", middlewares.AuthNotGuest)
+	{
+		freeze.GET("/list", handles.CertificateFreezeWindowList)
+		freeze.POST("/create", handles.CreateCertificateFreezeWindow)
+		freeze.PUT("/update/:id", handles.UpdateCertificateFreezeWindow)
+		freeze.DELETE("/delete/:id", handles.DeleteCertificateFreezeWindow)
+	}
+
+	// 组织信任库，管理员和组织管理员维护
+	trust := auth.Group("/certificate/trust", middlewares.AuthNotGuest)
+	{
+		trust.GET("/list", handles.CertificateTrustAnchorList)
+		trust.POST("/add", handles.AddCertificateTrustAnchors)
+		trust.DELETE("/delete/:id", handles.DeleteCertificateTrustAnchor)
+	}
+
+	// 关注证书或申请的生命周期事件
+	watch := auth.Group("/certificate/watch", middlewares.AuthNotGuest)
+	{
+		watch.GET("/list", handles.ListCertificateWatches)
+		watch.POST("/add", handles.WatchCertificate)
+		watch.POST("/remove", handles.UnwatchCertificate)
+	}
+
+	// 证书申请详情与评论
+	certRequest := auth.Group("/certificate/request", middlewares.AuthNotGuest)
+	{
+		certRequest.GET("/:id", handles.GetCertificateRequestDetail)
+		certRequest.GET("/:id/comments", handles.ListCertificateRequestComments)
+		certRequest.POST("/:id/comments", handles.AddCertificateRequestComment)
+		certRequest.POST("/:id/attachments", middlewares.CertificateUploadLimit(), handles.UploadCertificateRequestAttachment)
+		certRequest.PUT("/:id/ticket", handles.LinkCertificateRequestTicket)
+	}
+
+	// 带版本的证书接口
+	v1 := api.Group("/v1", middlewares.APIVersion(common.APIVersion1), middlewares.Auth(false))
+	_certificateTenant(v1.Group("/tenant", middlewares.ImpersonateTenant, middlewares.CertificateBodyLimit()))
+
+	// admin routes are served by the dedicated admin listener when it is enabled
+	if !conf.Conf.Admin.Enable {
+		admin(auth.Group("/admin", middlewares.AuthAdmin))
+		_certificateAdmin(auth.Group("/admin/certificate", middlewares.AuthAuditor, middlewares.RedactCertificateContent, middlewares.Deprecated))
+		_certificateAdmin(v1.Group("/admin/certificate", middlewares.AuthAuditor, middlewares.RedactCertificateContent))
+	}
 	if flags.Debug || flags.Dev {
 		debug(g.Group("/debug"))
 	}
@@ -175,21 +259,6 @@ func admin(g *gin.RouterGroup) {
 	setting.POST("/set_thunderx", handles.SetThunderX)
 	setting.POST("/set_thunder_browser", handles.SetThunderBrowser)
 
-	// **问题修复点**: 将证书管理路由整合到 admin 路由组下
-	certificate := g.Group("/certificate")
-	{
-		certificate.GET("/list", handles.CertificateList)
-		certificate.POST("/create", handles.CreateCertificate)
-		certificate.PUT("/update/:id", handles.UpdateCertificate)
-		certificate.DELETE("/delete/:id", handles.DeleteCertificate)
-		certificate.POST("/revoke/:id", handles.RevokeCertificate)
-		certificate.GET("/requests", handles.CertificateRequestList)
-		certificate.POST("/request/create", handles.CreateCertificateRequest)
-		certificate.POST("/request/approve/:id", handles.ApproveCertificateRequest)
-		certificate.POST("/request/reject/:id", handles.RejectCertificateRequest)
-		certificate.GET("/download/:id", handles.DownloadCertificate)
-	}
-
 	// retain /admin/task API to ensure compatibility with legacy automation scripts
 	_task(g.Group("/task"))
 
@@ -205,6 +274,147 @@ func admin(g *gin.RouterGroup) {
 	index.GET("/progress", middlewares.SearchIndex, handles.GetProgress)
 }
 
+// _certificateTenant 租户证书路由
+func _certificateTenant(g *gin.RouterGroup) {
+	g.POST("/certificate/request", middlewares.UserThrottle, handles.CreateTenantCertificateRequest)
+	g.GET("/certificate/resource/:external_id", handles.GetCertificateResource)
+	g.PUT("/certificate/resource/:external_id", middlewares.UserThrottle, handles.ApplyCertificateResource)
+	g.GET("/certificate", handles.GetTenantCertificate)
+	g.GET("/certificate/requests", handles.GetTenantCertificateRequests)
+	g.GET("/certificate/download", handles.DownloadCertificate)
+	g.GET("/certificate/chains", handles.CertificateChainOptionList)
+	g.GET("/certificate/timeline/:id", handles.GetCertificateTimeline)
+	g.GET("/certificate/fields", handles.GetTenantCertificateRequestFields)
+	g.GET("/certificate/types", handles.GetTenantCertificateTypes)
+	g.GET("/certificate/profiles", handles.CertificateProfileList)
+	g.GET("/certificate/quota", handles.GetTenantCertificateQuota)
+	g.PUT("/certificate/draft/:id", handles.UpdateCertificateRequestDraft)
+	g.POST("/certificate/draft/:id/submit", middlewares.UserThrottle, handles.SubmitCertificateRequestDraft)
+	g.DELETE("/certificate/draft/:id", handles.DeleteCertificateRequestDraft)
+	g.GET("/certificate/templates", handles.CertificateRequestTemplateList)
+	g.POST("/certificate/template/create", handles.CreateCertificateRequestTemplate)
+	g.PUT("/certificate/template/update/:id", handles.UpdateCertificateRequestTemplate)
+	g.DELETE("/certificate/template/delete/:id", handles.DeleteCertificateRequestTemplate)
+	g.POST("/certificate/template/:id/request", middlewares.UserThrottle, handles.CreateCertificateRequestFromTemplate)
+	g.POST("/certificate/preview", handles.PreviewTenantCertificate)
+	g.POST("/certificate/chain", handles.BuildCertificateChain)
+	g.POST("/certificate/sign", middlewares.UserThrottle, middlewares.CertificateUploadLimit(), handles.SignWithCertificate)
+	g.POST("/certificate/sign/file", middlewares.UserThrottle, middlewares.CertificateUploadLimit(), handles.SignFileWithCertificate)
+	g.GET("/certificate/signatures", handles.CertificateSignatureList)
+	g.POST("/certificate/renew", middlewares.UserThrottle, handles.RenewTenantCertificate)
+	g.POST("/certificate/auto_renew", handles.SetTenantCertificateAutoRenew)
+}
+
+// _certificateAdmin 证书管理路由，审计员只能访问其中的只读接口
+func _certificateAdmin(g *gin.RouterGroup) {
+	g.Use(middlewares.CertificateBodyLimit())
+	g.GET("/list", handles.CertificateList)
+	g.GET("/expiry/summary", handles.CertificateExpirySummary)
+	g.GET("/audit", handles.CertificateAuditLogList)
+	g.GET("/webhook/events", handles.CertificateWebhookEvents)
+	g.POST("/webhook/test", handles.FireCertificateWebhook)
+	g.GET("/webhook/endpoint/list", handles.CertificateWebhookList)
+	g.POST("/webhook/endpoint/create", handles.CreateCertificateWebhook)
+	g.PUT("/webhook/endpoint/update/:id", handles.UpdateCertificateWebhook)
+	g.DELETE("/webhook/endpoint/delete/:id", handles.DeleteCertificateWebhook)
+	g.GET("/webhook/delivery/list", handles.CertificateWebhookDeliveryList)
+	g.POST("/webhook/delivery/redeliver/:id", handles.RedeliverCertificateWebhook)
+	g.POST("/demo/seed", handles.SeedCertificateDemoData)
+	g.POST("/demo/wipe", handles.WipeCertificateDemoData)
+	g.GET("/export", handles.ExportCertificates)
+	g.POST("/import", middlewares.CertificateUploadLimit(), handles.ImportCertificates)
+	g.POST("/create", handles.CreateCertificate)
+	g.PUT("/update/:id", handles.UpdateCertificate)
+	g.DELETE("/delete/:id", handles.DeleteCertificate)
+	g.POST("/revoke/:id", handles.RevokeCertificate)
+	g.POST("/hold/:id", handles.HoldCertificate)
+	g.POST("/unhold/:id", handles.UnholdCertificate)
+	g.POST("/:id/renew", handles.RenewCertificate)
+	g.POST("/:id/activate_tls", handles.ActivateTLSCertificate)
+	g.GET("/legal_hold/list", handles.LegalHoldList)
+	g.POST("/legal_hold/place", handles.PlaceLegalHold)
+	g.POST("/legal_hold/release/:id", handles.ReleaseLegalHold)
+	g.GET("/quota/list", handles.CertificateTenantQuotaList)
+	g.POST("/quota/set", handles.SetCertificateTenantQuota)
+	g.GET("/reconcile", handles.GetCertificateReconciliation)
+	g.POST("/reconcile/fix", handles.RemediateCertificateAnomaly)
+	g.GET("/ca/list", handles.CertificateAuthorityList)
+	g.POST("/ca/add", handles.AddCertificateAuthority)
+	g.POST("/ca/retire/:id", handles.RetireCertificateAuthority)
+	g.POST("/ca/key/:id", handles.SetCertificateAuthorityKey)
+	g.POST("/ca/create", handles.CreateCertificateAuthority)
+	g.POST("/ca/cross_sign", handles.CrossSignCertificateAuthority)
+	g.POST("/ca/cross_sign/import", handles.ImportCrossSignedCertificateAuthority)
+	g.GET("/ca/active", handles.GetActiveCertificateAuthority)
+	g.POST("/ca/rotate", handles.RotateCertificateAuthority)
+	g.GET("/ca/export/:id", handles.ExportCertificateAuthority)
+	g.GET("/revocation/publish", handles.GetRevocationPublishStatus)
+	g.POST("/revocation/publish", handles.PublishRevocationData)
+	g.GET("/ca/rotation/list", handles.CARotationList)
+	g.GET("/ca/rotation/:id", handles.GetCARotation)
+	g.POST("/ca/rotation/start", handles.StartCARotation)
+	g.POST("/ca/rotation/cancel/:id", handles.CancelCARotation)
+	g.GET("/transfer/list", handles.OwnershipTransferList)
+	g.GET("/transfer/:id", handles.GetOwnershipTransfer)
+	g.POST("/transfer/start", handles.StartOwnershipTransfer)
+	g.GET("/scheduler/leases", handles.SchedulerLeaseList)
+	g.GET("/agent/list", handles.CertificateAgentList)
+	g.POST("/agent/create", handles.CreateCertificateAgent)
+	g.DELETE("/agent/delete/:id", handles.DeleteCertificateAgent)
+	g.GET("/requests", handles.CertificateRequestList)
+	g.POST("/request/create", handles.CreateCertificateRequest)
+	g.GET("/request/preview/:id", handles.PreviewCertificateRequest)
+	g.GET("/request/checklist", handles.GetCertificateApprovalChecklist)
+	g.POST("/request/approve/:id", handles.ApproveCertificateRequest)
+	g.POST("/request/reject/:id", handles.RejectCertificateRequest)
+	g.POST("/request/batch_approve", handles.BatchApproveCertificateRequests)
+	g.POST("/request/batch_reject", handles.BatchRejectCertificateRequests)
+	g.POST("/request/claim/:id", handles.ClaimCertificateRequest)
+	g.POST("/request/assign/:id", handles.AssignCertificateRequest)
+	g.GET("/request/notes/:id", handles.ListCertificateRequestNotes)
+	g.POST("/request/notes/:id", handles.AddCertificateRequestNote)
+	g.GET("/download/:id", handles.DownloadCertificate)
+	g.GET("/chains/:id", handles.CertificateChainOptionList)
+	g.GET("/downloads/:id", handles.CertificateDownloadList)
+	g.GET("/signatures/:id", handles.CertificateSignatureList)
+	g.POST("/key/:id", handles.SetCertificatePrivateKey)
+	g.POST("/jwt/sign", handles.SignJWT)
+	g.GET("/unused", handles.UnusedCertificateList)
+	g.GET("/honeytoken/list", handles.CertificateHoneytokenList)
+	g.POST("/honeytoken/mark/:id", handles.MarkCertificateHoneytoken)
+	g.POST("/honeytoken/unmark/:id", handles.UnmarkCertificateHoneytoken)
+	g.GET("/timeline/:id", handles.GetCertificateTimeline)
+	g.GET("/delegation/list", handles.ApprovalDelegationList)
+	g.POST("/delegation/create", handles.CreateApprovalDelegation)
+	g.DELETE("/delegation/delete/:id", handles.DeleteApprovalDelegation)
+	g.GET("/field/list", handles.CertificateRequestFieldList)
+	g.POST("/field/create", handles.CreateCertificateRequestField)
+	g.PUT("/field/update/:id", handles.UpdateCertificateRequestField)
+	g.DELETE("/field/delete/:id", handles.DeleteCertificateRequestField)
+	g.GET("/type/list", handles.CertificateTypeList)
+	g.POST("/type/create", handles.CreateCertificateType)
+	g.PUT("/type/update/:id", handles.UpdateCertificateType)
+	g.DELETE("/type/delete/:id", handles.DeleteCertificateType)
+	g.GET("/profile/list", handles.CertificateProfileList)
+	g.POST("/profile/create", handles.CreateCertificateProfile)
+	g.PUT("/profile/update/:id", handles.UpdateCertificateProfile)
+	g.DELETE("/profile/delete/:id", handles.DeleteCertificateProfile)
+	g.GET("/policy/export", handles.ExportPolicyBundle)
+	g.POST("/policy/import", middlewares.CertificateUploadLimit(), handles.ImportPolicyBundle)
+	g.GET("/ssh/principal/list", handles.SSHHostPrincipalList)
+	g.POST("/ssh/principal/create", handles.CreateSSHHostPrincipal)
+	g.PUT("/ssh/principal/update/:id", handles.UpdateSSHHostPrincipal)
+	g.DELETE("/ssh/principal/delete/:id", handles.DeleteSSHHostPrincipal)
+	g.GET("/ssh/known_hosts", handles.ExportSSHKnownHosts)
+	g.GET("/ssh/certificates", handles.SSHCertificateList)
+	g.GET("/maintenance/list", handles.CAMaintenanceWindowList)
+	g.POST("/maintenance/create", handles.CreateCAMaintenanceWindow)
+	g.PUT("/maintenance/update/:id", handles.UpdateCAMaintenanceWindow)
+	g.DELETE("/maintenance/delete/:id", handles.DeleteCAMaintenanceWindow)
+	g.POST("/state/export", handles.ExportCAState)
+	g.POST("/state/import", handles.ImportCAState)
+}
+
 func fsAndShare(g *gin.RouterGroup) {
 	g.Any("/list", handles.FsListSplit)
 	g.Any("/get", handles.FsGetSplit)
@@ -214,18 +424,18 @@ func fsAndShare(g *gin.RouterGroup) {
 }
 
 func _fs(g *gin.RouterGroup) {
-	g.Any("/search", middlewares.SearchIndex, handles.Search)
+	g.Any("/search", middlewares.SearchIndex, middlewares.UserThrottle, handles.Search)
 	g.Any("/other", handles.FsOther)
 	g.Any("/dirs", handles.FsDirs)
 	g.POST("/mkdir", handles.FsMkdir)
 	g.POST("/rename", handles.FsRename)
-	g.POST("/batch_rename", handles.FsBatchRename)
-	g.POST("/regex_rename", handles.FsRegexRename)
+	g.POST("/batch_rename", middlewares.UserThrottle, handles.FsBatchRename)
+	g.POST("/regex_rename", middlewares.UserThrottle, handles.FsRegexRename)
 	g.POST("/move", handles.FsMove)
-	g.POST("/recursive_move", handles.FsRecursiveMove)
+	g.POST("/recursive_move", middlewares.UserThrottle, handles.FsRecursiveMove)
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
-	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.POST("/remove_empty_directory", middlewares.UserThrottle, handles.FsRemoveEmptyDirectory)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -260,6 +470,26 @@ func Cors(r *gin.Engine) {
 	r.Use(cors.New(config))
 }
 
+// InitAdmin mounts the admin api on a separate engine, used by the dedicated admin listener
+func InitAdmin(e *gin.Engine) {
+	e.ContextWithFallback = true
+	Cors(e)
+	g := e.Group(conf.URL.Path)
+	g.Any("/ping", func(c *gin.Context) {
+		c.String(200, "pong")
+	})
+	g.Use(middlewares.StoragesLoaded)
+	api := g.Group("/api")
+	api.POST("/auth/login", handles.Login)
+	api.POST("/auth/login/hash", handles.LoginHash)
+	auth := api.Group("", middlewares.Auth(false))
+	auth.GET("/me", handles.CurrentUser)
+	admin(auth.Group("/admin", middlewares.AuthAdmin))
+	_certificateAdmin(auth.Group("/admin/certificate", middlewares.AuthAuditor, middlewares.RedactCertificateContent, middlewares.Deprecated))
+	v1 := api.Group("/v1", middlewares.APIVersion(common.APIVersion1), middlewares.Auth(false))
+	_certificateAdmin(v1.Group("/admin/certificate", middlewares.AuthAuditor, middlewares.RedactCertificateContent))
+}
+
 func InitS3(e *gin.Engine) {
 	Cors(e)
 	S3Server(e.Group("/"))